@@ -0,0 +1,24 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersion is the group and version of the kuttl API.
+var GroupVersion = schema.GroupVersion{Group: "kuttl.dev", Version: "v1beta1"}
+
+var (
+	// SchemeBuilder registers the kuttl types which are served by a cluster, the types of the test files are
+	// decoded without a scheme.
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	// AddToScheme adds the kuttl types which are served by a cluster to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(GroupVersion, &TestRun{}, &TestRunList{})
+	metav1.AddToGroupVersion(scheme, GroupVersion)
+	return nil
+}
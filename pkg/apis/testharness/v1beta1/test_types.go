@@ -22,6 +22,52 @@ type TestFile struct {
 
 	// Which test runs should this file be used in. Empty selector matches all test runs.
 	TestRunSelector *metav1.LabelSelector `json:"testRunSelector,omitempty"`
+	// Step is the index of the step of a file whose name does not start with an index (ex. "install.yaml"), so that
+	// step files may have any name.  It is ignored in files named after their index.
+	Step *int `json:"step,omitempty"`
+	// Type of the objects of the file, "apply", "assert" or "errors".  It overrides the type derived from the file
+	// name (ex. "assert" for "00-assert.yaml").
+	Type string `json:"type,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TestCase lists the step files of the test case of the directory it is in explicitly, in a file whose name does not
+// start with an index (ex. "test.yaml").  The files of the directory named after an index are not loaded then.
+type TestCase struct {
+	// The type meta object, should always be a GVK of kuttl.dev/v1beta1/TestCase.
+	metav1.TypeMeta `json:",inline"`
+	// Set labels or the test suite name.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Steps of the test case in order, the index of a step is its position in the list.
+	Steps []TestCaseStep `json:"steps,omitempty"`
+}
+
+// TestCaseStep lists the files of a step of a TestCase.
+type TestCaseStep struct {
+	// Files of the step relative to the test case directory, the files of a directory are loaded in name order.  The
+	// type of the objects of a file is derived from its name or set by its TestFile, like in step files named after
+	// their index.
+	Files []string `json:"files"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TestMetadata describes the test case of the step file it is in (ex. 00-metadata.yaml), it is shown when the test
+// fails and added to the properties of the test in the reports.
+type TestMetadata struct {
+	// The type meta object, should always be a GVK of kuttl.dev/v1beta1/TestMetadata.
+	metav1.TypeMeta `json:",inline"`
+	// Set labels or the test suite name.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Owner is the team or person to contact when the test fails (ex. "storage-team").
+	Owner string `json:"owner,omitempty"`
+	// Issue is a link to the issue tracking the test (ex. a known flake).
+	Issue string `json:"issue,omitempty"`
+	// Docs is a link to the documentation of the test (ex. a runbook).
+	Docs string `json:"docs,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -39,6 +85,22 @@ type TestSuite struct {
 	ManifestDirs []string `json:"manifestDirs"`
 	// Directories containing test cases to run.
 	TestDirs []string `json:"testDirs"`
+	// HelmCharts generate a test case for each test values file of a Helm chart, which installs the chart with the
+	// values and asserts that its workloads roll out.  They require the helm binary.
+	HelmCharts []HelmChartTests `json:"helmCharts"`
+	// Patterns of the tests to run, all tests are run if not set.  Patterns are globs (ex. "upgrade-*") or regular
+	// expressions between slashes (ex. "/^upgrade-v[0-9]+$/") matching the names of the tests, patterns containing a
+	// slash match the test directory and name instead (ex. "test/e2e/upgrade-*").
+	Tests []string `json:"tests"`
+	// Patterns of the tests not to run, in the form of the patterns of Tests.
+	SkipTests []string `json:"skipTests"`
+	// If set, the steps of the tests with a lower index are not run, ex. to develop a step of a test in a namespace
+	// kept with skipDelete.
+	// +kubebuilder:validation:Format:=int64
+	FromStep int `json:"fromStep"`
+	// If set, only the steps and asserts of the stage are run (ex. "smoke" for quick validations of pull requests), the
+	// steps and asserts without stages run in every stage.
+	Stage string `json:"stage"`
 	// Whether or not to start a local etcd and kubernetes API server for the tests.
 	StartControlPlane bool `json:"startControlPlane"`
 	// ControlPlaneArgs defaults to APIServerDefaultArgs from controller-runtime pkg/internal/testing/integration/internal/apiserver.go
@@ -60,20 +122,85 @@ type TestSuite struct {
 	KINDNodeCache bool `json:"kindNodeCache"`
 	// Containers to load to each KIND node prior to running the tests.
 	KINDContainers []string `json:"kindContainers"`
+	// Images to build and load to each KIND node prior to running the tests, an image is only built again if its
+	// build context changed.
+	KINDBuilds []ImageBuild `json:"kindBuilds"`
+	// Well-known test fixtures to install into the kind cluster once it is started, before the manifests of
+	// manifestDirs (ex. the hostpath CSI driver for storage operators).  They can only be set with startKIND.
+	KINDFixtures []KINDFixture `json:"kindFixtures"`
+	// Kubernetes feature gates to enable or disable in the kind cluster, these override the feature gates of the
+	// kind configuration.
+	KINDFeatureGates map[string]bool `json:"kindFeatureGates"`
+	// Kubernetes API server runtime-config of the kind cluster (ex. "api/alpha": "true" to enable all alpha APIs),
+	// these override the runtime-config of the kind configuration.
+	KINDRuntimeConfig map[string]string `json:"kindRuntimeConfig"`
+	// The IP family of the kind cluster or mocked control plane started for the tests: ipv4 (the default), ipv6 or
+	// dual (dual-stack).  It overrides the ipFamily of the kind configuration and sets the service CIDRs of the mocked
+	// control plane.
+	IPFamily string `json:"ipFamily"`
+	// If set, a local container registry is started next to the kind cluster and its nodes pull images pushed to it.
+	// Its address (ex. "localhost:5001") is set in the KUTTL_REGISTRY environment variable of commands and Jsonnet
+	// external variable.
+	KINDRegistry bool `json:"kindRegistry"`
+	// The host port of the local container registry started with kindRegistry, defaults to 5001.
+	KINDRegistryPort int `json:"kindRegistryPort"`
+	// If set, the test suites of one invocation which set it and start a kind cluster (ex. with multiple --suite flags)
+	// share one cluster, which is started by the first of them and deleted after the last of them.  The kind settings
+	// of the first suite are used and all of them must use the same kindContext.
+	ShareKINDCluster bool `json:"shareKindCluster"`
+	// An ephemeral cluster started for the tests with another provider than kind (ex. k3d, or EKS and GKE clusters
+	// created by scripts), it is deleted when the tests end like a kind cluster.  It cannot be used with startKIND or
+	// startControlPlane.
+	StartCluster *ClusterProvider `json:"startCluster"`
 	// If set, do not delete the resources after running the tests (implies SkipClusterDelete).
 	SkipDelete bool `json:"skipDelete"`
 	// If set, do not delete the mocked control plane or kind cluster.
 	SkipClusterDelete bool `json:"skipClusterDelete"`
+	// If set, the mocked control plane or kind cluster is only deleted if all tests passed.  On failure it is kept
+	// for debugging and the connection details are logged.  This is independent of SkipDelete.
+	KeepClusterOnFailure bool `json:"keepClusterOnFailure"`
+	// Seed of the random source used for namespace names and the order of shuffled tests.  If 0, a random seed is
+	// used, it is logged so that a run can be reproduced.
+	Seed int64 `json:"seed"`
+	// If set, the tests of each test directory are run in a random order determined by the seed.
+	Shuffle bool `json:"shuffle"`
 	// Override the default timeout of 30 seconds (in seconds).
 	// +kubebuilder:validation:Format:=int64
 	Timeout int `json:"timeout"`
+	// The maximum duration of the whole test suite, including setup and the suite commands (in seconds).  When it is
+	// exceeded, the run is aborted and the running tests fail with the step they were running.  0 is no limit.
+	// +kubebuilder:validation:Format:=int64
+	SuiteTimeout int `json:"suiteTimeout"`
+	// The maximum duration of each test case, including its steps and asserts (in seconds).  When it is exceeded, the
+	// run is aborted like for suiteTimeout.  0 is no limit.
+	// +kubebuilder:validation:Format:=int64
+	TestTimeout int `json:"testTimeout"`
+	// The maximum number of failed tests.  When it is reached, the run is aborted like for suiteTimeout: the running
+	// tests fail, the remaining tests are not run and the environment is torn down.  0 is no limit.
+	// +kubebuilder:validation:Format:=int64
+	MaxFailures int `json:"maxFailures"`
 	// The maximum number of tests to run at once (default: 8).
 	// +kubebuilder:validation:Format:=int64
 	Parallel int `json:"parallel"`
+	// The maximum number of test namespaces deleted at once (default: parallel).  The namespaces of finished tests are
+	// deleted in the background while the other tests run, each for up to the timeout of its test.
+	// +kubebuilder:validation:Format:=int64
+	CleanupParallel int `json:"cleanupParallel"`
+	// ConcurrencyGroups limit the number of tests of a group running at once, in addition to parallel.
+	// Test cases are assigned to groups by the concurrencyGroups of their steps.
+	ConcurrencyGroups []ConcurrencyGroup `json:"concurrencyGroups"`
 	// The directory to output artifacts to (current working directory if not specified).
 	ArtifactsDir string `json:"artifactsDir"`
 	// Commands to run prior to running the tests.
 	Commands []Command `json:"commands"`
+	// If set, the tests fail before they run if a command or step plugin relies on a host binary which is not in
+	// tools.  Commands with a built-in implementation ("kubectl apply -f" and "kubectl wait --for=condition|delete")
+	// are run by it instead of the host binary.  Other kubectl commands, ex. "kubectl exec" and "kubectl
+	// port-forward", have none and need kubectl in tools.  Executables given as relative paths (ex. "./bin/tool") are
+	// part of the suite and need not be declared.
+	Hermetic bool `json:"hermetic"`
+	// Tools are the host binaries the commands of the suite may rely on in hermetic mode (ex. "kubectl", "sh").
+	Tools []string `json:"tools"`
 
 	// ReportFormat determines test report format (JSON|XML|nil) nil == no report
 	// maps to report.Type, however we don't want generated.deepcopy to have reference to it.
@@ -81,6 +208,13 @@ type TestSuite struct {
 
 	// ReportName defines the name of report to create.  It defaults to "kuttl-report" and is not used unless ReportFormat is defined.
 	ReportName string `json:"reportName"`
+	// If set, the ANSI escape sequences (ex. colors) of the command output attached to the reports of the tests are kept,
+	// they are stripped by default.  XML reports cannot contain them, they are only kept in JSON reports.
+	ReportColors bool `json:"reportColors"`
+	// TimingsFormat is the format of the timings of the run (csv or json), they are not written if it is empty.  The
+	// timings are the duration and the API calls of the apply, command and assert phases of each step, they are
+	// written to kuttl-timings.<format> in the artifacts directory (ex. to track the reconcile latency of an operator).
+	TimingsFormat string `json:"timingsFormat"`
 	// Namespace defines the namespace to use for tests
 	// The value "" means to auto-generate tests namespaces, these namespaces will be created and removed for each test
 	// Any other value is the name of the namespace to use.  This namespace will be created if it does not exist and will
@@ -88,10 +222,247 @@ type TestSuite struct {
 	Namespace string `json:"namespace"`
 	// Suppress is used to suppress logs
 	Suppress []string `json:"suppress"`
+	// A file listing known acceptable differences between expected and actual objects (ex. environmental ones), which
+	// are ignored by the asserts.  The suppressions which suppress no difference are reported when the tests end.
+	SuppressionsFile string `json:"suppressionsFile"`
+	// If set, a NetworkPolicy allowing all traffic of pods labeled with kuttl.dev/helper=true is created in test
+	// namespaces which have NetworkPolicies, so that pods created by test commands are not blocked by them.
+	// Otherwise a warning is logged when commands run in a namespace with NetworkPolicies.
+	AllowHelperPodTraffic bool `json:"allowHelperPodTraffic"`
+	// A ResourceQuota and a LimitRange created in each auto-created test namespace, to keep runaway tests from starving
+	// a shared cluster.  Test cases with a step setting skipNamespaceQuota are not limited.
+	NamespaceQuota *NamespaceQuota `json:"namespaceQuota"`
+	// The image of the helper pods running the app probes of test asserts, it must contain the kubectl-kuttl binary.
+	// It defaults to the kuttl image of the running version.
+	ProbeImage string `json:"probeImage"`
+	// Scheduling constraints of the helper pods kuttl creates (ex. to run app probes and mock services or to pull the
+	// required images), so that they can be scheduled on the tainted nodes of CI clusters.
+	HelperPods *HelperPodScheduling `json:"helperPods"`
+	// Images the tests rely on, they are pulled by helper pods once the suite commands have run and the tests fail at
+	// once if an image cannot be pulled (ex. its tag does not exist), instead of each failing on ImagePullBackOff after
+	// its timeout.  Local images can be loaded into a kind cluster with kindContainers.
+	RequiredImages []string `json:"requiredImages"`
+	// If set, the required images are pulled on every schedulable node, so that the tests do not wait for the pulls.
+	PrePullImages bool `json:"prePullImages"`
+	// Executables handling custom step kinds of the kuttl.dev group by kind (ex. "LoadTest": "./bin/load-test").
+	// The object of the custom kind is passed to the executable as YAML on stdin.
+	StepPlugins map[string]string `json:"stepPlugins"`
+	// DuplicateObjects is what happens when an object (same kind, namespace and name) is applied more than once by the
+	// files of a test step: error fails loading the test (default), lastWins applies only the last definition and
+	// applyAll applies every definition in order, the later ones patching the earlier ones.
+	DuplicateObjects DuplicateObjectPolicy `json:"duplicateObjects"`
+	// The DNS domain of the cluster (ex. "cluster.local"), it is detected from the kubelets if it is not set.  It is set
+	// in the KUTTL_CLUSTER_DOMAIN environment variable of commands and Jsonnet external variable, and the in-cluster
+	// address of the API server in KUTTL_API_SERVER.
+	ClusterDomain string `json:"clusterDomain"`
+	// The directory of the shared fragments included in the files of the test steps by documents with a $ref (ex.
+	// the expected RBAC of an operator).  The $ref paths are relative to it, or to the including file if it is not set.
+	FragmentsDir string `json:"fragmentsDir"`
+	// External variables of the Jsonnet files of the tests and manifest directories, read with std.extVar(name).
+	// Jsonnet files (.jsonnet) are rendered with go-jsonnet when they are loaded.
+	JsonnetVars map[string]string `json:"jsonnetVars"`
+	// Secrets are resolved from external secret managers when the suite starts, so that credentials are not stored with
+	// the tests.  They are set as environment variables of commands and as Jsonnet external variables, and their values
+	// are redacted from the logs.
+	Secrets []Secret `json:"secrets"`
+	// MetricsPushgatewayURL is the URL of a Prometheus Pushgateway to push the run metrics to when the tests have finished.
+	MetricsPushgatewayURL string `json:"metricsPushgatewayURL"`
+	// MetricsAddress is the address (ex. ":9090") to expose the run metrics on at /metrics while the tests are running.
+	MetricsAddress string `json:"metricsAddress"`
+	// LogStreams are deployments (ex. the operator under test) whose logs are streamed into the artifacts directory
+	// from the start of the suite, into logs/<namespace>-<deployment>.log and into a file of each test which is
+	// running while the logs are written, logs/<test>/<namespace>-<deployment>.log.
+	LogStreams []LogStream `json:"logStreams"`
+	// If set, tests fail when the API server warns that an object applied by a step uses a deprecated API.
+	FailOnDeprecatedAPIs bool `json:"failOnDeprecatedAPIs"`
+	// If set, the test suite fails before running the tests when test cases which may run at the same time apply
+	// cluster-scoped objects of the same name, otherwise the collisions are only logged.  Test cases of a concurrency
+	// group with a limit of 1 never run at the same time.
+	FailOnCollisions bool `json:"failOnCollisions"`
+	// If set, the watch events of the objects in the namespace of each test are recorded in a JSON Lines file, whose
+	// path is set in the KUTTL_EVENT_JOURNAL environment variable of the commands of the test.
+	EventJournal *EventJournal `json:"eventJournal"`
+	// If set, test steps may not delete, prune, restart or evict objects outside the namespaces kuttl creates for the
+	// tests, nor change nodes, unless the safe mode allows it.  It protects shared clusters from misconfigured tests.
+	SafeMode *SafeMode `json:"safeMode"`
+	// Overlays are JSON patches applied to the objects the test steps apply when they are loaded, ex. to change the
+	// storageClassName of all PersistentVolumeClaims for an environment.  They are applied before those of the steps.
+	Overlays []Overlay `json:"overlays"`
+	// If set, the field managers which wrote the asserted fields of an object are reported when its assert keeps
+	// flapping between match and mismatch, ex. when another controller fights the operator over the object.
+	DetectDrift bool `json:"detectDrift"`
+	// If set, cluster-scoped objects are snapshotted before and after the tests, and the test suite fails if the tests
+	// leave objects created, deleted or changed, so that the suite leaves the cluster as it found it.
+	ClusterSnapshot *ClusterSnapshot `json:"clusterSnapshot"`
 
 	Config *RestConfig `json:"config,omitempty"`
 }
 
+// ConcurrencyGroup is a named group of test cases of which at most Limit run at once.
+type ConcurrencyGroup struct {
+	Name string `json:"name"`
+	// +kubebuilder:validation:Format:=int64
+	Limit int `json:"limit"`
+}
+
+// NamespaceQuota are the ResourceQuota and LimitRange of test namespaces.
+type NamespaceQuota struct {
+	// Hard limits of the ResourceQuota (ex. "limits.cpu": "2", "pods": "20").  When limits or requests of compute
+	// resources are limited, pods must set them, the limits of the LimitRange can default them.
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+	// Limits of the LimitRange (ex. the default limits of containers).
+	Limits []corev1.LimitRangeItem `json:"limits,omitempty"`
+}
+
+// Secret is a value resolved from an external secret manager.
+type Secret struct {
+	// Name of the environment variable and of the Jsonnet external variable set to the value.
+	Name string `json:"name"`
+	// Provider of the secret, one of "vault", "aws-secrets-manager" or "sops".
+	Provider string `json:"provider"`
+	// Ref references the secret in the provider: the path of a Vault secret (ex. "secret/data/ci/db"), the ID of an
+	// AWS Secrets Manager secret or the path of a SOPS encrypted file.
+	Ref string `json:"ref"`
+	// Key of the value in the secret: the field of a Vault secret, the key of a JSON AWS secret or the dotted path of
+	// the value in a SOPS file (ex. "db.password").  The whole secret is used if not set, a Vault secret must then
+	// have a single field.
+	Key string `json:"key,omitempty"`
+}
+
+// LogStream is a deployment whose logs are streamed into the artifacts directory.
+type LogStream struct {
+	// Namespace of the deployment.
+	Namespace string `json:"namespace"`
+	// Deployment is the name of the deployment, the logs of all of its pods are streamed.
+	Deployment string `json:"deployment"`
+	// Container is the container to stream the logs of, the logs of all containers are streamed if not set.
+	Container string `json:"container,omitempty"`
+}
+
+// ClusterProvider starts and deletes an ephemeral cluster.  The commands of the exec provider run with the KUBECONFIG
+// environment variable set to the path of the kubeconfig of the cluster and KUTTL_CLUSTER_NAME set to its name.
+type ClusterProvider struct {
+	// Provider of the cluster, one of "exec" (the default) running the commands below and "k3d".
+	Provider string `json:"provider,omitempty"`
+	// Name of the cluster, the default is "kuttl".
+	Name string `json:"name,omitempty"`
+	// Create is the command creating the cluster of the exec provider, it must write the kubeconfig of the cluster to
+	// $KUBECONFIG (ex. "eksctl create cluster --name $KUTTL_CLUSTER_NAME --kubeconfig $KUBECONFIG").
+	Create string `json:"create,omitempty"`
+	// Delete is the command deleting the cluster of the exec provider.
+	Delete string `json:"delete,omitempty"`
+	// CollectLogs is the command of the exec provider collecting the logs of the cluster into $KUTTL_LOGS_DIR, the
+	// logs are not collected if it is not set.
+	CollectLogs string `json:"collectLogs,omitempty"`
+	// Args are additional arguments of "k3d cluster create" (ex. "--agents", "2").
+	Args []string `json:"args,omitempty"`
+	// Timeout of creating, deleting and collecting the logs of the cluster in seconds, the default is 1800.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// ImageBuild is a Docker image built from a local build context.
+type ImageBuild struct {
+	// Image is the name the image is tagged with (ex. "example.com/operator:dev"), the tests refer to it by this name.
+	Image string `json:"image"`
+	// Context is the directory of the build context.
+	Context string `json:"context"`
+	// Dockerfile is the path of the Dockerfile in the build context, the default is "Dockerfile".
+	Dockerfile string `json:"dockerfile,omitempty"`
+	// BuildArgs are the build-time variables of the build.
+	BuildArgs map[string]string `json:"buildArgs,omitempty"`
+}
+
+// KINDFixture is a test fixture installed into the kind cluster.
+type KINDFixture struct {
+	// Name of the fixture: csi-hostpath (the hostpath CSI driver and its StorageClass), local-path-provisioner (a
+	// pinned version of the local path provisioner) or sample-device-plugin (a device plugin advertising
+	// example.com/resource on each node).  Other names require manifests.
+	Name string `json:"name"`
+	// Version of the fixture, the release of its project (ex. "v0.0.26"), the default is the version kuttl was
+	// tested with.
+	Version string `json:"version,omitempty"`
+	// Manifests are the URLs or paths of the manifests of the fixture, they replace the manifests of a well-known
+	// fixture (ex. to install it from a mirror).  "{version}" is replaced with the version.
+	Manifests []string `json:"manifests,omitempty"`
+}
+
+// HelperPodScheduling are the scheduling constraints of the helper pods kuttl creates.
+type HelperPodScheduling struct {
+	// NodeSelector of the helper pods.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations of the helper pods.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName of the helper pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// EventJournal configures the journal of the watch events of the objects in the namespace of a test.
+type EventJournal struct {
+	// Kinds to watch, the default is the kinds of the objects applied and asserted by the steps of the test and Events.
+	// The kinds of a step are watched from the start of the step, so that kinds of CRDs installed by the test can be
+	// watched.
+	Kinds []metav1.TypeMeta `json:"kinds,omitempty"`
+	// MaxEvents is the number of the latest watch events kept in the journal, the default is 1000.
+	MaxEvents int `json:"maxEvents,omitempty"`
+}
+
+// HelmChartTests are the test cases generated from the test values files of a Helm chart.  The test case of a values
+// file installs the chart with the values in the test namespace and asserts that all the Deployments, StatefulSets
+// and DaemonSets the chart renders roll out.  A file next to the values file named after the test case with an
+// "-assert.yaml" suffix (ex. "ci/ha-assert.yaml" for "ci/ha-values.yaml") adds its objects to the assert.
+type HelmChartTests struct {
+	// The directory of the chart.
+	Chart string `json:"chart"`
+	// Globs of the test values files relative to the chart directory, defaults to "ci/*-values.yaml".  The test cases
+	// are named after the values files without the extension and the "-values" suffix.
+	Values []string `json:"values,omitempty"`
+	// The name of the Helm release, defaults to the name of the chart directory.
+	Release string `json:"release,omitempty"`
+}
+
+// Overlay is a JSON patch (RFC 6902) applied to the objects matching its target.
+type Overlay struct {
+	// Target selects the objects the patch is applied to, it matches all objects if empty.
+	Target OverlayTarget `json:"target,omitempty"`
+	// Patch is the list of JSON patch operations in YAML or JSON (ex. "[{op: replace, path: /spec/storageClassName,
+	// value: fast}]").
+	Patch string `json:"patch"`
+}
+
+// OverlayTarget selects objects by their group, version, kind, name and namespace, the fields which are set must match.
+type OverlayTarget struct {
+	// Group of the objects, "" is the core group only if Version is set.
+	Group   string `json:"group,omitempty"`
+	Version string `json:"version,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	// Name and Namespace are patterns matched with path.Match (ex. "data-*").  An empty namespace pattern matches
+	// objects without a namespace, which are created in the test namespace.
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ClusterSnapshot configures the comparison of the cluster-scoped objects before and after the tests of a suite.
+type ClusterSnapshot struct {
+	// Kinds of the objects compared, the default is CustomResourceDefinitions, ClusterRoles, ClusterRoleBindings,
+	// MutatingWebhookConfigurations, ValidatingWebhookConfigurations and StorageClasses.  Kinds the cluster does not
+	// serve are skipped.
+	Kinds []metav1.TypeMeta `json:"kinds,omitempty"`
+	// Ignore are patterns of the objects whose differences are expected, in the form "<kind>[.<group>]/<name>" (ex.
+	// "ClusterRole.rbac.authorization.k8s.io/system:*").  The patterns are matched with path.Match.
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// SafeMode limits the destructive operations of test steps to the namespaces kuttl creates for the tests.
+type SafeMode struct {
+	// Allow are patterns of the objects outside the test namespaces which test steps may delete, prune, restart or
+	// evict, in the form "<kind>[.<group>]/<name>" for cluster-scoped objects and "<kind>[.<group>]/<namespace>/<name>"
+	// for namespaced objects (ex. "ClusterRole.rbac.authorization.k8s.io/my-operator-*" or "*/shared/*").  The
+	// patterns are matched with path.Match, * does not match a slash.
+	Allow []string `json:"allow,omitempty"`
+	// If set, test steps may change nodes.
+	AllowNodeChanges bool `json:"allowNodeChanges,omitempty"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // TestStep settings to apply to a test step.go
@@ -104,6 +475,10 @@ type TestStep struct {
 	// +kubebuilder:validation:Format:=int64
 	Index int `json:"index,omitempty"`
 
+	// Stages the step runs in (ex. ["smoke", "full"]), it runs in every stage if empty.  Steps which are not in the
+	// stage of the test suite are skipped.
+	Stages []string `json:"stages,omitempty"`
+
 	// Apply, Assert and Error lists of files or directories to use in the test step.
 	// Useful to reuse a number of applies across tests / test steps.
 	// all relative paths are relative to the folder the TestStep is defined in.
@@ -111,6 +486,13 @@ type TestStep struct {
 	Assert []string `json:"assert,omitempty"`
 	Error  []string `json:"error,omitempty"`
 
+	// If set, the objects of the step are applied with server-side apply instead of being created or merge patched.
+	ServerSideApply *ServerSideApply `json:"serverSideApply,omitempty"`
+
+	// If set, the status of the objects of the step is written to the status subresource after they are applied (ex.
+	// to simulate a controller in tests against a mocked control plane).
+	UpdateStatus bool `json:"updateStatus,omitempty"`
+
 	// Objects to delete at the beginning of the test step.
 	Delete []ObjectReference `json:"delete,omitempty"`
 
@@ -120,11 +502,356 @@ type TestStep struct {
 	// Commands to run prior at the beginning of the test step.
 	Commands []Command `json:"commands"`
 
+	// Cleanup commands release external resources created by the step (ex. cloud buckets or DNS entries).  They run
+	// when the test case ends, whether it passes, fails or panics, in the reverse order of the steps and before the
+	// namespace of the test case is deleted (unless --skip-delete is used).  Only the steps which were started are
+	// cleaned up, a failing cleanup command fails the test case without skipping the following ones.
+	Cleanup []Command `json:"cleanup,omitempty"`
+	// CleanupTimeout is the timeout of each cleanup command in seconds, the default is 300.
+	CleanupTimeout int `json:"cleanupTimeout,omitempty"`
+
 	// Allowed environment labels
 	// Disallowed environment labels
 
 	// Kubeconfig to use when applying and asserting for this step.
 	Kubeconfig string `json:"kubeconfig,omitempty"`
+
+	// Identity to use when applying, asserting and running commands for this step.
+	// The default is the identity of the kubeconfig.
+	Identity *Identity `json:"identity,omitempty"`
+
+	// Short-lived tokens of ServiceAccounts requested with the TokenRequest API at the beginning of the step.  They are
+	// set in environment variables of the commands of the step and redacted from the logs.
+	ServiceAccountTokens []ServiceAccountToken `json:"serviceAccountTokens,omitempty"`
+
+	// CopyFrom copies existing objects (ex. image pull Secrets of another namespace) into the test namespace at the
+	// beginning of the step, before its commands run and its objects are applied.
+	CopyFrom []CopyFrom `json:"copyFrom,omitempty"`
+
+	// WorkloadClusters are Cluster API workload clusters whose kubeconfig is written to a file once they are
+	// provisioned, after the objects of the step are applied.  The following steps of the test run against a workload
+	// cluster by setting their kubeconfig to the file.
+	WorkloadClusters []WorkloadCluster `json:"workloadClusters,omitempty"`
+
+	// Mocks of external HTTP and gRPC services the operator under test calls (ex. cloud provider APIs or license
+	// servers), started at the beginning of the step and served until the test case ends.
+	MockServices []MockService `json:"mockServices,omitempty"`
+
+	// Changes to nodes (labels, taints and cordoning) applied at the beginning of the step, after the objects in the
+	// delete list were deleted.  The changes are undone when the test case ends (unless --skip-delete is used).
+	Nodes []NodeChange `json:"nodes,omitempty"`
+
+	// If set, objects applied by earlier steps of the test case which are not part of the apply files of this step
+	// are deleted after this step is applied, like `kubectl apply --prune`.
+	Prune bool `json:"prune,omitempty"`
+	// PruneKinds limits pruning to objects of these kinds, in the form "<kind>" or "<kind>.<group>" (ex. "Deployment.apps").
+	PruneKinds []string `json:"pruneKinds,omitempty"`
+	// PruneSelector limits pruning to objects whose labels match the selector.
+	PruneSelector *metav1.LabelSelector `json:"pruneSelector,omitempty"`
+
+	// Preconditions the cluster must meet to run the test case.  The preconditions of all steps are checked before
+	// the test case starts, the test case is skipped if they are not met.
+	Preconditions *Preconditions `json:"preconditions,omitempty"`
+
+	// ConcurrencyGroups of the test suite the test case belongs to.  The groups of all steps apply to the whole test
+	// case, it only starts when it is within the limits of all of them.
+	ConcurrencyGroups []string `json:"concurrencyGroups,omitempty"`
+
+	// If set, the namespace of the test case has none of the namespaceQuota of the test suite.  It applies to the whole
+	// test case, like the concurrency groups.
+	SkipNamespaceQuota bool `json:"skipNamespaceQuota,omitempty"`
+
+	// Assertions on the warnings returned by the API server (ex. deprecation or admission warnings) when the objects
+	// of this step are applied.
+	Warnings *WarningAssertions `json:"warnings,omitempty"`
+
+	// Syncs trigger a reconciliation of GitOps objects (Flux Kustomizations and HelmReleases, Argo CD Applications)
+	// after the objects of this step are applied, and wait until they are synced and healthy.
+	Syncs []GitOpsSync `json:"syncs,omitempty"`
+
+	// Restarts roll out a restart of Deployments, StatefulSets or DaemonSets (ex. of the operator under test) after the
+	// objects of this step are applied and synced, and wait until the restarted pods are ready.
+	Restarts []Restart `json:"restarts,omitempty"`
+
+	// Evictions request the eviction of pods with the Eviction API after the objects of this step are applied, synced
+	// and restarted, and assert whether the PodDisruptionBudgets covering them allow or deny it.
+	Evictions []Eviction `json:"evictions,omitempty"`
+
+	// Autoscalers drive load against the targets of HorizontalPodAutoscalers after the objects of this step are
+	// applied, synced, restarted and evicted, and assert that they scale their targets within bounds in time.
+	Autoscalers []AutoscalerCheck `json:"autoscalers,omitempty"`
+
+	// If set, the step is expected to fail (ex. because of a known bug): the test case is reported as an expected
+	// failure if it does, and fails as an unexpected pass if it does not.
+	ExpectedFailure *ExpectedFailure `json:"expectedFailure,omitempty"`
+
+	// Overlays are JSON patches applied to the objects of this step when it is loaded, after those of the test suite.
+	Overlays []Overlay `json:"overlays,omitempty"`
+}
+
+// ExpectedFailure documents why a test step is expected to fail.
+type ExpectedFailure struct {
+	// Reason the step is expected to fail.
+	Reason string `json:"reason,omitempty"`
+	// Link to the issue tracking the failure.
+	Link string `json:"link,omitempty"`
+}
+
+// GitOpsSync triggers a reconciliation of a Flux Kustomization or HelmRelease or of an Argo CD Application and waits
+// for it to succeed.
+type GitOpsSync struct {
+	// Kind of the object: Kustomization or HelmRelease (Flux), or Application (Argo CD).
+	Kind string `json:"kind"`
+	// APIVersion of the object, the default depends on the kind (ex. "kustomize.toolkit.fluxcd.io/v1").
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Name of the object.
+	Name string `json:"name"`
+	// Namespace of the object, the test namespace by default.
+	Namespace string `json:"namespace,omitempty"`
+	// Timeout of the sync (in seconds), the timeout of the step by default.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Restart rolls out a restart of a workload like `kubectl rollout restart` and waits for it like `kubectl rollout
+// status`.
+type Restart struct {
+	// Kind of the workload: Deployment, StatefulSet or DaemonSet.
+	Kind string `json:"kind"`
+	// Name of the workload.
+	Name string `json:"name"`
+	// Namespace of the workload, the test namespace by default.
+	Namespace string `json:"namespace,omitempty"`
+	// Timeout of the rollout (in seconds), the timeout of the step by default.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// EvictionResult is the expected result of evicting a pod.
+type EvictionResult string
+
+const (
+	// EvictionAllowed expects the eviction to be allowed, the pod is evicted.
+	EvictionAllowed EvictionResult = "allowed"
+	// EvictionDenied expects the eviction to be denied by a PodDisruptionBudget.
+	EvictionDenied EvictionResult = "denied"
+)
+
+// Eviction evicts pods like a node drain and asserts the result, to test the PodDisruptionBudgets managed by an
+// operator or its maintenance workflows.
+type Eviction struct {
+	// Name of the pod to evict, either name or selector must be set.
+	Name string `json:"name,omitempty"`
+	// Label selector of the pods to evict (ex. "app=nginx"), each of them is evicted.
+	Selector string `json:"selector,omitempty"`
+	// Namespace of the pods, the test namespace by default.
+	Namespace string `json:"namespace,omitempty"`
+	// Expect is the expected result of each eviction: allowed (default) or denied.  Allowed evictions are retried
+	// until the timeout while they are denied, the status of the PodDisruptionBudgets may not be up to date yet.
+	Expect EvictionResult `json:"expect,omitempty"`
+	// If set, the evictions are server-side dry runs and the pods are not evicted.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Timeout of allowed evictions (in seconds), the timeout of the step by default.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// AutoscalerCheck asserts that a HorizontalPodAutoscaler scales its target, ex. under the load of busy-work pods.  The
+// check waits until the metrics APIs the autoscaler uses (ex. metrics-server) are available and the autoscaler reads
+// its metrics before it starts the load, so that a cluster which is still starting does not fail the check.
+type AutoscalerCheck struct {
+	// Name of the HorizontalPodAutoscaler.
+	Name string `json:"name"`
+	// Namespace of the HorizontalPodAutoscaler and of the load pods, the test namespace by default.
+	Namespace string `json:"namespace,omitempty"`
+	// Load generates load while the target is expected to scale, no load is generated if it is not set (ex. when a
+	// command of the step drives the load).
+	Load *AutoscalerLoad `json:"load,omitempty"`
+	// MinReplicas is the number of replicas the autoscaler must scale its target to, the default is one more than the
+	// replicas of the target when the load starts.
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the number of replicas the autoscaler may not scale its target above during the check.
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+	// Timeout of the check (in seconds), including waiting for the metrics, the timeout of the step by default.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// AutoscalerLoad are busy-work pods generating load, they are deleted when the check of the autoscaler ends.  Either
+// URL or Command must be set.
+type AutoscalerLoad struct {
+	// URL requested in a loop by each load pod (ex. "http://web" for the Service of the target).
+	URL string `json:"url,omitempty"`
+	// Command run by each load pod instead of requesting the URL (ex. ["sh", "-c", "while true; do ...; done"]).
+	Command []string `json:"command,omitempty"`
+	// Image of the load pods, the default is busybox.
+	Image string `json:"image,omitempty"`
+	// Pods is the number of load pods, the default is 1.
+	Pods int `json:"pods,omitempty"`
+}
+
+// MockService is a mock of an external HTTP or gRPC service serving the declarative responses of its routes or
+// methods, so that tests do not depend on the real service.
+type MockService struct {
+	// Name of the Service created in the test namespace for the mock.
+	Name string `json:"name"`
+	// Port of the Service, 443 with TLS and 80 otherwise by default.  On the host it is the local port and required.
+	Port int `json:"port,omitempty"`
+	// If set, the mock is served by kuttl on the host at 127.0.0.1 (::1 if the ipFamily of the suite is ipv6) instead
+	// of in the cluster, ex. for operators run outside of the cluster.
+	Host bool `json:"host,omitempty"`
+	// If set, the mock is served over HTTPS with a certificate signed by a CA created for it.  The certificate, its key
+	// and the certificate of the CA are stored in the Secret <name>-tls (tls.crt, tls.key and ca.crt) of the test
+	// namespace, for the operator to trust.
+	TLS bool `json:"tls,omitempty"`
+	// Routes of the mock, requests matching none of them get a 404 response.
+	Routes []MockRoute `json:"routes,omitempty"`
+	// GRPC serves the mock as a gRPC server instead of its routes, with the standard health service and the declared
+	// responses of unary methods.
+	GRPC *MockGRPC `json:"grpc,omitempty"`
+}
+
+// MockGRPC is a mock of an external gRPC service.
+type MockGRPC struct {
+	// Descriptors is the path of a protobuf FileDescriptorSet with the services of the methods and the files they
+	// import (ex. written by protoc --descriptor_set_out --include_imports), relative to the test directory.  It is
+	// needed to encode the responses of the methods, and its services are listed by server reflection.
+	Descriptors string `json:"descriptors,omitempty"`
+	// Health is the serving status (SERVING, NOT_SERVING or UNKNOWN) the health service reports for service names, the
+	// server as a whole and the services of the methods are SERVING by default.
+	Health map[string]string `json:"health,omitempty"`
+	// Methods are the responses of the unary methods, calls of other methods fail with the UNIMPLEMENTED status.
+	Methods []MockGRPCMethod `json:"methods,omitempty"`
+}
+
+// MockGRPCMethod is the response of a mock gRPC service to the calls of a unary method.
+type MockGRPCMethod struct {
+	// Method in the form <package>.<Service>/<Method>.
+	Method string `json:"method"`
+	// Response is the JSON of the response message in the protobuf JSON mapping, an empty message if not set.
+	Response string `json:"response,omitempty"`
+	// Code is the gRPC status code calls fail with instead of responding (ex. 5 for NOT_FOUND), 0 by default.
+	Code int `json:"code,omitempty"`
+	// Message is the status message of the failed calls.
+	Message string `json:"message,omitempty"`
+}
+
+// MockRoute is the response of a mock service to the requests matching a method and a path.
+type MockRoute struct {
+	// HTTP method of the requests, every method if empty.
+	Method string `json:"method,omitempty"`
+	// Path of the requests, or a prefix of it if the path ends with "*" (ex. "/v1/instances/*").
+	Path string `json:"path"`
+	// Status code of the response, 200 by default.
+	Status int `json:"status,omitempty"`
+	// Headers of the response.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body of the response.
+	Body string `json:"body,omitempty"`
+}
+
+// ServerSideApply are the options of applying the objects of a step with server-side apply.
+type ServerSideApply struct {
+	// FieldManager is the field manager the objects are applied as (default: kuttl).  Steps applying as different field
+	// managers can be used to simulate several controllers managing the same objects.
+	FieldManager string `json:"fieldManager,omitempty"`
+	// Force takes over the fields owned by other field managers, instead of failing the step with a conflict.
+	Force bool `json:"force,omitempty"`
+}
+
+// WarningAssertions are assertions on the warnings returned by the API server when the objects of a step are applied.
+// Patterns are regular expressions matched against the text of the warnings.
+type WarningAssertions struct {
+	// Expected are patterns each of which must match at least one warning.
+	Expected []string `json:"expected,omitempty"`
+	// Unexpected are patterns which must not match any warning.
+	Unexpected []string `json:"unexpected,omitempty"`
+	// FailOnDeprecatedAPIs overrides the failOnDeprecatedAPIs setting of the test suite for this step.
+	FailOnDeprecatedAPIs *bool `json:"failOnDeprecatedAPIs,omitempty"`
+}
+
+// NodeChange is a change to the nodes selected by name or labels. Exactly one of Name or Selector must be set.
+type NodeChange struct {
+	// Name of the node to change.
+	Name string `json:"name,omitempty"`
+	// Selector are the labels of the nodes to change.
+	Selector map[string]string `json:"selector,omitempty"`
+	// Labels to set on the nodes.
+	Labels map[string]string `json:"labels,omitempty"`
+	// RemoveLabels are the keys of labels to remove from the nodes.
+	RemoveLabels []string `json:"removeLabels,omitempty"`
+	// Taints to add to the nodes, they replace taints with the same key and effect.
+	Taints []corev1.Taint `json:"taints,omitempty"`
+	// RemoveTaints are the taints to remove from the nodes, in the form "<key>" (all effects) or "<key>:<effect>".
+	RemoveTaints []string `json:"removeTaints,omitempty"`
+	// Cordon marks the nodes unschedulable if true and schedulable if false.
+	Cordon *bool `json:"cordon,omitempty"`
+}
+
+// Preconditions are requirements on the state of the cluster for a test case to run.
+type Preconditions struct {
+	// CRDs are the names of CustomResourceDefinitions which must be established (ex. "certificates.cert-manager.io").
+	CRDs []string `json:"crds,omitempty"`
+	// StorageClasses are the names of StorageClasses which must exist.
+	StorageClasses []string `json:"storageClasses,omitempty"`
+	// MinNodes is the minimum number of ready and schedulable nodes.
+	MinNodes int `json:"minNodes,omitempty"`
+	// AllocatableCPU is the minimum allocatable CPU of all ready and schedulable nodes (ex. "4" or "3500m").
+	AllocatableCPU string `json:"allocatableCPU,omitempty"`
+	// AllocatableMemory is the minimum allocatable memory of all ready and schedulable nodes (ex. "8Gi").
+	AllocatableMemory string `json:"allocatableMemory,omitempty"`
+}
+
+// ServiceAccountToken is a token of a ServiceAccount requested by a test step.
+type ServiceAccountToken struct {
+	// ServiceAccount whose token is requested, in the test namespace unless given in the form "<namespace>/<name>".
+	ServiceAccount string `json:"serviceAccount"`
+	// Env is the environment variable the token is set in for the commands of the step (ex. "OPERATOR_TOKEN").
+	Env string `json:"env"`
+	// Audiences of the token, the audiences of the API server if not set.
+	Audiences []string `json:"audiences,omitempty"`
+	// ExpirationSeconds is the requested lifetime of the token, the default is 600 (the minimum of the API).
+	ExpirationSeconds int64 `json:"expirationSeconds,omitempty"`
+}
+
+// CopyFrom is an existing namespaced object copied into the test namespace by a test step.  The copy is created or
+// updated without the status and the metadata the API server maintains of the object.
+type CopyFrom struct {
+	// The object to copy, it is in the test namespace unless namespace is set.
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	// ToName is the name of the copy, the name of the object by default.
+	ToName string `json:"toName,omitempty"`
+	// Patch is a JSON merge patch in YAML or JSON applied to the copy (ex. "metadata: {labels: {app: web}}"), null
+	// values remove fields.
+	Patch string `json:"patch,omitempty"`
+}
+
+// WorkloadCluster is a Cluster API workload cluster, its kubeconfig is read from the "<name>-kubeconfig" Secret
+// Cluster API writes next to the Cluster.
+type WorkloadCluster struct {
+	// Name of the Cluster.
+	Name string `json:"name"`
+	// Namespace of the Cluster, the test namespace if not set.
+	Namespace string `json:"namespace,omitempty"`
+	// Kubeconfig is the path the kubeconfig of the cluster is written to, relative to the test directory.  The file is
+	// deleted when the test ends.
+	Kubeconfig string `json:"kubeconfig"`
+	// Machines is the number of Machines of the Cluster which must be Running before the kubeconfig is written, the
+	// step only waits for the Cluster to be Provisioned if not set.
+	Machines int `json:"machines,omitempty"`
+	// Timeout of waiting for the cluster in seconds, the timeout of the step if not set.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Identity is the client identity used by a test step. Exactly one of User, TokenSecret or ServiceAccount must be set.
+type Identity struct {
+	// User is the name of a user in the step's kubeconfig (or the kubeconfig kuttl was started with) to authenticate as.
+	User string `json:"user,omitempty"`
+	// TokenSecret is the name of a Secret holding a bearer token under the "token" key to authenticate with.
+	// The Secret is looked up in the test namespace unless given in the form "<namespace>/<name>".
+	TokenSecret string `json:"tokenSecret,omitempty"`
+	// ServiceAccount is the name of a ServiceAccount to impersonate.
+	// The ServiceAccount is in the test namespace unless given in the form "<namespace>/<name>".
+	ServiceAccount string `json:"serviceAccount,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -137,10 +864,142 @@ type TestAssert struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// Override the default timeout of 30 seconds (in seconds).
 	Timeout int `json:"timeout"`
+	// Stages the asserts and errors of the step are checked in, they are checked in every stage if empty.  In other
+	// stages the objects of the step are applied without checking them.
+	Stages []string `json:"stages,omitempty"`
+	// StrictConditions compares the lists of conditions of the asserted and the error objects by position, their length
+	// must match like for other lists.  By default the expected conditions are matched to the actual conditions of the
+	// same type, in any order, and the actual conditions which are not expected are ignored.
+	StrictConditions bool `json:"strictConditions,omitempty"`
 	// Collectors is a set of pod log collectors fired on an assert failure
 	Collectors []*TestCollector `json:"collectors,omitempty"`
+	// Escalation runs collectors once while the asserts are still failing shortly before the timeout, so that a failure
+	// includes the state before the timeout which is often more telling than the final state.
+	Escalation *AssertEscalation `json:"escalation,omitempty"`
 	// Commands is a set of commands to be run as assertions for the current step
 	Commands []TestAssertCommand `json:"commands,omitempty"`
+	// FieldAssertions check single fields of objects selected by JSONPath expressions, without mirroring the whole
+	// object in an expected object.  They are retried with the other asserts.
+	FieldAssertions []FieldAssertion `json:"fieldAssertions,omitempty"`
+	// Expressions check objects with CEL expressions, ex. relations between their fields which expected objects cannot
+	// express.  They are retried with the other asserts.
+	Expressions []ExpressionAssertion `json:"expressions,omitempty"`
+	// AnyOf is a list of assertion groups of which at least one must pass.
+	AnyOf []TestAssertGroup `json:"anyOf,omitempty"`
+	// AllOf is a list of assertion groups which must all pass, each within its own timeout.
+	AllOf []TestAssertGroup `json:"allOf,omitempty"`
+	// Sequences are lists of expected objects which must be observed in order while the step runs, ex. the phases
+	// Pending, Provisioning and Ready of a state machine rather than only its final state.
+	Sequences []AssertSequence `json:"sequences,omitempty"`
+	// Probes check that the applications behind services are ready at the protocol level, ex. that a database accepts
+	// connections.  They run in helper pods once the other assertions pass.
+	Probes []AppProbe `json:"probes,omitempty"`
+	// ExpectErrors are patches the API server is expected to reject, ex. changes of immutable fields or values denied by
+	// the validation of a CRD.  They are applied as dry runs once the other assertions pass, so that they change nothing
+	// if they are accepted.
+	ExpectErrors []ExpectError `json:"expectErrors,omitempty"`
+}
+
+// AssertEscalation is a set of collectors run once when the asserts of a step are still failing at a share of its
+// timeout.
+type AssertEscalation struct {
+	// Percent is the share of the timeout of the step at which the collectors run, 80 if not set.
+	Percent int `json:"percent,omitempty"`
+	// Collectors gather the diagnostics, ex. the logs of pods, events or the output of kubectl describe or a script.
+	Collectors []*TestCollector `json:"collectors,omitempty"`
+}
+
+// FieldAssertion compares the values a JSONPath expression selects in an object to a value.
+type FieldAssertion struct {
+	// The object to check, it is in the test namespace unless namespace is set.
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Path is a JSONPath expression in the syntax of kubectl, the braces are optional (ex.
+	// ".status.conditions[?(@.type=="Ready")].status").
+	Path string `json:"path"`
+	// Operator compares the selected values to the value, one of "equals" (the default), "notEquals", "contains",
+	// "matches" (a regular expression), "greaterThan", "lessThan" (quantities, ex. "2" or "1Gi"), "exists" and
+	// "notExists".  The path must select a single value, except for contains, exists and notExists.
+	Operator string `json:"operator,omitempty"`
+	// Value is the value to compare to, strings are compared as they are and other values in JSON (ex. "3" or
+	// "true").  It is ignored by exists and notExists.
+	Value string `json:"value,omitempty"`
+}
+
+// ExpressionAssertion checks an object with a CEL expression.
+type ExpressionAssertion struct {
+	// The object to check, it is in the test namespace unless namespace is set.
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Expression is a CEL expression which must evaluate to true.  The top-level fields of the object are its variables
+	// and the whole object is self, ex. "status.readyReplicas == spec.replicas && status.phase != 'Failed'".
+	Expression string `json:"expression"`
+	// Message is the error reported when the expression is false, ex. "the deployment is not rolled out".
+	Message string `json:"message,omitempty"`
+}
+
+// ExpectError is a patch of an object which the API server must reject with a matching message.
+type ExpectError struct {
+	// The object to patch, it is in the test namespace unless namespace is set.
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Patch is the patch in YAML or JSON (ex. "spec: {storageClassName: other}").
+	Patch string `json:"patch"`
+	// Type of the patch, one of "merge" (the default), "strategic" and "json".
+	Type string `json:"type,omitempty"`
+	// Message is a regular expression the message of the error must match (ex. "field is immutable").
+	Message string `json:"message,omitempty"`
+}
+
+// AppProbe is a protocol level readiness check of the application behind a service.
+type AppProbe struct {
+	// Service to probe, in the form "<name>" for a service in the test namespace or "<namespace>/<name>".
+	Service string `json:"service"`
+	// Port of the service to probe, the first port of the service if not set.
+	Port int32 `json:"port,omitempty"`
+	// Protocol of the application, one of "tcp" (the default), "postgres", "mysql", "redis" and "grpc".
+	Protocol string `json:"protocol,omitempty"`
+	// Banner is a regular expression the data a TCP server sends after the connection is established must match.
+	Banner string `json:"banner,omitempty"`
+	// GRPCService is the service whose health the standard gRPC health service reports, the server as a whole if not
+	// set.
+	GRPCService string `json:"grpcService,omitempty"`
+	// GRPCMethod is a unary method listed by server reflection which is called instead of checking the health, in the
+	// form "<package>.<Service>/<Method>".
+	GRPCMethod string `json:"grpcMethod,omitempty"`
+	// GRPCRequest is the JSON of the request message the method is called with, an empty message if not set.
+	GRPCRequest string `json:"grpcRequest,omitempty"`
+	// GRPCResponse is the JSON the response must contain, in the protobuf JSON mapping, ex. {"status": "SERVING"} which
+	// health checks expect by default.
+	GRPCResponse string `json:"grpcResponse,omitempty"`
+}
+
+// TestAssertGroup is a set of assertions which are evaluated together as part of an anyOf or allOf assertion.
+type TestAssertGroup struct {
+	// Files is a list of files or directories containing the expected objects of the group,
+	// relative to the test step directory. URLs are supported as well.
+	Files []string `json:"files,omitempty"`
+	// Commands is a set of commands to be run as assertions for the group.
+	Commands []TestAssertCommand `json:"commands,omitempty"`
+	// Override the timeout of the test step for the group (in seconds).
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// AssertSequence is a list of expected objects which must be observed in order.  The objects of their kinds are
+// watched from the start of the step, an expected object is observed when a watched object contains it like the
+// objects of assert files, ex. a Pod in the phase Pending or an Event with the reason Scheduled.
+type AssertSequence struct {
+	// Name of the sequence in messages, its number if not set.
+	Name string `json:"name,omitempty"`
+	// Files is a list of files or directories containing the expected objects of the sequence, relative to the test
+	// step directory.  They are expected in the order of the files and of the objects in the files.
+	Files []string `json:"files"`
 }
 
 // TestAssertCommand an assertion based on the result of the execution of a command
@@ -155,6 +1014,12 @@ type TestAssertCommand struct {
 	Script string `json:"script"`
 	// If set, the output from the command is NOT logged.  Useful for sensitive logs or to reduce noise.
 	SkipLogOutput bool `json:"skipLogOutput"`
+	// Output is when and how much of the output of the command is logged, it is logged as it is written if not set.
+	// SkipLogOutput takes precedence.
+	Output *CommandOutput `json:"output,omitempty"`
+	// Platforms the command runs on, in the form "<os>" or "<os>/<arch>" (ex. "linux", "darwin/arm64", "windows").
+	// On other platforms the command is skipped.  If empty, the command runs on all platforms.
+	Platforms []string `json:"platforms,omitempty"`
 }
 
 // ObjectReference is a Kubernetes object reference with added labels to allow referencing
@@ -163,6 +1028,11 @@ type ObjectReference struct {
 	corev1.ObjectReference `json:",inline"`
 	// Labels to match on.
 	Labels map[string]string `json:"labels"`
+	// FieldSelector of the objects to delete if no name is set (ex. "status.phase=Succeeded").
+	FieldSelector string `json:"fieldSelector,omitempty"`
+	// All deletes the matching objects with a single DeleteAllOf request of the kind instead of deleting them one by
+	// one, it cannot be used with a name.
+	All bool `json:"all,omitempty"`
 }
 
 // Command describes a command to run as a part of a test step or suite.
@@ -183,6 +1053,24 @@ type Command struct {
 	Timeout int `json:"timeout"`
 	// If set, the output from the command is NOT logged.  Useful for sensitive logs or to reduce noise.
 	SkipLogOutput bool `json:"skipLogOutput"`
+	// Output is when and how much of the output of the command is logged, it is logged as it is written if not set.
+	// SkipLogOutput takes precedence.
+	Output *CommandOutput `json:"output,omitempty"`
+	// Platforms the command runs on, in the form "<os>" or "<os>/<arch>" (ex. "linux", "darwin/arm64", "windows").
+	// On other platforms the command is skipped.  If empty, the command runs on all platforms.
+	Platforms []string `json:"platforms,omitempty"`
+}
+
+// CommandOutput is the logging policy of the output of a command, ex. to keep the output of noisy commands out of the
+// logs unless they fail.
+type CommandOutput struct {
+	// Log is when the output is logged: "always" (the default) as it is written, "onFailure" once the command ends if
+	// it fails, or "summaryOnly" once the command ends as its first and last lines.
+	Log string `json:"log,omitempty"`
+	// Head is the number of the first lines of the output logged with summaryOnly, 10 if not set.
+	Head int `json:"head,omitempty"`
+	// Tail is the number of the last lines of the output logged with summaryOnly, 10 if not set.
+	Tail int `json:"tail,omitempty"`
 }
 
 // TestCollector are post assert / error commands that allow for the collection of information sent to the test log.
@@ -211,9 +1099,207 @@ type TestCollector struct {
 	Cmd string `json:"command,omitempty"`
 }
 
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TestRun runs a test suite inside of the cluster with the kuttl operator, on demand or on a schedule.
+// The results of the last run are written to its status.
+type TestRun struct {
+	// The type meta object, should always be a GVK of kuttl.dev/v1beta1/TestRun.
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TestRunSpec   `json:"spec"`
+	Status TestRunStatus `json:"status,omitempty"`
+}
+
+// TestRunSpec defines the tests to run and when to run them.
+type TestRunSpec struct {
+	// Source is where the tests are loaded from.
+	Source TestRunSource `json:"source"`
+	// Suite is the name of a TestSuite in the namespace of the TestRun to configure the run with.
+	// If not set, the kuttl-test.yaml of the source is used if it exists.
+	Suite string `json:"suite,omitempty"`
+	// Interval is the time between the start of two runs (ex. "6h").  If not set, the tests run once.
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Trigger can be set to any new value to run the tests again on demand.
+	Trigger string `json:"trigger,omitempty"`
+	// Args are additional arguments for `kubectl kuttl test` (ex. "--parallel=2").
+	Args []string `json:"args,omitempty"`
+}
+
+// TestRunSource is the location of the tests of a TestRun. Exactly one of ConfigMap or Git must be set.
+type TestRunSource struct {
+	// ConfigMap is the name of a ConfigMap in the namespace of the TestRun containing the test files.
+	// Each key is a file of the form "<test>__<file>" (ex. "my-test__00-install.yaml"), keys without "__" are
+	// placed in the root of the suite (ex. "kuttl-test.yaml").
+	ConfigMap string `json:"configMap,omitempty"`
+	// Git is a git repository containing the tests.
+	Git *GitSource `json:"git,omitempty"`
+}
+
+// GitSource is a git repository containing a test suite.
+type GitSource struct {
+	// Repository is the URL of the repository to clone.
+	Repository string `json:"repository"`
+	// Revision is the branch or tag to check out, the default branch of the repository is used if not set.
+	Revision string `json:"revision,omitempty"`
+	// Path is the directory of the test suite in the repository, relative to its root.
+	Path string `json:"path,omitempty"`
+}
+
+// DuplicateObjectPolicy is what happens when an object is applied more than once by a test step.
+type DuplicateObjectPolicy string
+
+const (
+	// DuplicateObjectsError fails loading the test.
+	DuplicateObjectsError DuplicateObjectPolicy = "error"
+	// DuplicateObjectsLastWins applies only the last definition of the object.
+	DuplicateObjectsLastWins DuplicateObjectPolicy = "lastWins"
+	// DuplicateObjectsApplyAll applies every definition of the object in order.
+	DuplicateObjectsApplyAll DuplicateObjectPolicy = "applyAll"
+)
+
+// TestRunPhase is the phase of a TestRun.
+type TestRunPhase string
+
+const (
+	// TestRunPending is the phase of a TestRun which has not run yet.
+	TestRunPending TestRunPhase = "Pending"
+	// TestRunRunning is the phase of a TestRun whose tests are running.
+	TestRunRunning TestRunPhase = "Running"
+	// TestRunSucceeded is the phase of a TestRun whose last run passed.
+	TestRunSucceeded TestRunPhase = "Succeeded"
+	// TestRunFailed is the phase of a TestRun whose last run had failing tests.
+	TestRunFailed TestRunPhase = "Failed"
+	// TestRunError is the phase of a TestRun whose last run could not be started or did not produce a report.
+	TestRunError TestRunPhase = "Error"
+)
+
+// TestRunStatus is the result of the last run of a TestRun.
+type TestRunStatus struct {
+	// Phase is the phase of the TestRun.
+	Phase TestRunPhase `json:"phase,omitempty"`
+	// ObservedGeneration is the generation of the TestRun the last run was started for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// StartTime is the time the last run started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the last run finished.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Message describes why the last run failed to run.
+	Message string `json:"message,omitempty"`
+	// Tests is the number of tests of the last run.
+	Tests int `json:"tests,omitempty"`
+	// Failures is the number of failed tests of the last run.
+	Failures int `json:"failures,omitempty"`
+	// Results are the results of each test of the last run.
+	Results []TestResult `json:"results,omitempty"`
+}
+
+// TestResult is the result of a single test of a TestRun.
+type TestResult struct {
+	// Name is the name of the test.
+	Name string `json:"name"`
+	// Passed is true if the test passed.
+	Passed bool `json:"passed"`
+	// Duration is the duration of the test in seconds.
+	Duration string `json:"duration,omitempty"`
+	// Failure is the failure message of a failed test.
+	Failure string `json:"failure,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TestRunList is a list of TestRuns.
+type TestRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []TestRun `json:"items"`
+}
+
 // DefaultKINDContext defines the default kind context to use.
 const DefaultKINDContext = "kind"
 
+// HelperPodLabel is the label of pods created by test commands whose traffic is allowed by the NetworkPolicy created
+// with AllowHelperPodTraffic, it must be set to "true".
+const HelperPodLabel = "kuttl.dev/helper"
+
+// OwnedByAnnotation can be set on an object in an assert or errors file to require that the matching object is owned
+// by other objects, in the form "<kind>/<name>" (ex. "ReplicaSet/my-replicaset").  Multiple owners are separated by commas.
+// The owner UIDs are resolved at assert time, the annotation itself is not compared.
+const OwnedByAnnotation = "kuttl.dev/owned-by"
+
+// CountAnnotation can be set on an object without a name in an assert or errors file to require that exactly the
+// given number of objects match it (ex. "3").  The labels of the object are used as the selector.  It is checked on
+// every retry of the assert, the annotation itself is not compared.
+const CountAnnotation = "kuttl.dev/count"
+
+// SubresourceAnnotation can be set on an object in an assert or errors file to compare the object with the
+// representation of a subresource of the matching objects instead (ex. "scale").  The kind and name of the object are
+// used to look up the objects, its apiVersion, kind, labels and the annotation itself are not compared.
+const SubresourceAnnotation = "kuttl.dev/subresource"
+
+// TimeoutAnnotation can be set on an object in an assert file to override the timeout of the test step for the object
+// (in seconds, ex. "300").  The step fails as soon as an object does not match within its own timeout, and retries for
+// as long as the longest timeout of its objects.  It is ignored on the objects of assertion groups, which have their own
+// timeout, and the annotation itself is not compared.
+const TimeoutAnnotation = "kuttl.dev/timeout"
+
+// StableForAnnotation can be set on an object with a name in an assert file to require that the matching object does
+// not change for the given number of seconds (ex. "60") once the asserts of the test step pass, ex. to catch operators
+// which continuously rewrite objects.  Its resourceVersion is compared unless StableFieldAnnotation is set, the step
+// runs for the longest window of its objects and the annotation itself is not compared.
+const StableForAnnotation = "kuttl.dev/stable-for"
+
+// StableFieldAnnotation can be set with StableForAnnotation to compare the "generation" of the object instead of its
+// "resourceVersion", so that updates of its status are allowed.
+const StableFieldAnnotation = "kuttl.dev/stable-field"
+
+// ParseDataAnnotation can be set on a ConfigMap or Secret in an assert or errors file to compare the content of the
+// given data keys as parsed documents instead of strings, so that whitespace and the order of keys do not matter and
+// the expected content may be a subset of the actual one (ex. "config.yaml,app.properties").  The format is derived
+// from the extension of the key (yaml, yml, json, ini or properties) or given as "<key>=<format>", and the annotation
+// itself is not compared.
+const ParseDataAnnotation = "kuttl.dev/parse-data"
+
+// TerminatingAnnotation can be set on an object in an assert or errors file to require that the matching object is
+// being deleted ("true"), i.e. has a deletionTimestamp, or is not ("false"), whatever the time of the deletion.  The
+// annotation itself is not compared.
+const TerminatingAnnotation = "kuttl.dev/terminating"
+
+// FinalizersAnnotation can be set on an object in an assert or errors file to require that the matching object holds
+// the given finalizers, separated by commas, and does not hold those prefixed with "!" (ex.
+// "example.com/protection,!example.com/cleanup"), whatever its other finalizers.  The annotation itself is not
+// compared.
+const FinalizersAnnotation = "kuttl.dev/finalizers"
+
+// ConvertVersionAnnotation can be set on an object in an assert or errors file whose apiVersion is not served by the
+// server ("true"), ex. during an API migration, to compare it in the preferred version of the server instead of
+// failing.  Only the apiVersion of the object is replaced, its fields are compared as they are, and the annotation
+// itself is not compared.
+const ConvertVersionAnnotation = "kuttl.dev/convert-version"
+
+// RolledOutAnnotation can be set on a Deployment, StatefulSet or DaemonSet in an assert file to require that the
+// rollout of the matching object is complete ("true"), like `kubectl rollout status`, whatever its number of
+// replicas.  The annotation itself is not compared.
+const RolledOutAnnotation = "kuttl.dev/rolled-out"
+
+// ReadyAnnotation can be set on an Ingress, a Gateway or a route of the Gateway API (ex. an HTTPRoute) in an assert
+// file to require that the matching object is ready ("true"): an Ingress has an assigned address, a Gateway is
+// Accepted and Programmed, and a route is Accepted with ResolvedRefs by all its parents.  The annotation itself is not
+// compared.
+const ReadyAnnotation = "kuttl.dev/ready"
+
+// AddressAssignedAnnotation can be set on an Ingress or Gateway in an assert file to require that the matching object
+// has an assigned address ("true"), whatever the address is.  The annotation itself is not compared.
+const AddressAssignedAnnotation = "kuttl.dev/address-assigned"
+
+// ReachableAnnotation can be set on an Ingress or Gateway in an assert file to require that a GET request of the path
+// (ex. "/healthz") through the first assigned address of the matching object succeeds with a 2xx or 3xx status.  The
+// request is sent by kuttl to the host of the first rule of an Ingress or the first HTTP or HTTPS listener of a
+// Gateway.  The annotation itself is not compared.
+const ReachableAnnotation = "kuttl.dev/reachable"
+
 func (in *RestConfig) DeepCopyInto(out *RestConfig) {
 	out.RC = rest.CopyConfig(in.RC)
 }
@@ -20,96 +20,1210 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppProbe) DeepCopyInto(out *AppProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppProbe.
+func (in *AppProbe) DeepCopy() *AppProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(AppProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AssertEscalation) DeepCopyInto(out *AssertEscalation) {
+	*out = *in
+	if in.Collectors != nil {
+		in, out := &in.Collectors, &out.Collectors
+		*out = make([]*TestCollector, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(TestCollector)
+				**out = **in
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssertEscalation.
+func (in *AssertEscalation) DeepCopy() *AssertEscalation {
+	if in == nil {
+		return nil
+	}
+	out := new(AssertEscalation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AssertSequence) DeepCopyInto(out *AssertSequence) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssertSequence.
+func (in *AssertSequence) DeepCopy() *AssertSequence {
+	if in == nil {
+		return nil
+	}
+	out := new(AssertSequence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerCheck) DeepCopyInto(out *AutoscalerCheck) {
+	*out = *in
+	if in.Load != nil {
+		in, out := &in.Load, &out.Load
+		*out = new(AutoscalerLoad)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerCheck.
+func (in *AutoscalerCheck) DeepCopy() *AutoscalerCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerLoad) DeepCopyInto(out *AutoscalerLoad) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerLoad.
+func (in *AutoscalerLoad) DeepCopy() *AutoscalerLoad {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerLoad)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterProvider) DeepCopyInto(out *ClusterProvider) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProvider.
+func (in *ClusterProvider) DeepCopy() *ClusterProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSnapshot) DeepCopyInto(out *ClusterSnapshot) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]v1.TypeMeta, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ignore != nil {
+		in, out := &in.Ignore, &out.Ignore
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSnapshot.
+func (in *ClusterSnapshot) DeepCopy() *ClusterSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Command) DeepCopyInto(out *Command) {
 	*out = *in
+	if in.Output != nil {
+		in, out := &in.Output, &out.Output
+		*out = new(CommandOutput)
+		**out = **in
+	}
+	if in.Platforms != nil {
+		in, out := &in.Platforms, &out.Platforms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Command.
+func (in *Command) DeepCopy() *Command {
+	if in == nil {
+		return nil
+	}
+	out := new(Command)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommandOutput) DeepCopyInto(out *CommandOutput) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommandOutput.
+func (in *CommandOutput) DeepCopy() *CommandOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(CommandOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyGroup) DeepCopyInto(out *ConcurrencyGroup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyGroup.
+func (in *ConcurrencyGroup) DeepCopy() *ConcurrencyGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CopyFrom) DeepCopyInto(out *CopyFrom) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CopyFrom.
+func (in *CopyFrom) DeepCopy() *CopyFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(CopyFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventJournal) DeepCopyInto(out *EventJournal) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]v1.TypeMeta, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventJournal.
+func (in *EventJournal) DeepCopy() *EventJournal {
+	if in == nil {
+		return nil
+	}
+	out := new(EventJournal)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Eviction) DeepCopyInto(out *Eviction) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Eviction.
+func (in *Eviction) DeepCopy() *Eviction {
+	if in == nil {
+		return nil
+	}
+	out := new(Eviction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpectError) DeepCopyInto(out *ExpectError) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpectError.
+func (in *ExpectError) DeepCopy() *ExpectError {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpectError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpectedFailure) DeepCopyInto(out *ExpectedFailure) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpectedFailure.
+func (in *ExpectedFailure) DeepCopy() *ExpectedFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpectedFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpressionAssertion) DeepCopyInto(out *ExpressionAssertion) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpressionAssertion.
+func (in *ExpressionAssertion) DeepCopy() *ExpressionAssertion {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpressionAssertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldAssertion) DeepCopyInto(out *FieldAssertion) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldAssertion.
+func (in *FieldAssertion) DeepCopy() *FieldAssertion {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldAssertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsSync) DeepCopyInto(out *GitOpsSync) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsSync.
+func (in *GitOpsSync) DeepCopy() *GitOpsSync {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSource) DeepCopyInto(out *GitSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSource.
+func (in *GitSource) DeepCopy() *GitSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartTests) DeepCopyInto(out *HelmChartTests) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartTests.
+func (in *HelmChartTests) DeepCopy() *HelmChartTests {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartTests)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelperPodScheduling) DeepCopyInto(out *HelperPodScheduling) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelperPodScheduling.
+func (in *HelperPodScheduling) DeepCopy() *HelperPodScheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(HelperPodScheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity) DeepCopyInto(out *Identity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity.
+func (in *Identity) DeepCopy() *Identity {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageBuild) DeepCopyInto(out *ImageBuild) {
+	*out = *in
+	if in.BuildArgs != nil {
+		in, out := &in.BuildArgs, &out.BuildArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBuild.
+func (in *ImageBuild) DeepCopy() *ImageBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KINDFixture) DeepCopyInto(out *KINDFixture) {
+	*out = *in
+	if in.Manifests != nil {
+		in, out := &in.Manifests, &out.Manifests
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KINDFixture.
+func (in *KINDFixture) DeepCopy() *KINDFixture {
+	if in == nil {
+		return nil
+	}
+	out := new(KINDFixture)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogStream) DeepCopyInto(out *LogStream) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogStream.
+func (in *LogStream) DeepCopy() *LogStream {
+	if in == nil {
+		return nil
+	}
+	out := new(LogStream)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockGRPC) DeepCopyInto(out *MockGRPC) {
+	*out = *in
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]MockGRPCMethod, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockGRPC.
+func (in *MockGRPC) DeepCopy() *MockGRPC {
+	if in == nil {
+		return nil
+	}
+	out := new(MockGRPC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockGRPCMethod) DeepCopyInto(out *MockGRPCMethod) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockGRPCMethod.
+func (in *MockGRPCMethod) DeepCopy() *MockGRPCMethod {
+	if in == nil {
+		return nil
+	}
+	out := new(MockGRPCMethod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockRoute) DeepCopyInto(out *MockRoute) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockRoute.
+func (in *MockRoute) DeepCopy() *MockRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(MockRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockService) DeepCopyInto(out *MockService) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]MockRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(MockGRPC)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockService.
+func (in *MockService) DeepCopy() *MockService {
+	if in == nil {
+		return nil
+	}
+	out := new(MockService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceQuota) DeepCopyInto(out *NamespaceQuota) {
+	*out = *in
+	if in.Hard != nil {
+		in, out := &in.Hard, &out.Hard
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make([]corev1.LimitRangeItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceQuota.
+func (in *NamespaceQuota) DeepCopy() *NamespaceQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeChange) DeepCopyInto(out *NodeChange) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RemoveLabels != nil {
+		in, out := &in.RemoveLabels, &out.RemoveLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]corev1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RemoveTaints != nil {
+		in, out := &in.RemoveTaints, &out.RemoveTaints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Cordon != nil {
+		in, out := &in.Cordon, &out.Cordon
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeChange.
+func (in *NodeChange) DeepCopy() *NodeChange {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
+	*out = *in
+	out.ObjectReference = in.ObjectReference
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectReference.
+func (in *ObjectReference) DeepCopy() *ObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Overlay) DeepCopyInto(out *Overlay) {
+	*out = *in
+	out.Target = in.Target
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Overlay.
+func (in *Overlay) DeepCopy() *Overlay {
+	if in == nil {
+		return nil
+	}
+	out := new(Overlay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverlayTarget) DeepCopyInto(out *OverlayTarget) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverlayTarget.
+func (in *OverlayTarget) DeepCopy() *OverlayTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(OverlayTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Preconditions) DeepCopyInto(out *Preconditions) {
+	*out = *in
+	if in.CRDs != nil {
+		in, out := &in.CRDs, &out.CRDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StorageClasses != nil {
+		in, out := &in.StorageClasses, &out.StorageClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Preconditions.
+func (in *Preconditions) DeepCopy() *Preconditions {
+	if in == nil {
+		return nil
+	}
+	out := new(Preconditions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestConfig.
+func (in *RestConfig) DeepCopy() *RestConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RestConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Restart) DeepCopyInto(out *Restart) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Restart.
+func (in *Restart) DeepCopy() *Restart {
+	if in == nil {
+		return nil
+	}
+	out := new(Restart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafeMode) DeepCopyInto(out *SafeMode) {
+	*out = *in
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafeMode.
+func (in *SafeMode) DeepCopy() *SafeMode {
+	if in == nil {
+		return nil
+	}
+	out := new(SafeMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Secret) DeepCopyInto(out *Secret) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Secret.
+func (in *Secret) DeepCopy() *Secret {
+	if in == nil {
+		return nil
+	}
+	out := new(Secret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerSideApply) DeepCopyInto(out *ServerSideApply) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerSideApply.
+func (in *ServerSideApply) DeepCopy() *ServerSideApply {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerSideApply)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountToken) DeepCopyInto(out *ServiceAccountToken) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountToken.
+func (in *ServiceAccountToken) DeepCopy() *ServiceAccountToken {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestAssert) DeepCopyInto(out *TestAssert) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Collectors != nil {
+		in, out := &in.Collectors, &out.Collectors
+		*out = make([]*TestCollector, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(TestCollector)
+				**out = **in
+			}
+		}
+	}
+	if in.Escalation != nil {
+		in, out := &in.Escalation, &out.Escalation
+		*out = new(AssertEscalation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Commands != nil {
+		in, out := &in.Commands, &out.Commands
+		*out = make([]TestAssertCommand, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FieldAssertions != nil {
+		in, out := &in.FieldAssertions, &out.FieldAssertions
+		*out = make([]FieldAssertion, len(*in))
+		copy(*out, *in)
+	}
+	if in.Expressions != nil {
+		in, out := &in.Expressions, &out.Expressions
+		*out = make([]ExpressionAssertion, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]TestAssertGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]TestAssertGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sequences != nil {
+		in, out := &in.Sequences, &out.Sequences
+		*out = make([]AssertSequence, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = make([]AppProbe, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpectErrors != nil {
+		in, out := &in.ExpectErrors, &out.ExpectErrors
+		*out = make([]ExpectError, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestAssert.
+func (in *TestAssert) DeepCopy() *TestAssert {
+	if in == nil {
+		return nil
+	}
+	out := new(TestAssert)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TestAssert) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestAssertCommand) DeepCopyInto(out *TestAssertCommand) {
+	*out = *in
+	if in.Output != nil {
+		in, out := &in.Output, &out.Output
+		*out = new(CommandOutput)
+		**out = **in
+	}
+	if in.Platforms != nil {
+		in, out := &in.Platforms, &out.Platforms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestAssertCommand.
+func (in *TestAssertCommand) DeepCopy() *TestAssertCommand {
+	if in == nil {
+		return nil
+	}
+	out := new(TestAssertCommand)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestAssertGroup) DeepCopyInto(out *TestAssertGroup) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Commands != nil {
+		in, out := &in.Commands, &out.Commands
+		*out = make([]TestAssertCommand, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestAssertGroup.
+func (in *TestAssertGroup) DeepCopy() *TestAssertGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(TestAssertGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestCase) DeepCopyInto(out *TestCase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]TestCaseStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestCase.
+func (in *TestCase) DeepCopy() *TestCase {
+	if in == nil {
+		return nil
+	}
+	out := new(TestCase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TestCase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestCaseStep) DeepCopyInto(out *TestCaseStep) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestCaseStep.
+func (in *TestCaseStep) DeepCopy() *TestCaseStep {
+	if in == nil {
+		return nil
+	}
+	out := new(TestCaseStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestCollector) DeepCopyInto(out *TestCollector) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestCollector.
+func (in *TestCollector) DeepCopy() *TestCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(TestCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestFile) DeepCopyInto(out *TestFile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.TestRunSelector != nil {
+		in, out := &in.TestRunSelector, &out.TestRunSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Step != nil {
+		in, out := &in.Step, &out.Step
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestFile.
+func (in *TestFile) DeepCopy() *TestFile {
+	if in == nil {
+		return nil
+	}
+	out := new(TestFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TestFile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestMetadata) DeepCopyInto(out *TestMetadata) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Command.
-func (in *Command) DeepCopy() *Command {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestMetadata.
+func (in *TestMetadata) DeepCopy() *TestMetadata {
 	if in == nil {
 		return nil
 	}
-	out := new(Command)
+	out := new(TestMetadata)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TestMetadata) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
+func (in *TestResult) DeepCopyInto(out *TestResult) {
 	*out = *in
-	out.ObjectReference = in.ObjectReference
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectReference.
-func (in *ObjectReference) DeepCopy() *ObjectReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestResult.
+func (in *TestResult) DeepCopy() *TestResult {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectReference)
+	out := new(TestResult)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestConfig.
-func (in *RestConfig) DeepCopy() *RestConfig {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestRun) DeepCopyInto(out *TestRun) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestRun.
+func (in *TestRun) DeepCopy() *TestRun {
 	if in == nil {
 		return nil
 	}
-	out := new(RestConfig)
+	out := new(TestRun)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TestRun) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TestAssert) DeepCopyInto(out *TestAssert) {
+func (in *TestRunList) DeepCopyInto(out *TestRunList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	if in.Collectors != nil {
-		in, out := &in.Collectors, &out.Collectors
-		*out = make([]*TestCollector, len(*in))
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TestRun, len(*in))
 		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = new(TestCollector)
-				**out = **in
-			}
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Commands != nil {
-		in, out := &in.Commands, &out.Commands
-		*out = make([]TestAssertCommand, len(*in))
-		copy(*out, *in)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestAssert.
-func (in *TestAssert) DeepCopy() *TestAssert {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestRunList.
+func (in *TestRunList) DeepCopy() *TestRunList {
 	if in == nil {
 		return nil
 	}
-	out := new(TestAssert)
+	out := new(TestRunList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *TestAssert) DeepCopyObject() runtime.Object {
+func (in *TestRunList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -117,73 +1231,92 @@ func (in *TestAssert) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TestAssertCommand) DeepCopyInto(out *TestAssertCommand) {
+func (in *TestRunSource) DeepCopyInto(out *TestRunSource) {
 	*out = *in
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(GitSource)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestAssertCommand.
-func (in *TestAssertCommand) DeepCopy() *TestAssertCommand {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestRunSource.
+func (in *TestRunSource) DeepCopy() *TestRunSource {
 	if in == nil {
 		return nil
 	}
-	out := new(TestAssertCommand)
+	out := new(TestRunSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TestCollector) DeepCopyInto(out *TestCollector) {
+func (in *TestRunSpec) DeepCopyInto(out *TestRunSpec) {
 	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestCollector.
-func (in *TestCollector) DeepCopy() *TestCollector {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestRunSpec.
+func (in *TestRunSpec) DeepCopy() *TestRunSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(TestCollector)
+	out := new(TestRunSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TestFile) DeepCopyInto(out *TestFile) {
+func (in *TestRunStatus) DeepCopyInto(out *TestRunStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	if in.TestRunSelector != nil {
-		in, out := &in.TestRunSelector, &out.TestRunSelector
-		*out = new(v1.LabelSelector)
-		(*in).DeepCopyInto(*out)
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]TestResult, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestFile.
-func (in *TestFile) DeepCopy() *TestFile {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestRunStatus.
+func (in *TestRunStatus) DeepCopy() *TestRunStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(TestFile)
+	out := new(TestRunStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *TestFile) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TestStep) DeepCopyInto(out *TestStep) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Apply != nil {
 		in, out := &in.Apply, &out.Apply
 		*out = make([]string, len(*in))
@@ -199,6 +1332,11 @@ func (in *TestStep) DeepCopyInto(out *TestStep) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ServerSideApply != nil {
+		in, out := &in.ServerSideApply, &out.ServerSideApply
+		*out = new(ServerSideApply)
+		**out = **in
+	}
 	if in.Delete != nil {
 		in, out := &in.Delete, &out.Delete
 		*out = make([]ObjectReference, len(*in))
@@ -209,6 +1347,108 @@ func (in *TestStep) DeepCopyInto(out *TestStep) {
 	if in.Commands != nil {
 		in, out := &in.Commands, &out.Commands
 		*out = make([]Command, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Cleanup != nil {
+		in, out := &in.Cleanup, &out.Cleanup
+		*out = make([]Command, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Identity != nil {
+		in, out := &in.Identity, &out.Identity
+		*out = new(Identity)
+		**out = **in
+	}
+	if in.ServiceAccountTokens != nil {
+		in, out := &in.ServiceAccountTokens, &out.ServiceAccountTokens
+		*out = make([]ServiceAccountToken, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CopyFrom != nil {
+		in, out := &in.CopyFrom, &out.CopyFrom
+		*out = make([]CopyFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.WorkloadClusters != nil {
+		in, out := &in.WorkloadClusters, &out.WorkloadClusters
+		*out = make([]WorkloadCluster, len(*in))
+		copy(*out, *in)
+	}
+	if in.MockServices != nil {
+		in, out := &in.MockServices, &out.MockServices
+		*out = make([]MockService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PruneKinds != nil {
+		in, out := &in.PruneKinds, &out.PruneKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PruneSelector != nil {
+		in, out := &in.PruneSelector, &out.PruneSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Preconditions != nil {
+		in, out := &in.Preconditions, &out.Preconditions
+		*out = new(Preconditions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConcurrencyGroups != nil {
+		in, out := &in.ConcurrencyGroups, &out.ConcurrencyGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = new(WarningAssertions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Syncs != nil {
+		in, out := &in.Syncs, &out.Syncs
+		*out = make([]GitOpsSync, len(*in))
+		copy(*out, *in)
+	}
+	if in.Restarts != nil {
+		in, out := &in.Restarts, &out.Restarts
+		*out = make([]Restart, len(*in))
+		copy(*out, *in)
+	}
+	if in.Evictions != nil {
+		in, out := &in.Evictions, &out.Evictions
+		*out = make([]Eviction, len(*in))
+		copy(*out, *in)
+	}
+	if in.Autoscalers != nil {
+		in, out := &in.Autoscalers, &out.Autoscalers
+		*out = make([]AutoscalerCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpectedFailure != nil {
+		in, out := &in.ExpectedFailure, &out.ExpectedFailure
+		*out = new(ExpectedFailure)
+		**out = **in
+	}
+	if in.Overlays != nil {
+		in, out := &in.Overlays, &out.Overlays
+		*out = make([]Overlay, len(*in))
 		copy(*out, *in)
 	}
 	return
@@ -247,6 +1487,23 @@ func (in *TestSuite) DeepCopyInto(out *TestSuite) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.HelmCharts != nil {
+		in, out := &in.HelmCharts, &out.HelmCharts
+		*out = make([]HelmChartTests, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tests != nil {
+		in, out := &in.Tests, &out.Tests
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkipTests != nil {
+		in, out := &in.SkipTests, &out.SkipTests
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.ControlPlaneArgs != nil {
 		in, out := &in.ControlPlaneArgs, &out.ControlPlaneArgs
 		*out = make([]string, len(*in))
@@ -257,9 +1514,54 @@ func (in *TestSuite) DeepCopyInto(out *TestSuite) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.KINDBuilds != nil {
+		in, out := &in.KINDBuilds, &out.KINDBuilds
+		*out = make([]ImageBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.KINDFixtures != nil {
+		in, out := &in.KINDFixtures, &out.KINDFixtures
+		*out = make([]KINDFixture, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.KINDFeatureGates != nil {
+		in, out := &in.KINDFeatureGates, &out.KINDFeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KINDRuntimeConfig != nil {
+		in, out := &in.KINDRuntimeConfig, &out.KINDRuntimeConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StartCluster != nil {
+		in, out := &in.StartCluster, &out.StartCluster
+		*out = new(ClusterProvider)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConcurrencyGroups != nil {
+		in, out := &in.ConcurrencyGroups, &out.ConcurrencyGroups
+		*out = make([]ConcurrencyGroup, len(*in))
+		copy(*out, *in)
+	}
 	if in.Commands != nil {
 		in, out := &in.Commands, &out.Commands
 		*out = make([]Command, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 	if in.Suppress != nil {
@@ -267,6 +1569,65 @@ func (in *TestSuite) DeepCopyInto(out *TestSuite) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NamespaceQuota != nil {
+		in, out := &in.NamespaceQuota, &out.NamespaceQuota
+		*out = new(NamespaceQuota)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HelperPods != nil {
+		in, out := &in.HelperPods, &out.HelperPods
+		*out = new(HelperPodScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequiredImages != nil {
+		in, out := &in.RequiredImages, &out.RequiredImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StepPlugins != nil {
+		in, out := &in.StepPlugins, &out.StepPlugins
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.JsonnetVars != nil {
+		in, out := &in.JsonnetVars, &out.JsonnetVars
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]Secret, len(*in))
+		copy(*out, *in)
+	}
+	if in.LogStreams != nil {
+		in, out := &in.LogStreams, &out.LogStreams
+		*out = make([]LogStream, len(*in))
+		copy(*out, *in)
+	}
+	if in.EventJournal != nil {
+		in, out := &in.EventJournal, &out.EventJournal
+		*out = new(EventJournal)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SafeMode != nil {
+		in, out := &in.SafeMode, &out.SafeMode
+		*out = new(SafeMode)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Overlays != nil {
+		in, out := &in.Overlays, &out.Overlays
+		*out = make([]Overlay, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSnapshot != nil {
+		in, out := &in.ClusterSnapshot, &out.ClusterSnapshot
+		*out = new(ClusterSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = (*in).DeepCopy()
@@ -291,3 +1652,50 @@ func (in *TestSuite) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarningAssertions) DeepCopyInto(out *WarningAssertions) {
+	*out = *in
+	if in.Expected != nil {
+		in, out := &in.Expected, &out.Expected
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unexpected != nil {
+		in, out := &in.Unexpected, &out.Unexpected
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailOnDeprecatedAPIs != nil {
+		in, out := &in.FailOnDeprecatedAPIs, &out.FailOnDeprecatedAPIs
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarningAssertions.
+func (in *WarningAssertions) DeepCopy() *WarningAssertions {
+	if in == nil {
+		return nil
+	}
+	out := new(WarningAssertions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadCluster) DeepCopyInto(out *WorkloadCluster) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadCluster.
+func (in *WorkloadCluster) DeepCopy() *WorkloadCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadCluster)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -10,29 +10,44 @@ import (
 
 var (
 	assertExample = `  # Asserts against a $KUBECONFIG cluster the values defined in the assert file.
-  kubectl kuttl assert <path/to/assertfile.yaml>`
+  kubectl kuttl assert <path/to/assertfile.yaml>
+
+  # Asserts the values defined in the assert file against the fixture objects of a directory, without a cluster.
+  kubectl kuttl assert --offline --against fixtures/ <path/to/assertfile.yaml>`
 )
 
 // newAssertCmd returns a new initialized instance of the assert sub command
 func newAssertCmd() *cobra.Command {
 	timeout := 5
 	namespace := "default"
+	offline := false
+	against := []string{}
 
 	assertCmd := &cobra.Command{
-		Use:     "assert",
-		Short:   "Asserts the declared state to be true.",
-		Long:    `Asserts the declared state provided as an argument to be true in the $KUBECONFIG cluster. Valid arguments are a YAML file, URL to a YAML file.`,
+		Use:   "assert",
+		Short: "Asserts the declared state to be true.",
+		Long: `Asserts the declared state provided as an argument to be true in the $KUBECONFIG cluster. Valid arguments are a YAML file, URL to a YAML file.
+With --offline the state is asserted once against a fake cluster holding the fixture objects of --against, ex. to unit
+test assert files without a cluster.`,
 		Example: assertExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.New("one file argument is required")
 			}
+			if offline != (len(against) > 0) {
+				return errors.New("--offline and --against must be used together")
+			}
+			if offline {
+				return test.AssertOffline(namespace, against, args...)
+			}
 			return test.Assert(namespace, timeout, args...)
 		},
 	}
 
 	assertCmd.Flags().IntVar(&timeout, "timeout", 5, "The timeout to use as default for TestSuite configuration.")
 	assertCmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Namespace to use for test assert.")
+	assertCmd.Flags().BoolVar(&offline, "offline", false, "Assert against a fake cluster holding the fixture objects of --against instead of the $KUBECONFIG cluster.")
+	assertCmd.Flags().StringSliceVar(&against, "against", []string{}, "Files or directories of the fixture objects to assert against with --offline, objects without a namespace are in --namespace.")
 
 	return assertCmd
 }
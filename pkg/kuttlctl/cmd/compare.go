@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+var (
+	compareExample = `  # Compares the reports of two runs, for example before and after bumping the Kubernetes version.
+  kubectl kuttl compare-runs <path/to/artifactsA> <path/to/artifactsB>
+
+  # Only report tests which are at least twice and 10 seconds slower as duration regressions.
+  kubectl kuttl compare-runs --duration-factor 2 --min-duration 10 <path/to/artifactsA> <path/to/artifactsB>`
+)
+
+// newCompareCmd returns a new initialized instance of the compare-runs sub command
+func newCompareCmd() *cobra.Command {
+	opts := report.CompareOptions{}
+
+	compareCmd := &cobra.Command{
+		Use:   "compare-runs",
+		Short: "Compares the reports of two test runs.",
+		Long: `Compares the reports of two test runs, highlighting new failures, changed failures and regressions in test duration.
+Valid arguments are artifacts directories containing a kuttl-report.json or kuttl-report.xml report, or report files.
+The command fails if the second run has new failures or duration regressions.`,
+		Example: compareExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("two artifacts directories or reports are required")
+			}
+
+			before, err := report.Load(args[0])
+			if err != nil {
+				return err
+			}
+			after, err := report.Load(args[1])
+			if err != nil {
+				return err
+			}
+
+			comparison := report.Compare(before, after, opts)
+			if err := comparison.Write(os.Stdout); err != nil {
+				return err
+			}
+			if comparison.HasRegressions() {
+				return errors.New("the second run has new failures or duration regressions")
+			}
+			return nil
+		},
+	}
+
+	compareCmd.Flags().Float64Var(&opts.DurationFactor, "duration-factor", 1.5, "The factor by which a test must be slower in the second run to be a duration regression.")
+	compareCmd.Flags().Float64Var(&opts.MinDuration, "min-duration", 5, "The number of seconds a test must be slower in the second run to be a duration regression.")
+
+	return compareCmd
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/test"
+)
+
+var (
+	completionExample = `  # Load the bash completions in the current shell
+  source <(kubectl kuttl completion bash)
+
+  # Install the zsh completions
+  kubectl kuttl completion zsh > "${fpath[1]}/_kubectl-kuttl"
+
+  # Install the fish completions
+  kubectl kuttl completion fish > ~/.config/fish/completions/kubectl-kuttl.fish`
+)
+
+// completionShells are the shells completion scripts can be generated for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// newCompletionCmd returns the completion command, which generates the shell completion script of the root command.
+func newCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   fmt.Sprintf("completion %s", strings.Join(completionShells, "|")),
+		Short: "Generate the shell completion script.",
+		Long: `Generate the completion script of kuttl for bash, zsh or fish.
+
+The test names of --test and --skip-test are completed with the tests of the test suite, which is loaded like by
+kuttl test from kuttl-test.yaml, --config, --suite or the test directories on the command line.`,
+		Example:               completionExample,
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs:             completionShells,
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			}
+			return fmt.Errorf("unknown shell %q, must be one of %s", args[0], strings.Join(completionShells, ", "))
+		},
+	}
+}
+
+// testNameCompletions returns the names of the tests of the suites starting with toComplete, described by their test
+// directories.
+func testNameCompletions(suites []harness.TestSuite, toComplete string) ([]string, error) {
+	dirs := map[string][]string{}
+	for _, suite := range suites {
+		// the patterns of the flags are being completed, all tests are candidates
+		suite.Tests, suite.SkipTests = nil, nil
+		listed, err := (&test.Harness{TestSuite: suite}).ListTests()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range listed {
+			if strings.HasPrefix(t.Name, toComplete) && !contains(dirs[t.Name], t.TestDir) {
+				dirs[t.Name] = append(dirs[t.Name], t.TestDir)
+			}
+		}
+	}
+
+	completions := make([]string, 0, len(dirs))
+	for name, testDirs := range dirs {
+		completions = append(completions, name+"\t"+strings.Join(testDirs, ", "))
+	}
+	sort.Strings(completions)
+	return completions, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	for _, shell := range completionShells {
+		root := NewKuttlCmd()
+		out := &bytes.Buffer{}
+		root.SetOut(out)
+		root.SetArgs([]string{"completion", shell})
+		require.NoError(t, root.Execute(), shell)
+		assert.Contains(t, out.String(), "kubectl-kuttl", shell)
+	}
+
+	root := NewKuttlCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"completion", "powershell"})
+	assert.EqualError(t, root.Execute(), `invalid argument "powershell" for "kubectl-kuttl completion"`)
+}
+
+func TestTestNameCompletions(t *testing.T) {
+	first, second := testDir(t, "install", "upgrade"), testDir(t, "upgrade-web")
+	suites := []harness.TestSuite{
+		{TestDirs: []string{first}, Tests: []string{"install"}},
+		{TestDirs: []string{second, first}},
+	}
+
+	completions, err := testNameCompletions(suites, "up")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"upgrade\t" + first, "upgrade-web\t" + second}, completions)
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/test"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// configFileName is the name of the test suite configuration files which are discovered.
+const configFileName = "kuttl-test.yaml"
+
+// suitePathFields are the fields of a TestSuite which hold paths.
+var suitePathFields = []string{"crdDir", "manifestDirs", "testDirs", "kindConfig", "artifactsDir", "fragmentsDir", "suppressionsFile"}
+
+// suiteItemPathFields are the fields of the list items of a TestSuite which hold paths, by the field of the list.  The
+// Dockerfile of an image build and the values of a chart are relative to the build context and the chart directory,
+// they are not rebased.
+var suiteItemPathFields = map[string][]string{
+	"kindBuilds":   {"context"},
+	"kindFixtures": {"manifests"},
+	"helmCharts":   {"chart"},
+}
+
+// discoverConfigs returns the configuration files found in dir and its parent directories, ordered from the outermost
+// to dir.  The parent directories are searched up to the root of the repository (the first directory containing .git).
+func discoverConfigs(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := []string{}
+	for {
+		path := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(path); err == nil {
+			configs = append([]string{path}, configs...)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return configs, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return configs, nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfigs loads the TestSuites of the configuration files and merges them in order, the fields set in a file
+// override the fields of the files before it.  Objects are merged recursively, all other values are replaced.
+// If rebase is set, the relative paths of a file are resolved relative to its directory.
+func loadConfigs(paths []string, rebase bool) (harness.TestSuite, error) {
+	merged := map[string]interface{}{}
+	for _, path := range paths {
+		suite, err := loadConfig(path)
+		if err != nil {
+			return harness.TestSuite{}, err
+		}
+		if suite == nil {
+			continue
+		}
+		if rebase {
+			if err := rebasePaths(suite, filepath.Dir(path)); err != nil {
+				return harness.TestSuite{}, err
+			}
+		}
+		mergeConfig(merged, suite)
+	}
+
+	if len(merged) == 0 {
+		return harness.TestSuite{}, nil
+	}
+
+	obj, err := testutils.ConvertUnstructured(&unstructured.Unstructured{Object: merged})
+	if err != nil {
+		return harness.TestSuite{}, fmt.Errorf("bad configuration in files %q: %w", paths, err)
+	}
+	ts, ok := obj.(*harness.TestSuite)
+	if !ok {
+		return harness.TestSuite{}, fmt.Errorf("bad configuration in files %q", paths)
+	}
+	return *ts, nil
+}
+
+// loadConfig returns the fields of the TestSuite in the configuration file path, it is nil if path has no TestSuite.
+func loadConfig(path string) (map[string]interface{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var suite map[string]interface{}
+	yamlReader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(raw)))
+	for {
+		data, err := yamlReader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return suite, nil
+			}
+			return nil, fmt.Errorf("error reading yaml %s: %w", path, err)
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := yaml.NewYAMLOrJSONDecoder(bytes.NewBuffer(data), len(data)).Decode(obj); err != nil {
+			return nil, fmt.Errorf("error decoding yaml %s: %w", path, err)
+		}
+
+		if kind := obj.GetKind(); kind != "TestSuite" {
+			log.Println(fmt.Errorf("unknown object type: %s", kind))
+			continue
+		}
+		suite = obj.Object
+	}
+}
+
+// rebasePaths resolves the relative paths of suite relative to dir.
+func rebasePaths(suite map[string]interface{}, dir string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	rebase := func(path string) string {
+		// the manifests of fixtures may be URLs
+		if path == "" || filepath.IsAbs(path) || strings.Contains(path, "://") {
+			return path
+		}
+		rebased, err := filepath.Rel(wd, filepath.Join(dir, path))
+		if err != nil {
+			return filepath.Join(dir, path)
+		}
+		return rebased
+	}
+
+	rebaseFields := func(obj map[string]interface{}, fields []string) {
+		for _, field := range fields {
+			switch value := obj[field].(type) {
+			case string:
+				obj[field] = rebase(value)
+			case []interface{}:
+				for i, path := range value {
+					if path, ok := path.(string); ok {
+						value[i] = rebase(path)
+					}
+				}
+			}
+		}
+	}
+
+	rebaseFields(suite, suitePathFields)
+	for list, fields := range suiteItemPathFields {
+		items, _ := suite[list].([]interface{})
+		for _, item := range items {
+			if item, ok := item.(map[string]interface{}); ok {
+				rebaseFields(item, fields)
+			}
+		}
+	}
+
+	// the executable of a plugin is rebased if it is a path, without a slash it is looked up on the PATH
+	plugins, _ := suite["stepPlugins"].(map[string]interface{})
+	for kind, command := range plugins {
+		command, ok := command.(string)
+		if !ok {
+			continue
+		}
+		executable, args, _ := strings.Cut(strings.TrimSpace(command), " ")
+		if !strings.ContainsRune(executable, '/') {
+			continue
+		}
+		rebased := rebase(executable)
+		if !strings.ContainsRune(rebased, '/') {
+			rebased = "./" + rebased
+		}
+		if args != "" {
+			rebased += " " + args
+		}
+		plugins[kind] = rebased
+	}
+	return nil
+}
+
+// mergeConfig merges src into dst recursively.
+func mergeConfig(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcOk := value.(map[string]interface{})
+		dstMap, dstOk := dst[key].(map[string]interface{})
+		if srcOk && dstOk {
+			mergeConfig(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// printConfig writes the effective configuration of the test suite as YAML, with the defaults of the harness applied.
+func printConfig(w io.Writer, suite harness.TestSuite) error {
+	h := test.Harness{TestSuite: suite}
+	effective := h.EffectiveTestSuite()
+	effective.SetGroupVersionKind(harness.GroupVersion.WithKind("TestSuite"))
+	return testutils.MarshalObject(&effective, w)
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, configFileName)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestDiscoverConfigs(t *testing.T) {
+	root := t.TempDir()
+	outside := writeConfig(t, root, "kind: TestSuite\n")
+	repo := filepath.Join(root, "repo")
+	assert.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	base := writeConfig(t, repo, "kind: TestSuite\n")
+	component := writeConfig(t, filepath.Join(repo, "component"), "kind: TestSuite\n")
+	nested := filepath.Join(repo, "component", "tests", "e2e")
+	assert.NoError(t, os.MkdirAll(nested, 0755))
+
+	configs, err := discoverConfigs(nested)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{base, component}, configs)
+	assert.NotContains(t, configs, outside)
+
+	configs, err = discoverConfigs(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{base}, configs)
+}
+
+func TestLoadConfigs(t *testing.T) {
+	root := t.TempDir()
+	base := writeConfig(t, root, `apiVersion: kuttl.dev/v1beta1
+kind: TestSuite
+testDirs:
+- ./e2e
+- /abs/e2e
+crdDir: crds
+timeout: 60
+parallel: 4
+kindFeatureGates:
+  A: true
+  B: true
+stepPlugins:
+  Chaos: ./bin/chaos
+  LoadTest: load-test --rate 10 ./profiles/smoke.yaml
+kindBuilds:
+- image: example.com/operator:dev
+  context: operator
+  dockerfile: build/Dockerfile
+kindFixtures:
+- name: csi-hostpath
+  manifests:
+  - fixtures/csi.yaml
+  - https://example.com/csi.yaml
+helmCharts:
+- chart: charts/web
+  values:
+  - ci/*-values.yaml
+`)
+	component := writeConfig(t, filepath.Join(root, "component"), `apiVersion: kuttl.dev/v1beta1
+kind: TestSuite
+testDirs:
+- ./tests
+parallel: 1
+kindFeatureGates:
+  B: false
+stepPlugins:
+  Local: ./local-plugin --verbose
+`)
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(filepath.Join(root, "component")))
+	defer func() { assert.NoError(t, os.Chdir(wd)) }()
+
+	suite, err := loadConfigs([]string{base, component}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tests"}, suite.TestDirs)
+	assert.Equal(t, filepath.Join("..", "crds"), suite.CRDDir)
+	assert.Equal(t, 60, suite.Timeout)
+	assert.Equal(t, 1, suite.Parallel)
+	assert.Equal(t, map[string]bool{"A": true, "B": false}, suite.KINDFeatureGates)
+	// the paths of list items are rebased, the paths relative to the build context and the chart and URLs are kept
+	require.Len(t, suite.KINDBuilds, 1)
+	assert.Equal(t, harness.ImageBuild{Image: "example.com/operator:dev", Context: filepath.Join("..", "operator"), Dockerfile: "build/Dockerfile"},
+		suite.KINDBuilds[0])
+	require.Len(t, suite.KINDFixtures, 1)
+	assert.Equal(t, []string{filepath.Join("..", "fixtures", "csi.yaml"), "https://example.com/csi.yaml"}, suite.KINDFixtures[0].Manifests)
+	assert.Equal(t, []harness.HelmChartTests{{Chart: filepath.Join("..", "charts", "web"), Values: []string{"ci/*-values.yaml"}}}, suite.HelmCharts)
+	// the plugins given as paths are rebased, the others are looked up on the PATH
+	assert.Equal(t, map[string]string{"Chaos": filepath.Join("..", "bin", "chaos"), "LoadTest": "load-test --rate 10 ./profiles/smoke.yaml", "Local": "./local-plugin --verbose"}, suite.StepPlugins)
+
+	suite, err = loadConfigs([]string{base}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("..", "e2e"), "/abs/e2e"}, suite.TestDirs)
+
+	// explicit configuration files keep their paths relative to the working directory
+	suite, err = loadConfigs([]string{base}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"./e2e", "/abs/e2e"}, suite.TestDirs)
+	assert.Equal(t, "crds", suite.CRDDir)
+
+	suite, err = loadConfigs(nil, true)
+	assert.NoError(t, err)
+	assert.Nil(t, suite.TestDirs)
+
+	_, err = loadConfigs([]string{filepath.Join(root, "missing.yaml")}, false)
+	assert.Error(t, err)
+}
+
+func TestPrintConfig(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, printConfig(buf, harness.TestSuite{TestDirs: []string{"./e2e"}, Parallel: 4}))
+
+	objs, err := testutils.LoadYAML("config", buf)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	suite, ok := objs[0].(*harness.TestSuite)
+	require.True(t, ok)
+
+	assert.Equal(t, []string{"./e2e"}, suite.TestDirs)
+	// the defaults are applied to the settings which are not set
+	assert.Equal(t, 30, suite.Timeout)
+	assert.Equal(t, 4, suite.Parallel)
+	assert.Equal(t, 4, suite.CleanupParallel)
+	assert.Equal(t, "kind", suite.KINDContext)
+	assert.Equal(t, "kuttl-report", suite.ReportName)
+}
+
+func TestSuiteFlags(t *testing.T) {
+	root := t.TempDir()
+	base := writeConfig(t, root, `apiVersion: kuttl.dev/v1beta1
+kind: TestSuite
+startKIND: true
+shareKindCluster: true
+timeout: 60
+`)
+	api := writeConfig(t, filepath.Join(root, "api"), `apiVersion: kuttl.dev/v1beta1
+kind: TestSuite
+testDirs:
+- api/tests
+`)
+	web := writeConfig(t, filepath.Join(root, "web"), `apiVersion: kuttl.dev/v1beta1
+kind: TestSuite
+testDirs:
+- web/tests
+timeout: 10
+`)
+
+	buf := &bytes.Buffer{}
+	cmd := newTestCmd()
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--suite", base + "," + api, "--suite", base + "," + web, "--parallel", "2", "--print-config"})
+	require.NoError(t, cmd.Execute())
+
+	objs, err := testutils.LoadYAML("config", buf)
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	for i, expected := range []struct {
+		testDirs []string
+		timeout  int
+	}{{[]string{"api/tests"}, 60}, {[]string{"web/tests"}, 10}} {
+		suite, ok := objs[i].(*harness.TestSuite)
+		require.True(t, ok)
+		assert.Equal(t, expected.testDirs, suite.TestDirs)
+		assert.Equal(t, expected.timeout, suite.Timeout)
+		// the flags apply to all suites
+		assert.Equal(t, 2, suite.Parallel)
+		assert.True(t, suite.ShareKINDCluster)
+	}
+
+	cmd = newTestCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--suite", api, "--config", base, "--print-config"})
+	assert.EqualError(t, cmd.Execute(), "only one of --config and --suite can be set")
+}
+
+func TestApplyFlags(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		flags    []string
+		args     []string
+		expected harness.TestSuite
+		err      string
+	}{
+		{name: "config", expected: harness.TestSuite{TestDirs: []string{"tests"}, Timeout: 60, KINDContext: harness.DefaultKINDContext}},
+		{name: "flags", flags: []string{"--timeout", "10", "--kind-config", "kind.yaml", "--jsonnet-var", "env=ci"}, args: []string{"e2e"},
+			expected: harness.TestSuite{TestDirs: []string{"e2e"}, Timeout: 10, StartKIND: true, KINDConfig: "kind.yaml",
+				KINDContext: harness.DefaultKINDContext, JsonnetVars: map[string]string{"env": "ci"}}},
+		{name: "from step", flags: []string{"--from-step", "three"}, err: `--from-step must be a step index, got "three"`},
+		{name: "control plane and kind", flags: []string{"--start-control-plane", "--start-kind"},
+			err: "only one of --start-control-plane and --start-kind can be set"},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			flags := newTestCmd().Flags()
+			require.NoError(t, flags.Parse(test.flags))
+
+			options := harness.TestSuite{TestDirs: []string{"tests"}, Timeout: 60}
+			err := applyFlags(flags, &options, test.args)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, options)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/test"
+)
+
+// listFormats are the output formats of kuttl test --list.
+var listFormats = []string{"table", "json"}
+
+// listTests prints the test cases of the suites and their steps in the format, without running them.
+func listTests(w io.Writer, suites []harness.TestSuite, format string) error {
+	listed := []test.ListedTest{}
+	for i, suite := range suites {
+		if suite.Name == "" && len(suites) > 1 {
+			suite.Name = fmt.Sprintf("suite-%d", i+1)
+		}
+		tests, err := (&test.Harness{TestSuite: suite}).ListTests()
+		if err != nil {
+			return err
+		}
+		listed = append(listed, tests...)
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listed)
+	case "table":
+		return printTestTable(w, listed)
+	}
+	return fmt.Errorf("unknown list format %q, must be one of %s", format, strings.Join(listFormats, ", "))
+}
+
+// printTestTable prints a row for each step of the tests, the settings of a test case are printed with its first step.
+func printTestTable(w io.Writer, tests []test.ListedTest) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "SUITE\tTEST DIR\tTEST\tSTEP\tTIMEOUT\tGROUPS\tPRECONDITIONS\tOWNER")
+	for _, listed := range tests {
+		suite := listed.Suite
+		if suite == "" {
+			suite = "-"
+		}
+		columns := []string{suite, listed.TestDir, listed.Name}
+		settings := []string{orNone(strings.Join(listed.ConcurrencyGroups, ",")), orNone(describePreconditions(listed.Preconditions)), orNone(listed.Owner)}
+		if len(listed.Steps) == 0 {
+			fmt.Fprintln(table, strings.Join(append(append(columns, "-", "-"), settings...), "\t"))
+			continue
+		}
+		for i, step := range listed.Steps {
+			if i > 0 {
+				columns = []string{"", "", ""}
+				settings = []string{"", "", ""}
+			}
+			name := fmt.Sprintf("%02d", step.Index)
+			if step.Name != "" {
+				name += "-" + step.Name
+			}
+			row := append(columns, name, fmt.Sprintf("%ds", step.Timeout))
+			fmt.Fprintln(table, strings.Join(append(row, settings...), "\t"))
+		}
+	}
+	return table.Flush()
+}
+
+// describePreconditions summarizes the preconditions of a test case, ex. "crds=a,b minNodes=3".
+func describePreconditions(preconditions []harness.Preconditions) string {
+	parts := []string{}
+	for _, p := range preconditions {
+		if len(p.CRDs) > 0 {
+			parts = append(parts, "crds="+strings.Join(p.CRDs, ","))
+		}
+		if len(p.StorageClasses) > 0 {
+			parts = append(parts, "storageClasses="+strings.Join(p.StorageClasses, ","))
+		}
+		if p.MinNodes > 0 {
+			parts = append(parts, fmt.Sprintf("minNodes=%d", p.MinNodes))
+		}
+		if p.AllocatableCPU != "" {
+			parts = append(parts, "allocatableCPU="+p.AllocatableCPU)
+		}
+		if p.AllocatableMemory != "" {
+			parts = append(parts, "allocatableMemory="+p.AllocatableMemory)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// orNone returns value, or "-" if it is empty.
+func orNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/test"
+)
+
+func TestPrintTestTable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, printTestTable(buf, []test.ListedTest{
+		{
+			TestDir:           "tests",
+			Name:              "install",
+			ConcurrencyGroups: []string{"db", "kind"},
+			Preconditions:     []harness.Preconditions{{CRDs: []string{"things.example.com"}}, {MinNodes: 2}},
+			Owner:             "team-db",
+			Steps:             []test.ListedStep{{Index: 0, Name: "install", Timeout: 30}, {Index: 1, Timeout: 90}},
+		},
+		{Suite: "web", TestDir: "tests", Name: "empty"},
+	}))
+	assert.Equal(t, `SUITE  TEST DIR  TEST     STEP        TIMEOUT  GROUPS   PRECONDITIONS                       OWNER
+-      tests     install  00-install  30s      db,kind  crds=things.example.com minNodes=2  team-db
+                          01          90s                                                   
+web    tests     empty    -           -        -        -                                   -
+`, buf.String())
+
+	assert.EqualError(t, listTests(buf, nil, "yaml"), "unknown list format \"yaml\", must be one of table, json")
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/mock"
+)
+
+var (
+	mockExample = `  # Serve the routes of routes.json on port 8080.
+  kubectl kuttl mock --routes routes.json --listen :8080
+
+  # Serve them over HTTPS.
+  kubectl kuttl mock --routes routes.json --listen :8443 --tls-cert tls.crt --tls-key tls.key
+
+  # Serve the gRPC methods of grpc.json, with the services of a protoc descriptor set.
+  kubectl kuttl mock --grpc grpc.json --descriptors services.pb --listen :8080`
+)
+
+// newMockCmd returns a new initialized instance of the mock sub command
+func newMockCmd() *cobra.Command {
+	routesFile := ""
+	grpcFile := ""
+	descriptorsFile := ""
+	listen := ":8080"
+	tlsCert := ""
+	tlsKey := ""
+
+	mockCmd := &cobra.Command{
+		Use:   "mock",
+		Short: "Serves a mock of an external HTTP or gRPC service.",
+		Long: `Serves the declarative responses of the routes of a mock service, a JSON list of routes with a method, a path,
+a status code, headers and a body. With --grpc it serves the responses of the unary methods of a gRPC mock instead,
+along with the standard health service and server reflection. The mock services of test steps run this command in
+helper pods.`,
+		Example: mockExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (routesFile == "") == (grpcFile == "") {
+				return errors.New("exactly one of --routes and --grpc is required")
+			}
+			if descriptorsFile != "" && grpcFile == "" {
+				return errors.New("--descriptors can only be set with --grpc")
+			}
+			if (tlsCert == "") != (tlsKey == "") {
+				return errors.New("--tls-cert and --tls-key must be set together")
+			}
+
+			server := &http.Server{Addr: listen}
+			if grpcFile != "" {
+				handler, err := grpcMockHandler(grpcFile, descriptorsFile)
+				if err != nil {
+					return err
+				}
+				server.Handler = handler
+				if tlsCert != "" {
+					server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+				}
+				if err := mock.ServeGRPC(server); err != nil {
+					return err
+				}
+				log.Printf("serving the gRPC methods of %s on %s", grpcFile, listen)
+			} else {
+				raw, err := os.ReadFile(routesFile)
+				if err != nil {
+					return err
+				}
+				routes := []harness.MockRoute{}
+				if err := json.Unmarshal(raw, &routes); err != nil {
+					return fmt.Errorf("reading routes %s: %w", routesFile, err)
+				}
+				if err := mock.Validate(routes); err != nil {
+					return err
+				}
+				server.Handler = mock.Handler(routes, log.Printf)
+				log.Printf("serving %d routes on %s", len(routes), listen)
+			}
+
+			if tlsCert != "" {
+				return server.ListenAndServeTLS(tlsCert, tlsKey)
+			}
+			return server.ListenAndServe()
+		},
+	}
+
+	mockCmd.Flags().StringVar(&routesFile, "routes", routesFile, "JSON file with the routes of the mock.")
+	mockCmd.Flags().StringVar(&grpcFile, "grpc", grpcFile, "JSON file with the gRPC methods and health statuses of the mock, served instead of routes.")
+	mockCmd.Flags().StringVar(&descriptorsFile, "descriptors", descriptorsFile, "Protobuf FileDescriptorSet with the services of the gRPC methods.")
+	mockCmd.Flags().StringVar(&listen, "listen", listen, "Address to listen on.")
+	mockCmd.Flags().StringVar(&tlsCert, "tls-cert", tlsCert, "PEM certificate to serve HTTPS with.")
+	mockCmd.Flags().StringVar(&tlsKey, "tls-key", tlsKey, "PEM key of the certificate.")
+	return mockCmd
+}
+
+// grpcMockHandler returns the handler of the gRPC mock of the JSON file, with the descriptors of its services if
+// descriptorsFile is set.
+func grpcMockHandler(grpcFile, descriptorsFile string) (http.Handler, error) {
+	raw, err := os.ReadFile(grpcFile)
+	if err != nil {
+		return nil, err
+	}
+	config := harness.MockGRPC{}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("reading gRPC methods %s: %w", grpcFile, err)
+	}
+	var descriptors []byte
+	if descriptorsFile != "" {
+		if descriptors, err = os.ReadFile(descriptorsFile); err != nil {
+			return nil, err
+		}
+	}
+	return mock.GRPCHandler(config, descriptors, log.Printf)
+}
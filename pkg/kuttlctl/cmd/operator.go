@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/operator"
+)
+
+var (
+	operatorExample = `  # Runs the TestRuns of all namespaces.
+  kubectl kuttl operator
+
+  # Runs the TestRuns of the namespace "conformance", two at a time.
+  kubectl kuttl operator --namespace conformance --max-concurrent-runs 2`
+)
+
+// newOperatorCmd returns a new initialized instance of the operator sub command
+func newOperatorCmd() *cobra.Command {
+	namespace := ""
+	workDir := ""
+	maxConcurrentRuns := 1
+	metricsAddress := ""
+	healthProbeAddress := ""
+
+	operatorCmd := &cobra.Command{
+		Use:   "operator",
+		Short: "Runs the TestRuns of a cluster.",
+		Long: `Runs a controller which executes the test suites of TestRun resources inside of the cluster, on demand or on a schedule.
+The tests are loaded from a ConfigMap or a git repository and the results are written to the status of the TestRun.
+The TestRun CRD (crds/testrun_crd.yaml) must be installed, git is required for git sources.`,
+		Example: operatorExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctrl.SetLogger(klog.NewKlogr())
+
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				return err
+			}
+			if err := harness.AddToScheme(scheme); err != nil {
+				return err
+			}
+
+			mgr, err := ctrl.NewManager(cfg, manager.Options{
+				Scheme:                 scheme,
+				Namespace:              namespace,
+				MetricsBindAddress:     metricsAddress,
+				HealthProbeBindAddress: healthProbeAddress,
+			})
+			if err != nil {
+				return err
+			}
+			if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+				return err
+			}
+			if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+				return err
+			}
+
+			reconciler := &operator.TestRunReconciler{
+				Client:            mgr.GetClient(),
+				Runner:            operator.ExecRunner(executable),
+				WorkDir:           workDir,
+				MaxConcurrentRuns: maxConcurrentRuns,
+			}
+			if err := reconciler.SetupWithManager(mgr); err != nil {
+				return err
+			}
+
+			return mgr.Start(ctrl.SetupSignalHandler())
+		},
+	}
+
+	operatorCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to watch TestRuns in. If not set, the TestRuns of all namespaces are run.")
+	operatorCmd.Flags().StringVar(&workDir, "work-dir", "", "Directory to check out the tests into (if not specified, the directory for temporary files).")
+	operatorCmd.Flags().IntVar(&maxConcurrentRuns, "max-concurrent-runs", 1, "The maximum number of TestRuns to run at once.")
+	operatorCmd.Flags().StringVar(&metricsAddress, "metrics-address", "0", "Address (ex. :8080) to expose the controller metrics on, \"0\" disables the metrics.")
+	operatorCmd.Flags().StringVar(&healthProbeAddress, "health-probe-address", "0", "Address (ex. :8081) to expose the health probes on, \"0\" disables the probes.")
+
+	return operatorCmd
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/test"
+)
+
+// pickerMatches is the number of matching tests the picker shows.
+const pickerMatches = 20
+
+// pickedTest is a test of a suite which can be picked.
+type pickedTest struct {
+	suite int
+	test  test.ListedTest
+}
+
+// String describes the test with its suite, if it has a name, ex. "web: tests/e2e/upgrade".
+func (p pickedTest) String() string {
+	if p.test.Suite != "" {
+		return p.test.Suite + ": " + p.path()
+	}
+	return p.path()
+}
+
+// path returns the path of the test in its test directory, ex. "tests/e2e/upgrade".
+func (p pickedTest) path() string {
+	return strings.TrimSuffix(p.test.TestDir, "/") + "/" + p.test.Name
+}
+
+// pickTest lets the user pick one of the tests of the suites by typing a fuzzy filter and the number of a match, and
+// returns the suite of the picked test which only runs it.
+func pickTest(in io.Reader, out io.Writer, suites []harness.TestSuite) ([]harness.TestSuite, error) {
+	candidates := []pickedTest{}
+	for i, suite := range suites {
+		if suite.Name == "" && len(suites) > 1 {
+			suite.Name = fmt.Sprintf("suite-%d", i+1)
+		}
+		listed, err := (&test.Harness{TestSuite: suite}).ListTests()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range listed {
+			candidates = append(candidates, pickedTest{suite: i, test: t})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("there are no tests to pick from")
+	}
+
+	picked, err := pickFrom(bufio.NewScanner(in), out, candidates)
+	if err != nil {
+		return nil, err
+	}
+	suite := suites[picked.suite]
+	suite.TestDirs = []string{picked.test.TestDir}
+	suite.Tests = []string{"/^" + regexp.QuoteMeta(picked.test.Name) + "$/"}
+	suite.SkipTests = nil
+	return []harness.TestSuite{suite}, nil
+}
+
+// pickFrom prompts for a filter or the number of a match until a test is picked.  An empty line picks the only
+// match.
+func pickFrom(scanner *bufio.Scanner, out io.Writer, candidates []pickedTest) (pickedTest, error) {
+	matches := candidates
+	for {
+		printMatches(out, matches)
+		fmt.Fprint(out, "filter or number of the test to run: ")
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return pickedTest{}, err
+			}
+			return pickedTest{}, errors.New("no test was picked")
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(matches) && n <= pickerMatches {
+			return matches[n-1], nil
+		}
+		if line == "" && len(matches) == 1 {
+			return matches[0], nil
+		}
+		if line != "" {
+			matches = fuzzyFilter(candidates, line)
+		}
+	}
+}
+
+// printMatches prints the numbered matches, up to pickerMatches of them.
+func printMatches(out io.Writer, matches []pickedTest) {
+	if len(matches) == 0 {
+		fmt.Fprintln(out, "no test matches")
+		return
+	}
+	for i, match := range matches {
+		if i == pickerMatches {
+			fmt.Fprintf(out, "... %d more, type to filter\n", len(matches)-pickerMatches)
+			break
+		}
+		fmt.Fprintf(out, "%3d) %s\n", i+1, match)
+	}
+}
+
+// fuzzyFilter returns the candidates whose name contains the characters of the filter in order, ignoring case, or
+// their path for filters containing a slash.  Candidates containing the filter as a whole come first, then those in
+// which its characters are closer together.
+func fuzzyFilter(candidates []pickedTest, filter string) []pickedTest {
+	type scored struct {
+		pickedTest
+		score int
+	}
+	filter = strings.ToLower(filter)
+	matches := []scored{}
+	for _, c := range candidates {
+		value := c.test.Name
+		if strings.Contains(filter, "/") {
+			value = c.path()
+		}
+		if score, ok := fuzzyScore(strings.ToLower(value), filter); ok {
+			matches = append(matches, scored{pickedTest: c, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+
+	filtered := make([]pickedTest, 0, len(matches))
+	for _, m := range matches {
+		filtered = append(filtered, m.pickedTest)
+	}
+	return filtered
+}
+
+// fuzzyScore returns whether value contains the characters of filter in order and the number of characters between
+// them, a value containing filter as a whole scores -1.
+func fuzzyScore(value, filter string) (int, bool) {
+	if strings.Contains(value, filter) {
+		return -1, true
+	}
+	score, last := 0, -1
+	for _, r := range filter {
+		i := strings.IndexRune(value[last+1:], r)
+		if i < 0 {
+			return 0, false
+		}
+		if last >= 0 {
+			score += i
+		}
+		last += i + 1
+	}
+	return score, true
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/test"
+)
+
+// testDir creates a test directory with empty test cases of the names.
+func testDir(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+	for _, name := range names {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0755))
+	}
+	return dir
+}
+
+func TestFuzzyScore(t *testing.T) {
+	for _, tt := range []struct {
+		value, filter string
+		score         int
+		matches       bool
+	}{
+		{value: "upgrade-postgres", filter: "postgres", score: -1, matches: true},
+		{value: "upgrade-postgres", filter: "upg", score: -1, matches: true},
+		{value: "upgrade-postgres", filter: "upps", score: 7, matches: true},
+		{value: "upgrade-postgres", filter: "uppg", score: 9, matches: true},
+		{value: "upgrade-postgres", filter: "mysql"},
+		{value: "upgrade", filter: "upgrade-postgres"},
+	} {
+		score, matches := fuzzyScore(tt.value, tt.filter)
+		assert.Equal(t, tt.matches, matches, "%s in %s", tt.filter, tt.value)
+		if tt.matches {
+			assert.Equal(t, tt.score, score, "%s in %s", tt.filter, tt.value)
+		}
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	candidates := []pickedTest{}
+	for _, name := range []string{"upgrade-postgres", "postgres", "install-mysql"} {
+		candidates = append(candidates, pickedTest{test: test.ListedTest{TestDir: "tests/", Name: name}})
+	}
+	names := func(tests []pickedTest) []string {
+		names := []string{}
+		for _, t := range tests {
+			names = append(names, t.test.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"upgrade-postgres", "postgres"}, names(fuzzyFilter(candidates, "Postgres")))
+	assert.Equal(t, []string{"postgres", "upgrade-postgres"}, names(fuzzyFilter(candidates, "pgrs")))
+	assert.Equal(t, []string{"install-mysql"}, names(fuzzyFilter(candidates, "tests/ins")))
+	assert.Empty(t, fuzzyFilter(candidates, "kafka"))
+}
+
+func TestPickFrom(t *testing.T) {
+	candidates := []pickedTest{
+		{test: test.ListedTest{TestDir: "tests", Name: "upgrade-postgres"}},
+		{suite: 1, test: test.ListedTest{Suite: "web", TestDir: "web/tests", Name: "install"}},
+	}
+
+	for _, tt := range []struct {
+		name, input, picked, err string
+	}{
+		{name: "number", input: "2\n", picked: "install"},
+		{name: "number of a match", input: "post\n1\n", picked: "upgrade-postgres"},
+		{name: "only match", input: "inst\n\n", picked: "install"},
+		{name: "number out of range", input: "3\ninst\n\n", picked: "install"},
+		{name: "nothing picked", input: "kafka\n\n", err: "no test was picked"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			picked, err := pickFrom(bufio.NewScanner(strings.NewReader(tt.input)), out, candidates)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.picked, picked.test.Name)
+		})
+	}
+
+	out := &bytes.Buffer{}
+	_, _ = pickFrom(bufio.NewScanner(strings.NewReader("kafka\n")), out, candidates)
+	assert.Equal(t, `  1) tests/upgrade-postgres
+  2) web: web/tests/install
+filter or number of the test to run: no test matches
+filter or number of the test to run: `, out.String())
+}
+
+func TestPickTest(t *testing.T) {
+	dir := testDir(t, "install", "upgrade")
+	suites := []harness.TestSuite{
+		{TestDirs: []string{testDir(t, "other")}},
+		{TestDirs: []string{dir}, SkipTests: []string{"install"}, Parallel: 2},
+	}
+
+	picked, err := pickTest(strings.NewReader("upg\n\n"), &bytes.Buffer{}, suites)
+	require.NoError(t, err)
+	assert.Equal(t, []harness.TestSuite{{TestDirs: []string{dir}, Tests: []string{"/^upgrade$/"}, Parallel: 2}}, picked)
+
+	_, err = pickTest(strings.NewReader(""), &bytes.Buffer{}, []harness.TestSuite{{TestDirs: []string{testDir(t)}}})
+	assert.EqualError(t, err, "there are no tests to pick from")
+}
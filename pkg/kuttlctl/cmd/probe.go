@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kudobuilder/kuttl/pkg/probe"
+)
+
+var (
+	probeExample = `  # Wait for a PostgreSQL server to accept connections.
+  kubectl kuttl probe postgres.default.svc:5432 --protocol postgres
+
+  # Wait for a TCP server to send a banner.
+  kubectl kuttl probe localhost:22 --banner '^SSH-2\.0-' --timeout 10s
+
+  # Wait for the catalog service of a gRPC server to be serving.
+  kubectl kuttl probe catalog.default.svc:9090 --protocol grpc --grpc-service catalog.v1.Catalog
+
+  # Call a gRPC method listed by server reflection and check its response.
+  kubectl kuttl probe catalog.default.svc:9090 --protocol grpc --grpc-method catalog.v1.Catalog/GetItem \
+    --grpc-request '{"id": "1"}' --grpc-response '{"item": {"available": true}}'`
+)
+
+// newProbeCmd returns a new initialized instance of the probe sub command
+func newProbeCmd() *cobra.Command {
+	p := probe.Probe{}
+	timeout := 30 * time.Second
+
+	probeCmd := &cobra.Command{
+		Use:   "probe <host:port>",
+		Short: "Waits for an application to be ready at the protocol level.",
+		Long: `Waits for the application listening at the address to be ready, ex. for a database to accept connections.
+The probe is retried every second until it succeeds or the timeout expires. The app probes of test asserts run this
+command in helper pods.`,
+		Example: probeExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("one address argument is required")
+			}
+			p.Address = args[0]
+			if err := p.Validate(); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := p.WaitFor(ctx, time.Second); err != nil {
+				return fmt.Errorf("%s probe of %s failed: %w", p.Protocol, p.Address, err)
+			}
+			fmt.Printf("%s probe of %s succeeded\n", p.Protocol, p.Address)
+			return nil
+		},
+	}
+
+	probeCmd.Flags().StringVar(&p.Protocol, "protocol", probe.TCP, fmt.Sprintf("Protocol of the application, one of %s.", strings.Join(probe.Protocols, ", ")))
+	probeCmd.Flags().StringVar(&p.Banner, "banner", "", "Regular expression the banner a TCP server sends must match.")
+	probeCmd.Flags().StringVar(&p.GRPCService, "grpc-service", "", "Service whose health a gRPC probe checks, the server as a whole by default.")
+	probeCmd.Flags().StringVar(&p.GRPCMethod, "grpc-method", "", "Unary method listed by server reflection a gRPC probe calls instead of checking the health, in the form <package>.<Service>/<Method>.")
+	probeCmd.Flags().StringVar(&p.GRPCRequest, "grpc-request", "", "JSON of the request message of the gRPC method.")
+	probeCmd.Flags().StringVar(&p.GRPCResponse, "grpc-response", "", "JSON the response of a gRPC probe must contain, {\"status\": \"SERVING\"} for health checks by default.")
+	probeCmd.Flags().DurationVar(&timeout, "timeout", timeout, "Time to wait for the application to be ready.")
+	return probeCmd
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kudobuilder/kuttl/pkg/test"
+)
+
+var (
+	recordExample = `  # Record the objects created in a namespace until ctrl+c, then write the files of a test step to ./tests/e2e/my-test.
+  kubectl kuttl record --namespace my-ns --output tests/e2e/my-test
+
+  # Record for five minutes and write the files of the second step of a test.
+  kubectl kuttl record -n my-ns --output tests/e2e/my-test --index 1 --duration 5m`
+)
+
+// newRecordCmd returns a new initialized instance of the record sub command
+func newRecordCmd() *cobra.Command {
+	namespace := "default"
+	output := "."
+	index := 0
+	duration := time.Duration(0)
+	interval := time.Second
+
+	recordCmd := &cobra.Command{
+		Use:   "record",
+		Short: "Records the changes to a namespace as the files of a test step.",
+		Long: `Records the objects created, modified and deleted in a namespace of the $KUBECONFIG cluster, ex. while trying an
+operator with kubectl, until interrupted.  The files of a candidate test step are then written: the objects to apply
+(<index>-install.yaml), the final state to assert (<index>-assert.yaml) and the deleted objects (<index>-errors.yaml).
+Objects with generated names are asserted by number, existing files are not overwritten.  The files should be reviewed
+before they are used as a test.`,
+		Example: recordExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, err := test.Client(false)
+			if err != nil {
+				return err
+			}
+			dClient, err := test.DiscoveryClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if duration > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, duration)
+				defer cancel()
+			}
+
+			r := &test.Recorder{
+				Namespace:       namespace,
+				Interval:        interval,
+				Client:          cl,
+				DiscoveryClient: dClient,
+				Out:             cmd.OutOrStdout(),
+			}
+			if err := r.Record(ctx); err != nil {
+				return err
+			}
+
+			files, err := r.WriteStep(output, index)
+			for _, file := range files {
+				fmt.Fprintf(cmd.OutOrStdout(), "wrote %s\n", file)
+			}
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "no changes were recorded")
+			}
+			return nil
+		},
+	}
+
+	recordCmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "Namespace to record.")
+	recordCmd.Flags().StringVarP(&output, "output", "o", ".", "Directory to write the files of the test step to.")
+	recordCmd.Flags().IntVar(&index, "index", 0, "Index of the test step, used as the prefix of the file names.")
+	recordCmd.Flags().DurationVar(&duration, "duration", 0, "Time to record for, the recording runs until interrupted if not set.")
+	recordCmd.Flags().DurationVar(&interval, "interval", time.Second, "Interval between two snapshots of the namespace.")
+	return recordCmd
+}
@@ -24,14 +24,41 @@ and serves as an API aggregation layer.
   # Test 1 assertion file against a cluster
   kubectl kuttl assert ../01-assert.yaml
 
+  # Compare the reports of two test runs
+  kubectl kuttl compare-runs ./artifacts-before ./artifacts-after
+
+  # Wait for a database to accept connections
+  kubectl kuttl probe postgres.default.svc:5432 --protocol postgres
+
+  # Record the changes to a namespace as the files of a test step
+  kubectl kuttl record --namespace my-ns --output tests/e2e/my-test
+
+  # Run the TestRuns of a cluster
+  kubectl kuttl operator
+
+  # Run the test suites submitted to an HTTP API
+  kubectl kuttl serve --listen :8080
+
+  # Load the shell completions of kuttl
+  source <(kubectl kuttl completion bash)
+
   # View kuttl version
   kubectl kuttl version
 `,
 		Version: version.Get().GitVersion,
+		// the completion command is added with its examples
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
 	}
 
 	cmd.AddCommand(newAssertCmd())
+	cmd.AddCommand(newCompareCmd())
+	cmd.AddCommand(newCompletionCmd())
 	cmd.AddCommand(newErrorsCmd())
+	cmd.AddCommand(newMockCmd())
+	cmd.AddCommand(newOperatorCmd())
+	cmd.AddCommand(newProbeCmd())
+	cmd.AddCommand(newRecordCmd())
+	cmd.AddCommand(newServeCmd())
 	cmd.AddCommand(newTestCmd())
 	cmd.AddCommand(newVersionCmd())
 
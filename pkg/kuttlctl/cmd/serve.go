@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kudobuilder/kuttl/pkg/serve"
+)
+
+var (
+	serveExample = `  # Serve the API on port 8080, running one test suite at a time.
+  kubectl kuttl serve --listen :8080
+
+  # Submit a test suite, its test directories are relative to the directory of the server.
+  curl --data-binary @kuttl-test.yaml 'http://localhost:8080/runs?arg=--parallel&arg=4'
+
+  # Stream the output of run 1, then fetch its report and an artifact.
+  curl http://localhost:8080/runs/1/log
+  curl http://localhost:8080/runs/1/report
+  curl http://localhost:8080/runs/1/artifacts/kuttl-report.json`
+)
+
+// newServeCmd returns a new initialized instance of the serve sub command
+func newServeCmd() *cobra.Command {
+	listen := ":8080"
+	dir := ""
+	workDir := ""
+	maxConcurrentRuns := 1
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Runs the test suites submitted to an HTTP API.",
+		Long: `Serves an HTTP API to submit the test suites of kuttl-test.yaml files, stream the progress of their runs and fetch
+their reports and artifacts. The test suites are run by "kubectl kuttl test" against the current cluster, their
+test directories must exist on the host of the server. The routes of the API are:
+
+  POST   /runs                  submits the TestSuite of the body, arg query parameters are added to the test command
+  GET    /runs                  lists the runs
+  GET    /runs/<id>             returns the status of a run
+  GET    /runs/<id>/log         streams the output of a run until it finishes
+  GET    /runs/<id>/report      returns the JSON report of a finished run
+  GET    /runs/<id>/artifacts/  serves the artifacts of a run
+  DELETE /runs/<id>             cancels a run, or deletes a finished run and its artifacts`,
+		Example: serveExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+
+			server := &serve.Server{
+				Runner:            serve.ExecRunner(executable),
+				Dir:               dir,
+				WorkDir:           workDir,
+				MaxConcurrentRuns: maxConcurrentRuns,
+			}
+			httpServer := &http.Server{Addr: listen, Handler: server, ReadHeaderTimeout: 10 * time.Second}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				if err := httpServer.Shutdown(shutdownCtx); err != nil {
+					log.Printf("stopping the server: %v", err)
+				}
+			}()
+
+			log.Printf("serving the API on %s", listen)
+			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return server.Shutdown()
+		},
+	}
+
+	serveCmd.Flags().StringVar(&listen, "listen", listen, "Address to serve the API on.")
+	serveCmd.Flags().StringVar(&dir, "dir", "", "Directory to run the tests in, relative paths of submitted test suites are relative to it (if not specified, the working directory).")
+	serveCmd.Flags().StringVar(&workDir, "work-dir", "", "Directory to store the runs and their artifacts in (if not specified, the directory for temporary files).")
+	serveCmd.Flags().IntVar(&maxConcurrentRuns, "max-concurrent-runs", 1, "The maximum number of test suites to run at once.")
+
+	return serveCmd
+}
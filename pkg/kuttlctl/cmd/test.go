@@ -4,13 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"os"
+	"math"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
 	"github.com/kudobuilder/kuttl/pkg/report"
@@ -25,6 +25,12 @@ var (
   Load a specific test configuration:
     kubectl kuttl test --config test.yaml
 
+  Load a base test configuration overridden by a component test configuration:
+    kubectl kuttl test --config ../kuttl-test.yaml,kuttl-test.yaml
+
+  Run the test suites of two components, sharing a kind cluster if they set shareKindCluster:
+    kubectl kuttl test --suite kuttl-test.yaml,api/kuttl-test.yaml --suite kuttl-test.yaml,web/kuttl-test.yaml
+
   Run tests against an existing Kubernetes cluster:
     kubectl kuttl test ./test/integration/
 
@@ -34,6 +40,21 @@ var (
   Run a Kubernetes control plane and install manifests and CRDs for the running tests:
     kubectl kuttl test --start-control-plane  --crd-dir ./config/crds/ --manifests-dir ./test/manifests/ ./test/integration/
 
+  Run the upgrade tests except the slow ones, from step 03:
+    kubectl kuttl test ./test/integration/ --test 'upgrade-*' --skip-test '/-slow$/' --from-step 03
+
+  Run only the steps and asserts of the smoke stage of the tests:
+    kubectl kuttl test ./test/integration/ --stage smoke
+
+  Print the settings the tests would be run with:
+    kubectl kuttl test ./test/integration/ --parallel 4 --print-config
+
+  List the upgrade tests and their steps as JSON, without running them:
+    kubectl kuttl test ./test/integration/ --test 'upgrade-*' --list=json
+
+  Pick the test to run with a fuzzy filter:
+    kubectl kuttl test ./test/integration/ -i
+
   Run tests against an existing Kubernetes cluster with a JUnit XML file output:
     kubectl kuttl test ./test/integration/ --report xml
 `
@@ -41,29 +62,14 @@ var (
 
 // newTestCmd creates the test command for the CLI
 func newTestCmd() *cobra.Command { //nolint:gocyclo
-	configPath := ""
-	crdDir := ""
-	manifestDirs := []string{}
-	testToRun := ""
-	startControlPlane := false
-	attachControlPlaneOutput := false
-	startKIND := false
-	kindConfig := ""
-	kindContext := ""
-	skipDelete := false
-	skipClusterDelete := false
-	parallel := 0
-	artifactsDir := ""
-	// TODO: remove after v0.16.0 deprecated
-	mockControllerFile := ""
-	timeout := 30
-	reportFormat := ""
-	reportName := "kuttl-report"
-	namespace := ""
-	suppress := []string{}
+	configPaths := []string{}
+	printEffectiveConfig := false
+	listFormat := ""
+	interactive := false
 	var runLabels labelSetValue
 
-	options := harness.TestSuite{}
+	suitePaths := []string{}
+	suites := []harness.TestSuite{}
 
 	testCmd := &cobra.Command{
 		Use:   "test [flags]... [test directories]...",
@@ -72,194 +78,199 @@ func newTestCmd() *cobra.Command { //nolint:gocyclo
 
 The test operator supports connecting to an existing Kubernetes cluster or it can start a Kubernetes API server during the test run.
 It can also apply manifests before running the tests. If no arguments are provided, the test harness will attempt to
-load the test configuration from kuttl-test.yaml.
+load the test configuration from kuttl-test.yaml. The kuttl-test.yaml files of parent directories (up to the root of
+the repository) are loaded as well, the settings of files in nested directories override those of their parents.
 
 For more detailed documentation, visit: https://kuttl.dev`,
 		Example: testExample,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			flags := cmd.Flags()
 
-			// If a config is not set and kuttl-test.yaml exists, set configPath to kuttl-test.yaml.
-			if configPath == "" {
-				if _, err := os.Stat("kuttl-test.yaml"); err == nil {
-					configPath = "kuttl-test.yaml"
-				} else {
-					log.Println("running without a 'kuttl-test.yaml' configuration")
+			// Each --suite is a test suite with its own configuration files, which are not discovered.
+			if len(suitePaths) > 0 {
+				if len(configPaths) > 0 {
+					return errors.New("only one of --config and --suite can be set")
+				}
+				if len(args) > 0 {
+					return errors.New("test directories cannot be given on the command line with --suite")
 				}
 			}
 
-			// Load the configuration YAML into options.
-			if configPath != "" {
-				objects, err := testutils.LoadYAMLFromFile(configPath)
+			// If no config is set, load the kuttl-test.yaml files of the working directory and its parents.
+			rebase := false
+			if len(configPaths) == 0 && len(suitePaths) == 0 {
+				discovered, err := discoverConfigs(".")
 				if err != nil {
 					return err
 				}
-
-				for _, obj := range objects {
-					kind := obj.GetObjectKind().GroupVersionKind().Kind
-
-					if kind == "TestSuite" {
-						switch ts := obj.(type) {
-						case *harness.TestSuite:
-							options = *ts
-						case *unstructured.Unstructured:
-							log.Println(fmt.Errorf("bad configuration in file %q", configPath))
-						}
-					} else {
-						log.Println(fmt.Errorf("unknown object type: %s", kind))
-					}
+				if len(discovered) == 0 {
+					log.Println("running without a 'kuttl-test.yaml' configuration")
+				} else {
+					log.Println("loading configuration from [", strings.Join(discovered, ", "), "]")
 				}
+				configPaths = discovered
+				rebase = true
 			}
 
-			// Override configuration file options with any command line flags if they are set.
-			if isSet(flags, "crd-dir") {
-				options.CRDDir = crdDir
-			}
-
-			if isSet(flags, "manifest-dir") {
-				options.ManifestDirs = manifestDirs
-			}
-
-			if isSet(flags, "start-control-plane") {
-				options.StartControlPlane = startControlPlane
-			}
-
-			if isSet(flags, "attach-control-plane-output") {
-				options.AttachControlPlaneOutput = attachControlPlaneOutput
-			}
-
-			if isSet(flags, "start-kind") {
-				options.StartKIND = startKIND
-			}
-
-			if isSet(flags, "kind-config") {
-				options.StartKIND = true
-				options.KINDConfig = kindConfig
-			}
-
-			if isSet(flags, "kind-context") {
-				options.KINDContext = kindContext
-			}
-
-			if options.KINDContext == "" {
-				options.KINDContext = harness.DefaultKINDContext
-			}
-
-			if options.StartControlPlane && options.StartKIND {
-				return errors.New("only one of --start-control-plane and --start-kind can be set")
-			}
-
-			// after control-plane && start=kind check
-			if options.AttachControlPlaneOutput && !options.StartControlPlane {
-				return errors.New("only use --attach-control-plane-output with --start-control-plane")
-			}
-
-			// if we are working with a control plane we can not wait to delete ns (there is no ns controller)
-			// this is added before flags potentially override.  control plane should skip ns and cluster delete but
-			// perhaps there are cases where that is part of the test.  In general, there is no cluster to delete and
-			// there is no namespace controller.
-			if options.StartControlPlane {
-				options.SkipDelete = true
-			}
-
-			if isSet(flags, "skip-delete") {
-				options.SkipDelete = skipDelete
-			}
-
-			if isSet(flags, "skip-cluster-delete") {
-				options.SkipClusterDelete = skipClusterDelete
-			}
-
-			if isSet(flags, "parallel") {
-				options.Parallel = parallel
+			suiteConfigs := [][]string{configPaths}
+			if len(suitePaths) > 0 {
+				suiteConfigs = nil
+				for _, paths := range suitePaths {
+					suiteConfigs = append(suiteConfigs, strings.Split(paths, ","))
+				}
 			}
 
-			if isSet(flags, "report") {
-				var ftype = report.Type(strings.ToLower(reportFormat))
-				options.ReportFormat = reportType(ftype)
+			suites = nil
+			for _, paths := range suiteConfigs {
+				// Load the configuration YAML files of the suite, later files override earlier files.
+				loaded, err := loadConfigs(paths, rebase)
+				if err != nil {
+					return err
+				}
+				if err := applyFlags(flags, &loaded, args); err != nil {
+					return err
+				}
+				suites = append(suites, loaded)
 			}
-
-			if isSet(flags, "report-name") {
-				options.ReportName = reportName
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if interactive {
+				picked, err := pickTest(cmd.InOrStdin(), cmd.ErrOrStderr(), suites)
+				if err != nil {
+					log.Fatalf("picking the test to run: %v", err)
+				}
+				suites = picked
 			}
-
-			if isSet(flags, "artifacts-dir") {
-				options.ArtifactsDir = artifactsDir
+			if printEffectiveConfig {
+				for i, suite := range suites {
+					if i > 0 {
+						fmt.Fprintln(cmd.OutOrStdout(), "---")
+					}
+					if err := printConfig(cmd.OutOrStdout(), suite); err != nil {
+						log.Fatalf("printing the configuration: %v", err)
+					}
+				}
+				return
 			}
-
-			if isSet(flags, "namespace") {
-				if strings.TrimSpace(namespace) == "" {
-					return errors.New(`setting namespace explicitly to "" or empty string is not supported`)
+			if listFormat != "" {
+				if err := listTests(cmd.OutOrStdout(), suites, listFormat); err != nil {
+					log.Fatalf("listing the tests: %v", err)
 				}
-				options.Namespace = namespace
+				return
 			}
 
-			if isSet(flags, "suppress-log") {
-				suppressSet := make(map[string]struct{})
-				for _, s := range append(options.Suppress, suppress...) {
-					suppressSet[strings.ToLower(s)] = struct{}{}
+			testParallel := 0
+			sharingKIND := 0
+			for _, suite := range suites {
+				parallel := suite.Parallel
+				if len(suites) > 1 {
+					parallel = (&test.Harness{TestSuite: suite}).EffectiveTestSuite().Parallel
 				}
-				options.Suppress = make([]string, len(suppressSet))
-				i := 0
-				for s := range suppressSet {
-					options.Suppress[i] = s
-					i++
+				// with concurrency groups the harness limits the number of tests running at once itself, so that tests
+				// waiting for a group do not count against the limit
+				if len(suite.ConcurrencyGroups) > 0 {
+					parallel = math.MaxInt32
+				}
+				testParallel = max(testParallel, parallel)
+				if suite.StartKIND && suite.ShareKINDCluster {
+					sharingKIND++
 				}
 			}
+			sharedKIND := test.NewSharedKIND(sharingKIND)
 
-			if isSet(flags, "timeout") {
-				options.Timeout = timeout
-			}
-
-			if len(args) != 0 {
-				log.Println("kutt-test config testdirs is overridden with args: [", strings.Join(args, ", "), "]")
-				options.TestDirs = args
-			}
-
-			if len(options.TestDirs) == 0 {
-				return errors.New("no test directories provided, please provide either --config or test directories on the command line")
-			}
-			if mockControllerFile != "" {
-				log.Println("use of --control-plane-config is deprecated and no longer functions")
-			}
-
-			return nil
-		},
-		Run: func(cmd *cobra.Command, args []string) {
-			testutils.RunTests("kuttl", testToRun, options.Parallel, func(t *testing.T) {
+			run := func(t *testing.T, suite harness.TestSuite) {
 				harness := test.Harness{
-					TestSuite: options,
-					T:         t,
-					RunLabels: runLabels.AsLabelSet(),
+					TestSuite:  suite,
+					T:          t,
+					RunLabels:  runLabels.AsLabelSet(),
+					SharedKIND: sharedKIND,
 				}
 
 				harness.Run()
+			}
+			testutils.RunTests("kuttl", "", testParallel, func(t *testing.T) {
+				if len(suites) == 1 {
+					run(t, suites[0])
+					return
+				}
+				// the suites are run one after the other, each waits for the tests of the previous one
+				for i, suite := range suites {
+					suite := suite
+					name := suite.Name
+					if name == "" {
+						name = fmt.Sprintf("suite-%d", i+1)
+					}
+					t.Run(name, func(t *testing.T) { run(t, suite) })
+				}
 			})
 		},
 	}
 
-	testCmd.Flags().StringVar(&configPath, "config", "", "Path to file to load base test settings from (these may be overridden with command-line arguments).")
-	testCmd.Flags().StringVar(&crdDir, "crd-dir", "", "Directory to load CustomResourceDefinitions from prior to running the tests.")
-	testCmd.Flags().StringSliceVar(&manifestDirs, "manifest-dir", []string{}, "One or more directories containing manifests to apply before running the tests.")
-	testCmd.Flags().StringVar(&testToRun, "test", "", "If set, the specific test case to run.")
-	testCmd.Flags().BoolVar(&startControlPlane, "start-control-plane", false, "Start a local Kubernetes control plane for the tests (requires etcd and kube-apiserver binaries, cannot be used with --start-kind).")
-	testCmd.Flags().BoolVar(&attachControlPlaneOutput, "attach-control-plane-output", false, "Attaches control plane to stdout when using --start-control-plane.")
+	testCmd.Flags().StringSliceVar(&configPaths, "config", []string{}, "One or more paths to files to load base test settings from, later files override earlier files (these may be overridden with command-line arguments). If not set, kuttl-test.yaml is loaded from the working directory and its parent directories up to the repository root.")
+	testCmd.Flags().StringArrayVar(&suitePaths, "suite", []string{}, "Paths to the files of a test suite, comma separated like --config. May be repeated to run multiple test suites one after the other, each with its own settings (cannot be used with --config). The command line flags apply to all of them, suites which set shareKindCluster share one kind cluster.")
+	testCmd.Flags().BoolVar(&printEffectiveConfig, "print-config", false, "Print the effective test settings as a TestSuite in YAML, after the configuration files, command line flags and defaults are applied, instead of running the tests.")
+	testCmd.Flags().StringVar(&listFormat, "list", "", fmt.Sprintf("Print the test cases and their steps with their timeouts, concurrency groups, preconditions and owners instead of running them, as a %s (--list=<format>).", strings.Join(listFormats, " or ")))
+	testCmd.Flags().Lookup("list").NoOptDefVal = "table"
+	testCmd.Flags().String("crd-dir", "", "Directory to load CustomResourceDefinitions from prior to running the tests.")
+	testCmd.Flags().StringSlice("manifest-dir", []string{}, "One or more directories containing manifests to apply before running the tests.")
+	testCmd.Flags().StringArray("test", []string{}, "Pattern of the tests to run, a glob (ex. upgrade-*) or a regular expression between slashes (ex. /^upgrade-v[0-9]+$/). Patterns containing a slash match the test directory and name (ex. test/e2e/upgrade-*). May be repeated, all tests are run if not set.")
+	testCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Pick the test to run from the tests of the suite with a fuzzy filter, to run a single test locally.")
+	testCmd.Flags().StringArray("skip-test", []string{}, "Pattern of the tests not to run, in the form of --test. May be repeated.")
+	testCmd.Flags().String("from-step", "", "If set, the steps of the tests with a lower index are not run (ex. 03), to develop a step in a namespace kept with --skip-delete.")
+	testCmd.Flags().String("stage", "", "If set, only the steps and asserts of the stage are run (ex. smoke), those without stages run in every stage.")
+	testCmd.Flags().Bool("start-control-plane", false, "Start a local Kubernetes control plane for the tests (requires etcd and kube-apiserver binaries, cannot be used with --start-kind).")
+	testCmd.Flags().Bool("attach-control-plane-output", false, "Attaches control plane to stdout when using --start-control-plane.")
 	// TODO: remove after v0.16.0 deprecated mockControllerFile is not supported in the latest testenv
-	testCmd.Flags().StringVar(&mockControllerFile, "control-plane-config", "", "Path to file to load controller-runtime APIServer configuration arguments (only useful when --startControlPlane).")
-	testCmd.Flags().BoolVar(&startKIND, "start-kind", false, "Start a KIND cluster for the tests (cannot be used with --start-control-plane).")
-	testCmd.Flags().StringVar(&kindConfig, "kind-config", "", "Specify the KIND configuration file path (implies --start-kind, cannot be used with --start-control-plane).")
-	testCmd.Flags().StringVar(&kindContext, "kind-context", "", "Specify the KIND context name to use (default: kind).")
-	testCmd.Flags().StringVar(&artifactsDir, "artifacts-dir", "", "Directory to output kind logs to (if not specified, the current working directory).")
-	testCmd.Flags().BoolVar(&skipDelete, "skip-delete", false, "If set, do not delete resources created during tests (helpful for debugging test failures, implies --skip-cluster-delete).")
-	testCmd.Flags().BoolVar(&skipClusterDelete, "skip-cluster-delete", false, "If set, do not delete the mocked control plane or kind cluster.")
+	testCmd.Flags().String("control-plane-config", "", "Path to file to load controller-runtime APIServer configuration arguments (only useful when --startControlPlane).")
+	testCmd.Flags().Bool("start-kind", false, "Start a KIND cluster for the tests (cannot be used with --start-control-plane).")
+	testCmd.Flags().String("kind-config", "", "Specify the KIND configuration file path (implies --start-kind, cannot be used with --start-control-plane).")
+	testCmd.Flags().String("kind-context", "", "Specify the KIND context name to use (default: kind).")
+	testCmd.Flags().Bool("kind-registry", false, "Start a local container registry for the KIND cluster, its address is set in $KUTTL_REGISTRY.")
+	testCmd.Flags().StringArray("kind-fixture", []string{}, "A well-known test fixture to install into the KIND cluster, in the form <name>[=<version>]: csi-hostpath, local-path-provisioner or sample-device-plugin. May be repeated, a fixture of the test suite with the same name gets the version.")
+	testCmd.Flags().String("ip-family", "", "The IP family of the KIND cluster or mocked control plane: ipv4, ipv6 or dual (default: ipv4).")
+	testCmd.Flags().String("artifacts-dir", "", "Directory to output kind logs to (if not specified, the current working directory).")
+	testCmd.Flags().Bool("skip-delete", false, "If set, do not delete resources created during tests (helpful for debugging test failures, implies --skip-cluster-delete).")
+	testCmd.Flags().Bool("skip-cluster-delete", false, "If set, do not delete the mocked control plane or kind cluster.")
+	testCmd.Flags().Bool("keep-cluster-on-failure", false, "If set, only delete the mocked control plane or kind cluster if all tests pass (independent of --skip-delete).")
+	testCmd.Flags().Int64("seed", 0, "The seed of the random source used for namespace names and shuffling tests. If not set, a random seed is used and logged.")
+	testCmd.Flags().Bool("shuffle", false, "If set, run the tests of each test directory in a random order determined by the seed.")
 	// The default value here is only used for the help message. The default is actually enforced in RunTests.
-	testCmd.Flags().IntVar(&parallel, "parallel", 8, "The maximum number of tests to run at once.")
-	testCmd.Flags().IntVar(&timeout, "timeout", 30, "The timeout to use as default for TestSuite configuration.")
-	testCmd.Flags().StringVar(&reportFormat, "report", "", "Specify JSON|XML for report.  Report location determined by --artifacts-dir.")
-	testCmd.Flags().StringVar(&reportName, "report-name", "kuttl-report", "Name for the report.  Report location determined by --artifacts-dir and report file type determined by --report.")
-	testCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to use for tests. Provided namespaces must exist prior to running tests.")
-	testCmd.Flags().StringSliceVar(&suppress, "suppress-log", []string{}, "Suppress logging for these kinds of logs (events).")
+	testCmd.Flags().Int("parallel", 8, "The maximum number of tests to run at once.")
+	testCmd.Flags().Int("cleanup-parallel", 0, "The maximum number of test namespaces deleted at once in the background (default: --parallel).")
+	testCmd.Flags().Int("timeout", 30, "The timeout to use as default for TestSuite configuration.")
+	testCmd.Flags().Int("suite-timeout", 0, "The maximum duration of the whole test suite in seconds, after which the run is aborted (0 is no limit).")
+	testCmd.Flags().Int("test-timeout", 0, "The maximum duration of each test case in seconds, after which the run is aborted (0 is no limit).")
+	testCmd.Flags().Int("max-failures", 0, "The maximum number of failed tests, after which the run is aborted (0 is no limit).")
+	testCmd.Flags().String("report", "", "Specify JSON|XML for report.  Report location determined by --artifacts-dir.")
+	testCmd.Flags().String("report-name", "kuttl-report", "Name for the report.  Report location determined by --artifacts-dir and report file type determined by --report.")
+	testCmd.Flags().Bool("report-colors", false, "Keep the ANSI colors of the command output attached to JSON reports, they are stripped by default.")
+	testCmd.Flags().String("timings", "", "Specify CSV|JSON to write the timings of the phases of each step.  Timings location determined by --artifacts-dir.")
+	testCmd.Flags().StringP("namespace", "n", "", "Namespace to use for tests. Provided namespaces must exist prior to running tests.")
+	testCmd.Flags().StringSlice("suppress-log", []string{}, "Suppress logging for these kinds of logs (events).")
 	testCmd.Flags().Var(&runLabels, "test-run-labels", "Labels to use for this test run.")
+	testCmd.Flags().String("metrics-pushgateway-url", "", "URL of a Prometheus Pushgateway to push run metrics to when the tests have finished. Test run labels are added to the metrics.")
+	testCmd.Flags().Bool("safe-mode", false, "If set, test steps may not delete, prune, restart or evict objects outside the namespaces kuttl creates for the tests, nor change nodes, unless the safeMode of the test suite allows it.")
+	testCmd.Flags().Bool("cluster-snapshot", false, "If set, fail if the tests leave CRDs, ClusterRoles, ClusterRoleBindings, webhook configurations or StorageClasses created, deleted or changed, unless the clusterSnapshot of the test suite ignores them.")
+	testCmd.Flags().Bool("detect-drift", false, "If set, report the field managers which wrote the asserted fields of objects whose asserts keep flapping between match and mismatch, to debug controllers fighting over them.")
+	testCmd.Flags().Bool("hermetic", false, "If set, fail before running the tests if a command relies on a host binary not declared in the tools of the test suite, and use built-in implementations of kubectl apply and wait.")
+	testCmd.Flags().StringToString("jsonnet-var", map[string]string{}, "External variables of the Jsonnet files of the tests, in the form <name>=<value>. They are added to the jsonnetVars of the test suite.")
+	testCmd.Flags().String("metrics-address", "", "Address (ex. :9090) to expose run metrics on at /metrics while the tests are running.")
+	// The test names are completed with the tests of the suite, which is loaded like to run it.
+	completeTests := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if err := testCmd.PreRunE(cmd, args); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		completions, err := testNameCompletions(suites, toComplete)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	for _, name := range []string{"test", "skip-test"} {
+		// the flags are defined above, registering their completion does not fail
+		_ = testCmd.RegisterFlagCompletionFunc(name, completeTests)
+	}
 	// This cannot be a global flag because pkg/test/utils.RunTests calls flag.Parse which barfs on unknown top-level flags.
 	// Putting it here at least does not advertise it on a level where using it is impossible.
 	test.SetFlags(testCmd.Flags())
@@ -278,6 +289,262 @@ func reportType(ftype report.Type) string {
 	}
 }
 
+// addKINDFixtures adds the fixtures of --kind-fixture flags in the form <name>[=<version>] to the fixtures of the test
+// suite, the version of a fixture of the same name is replaced.
+func addKINDFixtures(fixtures []harness.KINDFixture, flags []string) []harness.KINDFixture {
+	fixtures = append([]harness.KINDFixture{}, fixtures...)
+	for _, flag := range flags {
+		name, version, _ := strings.Cut(flag, "=")
+		found := false
+		for i := range fixtures {
+			if fixtures[i].Name == name {
+				if version != "" {
+					fixtures[i].Version = version
+				}
+				found = true
+			}
+		}
+		if !found {
+			fixtures = append(fixtures, harness.KINDFixture{Name: name, Version: version})
+		}
+	}
+	return fixtures
+}
+
+// applyFlags overrides the settings of the configuration files of a test suite with the command line flags which are
+// set, and checks the resulting settings.
+func applyFlags(flags *pflag.FlagSet, options *harness.TestSuite, args []string) error { //nolint:gocyclo
+	// the flags are defined by newTestCmd, getting their values does not fail
+	if isSet(flags, "crd-dir") {
+		options.CRDDir, _ = flags.GetString("crd-dir")
+	}
+
+	if isSet(flags, "manifest-dir") {
+		options.ManifestDirs, _ = flags.GetStringSlice("manifest-dir")
+	}
+
+	if isSet(flags, "start-control-plane") {
+		options.StartControlPlane, _ = flags.GetBool("start-control-plane")
+	}
+
+	if isSet(flags, "attach-control-plane-output") {
+		options.AttachControlPlaneOutput, _ = flags.GetBool("attach-control-plane-output")
+	}
+
+	if isSet(flags, "start-kind") {
+		options.StartKIND, _ = flags.GetBool("start-kind")
+	}
+
+	if isSet(flags, "kind-config") {
+		options.StartKIND = true
+		options.KINDConfig, _ = flags.GetString("kind-config")
+	}
+
+	if isSet(flags, "kind-context") {
+		options.KINDContext, _ = flags.GetString("kind-context")
+	}
+
+	if isSet(flags, "kind-registry") {
+		options.KINDRegistry, _ = flags.GetBool("kind-registry")
+	}
+
+	if isSet(flags, "kind-fixture") {
+		kindFixtures, _ := flags.GetStringArray("kind-fixture")
+		options.KINDFixtures = addKINDFixtures(options.KINDFixtures, kindFixtures)
+	}
+
+	if isSet(flags, "ip-family") {
+		options.IPFamily, _ = flags.GetString("ip-family")
+	}
+
+	if options.KINDContext == "" {
+		options.KINDContext = harness.DefaultKINDContext
+	}
+
+	if options.StartControlPlane && options.StartKIND {
+		return errors.New("only one of --start-control-plane and --start-kind can be set")
+	}
+
+	if options.StartCluster != nil && (options.StartControlPlane || options.StartKIND) {
+		return errors.New("startCluster cannot be used with --start-control-plane or --start-kind")
+	}
+
+	// after control-plane && start=kind check
+	if options.AttachControlPlaneOutput && !options.StartControlPlane {
+		return errors.New("only use --attach-control-plane-output with --start-control-plane")
+	}
+
+	// if we are working with a control plane we can not wait to delete ns (there is no ns controller)
+	// this is added before flags potentially override.  control plane should skip ns and cluster delete but
+	// perhaps there are cases where that is part of the test.  In general, there is no cluster to delete and
+	// there is no namespace controller.
+	if options.StartControlPlane {
+		options.SkipDelete = true
+	}
+
+	if isSet(flags, "skip-delete") {
+		options.SkipDelete, _ = flags.GetBool("skip-delete")
+	}
+
+	if isSet(flags, "skip-cluster-delete") {
+		options.SkipClusterDelete, _ = flags.GetBool("skip-cluster-delete")
+	}
+
+	if isSet(flags, "keep-cluster-on-failure") {
+		options.KeepClusterOnFailure, _ = flags.GetBool("keep-cluster-on-failure")
+	}
+
+	if isSet(flags, "seed") {
+		options.Seed, _ = flags.GetInt64("seed")
+	}
+
+	if isSet(flags, "shuffle") {
+		options.Shuffle, _ = flags.GetBool("shuffle")
+	}
+
+	if isSet(flags, "test") {
+		options.Tests, _ = flags.GetStringArray("test")
+	}
+
+	if isSet(flags, "skip-test") {
+		options.SkipTests, _ = flags.GetStringArray("skip-test")
+	}
+
+	if isSet(flags, "from-step") {
+		fromStep, _ := flags.GetString("from-step")
+		// step indexes are parsed as decimal numbers, ex. 08 is 8
+		index, err := strconv.Atoi(fromStep)
+		if err != nil || index < 0 {
+			return fmt.Errorf("--from-step must be a step index, got %q", fromStep)
+		}
+		options.FromStep = index
+	}
+
+	if isSet(flags, "stage") {
+		options.Stage, _ = flags.GetString("stage")
+	}
+
+	if isSet(flags, "parallel") {
+		options.Parallel, _ = flags.GetInt("parallel")
+	}
+
+	if isSet(flags, "cleanup-parallel") {
+		options.CleanupParallel, _ = flags.GetInt("cleanup-parallel")
+	}
+
+	if isSet(flags, "report") {
+		reportFormat, _ := flags.GetString("report")
+		var ftype = report.Type(strings.ToLower(reportFormat))
+		options.ReportFormat = reportType(ftype)
+	}
+
+	if isSet(flags, "report-name") {
+		options.ReportName, _ = flags.GetString("report-name")
+	}
+
+	if isSet(flags, "report-colors") {
+		options.ReportColors, _ = flags.GetBool("report-colors")
+	}
+
+	if isSet(flags, "timings") {
+		timingsFormat, _ := flags.GetString("timings")
+		options.TimingsFormat = strings.ToLower(timingsFormat)
+	}
+
+	if isSet(flags, "artifacts-dir") {
+		options.ArtifactsDir, _ = flags.GetString("artifacts-dir")
+	}
+
+	if isSet(flags, "namespace") {
+		namespace, _ := flags.GetString("namespace")
+		if strings.TrimSpace(namespace) == "" {
+			return errors.New(`setting namespace explicitly to "" or empty string is not supported`)
+		}
+		options.Namespace = namespace
+	}
+
+	if isSet(flags, "suppress-log") {
+		suppress, _ := flags.GetStringSlice("suppress-log")
+		suppressSet := make(map[string]struct{})
+		for _, s := range append(options.Suppress, suppress...) {
+			suppressSet[strings.ToLower(s)] = struct{}{}
+		}
+		options.Suppress = make([]string, len(suppressSet))
+		i := 0
+		for s := range suppressSet {
+			options.Suppress[i] = s
+			i++
+		}
+	}
+
+	if isSet(flags, "timeout") {
+		options.Timeout, _ = flags.GetInt("timeout")
+	}
+
+	if isSet(flags, "suite-timeout") {
+		options.SuiteTimeout, _ = flags.GetInt("suite-timeout")
+	}
+
+	if isSet(flags, "test-timeout") {
+		options.TestTimeout, _ = flags.GetInt("test-timeout")
+	}
+
+	if isSet(flags, "max-failures") {
+		options.MaxFailures, _ = flags.GetInt("max-failures")
+	}
+
+	if isSet(flags, "metrics-pushgateway-url") {
+		options.MetricsPushgatewayURL, _ = flags.GetString("metrics-pushgateway-url")
+	}
+
+	if isSet(flags, "metrics-address") {
+		options.MetricsAddress, _ = flags.GetString("metrics-address")
+	}
+
+	if isSet(flags, "hermetic") {
+		options.Hermetic, _ = flags.GetBool("hermetic")
+	}
+
+	safeMode, _ := flags.GetBool("safe-mode")
+	if safeMode && options.SafeMode == nil {
+		options.SafeMode = &harness.SafeMode{}
+	}
+
+	clusterSnapshot, _ := flags.GetBool("cluster-snapshot")
+	if clusterSnapshot && options.ClusterSnapshot == nil {
+		options.ClusterSnapshot = &harness.ClusterSnapshot{}
+	}
+
+	if isSet(flags, "detect-drift") {
+		options.DetectDrift, _ = flags.GetBool("detect-drift")
+	}
+
+	if isSet(flags, "jsonnet-var") {
+		jsonnetVars, _ := flags.GetStringToString("jsonnet-var")
+		if options.JsonnetVars == nil {
+			options.JsonnetVars = map[string]string{}
+		}
+		for name, value := range jsonnetVars {
+			options.JsonnetVars[name] = value
+		}
+	}
+
+	if len(args) != 0 {
+		log.Println("kutt-test config testdirs is overridden with args: [", strings.Join(args, ", "), "]")
+		options.TestDirs = args
+	}
+
+	if len(options.TestDirs) == 0 && len(options.HelmCharts) == 0 {
+		return errors.New("no test directories provided, please provide either --config or test directories on the command line")
+	}
+	mockControllerFile, _ := flags.GetString("control-plane-config")
+	if mockControllerFile != "" {
+		log.Println("use of --control-plane-config is deprecated and no longer functions")
+	}
+
+	return nil
+}
+
 // isSet returns true if a flag is set on the command line.
 func isSet(flagSet *pflag.FlagSet, name string) bool {
 	found := false
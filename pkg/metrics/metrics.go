@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Metrics collects run metrics for a kuttl test run in a Prometheus registry of its own.
+// Every metric carries a `suite` and `test` label (where applicable) as well as the constant labels of the run.
+type Metrics struct {
+	lock     sync.Mutex
+	registry *prometheus.Registry
+	handler  http.Handler
+	tests    map[testKey]bool
+
+	duration *prometheus.GaugeVec
+	failed   *prometheus.GaugeVec
+	retries  *prometheus.GaugeVec
+	total    *prometheus.GaugeVec
+}
+
+type testKey struct {
+	suite string
+	test  string
+}
+
+// reservedLabels are the labels set by the metrics themselves, the constant labels of the run cannot use them.
+var reservedLabels = []string{"suite", "test", "result"}
+
+// invalidLabelChars matches characters which are not allowed in Prometheus label names.
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// New returns an initialized Metrics. The constLabels are added to every metric,
+// label names are sanitized to conform to the Prometheus label name rules.
+// It fails if a label is one of the labels of the metrics (suite, test and result) once sanitized.
+func New(constLabels map[string]string) (*Metrics, error) {
+	labels := prometheus.Labels{}
+	for k, v := range constLabels {
+		name := sanitizeLabelName(k)
+		for _, reserved := range reservedLabels {
+			if name == reserved {
+				return nil, fmt.Errorf("run label %q is reserved for the label %s of the metrics", k, reserved)
+			}
+		}
+		labels[name] = v
+	}
+
+	gauge := func(name, help string, labelNames ...string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help, ConstLabels: labels}, labelNames)
+	}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		tests:    map[testKey]bool{},
+		duration: gauge("kuttl_test_duration_seconds", "Duration of a test case in seconds.", "suite", "test"),
+		failed:   gauge("kuttl_test_failed", "Whether a test case failed (1) or passed (0).", "suite", "test"),
+		retries: gauge("kuttl_test_assert_retries", "Number of times the asserts of a test case were retried before they passed or timed out.",
+			"suite", "test"),
+		total: gauge("kuttl_tests_total", "Number of test cases in a suite by result.", "suite", "result"),
+	}
+	for _, collector := range []prometheus.Collector{m.duration, m.failed, m.retries, m.total} {
+		if err := m.registry.Register(collector); err != nil {
+			return nil, fmt.Errorf("registering metrics: %w", err)
+		}
+	}
+	m.handler = promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return m, nil
+}
+
+// AddTest records the result of a test case in a suite.
+func (m *Metrics) AddTest(suite, test string, duration time.Duration, failed bool, retries int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.tests[testKey{suite: suite, test: test}] = failed
+	m.duration.WithLabelValues(suite, test).Set(duration.Seconds())
+	m.failed.WithLabelValues(suite, test).Set(boolValue(failed))
+	m.retries.WithLabelValues(suite, test).Set(float64(retries))
+
+	// a test recorded again replaces its previous result, the counts of its suite are recomputed
+	passedCount, failedCount := 0, 0
+	for key, testFailed := range m.tests {
+		switch {
+		case key.suite != suite:
+		case testFailed:
+			failedCount++
+		default:
+			passedCount++
+		}
+	}
+	m.total.WithLabelValues(suite, "passed").Set(float64(passedCount))
+	m.total.WithLabelValues(suite, "failed").Set(float64(failedCount))
+}
+
+// Write writes all of the collected metrics to w in the Prometheus text exposition format.
+func (m *Metrics) Write(w io.Writer) error {
+	families, err := m.registry.Gather()
+	if err != nil {
+		return err
+	}
+	encoder := expfmt.NewEncoder(w, expfmt.FmtText)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface, exposing the metrics collected so far.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
+
+// Serve starts an HTTP server on addr which exposes the metrics at /metrics.
+// The caller is responsible for shutting down the returned server.
+func (m *Metrics) Serve(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening for metrics on %q: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		// ErrServerClosed is the expected result of a shutdown
+		_ = server.Serve(listener)
+	}()
+
+	return server, nil
+}
+
+// Push pushes the metrics to a Prometheus Pushgateway, replacing all metrics of the grouping job.
+func (m *Metrics) Push(ctx context.Context, gatewayURL, job string) error {
+	if err := push.New(gatewayURL, job).Gatherer(m.registry).PushContext(ctx); err != nil {
+		return fmt.Errorf("pushing metrics to %s: %w", gatewayURL, err)
+	}
+	return nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sanitizeLabelName(name string) string {
+	name = invalidLabelChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
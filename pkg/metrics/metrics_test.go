@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	m, err := New(map[string]string{"kuttl.dev/run": "nightly"})
+	require.NoError(t, err)
+	m.AddTest("./test/e2e", "b-test", 1500*time.Millisecond, true, 3)
+	m.AddTest("./test/e2e", "a-test", 2*time.Second, false, 0)
+
+	var b bytes.Buffer
+	assert.NoError(t, m.Write(&b))
+
+	expected := `# HELP kuttl_test_assert_retries Number of times the asserts of a test case were retried before they passed or timed out.
+# TYPE kuttl_test_assert_retries gauge
+kuttl_test_assert_retries{kuttl_dev_run="nightly",suite="./test/e2e",test="a-test"} 0
+kuttl_test_assert_retries{kuttl_dev_run="nightly",suite="./test/e2e",test="b-test"} 3
+# HELP kuttl_test_duration_seconds Duration of a test case in seconds.
+# TYPE kuttl_test_duration_seconds gauge
+kuttl_test_duration_seconds{kuttl_dev_run="nightly",suite="./test/e2e",test="a-test"} 2
+kuttl_test_duration_seconds{kuttl_dev_run="nightly",suite="./test/e2e",test="b-test"} 1.5
+# HELP kuttl_test_failed Whether a test case failed (1) or passed (0).
+# TYPE kuttl_test_failed gauge
+kuttl_test_failed{kuttl_dev_run="nightly",suite="./test/e2e",test="a-test"} 0
+kuttl_test_failed{kuttl_dev_run="nightly",suite="./test/e2e",test="b-test"} 1
+# HELP kuttl_tests_total Number of test cases in a suite by result.
+# TYPE kuttl_tests_total gauge
+kuttl_tests_total{kuttl_dev_run="nightly",result="failed",suite="./test/e2e"} 1
+kuttl_tests_total{kuttl_dev_run="nightly",result="passed",suite="./test/e2e"} 1
+`
+	assert.Equal(t, expected, b.String())
+
+	// a test recorded again replaces its result
+	m.AddTest("./test/e2e", "b-test", time.Second, false, 0)
+	b.Reset()
+	assert.NoError(t, m.Write(&b))
+	assert.Contains(t, b.String(), `kuttl_tests_total{kuttl_dev_run="nightly",result="failed",suite="./test/e2e"} 0`)
+	assert.Contains(t, b.String(), `kuttl_tests_total{kuttl_dev_run="nightly",result="passed",suite="./test/e2e"} 2`)
+}
+
+func TestServeHTTP(t *testing.T) {
+	m, err := New(nil)
+	require.NoError(t, err)
+	m.AddTest("suite", "test", time.Second, false, 0)
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `kuttl_test_duration_seconds{suite="suite",test="test"} 1`)
+}
+
+func TestReservedLabels(t *testing.T) {
+	for _, label := range []string{"suite", "test", "result"} {
+		_, err := New(map[string]string{label: "e2e"})
+		assert.EqualError(t, err, `run label "`+label+`" is reserved for the label `+label+` of the metrics`)
+	}
+	assert.Equal(t, "_1_a", sanitizeLabelName("1.a"))
+}
+
+func TestPush(t *testing.T) {
+	var method, path string
+	var families []*dto.MetricFamily
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.EscapedPath()
+		decoder := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		for {
+			family := &dto.MetricFamily{}
+			if err := decoder.Decode(family); err != nil {
+				if err != io.EOF {
+					t.Error(err)
+				}
+				break
+			}
+			families = append(families, family)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	m, err := New(nil)
+	require.NoError(t, err)
+	m.AddTest("suite", "test", time.Second, false, 0)
+
+	assert.NoError(t, m.Push(context.TODO(), server.URL+"/", "kuttl/nightly"))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job@base64/a3V0dGwvbmlnaHRseQ", path)
+	require.Len(t, families, 4)
+	assert.Equal(t, "kuttl_test_duration_seconds", families[1].GetName())
+	assert.Equal(t, 1.0, families[1].GetMetric()[0].GetGauge().GetValue())
+}
+
+func TestPushFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	m, err := New(nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, m.Push(context.TODO(), server.URL, "kuttl"), "pushing metrics to "+server.URL)
+}
@@ -0,0 +1,430 @@
+package mock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// gRPC methods of the standard health and reflection services.
+const (
+	healthService         = "grpc.health.v1.Health"
+	healthCheckMethod     = healthService + "/Check"
+	reflectionMethod      = "grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+	reflectionAlphaMethod = "grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+)
+
+// gRPC status codes returned by the mock itself.
+const (
+	grpcNotFound      = 5
+	grpcInvalid       = 3
+	grpcUnimplemented = 12
+)
+
+// maxGRPCMessage is the maximum size of the gRPC requests read.
+const maxGRPCMessage = 4 * 1024 * 1024
+
+// healthStatuses are the serving statuses of grpc.health.v1.HealthCheckResponse which can be declared.
+var healthStatuses = map[string]uint64{"UNKNOWN": 0, "SERVING": 1, "NOT_SERVING": 2}
+
+// grpcMock serves the health service, server reflection and the declared responses of a mock gRPC service.
+type grpcMock struct {
+	health map[string]uint64
+	// files are the descriptors of the services, nil if the mock has none.
+	files *protoregistry.Files
+	// services are the services of the declared methods.
+	services map[string]bool
+	methods  map[string]grpcResponse
+	logf     func(format string, args ...interface{})
+}
+
+// grpcResponse are the encoded response messages or the status of a failed call.
+type grpcResponse struct {
+	messages [][]byte
+	code     int
+	status   string
+}
+
+// GRPCHandler returns a handler serving the mock gRPC service, the standard health service and server reflection of
+// the services of descriptors, a serialized FileDescriptorSet which may be empty.  Each call is logged with logf if it is
+// not nil.  It fails if a method or health status is invalid, or a response does not match its method.
+func GRPCHandler(config harness.MockGRPC, descriptors []byte, logf func(format string, args ...interface{})) (http.Handler, error) {
+	m := &grpcMock{health: map[string]uint64{}, services: map[string]bool{}, methods: map[string]grpcResponse{}, logf: logf}
+
+	if len(descriptors) > 0 {
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(descriptors, set); err != nil {
+			return nil, fmt.Errorf("reading descriptors: %w", err)
+		}
+		files, err := protodesc.NewFiles(set)
+		if err != nil {
+			return nil, fmt.Errorf("loading descriptors: %w", err)
+		}
+		m.files = files
+	}
+
+	for service, status := range config.Health {
+		value, ok := healthStatuses[status]
+		if !ok {
+			return nil, fmt.Errorf("invalid health status %q of service %q, must be SERVING, NOT_SERVING or UNKNOWN", status, service)
+		}
+		m.health[service] = value
+	}
+
+	for _, method := range config.Methods {
+		service, name, ok := strings.Cut(method.Method, "/")
+		if !ok || service == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid gRPC method %q, must be in the form <package>.<Service>/<Method>", method.Method)
+		}
+		if _, ok := m.methods[method.Method]; ok {
+			return nil, fmt.Errorf("gRPC method %s is declared twice", method.Method)
+		}
+		response, err := m.encodeResponse(method, service, name)
+		if err != nil {
+			return nil, fmt.Errorf("gRPC method %s: %w", method.Method, err)
+		}
+		m.services[service] = true
+		m.methods[method.Method] = response
+	}
+	return m, nil
+}
+
+// encodeResponse encodes the response of the method with the descriptor of its output message.
+func (m *grpcMock) encodeResponse(method harness.MockGRPCMethod, service, name string) (grpcResponse, error) {
+	if method.Code < 0 || method.Code > 16 {
+		return grpcResponse{}, fmt.Errorf("invalid status code %d", method.Code)
+	}
+	if method.Code != 0 {
+		return grpcResponse{code: method.Code, status: method.Message}, nil
+	}
+	if m.files == nil {
+		if method.Response != "" {
+			return grpcResponse{}, errors.New("the response needs the descriptors of the service")
+		}
+		return grpcResponse{messages: [][]byte{{}}}, nil
+	}
+
+	descriptor, err := m.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return grpcResponse{}, fmt.Errorf("finding service %s: %w", service, err)
+	}
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return grpcResponse{}, fmt.Errorf("%s is not a service", service)
+	}
+	methodDescriptor := serviceDescriptor.Methods().ByName(protoreflect.Name(name))
+	if methodDescriptor == nil {
+		return grpcResponse{}, fmt.Errorf("service %s has no method %s", service, name)
+	}
+	if methodDescriptor.IsStreamingClient() || methodDescriptor.IsStreamingServer() {
+		return grpcResponse{}, errors.New("the method is streaming, only unary methods can be mocked")
+	}
+
+	output := dynamicpb.NewMessage(methodDescriptor.Output())
+	if method.Response != "" {
+		if err := protojson.Unmarshal([]byte(method.Response), output); err != nil {
+			return grpcResponse{}, fmt.Errorf("parsing response: %w", err)
+		}
+	}
+	message, err := proto.Marshal(output)
+	if err != nil {
+		return grpcResponse{}, err
+	}
+	return grpcResponse{messages: [][]byte{message}}, nil
+}
+
+func (m *grpcMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/")
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, fmt.Sprintf("%s %s is not a gRPC call", r.Method, r.URL.Path), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var response grpcResponse
+	requests, err := readMessages(r.Body)
+	if err != nil {
+		response = grpcResponse{code: grpcInvalid, status: err.Error()}
+	} else {
+		response = m.call(method, requests)
+	}
+	writeResponse(w, response)
+	if m.logf != nil {
+		m.logf("%s: status %d", method, response.code)
+	}
+}
+
+// call returns the response of the method to the request messages.
+func (m *grpcMock) call(method string, requests [][]byte) grpcResponse {
+	switch method {
+	case healthCheckMethod:
+		if len(requests) != 1 {
+			return grpcResponse{code: grpcInvalid, status: fmt.Sprintf("%d requests received, expected 1", len(requests))}
+		}
+		return m.check(requests[0])
+	case reflectionMethod, reflectionAlphaMethod:
+		if m.files == nil {
+			return grpcResponse{code: grpcUnimplemented, status: "the mock has no descriptors to reflect"}
+		}
+		return m.reflect(requests)
+	}
+
+	response, ok := m.methods[method]
+	if !ok {
+		return grpcResponse{code: grpcUnimplemented, status: "unknown method " + method}
+	}
+	if response.code == 0 && len(requests) != 1 {
+		return grpcResponse{code: grpcInvalid, status: fmt.Sprintf("%d requests received, expected 1", len(requests))}
+	}
+	return response
+}
+
+// check responds to a health check with the status of the service of the request.
+func (m *grpcMock) check(request []byte) grpcResponse {
+	service := ""
+	err := parseFields(request, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		v, n := protowire.ConsumeString(value)
+		service = v
+		return protowire.ParseError(n)
+	})
+	if err != nil {
+		return grpcResponse{code: grpcInvalid, status: fmt.Sprintf("parsing health check request: %v", err)}
+	}
+
+	status, ok := m.health[service]
+	if !ok {
+		if service != "" && !m.services[service] && !m.hasService(service) {
+			return grpcResponse{code: grpcNotFound, status: "unknown service " + service}
+		}
+		status = healthStatuses["SERVING"]
+	}
+	message := protowire.AppendTag(nil, 1, protowire.VarintType)
+	return grpcResponse{messages: [][]byte{protowire.AppendVarint(message, status)}}
+}
+
+// hasService returns true if the service is in the descriptors of the mock.
+func (m *grpcMock) hasService(service string) bool {
+	if m.files == nil {
+		return false
+	}
+	descriptor, err := m.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return false
+	}
+	_, ok := descriptor.(protoreflect.ServiceDescriptor)
+	return ok
+}
+
+// reflect responds to each server reflection request, listing the services or returning the descriptors of a file
+// and of the files it imports.
+func (m *grpcMock) reflect(requests [][]byte) grpcResponse {
+	responses := [][]byte{}
+	for _, request := range requests {
+		response := protowire.AppendTag(nil, 2, protowire.BytesType)
+		response = protowire.AppendBytes(response, request)
+
+		var file protoreflect.FileDescriptor
+		var lookupErr error
+		listServices := false
+		err := parseFields(request, func(num protowire.Number, typ protowire.Type, value []byte) error {
+			if typ != protowire.BytesType {
+				return nil
+			}
+			field, n := protowire.ConsumeString(value)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			switch num {
+			case 3:
+				file, lookupErr = m.files.FindFileByPath(field)
+			case 4:
+				var descriptor protoreflect.Descriptor
+				if descriptor, lookupErr = m.files.FindDescriptorByName(protoreflect.FullName(field)); lookupErr == nil {
+					file = descriptor.ParentFile()
+				}
+			case 7:
+				listServices = true
+			}
+			return nil
+		})
+
+		switch {
+		case err != nil:
+			response = appendReflectionError(response, grpcInvalid, fmt.Sprintf("parsing reflection request: %v", err))
+		case lookupErr != nil:
+			response = appendReflectionError(response, grpcNotFound, lookupErr.Error())
+		case file != nil:
+			descriptors, err := fileDescriptors(file)
+			if err != nil {
+				return grpcResponse{code: grpcInvalid, status: err.Error()}
+			}
+			response = protowire.AppendTag(response, 4, protowire.BytesType)
+			response = protowire.AppendBytes(response, descriptors)
+		case listServices:
+			response = protowire.AppendTag(response, 6, protowire.BytesType)
+			response = protowire.AppendBytes(response, m.listServices())
+		default:
+			response = appendReflectionError(response, grpcUnimplemented, "unsupported reflection request")
+		}
+		responses = append(responses, response)
+	}
+	return grpcResponse{messages: responses}
+}
+
+// fileDescriptors returns the FileDescriptorResponse with the serialized descriptors of the file and of the files it
+// imports, directly or not.
+func fileDescriptors(file protoreflect.FileDescriptor) ([]byte, error) {
+	seen := map[string]bool{}
+	response := []byte{}
+	var add func(file protoreflect.FileDescriptor) error
+	add = func(file protoreflect.FileDescriptor) error {
+		if seen[file.Path()] {
+			return nil
+		}
+		seen[file.Path()] = true
+		data, err := proto.Marshal(protodesc.ToFileDescriptorProto(file))
+		if err != nil {
+			return err
+		}
+		response = protowire.AppendTag(response, 1, protowire.BytesType)
+		response = protowire.AppendBytes(response, data)
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			if err := add(imports.Get(i).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(file); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// listServices returns the ListServiceResponse with the services of the descriptors and the health service.
+func (m *grpcMock) listServices() []byte {
+	names := []string{healthService}
+	m.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			names = append(names, string(services.Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(names)
+
+	response := []byte{}
+	for _, name := range names {
+		service := protowire.AppendTag(nil, 1, protowire.BytesType)
+		service = protowire.AppendString(service, name)
+		response = protowire.AppendTag(response, 1, protowire.BytesType)
+		response = protowire.AppendBytes(response, service)
+	}
+	return response
+}
+
+// appendReflectionError appends the ErrorResponse of a reflection request to its response.
+func appendReflectionError(response []byte, code int, message string) []byte {
+	errorResponse := protowire.AppendTag(nil, 1, protowire.VarintType)
+	errorResponse = protowire.AppendVarint(errorResponse, uint64(code))
+	errorResponse = protowire.AppendTag(errorResponse, 2, protowire.BytesType)
+	errorResponse = protowire.AppendString(errorResponse, message)
+	response = protowire.AppendTag(response, 7, protowire.BytesType)
+	return protowire.AppendBytes(response, errorResponse)
+}
+
+// writeResponse writes the response messages of a call with the OK status, or only the status of a failed call.
+func writeResponse(w http.ResponseWriter, response grpcResponse) {
+	w.Header().Set("Content-Type", "application/grpc")
+	if response.code != 0 {
+		w.Header().Set("Grpc-Status", fmt.Sprint(response.code))
+		w.Header().Set("Grpc-Message", url.PathEscape(response.status))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	for _, message := range response.messages {
+		// each message is prefixed with the uncompressed flag and its length
+		frame := make([]byte, 5, 5+len(message))
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+		_, _ = w.Write(append(frame, message...))
+	}
+	w.Header().Set("Grpc-Status", "0")
+}
+
+// readMessages reads the messages of a gRPC request.
+func readMessages(body io.Reader) ([][]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxGRPCMessage))
+	if err != nil {
+		return nil, err
+	}
+	messages := [][]byte{}
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return nil, errors.New("truncated request")
+		}
+		if data[0] != 0 {
+			return nil, errors.New("compressed requests are not supported")
+		}
+		length := binary.BigEndian.Uint32(data[1:5])
+		if uint32(len(data)-5) < length {
+			return nil, errors.New("truncated request")
+		}
+		messages = append(messages, data[5:5+length])
+		data = data[5+length:]
+	}
+	return messages, nil
+}
+
+// parseFields calls field with the number, type and the encoded value of each field of the protobuf message.
+func parseFields(message []byte, field func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		message = message[n:]
+		n = protowire.ConsumeFieldValue(num, typ, message)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := field(num, typ, message[:n]); err != nil {
+			return err
+		}
+		message = message[n:]
+	}
+	return nil
+}
+
+// ServeGRPC configures server to serve gRPC over HTTP/2, with TLS if it has a TLS configuration and over cleartext
+// HTTP/2 otherwise.
+func ServeGRPC(server *http.Server) error {
+	if server.TLSConfig == nil {
+		server.Handler = h2c.NewHandler(server.Handler, &http2.Server{})
+		return nil
+	}
+	return http2.ConfigureServer(server, nil)
+}
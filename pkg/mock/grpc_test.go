@@ -0,0 +1,129 @@
+package mock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/probe"
+)
+
+// licensesDescriptors is a FileDescriptorSet with the test.v1.Licenses service.
+func licensesDescriptors(t *testing.T) []byte {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{Name: proto.String(name), JsonName: proto.String(name), Number: proto.Int32(number),
+			Type: typ.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()}
+	}
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("test/v1/licenses.proto"),
+		Package: proto.String("test.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)}},
+			{Name: proto.String("License"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("valid", 2, descriptorpb.FieldDescriptorProto_TYPE_BOOL),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Licenses"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Get"), InputType: proto.String(".test.v1.GetRequest"), OutputType: proto.String(".test.v1.License")},
+				{Name: proto.String("Revoke"), InputType: proto.String(".test.v1.GetRequest"), OutputType: proto.String(".test.v1.License")},
+				{Name: proto.String("Watch"), InputType: proto.String(".test.v1.GetRequest"), OutputType: proto.String(".test.v1.License"),
+					ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}}}
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+func TestGRPCHandler(t *testing.T) {
+	handler, err := GRPCHandler(harness.MockGRPC{
+		Health: map[string]string{"test.v1.Billing": "NOT_SERVING"},
+		Methods: []harness.MockGRPCMethod{
+			{Method: "test.v1.Licenses/Get", Response: `{"id": "l-1", "valid": true}`},
+			{Method: "test.v1.Licenses/Revoke", Code: 7, Message: "revoking licenses is not allowed"},
+		},
+	}, licensesDescriptors(t), nil)
+	require.NoError(t, err)
+	server := &http.Server{Handler: handler}
+	require.NoError(t, ServeGRPC(server))
+	backend := httptest.NewServer(server.Handler)
+	defer backend.Close()
+	address := strings.TrimPrefix(backend.URL, "http://")
+
+	for _, test := range []struct {
+		name  string
+		probe probe.Probe
+		err   string
+	}{
+		{name: "server health", probe: probe.Probe{}},
+		{name: "service health", probe: probe.Probe{GRPCService: "test.v1.Licenses"}},
+		{name: "declared health", probe: probe.Probe{GRPCService: "test.v1.Billing", GRPCResponse: `{"status": "NOT_SERVING"}`}},
+		{name: "unknown service health", probe: probe.Probe{GRPCService: "test.v1.Orders"},
+			err: "call of grpc.health.v1.Health/Check failed with status 5: unknown service test.v1.Orders"},
+		{name: "method", probe: probe.Probe{GRPCMethod: "test.v1.Licenses/Get", GRPCRequest: `{"id": "l-1"}`, GRPCResponse: `{"id": "l-1", "valid": true}`}},
+		{name: "failing method", probe: probe.Probe{GRPCMethod: "test.v1.Licenses/Revoke"},
+			err: "call of test.v1.Licenses/Revoke failed with status 7: revoking licenses is not allowed"},
+		{name: "unknown symbol", probe: probe.Probe{GRPCMethod: "test.v1.Orders/Get"},
+			err: "looking up test.v1.Orders with server reflection"},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			test.probe.Protocol = probe.GRPC
+			test.probe.Address = address
+			err := test.probe.Run(context.TODO(), 5*time.Second)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestGRPCHandlerErrors(t *testing.T) {
+	descriptors := licensesDescriptors(t)
+	for _, test := range []struct {
+		name        string
+		config      harness.MockGRPC
+		descriptors []byte
+		err         string
+	}{
+		{name: "method", config: harness.MockGRPC{Methods: []harness.MockGRPCMethod{{Method: "Licenses.Get"}}},
+			err: `invalid gRPC method "Licenses.Get", must be in the form <package>.<Service>/<Method>`},
+		{name: "health", config: harness.MockGRPC{Health: map[string]string{"": "DOWN"}},
+			err: `invalid health status "DOWN" of service "", must be SERVING, NOT_SERVING or UNKNOWN`},
+		{name: "response without descriptors", config: harness.MockGRPC{Methods: []harness.MockGRPCMethod{{Method: "test.v1.Licenses/Get", Response: `{"valid": true}`}}},
+			err: "gRPC method test.v1.Licenses/Get: the response needs the descriptors of the service"},
+		{name: "unknown field", config: harness.MockGRPC{Methods: []harness.MockGRPCMethod{{Method: "test.v1.Licenses/Get", Response: `{"owner": "me"}`}}},
+			descriptors: descriptors, err: "gRPC method test.v1.Licenses/Get: parsing response"},
+		{name: "unknown method", config: harness.MockGRPC{Methods: []harness.MockGRPCMethod{{Method: "test.v1.Licenses/List"}}},
+			descriptors: descriptors, err: "gRPC method test.v1.Licenses/List: service test.v1.Licenses has no method List"},
+		{name: "streaming", config: harness.MockGRPC{Methods: []harness.MockGRPCMethod{{Method: "test.v1.Licenses/Watch"}}},
+			descriptors: descriptors, err: "the method is streaming, only unary methods can be mocked"},
+		{name: "status code", config: harness.MockGRPC{Methods: []harness.MockGRPCMethod{{Method: "test.v1.Licenses/Get", Code: 17}}},
+			err: "gRPC method test.v1.Licenses/Get: invalid status code 17"},
+		{name: "descriptors", descriptors: []byte("not a descriptor set"), err: "reading descriptors"},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			_, err := GRPCHandler(test.config, test.descriptors, nil)
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package mock serves mocks of the external HTTP and gRPC services an operator under test calls, ex. cloud provider
+// APIs or license servers, with the declarative responses of their routes or methods.
+package mock
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// Validate fails if a route has no path or an invalid status code.
+func Validate(routes []harness.MockRoute) error {
+	for i, route := range routes {
+		if !strings.HasPrefix(route.Path, "/") {
+			return fmt.Errorf("route %d: the path %q does not start with /", i+1, route.Path)
+		}
+		if route.Status != 0 && (route.Status < 100 || route.Status > 599) {
+			return fmt.Errorf("route %d: invalid status code %d", i+1, route.Status)
+		}
+	}
+	return nil
+}
+
+// matches returns true if the request matches the method and path of the route.
+func matches(route harness.MockRoute, r *http.Request) bool {
+	if route.Method != "" && !strings.EqualFold(route.Method, r.Method) {
+		return false
+	}
+	if prefix := strings.TrimSuffix(route.Path, "*"); prefix != route.Path {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}
+	return r.URL.Path == route.Path
+}
+
+// Handler returns a handler responding to requests with the first route they match, and with a 404 response to the
+// requests matching no route.  Each request is logged with logf if it is not nil.
+func Handler(routes []harness.MockRoute, logf func(format string, args ...interface{})) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, route := range routes {
+			if !matches(route, r) {
+				continue
+			}
+			status := route.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			for name, value := range route.Headers {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(route.Body))
+			if logf != nil {
+				logf("%s %s: %d", r.Method, r.URL.Path, status)
+			}
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("no mock route for %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+		if logf != nil {
+			logf("%s %s: no route", r.Method, r.URL.Path)
+		}
+	})
+}
+
+// Certificate is a serving certificate and the certificate of the CA which signed it, PEM encoded.
+type Certificate struct {
+	CA   []byte
+	Cert []byte
+	Key  []byte
+}
+
+// NewCertificate returns a serving certificate for the hosts (DNS names or IP addresses) signed by a new CA, both
+// valid for a day.
+func NewCertificate(hosts []string) (*Certificate, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("a certificate needs at least one host")
+	}
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kuttl mock CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			cert.IPAddresses = append(cert.IPAddresses, ip)
+		} else {
+			cert.DNSNames = append(cert.DNSNames, host)
+		}
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, cert, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificate{
+		CA:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
@@ -0,0 +1,82 @@
+package mock
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]harness.MockRoute{{Path: "/"}, {Path: "/v1/*", Status: 503}}))
+	assert.EqualError(t, Validate([]harness.MockRoute{{Path: "/"}, {Path: "v1"}}), `route 2: the path "v1" does not start with /`)
+	assert.EqualError(t, Validate([]harness.MockRoute{{Path: "/", Status: 99}}), "route 1: invalid status code 99")
+}
+
+func TestHandler(t *testing.T) {
+	routes := []harness.MockRoute{
+		{Method: "POST", Path: "/v1/instances", Status: http.StatusCreated, Body: `{"id":"i-1"}`,
+			Headers: map[string]string{"Content-Type": "application/json"}},
+		{Path: "/v1/instances/*", Body: `{"state":"running"}`},
+		{Path: "/v1/license", Status: http.StatusPaymentRequired},
+	}
+	logged := []string{}
+	server := httptest.NewServer(Handler(routes, func(format string, args ...interface{}) {
+		logged = append(logged, strings.TrimSpace(format))
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{method: "POST", path: "/v1/instances", status: http.StatusCreated, body: `{"id":"i-1"}`},
+		{method: "GET", path: "/v1/instances", status: http.StatusNotFound, body: "no mock route for GET /v1/instances\n"},
+		{method: "DELETE", path: "/v1/instances/i-1", status: http.StatusOK, body: `{"state":"running"}`},
+		{method: "GET", path: "/v1/license", status: http.StatusPaymentRequired},
+	} {
+		req, err := http.NewRequest(test.method, server.URL+test.path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, test.status, resp.StatusCode, test.path)
+		assert.Equal(t, test.body, string(body), test.path)
+	}
+	assert.Len(t, logged, 4)
+}
+
+func TestNewCertificate(t *testing.T) {
+	cert, err := NewCertificate([]string{"api.world.svc", "127.0.0.1"})
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(cert.CA))
+	block, _ := pem.Decode(cert.Cert)
+	require.NotNil(t, block)
+	serving, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	for _, host := range []string{"api.world.svc", "127.0.0.1"} {
+		_, err = serving.Verify(x509.VerifyOptions{DNSName: host, Roots: roots})
+		assert.NoError(t, err, host)
+	}
+	_, err = serving.Verify(x509.VerifyOptions{DNSName: "other.world.svc", Roots: roots})
+	assert.Error(t, err)
+
+	_, err = NewCertificate(nil)
+	assert.EqualError(t, err, "a certificate needs at least one host")
+}
@@ -0,0 +1,154 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// configFile is the name of the test suite configuration in the root of a test source.
+const configFile = "kuttl-test.yaml"
+
+// configMapSeparator separates the test name and the file name in the keys of a ConfigMap source.
+const configMapSeparator = "__"
+
+// checkout writes the tests of the source of run into dir and returns the directory of the test suite.
+func (r *TestRunReconciler) checkout(ctx context.Context, run *harness.TestRun, dir string) (string, error) {
+	source := run.Spec.Source
+
+	switch {
+	case source.ConfigMap != "" && source.Git != nil:
+		return "", errors.New("only one of configMap or git can be set as the source")
+	case source.ConfigMap != "":
+		cm := &corev1.ConfigMap{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: source.ConfigMap}, cm); err != nil {
+			return "", fmt.Errorf("retrieving ConfigMap %s: %w", source.ConfigMap, err)
+		}
+		if err := writeConfigMap(cm, dir); err != nil {
+			return "", fmt.Errorf("writing tests of ConfigMap %s: %w", source.ConfigMap, err)
+		}
+		return dir, nil
+	case source.Git != nil:
+		if err := r.clone(ctx, source.Git, dir); err != nil {
+			return "", fmt.Errorf("cloning %s: %w", source.Git.Repository, err)
+		}
+		return filepath.Join(dir, filepath.FromSlash(source.Git.Path)), nil
+	default:
+		return "", errors.New("one of configMap or git must be set as the source")
+	}
+}
+
+// writeConfigMap writes the test files of a ConfigMap source into dir.
+func writeConfigMap(cm *corev1.ConfigMap, dir string) error {
+	files := map[string][]byte{}
+	for key, value := range cm.Data {
+		files[key] = []byte(value)
+	}
+	for key, value := range cm.BinaryData {
+		files[key] = value
+	}
+
+	for key, content := range files {
+		path := filepath.Join(dir, key)
+		if test, file, found := strings.Cut(key, configMapSeparator); found {
+			if test == "" || file == "" || test == "." || test == ".." {
+				return fmt.Errorf("key %q must be of the form <test>%s<file>", key, configMapSeparator)
+			}
+			path = filepath.Join(dir, test, file)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clone clones a git source into dir.
+func (r *TestRunReconciler) clone(ctx context.Context, source *harness.GitSource, dir string) error {
+	args := []string{"clone", "--depth", "1"}
+	if source.Revision != "" {
+		args = append(args, "--branch", source.Revision)
+	}
+	args = append(args, "--", source.Repository, dir)
+
+	output, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeSuite writes the TestSuite of run as the test suite configuration of suiteDir.
+func (r *TestRunReconciler) writeSuite(ctx context.Context, run *harness.TestRun, suiteDir string) error {
+	suite := &unstructured.Unstructured{}
+	suite.SetGroupVersionKind(harness.GroupVersion.WithKind("TestSuite"))
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: run.Spec.Suite}, suite); err != nil {
+		return fmt.Errorf("retrieving TestSuite %s: %w", run.Spec.Suite, err)
+	}
+
+	// the configuration only needs the settings of the suite, JSON is written as it is valid YAML
+	unstructured.RemoveNestedField(suite.Object, "metadata")
+	unstructured.RemoveNestedField(suite.Object, "status")
+
+	data, err := json.Marshal(suite.Object)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(suiteDir, configFile), data, 0644)
+}
+
+// testArgs returns the arguments of `kubectl kuttl test` for the test suite in suiteDir.  If the suite has no
+// configuration or its configuration has no test directories, the suite directory itself is the test directory.
+func testArgs(run *harness.TestRun, suiteDir, artifactsDir string) ([]string, error) {
+	args := []string{"test", "--artifacts-dir", artifactsDir, "--report", "json", "--report-name", report.DefaultName}
+
+	testDirs := true
+	config := filepath.Join(suiteDir, configFile)
+	if _, err := os.Stat(config); err == nil {
+		suite, err := loadSuite(config)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--config", config)
+		testDirs = len(suite.TestDirs) == 0
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	args = append(args, run.Spec.Args...)
+	if testDirs {
+		args = append(args, suiteDir)
+	}
+	return args, nil
+}
+
+// loadSuite loads the TestSuite of a test suite configuration file.
+func loadSuite(path string) (*harness.TestSuite, error) {
+	objects, err := testutils.LoadYAMLFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+	for _, obj := range objects {
+		if suite, ok := obj.(*harness.TestSuite); ok {
+			return suite, nil
+		}
+	}
+	return nil, fmt.Errorf("no TestSuite found in %s", path)
+}
@@ -0,0 +1,220 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+// Runner runs kuttl with args in dir.  An error is expected for failing tests, the result of the run is read from
+// the report in the artifacts directory.
+type Runner func(ctx context.Context, dir string, args []string) error
+
+// ExecRunner returns a Runner which runs the kuttl executable at path, its output is written to the output of the
+// operator.
+func ExecRunner(path string) Runner {
+	return func(ctx context.Context, dir string, args []string) error {
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Dir = dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+}
+
+// TestRunReconciler runs the test suites of TestRuns and writes their results to the TestRun status.
+// A TestRun is run when it is created, when its spec changes and every interval after the start of its last run.
+type TestRunReconciler struct {
+	Client client.Client
+	// Runner runs kuttl for a TestRun.
+	Runner Runner
+	// WorkDir is the directory the tests are checked out into, the default directory for temporary files is used
+	// if it is empty.
+	WorkDir string
+	// MaxConcurrentRuns is the maximum number of TestRuns to run at once (default 1).
+	MaxConcurrentRuns int
+
+	// now returns the current time, it can be replaced in tests.
+	now func() time.Time
+}
+
+// SetupWithManager registers the reconciler with a manager.
+func (r *TestRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&harness.TestRun{}).
+		// status updates must not trigger a new run
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: max(r.MaxConcurrentRuns, 1)}).
+		Complete(r)
+}
+
+// Reconcile runs the tests of a TestRun if a run is due.
+func (r *TestRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	run := &harness.TestRun{}
+	if err := r.Client.Get(ctx, req.NamespacedName, run); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	now := r.currentTime()
+	if due, next := nextRun(run, now); !due {
+		return ctrl.Result{RequeueAfter: next}, nil
+	}
+
+	logger.Info("running tests", "generation", run.Generation)
+	start := metav1.NewTime(now)
+	if err := r.updateStatus(ctx, run, func(status *harness.TestRunStatus) {
+		*status = harness.TestRunStatus{
+			Phase:              harness.TestRunRunning,
+			ObservedGeneration: status.ObservedGeneration,
+			StartTime:          &start,
+		}
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	result := r.runTests(ctx, run)
+	result.ObservedGeneration = run.Generation
+	result.StartTime = &start
+	completion := metav1.NewTime(r.currentTime())
+	result.CompletionTime = &completion
+
+	logger.Info("tests finished", "phase", result.Phase, "tests", result.Tests, "failures", result.Failures)
+	if err := r.updateStatus(ctx, run, func(status *harness.TestRunStatus) {
+		*status = result
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	run.Status = result
+	_, next := nextRun(run, r.currentTime())
+	return ctrl.Result{RequeueAfter: next}, nil
+}
+
+// runTests checks out and runs the tests of run and returns the resulting status.
+func (r *TestRunReconciler) runTests(ctx context.Context, run *harness.TestRun) harness.TestRunStatus {
+	failed := func(err error) harness.TestRunStatus {
+		return harness.TestRunStatus{Phase: harness.TestRunError, Message: err.Error()}
+	}
+
+	dir, err := os.MkdirTemp(r.WorkDir, fmt.Sprintf("kuttl-%s-%s-", run.Namespace, run.Name))
+	if err != nil {
+		return failed(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sourceDir := filepath.Join(dir, "source")
+	artifactsDir := filepath.Join(dir, "artifacts")
+	for _, d := range []string{sourceDir, artifactsDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			return failed(err)
+		}
+	}
+
+	suiteDir, err := r.checkout(ctx, run, sourceDir)
+	if err != nil {
+		return failed(err)
+	}
+	if run.Spec.Suite != "" {
+		if err := r.writeSuite(ctx, run, suiteDir); err != nil {
+			return failed(err)
+		}
+	}
+
+	args, err := testArgs(run, suiteDir, artifactsDir)
+	if err != nil {
+		return failed(err)
+	}
+
+	runErr := r.Runner(ctx, suiteDir, args)
+	ts, err := report.Load(artifactsDir)
+	if err != nil {
+		if runErr != nil {
+			return failed(fmt.Errorf("running tests: %w", runErr))
+		}
+		return failed(err)
+	}
+
+	return reportStatus(ts)
+}
+
+// reportStatus returns the status of a run with the report ts.
+func reportStatus(ts *report.Testsuites) harness.TestRunStatus {
+	status := harness.TestRunStatus{Phase: harness.TestRunSucceeded}
+	if ts.Failure != nil {
+		status.Phase = harness.TestRunFailed
+		status.Message = ts.Failure.Message
+	}
+
+	for _, suite := range ts.Testsuite {
+		for _, testcase := range suite.Testcase {
+			result := harness.TestResult{
+				Name:     suite.Name + "/" + testcase.Name,
+				Passed:   testcase.Failure == nil,
+				Duration: testcase.Time,
+			}
+			if testcase.Failure != nil {
+				result.Failure = testcase.Failure.Message
+				status.Failures++
+				status.Phase = harness.TestRunFailed
+			}
+			status.Results = append(status.Results, result)
+			status.Tests++
+		}
+	}
+	return status
+}
+
+// nextRun returns true if run is due at now.  Otherwise it returns the time until the next run, or 0 if there is
+// no next run.
+func nextRun(run *harness.TestRun, now time.Time) (bool, time.Duration) {
+	status := run.Status
+	// a run which is still running when reconciled was interrupted by a restart of the operator
+	if status.StartTime == nil || status.ObservedGeneration != run.Generation || status.Phase == harness.TestRunRunning {
+		return true, 0
+	}
+	if run.Spec.Interval == nil || run.Spec.Interval.Duration <= 0 {
+		return false, 0
+	}
+
+	next := status.StartTime.Add(run.Spec.Interval.Duration).Sub(now)
+	if next <= 0 {
+		return true, 0
+	}
+	return false, next
+}
+
+// updateStatus updates the status of the latest version of run with update, retrying on conflicts.  run itself is
+// not modified so that the run uses the spec it was started with.
+func (r *TestRunReconciler) updateStatus(ctx context.Context, run *harness.TestRun, update func(*harness.TestRunStatus)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &harness.TestRun{}
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(run), latest); err != nil {
+			return err
+		}
+		update(&latest.Status)
+		return r.Client.Status().Update(ctx, latest)
+	})
+}
+
+func (r *TestRunReconciler) currentTime() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,209 @@
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, harness.AddToScheme(scheme))
+	return scheme
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	started := func(ago time.Duration) *metav1.Time {
+		start := metav1.NewTime(now.Add(-ago))
+		return &start
+	}
+
+	for _, test := range []struct {
+		name     string
+		interval *metav1.Duration
+		status   harness.TestRunStatus
+		due      bool
+		next     time.Duration
+	}{
+		{
+			name: "never run",
+			due:  true,
+		},
+		{
+			name:   "run once",
+			status: harness.TestRunStatus{Phase: harness.TestRunSucceeded, ObservedGeneration: 2, StartTime: started(time.Hour)},
+		},
+		{
+			name:   "spec changed",
+			status: harness.TestRunStatus{Phase: harness.TestRunSucceeded, ObservedGeneration: 1, StartTime: started(time.Hour)},
+			due:    true,
+		},
+		{
+			name:   "interrupted run",
+			status: harness.TestRunStatus{Phase: harness.TestRunRunning, ObservedGeneration: 2, StartTime: started(time.Minute)},
+			due:    true,
+		},
+		{
+			name:     "interval not elapsed",
+			interval: &metav1.Duration{Duration: 2 * time.Hour},
+			status:   harness.TestRunStatus{Phase: harness.TestRunFailed, ObservedGeneration: 2, StartTime: started(time.Hour)},
+			next:     time.Hour,
+		},
+		{
+			name:     "interval elapsed",
+			interval: &metav1.Duration{Duration: time.Hour},
+			status:   harness.TestRunStatus{Phase: harness.TestRunFailed, ObservedGeneration: 2, StartTime: started(time.Hour)},
+			due:      true,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			run := &harness.TestRun{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       harness.TestRunSpec{Interval: test.interval},
+				Status:     test.status,
+			}
+
+			due, next := nextRun(run, now)
+			assert.Equal(t, test.due, due)
+			assert.Equal(t, test.next, next)
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	run := &harness.TestRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "conformance", Namespace: "default", Generation: 1},
+		Spec: harness.TestRunSpec{
+			Source:   harness.TestRunSource{ConfigMap: "tests"},
+			Interval: &metav1.Duration{Duration: time.Hour},
+			Args:     []string{"--parallel=1"},
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "tests", Namespace: "default"},
+		Data: map[string]string{
+			"my-test__00-assert.yaml": "apiVersion: v1\nkind: Pod\n",
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(run, cm).Build()
+
+	var ranArgs []string
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &TestRunReconciler{
+		Client: cl,
+		Runner: func(ctx context.Context, dir string, args []string) error {
+			ranArgs = args
+			assert.FileExists(t, filepath.Join(dir, "my-test", "00-assert.yaml"))
+
+			ts := report.NewSuiteCollection("")
+			suite := ts.NewSuite(dir)
+			suite.AddTestcase(&report.Testcase{Name: "my-test", Time: "1.5"})
+			suite.AddTestcase(&report.Testcase{Name: "other-test", Time: "2", Failure: &report.Failure{Message: "failed"}})
+			return ts.Report(args[2], report.DefaultName, report.JSON)
+		},
+		now: func() time.Time { return now },
+	}
+
+	result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, result.RequeueAfter)
+
+	assert.Equal(t, "test", ranArgs[0])
+	assert.Contains(t, ranArgs, "--parallel=1")
+	assert.NotContains(t, ranArgs, "--config")
+
+	updated := &harness.TestRun{}
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(run), updated))
+	assert.Equal(t, harness.TestRunFailed, updated.Status.Phase)
+	assert.Equal(t, int64(1), updated.Status.ObservedGeneration)
+	assert.Equal(t, 2, updated.Status.Tests)
+	assert.Equal(t, 1, updated.Status.Failures)
+	assert.Len(t, updated.Status.Results, 2)
+	assert.Equal(t, "failed", updated.Status.Results[1].Failure)
+	assert.NotNil(t, updated.Status.CompletionTime)
+
+	// the run is not due again before the interval elapsed
+	ranArgs = nil
+	now = now.Add(30 * time.Minute)
+	result, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)})
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, result.RequeueAfter)
+	assert.Nil(t, ranArgs)
+}
+
+func TestReconcileMissingSource(t *testing.T) {
+	run := &harness.TestRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "conformance", Namespace: "default"},
+		Spec:       harness.TestRunSpec{Source: harness.TestRunSource{ConfigMap: "missing"}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(run).Build()
+
+	r := &TestRunReconciler{
+		Client: cl,
+		Runner: func(context.Context, string, []string) error {
+			t.Fatal("tests should not run without source")
+			return nil
+		},
+	}
+
+	result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)})
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+
+	updated := &harness.TestRun{}
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(run), updated))
+	assert.Equal(t, harness.TestRunError, updated.Status.Phase)
+	assert.Contains(t, updated.Status.Message, "retrieving ConfigMap missing")
+}
+
+func TestTestArgs(t *testing.T) {
+	dir := t.TempDir()
+	run := &harness.TestRun{Spec: harness.TestRunSpec{Args: []string{"--parallel=2"}}}
+
+	args, err := testArgs(run, dir, "artifacts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test", "--artifacts-dir", "artifacts", "--report", "json", "--report-name", report.DefaultName, "--parallel=2", dir}, args)
+
+	config := filepath.Join(dir, configFile)
+	assert.NoError(t, os.WriteFile(config, []byte("apiVersion: kuttl.dev/v1beta1\nkind: TestSuite\ntestDirs:\n- ./e2e\n"), 0600))
+	args, err = testArgs(run, dir, "artifacts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test", "--artifacts-dir", "artifacts", "--report", "json", "--report-name", report.DefaultName, "--config", config, "--parallel=2"}, args)
+}
+
+func TestWriteConfigMap(t *testing.T) {
+	dir := t.TempDir()
+	cm := &corev1.ConfigMap{
+		Data: map[string]string{
+			"kuttl-test.yaml":          "kind: TestSuite",
+			"my-test__00-install.yaml": "kind: Pod",
+		},
+		BinaryData: map[string][]byte{"my-test__data.bin": {0, 1}},
+	}
+
+	assert.NoError(t, writeConfigMap(cm, dir))
+	assert.FileExists(t, filepath.Join(dir, "kuttl-test.yaml"))
+	assert.FileExists(t, filepath.Join(dir, "my-test", "00-install.yaml"))
+	assert.FileExists(t, filepath.Join(dir, "my-test", "data.bin"))
+
+	assert.Error(t, writeConfigMap(&corev1.ConfigMap{Data: map[string]string{"__file.yaml": ""}}, t.TempDir()))
+	assert.Error(t, writeConfigMap(&corev1.ConfigMap{Data: map[string]string{"..__file.yaml": ""}}, t.TempDir()))
+}
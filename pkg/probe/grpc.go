@@ -0,0 +1,358 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// gRPC methods of the standard health and reflection services.
+const (
+	healthCheckMethod = "grpc.health.v1.Health/Check"
+	reflectionMethod  = "grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+	// reflectionAlphaMethod is the reflection service of servers which do not implement v1 yet.
+	reflectionAlphaMethod = "grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+)
+
+// grpcUnimplemented is the status of calls to methods the server does not implement.
+const grpcUnimplemented = "12"
+
+// maxGRPCMessage is the maximum size of the gRPC responses read.
+const maxGRPCMessage = 4 * 1024 * 1024
+
+// healthStatuses are the names of the serving statuses of grpc.health.v1.HealthCheckResponse.
+var healthStatuses = map[uint64]string{0: "UNKNOWN", 1: "SERVING", 2: "NOT_SERVING", 3: "SERVICE_UNKNOWN"}
+
+// grpcError is the non-OK status a gRPC call completed with.
+type grpcError struct {
+	method  string
+	code    string
+	message string
+}
+
+func (e *grpcError) Error() string {
+	return fmt.Sprintf("call of %s failed with status %s: %s", e.method, e.code, e.message)
+}
+
+// validateGRPC fails if the method of a gRPC probe is not in the form "<service>/<method>" or its request or response
+// are not JSON.
+func (p Probe) validateGRPC() error {
+	if p.GRPCMethod != "" {
+		service, method, ok := strings.Cut(p.GRPCMethod, "/")
+		if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+			return fmt.Errorf("invalid gRPC method %q, must be in the form <package>.<Service>/<Method>", p.GRPCMethod)
+		}
+		if p.GRPCService != "" {
+			return errors.New("a gRPC service can only be set for health checks, not with a gRPC method")
+		}
+	} else if p.GRPCRequest != "" {
+		return errors.New("a gRPC request can only be set with a gRPC method")
+	}
+	for name, value := range map[string]string{"request": p.GRPCRequest, "response": p.GRPCResponse} {
+		if value != "" && !json.Valid([]byte(value)) {
+			return fmt.Errorf("gRPC %s is not valid JSON: %s", name, value)
+		}
+	}
+	return nil
+}
+
+// checkGRPC checks the health of the gRPC server at the other end of conn, or calls the method of the probe, and
+// compares the response with the expected response of the probe.
+func (p Probe) checkGRPC(conn net.Conn) error {
+	cc, err := (&http2.Transport{}).NewClientConn(conn)
+	if err != nil {
+		return err
+	}
+	defer cc.Close()
+
+	var response interface{}
+	expected := p.GRPCResponse
+	if p.GRPCMethod == "" {
+		if response, err = checkHealth(cc, p.Address, p.GRPCService); err != nil {
+			return err
+		}
+		if expected == "" {
+			expected = `{"status": "SERVING"}`
+		}
+	} else if response, err = callMethod(cc, p.Address, p.GRPCMethod, p.GRPCRequest); err != nil {
+		return err
+	}
+
+	if expected == "" {
+		return nil
+	}
+	var want interface{}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		return err
+	}
+	if err := testutils.IsSubset(want, response); err != nil {
+		received, _ := json.Marshal(response)
+		return fmt.Errorf("unexpected response %s: %w", received, err)
+	}
+	return nil
+}
+
+// checkHealth calls the standard gRPC health service and returns the response in its JSON form.
+func checkHealth(cc *http2.ClientConn, address, service string) (interface{}, error) {
+	request := []byte{}
+	if service != "" {
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendString(request, service)
+	}
+	messages, err := callGRPC(cc, address, healthCheckMethod, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) != 1 {
+		return nil, fmt.Errorf("%d health check responses received", len(messages))
+	}
+
+	status := uint64(0)
+	err = parseFields(messages[0], func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.VarintType {
+			return nil
+		}
+		v, n := protowire.ConsumeVarint(value)
+		status = v
+		return protowire.ParseError(n)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parsing health check response: %w", err)
+	}
+	name, ok := healthStatuses[status]
+	if !ok {
+		name = fmt.Sprint(status)
+	}
+	return map[string]interface{}{"status": name}, nil
+}
+
+// callMethod looks up the method with server reflection, calls it with the JSON request and returns the response in its
+// JSON form.
+func callMethod(cc *http2.ClientConn, address, fullMethod, request string) (interface{}, error) {
+	service, method, _ := strings.Cut(fullMethod, "/")
+	files, err := reflectFiles(cc, address, service)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("finding service %s: %w", service, err)
+	}
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	methodDescriptor := serviceDescriptor.Methods().ByName(protoreflect.Name(method))
+	if methodDescriptor == nil {
+		return nil, fmt.Errorf("service %s has no method %s", service, method)
+	}
+	if methodDescriptor.IsStreamingClient() || methodDescriptor.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming, only unary methods can be called", fullMethod)
+	}
+
+	input := dynamicpb.NewMessage(methodDescriptor.Input())
+	if request != "" {
+		if err := protojson.Unmarshal([]byte(request), input); err != nil {
+			return nil, fmt.Errorf("parsing request of %s: %w", fullMethod, err)
+		}
+	}
+	data, err := proto.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := callGRPC(cc, address, fullMethod, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) != 1 {
+		return nil, fmt.Errorf("%d responses of %s received", len(messages), fullMethod)
+	}
+
+	output := dynamicpb.NewMessage(methodDescriptor.Output())
+	if err := proto.Unmarshal(messages[0], output); err != nil {
+		return nil, fmt.Errorf("parsing response of %s: %w", fullMethod, err)
+	}
+	data, err = protojson.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	var response interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// reflectFiles returns the descriptors of the file defining the symbol and its dependencies, which the server returns
+// with server reflection.
+func reflectFiles(cc *http2.ClientConn, address, symbol string) (*protoregistry.Files, error) {
+	request := protowire.AppendTag(nil, 4, protowire.BytesType)
+	request = protowire.AppendString(request, symbol)
+
+	messages, err := callGRPC(cc, address, reflectionMethod, request)
+	var statusErr *grpcError
+	if errors.As(err, &statusErr) && statusErr.code == grpcUnimplemented {
+		messages, err = callGRPC(cc, address, reflectionAlphaMethod, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) != 1 {
+		return nil, fmt.Errorf("%d reflection responses received", len(messages))
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	reflectionErr := ""
+	err = parseFields(messages[0], func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		field, n := protowire.ConsumeBytes(value)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		switch num {
+		case 4:
+			// FileDescriptorResponse, its repeated field 1 are the serialized file descriptors
+			return parseFields(field, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if num != 1 || typ != protowire.BytesType {
+					return nil
+				}
+				data, n := protowire.ConsumeBytes(value)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				file := &descriptorpb.FileDescriptorProto{}
+				if err := proto.Unmarshal(data, file); err != nil {
+					return err
+				}
+				set.File = append(set.File, file)
+				return nil
+			})
+		case 7:
+			// ErrorResponse, its field 2 is the error message
+			reflectionErr = "unknown error"
+			return parseFields(field, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if num == 2 && typ == protowire.BytesType {
+					message, n := protowire.ConsumeString(value)
+					reflectionErr = message
+					return protowire.ParseError(n)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parsing reflection response: %w", err)
+	}
+	if reflectionErr != "" {
+		return nil, fmt.Errorf("looking up %s with server reflection: %s", symbol, reflectionErr)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("loading descriptors of %s: %w", symbol, err)
+	}
+	return files, nil
+}
+
+// parseFields calls field with the number, type and the encoded value of each field of the protobuf message.
+func parseFields(message []byte, field func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		message = message[n:]
+		n = protowire.ConsumeFieldValue(num, typ, message)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := field(num, typ, message[:n]); err != nil {
+			return err
+		}
+		message = message[n:]
+	}
+	return nil
+}
+
+// callGRPC sends the request message to the method and returns the response messages, it fails if the call does not
+// complete with the OK status.
+func callGRPC(cc *http2.ClientConn, address, method string, message []byte) ([][]byte, error) {
+	body := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(message)))
+	body = append(body, message...)
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+address+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := cc.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("call of %s failed with HTTP status %s", method, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxGRPCMessage))
+	if err != nil {
+		return nil, fmt.Errorf("reading response of %s: %w", method, err)
+	}
+
+	// the status is in the headers of responses without messages
+	status := resp.Trailer
+	if resp.Header.Get("Grpc-Status") != "" {
+		status = resp.Header
+	}
+	if code := status.Get("Grpc-Status"); code != "0" {
+		if code == "" {
+			return nil, fmt.Errorf("call of %s returned no status", method)
+		}
+		message, err := url.PathUnescape(status.Get("Grpc-Message"))
+		if err != nil {
+			message = status.Get("Grpc-Message")
+		}
+		return nil, &grpcError{method: method, code: code, message: message}
+	}
+
+	messages := [][]byte{}
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return nil, fmt.Errorf("truncated response of %s", method)
+		}
+		if data[0] != 0 {
+			return nil, fmt.Errorf("compressed response of %s is not supported", method)
+		}
+		length := binary.BigEndian.Uint32(data[1:5])
+		if uint32(len(data)-5) < length {
+			return nil, fmt.Errorf("truncated response of %s", method)
+		}
+		messages = append(messages, data[5:5+length])
+		data = data[5+length:]
+	}
+	return messages, nil
+}
@@ -0,0 +1,224 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// itemsFile is the descriptor of the test.v1.Items service the gRPC test server lists with server reflection.
+var itemsFile = &descriptorpb.FileDescriptorProto{
+	Name:    proto.String("test/v1/items.proto"),
+	Package: proto.String("test.v1"),
+	Syntax:  proto.String("proto3"),
+	MessageType: []*descriptorpb.DescriptorProto{
+		{
+			Name: proto.String("GetRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("id"), JsonName: proto.String("id"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+			},
+		},
+		{
+			Name: proto.String("GetResponse"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("id"), JsonName: proto.String("id"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				{Name: proto.String("available"), JsonName: proto.String("available"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+			},
+		},
+	},
+	Service: []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("Items"),
+		Method: []*descriptorpb.MethodDescriptorProto{
+			{Name: proto.String("Get"), InputType: proto.String(".test.v1.GetRequest"), OutputType: proto.String(".test.v1.GetResponse")},
+		},
+	}},
+}
+
+// grpcServer serves the health service, reporting the serving status of the services in health, v1alpha server
+// reflection of the test.v1.Items service and its Get method over plaintext HTTP/2.
+func grpcServer(health map[string]uint64) func(conn net.Conn) {
+	reply := func(w http.ResponseWriter, message []byte) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		body := make([]byte, 5, 5+len(message))
+		binary.BigEndian.PutUint32(body[1:], uint32(len(message)))
+		_, _ = w.Write(append(body, message...))
+		w.Header().Set("Grpc-Status", "0")
+	}
+	fail := func(w http.ResponseWriter, code, message string) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", code)
+		w.Header().Set("Grpc-Message", message)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil || len(body) < 5 {
+			fail(w, "13", "invalid request")
+			return
+		}
+		message := body[5:]
+		fields := map[protowire.Number]string{}
+		_ = parseFields(message, func(num protowire.Number, typ protowire.Type, value []byte) error {
+			if typ == protowire.BytesType {
+				field, _ := protowire.ConsumeString(value)
+				fields[num] = field
+			}
+			return nil
+		})
+
+		switch r.URL.Path {
+		case "/" + healthCheckMethod:
+			status, ok := health[fields[1]]
+			if !ok {
+				fail(w, "5", "unknown service")
+				return
+			}
+			reply(w, protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), status))
+		case "/" + reflectionAlphaMethod:
+			response := []byte{}
+			if fields[4] == "test.v1.Items" {
+				file, _ := proto.Marshal(itemsFile)
+				files := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), file)
+				response = protowire.AppendBytes(protowire.AppendTag(response, 4, protowire.BytesType), files)
+			} else {
+				errResponse := protowire.AppendString(protowire.AppendTag(nil, 2, protowire.BytesType), "symbol not found")
+				response = protowire.AppendBytes(protowire.AppendTag(response, 7, protowire.BytesType), errResponse)
+			}
+			reply(w, response)
+		case "/test.v1.Items/Get":
+			response := protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), fields[1])
+			response = protowire.AppendVarint(protowire.AppendTag(response, 2, protowire.VarintType), protowire.EncodeBool(fields[1] == "1"))
+			reply(w, response)
+		default:
+			fail(w, grpcUnimplemented, "unknown method")
+		}
+	})
+
+	return func(conn net.Conn) {
+		(&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+	}
+}
+
+func TestRunGRPC(t *testing.T) {
+	address := serve(t, grpcServer(map[string]uint64{"": 1, "test.v1.Items": 2}))
+
+	for _, test := range []struct {
+		name  string
+		probe Probe
+		err   string
+	}{
+		{
+			name:  "serving",
+			probe: Probe{Protocol: GRPC},
+		},
+		{
+			name:  "service not serving",
+			probe: Probe{Protocol: GRPC, GRPCService: "test.v1.Items"},
+			err:   `unexpected response {"status":"NOT_SERVING"}: .status: value mismatch, expected: SERVING != actual: NOT_SERVING`,
+		},
+		{
+			name:  "expected status",
+			probe: Probe{Protocol: GRPC, GRPCService: "test.v1.Items", GRPCResponse: `{"status": "NOT_SERVING"}`},
+		},
+		{
+			name:  "unknown service",
+			probe: Probe{Protocol: GRPC, GRPCService: "test.v1.Orders"},
+			err:   "call of grpc.health.v1.Health/Check failed with status 5: unknown service",
+		},
+		{
+			name:  "method",
+			probe: Probe{Protocol: GRPC, GRPCMethod: "test.v1.Items/Get", GRPCRequest: `{"id": "1"}`, GRPCResponse: `{"id": "1", "available": true}`},
+		},
+		{
+			name:  "method response mismatch",
+			probe: Probe{Protocol: GRPC, GRPCMethod: "test.v1.Items/Get", GRPCRequest: `{"id": "2"}`, GRPCResponse: `{"id": "2", "available": true}`},
+			err:   `unexpected response {"id":"2"}: .available: key is missing from map`,
+		},
+		{
+			name:  "method without expected response",
+			probe: Probe{Protocol: GRPC, GRPCMethod: "test.v1.Items/Get"},
+		},
+		{
+			name:  "unknown method",
+			probe: Probe{Protocol: GRPC, GRPCMethod: "test.v1.Items/List"},
+			err:   "service test.v1.Items has no method List",
+		},
+		{
+			name:  "service not listed",
+			probe: Probe{Protocol: GRPC, GRPCMethod: "test.v1.Orders/Get"},
+			err:   "looking up test.v1.Orders with server reflection: symbol not found",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			test.probe.Address = address
+			err := test.probe.Run(context.Background(), 5*time.Second)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestValidateGRPC(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		probe Probe
+		err   string
+	}{
+		{
+			name:  "method",
+			probe: Probe{Protocol: GRPC, GRPCMethod: "test.v1.Items/Get", GRPCRequest: `{}`},
+		},
+		{
+			name:  "other protocol",
+			probe: Probe{Protocol: TCP, GRPCService: "test.v1.Items"},
+			err:   "gRPC settings can only be set for the grpc protocol",
+		},
+		{
+			name:  "invalid method",
+			probe: Probe{Protocol: GRPC, GRPCMethod: "test.v1.Items.Get"},
+			err:   `invalid gRPC method "test.v1.Items.Get", must be in the form <package>.<Service>/<Method>`,
+		},
+		{
+			name:  "service and method",
+			probe: Probe{Protocol: GRPC, GRPCService: "test.v1.Items", GRPCMethod: "test.v1.Items/Get"},
+			err:   "a gRPC service can only be set for health checks, not with a gRPC method",
+		},
+		{
+			name:  "request without method",
+			probe: Probe{Protocol: GRPC, GRPCRequest: `{}`},
+			err:   "a gRPC request can only be set with a gRPC method",
+		},
+		{
+			name:  "invalid response",
+			probe: Probe{Protocol: GRPC, GRPCResponse: `status: SERVING`},
+			err:   "gRPC response is not valid JSON: status: SERVING",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := test.probe.Validate()
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
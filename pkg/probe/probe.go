@@ -0,0 +1,250 @@
+// Package probe checks the readiness of applications at the protocol level, ex. whether a database accepts
+// connections, which a Ready pod does not guarantee.
+package probe
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Protocols which can be probed.
+const (
+	// TCP probes that a connection can be established and, if a banner is given, that the server sends it.
+	TCP = "tcp"
+	// Postgres probes that the server accepts connections, like pg_isready.
+	Postgres = "postgres"
+	// MySQL probes that the server sends its handshake, like mysqladmin ping.
+	MySQL = "mysql"
+	// Redis probes that the server replies to PING.
+	Redis = "redis"
+	// GRPC probes that the standard health service reports the server as serving, or calls a method listed by server
+	// reflection, over plaintext HTTP/2.
+	GRPC = "grpc"
+)
+
+// Protocols are the protocols which can be probed.
+var Protocols = []string{TCP, Postgres, MySQL, Redis, GRPC}
+
+// maxBanner is the maximum number of bytes read to match a TCP banner.
+const maxBanner = 4096
+
+// Probe is a readiness check of an application listening at an address.
+type Probe struct {
+	// Address in the form "<host>:<port>".
+	Address string
+	// Protocol of the application, one of Protocols.
+	Protocol string
+	// Banner is a regular expression the data a TCP server sends after the connection is established must match.
+	Banner string
+	// GRPCService is the service whose health a gRPC probe checks, the server as a whole if empty.
+	GRPCService string
+	// GRPCMethod is the method a gRPC probe calls instead of checking the health, in the form
+	// "<package>.<Service>/<Method>".  The method must be unary and listed by server reflection.
+	GRPCMethod string
+	// GRPCRequest is the JSON of the request message of the method, an empty message if not set.
+	GRPCRequest string
+	// GRPCResponse is the JSON the response of a gRPC probe must contain, in the protobuf JSON mapping.  Health checks
+	// expect {"status": "SERVING"} by default.
+	GRPCResponse string
+}
+
+// Validate fails if the protocol of the probe is unknown, its banner is not a valid regular expression or its gRPC
+// settings are invalid.
+func (p Probe) Validate() error {
+	switch p.Protocol {
+	case TCP, Postgres, MySQL, Redis, GRPC:
+	default:
+		return fmt.Errorf("unknown protocol %q, must be one of %s", p.Protocol, strings.Join(Protocols, ", "))
+	}
+	if p.Protocol != TCP && p.Banner != "" {
+		return fmt.Errorf("a banner can only be set for the %s protocol", TCP)
+	}
+	if p.Protocol != GRPC {
+		if p.GRPCService != "" || p.GRPCMethod != "" || p.GRPCRequest != "" || p.GRPCResponse != "" {
+			return fmt.Errorf("gRPC settings can only be set for the %s protocol", GRPC)
+		}
+	} else if err := p.validateGRPC(); err != nil {
+		return err
+	}
+	if _, err := regexp.Compile(p.Banner); err != nil {
+		return fmt.Errorf("invalid banner: %w", err)
+	}
+	return nil
+}
+
+// Run connects to the application and checks it once.  The check fails if it takes longer than timeout.
+func (p Probe) Run(ctx context.Context, timeout time.Duration) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	return p.check(conn)
+}
+
+// WaitFor runs the probe every interval until it succeeds or ctx is done, in which case the last error is returned.
+func (p Probe) WaitFor(ctx context.Context, interval time.Duration) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	for {
+		err := p.Run(ctx, interval)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+	}
+}
+
+// check checks the application at the other end of conn.
+func (p Probe) check(conn net.Conn) error {
+	switch p.Protocol {
+	case Postgres:
+		return checkPostgres(conn)
+	case MySQL:
+		return checkMySQL(conn)
+	case Redis:
+		return checkRedis(conn)
+	case GRPC:
+		return p.checkGRPC(conn)
+	}
+	if p.Banner == "" {
+		return nil
+	}
+	return checkBanner(conn, regexp.MustCompile(p.Banner))
+}
+
+// checkBanner reads from conn until the data matches banner.
+func checkBanner(conn net.Conn, banner *regexp.Regexp) error {
+	received := []byte{}
+	buf := make([]byte, 512)
+	for len(received) < maxBanner {
+		n, err := conn.Read(buf)
+		received = append(received, buf[:n]...)
+		if banner.Match(received) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("banner %q not received, received %q: %w", banner, received, err)
+		}
+	}
+	return fmt.Errorf("banner %q not received in the first %d bytes, received %q", banner, maxBanner, received)
+}
+
+// checkPostgres sends a startup message and succeeds if the server responds with an authentication request or an
+// error other than that it is starting up or shutting down, like pg_isready.
+func checkPostgres(conn net.Conn) error {
+	params := "user\x00postgres\x00database\x00postgres\x00\x00"
+	startup := make([]byte, 8, 8+len(params))
+	binary.BigEndian.PutUint32(startup[0:4], uint32(8+len(params)))
+	// protocol version 3.0
+	binary.BigEndian.PutUint32(startup[4:8], 3<<16)
+	startup = append(startup, params...)
+	if _, err := conn.Write(startup); err != nil {
+		return err
+	}
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("reading startup response: %w", err)
+	}
+	switch header[0] {
+	case 'R':
+		return nil
+	case 'E':
+	default:
+		return fmt.Errorf("unexpected startup response message type %q", header[0])
+	}
+
+	length := binary.BigEndian.Uint32(header[1:5])
+	if length < 4 || length > 64*1024 {
+		return fmt.Errorf("invalid error response length %d", length)
+	}
+	body := make([]byte, length-4)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return fmt.Errorf("reading error response: %w", err)
+	}
+
+	fields := map[byte]string{}
+	for _, field := range strings.Split(string(body), "\x00") {
+		if len(field) > 1 {
+			fields[field[0]] = field[1:]
+		}
+	}
+	// 57P03 is cannot_connect_now, errors such as failed authentication show that connections are accepted
+	if fields['C'] == "57P03" {
+		return fmt.Errorf("server is not accepting connections: %s", fields['M'])
+	}
+	return nil
+}
+
+// checkMySQL succeeds if the server sends a protocol version 10 handshake.
+func checkMySQL(conn net.Conn) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("reading handshake: %w", err)
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	if length == 0 {
+		return errors.New("empty handshake")
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return fmt.Errorf("reading handshake: %w", err)
+	}
+
+	switch payload[0] {
+	case 10:
+		return nil
+	case 0xff:
+		// error packet: 0xff, error code, message
+		message := ""
+		if len(payload) > 3 {
+			message = string(payload[3:])
+		}
+		return fmt.Errorf("server refused connection: %s", message)
+	}
+	return fmt.Errorf("unsupported protocol version %d", payload[0])
+}
+
+// checkRedis sends PING and succeeds if the server replies PONG or requires authentication.
+func checkRedis(conn net.Conn) error {
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("reading reply to PING: %w", err)
+	}
+	reply = strings.TrimSpace(reply)
+	if reply == "+PONG" || strings.HasPrefix(reply, "-NOAUTH") {
+		return nil
+	}
+	return fmt.Errorf("unexpected reply to PING: %s", reply)
+}
@@ -0,0 +1,191 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve accepts connections on a local port and handles them with handle, it returns the address.
+func serve(t *testing.T, handle func(conn net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// postgresServer replies to startup messages with msg.
+func postgresServer(msg []byte) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(conn, make([]byte, binary.BigEndian.Uint32(header)-4)); err != nil {
+			return
+		}
+		_, _ = conn.Write(msg)
+	}
+}
+
+// postgresError returns an error response with the code and message.
+func postgresError(code, message string) []byte {
+	body := "SFATAL\x00C" + code + "\x00M" + message + "\x00\x00"
+	msg := []byte{'E', 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(4+len(body)))
+	return append(msg, body...)
+}
+
+// mysqlServer sends a packet with the payload.
+func mysqlServer(payload []byte) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		_, _ = conn.Write(append([]byte{byte(len(payload)), 0, 0, 0}, payload...))
+	}
+}
+
+// redisServer replies to PING with reply.
+func redisServer(reply string) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte(reply + "\r\n"))
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		probe  Probe
+		handle func(conn net.Conn)
+		err    string
+	}{
+		{
+			name:   "tcp",
+			probe:  Probe{Protocol: TCP},
+			handle: func(conn net.Conn) {},
+		},
+		{
+			name:   "tcp banner",
+			probe:  Probe{Protocol: TCP, Banner: `^SSH-2\.0-`},
+			handle: func(conn net.Conn) { _, _ = conn.Write([]byte("SSH-2.0-OpenSSH_9.0\r\n")) },
+		},
+		{
+			name:   "tcp wrong banner",
+			probe:  Probe{Protocol: TCP, Banner: `^SSH-2\.0-`},
+			handle: func(conn net.Conn) { _, _ = conn.Write([]byte("220 smtp ready\r\n")) },
+			err:    `banner "^SSH-2\\.0-" not received, received "220 smtp ready\r\n": EOF`,
+		},
+		{
+			name:   "postgres authentication request",
+			probe:  Probe{Protocol: Postgres},
+			handle: postgresServer([]byte{'R', 0, 0, 0, 8, 0, 0, 0, 5}),
+		},
+		{
+			name:   "postgres authentication failed",
+			probe:  Probe{Protocol: Postgres},
+			handle: postgresServer(postgresError("28000", `role "postgres" does not exist`)),
+		},
+		{
+			name:   "postgres starting up",
+			probe:  Probe{Protocol: Postgres},
+			handle: postgresServer(postgresError("57P03", "the database system is starting up")),
+			err:    "server is not accepting connections: the database system is starting up",
+		},
+		{
+			name:   "mysql handshake",
+			probe:  Probe{Protocol: MySQL},
+			handle: mysqlServer(append([]byte{10}, "8.0.32\x00"...)),
+		},
+		{
+			name:   "mysql error",
+			probe:  Probe{Protocol: MySQL},
+			handle: mysqlServer(append([]byte{0xff, 0x10, 0x04}, "Too many connections"...)),
+			err:    "server refused connection: Too many connections",
+		},
+		{
+			name:   "redis pong",
+			probe:  Probe{Protocol: Redis},
+			handle: redisServer("+PONG"),
+		},
+		{
+			name:   "redis authentication required",
+			probe:  Probe{Protocol: Redis},
+			handle: redisServer("-NOAUTH Authentication required."),
+		},
+		{
+			name:   "redis loading",
+			probe:  Probe{Protocol: Redis},
+			handle: redisServer("-LOADING Redis is loading the dataset in memory"),
+			err:    "unexpected reply to PING: -LOADING Redis is loading the dataset in memory",
+		},
+		{
+			name:  "unknown protocol",
+			probe: Probe{Protocol: "mongodb"},
+			err:   `unknown protocol "mongodb", must be one of tcp, postgres, mysql, redis, grpc`,
+		},
+		{
+			name:  "banner of other protocol",
+			probe: Probe{Protocol: Redis, Banner: "PONG"},
+			err:   "a banner can only be set for the tcp protocol",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			test.probe.Address = "127.0.0.1:1"
+			if test.handle != nil {
+				test.probe.Address = serve(t, test.handle)
+			}
+
+			err := test.probe.Run(context.Background(), 5*time.Second)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	var attempts int32
+	address := serve(t, func(conn net.Conn) {
+		reply := "-LOADING Redis is loading the dataset in memory"
+		if atomic.AddInt32(&attempts, 1) > 2 {
+			reply = "+PONG"
+		}
+		redisServer(reply)(conn)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, Probe{Address: address, Protocol: Redis}.WaitFor(ctx, 10*time.Millisecond))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := Probe{Address: serve(t, redisServer("-ERR unknown command")), Protocol: Redis}.WaitFor(ctx, 10*time.Millisecond)
+	assert.EqualError(t, err, "unexpected reply to PING: -ERR unknown command")
+}
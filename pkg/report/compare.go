@@ -0,0 +1,227 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// DefaultName is the name of the report if the TestSuite does not define a report name.
+const DefaultName = "kuttl-report"
+
+// Load reads a JSON or XML report.  path is either a report file or an artifacts directory containing a
+// report named kuttl-report.json or kuttl-report.xml.
+func Load(path string) (*Testsuites, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		found := false
+		for _, ext := range []string{".json", ".xml"} {
+			file := filepath.Join(path, DefaultName+ext)
+			if _, err := os.Stat(file); err == nil {
+				path = file
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no %s.json or %s.xml report found in %s", DefaultName, DefaultName, path)
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &Testsuites{}
+	switch filepath.Ext(path) {
+	case ".xml":
+		err = xml.Unmarshal(raw, ts)
+	case ".json":
+		err = json.Unmarshal(raw, ts)
+	default:
+		return nil, fmt.Errorf("unknown report format of %s, expected a .json or .xml file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading report %s: %w", path, err)
+	}
+	return ts, nil
+}
+
+// CaseChange is a test case which changed between two runs.
+type CaseChange struct {
+	// Name is the name of the test case, prefixed with the name of its test suite.
+	Name string
+	// Before and After are the test case in the first and the second run, either may be nil.
+	Before *Testcase
+	After  *Testcase
+}
+
+// DurationChange is a test case that took longer in the second run.
+type DurationChange struct {
+	Name   string
+	Before float64
+	After  float64
+}
+
+// Comparison is the result of comparing the reports of two runs.
+type Comparison struct {
+	// NewFailures are test cases which failed in the second run but not in the first run (or were not part of it).
+	NewFailures []CaseChange
+	// Fixed are test cases which failed in the first run and passed in the second run.
+	Fixed []CaseChange
+	// ChangedFailures are test cases which failed in both runs but with a different failure.
+	ChangedFailures []CaseChange
+	// Regressions are test cases which took longer than allowed by the thresholds in the second run.
+	Regressions []DurationChange
+	// Added and Removed are test cases which are only part of the second or the first run.
+	Added   []string
+	Removed []string
+}
+
+// CompareOptions controls which changes in duration are reported as regressions.
+type CompareOptions struct {
+	// DurationFactor is the factor by which a test case must be slower to be a regression (ex. 1.5 for 50% slower).
+	DurationFactor float64
+	// MinDuration is the minimum number of seconds a test case must be slower to be a regression, this avoids
+	// reporting noise from very short test cases.
+	MinDuration float64
+}
+
+// Compare compares the reports of two runs, before is the first run and after is the second run.
+func Compare(before, after *Testsuites, opts CompareOptions) *Comparison {
+	b := testcases(before)
+	a := testcases(after)
+	c := &Comparison{}
+
+	for _, name := range sortedNames(a) {
+		afterCase := a[name]
+		beforeCase, ok := b[name]
+		if !ok {
+			c.Added = append(c.Added, name)
+			if afterCase.Failure != nil {
+				c.NewFailures = append(c.NewFailures, CaseChange{Name: name, After: afterCase})
+			}
+			continue
+		}
+
+		switch {
+		case afterCase.Failure != nil && beforeCase.Failure == nil:
+			c.NewFailures = append(c.NewFailures, CaseChange{Name: name, Before: beforeCase, After: afterCase})
+		case afterCase.Failure == nil && beforeCase.Failure != nil:
+			c.Fixed = append(c.Fixed, CaseChange{Name: name, Before: beforeCase, After: afterCase})
+		case afterCase.Failure != nil && *afterCase.Failure != *beforeCase.Failure:
+			c.ChangedFailures = append(c.ChangedFailures, CaseChange{Name: name, Before: beforeCase, After: afterCase})
+		}
+
+		beforeTime, errBefore := strconv.ParseFloat(beforeCase.Time, 64)
+		afterTime, errAfter := strconv.ParseFloat(afterCase.Time, 64)
+		if errBefore != nil || errAfter != nil {
+			continue
+		}
+		if afterTime-beforeTime >= opts.MinDuration && afterTime > beforeTime*opts.DurationFactor {
+			c.Regressions = append(c.Regressions, DurationChange{Name: name, Before: beforeTime, After: afterTime})
+		}
+	}
+
+	for _, name := range sortedNames(b) {
+		if _, ok := a[name]; !ok {
+			c.Removed = append(c.Removed, name)
+		}
+	}
+
+	return c
+}
+
+// HasRegressions returns true if there are new failures or duration regressions.
+func (c *Comparison) HasRegressions() bool {
+	return len(c.NewFailures) > 0 || len(c.Regressions) > 0
+}
+
+// Write writes a human readable summary of the comparison.
+func (c *Comparison) Write(w io.Writer) error {
+	var errs []error
+	printf := func(format string, args ...interface{}) {
+		_, err := fmt.Fprintf(w, format, args...)
+		errs = append(errs, err)
+	}
+
+	if len(c.NewFailures) > 0 {
+		printf("New failures:\n")
+		for _, change := range c.NewFailures {
+			printf("  %s: %s\n", change.Name, failureText(change.After.Failure))
+		}
+	}
+	if len(c.ChangedFailures) > 0 {
+		printf("Changed failures:\n")
+		for _, change := range c.ChangedFailures {
+			printf("  %s:\n    before: %s\n    after:  %s\n", change.Name, failureText(change.Before.Failure), failureText(change.After.Failure))
+		}
+	}
+	if len(c.Fixed) > 0 {
+		printf("Fixed:\n")
+		for _, change := range c.Fixed {
+			printf("  %s\n", change.Name)
+		}
+	}
+	if len(c.Regressions) > 0 {
+		printf("Duration regressions:\n")
+		for _, change := range c.Regressions {
+			printf("  %s: %.3fs -> %.3fs\n", change.Name, change.Before, change.After)
+		}
+	}
+	if len(c.Added) > 0 {
+		printf("Added tests:\n")
+		for _, name := range c.Added {
+			printf("  %s\n", name)
+		}
+	}
+	if len(c.Removed) > 0 {
+		printf("Removed tests:\n")
+		for _, name := range c.Removed {
+			printf("  %s\n", name)
+		}
+	}
+	if len(c.NewFailures)+len(c.ChangedFailures)+len(c.Fixed)+len(c.Regressions)+len(c.Added)+len(c.Removed) == 0 {
+		printf("No differences found.\n")
+	}
+
+	return errors.Join(errs...)
+}
+
+// testcases returns the test cases of a report by suite and test case name.
+func testcases(ts *Testsuites) map[string]*Testcase {
+	cases := map[string]*Testcase{}
+	for _, suite := range ts.Testsuite {
+		for _, testcase := range suite.Testcase {
+			cases[suite.Name+"/"+testcase.Name] = testcase
+		}
+	}
+	return cases
+}
+
+func sortedNames(cases map[string]*Testcase) []string {
+	names := make([]string, 0, len(cases))
+	for name := range cases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func failureText(f *Failure) string {
+	if f.Text != "" {
+		return fmt.Sprintf("%s: %s", f.Message, f.Text)
+	}
+	return f.Message
+}
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func run(cases ...*Testcase) *Testsuites {
+	return &Testsuites{Testsuite: []*Testsuite{{Name: "./e2e", Testcase: cases}}}
+}
+
+func TestCompare(t *testing.T) {
+	failure := &Failure{Message: "failed in step 1-install", Text: "resource Deployment:app: .status.readyReplicas: value mismatch"}
+	otherFailure := &Failure{Message: "failed in step 2-upgrade", Text: "command \"helm upgrade\" failed"}
+
+	before := run(
+		&Testcase{Name: "stable", Time: "10.000"},
+		&Testcase{Name: "breaks", Time: "10.000"},
+		&Testcase{Name: "fixed", Time: "10.000", Failure: failure},
+		&Testcase{Name: "changed", Time: "10.000", Failure: failure},
+		&Testcase{Name: "slower", Time: "10.000"},
+		&Testcase{Name: "slightly-slower", Time: "1.000"},
+		&Testcase{Name: "removed", Time: "1.000"},
+	)
+	after := run(
+		&Testcase{Name: "stable", Time: "11.000"},
+		&Testcase{Name: "breaks", Time: "10.000", Failure: failure},
+		&Testcase{Name: "fixed", Time: "10.000"},
+		&Testcase{Name: "changed", Time: "10.000", Failure: otherFailure},
+		&Testcase{Name: "slower", Time: "30.000"},
+		&Testcase{Name: "slightly-slower", Time: "3.000"},
+		&Testcase{Name: "added", Time: "1.000", Failure: failure},
+	)
+
+	c := Compare(before, after, CompareOptions{DurationFactor: 1.5, MinDuration: 5})
+
+	names := func(changes []CaseChange) []string {
+		n := []string{}
+		for _, change := range changes {
+			n = append(n, change.Name)
+		}
+		return n
+	}
+	assert.Equal(t, []string{"./e2e/added", "./e2e/breaks"}, names(c.NewFailures))
+	assert.Equal(t, []string{"./e2e/fixed"}, names(c.Fixed))
+	assert.Equal(t, []string{"./e2e/changed"}, names(c.ChangedFailures))
+	assert.Equal(t, []DurationChange{{Name: "./e2e/slower", Before: 10, After: 30}}, c.Regressions)
+	assert.Equal(t, []string{"./e2e/added"}, c.Added)
+	assert.Equal(t, []string{"./e2e/removed"}, c.Removed)
+	assert.True(t, c.HasRegressions())
+
+	var b bytes.Buffer
+	assert.NoError(t, c.Write(&b))
+	assert.Contains(t, b.String(), "New failures:\n  ./e2e/added: failed in step 1-install: resource Deployment:app")
+	assert.Contains(t, b.String(), "Duration regressions:\n  ./e2e/slower: 10.000s -> 30.000s\n")
+}
+
+func TestCompareNoDifferences(t *testing.T) {
+	before := run(&Testcase{Name: "stable", Time: "10.000"})
+	c := Compare(before, before, CompareOptions{DurationFactor: 1.5})
+	assert.False(t, c.HasRegressions())
+
+	var b bytes.Buffer
+	assert.NoError(t, c.Write(&b))
+	assert.Equal(t, "No differences found.\n", b.String())
+}
+
+func TestLoad(t *testing.T) {
+	ts, err := Load(filepath.Join("testdata", "report.json.golden"))
+	assert.Error(t, err, "golden files do not have a report extension")
+	assert.Nil(t, ts)
+
+	dir := t.TempDir()
+	json, err := os.ReadFile(filepath.Join("testdata", "report.json.golden"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, DefaultName+".json"), json, 0600))
+
+	ts, err = Load(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "test_params_func:2", ts.Testsuite[0].Testcase[0].Name)
+	assert.Equal(t, "test failure", ts.Testsuite[0].Testcase[0].Failure.Message)
+
+	xmlFile := filepath.Join(t.TempDir(), "report.xml")
+	xml, err := os.ReadFile(filepath.Join("testdata", "report.xml.golden"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(xmlFile, xml, 0600))
+
+	ts, err = Load(xmlFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "test_params_func:2", ts.Testsuite[0].Testcase[0].Name)
+
+	_, err = Load(t.TempDir())
+	assert.Error(t, err)
+}
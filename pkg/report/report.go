@@ -46,6 +46,20 @@ type Failure struct {
 	Type    string `xml:"type,attr" json:"type,omitempty"`
 }
 
+// Skipped defines a skipped test
+type Skipped struct {
+	// Message provides the reason the test was skipped.
+	Message string `xml:"message,attr" json:"message"`
+	Type    string `xml:"type,attr,omitempty" json:"type,omitempty"`
+}
+
+const (
+	// ExpectedFailure is the Skipped type of a test which failed as expected.
+	ExpectedFailure = "ExpectedFailure"
+	// UnexpectedPass is the Failure type of a test which passed although it was expected to fail.
+	UnexpectedPass = "UnexpectedPass"
+)
+
 // Testcase is the finest grain level of reporting, it is the kuttl test (which contains steps).
 type Testcase struct {
 	// Classname is a junit thing, for kuttl it is the testsuite name.
@@ -62,6 +76,14 @@ type Testcase struct {
 	Assertions int `xml:"assertions,attr" json:"assertions,omitempty"`
 	// Failure defines a failure in this Testcase.
 	Failure *Failure `xml:"failure" json:"failure,omitempty"`
+	// Skipped defines why this Testcase was skipped.
+	Skipped *Skipped `xml:"skipped" json:"skipped,omitempty"`
+	// Properties which are specific to this test case, ex. its owner.
+	Properties *Properties `xml:"properties" json:"properties,omitempty"`
+	// SystemOut is the stdout of the commands of the test, each output is preceded by its command.
+	SystemOut string `xml:"system-out,omitempty" json:"systemOut,omitempty"`
+	// SystemErr is the stderr of the commands of the test, each output is preceded by its command.
+	SystemErr string `xml:"system-err,omitempty" json:"systemErr,omitempty"`
 
 	// end is not reported.  It is used to calculate duration times for testcase and testsuite.
 	end time.Time
@@ -73,6 +95,8 @@ type Testsuite struct {
 	Tests int `xml:"tests,attr" json:"tests"`
 	// Failures is the summary number of all failure in the collection testcases.
 	Failures int `xml:"failures,attr" json:"failures"`
+	// Skipped is the summary number of all skipped testcases in the collection.
+	Skipped int `xml:"skipped,attr,omitempty" json:"skipped,omitempty"`
 	// Timestamp is the time when this Testsuite started.
 	Timestamp time.Time `xml:"timestamp,attr" json:"timestamp"`
 	// Time is the duration of time for this Testsuite, this is tricky as tests run concurrently.
@@ -96,6 +120,8 @@ type Testsuites struct {
 	Tests int `xml:"tests,attr" json:"tests"`
 	// Failures is a summary value of the total number of failures for all testsuites.
 	Failures int `xml:"failures,attr" json:"failures"`
+	// Skipped is a summary value of the total number of skipped tests for all testsuites.
+	Skipped int `xml:"skipped,attr,omitempty" json:"skipped,omitempty"`
 	// Time is the elapsed time of the entire suite of tests.
 	Time string `xml:"time,attr" json:"time"`
 	// Properties which are for the entire set of tests.
@@ -153,6 +179,17 @@ func (ts *Testsuite) AddTestcase(testcase *Testcase) {
 	if testcase.Failure != nil {
 		ts.Failures++
 	}
+	if testcase.Skipped != nil {
+		ts.Skipped++
+	}
+}
+
+// AddProperty adds a property to a testcase
+func (tc *Testcase) AddProperty(property Property) {
+	if tc.Properties == nil {
+		tc.Properties = &Properties{}
+	}
+	tc.Properties.Property = append(tc.Properties.Property, property)
 }
 
 // AddProperty adds a property to a testsuite
@@ -199,6 +236,7 @@ func (ts *Testsuites) Close() {
 
 		ts.Tests += testsuite.Tests
 		ts.Failures += testsuite.Failures
+		ts.Skipped += testsuite.Skipped
 	}
 }
 
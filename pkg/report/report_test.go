@@ -30,6 +30,8 @@ func TestXML(t *testing.T) {
 AssertionError`,
 			Message: "test failure",
 		},
+		SystemOut: "$ pytest\ncollected 9 items\n",
+		SystemErr: "$ pytest\nDeprecationWarning: nose2\n",
 	}
 	suite := &Testsuite{
 		Tests:    9,
@@ -85,3 +87,40 @@ AssertionError`,
 	}
 	assert.Equal(t, string(gjson), jout, "for golden file: %s", jsonFile)
 }
+
+func TestSkipped(t *testing.T) {
+	ts := NewSuiteCollection("")
+	suite := ts.NewSuite("suite")
+	suite.AddTestcase(NewCase("passed"))
+	skipped := NewCase("skipped")
+	skipped.Skipped = &Skipped{Message: "CRD certificates.cert-manager.io is not installed"}
+	suite.AddTestcase(skipped)
+	ts.Close()
+
+	assert.Equal(t, 2, suite.Tests)
+	assert.Equal(t, 1, suite.Skipped)
+	assert.Equal(t, 1, ts.Skipped)
+
+	x, err := xml.Marshal(skipped)
+	assert.NoError(t, err)
+	assert.Contains(t, string(x), `<skipped message="CRD certificates.cert-manager.io is not installed"></skipped>`)
+}
+
+func TestExpectedFailure(t *testing.T) {
+	ts := NewSuiteCollection("")
+	suite := ts.NewSuite("suite")
+	xfail := NewCase("xfail")
+	xfail.Skipped = &Skipped{Message: "expected failure in step 1-upgrade: known bug", Type: ExpectedFailure}
+	suite.AddTestcase(xfail)
+	xpass := NewCase("xpass")
+	xpass.Failure = &Failure{Message: "step 1-upgrade passed unexpectedly, it is expected to fail: known bug", Type: UnexpectedPass}
+	suite.AddTestcase(xpass)
+	ts.Close()
+
+	assert.Equal(t, 1, ts.Skipped)
+	assert.Equal(t, 1, ts.Failures)
+
+	x, err := xml.Marshal(xfail)
+	assert.NoError(t, err)
+	assert.Contains(t, string(x), `<skipped message="expected failure in step 1-upgrade: known bug" type="ExpectedFailure"></skipped>`)
+}
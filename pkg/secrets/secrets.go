@@ -0,0 +1,153 @@
+// Package secrets resolves the secrets of test suites from external secret managers, so that credentials are not
+// stored with the tests.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// Providers of secrets.
+const (
+	// Vault reads secrets from HashiCorp Vault with the address and token of $VAULT_ADDR and $VAULT_TOKEN.
+	Vault = "vault"
+	// AWSSecretsManager reads secrets from AWS Secrets Manager with the aws CLI and its configuration.
+	AWSSecretsManager = "aws-secrets-manager"
+	// SOPS decrypts SOPS encrypted files with the sops CLI and its configuration.
+	SOPS = "sops"
+)
+
+// Provider resolves the secrets of an external secret manager.
+type Provider interface {
+	// Resolve returns the value with the key in the secret with the reference, or the whole secret if key is empty.
+	Resolve(ctx context.Context, ref, key string) (string, error)
+}
+
+// Providers are the secret providers by name, other providers can be added before the secrets are resolved.
+var Providers = map[string]Provider{
+	Vault:             &VaultProvider{},
+	AWSSecretsManager: awsProvider{},
+	SOPS:              sopsProvider{},
+}
+
+var (
+	// namePattern matches the names of secrets, they must be valid environment variable names.
+	namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// indexPattern matches the segments of dotted paths which are array indexes.
+	indexPattern = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Resolve resolves the secrets and returns their values by name.
+func Resolve(ctx context.Context, secrets []harness.Secret) (map[string]string, error) {
+	values := map[string]string{}
+	for _, secret := range secrets {
+		if !namePattern.MatchString(secret.Name) {
+			return nil, fmt.Errorf("secret name %q is not a valid environment variable name", secret.Name)
+		}
+		if _, ok := values[secret.Name]; ok {
+			return nil, fmt.Errorf("secret %s is declared more than once", secret.Name)
+		}
+		provider, ok := Providers[secret.Provider]
+		if !ok {
+			return nil, fmt.Errorf("secret %s: unknown provider %q, must be one of %s", secret.Name, secret.Provider, strings.Join(providerNames(), ", "))
+		}
+		if secret.Ref == "" {
+			return nil, fmt.Errorf("secret %s: ref must be set", secret.Name)
+		}
+
+		value, err := provider.Resolve(ctx, secret.Ref, secret.Key)
+		if err != nil {
+			return nil, fmt.Errorf("resolving secret %s from %s: %w", secret.Name, secret.Provider, err)
+		}
+		values[secret.Name] = value
+	}
+	return values, nil
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(Providers))
+	for name := range Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// awsProvider reads secrets with `aws secretsmanager get-secret-value`, the key selects a value of a JSON secret.
+type awsProvider struct{}
+
+func (awsProvider) Resolve(ctx context.Context, ref, key string) (string, error) {
+	value, err := output(ctx, "aws", "secretsmanager", "get-secret-value", "--secret-id", ref, "--query", "SecretString", "--output", "text")
+	if err != nil || key == "" {
+		return value, err
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, it has no key %q", ref, key)
+	}
+	return field(fields, ref, key)
+}
+
+// sopsProvider decrypts files with `sops --decrypt`, the key is the dotted path of the extracted value.
+type sopsProvider struct{}
+
+func (sopsProvider) Resolve(ctx context.Context, ref, key string) (string, error) {
+	args := []string{"--decrypt"}
+	if key != "" {
+		args = append(args, "--extract", extractPath(key))
+	}
+	return output(ctx, "sops", append(args, ref)...)
+}
+
+// extractPath returns the sops --extract expression of a dotted path, ex. `["db"]["hosts"][0]` for "db.hosts.0".
+func extractPath(key string) string {
+	path := ""
+	for _, segment := range strings.Split(key, ".") {
+		if indexPattern.MatchString(segment) {
+			path += "[" + segment + "]"
+			continue
+		}
+		path += fmt.Sprintf("[%q]", segment)
+	}
+	return path
+}
+
+// output runs the command and returns its output without the trailing newline.  The output is not part of errors,
+// it may contain the secret.
+func output(ctx context.Context, name string, args ...string) (string, error) {
+	// #nosec G204 the command is one of the providers, its arguments are from the test suite
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// field returns the string form of the field of a secret.
+func field(fields map[string]interface{}, ref, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %q", ref, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/ci/db":
+			_, _ = w.Write([]byte(`{"data":{"data":{"user":"kuttl","password":"hunter2","port":5432},"metadata":{"version":3}}}`))
+		case "/v1/kv/ci/token":
+			_, _ = w.Write([]byte(`{"data":{"token":"abc"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	p := &VaultProvider{Address: server.URL, Token: "s.token"}
+	tests := []struct {
+		name     string
+		ref      string
+		key      string
+		expected string
+		err      string
+	}{
+		{name: "kv version 2", ref: "secret/data/ci/db", key: "password", expected: "hunter2"},
+		{name: "non string field", ref: "secret/data/ci/db", key: "port", expected: "5432"},
+		{name: "single field", ref: "kv/ci/token", expected: "abc"},
+		{name: "missing key", ref: "secret/data/ci/db", key: "host", err: `secret secret/data/ci/db has no key "host"`},
+		{name: "ambiguous", ref: "secret/data/ci/db", err: "secret secret/data/ci/db has 3 fields, the key must be set"},
+		{name: "not found", ref: "secret/data/missing", key: "password", err: "reading secret secret/data/missing: 404 Not Found"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := p.Resolve(context.TODO(), tt.ref, tt.key)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+
+	denied := &VaultProvider{Address: server.URL, Token: "s.other"}
+	_, err := denied.Resolve(context.TODO(), "secret/data/ci/db", "password")
+	assert.EqualError(t, err, "reading secret secret/data/ci/db: 403 Forbidden: permission denied")
+
+	t.Setenv("VAULT_ADDR", "")
+	_, err = (&VaultProvider{}).Resolve(context.TODO(), "secret/data/ci/db", "password")
+	assert.EqualError(t, err, "the address of the Vault server is not set, set $VAULT_ADDR")
+}
+
+// fakeCommand installs an executable script with the name on the PATH of the test.
+func fakeCommand(t *testing.T, name, script string) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestCLIProviders(t *testing.T) {
+	fakeCommand(t, "aws", `[ "$4" = "ci/db" ] || { echo "ResourceNotFoundException: $4" >&2; exit 254; }
+echo '{"user":"kuttl","password":"hunter2"}'
+`)
+	fakeCommand(t, "sops", `echo "$@"`)
+
+	value, err := awsProvider{}.Resolve(context.TODO(), "ci/db", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	value, err = awsProvider{}.Resolve(context.TODO(), "ci/db", "")
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":"kuttl","password":"hunter2"}`, value)
+
+	_, err = awsProvider{}.Resolve(context.TODO(), "ci/missing", "password")
+	assert.EqualError(t, err, "aws failed: exit status 254: ResourceNotFoundException: ci/missing")
+
+	value, err = sopsProvider{}.Resolve(context.TODO(), "secrets.enc.yaml", "db.hosts.0")
+	require.NoError(t, err)
+	assert.Equal(t, `--decrypt --extract ["db"]["hosts"][0] secrets.enc.yaml`, value)
+}
+
+type fakeProvider map[string]string
+
+func (p fakeProvider) Resolve(_ context.Context, ref, key string) (string, error) {
+	return p[ref+"#"+key], nil
+}
+
+func TestResolve(t *testing.T) {
+	defer func(previous map[string]Provider) { Providers = previous }(Providers)
+	Providers = map[string]Provider{"fake": fakeProvider{"ci/db#password": "hunter2", "ci/token#": "abc"}}
+
+	values, err := Resolve(context.TODO(), []harness.Secret{
+		{Name: "DB_PASSWORD", Provider: "fake", Ref: "ci/db", Key: "password"},
+		{Name: "TOKEN", Provider: "fake", Ref: "ci/token"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "hunter2", "TOKEN": "abc"}, values)
+
+	tests := []struct {
+		name    string
+		secrets []harness.Secret
+		err     string
+	}{
+		{name: "invalid name", secrets: []harness.Secret{{Name: "db-password", Provider: "fake", Ref: "ci/db"}}, err: `secret name "db-password" is not a valid environment variable name`},
+		{name: "duplicate", secrets: []harness.Secret{{Name: "TOKEN", Provider: "fake", Ref: "ci/token"}, {Name: "TOKEN", Provider: "fake", Ref: "ci/token"}}, err: "secret TOKEN is declared more than once"},
+		{name: "unknown provider", secrets: []harness.Secret{{Name: "TOKEN", Provider: "keychain", Ref: "ci/token"}}, err: `secret TOKEN: unknown provider "keychain", must be one of fake`},
+		{name: "no ref", secrets: []harness.Secret{{Name: "TOKEN", Provider: "fake"}}, err: "secret TOKEN: ref must be set"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Resolve(context.TODO(), tt.secrets)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
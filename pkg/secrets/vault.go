@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VaultProvider reads secrets from the HTTP API of HashiCorp Vault.  The ref is the API path of the secret without
+// the /v1 prefix, ex. "secret/data/ci/db" for the "ci/db" secret of a KV version 2 engine mounted at "secret".
+type VaultProvider struct {
+	// Address of the Vault server, $VAULT_ADDR if not set.
+	Address string
+	// Token authenticating the requests, $VAULT_TOKEN or the token stored by `vault login` if not set.
+	Token string
+	// Client sends the requests, http.DefaultClient if not set.
+	Client *http.Client
+}
+
+func (p *VaultProvider) Resolve(ctx context.Context, ref, key string) (string, error) {
+	address := p.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", errors.New("the address of the Vault server is not set, set $VAULT_ADDR")
+	}
+	token, err := p.token()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(address, "/")+"/v1/"+strings.TrimPrefix(ref, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body := struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []string               `json:"errors"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("decoding secret %s: %w", ref, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body.Errors) > 0 {
+			return "", fmt.Errorf("reading secret %s: %s: %s", ref, resp.Status, strings.Join(body.Errors, ", "))
+		}
+		return "", fmt.Errorf("reading secret %s: %s", ref, resp.Status)
+	}
+
+	fields := body.Data
+	// the fields of KV version 2 secrets are nested with their metadata
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nested
+		}
+	}
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret %s has %d fields, the key must be set", ref, len(fields))
+		}
+		for name := range fields {
+			key = name
+		}
+	}
+	return field(fields, ref, key)
+}
+
+// token returns the token of the provider, $VAULT_TOKEN or the token stored by `vault login`.
+func (p *VaultProvider) token() (string, error) {
+	if p.Token != "" {
+		return p.Token, nil
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if token, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			return strings.TrimSpace(string(token)), nil
+		}
+	}
+	return "", errors.New("no Vault token is set, set $VAULT_TOKEN or run vault login")
+}
@@ -0,0 +1,472 @@
+// Package serve runs the test suites submitted to an HTTP API, so that kuttl can be embedded as a service.
+package serve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+// configFile is the name of the test suite configuration written for a run.
+const configFile = "kuttl-test.yaml"
+
+// maxSuiteSize is the maximum size of a submitted test suite.
+const maxSuiteSize = 1 << 20
+
+// pathFields are the fields of a TestSuite which hold paths, as in the configuration files of the test command.
+var pathFields = []string{"crdDir", "manifestDirs", "testDirs", "kindConfig", "fragmentsDir", "suppressionsFile"}
+
+// Runner runs kuttl with args in dir and writes its output to out.  An error is expected for failing tests, the
+// result of the run is read from the report in the artifacts directory.
+type Runner func(ctx context.Context, dir string, args []string, out io.Writer) error
+
+// ExecRunner returns a Runner which runs the kuttl executable at path.
+func ExecRunner(path string) Runner {
+	return func(ctx context.Context, dir string, args []string, out io.Writer) error {
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Dir = dir
+		cmd.Stdout = out
+		cmd.Stderr = out
+		return cmd.Run()
+	}
+}
+
+// Phase is the phase of a run.
+type Phase string
+
+const (
+	// Pending runs wait for another run to finish.
+	Pending Phase = "Pending"
+	// Running runs are running their tests.
+	Running Phase = "Running"
+	// Succeeded runs passed all their tests.
+	Succeeded Phase = "Succeeded"
+	// Failed runs have failing tests.
+	Failed Phase = "Failed"
+	// Error runs could not run their tests or report their results.
+	Error Phase = "Error"
+	// Canceled runs were canceled before they finished.
+	Canceled Phase = "Canceled"
+)
+
+// Run is the status of a submitted test suite.
+type Run struct {
+	ID             string     `json:"id"`
+	Phase          Phase      `json:"phase"`
+	Message        string     `json:"message,omitempty"`
+	Tests          int        `json:"tests"`
+	Failures       int        `json:"failures"`
+	SubmitTime     time.Time  `json:"submitTime"`
+	StartTime      *time.Time `json:"startTime,omitempty"`
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+}
+
+// finished returns true if the run will not change anymore.
+func (r Run) finished() bool {
+	return r.Phase != Pending && r.Phase != Running
+}
+
+// Server runs the test suites submitted to its API, each in its own directory.  Its routes are:
+//
+//	POST   /runs                  submits the TestSuite of the body, arg query parameters are added to the arguments of the test command
+//	GET    /runs                  lists the runs
+//	GET    /runs/<id>             returns the status of a run
+//	GET    /runs/<id>/log         streams the output of a run until it finishes
+//	GET    /runs/<id>/report      returns the JSON report of a finished run
+//	GET    /runs/<id>/artifacts/  serves the artifacts of a run
+//	DELETE /runs/<id>             cancels a run, or deletes a finished run and its artifacts
+type Server struct {
+	// Runner runs kuttl for a test suite.
+	Runner Runner
+	// Dir is the directory the tests are run in, relative paths of submitted test suites are relative to it.  The
+	// working directory is used if it is empty.
+	Dir string
+	// WorkDir is the directory the runs are stored in, the default directory for temporary files is used if it is
+	// empty.
+	WorkDir string
+	// MaxConcurrentRuns is the maximum number of runs running at once (default 1).
+	MaxConcurrentRuns int
+
+	init   sync.Once
+	slots  chan struct{}
+	lock   sync.Mutex
+	runs   map[string]*run
+	nextID int
+}
+
+// run is a submitted test suite.
+type run struct {
+	status Run
+	dir    string
+	log    *output
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *Server) setup() {
+	s.init.Do(func() {
+		s.slots = make(chan struct{}, max(s.MaxConcurrentRuns, 1))
+		s.runs = map[string]*run{}
+	})
+}
+
+// ServeHTTP serves the API of the server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.setup()
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "runs" {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.list())
+		case http.MethodPost:
+			s.submit(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, resource, _ := strings.Cut(strings.TrimPrefix(path, "runs/"), "/")
+	if !strings.HasPrefix(path, "runs/") || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	s.lock.Lock()
+	current, ok := s.runs[id]
+	var status Run
+	if ok {
+		status = current.status
+	}
+	s.lock.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("run %s not found", id), http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case resource == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, status)
+	case resource == "" && r.Method == http.MethodDelete:
+		s.delete(w, current)
+	case resource == "log" && r.Method == http.MethodGet:
+		current.log.stream(r.Context(), w)
+	case resource == "report" && r.Method == http.MethodGet:
+		ts, err := report.Load(filepath.Join(current.dir, "artifacts"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, ts)
+	case (resource == "artifacts" || strings.HasPrefix(resource, "artifacts/")) && r.Method == http.MethodGet:
+		prefix := "/runs/" + id + "/artifacts"
+		http.StripPrefix(prefix, http.FileServer(http.Dir(filepath.Join(current.dir, "artifacts")))).ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// list returns the runs ordered by their submission.
+func (s *Server) list() []Run {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	runs := []Run{}
+	for _, current := range s.runs {
+		runs = append(runs, current.status)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		a, _ := strconv.Atoi(runs[i].ID)
+		b, _ := strconv.Atoi(runs[j].ID)
+		return a < b
+	})
+	return runs
+}
+
+// submit starts a run of the TestSuite of the request body.
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxSuiteSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxSuiteSize {
+		http.Error(w, "the test suite is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	suite, err := s.loadSuite(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dir, err := os.MkdirTemp(s.WorkDir, "kuttl-run-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, configFile), suite, 0644); err != nil {
+		os.RemoveAll(dir)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.lock.Lock()
+	s.nextID++
+	current := &run{
+		status: Run{ID: strconv.Itoa(s.nextID), Phase: Pending, SubmitTime: time.Now()},
+		dir:    dir,
+		log:    newOutput(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.runs[current.status.ID] = current
+	status := current.status
+	s.lock.Unlock()
+
+	go s.execute(ctx, current, r.URL.Query()["arg"])
+	writeJSON(w, http.StatusCreated, status)
+}
+
+// loadSuite returns the configuration of the TestSuite in data, with its relative paths resolved against the
+// directory of the server.
+func (s *Server) loadSuite(data []byte) ([]byte, error) {
+	obj := &unstructured.Unstructured{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), len(data)).Decode(obj); err != nil {
+		return nil, fmt.Errorf("decoding the test suite: %w", err)
+	}
+	if obj.GetKind() != "TestSuite" {
+		return nil, fmt.Errorf("expected a TestSuite, got %q", obj.GetKind())
+	}
+	if dirs, _, _ := unstructured.NestedStringSlice(obj.Object, "testDirs"); len(dirs) == 0 {
+		return nil, errors.New("the test suite has no testDirs")
+	}
+
+	dir, err := filepath.Abs(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	abs := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	for _, field := range pathFields {
+		switch value := obj.Object[field].(type) {
+		case string:
+			obj.Object[field] = abs(value)
+		case []interface{}:
+			for i, path := range value {
+				if path, ok := path.(string); ok {
+					value[i] = abs(path)
+				}
+			}
+		}
+	}
+	unstructured.RemoveNestedField(obj.Object, "metadata")
+
+	// JSON is written as it is valid YAML
+	return json.Marshal(obj.Object)
+}
+
+// execute runs the tests of current once a slot is free and records the result.
+func (s *Server) execute(ctx context.Context, current *run, args []string) {
+	defer close(current.done)
+	defer current.log.close()
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		s.finish(current, Run{Phase: Canceled})
+		return
+	}
+
+	start := time.Now()
+	s.lock.Lock()
+	current.status.Phase = Running
+	current.status.StartTime = &start
+	s.lock.Unlock()
+
+	artifactsDir := filepath.Join(current.dir, "artifacts")
+	testArgs := []string{"test", "--config", filepath.Join(current.dir, configFile), "--artifacts-dir", artifactsDir,
+		"--report", "json", "--report-name", report.DefaultName}
+	runErr := s.Runner(ctx, s.Dir, append(testArgs, args...), current.log)
+
+	if ctx.Err() != nil {
+		s.finish(current, Run{Phase: Canceled})
+		return
+	}
+	ts, err := report.Load(artifactsDir)
+	if err != nil {
+		if runErr != nil {
+			err = fmt.Errorf("running tests: %w", runErr)
+		}
+		s.finish(current, Run{Phase: Error, Message: err.Error()})
+		return
+	}
+	s.finish(current, reportResult(ts))
+}
+
+// reportResult returns the result of a run with the report ts.
+func reportResult(ts *report.Testsuites) Run {
+	result := Run{Phase: Succeeded, Tests: ts.Tests, Failures: ts.Failures}
+	if ts.Failure != nil {
+		result.Phase = Failed
+		result.Message = ts.Failure.Message
+	}
+	if ts.Failures > 0 {
+		result.Phase = Failed
+	}
+	return result
+}
+
+// finish records the result of current.
+func (s *Server) finish(current *run, result Run) {
+	completion := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current.status.Phase = result.Phase
+	current.status.Message = result.Message
+	current.status.Tests = result.Tests
+	current.status.Failures = result.Failures
+	current.status.CompletionTime = &completion
+}
+
+// delete cancels current if it has not finished, otherwise current and its directory are deleted.
+func (s *Server) delete(w http.ResponseWriter, current *run) {
+	s.lock.Lock()
+	finished := current.status.finished()
+	s.lock.Unlock()
+
+	if !finished {
+		current.cancel()
+		<-current.done
+		s.lock.Lock()
+		status := current.status
+		s.lock.Unlock()
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+
+	s.lock.Lock()
+	delete(s.runs, current.status.ID)
+	s.lock.Unlock()
+	if err := os.RemoveAll(current.dir); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Shutdown cancels the runs which have not finished, waits for them and deletes the directories of all runs.
+func (s *Server) Shutdown() error {
+	s.setup()
+
+	s.lock.Lock()
+	runs := []*run{}
+	for _, current := range s.runs {
+		runs = append(runs, current)
+	}
+	s.runs = map[string]*run{}
+	s.lock.Unlock()
+
+	var errs []error
+	for _, current := range runs {
+		current.cancel()
+		<-current.done
+		if err := os.RemoveAll(current.dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// output is the output of a run, which can be streamed while it is written.
+type output struct {
+	lock    sync.Mutex
+	data    []byte
+	closed  bool
+	changed chan struct{}
+}
+
+func newOutput() *output {
+	return &output{changed: make(chan struct{})}
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.data = append(o.data, p...)
+	close(o.changed)
+	o.changed = make(chan struct{})
+	return len(p), nil
+}
+
+// close marks the output as complete.
+func (o *output) close() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.closed = true
+	close(o.changed)
+	o.changed = make(chan struct{})
+}
+
+// stream writes the output to w as it is written, until it is closed or ctx is done.
+func (o *output) stream(ctx context.Context, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	flusher, _ := w.(http.Flusher)
+
+	written := 0
+	for {
+		o.lock.Lock()
+		data, closed, changed := o.data[written:], o.closed, o.changed
+		o.lock.Unlock()
+
+		if len(data) > 0 {
+			if _, err := w.Write(data); err != nil {
+				return
+			}
+			written += len(data)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if closed {
+			return
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
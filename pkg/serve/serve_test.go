@@ -0,0 +1,173 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+const suite = `apiVersion: kuttl.dev/v1beta1
+kind: TestSuite
+testDirs:
+- tests/e2e
+timeout: 60
+`
+
+// argValue returns the value of a flag in args.
+func argValue(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// reportingRunner writes output and a report with a failing test once released.
+func reportingRunner(release chan struct{}, called chan []string) Runner {
+	return func(ctx context.Context, dir string, args []string, out io.Writer) error {
+		called <- args
+		_, _ = io.WriteString(out, "=== RUN kuttl/harness/create\n")
+		<-release
+		_, _ = io.WriteString(out, "--- FAIL: kuttl/harness/update\n")
+
+		ts := report.NewSuiteCollection("kuttl")
+		suite := ts.NewSuite("tests/e2e")
+		suite.AddTestcase(report.NewCase("create"))
+		failed := report.NewCase("update")
+		failed.Failure = report.NewFailure("update failed", nil)
+		suite.AddTestcase(failed)
+		if err := ts.Report(argValue(args, "--artifacts-dir"), argValue(args, "--report-name"), report.JSON); err != nil {
+			return err
+		}
+		return errors.New("exit status 1")
+	}
+}
+
+func request(t *testing.T, method, url, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func getRun(t *testing.T, url string) Run {
+	status, body := request(t, http.MethodGet, url, "")
+	require.Equal(t, http.StatusOK, status, body)
+	run := Run{}
+	require.NoError(t, json.Unmarshal([]byte(body), &run))
+	return run
+}
+
+func TestServer(t *testing.T) {
+	release := make(chan struct{})
+	called := make(chan []string, 1)
+	dir := t.TempDir()
+	server := &Server{Runner: reportingRunner(release, called), Dir: dir, WorkDir: t.TempDir()}
+	api := httptest.NewServer(server)
+	defer api.Close()
+	defer func() { assert.NoError(t, server.Shutdown()) }()
+
+	status, body := request(t, "POST", api.URL+"/runs", "kind: Pod")
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "expected a TestSuite, got \"Pod\"\n", body)
+	status, body = request(t, "POST", api.URL+"/runs", "kind: TestSuite")
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "the test suite has no testDirs\n", body)
+
+	status, body = request(t, "POST", api.URL+"/runs?arg=--parallel&arg=4", suite)
+	require.Equal(t, 201, status, body)
+	assert.Contains(t, body, `"id":"1"`)
+
+	args := <-called
+	assert.Equal(t, []string{"--report", "json", "--report-name", "kuttl-report", "--parallel", "4"}, args[5:])
+	config, err := os.ReadFile(argValue(args, "--config"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"apiVersion":"kuttl.dev/v1beta1","kind":"TestSuite","testDirs":["`+filepath.Join(dir, "tests/e2e")+`"],"timeout":60}`,
+		string(config))
+	assert.Equal(t, Running, getRun(t, api.URL+"/runs/1").Phase)
+
+	// the log is streamed until the run finishes
+	resp, err := api.Client().Get(api.URL + "/runs/1/log")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	line := make([]byte, len("=== RUN kuttl/harness/create\n"))
+	_, err = io.ReadFull(resp.Body, line)
+	require.NoError(t, err)
+	assert.Equal(t, "=== RUN kuttl/harness/create\n", string(line))
+	close(release)
+	rest, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "--- FAIL: kuttl/harness/update\n", string(rest))
+
+	require.Eventually(t, func() bool { return getRun(t, api.URL+"/runs/1").Phase == Failed }, 5*time.Second, 10*time.Millisecond)
+	run := getRun(t, api.URL+"/runs/1")
+	assert.Equal(t, 2, run.Tests)
+	assert.Equal(t, 1, run.Failures)
+	assert.NotNil(t, run.CompletionTime)
+
+	status, body = request(t, "GET", api.URL+"/runs/1/report", "")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"message":"update failed"`)
+	status, body = request(t, "GET", api.URL+"/runs/1/artifacts/kuttl-report.json", "")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"name": "update"`)
+	status, body = request(t, "GET", api.URL+"/runs", "")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"phase":"Failed"`)
+
+	status, _ = request(t, "DELETE", api.URL+"/runs/1", "")
+	assert.Equal(t, 204, status)
+	status, body = request(t, "GET", api.URL+"/runs/1", "")
+	assert.Equal(t, 404, status)
+	assert.Equal(t, "run 1 not found\n", body)
+}
+
+func TestServerCancel(t *testing.T) {
+	started := make(chan struct{}, 2)
+	server := &Server{
+		Runner: func(ctx context.Context, dir string, args []string, out io.Writer) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		WorkDir: t.TempDir(),
+	}
+	api := httptest.NewServer(server)
+	defer api.Close()
+	defer func() { assert.NoError(t, server.Shutdown()) }()
+
+	for _, id := range []string{"1", "2"} {
+		status, body := request(t, "POST", api.URL+"/runs", suite)
+		require.Equal(t, 201, status, body)
+		assert.Contains(t, body, `"id":"`+id+`"`)
+	}
+	<-started
+
+	// only one run is running at once
+	assert.Equal(t, Running, getRun(t, api.URL+"/runs/1").Phase)
+	assert.Equal(t, Pending, getRun(t, api.URL+"/runs/2").Phase)
+
+	status, body := request(t, "DELETE", api.URL+"/runs/1", "")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"phase":"Canceled"`)
+	<-started
+	assert.Equal(t, Running, getRun(t, api.URL+"/runs/2").Phase)
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// expectedVersion returns a copy of expected without the convert-version annotation.  If the server does not serve
+// the apiVersion of expected but serves its kind in another version of the group, it fails with the versions it
+// serves, or the copy is in the preferred one of them if the annotation is set.
+func expectedVersion(dClient discovery.DiscoveryInterface, expected runtime.Object) (runtime.Object, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, err
+	}
+
+	convert := false
+	if value, ok := m.GetAnnotations()[harness.ConvertVersionAnnotation]; ok {
+		if convert, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("annotation %s: %q is not a boolean", harness.ConvertVersionAnnotation, value)
+		}
+		if expected, err = withoutAnnotation(expected, harness.ConvertVersionAnnotation); err != nil {
+			return nil, err
+		}
+	}
+
+	gvk := expected.GetObjectKind().GroupVersionKind()
+	served, err := servesKind(dClient, gvk.GroupVersion(), gvk.Kind)
+	// other errors are left to the lookup of the object
+	if served || err != nil {
+		return expected, nil
+	}
+
+	versions, err := kindVersions(dClient, gvk.GroupKind())
+	if err != nil {
+		return expected, nil
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("the server does not serve %s in any version of the API group %q", gvk.Kind, gvk.Group)
+	}
+	if !convert {
+		return nil, fmt.Errorf("apiVersion %s is not served, the server serves %s as %s, set the %s annotation to \"true\" to compare the object in %s",
+			gvk.GroupVersion(), gvk.Kind, strings.Join(versions, ", "), harness.ConvertVersionAnnotation, versions[0])
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(expected)
+	if err != nil {
+		return nil, err
+	}
+	converted := &unstructured.Unstructured{Object: content}
+	converted.SetAPIVersion(versions[0])
+	return converted, nil
+}
+
+// servesKind returns true if the server serves the kind in the group version.
+func servesKind(dClient discovery.DiscoveryInterface, gv schema.GroupVersion, kind string) (bool, error) {
+	resources, err := dClient.ServerResourcesForGroupVersion(gv.String())
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if strings.EqualFold(resource.Kind, kind) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// kindVersions returns the group versions the server serves the kind in, the preferred version of the group first.
+func kindVersions(dClient discovery.DiscoveryInterface, gk schema.GroupKind) ([]string, error) {
+	groups, err := dClient.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+	for _, group := range groups.Groups {
+		if group.Name != gk.Group {
+			continue
+		}
+		candidates := []string{group.PreferredVersion.GroupVersion}
+		for _, version := range group.Versions {
+			if version.GroupVersion != group.PreferredVersion.GroupVersion {
+				candidates = append(candidates, version.GroupVersion)
+			}
+		}
+		for _, candidate := range candidates {
+			gv, err := schema.ParseGroupVersion(candidate)
+			if err != nil {
+				return nil, err
+			}
+			served, err := servesKind(dClient, gv, gk.Kind)
+			if err != nil {
+				return nil, err
+			}
+			if served {
+				versions = append(versions, candidate)
+			}
+		}
+	}
+	return versions, nil
+}
@@ -0,0 +1,143 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	coretesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedVersion(t *testing.T) {
+	// example.com/v2 is the preferred version, Widgets are served in v2 and v1 and Gadgets only in v1
+	dClient := &fakediscovery.FakeDiscovery{Fake: &coretesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "example.com/v2", APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Kind: "Widget"}}},
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+			{Name: "widgets", Namespaced: true, Kind: "Widget"},
+			{Name: "gadgets", Namespaced: true, Kind: "Gadget"},
+		}},
+	}}}
+
+	for _, test := range []struct {
+		name       string
+		apiVersion string
+		kind       string
+		convert    string
+		converted  string
+		err        string
+	}{
+		{
+			name:       "served",
+			apiVersion: "example.com/v1",
+			kind:       "Widget",
+			converted:  "example.com/v1",
+		},
+		{
+			name:       "not served",
+			apiVersion: "example.com/v1beta1",
+			kind:       "Widget",
+			err:        `apiVersion example.com/v1beta1 is not served, the server serves Widget as example.com/v2, example.com/v1, set the kuttl.dev/convert-version annotation to "true" to compare the object in example.com/v2`,
+		},
+		{
+			name:       "converted to the preferred version",
+			apiVersion: "example.com/v1beta1",
+			kind:       "Widget",
+			convert:    "true",
+			converted:  "example.com/v2",
+		},
+		{
+			name:       "converted to the only version of the kind",
+			apiVersion: "example.com/v1beta1",
+			kind:       "Gadget",
+			convert:    "true",
+			converted:  "example.com/v1",
+		},
+		{
+			name:       "served version is not converted",
+			apiVersion: "example.com/v1",
+			kind:       "Widget",
+			convert:    "true",
+			converted:  "example.com/v1",
+		},
+		{
+			name:       "kind not served",
+			apiVersion: "example.com/v1",
+			kind:       "Gizmo",
+			convert:    "true",
+			err:        `the server does not serve Gizmo in any version of the API group "example.com"`,
+		},
+		{
+			name:       "not converted",
+			apiVersion: "example.com/v1beta1",
+			kind:       "Widget",
+			convert:    "false",
+			err:        `apiVersion example.com/v1beta1 is not served, the server serves Widget as example.com/v2, example.com/v1, set the kuttl.dev/convert-version annotation to "true" to compare the object in example.com/v2`,
+		},
+		{
+			name:       "invalid annotation",
+			apiVersion: "example.com/v1beta1",
+			kind:       "Widget",
+			convert:    "preferred",
+			err:        `annotation kuttl.dev/convert-version: "preferred" is not a boolean`,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			expected := testutils.NewResource(test.apiVersion, test.kind, "hello", "")
+			expected.Object["spec"] = map[string]interface{}{"size": int64(3)}
+			if test.convert != "" {
+				expected = testutils.SetAnnotation(expected, harness.ConvertVersionAnnotation, test.convert)
+			}
+
+			versioned, err := expectedVersion(dClient, expected)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			obj := versioned.(*unstructured.Unstructured)
+			assert.Equal(t, test.converted, obj.GetAPIVersion())
+			assert.Equal(t, test.kind, obj.GetKind())
+			assert.Nil(t, obj.GetAnnotations())
+			assert.Equal(t, map[string]interface{}{"size": int64(3)}, obj.Object["spec"])
+		})
+	}
+}
+
+func TestCheckResourceConvertVersion(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: testNamespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	step := Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) {
+			return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(deployment).Build(), nil
+		},
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+
+	expected := testutils.NewResource("apps/v1beta2", "Deployment", "operator", "")
+	expected.Object["spec"] = map[string]interface{}{"replicas": int64(2)}
+
+	errs := step.CheckResource(expected, testNamespace)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `resource Deployment:/operator: apiVersion apps/v1beta2 is not served, the server serves Deployment as apps/v1, set the kuttl.dev/convert-version annotation to "true" to compare the object in apps/v1`)
+
+	converted := testutils.SetAnnotation(expected, harness.ConvertVersionAnnotation, "true")
+	assert.Equal(t, []error{}, step.CheckResource(converted, testNamespace))
+	assert.Error(t, step.CheckResourceAbsent(converted, testNamespace))
+}
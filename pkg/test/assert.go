@@ -35,9 +35,11 @@ func Assert(namespace string, timeout int, assertFiles ...string) error {
 	for i := 0; i < timeout; i++ {
 		// start fresh
 		testErrors = []error{}
+		restore := s.cacheLists()
 		for _, expected := range objects {
 			testErrors = append(testErrors, s.CheckResource(expected, namespace)...)
 		}
+		restore()
 
 		if len(testErrors) == 0 {
 			break
@@ -57,6 +59,36 @@ func Assert(namespace string, timeout int, assertFiles ...string) error {
 	return errors.New("asserts not valid")
 }
 
+// AssertOffline checks all provided assert files once against a fake cluster holding the objects of the fixture files
+// or directories, without an API server.  Upon assert failure, it prints the failures and returns an error
+func AssertOffline(namespace string, fixtures []string, assertFiles ...string) error {
+	var objects []client.Object
+
+	for _, file := range assertFiles {
+		o, err := ObjectsFromPath(file, "")
+		if err != nil {
+			return err
+		}
+		objects = append(objects, o...)
+	}
+
+	cluster, err := LoadFakeCluster(namespace, fixtures...)
+	if err != nil {
+		return err
+	}
+
+	testErrors := cluster.Assert(namespace, objects...)
+	if len(testErrors) == 0 {
+		fmt.Printf("assert is valid\n")
+		return nil
+	}
+
+	for _, testError := range testErrors {
+		fmt.Println(testError)
+	}
+	return errors.New("asserts not valid")
+}
+
 // Errors checks all provided errors files against a namespace.  Upon assert failure, it prints the failures and returns an error
 func Errors(namespace string, timeout int, errorFiles ...string) error {
 	var objects []client.Object
@@ -80,11 +112,13 @@ func Errors(namespace string, timeout int, errorFiles ...string) error {
 	for i := 0; i < timeout; i++ {
 		// start fresh
 		testErrors = []error{}
+		restore := s.cacheLists()
 		for _, expected := range objects {
 			if err := s.CheckResourceAbsent(expected, namespace); err != nil {
 				testErrors = append(testErrors, err)
 			}
 		}
+		restore()
 
 		if len(testErrors) == 0 {
 			break
@@ -0,0 +1,304 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// defaultLoadImage is the image of the load pods of autoscaler checks if they do not set one.
+const defaultLoadImage = "busybox:1.36"
+
+// loadURLEnv is the environment variable of the load pods set to the URL they request.
+const loadURLEnv = "LOAD_URL"
+
+// autoscaleInterval is the interval at which the metrics APIs and the autoscalers of autoscaler checks are checked.
+var autoscaleInterval = time.Second
+
+// Autoscale runs the autoscaler checks of the step, each of them generates its load and waits until the autoscaler has
+// scaled its target.
+func (s *Step) Autoscale(namespace string) []error {
+	if s.Step == nil || len(s.Step.Autoscalers) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, check := range s.Step.Autoscalers {
+		if err := s.autoscale(cl, check, namespace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// autoscale waits until the autoscaler of check reads its metrics, starts the load and waits until the target is scaled
+// within the bounds of check.
+func (s *Step) autoscale(cl client.Client, check harness.AutoscalerCheck, namespace string) error {
+	if check.Namespace != "" {
+		namespace = check.Namespace
+	}
+	id := fmt.Sprintf("HorizontalPodAutoscaler %s/%s", namespace, check.Name)
+	if check.MaxReplicas != 0 && check.MinReplicas > check.MaxReplicas {
+		return fmt.Errorf("%s: minReplicas %d is above maxReplicas %d", id, check.MinReplicas, check.MaxReplicas)
+	}
+
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = s.GetTimeout()
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	ctx := context.TODO()
+	key := client.ObjectKey{Namespace: namespace, Name: check.Name}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := cl.Get(ctx, key, hpa); err != nil {
+		return fmt.Errorf("getting %s: %w", id, err)
+	}
+
+	if err := waitForMetricsAPIs(cl, hpa, deadline); err != nil {
+		return fmt.Errorf("%s: %w", id, err)
+	}
+
+	reason := "not checked"
+	err := pollUntil(deadline, func() (bool, error) {
+		if err := cl.Get(ctx, key, hpa); err != nil {
+			return false, err
+		}
+		var active bool
+		active, reason = scalingActive(hpa)
+		return active, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("timed out waiting for %s to read its metrics: %s", id, reason)
+	}
+	if err != nil {
+		return fmt.Errorf("getting %s: %w", id, err)
+	}
+
+	minReplicas := check.MinReplicas
+	if minReplicas == 0 {
+		minReplicas = hpa.Status.CurrentReplicas + 1
+	}
+
+	if check.Load != nil {
+		pods, err := s.startLoad(cl, check.Load, namespace)
+		defer s.stopLoad(cl, pods)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		s.Logger.Logf("started %d load pods for %s", len(pods), id)
+	}
+
+	err = pollUntil(deadline, func() (bool, error) {
+		if err := cl.Get(ctx, key, hpa); err != nil {
+			return false, err
+		}
+		return scaledWithin(hpa.Status, minReplicas, check.MaxReplicas)
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("timed out waiting for %s to scale its target to %d replicas: %s", id, minReplicas, replicasMessage(hpa.Status))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", id, err)
+	}
+	s.Logger.Logf("%s scaled its target to %d replicas", id, hpa.Status.CurrentReplicas)
+	return nil
+}
+
+// pollUntil calls condition at the autoscale interval until it is done, it fails with wait.ErrWaitTimeout once the
+// deadline has passed.
+func pollUntil(deadline time.Time, condition wait.ConditionFunc) error {
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		timeout = time.Nanosecond
+	}
+	return wait.PollImmediate(autoscaleInterval, timeout, condition)
+}
+
+// scaledWithin returns whether the autoscaler has scaled its target to at least minReplicas, it fails if it has scaled
+// it above maxReplicas (if set).
+func scaledWithin(status autoscalingv2.HorizontalPodAutoscalerStatus, minReplicas, maxReplicas int32) (bool, error) {
+	if maxReplicas != 0 && status.CurrentReplicas > maxReplicas {
+		return false, fmt.Errorf("scaled its target above the maximum of %d replicas: %s", maxReplicas, replicasMessage(status))
+	}
+	return status.CurrentReplicas >= minReplicas, nil
+}
+
+// replicasMessage describes the replicas of the target of an autoscaler.
+func replicasMessage(status autoscalingv2.HorizontalPodAutoscalerStatus) string {
+	return fmt.Sprintf("%d current, %d desired replicas", status.CurrentReplicas, status.DesiredReplicas)
+}
+
+// scalingActive returns whether the autoscaler is able to read its metrics and scale its target, and the message of its
+// ScalingActive condition otherwise.
+func scalingActive(hpa *autoscalingv2.HorizontalPodAutoscaler) (bool, string) {
+	for _, condition := range hpa.Status.Conditions {
+		if condition.Type != autoscalingv2.ScalingActive {
+			continue
+		}
+		if condition.Status == corev1.ConditionTrue {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+	}
+	return false, "the autoscaler has no ScalingActive condition yet"
+}
+
+// metricsAPIServices returns the names of the APIServices serving the metrics of the autoscaler.
+func metricsAPIServices(hpa *autoscalingv2.HorizontalPodAutoscaler) []string {
+	names := map[string]bool{}
+	for _, metric := range hpa.Spec.Metrics {
+		switch metric.Type {
+		case autoscalingv2.ResourceMetricSourceType, autoscalingv2.ContainerResourceMetricSourceType:
+			names["v1beta1.metrics.k8s.io"] = true
+		case autoscalingv2.PodsMetricSourceType, autoscalingv2.ObjectMetricSourceType:
+			names["v1beta1.custom.metrics.k8s.io"] = true
+		case autoscalingv2.ExternalMetricSourceType:
+			names["v1beta1.external.metrics.k8s.io"] = true
+		}
+	}
+	// autoscalers without metrics scale on the CPU utilization of their target
+	if len(hpa.Spec.Metrics) == 0 {
+		names["v1beta1.metrics.k8s.io"] = true
+	}
+
+	services := []string{}
+	for name := range names {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// waitForMetricsAPIs waits until the APIServices serving the metrics of the autoscaler are available.
+func waitForMetricsAPIs(cl client.Client, hpa *autoscalingv2.HorizontalPodAutoscaler, deadline time.Time) error {
+	for _, name := range metricsAPIServices(hpa) {
+		message := "not checked"
+		err := pollUntil(deadline, func() (bool, error) {
+			service := &unstructured.Unstructured{}
+			service.SetAPIVersion("apiregistration.k8s.io/v1")
+			service.SetKind("APIService")
+			if err := cl.Get(context.TODO(), client.ObjectKey{Name: name}, service); err != nil {
+				if k8serrors.IsNotFound(err) {
+					message = "it is not installed (ex. metrics-server is missing)"
+					return false, nil
+				}
+				return false, err
+			}
+			var available bool
+			available, message = apiServiceAvailable(service)
+			return available, nil
+		})
+		if errors.Is(err, wait.ErrWaitTimeout) {
+			return fmt.Errorf("timed out waiting for the metrics API %s: %s", name, message)
+		}
+		if err != nil {
+			return fmt.Errorf("getting the metrics API %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// apiServiceAvailable returns whether the APIService is available, and the message of its Available condition
+// otherwise.
+func apiServiceAvailable(service *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(service.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Available" {
+			continue
+		}
+		if condition["status"] == string(corev1.ConditionTrue) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%v: %v", condition["reason"], condition["message"])
+	}
+	return false, "it has no Available condition yet"
+}
+
+// startLoad creates the load pods, it returns the pods created before an error.
+func (s *Step) startLoad(cl client.Client, load *harness.AutoscalerLoad, namespace string) ([]*corev1.Pod, error) {
+	if load.URL != "" {
+		// the load pods are helper pods, their requests may be blocked by the NetworkPolicies of the namespace
+		if err := s.checkNetworkPolicies(namespace); err != nil {
+			return nil, err
+		}
+	}
+
+	count := load.Pods
+	if count == 0 {
+		count = 1
+	}
+	pods := []*corev1.Pod{}
+	for i := 0; i < count; i++ {
+		pod, err := s.loadPod(load, namespace)
+		if err != nil {
+			return pods, err
+		}
+		if err := cl.Create(context.TODO(), pod); err != nil {
+			return pods, fmt.Errorf("creating load pod: %w", err)
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// stopLoad deletes the load pods, failing to delete them is logged as the pods are deleted with the namespace.
+func (s *Step) stopLoad(cl client.Client, pods []*corev1.Pod) {
+	for _, pod := range pods {
+		if err := cl.Delete(context.TODO(), pod, client.GracePeriodSeconds(0)); err != nil && !k8serrors.IsNotFound(err) {
+			s.Logger.Logf("deleting load pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+}
+
+// loadPod returns a helper pod generating the load, it requests the URL of the load in a loop unless the load sets a
+// command.
+func (s *Step) loadPod(load *harness.AutoscalerLoad, namespace string) (*corev1.Pod, error) {
+	container := corev1.Container{
+		Name:    "load",
+		Image:   load.Image,
+		Command: load.Command,
+	}
+	if container.Image == "" {
+		container.Image = defaultLoadImage
+	}
+	if len(container.Command) == 0 {
+		if load.URL == "" {
+			return nil, errors.New("the load sets neither a URL nor a command")
+		}
+		container.Command = []string{"sh", "-c", fmt.Sprintf(`while true; do wget -q -O /dev/null "$%s"; done`, loadURLEnv)}
+		container.Env = []corev1.EnvVar{{Name: loadURLEnv, Value: load.URL}}
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kuttl-load-",
+			Namespace:    namespace,
+			Labels:       map[string]string{harness.HelperPodLabel: "true"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+			Containers:    []corev1.Container{container},
+		},
+	}
+	scheduleHelperPod(&pod.Spec, s.HelperPods)
+	return pod, nil
+}
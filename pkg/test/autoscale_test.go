@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// autoscalingClient scales the target of HorizontalPodAutoscalers by step replicas each time they are read while load
+// pods are running, like the autoscaler controller.
+type autoscalingClient struct {
+	client.Client
+	step int32
+}
+
+func (c *autoscalingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.Client.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	hpa, ok := obj.(*autoscalingv2.HorizontalPodAutoscaler)
+	if !ok {
+		return nil
+	}
+	pods := &corev1.PodList{}
+	if err := c.Client.List(ctx, pods, client.InNamespace(key.Namespace), client.MatchingLabels{harness.HelperPodLabel: "true"}); err != nil {
+		return err
+	}
+	if len(pods.Items) > 0 {
+		hpa.Status.CurrentReplicas += c.step
+		hpa.Status.DesiredReplicas = hpa.Status.CurrentReplicas
+		return c.Client.Update(ctx, hpa)
+	}
+	return nil
+}
+
+func metricsAPIService(status string) *unstructured.Unstructured {
+	service := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiregistration.k8s.io/v1",
+		"kind":       "APIService",
+		"metadata":   map[string]interface{}{"name": "v1beta1.metrics.k8s.io"},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": status, "reason": "MissingEndpoints", "message": "no endpoints"},
+		}},
+	}}
+	return service
+}
+
+func TestAutoscale(t *testing.T) {
+	autoscaleInterval = 10 * time.Millisecond
+	defer func() { autoscaleInterval = time.Second }()
+
+	hpa := func(active corev1.ConditionStatus) *autoscalingv2.HorizontalPodAutoscaler {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+				CurrentReplicas: 1,
+				Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+					{Type: autoscalingv2.ScalingActive, Status: active, Reason: "FailedGetResourceMetric", Message: "no metrics"},
+				},
+			},
+		}
+	}
+	load := &harness.AutoscalerLoad{URL: "http://web", Pods: 2}
+
+	for _, test := range []struct {
+		name  string
+		check harness.AutoscalerCheck
+		objs  []client.Object
+		step  int32
+		err   string
+	}{
+		{
+			name:  "scaled",
+			check: harness.AutoscalerCheck{Name: "web", Load: load, MinReplicas: 3, MaxReplicas: 5},
+			objs:  []client.Object{hpa(corev1.ConditionTrue), metricsAPIService("True")},
+			step:  1,
+		},
+		{
+			name:  "scaled by one replica by default",
+			check: harness.AutoscalerCheck{Name: "web", Load: load},
+			objs:  []client.Object{hpa(corev1.ConditionTrue), metricsAPIService("True")},
+			step:  1,
+		},
+		{
+			name:  "scaled above the maximum",
+			check: harness.AutoscalerCheck{Name: "web", Load: load, MinReplicas: 3, MaxReplicas: 4},
+			objs:  []client.Object{hpa(corev1.ConditionTrue), metricsAPIService("True")},
+			step:  4,
+			err:   "scaled its target above the maximum of 4 replicas: 5 current, 5 desired replicas",
+		},
+		{
+			name:  "not scaled",
+			check: harness.AutoscalerCheck{Name: "web", Load: load, MinReplicas: 3},
+			objs:  []client.Object{hpa(corev1.ConditionTrue), metricsAPIService("True")},
+			err:   "timed out waiting for HorizontalPodAutoscaler " + testNamespace + "/web to scale its target to 3 replicas: 1 current, 1 desired replicas",
+		},
+		{
+			name:  "metrics not installed",
+			check: harness.AutoscalerCheck{Name: "web", Load: load},
+			objs:  []client.Object{hpa(corev1.ConditionTrue)},
+			err:   "timed out waiting for the metrics API v1beta1.metrics.k8s.io: it is not installed (ex. metrics-server is missing)",
+		},
+		{
+			name:  "metrics not available",
+			check: harness.AutoscalerCheck{Name: "web", Load: load},
+			objs:  []client.Object{hpa(corev1.ConditionTrue), metricsAPIService("False")},
+			err:   "timed out waiting for the metrics API v1beta1.metrics.k8s.io: MissingEndpoints: no endpoints",
+		},
+		{
+			name:  "metrics not read",
+			check: harness.AutoscalerCheck{Name: "web", Load: load},
+			objs:  []client.Object{hpa(corev1.ConditionFalse), metricsAPIService("True")},
+			err:   "to read its metrics: FailedGetResourceMetric: no metrics",
+		},
+		{
+			name:  "invalid bounds",
+			check: harness.AutoscalerCheck{Name: "web", MinReplicas: 3, MaxReplicas: 2},
+			err:   "minReplicas 3 is above maxReplicas 2",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := &autoscalingClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(test.objs...).Build(), step: test.step}
+			test.check.Timeout = 1
+			step := &Step{
+				Step:   &harness.TestStep{Autoscalers: []harness.AutoscalerCheck{test.check}},
+				Logger: testutils.NewTestLogger(t, test.name),
+				Client: func(bool) (client.Client, error) { return cl, nil },
+			}
+
+			errs := step.Autoscale(testNamespace)
+			if test.err == "" {
+				assert.Empty(t, errs)
+			} else {
+				require.Len(t, errs, 1)
+				assert.ErrorContains(t, errs[0], test.err)
+			}
+
+			pods := &corev1.PodList{}
+			require.NoError(t, cl.List(context.TODO(), pods))
+			assert.Empty(t, pods.Items, "the load pods are deleted")
+		})
+	}
+}
+
+func TestMetricsAPIServices(t *testing.T) {
+	metrics := func(types ...autoscalingv2.MetricSourceType) *autoscalingv2.HorizontalPodAutoscaler {
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		for _, metricType := range types {
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, autoscalingv2.MetricSpec{Type: metricType})
+		}
+		return hpa
+	}
+
+	assert.Equal(t, []string{"v1beta1.metrics.k8s.io"}, metricsAPIServices(metrics()))
+	assert.Equal(t, []string{"v1beta1.metrics.k8s.io"}, metricsAPIServices(metrics(autoscalingv2.ResourceMetricSourceType, autoscalingv2.ContainerResourceMetricSourceType)))
+	assert.Equal(t, []string{"v1beta1.custom.metrics.k8s.io", "v1beta1.external.metrics.k8s.io", "v1beta1.metrics.k8s.io"},
+		metricsAPIServices(metrics(autoscalingv2.ExternalMetricSourceType, autoscalingv2.PodsMetricSourceType, autoscalingv2.ObjectMetricSourceType, autoscalingv2.ResourceMetricSourceType)))
+}
+
+func TestLoadPod(t *testing.T) {
+	step := &Step{HelperPods: &harness.HelperPodScheduling{NodeSelector: map[string]string{"pool": "helpers"}}}
+
+	pod, err := step.loadPod(&harness.AutoscalerLoad{URL: "http://web"}, testNamespace)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{harness.HelperPodLabel: "true"}, pod.Labels)
+	assert.Equal(t, map[string]string{"pool": "helpers"}, pod.Spec.NodeSelector)
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, defaultLoadImage, container.Image)
+	assert.Equal(t, []corev1.EnvVar{{Name: loadURLEnv, Value: "http://web"}}, container.Env)
+
+	pod, err = step.loadPod(&harness.AutoscalerLoad{Image: "loader", Command: []string{"load", "--rps", "100"}}, testNamespace)
+	require.NoError(t, err)
+	assert.Equal(t, "loader", pod.Spec.Containers[0].Image)
+	assert.Equal(t, []string{"load", "--rps", "100"}, pod.Spec.Containers[0].Command)
+	assert.Empty(t, pod.Spec.Containers[0].Env)
+
+	_, err = step.loadPod(&harness.AutoscalerLoad{}, testNamespace)
+	assert.EqualError(t, err, "the load sets neither a URL nor a command")
+}
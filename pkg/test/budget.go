@@ -0,0 +1,208 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+// runTracker tracks the phase (ex. the step) of the harness and of each running test, so that a run aborted after
+// its suite or test timeout can report what was running.  A nil runTracker does not track anything.
+type runTracker struct {
+	lock    sync.Mutex
+	phase   string
+	running map[string]*trackedTest
+	aborted bool
+}
+
+// trackedTest is a running test.
+type trackedTest struct {
+	suite *report.Testsuite
+	tc    *report.Testcase
+	phase string
+	// reported is set once tc has been added to suite.
+	reported bool
+}
+
+func newRunTracker() *runTracker {
+	return &runTracker{phase: "setup", running: map[string]*trackedTest{}}
+}
+
+// setPhase sets the phase of the harness.
+func (r *runTracker) setPhase(phase string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.phase = phase
+}
+
+// start tracks the test name of suite, whose results are tc.
+func (r *runTracker) start(name string, suite *report.Testsuite, tc *report.Testcase) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.running[name] = &trackedTest{suite: suite, tc: tc, phase: "starting"}
+}
+
+// progress returns a function setting the phase of the test name.
+func (r *runTracker) progress(name string) func(string) {
+	if r == nil {
+		return nil
+	}
+	return func(phase string) {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if test, ok := r.running[name]; ok {
+			test.phase = phase
+		}
+	}
+}
+
+// addTestcase adds the results of the test name to its suite, unless they were already added by abort.  Tests are
+// tracked until their cleanups have run, so the results are added before the test ends.
+func (r *runTracker) addTestcase(name string, suite *report.Testsuite, tc *report.Testcase) {
+	if r == nil {
+		suite.AddTestcase(tc)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.aborted {
+		return
+	}
+	if test, ok := r.running[name]; ok {
+		test.reported = true
+	}
+	suite.AddTestcase(tc)
+}
+
+// addSkipped adds the results of a test which was skipped because the run was aborted before it started.
+func (r *runTracker) addSkipped(suite *report.Testsuite, tc *report.Testcase) {
+	if r == nil {
+		suite.AddTestcase(tc)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	suite.AddTestcase(tc)
+}
+
+// end stops tracking the test name.
+func (r *runTracker) end(name string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.running, name)
+}
+
+// abort adds a failure describing reason and the phase of the test to the results of each running test which were not
+// reported yet and returns a description of what was running.  Results of tests are no longer added afterwards.
+func (r *runTracker) abort(reason string) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.running) == 0 {
+		return []string{fmt.Sprintf("%s during %s", reason, r.phase)}
+	}
+
+	names := make([]string, 0, len(r.running))
+	for name := range r.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	running := []string{}
+	for _, name := range names {
+		test := r.running[name]
+		message := fmt.Sprintf("%s: test %s was aborted in %s", reason, name, test.phase)
+		if !test.reported {
+			test.tc.Failure = report.NewFailure(message, nil)
+			test.suite.AddTestcase(test.tc)
+		}
+		running = append(running, message)
+	}
+	r.running = map[string]*trackedTest{}
+	r.aborted = true
+	return running
+}
+
+// startTimeout calls abort with reason after timeout seconds unless the returned timer is stopped.  It returns nil if
+// timeout is not positive.
+func (h *Harness) startTimeout(timeout int, reason string) *time.Timer {
+	if timeout <= 0 {
+		return nil
+	}
+	return time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+		h.abort(reason)
+	})
+}
+
+// countFailure counts a failed test and aborts the run once the maximum of failed tests of the test suite is reached.
+func (h *Harness) countFailure() {
+	if h.TestSuite.MaxFailures <= 0 {
+		return
+	}
+	if failures := int(atomic.AddInt32(&h.failures, 1)); failures >= h.TestSuite.MaxFailures {
+		h.abort(fmt.Sprintf("%d tests failed, reaching the maximum of failures", failures))
+	}
+}
+
+// abort fails the running tests with reason and cancels the context of the run, so that the tests which did not start
+// yet are skipped and the running steps stop.  It may be called from any goroutine, what was running is reported by
+// reportAbort once the tests have returned, so that their cleanups still delete their namespaces.
+func (h *Harness) abort(reason string) {
+	h.abortOnce.Do(func() {
+		messages := h.tracker.abort(reason)
+		h.abortLock.Lock()
+		h.abortReason = reason
+		h.abortMessages = messages
+		h.abortLock.Unlock()
+		if h.cancel != nil {
+			h.cancel(errors.New(reason))
+		}
+	})
+}
+
+// reportAbort logs what was running when the run was aborted and fails the harness, it does nothing if the run was not
+// aborted or the abort was already reported.  It must be called from the goroutine of h.T.
+func (h *Harness) reportAbort() {
+	h.abortLock.Lock()
+	defer h.abortLock.Unlock()
+	if h.abortReason == "" || h.abortReported {
+		return
+	}
+	h.abortReported = true
+	for _, message := range h.abortMessages {
+		h.T.Log(message)
+	}
+	h.report.SetFailure(h.abortReason)
+	h.T.Error(h.abortReason)
+}
+
+// runContext returns the context of the run, it is cancelled with the reason of the abort when the run is aborted.
+func (h *Harness) runContext() context.Context {
+	if h.ctx == nil {
+		return context.Background()
+	}
+	return h.ctx
+}
+
+// abortErr returns the reason of the abort if the run was aborted.
+func abortErr(ctx context.Context) error {
+	if ctx == nil || ctx.Err() == nil {
+		return nil
+	}
+	return fmt.Errorf("the run was aborted: %w", context.Cause(ctx))
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestRunTrackerAbort(t *testing.T) {
+	tracker := newRunTracker()
+	assert.Equal(t, []string{"suite timeout during setup"}, tracker.abort("suite timeout"))
+
+	tracker.setPhase("running tests")
+	suite := report.NewSuite("e2e")
+	slow, fast, done, cleanup := report.NewCase("slow"), report.NewCase("fast"), report.NewCase("done"), report.NewCase("cleanup")
+	tracker.start("e2e/slow", suite, slow)
+	tracker.start("e2e/fast", suite, fast)
+	tracker.start("e2e/done", suite, done)
+	tracker.progress("e2e/slow")("step 2-install")
+	tracker.addTestcase("e2e/done", suite, done)
+	tracker.end("e2e/done")
+	// tests in their cleanups are listed, but their results were already reported
+	tracker.start("e2e/cleanup", suite, cleanup)
+	tracker.addTestcase("e2e/cleanup", suite, cleanup)
+	tracker.progress("e2e/cleanup")("cleanup")
+	// progress of tests which are not tracked is ignored
+	tracker.progress("e2e/done")("cleanup")
+
+	assert.Equal(t, []string{
+		"suite timeout: test e2e/cleanup was aborted in cleanup",
+		"suite timeout: test e2e/fast was aborted in starting",
+		"suite timeout: test e2e/slow was aborted in step 2-install",
+	}, tracker.abort("suite timeout"))
+	assert.Equal(t, []*report.Testcase{done, cleanup, fast, slow}, suite.Testcase)
+	assert.Equal(t, "suite timeout: test e2e/slow was aborted in step 2-install", slow.Failure.Message)
+	assert.Nil(t, done.Failure)
+	assert.Nil(t, cleanup.Failure)
+
+	// results of aborted tests are not added twice
+	tracker.addTestcase("e2e/slow", suite, slow)
+	assert.Len(t, suite.Testcase, 4)
+
+	// aborted tests are no longer tracked
+	assert.Equal(t, []string{"suite timeout during running tests"}, tracker.abort("suite timeout"))
+}
+
+func TestNilRunTracker(t *testing.T) {
+	var tracker *runTracker
+	tracker.setPhase("cleanup")
+	tracker.start("e2e/test", report.NewSuite("e2e"), report.NewCase("test"))
+	tracker.end("e2e/test")
+	suite := report.NewSuite("e2e")
+	tracker.addTestcase("e2e/test", suite, report.NewCase("test"))
+	assert.Len(t, suite.Testcase, 1)
+	assert.Nil(t, tracker.progress("e2e/test"))
+}
+
+func TestCountFailure(t *testing.T) {
+	tracker := newRunTracker()
+	suite := report.NewSuite("e2e")
+	tracker.start("e2e/running", suite, report.NewCase("running"))
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	h := &Harness{
+		T:         t,
+		TestSuite: harness.TestSuite{MaxFailures: 2},
+		report:    report.NewSuiteCollection("e2e"),
+		tracker:   tracker,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	h.countFailure()
+	assert.NoError(t, abortErr(h.runContext()))
+
+	h.countFailure()
+	assert.EqualError(t, abortErr(h.runContext()), "the run was aborted: 2 tests failed, reaching the maximum of failures")
+	require.Len(t, suite.Testcase, 1)
+	assert.Equal(t, "2 tests failed, reaching the maximum of failures: test e2e/running was aborted in starting", suite.Testcase[0].Failure.Message)
+	// the abort is reported on the goroutine of the test
+	assert.Nil(t, h.report.Failure)
+
+	// the run is aborted once
+	h.countFailure()
+	assert.EqualError(t, context.Cause(h.runContext()), "2 tests failed, reaching the maximum of failures")
+	assert.Equal(t, []string{"2 tests failed, reaching the maximum of failures: test e2e/running was aborted in starting"}, h.abortMessages)
+
+	unlimited := &Harness{T: t, report: report.NewSuiteCollection("e2e"), tracker: newRunTracker()}
+	unlimited.countFailure()
+	assert.NoError(t, abortErr(unlimited.runContext()))
+}
+
+func TestReportAbort(t *testing.T) {
+	h := &Harness{T: &testing.T{}, report: report.NewSuiteCollection("e2e"), tracker: newRunTracker()}
+	h.reportAbort()
+	assert.Nil(t, h.report.Failure)
+	assert.False(t, h.T.Failed())
+
+	h.abort("test suite exceeded the suite timeout of 10s")
+	h.reportAbort()
+	h.reportAbort()
+	require.NotNil(t, h.report.Failure)
+	assert.Equal(t, "test suite exceeded the suite timeout of 10s", h.report.Failure.Message)
+	assert.True(t, h.T.Failed())
+}
+
+func TestAbortedStep(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(100*time.Millisecond, func() { cancel(errors.New("test suite exceeded the suite timeout of 10s")) })
+
+	step := &Step{
+		Name:    "missing",
+		Timeout: 30,
+		Asserts: []client.Object{&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "missing"},
+		}},
+		Logger:          testutils.NewTestLogger(t, "missing"),
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+		RunContext:      ctx,
+	}
+
+	start := time.Now()
+	errs := step.Run(t, testNamespace)
+	assert.Less(t, time.Since(start), 5*time.Second, "the asserts are no longer retried once the run is aborted")
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "the run was aborted: test suite exceeded the suite timeout of 10s")
+}
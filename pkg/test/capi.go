@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/env"
+)
+
+const (
+	// capiAPIVersion is the API version of the Cluster API kinds.
+	capiAPIVersion = "cluster.x-k8s.io/v1beta1"
+	// capiClusterNameLabel is the label of the Machines of a Cluster with its name.
+	capiClusterNameLabel = "cluster.x-k8s.io/cluster-name"
+	// capiKubeconfigKey is the key of the kubeconfig in the kubeconfig Secret of a Cluster.
+	capiKubeconfigKey = "value"
+)
+
+// capiInterval is the interval at which the Clusters and Machines of workload clusters are checked.
+var capiInterval = time.Second
+
+// FetchWorkloadClusters waits for the Cluster API workload clusters of the step to be provisioned and writes their
+// kubeconfigs, the files are deleted when the test ends.
+func (s *Step) FetchWorkloadClusters(test *testing.T, namespace string) error {
+	if s.Step == nil || len(s.Step.WorkloadClusters) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return err
+	}
+
+	for _, cluster := range s.Step.WorkloadClusters {
+		path := s.workloadKubeconfig(cluster)
+		if err := s.fetchWorkloadCluster(cl, cluster, namespace, path); err != nil {
+			return err
+		}
+		test.Cleanup(func() {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				test.Errorf("deleting the kubeconfig of a workload cluster: %v", err)
+			}
+		})
+	}
+	return nil
+}
+
+// workloadKubeconfig returns the path the kubeconfig of the workload cluster is written to, it is the kubeconfig of
+// the steps running against the cluster.
+func (s *Step) workloadKubeconfig(cluster harness.WorkloadCluster) string {
+	return cleanPath(env.Expand(cluster.Kubeconfig), s.Dir)
+}
+
+// fetchWorkloadCluster waits until the Cluster is provisioned and its Machines are running, then writes the kubeconfig
+// of its Secret to path.
+func (s *Step) fetchWorkloadCluster(cl client.Client, cluster harness.WorkloadCluster, namespace, path string) error {
+	if cluster.Namespace != "" {
+		namespace = cluster.Namespace
+	}
+	id := fmt.Sprintf("Cluster %s/%s", namespace, cluster.Name)
+
+	timeout := cluster.Timeout
+	if timeout == 0 {
+		timeout = s.GetTimeout()
+	}
+	ctx := context.TODO()
+
+	message := "not checked"
+	err := wait.PollImmediate(capiInterval, time.Duration(timeout)*time.Second, func() (bool, error) {
+		var done bool
+		var err error
+		done, message, err = workloadClusterReady(ctx, cl, cluster, namespace)
+		return done, err
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("timed out waiting for %s: %s", id, message)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", id, err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: cluster.Name + "-kubeconfig"}, secret); err != nil {
+		return fmt.Errorf("getting the kubeconfig of %s: %w", id, err)
+	}
+	kubeconfig, ok := secret.Data[capiKubeconfigKey]
+	if !ok {
+		return fmt.Errorf("the kubeconfig Secret of %s has no %q key", id, capiKubeconfigKey)
+	}
+	if err := os.WriteFile(path, kubeconfig, 0600); err != nil {
+		return fmt.Errorf("writing the kubeconfig of %s: %w", id, err)
+	}
+	s.Logger.Logf("wrote the kubeconfig of %s to %s", id, path)
+	return nil
+}
+
+// workloadClusterReady returns whether the Cluster is provisioned with the expected number of running Machines, and
+// what it waits for otherwise.
+func workloadClusterReady(ctx context.Context, cl client.Client, cluster harness.WorkloadCluster, namespace string) (bool, string, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(capiAPIVersion)
+	obj.SetKind("Cluster")
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: cluster.Name}, obj); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, "it does not exist", nil
+		}
+		return false, "", err
+	}
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if phase != "Provisioned" {
+		if phase == "" {
+			phase = "unknown"
+		}
+		return false, fmt.Sprintf("it is not Provisioned, its phase is %s", phase), nil
+	}
+	if cluster.Machines == 0 {
+		return true, "", nil
+	}
+
+	machines := &unstructured.UnstructuredList{}
+	machines.SetAPIVersion(capiAPIVersion)
+	machines.SetKind("MachineList")
+	if err := cl.List(ctx, machines, client.InNamespace(namespace), client.MatchingLabels{capiClusterNameLabel: cluster.Name}); err != nil {
+		return false, "", err
+	}
+	running := 0
+	pending := []string{}
+	for _, machine := range machines.Items {
+		phase, _, _ := unstructured.NestedString(machine.Object, "status", "phase")
+		if phase == "Running" {
+			running++
+			continue
+		}
+		if phase == "" {
+			phase = "unknown"
+		}
+		pending = append(pending, fmt.Sprintf("%s is %s", machine.GetName(), phase))
+	}
+	if running >= cluster.Machines {
+		return true, "", nil
+	}
+	sort.Strings(pending)
+	message := fmt.Sprintf("%d of %d Machines are Running", running, cluster.Machines)
+	if len(pending) > 0 {
+		message += ": " + strings.Join(pending, ", ")
+	}
+	return false, message, nil
+}
+
+// useWorkloadCluster creates the client of the workload cluster the step runs against and the test namespace in the
+// cluster.
+func (t *Case) useWorkloadCluster(test *testing.T, clients map[string]client.Client, step *Step, ns *namespace) error {
+	cl, err := newClient(step.Kubeconfig)(false)
+	if err != nil {
+		return fmt.Errorf("workload cluster %s: %w", step.Kubeconfig, err)
+	}
+	t.progress("creating namespace " + ns.Name + " in workload cluster " + step.Kubeconfig)
+	if err := t.CreateNamespace(test, cl, ns); err != nil {
+		return err
+	}
+	clients[step.Kubeconfig] = cl
+	return nil
+}
+
+// workloadKubeconfigs returns the paths of the kubeconfigs of the workload clusters of the steps, they are written
+// while the test runs and the clients of the steps using them are created once the steps run.
+func workloadKubeconfigs(steps []*Step) map[string]bool {
+	paths := map[string]bool{}
+	for _, step := range steps {
+		if step.Step == nil {
+			continue
+		}
+		for _, cluster := range step.Step.WorkloadClusters {
+			paths[step.workloadKubeconfig(cluster)] = true
+		}
+	}
+	return paths
+}
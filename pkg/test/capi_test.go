@@ -0,0 +1,128 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func capiObject(kind, name, phase string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": capiAPIVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": testNamespace},
+		"status":     map[string]interface{}{"phase": phase},
+	}}
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestFetchWorkloadClusters(t *testing.T) {
+	capiInterval = 10 * time.Millisecond
+	defer func() { capiInterval = time.Second }()
+
+	machine := func(cluster, name, phase string) client.Object {
+		return capiObject("Machine", name, phase, map[string]string{capiClusterNameLabel: cluster})
+	}
+	secret := func(key string) client.Object {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "workload-kubeconfig", Namespace: testNamespace},
+			Data:       map[string][]byte{key: []byte("apiVersion: v1\nkind: Config\n")},
+		}
+	}
+
+	for _, test := range []struct {
+		name     string
+		machines int
+		objs     []client.Object
+		err      string
+	}{
+		{
+			name: "provisioned",
+			objs: []client.Object{capiObject("Cluster", "workload", "Provisioned", nil), secret(capiKubeconfigKey)},
+		},
+		{
+			name:     "machines running",
+			machines: 2,
+			objs: []client.Object{capiObject("Cluster", "workload", "Provisioned", nil), secret(capiKubeconfigKey),
+				machine("workload", "workload-md-0", "Running"), machine("workload", "workload-md-1", "Running")},
+		},
+		{
+			name: "provisioning",
+			objs: []client.Object{capiObject("Cluster", "workload", "Provisioning", nil), secret(capiKubeconfigKey)},
+			err:  "timed out waiting for Cluster " + testNamespace + "/workload: it is not Provisioned, its phase is Provisioning",
+		},
+		{
+			name: "missing cluster",
+			err:  "timed out waiting for Cluster " + testNamespace + "/workload: it does not exist",
+		},
+		{
+			name:     "machines not running",
+			machines: 2,
+			objs: []client.Object{capiObject("Cluster", "workload", "Provisioned", nil), secret(capiKubeconfigKey),
+				machine("workload", "workload-md-0", "Running"), machine("workload", "workload-md-1", "Provisioning"),
+				// the Machines of other clusters are not counted
+				machine("other", "other-md-0", "Running")},
+			err: "1 of 2 Machines are Running: workload-md-1 is Provisioning",
+		},
+		{
+			name: "missing key",
+			objs: []client.Object{capiObject("Cluster", "workload", "Provisioned", nil), secret("kubeconfig")},
+			err:  `the kubeconfig Secret of Cluster ` + testNamespace + `/workload has no "value" key`,
+		},
+	} {
+		test := test
+		dir := t.TempDir()
+		path := filepath.Join(dir, "workload.kubeconfig")
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(test.objs...).Build()
+			step := &Step{
+				Dir: dir,
+				Step: &harness.TestStep{WorkloadClusters: []harness.WorkloadCluster{
+					{Name: "workload", Kubeconfig: "workload.kubeconfig", Machines: test.machines, Timeout: 1},
+				}},
+				Logger: testutils.NewTestLogger(t, test.name),
+				Client: func(bool) (client.Client, error) { return cl, nil },
+			}
+
+			err := step.FetchWorkloadClusters(t, testNamespace)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				assert.NoFileExists(t, path)
+				return
+			}
+			require.NoError(t, err)
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "apiVersion: v1\nkind: Config\n", string(data))
+		})
+
+		assert.NoFileExists(t, path, "the kubeconfig is deleted when the test ends")
+	}
+}
+
+func TestWorkloadKubeconfigs(t *testing.T) {
+	steps := []*Step{
+		{Dir: "/tests/capi"},
+		{Dir: "/tests/capi", Step: &harness.TestStep{WorkloadClusters: []harness.WorkloadCluster{
+			{Name: "a", Kubeconfig: "a.kubeconfig"},
+			{Name: "b", Kubeconfig: "/tmp/b.kubeconfig"},
+		}}},
+	}
+
+	assert.Equal(t, map[string]bool{"/tests/capi/a.kubeconfig": true, "/tmp/b.kubeconfig": true}, workloadKubeconfigs(steps))
+}
@@ -3,6 +3,8 @@ package test
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -11,7 +13,6 @@ import (
 	"testing"
 	"time"
 
-	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/thoas/go-funk"
 	corev1 "k8s.io/api/core/v1"
 	eventsv1 "k8s.io/api/events/v1"
@@ -23,14 +24,16 @@ import (
 	"k8s.io/client-go/discovery"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
 	"github.com/kudobuilder/kuttl/pkg/report"
 	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
 )
 
 // testStepRegex contains one capturing group to determine the index of a step file.
-var testStepRegex = regexp.MustCompile(`^(\d+)-(?:[^\.]+)(?:\.yaml)?$`)
+var testStepRegex = regexp.MustCompile(`^(\d+)-(?:[^\.]+)(?:\.(?:yaml|json|jsonnet))?$`)
 
 // Case contains all of the test steps and the Kubernetes client and other global configuration
 // for a test.
@@ -42,13 +45,62 @@ type Case struct {
 	Timeout            int
 	PreferredNamespace string
 	RunLabels          labels.Set
+	// Seed is the seed of the run, if set names of auto-created namespaces are derived from it and the test name.
+	Seed int64
+	// FromStep is the index of the first step which is run, the steps with a lower index are skipped.
+	FromStep int
+	// Stage is the stage the test runs in, only the steps and asserts of the stage are run if it is set.
+	Stage string
+	// ReportColors keeps the ANSI escape sequences of the command output attached to the report of the test.
+	ReportColors bool
+	// Context is the test context of the suite of the test, ex. its cluster and artifacts directory.  The test and its
+	// steps add their own fields to it.
+	Context TestContext
+	// JsonnetVars are the external variables of the Jsonnet files of the test suite, ex. its jsonnetVars and secrets.
+	JsonnetVars map[string]string
+	// DuplicateObjects is what happens when an object is applied more than once by the files of a step.
+	DuplicateObjects harness.DuplicateObjectPolicy
+	// Overlays are applied to the objects of the steps when they are loaded, before those of the steps.
+	Overlays []harness.Overlay
+	// NamespaceQuota is created in the auto-created namespaces of the test, unless a step opts out of it.
+	NamespaceQuota *harness.NamespaceQuota
+	// EventJournal records the watch events of the objects in the namespace of the test for its commands, it may be nil.
+	EventJournal *harness.EventJournal
+	// Suppressions are the known differences ignored by the asserts of the steps, they may be nil.
+	Suppressions *Suppressions
+	// AllowHelperPodTraffic, FailOnDeprecatedAPIs, Hermetic, ProbeImage, HelperPods, StepHandlers, SafeMode,
+	// DetectDrift and IPFamily are passed to the steps of the test.
+	AllowHelperPodTraffic bool
+	FailOnDeprecatedAPIs  bool
+	Hermetic              bool
+	ProbeImage            string
+	HelperPods            *harness.HelperPodScheduling
+	StepHandlers          map[string]StepHandler
+	SafeMode              *harness.SafeMode
+	DetectDrift           bool
+	IPFamily              string
 
 	Client          func(forceNew bool) (client.Client, error)
 	DiscoveryClient func() (discovery.DiscoveryInterface, error)
+	// Config returns the cluster configuration, it is needed to derive step identities and record API server warnings.
+	Config func() (*rest.Config, error)
 
 	Logger testutils.Logger
 	// Suppress is used to suppress logs
 	Suppress []string
+	// Progress is called with the phase of the test (ex. the step) whenever it changes, it may be nil.
+	Progress func(phase string)
+	// DeferCleanup is called with the deletion of the auto-created namespaces of the test when it ends, to run it in
+	// the background while the other tests run.  If it is nil, the test waits for its namespaces to be deleted.
+	DeferCleanup func(description string, cleanup func() error)
+	// RunContext is cancelled when the run is aborted (ex. its suite timeout expired), the test then fails before its
+	// next step and its running step stops.  It may be nil.
+	RunContext context.Context
+
+	// retries are the retries of the steps of the test case after they were released.
+	retries int
+	// timings collect the timings of the steps of the test case, they may be nil.
+	timings *timingTable
 }
 
 type namespace struct {
@@ -64,7 +116,11 @@ func (t *Case) DeleteNamespace(cl client.Client, ns *namespace) error {
 	}
 
 	t.Logger.Log("Deleting namespace:", ns.Name)
+	return t.deleteNamespace(cl, ns.Name)
+}
 
+// deleteNamespace deletes the namespace and waits for it to be gone, for up to the timeout of the test.
+func (t *Case) deleteNamespace(cl client.Client, name string) error {
 	ctx := context.Background()
 	if t.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -74,7 +130,7 @@ func (t *Case) DeleteNamespace(cl client.Client, ns *namespace) error {
 
 	nsObj := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: ns.Name,
+			Name: name,
 		},
 		TypeMeta: metav1.TypeMeta{
 			Kind: "Namespace",
@@ -85,14 +141,28 @@ func (t *Case) DeleteNamespace(cl client.Client, ns *namespace) error {
 		return err
 	}
 
-	return wait.PollImmediateUntilWithContext(ctx, 100*time.Millisecond, func(ctx context.Context) (done bool, err error) {
+	// the deletion is not waited for once the run is aborted, so that a namespace stuck terminating does not keep
+	// the harness from cleaning up
+	if t.RunContext != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(t.RunContext, cancel)
+		defer stop()
+	}
+
+	err := wait.PollImmediateUntilWithContext(ctx, 100*time.Millisecond, func(ctx context.Context) (done bool, err error) {
 		actual := &corev1.Namespace{}
-		err = cl.Get(ctx, client.ObjectKey{Name: ns.Name}, actual)
+		err = cl.Get(ctx, client.ObjectKey{Name: name}, actual)
 		if k8serrors.IsNotFound(err) {
 			return true, nil
 		}
 		return false, err
 	})
+	if abortErr := abortErr(t.RunContext); err != nil && abortErr != nil {
+		return fmt.Errorf("namespace %s is still being deleted: %w", name, abortErr)
+	}
+	return err
 }
 
 // CreateNamespace creates a namespace in Kubernetes to use for a test.
@@ -112,20 +182,33 @@ func (t *Case) CreateNamespace(test *testing.T, cl client.Client, ns *namespace)
 
 	if !t.SkipDelete {
 		test.Cleanup(func() {
+			// the test does not wait for its namespace to be deleted if it can be deleted in the background, its
+			// logger must not be used once the test has finished
+			if t.DeferCleanup != nil {
+				t.Logger.Log("Deleting namespace in the background:", ns.Name)
+				t.DeferCleanup("deleting namespace "+ns.Name, func() error {
+					return t.deleteNamespace(cl, ns.Name)
+				})
+				return
+			}
 			if err := t.DeleteNamespace(cl, ns); err != nil {
 				test.Error(err)
 			}
 		})
 	}
 
-	return cl.Create(ctx, &corev1.Namespace{
+	if err := cl.Create(ctx, &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: ns.Name,
 		},
 		TypeMeta: metav1.TypeMeta{
 			Kind: "Namespace",
 		},
-	})
+	}); err != nil {
+		return err
+	}
+
+	return t.createNamespaceQuota(ctx, cl, ns.Name)
 }
 
 // NamespaceExists gets namespace and returns true if it exists
@@ -315,6 +398,15 @@ func shortString(obj *corev1.ObjectReference) string {
 
 // Run runs a test case including all of its steps.
 func (t *Case) Run(test *testing.T, tc *report.Testcase) {
+	t.reportMetadata(test, tc)
+	// the output of the commands is attached once the cleanup commands ran as well
+	capture := &testutils.CommandCapture{KeepColors: t.ReportColors}
+	test.Cleanup(func() {
+		tc.SystemOut, tc.SystemErr = capture.Stdout(), capture.Stderr()
+		if err := capture.Close(); err != nil {
+			t.Logger.Logf("removing the captured output of the commands: %v", err)
+		}
+	})
 	ns := t.determineNamespace()
 
 	cl, err := t.Client(false)
@@ -323,10 +415,22 @@ func (t *Case) Run(test *testing.T, tc *report.Testcase) {
 		test.Fatal(err)
 	}
 
+	reason, err := t.unmetPreconditions()
+	if err != nil {
+		tc.Failure = report.NewFailure(err.Error(), nil)
+		test.Fatal(err)
+	}
+	if reason != "" {
+		t.Logger.Log("skipping test, preconditions not met:", reason)
+		tc.Skipped = &report.Skipped{Message: reason}
+		return
+	}
+
 	clients := map[string]client.Client{"": cl}
+	workloads := workloadKubeconfigs(t.Steps)
 
 	for _, testStep := range t.Steps {
-		if clients[testStep.Kubeconfig] != nil {
+		if clients[testStep.Kubeconfig] != nil || workloads[testStep.Kubeconfig] {
 			continue
 		}
 
@@ -339,6 +443,7 @@ func (t *Case) Run(test *testing.T, tc *report.Testcase) {
 		clients[testStep.Kubeconfig] = cl
 	}
 
+	t.progress("creating namespace " + ns.Name)
 	for _, c := range clients {
 		if err := t.CreateNamespace(test, c, ns); err != nil {
 			tc.Failure = report.NewFailure(err.Error(), nil)
@@ -346,7 +451,73 @@ func (t *Case) Run(test *testing.T, tc *report.Testcase) {
 		}
 	}
 
+	commandEnv := map[string]string{}
+	kubeconfig, err := t.writeKubeconfig(test)
+	if err != nil {
+		tc.Failure = report.NewFailure(err.Error(), nil)
+		test.Fatal(err)
+	}
+	if kubeconfig != "" {
+		commandEnv["KUBECONFIG"] = kubeconfig
+	}
+
+	var journal *eventJournal
+	if t.EventJournal != nil {
+		if journal, err = t.startEventJournal(test, cl, ns.Name); err != nil {
+			tc.Failure = report.NewFailure(err.Error(), nil)
+			test.Fatal(err)
+		}
+		commandEnv[EventJournalEnv] = journal.path
+	}
+
+	var applied []client.Object
 	for _, testStep := range t.Steps {
+		// the objects of the steps of other stages are never applied
+		if t.Stage != "" && testStep.Step != nil && !inStage(testStep.Step.Stages, t.Stage) {
+			t.Logger.Logf("skipping step %s, it is not in stage %s", testStep.String(), t.Stage)
+			continue
+		}
+
+		testStep.PreviouslyApplied = applied
+		applied = append(applied, testStep.Apply...)
+
+		if testStep.Index < t.FromStep {
+			t.Logger.Logf("skipping step %s, the test runs from step %d", testStep.String(), t.FromStep)
+			continue
+		}
+
+		if err := abortErr(t.RunContext); err != nil {
+			caseErr := fmt.Errorf("failed before step %s", testStep.String())
+			tc.Failure = report.NewFailure(caseErr.Error(), []error{err})
+
+			test.Error(caseErr)
+			test.Error(err)
+			break
+		}
+
+		// the kubeconfigs of workload clusters are written by earlier steps of the test
+		if clients[testStep.Kubeconfig] == nil {
+			if err := t.useWorkloadCluster(test, clients, testStep, ns); err != nil {
+				caseErr := fmt.Errorf("failed in step %s", testStep.String())
+				tc.Failure = report.NewFailure(caseErr.Error(), []error{err})
+
+				test.Error(caseErr)
+				test.Error(err)
+				break
+			}
+		}
+
+		if testStep.Step != nil && testStep.Step.Identity != nil {
+			if err := t.useIdentity(test, testStep, ns.Name); err != nil {
+				caseErr := fmt.Errorf("failed in step %s", testStep.String())
+				tc.Failure = report.NewFailure(caseErr.Error(), []error{err})
+
+				test.Error(caseErr)
+				test.Error(err)
+				break
+			}
+		}
+
 		testStep.Client = t.Client
 		if testStep.Kubeconfig != "" {
 			testStep.Client = newClient(testStep.Kubeconfig)
@@ -355,11 +526,42 @@ func (t *Case) Run(test *testing.T, tc *report.Testcase) {
 		if testStep.Kubeconfig != "" {
 			testStep.DiscoveryClient = newDiscoveryClient(testStep.Kubeconfig)
 		}
+		testStep.Config = t.Config
+		if testStep.Kubeconfig != "" {
+			testStep.Config = newConfig(testStep.Kubeconfig)
+		}
+		if t.timings != nil {
+			testStep.timings = &stepTimings{}
+			testStep.Client = testStep.timings.countCalls(testStep.Client)
+		}
 		testStep.Logger = t.Logger.WithPrefix(testStep.String())
+		testStep.Stage = t.Stage
+		testStep.Capture = capture
+		testStep.Context = t.stepContext(testStep.Index, ns.Name)
+		testStep.CommandEnv = commandEnv
+		testStep.RunContext = t.RunContext
+		if ns.AutoCreated {
+			testStep.SafeNamespace = ns.Name
+		}
+		if journal != nil {
+			journal.watchStep(t.EventJournal, testStep)
+		}
 		tc.Assertions += len(testStep.Asserts)
 		tc.Assertions += len(testStep.Errors)
-
-		if errs := testStep.Run(test, ns.Name); len(errs) > 0 {
+		tc.Assertions += len(testStep.Sequences)
+
+		t.progress("step " + testStep.String())
+		testStep.RegisterCleanup(test, ns.Name)
+		errs := testStep.Run(test, ns.Name)
+		t.timings.add(t.Context.Suite, t.Name, testStep)
+		// the test ends with a step expected to fail, whether it fails or not
+		if testStep.expectedFailure() != nil {
+			if err := t.checkExpectedFailure(tc, testStep, errs); err != nil {
+				test.Error(err)
+			}
+			break
+		}
+		if len(errs) > 0 {
 			caseErr := fmt.Errorf("failed in step %s", testStep.String())
 			tc.Failure = report.NewFailure(caseErr.Error(), errs)
 
@@ -371,11 +573,38 @@ func (t *Case) Run(test *testing.T, tc *report.Testcase) {
 		}
 	}
 
+	t.progress("collecting events")
 	if funk.Contains(t.Suppress, "events") {
 		t.Logger.Logf("skipping kubernetes event logging")
 	} else {
 		t.CollectEvents(ns.Name)
 	}
+	// cleanups run last in first out, so this runs before the cleanups registered by the steps and namespaces
+	test.Cleanup(func() {
+		t.progress("cleanup")
+	})
+}
+
+// progress reports the phase of the test if Progress is set.
+func (t *Case) progress(phase string) {
+	if t.Progress != nil {
+		t.Progress(phase)
+	}
+}
+
+// Retries returns the total number of times the asserts of the test steps were re-checked.
+func (t *Case) Retries() int {
+	retries := t.retries
+	for _, step := range t.Steps {
+		retries += step.retries
+	}
+	return retries
+}
+
+// releaseSteps drops the loaded steps of the test case once it has run, keeping only the number of retries.
+func (t *Case) releaseSteps() {
+	t.retries = t.Retries()
+	t.Steps = nil
 }
 
 func (t *Case) determineNamespace() *namespace {
@@ -383,54 +612,102 @@ func (t *Case) determineNamespace() *namespace {
 		Name:        t.PreferredNamespace,
 		AutoCreated: false,
 	}
-	// no preferred ns, means we auto-create with petnames
+	// no preferred ns, means we auto-create with a random name
 	if t.PreferredNamespace == "" {
-		ns.Name = fmt.Sprintf("kuttl-test-%s", petname.Generate(2, "-"))
+		ns.Name = fmt.Sprintf("kuttl-test-%s", t.namespaceSuffix())
 		ns.AutoCreated = true
 	}
 	// if we have a preferred namespace, we do NOT auto-create
 	return ns
 }
 
+// namespaceSuffixChars are the characters of the random names of namespaces, they have no vowels so that the names
+// do not spell words, like the names generated by the API server.
+const namespaceSuffixChars = "bcdfghjklmnpqrstvwxz2456789"
+
+// namespaceSuffixLength is the length of the random names of namespaces.
+const namespaceSuffixLength = 10
+
+// namespaceSuffix returns a random name for the namespace of the test.  If the run has a seed, the name only depends
+// on the seed and the directory of the test, so that it is the same regardless of the order in which tests run and
+// tests of the same name in different test directories do not share a namespace.
+func (t *Case) namespaceSuffix() string {
+	seed := time.Now().UnixNano()
+	if t.Seed != 0 {
+		path := t.Dir
+		if path == "" {
+			path = t.Name
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(path))
+		seed = t.Seed ^ int64(h.Sum64())
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	suffix := make([]byte, namespaceSuffixLength)
+	for i := range suffix {
+		suffix[i] = namespaceSuffixChars[r.Intn(len(namespaceSuffixChars))]
+	}
+	return string(suffix)
+}
+
 // CollectTestStepFiles collects a map of test steps and their associated files
-// from a directory.
+// from a directory.  The step files are those listed by the TestCase of the directory if it has one, otherwise those of
+// the directory and of its steps subdirectory named after their index or whose TestFile sets their step.
 func (t *Case) CollectTestStepFiles() (map[int64][]string, error) {
-	testStepFiles := map[int64][]string{}
-
-	files, err := os.ReadDir(t.Dir)
+	testCase, err := t.testCaseListing()
 	if err != nil {
 		return nil, err
 	}
+	if testCase != nil {
+		return t.listedStepFiles(testCase)
+	}
+
+	testStepFiles := map[int64][]string{}
+	dirs := []string{t.Dir}
+	if info, err := os.Stat(filepath.Join(t.Dir, stepsDir)); err == nil && info.IsDir() {
+		dirs = append(dirs, filepath.Join(t.Dir, stepsDir))
+	}
 
-	for _, file := range files {
-		index, err := getIndexFromFile(file.Name())
+	for _, dir := range dirs {
+		files, err := os.ReadDir(dir)
 		if err != nil {
 			return nil, err
 		}
-		if index < 0 {
-			t.Logger.Log("Ignoring", file.Name(), "as it does not match file name regexp:", testStepRegex.String())
-			continue
-		}
-
-		if testStepFiles[index] == nil {
-			testStepFiles[index] = []string{}
-		}
 
-		testStepPath := filepath.Join(t.Dir, file.Name())
+		for _, file := range files {
+			if dir == t.Dir && file.Name() == stepsDir && file.IsDir() {
+				continue
+			}
+			testStepPath := filepath.Join(dir, file.Name())
 
-		if file.IsDir() {
-			testStepDir, err := os.ReadDir(testStepPath)
+			index, err := getIndexFromFile(file.Name())
 			if err != nil {
 				return nil, err
 			}
+			if index < 0 && !file.IsDir() {
+				if index, err = frontMatterIndex(testStepPath); err != nil {
+					return nil, err
+				}
+			}
+			if index < 0 {
+				t.Logger.Log("Ignoring", file.Name(), "as it does not match file name regexp:", testStepRegex.String())
+				continue
+			}
+
+			if testStepFiles[index] == nil {
+				testStepFiles[index] = []string{}
+			}
 
-			for _, testStepFile := range testStepDir {
-				testStepFiles[index] = append(testStepFiles[index], filepath.Join(
-					testStepPath, testStepFile.Name(),
-				))
+			if file.IsDir() {
+				stepDirFiles, err := dirFiles(testStepPath)
+				if err != nil {
+					return nil, err
+				}
+				testStepFiles[index] = append(testStepFiles[index], stepDirFiles...)
+			} else {
+				testStepFiles[index] = append(testStepFiles[index], testStepPath)
 			}
-		} else {
-			testStepFiles[index] = append(testStepFiles[index], testStepPath)
 		}
 	}
 
@@ -457,6 +734,11 @@ func (t *Case) LoadTestSteps() error {
 	}
 
 	testSteps := []*Step{}
+	// the namespace of the test is only known before it runs if it is preferred or derived from the seed of the run
+	namespace := ""
+	if t.PreferredNamespace != "" || t.Seed != 0 {
+		namespace = t.determineNamespace().Name
+	}
 
 	for index, files := range testStepFiles {
 		testStep := &Step{
@@ -465,9 +747,22 @@ func (t *Case) LoadTestSteps() error {
 			SkipDelete:    t.SkipDelete,
 			Dir:           t.Dir,
 			TestRunLabels: t.RunLabels,
+			Context:       t.stepContext(int(index), namespace),
+			JsonnetVars:   t.JsonnetVars,
 			Asserts:       []client.Object{},
 			Apply:         []client.Object{},
 			Errors:        []client.Object{},
+
+			AllowHelperPodTraffic: t.AllowHelperPodTraffic,
+			FailOnDeprecatedAPIs:  t.FailOnDeprecatedAPIs,
+			Hermetic:              t.Hermetic,
+			ProbeImage:            t.ProbeImage,
+			HelperPods:            t.HelperPods,
+			StepHandlers:          t.StepHandlers,
+			Suppressions:          t.Suppressions,
+			SafeMode:              t.SafeMode,
+			DetectDrift:           t.DetectDrift,
+			IPFamily:              t.IPFamily,
 		}
 
 		for _, file := range files {
@@ -476,6 +771,20 @@ func (t *Case) LoadTestSteps() error {
 			}
 		}
 
+		if err := testStep.applyOverlays(t.Overlays); err != nil {
+			return fmt.Errorf("step %s: %w", testStep.String(), err)
+		}
+
+		replaced, err := testStep.resolveDuplicates(t.DuplicateObjects)
+		if err != nil {
+			return err
+		}
+		if t.Logger != nil {
+			for _, id := range replaced {
+				t.Logger.Logf("step %s: %s is replaced by a later definition", testStep.String(), id)
+			}
+		}
+
 		testSteps = append(testSteps, testStep)
 	}
 
@@ -487,6 +796,36 @@ func (t *Case) LoadTestSteps() error {
 	return nil
 }
 
+// stepContext returns the test context of a step of the test in the namespace.
+func (t *Case) stepContext(index int, namespace string) TestContext {
+	c := t.Context
+	c.Test = t.Name
+	c.Namespace = namespace
+	if c.Seed == 0 {
+		c.Seed = t.Seed
+	}
+	return c.forStep(index)
+}
+
+// writeKubeconfig writes the kubeconfig of the cluster into a temp folder of the test and returns its path, the
+// commands of tests running in parallel in the same working directory don't share a kubeconfig.  The path is empty if
+// the test has no cluster configuration.
+func (t *Case) writeKubeconfig(test *testing.T) (string, error) {
+	if t.Config == nil {
+		return "", nil
+	}
+	cfg, err := t.Config()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(test.TempDir(), "kubeconfig")
+	if err := writeKubeconfig(cfg, path); err != nil {
+		return "", fmt.Errorf("writing the kubeconfig of the test: %w", err)
+	}
+	return path, nil
+}
+
 func newClient(kubeconfig string) func(bool) (client.Client, error) {
 	return func(bool) (client.Client, error) {
 		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
@@ -500,6 +839,12 @@ func newClient(kubeconfig string) func(bool) (client.Client, error) {
 	}
 }
 
+func newConfig(kubeconfig string) func() (*rest.Config, error) {
+	return func() (*rest.Config, error) {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+}
+
 func newDiscoveryClient(kubeconfig string) func() (discovery.DiscoveryInterface, error) {
 	return func() (discovery.DiscoveryInterface, error) {
 		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
@@ -2,13 +2,18 @@ package test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
@@ -342,6 +347,7 @@ func TestLoadTestSteps(t *testing.T) {
 			assert.Equal(t, len(tt.testSteps), len(testStepsVal))
 			for index := range tt.testSteps {
 				tt.testSteps[index].Dir = tt.path
+				tt.testSteps[index].Context = TestContext{}.forStep(tt.testSteps[index].Index)
 				assert.Equal(t, tt.testSteps[index].Apply, testStepsVal[index].Apply, "apply objects need to match")
 				assert.Equal(t, tt.testSteps[index].Asserts, testStepsVal[index].Asserts, "assert objects need to match")
 				assert.Equal(t, tt.testSteps[index].Errors, testStepsVal[index].Errors, "error objects need to match")
@@ -390,6 +396,32 @@ func TestCollectTestStepFiles(t *testing.T) {
 				},
 			},
 		},
+		{
+			"test_data/steps-dir",
+			map[int64][]string{
+				int64(0): {
+					"test_data/steps-dir/steps/00-assert.yaml",
+					"test_data/steps-dir/steps/00-pod.yaml",
+				},
+				int64(1): {
+					"test_data/steps-dir/01-pod.yaml",
+					"test_data/steps-dir/check-pod.yaml",
+				},
+			},
+		},
+		{
+			"test_data/test-case-listing",
+			map[int64][]string{
+				int64(0): {
+					"test_data/test-case-listing/install.yaml",
+					"test_data/test-case-listing/check.yaml",
+				},
+				int64(1): {
+					"test_data/test-case-listing/upgrade/assert.yaml",
+					"test_data/test-case-listing/upgrade/pod.yaml",
+				},
+			},
+		},
 	} {
 		tt := tt
 
@@ -402,6 +434,76 @@ func TestCollectTestStepFiles(t *testing.T) {
 	}
 }
 
+func TestLoadTestStepsLayouts(t *testing.T) {
+	for _, tt := range []struct {
+		path    string
+		names   []string
+		applies []int
+		asserts []int
+	}{
+		{path: "test_data/steps-dir", names: []string{"pod", "pod"}, applies: []int{1, 1}, asserts: []int{1, 1}},
+		// the type of check.yaml is set by its TestFile
+		{path: "test_data/test-case-listing", names: []string{"install", "pod"}, applies: []int{1, 1}, asserts: []int{1, 1}},
+	} {
+		tt := tt
+
+		t.Run(tt.path, func(t *testing.T) {
+			test := &Case{Dir: tt.path, Logger: testutils.NewTestLogger(t, tt.path)}
+			require.NoError(t, test.LoadTestSteps())
+			require.Len(t, test.Steps, len(tt.names))
+			for i, step := range test.Steps {
+				assert.Equal(t, i, step.Index)
+				assert.Equal(t, tt.names[i], step.Name)
+				assert.Len(t, step.Apply, tt.applies[i], step.String())
+				assert.Len(t, step.Asserts, tt.asserts[i], step.String())
+			}
+		})
+	}
+}
+
+func TestCollectTestStepFilesErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "negative front matter step",
+			files: map[string]string{
+				"pod.yaml": "apiVersion: kuttl.dev/v1beta1\nkind: TestFile\nstep: -1\n",
+			},
+			err: "has a negative step -1",
+		},
+		{
+			name: "missing listed file",
+			files: map[string]string{
+				"test.yaml": "apiVersion: kuttl.dev/v1beta1\nkind: TestCase\nsteps:\n- files: [missing.yaml]\n",
+			},
+			err: "step 0 of the TestCase",
+		},
+		{
+			name: "two test cases",
+			files: map[string]string{
+				"a.yaml": "apiVersion: kuttl.dev/v1beta1\nkind: TestCase\n",
+				"b.yaml": "apiVersion: kuttl.dev/v1beta1\nkind: TestCase\n",
+			},
+			err: "more than 1 TestCase not allowed",
+		},
+	} {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+			}
+			test := &Case{Dir: dir, Logger: testutils.NewTestLogger(t, tt.name)}
+			_, err := test.CollectTestStepFiles()
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
 func TestGetIndexFromFile(t *testing.T) {
 	for _, tt := range []struct {
 		fileName string
@@ -413,6 +515,9 @@ func TestGetIndexFromFile(t *testing.T) {
 		{"01-foo", 1},
 		{"01234-foo.yaml", 1234},
 		{"1-foo-bar.yaml", 1},
+		{"02-foo.json", 2},
+		{"03-foo.jsonnet", 3},
+		{"03-foo.libsonnet", -1},
 		{"01.yaml", -1},
 		{"foo-01.yaml", -1},
 	} {
@@ -425,3 +530,52 @@ func TestGetIndexFromFile(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceSuffix(t *testing.T) {
+	a := &Case{Name: "a", Seed: 42}
+	b := &Case{Name: "b", Seed: 42}
+
+	assert.Equal(t, a.namespaceSuffix(), a.namespaceSuffix())
+	assert.Equal(t, b.namespaceSuffix(), (&Case{Name: "b", Seed: 42}).namespaceSuffix())
+	assert.NotEqual(t, a.namespaceSuffix(), (&Case{Name: "a", Seed: 43}).namespaceSuffix())
+	assert.Regexp(t, "^[a-z0-9]{10}$", a.namespaceSuffix())
+	assert.NotEqual(t, a.namespaceSuffix(), b.namespaceSuffix())
+	// tests of the same name in different test directories have different namespaces
+	assert.NotEqual(t, (&Case{Name: "a", Dir: "tests/e2e/a", Seed: 42}).namespaceSuffix(),
+		(&Case{Name: "a", Dir: "tests/upgrade/a", Seed: 42}).namespaceSuffix())
+
+	ns := a.determineNamespace()
+	assert.Equal(t, "kuttl-test-"+a.namespaceSuffix(), ns.Name)
+	assert.True(t, ns.AutoCreated)
+}
+
+func TestReleaseSteps(t *testing.T) {
+	test := &Case{Steps: []*Step{{retries: 2}, {retries: 3}}}
+	assert.Equal(t, 5, test.Retries())
+
+	test.releaseSteps()
+	assert.Nil(t, test.Steps)
+	assert.Equal(t, 5, test.Retries())
+}
+
+func TestWriteKubeconfig(t *testing.T) {
+	path, err := (&Case{}).writeKubeconfig(t)
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	config := func(host string) func() (*rest.Config, error) {
+		return func() (*rest.Config, error) { return &rest.Config{Host: host}, nil }
+	}
+	first, err := (&Case{Config: config("https://first:6443")}).writeKubeconfig(t)
+	require.NoError(t, err)
+	second, err := (&Case{Config: config("https://second:6443")}).writeKubeconfig(t)
+	require.NoError(t, err)
+	// each test has its own kubeconfig
+	assert.NotEqual(t, first, second)
+
+	for path, host := range map[string]string{first: "https://first:6443", second: "https://second:6443"} {
+		cfg, err := clientcmd.BuildConfigFromFlags("", path)
+		require.NoError(t, err)
+		assert.Equal(t, host, cfg.Host)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+)
+
+// cleanupPool runs the cleanups of finished tests in the background while the other tests run, at most workers of
+// them at once.  The errors of the cleanups are returned by wait, as the tests they belong to have finished.
+type cleanupPool struct {
+	workers chan struct{}
+	wg      sync.WaitGroup
+	lock    sync.Mutex
+	pending int
+	errs    []error
+}
+
+// newCleanupPool returns a cleanupPool running the cleanups of up to workers tests at once.
+func newCleanupPool(workers int) *cleanupPool {
+	if workers <= 0 {
+		workers = defaultParallel
+	}
+	return &cleanupPool{workers: make(chan struct{}, workers)}
+}
+
+// submit runs cleanup in the background as soon as a worker is available, it does not wait for it.  Failures are
+// reported as errors of the description.
+func (p *cleanupPool) submit(description string, cleanup func() error) {
+	p.lock.Lock()
+	p.pending++
+	p.lock.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.workers <- struct{}{}
+		err := cleanup()
+		<-p.workers
+
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		p.pending--
+		if err != nil {
+			p.errs = append(p.errs, fmt.Errorf("%s: %w", description, err))
+		}
+	}()
+}
+
+// running returns the number of cleanups which have not finished yet.
+func (p *cleanupPool) running() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.pending
+}
+
+// wait waits for all submitted cleanups to finish and returns their errors.
+func (p *cleanupPool) wait() []error {
+	p.wg.Wait()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	errs := p.errs
+	p.errs = nil
+	return errs
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCleanupPool(t *testing.T) {
+	p := newCleanupPool(2)
+
+	var running, most atomic.Int32
+	for i := 0; i < 6; i++ {
+		i := i
+		p.submit(fmt.Sprintf("cleanup %d", i), func() error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				previous := most.Load()
+				if current <= previous || most.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			if i == 3 {
+				return errors.New("boom")
+			}
+			return nil
+		})
+	}
+
+	errs := p.wait()
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "cleanup 3: boom")
+	assert.Equal(t, int32(2), most.Load())
+	assert.Equal(t, 0, p.running())
+	assert.Empty(t, p.wait())
+}
+
+func TestDeferredNamespaceDeletion(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	deferred := map[string]func() error{}
+
+	t.Run("deferred", func(t *testing.T) {
+		c := &Case{
+			Name:            "deferred",
+			Timeout:         1,
+			Suppress:        []string{"events"},
+			Logger:          testutils.NewTestLogger(t, "deferred"),
+			Client:          func(bool) (client.Client, error) { return cl, nil },
+			DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			DeferCleanup: func(description string, cleanup func() error) {
+				deferred[description] = cleanup
+			},
+		}
+		tc := report.NewCase(c.Name)
+		c.Run(t, tc)
+		assert.Nil(t, tc.Failure)
+	})
+
+	// the test has finished without deleting its namespace
+	namespaces := &corev1.NamespaceList{}
+	require.NoError(t, cl.List(context.TODO(), namespaces))
+	require.Len(t, namespaces.Items, 1)
+	name := namespaces.Items[0].Name
+	require.Contains(t, deferred, "deleting namespace "+name)
+
+	require.NoError(t, deferred["deleting namespace "+name]())
+	require.NoError(t, cl.List(context.TODO(), namespaces))
+	assert.Empty(t, namespaces.Items)
+}
+
+// terminatingClient never finishes deleting namespaces.
+type terminatingClient struct {
+	client.Client
+}
+
+func (c terminatingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if _, ok := obj.(*corev1.Namespace); ok {
+		return nil
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func TestAbortedNamespaceDeletion(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "stuck"}}).Build()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(100*time.Millisecond, func() { cancel(errors.New("test suite exceeded the suite timeout of 10s")) })
+	c := &Case{Name: "stuck", Timeout: 30, RunContext: ctx, Logger: testutils.NewTestLogger(t, "stuck")}
+
+	start := time.Now()
+	err := c.deleteNamespace(terminatingClient{cl}, "stuck")
+	assert.Less(t, time.Since(start), 5*time.Second, "the deletion is not waited for once the run is aborted")
+	assert.EqualError(t, err, "namespace stuck is still being deleted: the run was aborted: test suite exceeded the suite timeout of 10s")
+}
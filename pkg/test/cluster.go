@@ -0,0 +1,165 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+const (
+	// ClusterNameEnv is the environment variable of the commands of the exec cluster provider set to the name of the
+	// cluster, it is also set in the test context of commands for the clusters kuttl starts.
+	ClusterNameEnv = "KUTTL_CLUSTER_NAME"
+	// LogsDirEnv is the environment variable of the command collecting the logs of a cluster of the exec provider set
+	// to the directory to collect them into.
+	LogsDirEnv = "KUTTL_LOGS_DIR"
+)
+
+// defaultClusterName is the name of a cluster started with startCluster if it is not set.
+const defaultClusterName = "kuttl"
+
+// defaultClusterTimeout is the timeout in seconds of the commands of a cluster provider if it is not set, managed
+// clusters often take a quarter of an hour to be created.
+const defaultClusterTimeout = 1800
+
+// ClusterProvider starts and deletes an ephemeral cluster for the tests.
+type ClusterProvider interface {
+	// Start creates the cluster and writes its kubeconfig to the path.
+	Start(ctx context.Context, kubeconfig string) error
+	// CollectLogs saves the logs of the cluster to a directory.
+	CollectLogs(ctx context.Context, dir string) error
+	// Stop deletes the cluster.
+	Stop(ctx context.Context) error
+	// DeleteCommand returns a command deleting the cluster, it is logged when the cluster is kept.
+	DeleteCommand() string
+}
+
+// newClusterProvider returns the provider of the startCluster settings.
+func newClusterProvider(settings *harness.ClusterProvider, logger testutils.Logger) (ClusterProvider, error) {
+	name := settings.Name
+	if name == "" {
+		name = defaultClusterName
+	}
+	timeout := settings.Timeout
+	if timeout == 0 {
+		timeout = defaultClusterTimeout
+	}
+
+	switch settings.Provider {
+	case "", "exec":
+		if settings.Create == "" || settings.Delete == "" {
+			return nil, errors.New("the exec cluster provider requires the create and delete commands")
+		}
+		return &execCluster{
+			name:        name,
+			create:      []harness.Command{{Script: settings.Create}},
+			delete:      harness.Command{Script: settings.Delete},
+			collectLogs: settings.CollectLogs,
+			timeout:     timeout,
+			logger:      logger,
+		}, nil
+	case "k3d":
+		create := "k3d cluster create " + name + " --kubeconfig-update-default=false --kubeconfig-switch-context=false --wait"
+		if len(settings.Args) > 0 {
+			create += " " + strings.Join(settings.Args, " ")
+		}
+		return &execCluster{
+			name: name,
+			create: []harness.Command{
+				{Command: create},
+				{Command: "k3d kubeconfig write " + name + " --output $KUBECONFIG"},
+			},
+			delete:  harness.Command{Command: "k3d cluster delete " + name},
+			timeout: timeout,
+			logger:  logger,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown cluster provider %q, must be exec or k3d", settings.Provider)
+	}
+}
+
+// execCluster is a cluster created and deleted by commands.
+type execCluster struct {
+	name        string
+	create      []harness.Command
+	delete      harness.Command
+	collectLogs string
+	timeout     int
+	logger      testutils.Logger
+	kubeconfig  string
+}
+
+// Start runs the commands creating the cluster.
+func (c *execCluster) Start(ctx context.Context, kubeconfig string) error {
+	c.kubeconfig = kubeconfig
+	for _, command := range c.create {
+		if err := c.run(ctx, command, nil); err != nil {
+			return fmt.Errorf("creating cluster %s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// CollectLogs runs the command collecting the logs of the cluster, if there is one.
+func (c *execCluster) CollectLogs(ctx context.Context, dir string) error {
+	if c.collectLogs == "" {
+		return nil
+	}
+	return c.run(ctx, harness.Command{Script: c.collectLogs}, map[string]string{LogsDirEnv: dir})
+}
+
+// Stop runs the command deleting the cluster.
+func (c *execCluster) Stop(ctx context.Context) error {
+	if err := c.run(ctx, c.delete, nil); err != nil {
+		return fmt.Errorf("deleting cluster %s: %w", c.name, err)
+	}
+	return nil
+}
+
+// DeleteCommand returns the command deleting the cluster.
+func (c *execCluster) DeleteCommand() string {
+	return fmt.Sprintf("%s=%s KUBECONFIG=%s %s", ClusterNameEnv, c.name, c.kubeconfig, commandLine(c.delete))
+}
+
+// run runs a command of the cluster with the kubeconfig and name of the cluster and env in its environment.
+func (c *execCluster) run(ctx context.Context, command harness.Command, env map[string]string) error {
+	ctx = testutils.WithCommandEnv(ctx, map[string]string{ClusterNameEnv: c.name})
+	ctx = testutils.WithCommandEnv(ctx, env)
+	_, err := testutils.RunCommand(ctx, "", command, "", c.logger, c.logger, c.logger, c.timeout, c.kubeconfig)
+	c.logger.Flush()
+	return err
+}
+
+// commandLine returns the command or the script of command.
+func commandLine(command harness.Command) string {
+	if command.Command != "" {
+		return command.Command
+	}
+	return command.Script
+}
+
+// RunCluster starts the cluster of the startCluster provider of the test suite.
+func (h *Harness) RunCluster() (*rest.Config, error) {
+	if err := h.initTempPath(); err != nil {
+		return nil, err
+	}
+	provider, err := newClusterProvider(h.TestSuite.StartCluster, h.GetLogger())
+	if err != nil {
+		return nil, err
+	}
+
+	// the cluster is deleted when the harness stops, even if it was not fully created
+	h.cluster = provider
+	h.T.Log("starting cluster")
+	if err := provider.Start(context.TODO(), h.kubeconfigPath()); err != nil {
+		return nil, err
+	}
+	return clientcmd.BuildConfigFromFlags("", h.kubeconfigPath())
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExecCluster(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	logs := filepath.Join(dir, "logs")
+
+	provider, err := newClusterProvider(&harness.ClusterProvider{
+		Name:        "e2e",
+		Create:      fmt.Sprintf("echo created $KUTTL_CLUSTER_NAME > $KUBECONFIG && touch %s/created", dir),
+		Delete:      fmt.Sprintf("rm %s/created", dir),
+		CollectLogs: "mkdir $KUTTL_LOGS_DIR && echo $KUTTL_CLUSTER_NAME > $KUTTL_LOGS_DIR/cluster.log",
+	}, testutils.NewTestLogger(t, ""))
+	require.NoError(t, err)
+
+	require.NoError(t, provider.Start(context.TODO(), kubeconfig))
+	content, err := os.ReadFile(kubeconfig)
+	require.NoError(t, err)
+	assert.Equal(t, "created e2e\n", string(content))
+	assert.FileExists(t, filepath.Join(dir, "created"))
+
+	require.NoError(t, provider.CollectLogs(context.TODO(), logs))
+	content, err = os.ReadFile(filepath.Join(logs, "cluster.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "e2e\n", string(content))
+
+	assert.Equal(t, fmt.Sprintf("KUTTL_CLUSTER_NAME=e2e KUBECONFIG=%s rm %s/created", kubeconfig, dir), provider.DeleteCommand())
+	require.NoError(t, provider.Stop(context.TODO()))
+	assert.NoFileExists(t, filepath.Join(dir, "created"))
+	// the cluster is already deleted
+	assert.ErrorContains(t, provider.Stop(context.TODO()), "deleting cluster e2e")
+}
+
+func TestNewClusterProvider(t *testing.T) {
+	logger := testutils.NewTestLogger(t, "")
+
+	provider, err := newClusterProvider(&harness.ClusterProvider{Provider: "k3d", Args: []string{"--agents", "2"}}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, &execCluster{
+		name: "kuttl",
+		create: []harness.Command{
+			{Command: "k3d cluster create kuttl --kubeconfig-update-default=false --kubeconfig-switch-context=false --wait --agents 2"},
+			{Command: "k3d kubeconfig write kuttl --output $KUBECONFIG"},
+		},
+		delete:  harness.Command{Command: "k3d cluster delete kuttl"},
+		timeout: defaultClusterTimeout,
+		logger:  logger,
+	}, provider)
+
+	_, err = newClusterProvider(&harness.ClusterProvider{Create: "eksctl create cluster"}, logger)
+	assert.EqualError(t, err, "the exec cluster provider requires the create and delete commands")
+	_, err = newClusterProvider(&harness.ClusterProvider{Provider: "minikube"}, logger)
+	assert.EqualError(t, err, `unknown cluster provider "minikube", must be exec or k3d`)
+}
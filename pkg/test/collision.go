@@ -0,0 +1,149 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// clusterObject identifies a cluster-scoped object.
+type clusterObject struct {
+	kind schema.GroupKind
+	name string
+}
+
+func (o clusterObject) String() string {
+	return fmt.Sprintf("%s %s", o.kind, o.name)
+}
+
+// findCollisions returns a description of each cluster-scoped object applied or deleted by several test cases which
+// may run at the same time.  Test cases sharing a concurrency group of serial never run at the same time.  The scope of
+// kinds unknown to the cluster (ex. of CRDs installed by the tests) cannot be determined, their objects are ignored.
+func findCollisions(tests []*Case, dClient discovery.DiscoveryInterface, serial map[string]bool) []string {
+	clusterScoped := map[schema.GroupVersionKind]bool{}
+	isClusterScoped := func(gvk schema.GroupVersionKind) bool {
+		scoped, ok := clusterScoped[gvk]
+		if !ok {
+			resource, err := testutils.GetAPIResource(dClient, gvk)
+			scoped = err == nil && !resource.Namespaced
+			clusterScoped[gvk] = scoped
+		}
+		return scoped
+	}
+
+	users := map[clusterObject][]*Case{}
+	for _, test := range tests {
+		used := map[clusterObject]bool{}
+		use := func(gvk schema.GroupVersionKind, name, namespace string) {
+			if name == "" || namespace != "" || !isClusterScoped(gvk) {
+				return
+			}
+			object := clusterObject{kind: gvk.GroupKind(), name: name}
+			if !used[object] {
+				used[object] = true
+				users[object] = append(users[object], test)
+			}
+		}
+
+		for _, step := range test.Steps {
+			for _, obj := range step.Apply {
+				use(obj.GetObjectKind().GroupVersionKind(), obj.GetName(), obj.GetNamespace())
+			}
+			if step.Step == nil {
+				continue
+			}
+			for _, ref := range step.Step.Delete {
+				use(ref.GroupVersionKind(), ref.Name, ref.Namespace)
+			}
+		}
+	}
+
+	collisions := []string{}
+	for object, tests := range users {
+		if !concurrent(tests, serial) {
+			continue
+		}
+		names := make([]string, 0, len(tests))
+		for _, test := range tests {
+			names = append(names, test.Name)
+		}
+		sort.Strings(names)
+		collisions = append(collisions, fmt.Sprintf("%s is used by tests %s", object, strings.Join(names, ", ")))
+	}
+	sort.Strings(collisions)
+	return collisions
+}
+
+// concurrent returns true if two of the tests may run at the same time, they do not if they share a serial group.
+func concurrent(tests []*Case, serial map[string]bool) bool {
+	for i := range tests {
+		for j := i + 1; j < len(tests); j++ {
+			if !shareGroup(tests[i].ConcurrencyGroups(), tests[j].ConcurrencyGroups(), serial) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shareGroup returns true if the groups a and b have a group of serial in common.
+func shareGroup(a, b []string, serial map[string]bool) bool {
+	for _, group := range a {
+		if !serial[group] {
+			continue
+		}
+		for _, other := range b {
+			if group == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkCollisions logs the cluster-scoped objects used by tests which may run at the same time, or fails if the test
+// suite fails on collisions.  Tests are only checked if they run in parallel.
+func (h *Harness) checkCollisions(tests []*Case) error {
+	if h.TestSuite.Parallel == 1 || len(tests) < 2 {
+		return nil
+	}
+	dClient, err := h.DiscoveryClient()
+	if err != nil {
+		return err
+	}
+
+	serial := map[string]bool{}
+	for _, group := range h.TestSuite.ConcurrencyGroups {
+		serial[group.Name] = group.Limit == 1
+	}
+
+	for _, test := range tests {
+		if test.Logger == nil {
+			test.Logger = h.GetLogger()
+		}
+		if err := test.LoadTestSteps(); err != nil {
+			return err
+		}
+	}
+	collisions := findCollisions(tests, dClient, serial)
+	// the steps are loaded again when the tests run, they are not kept meanwhile
+	for _, test := range tests {
+		test.Steps = nil
+	}
+
+	if len(collisions) == 0 {
+		return nil
+	}
+	message := fmt.Sprintf("tests running in parallel use the same cluster-scoped objects, put them in a concurrency group with a limit of 1:\n%s", strings.Join(collisions, "\n"))
+	if h.TestSuite.FailOnCollisions {
+		return errors.New(message)
+	}
+	h.T.Log(message)
+	return nil
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestFindCollisions(t *testing.T) {
+	newTest := func(name string, groups []string, objs ...client.Object) *Case {
+		return &Case{Name: name, Steps: []*Step{{Apply: objs, Step: &harness.TestStep{ConcurrencyGroups: groups}}}}
+	}
+	deleting := newTest("deleting", nil)
+	deleting.Steps[0].Step.Delete = []harness.ObjectReference{{ObjectReference: corev1.ObjectReference{
+		APIVersion: "v1", Kind: "Namespace", Name: "shared",
+	}}}
+
+	tests := []*Case{
+		newTest("first", nil, testutils.NewResource("v1", "Namespace", "shared", ""), testutils.NewResource("v1", "ConfigMap", "config", "")),
+		newTest("second", nil, testutils.NewResource("v1", "Namespace", "shared", ""), testutils.NewResource("v1", "ConfigMap", "config", "")),
+		deleting,
+		// tests of a serial group never run at the same time
+		newTest("serial-1", []string{"serial"}, testutils.NewResource("v1", "Namespace", "serial", "")),
+		newTest("serial-2", []string{"serial", "other"}, testutils.NewResource("v1", "Namespace", "serial", "")),
+		newTest("limited-1", []string{"limited"}, testutils.NewResource("v1", "Namespace", "limited", "")),
+		newTest("limited-2", []string{"limited"}, testutils.NewResource("v1", "Namespace", "limited", "")),
+		// the scope of unknown kinds is not known
+		newTest("unknown-1", nil, testutils.NewResource("example.com/v1", "Widget", "widget", "")),
+		newTest("unknown-2", nil, testutils.NewResource("example.com/v1", "Widget", "widget", "")),
+	}
+
+	assert.Equal(t, []string{
+		"Namespace limited is used by tests limited-1, limited-2",
+		"Namespace shared is used by tests deleting, first, second",
+	}, findCollisions(tests, testutils.FakeDiscoveryClient(), map[string]bool{"serial": true, "limited": false}))
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// defaultParallel is the number of tests running at once if the test suite does not set parallel.
+const defaultParallel = 8
+
+// concurrencyLimiter limits the number of tests running at once overall and per concurrency group.  Tests waiting for
+// a group do not count against the overall limit.  A nil concurrencyLimiter does not limit tests.
+type concurrencyLimiter struct {
+	all    chan struct{}
+	groups map[string]chan struct{}
+}
+
+// newConcurrencyLimiter returns a concurrencyLimiter for the groups, it returns nil if there are no groups.
+func newConcurrencyLimiter(parallel int, groups []harness.ConcurrencyGroup) (*concurrencyLimiter, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	if parallel <= 0 {
+		parallel = defaultParallel
+	}
+
+	l := &concurrencyLimiter{
+		all:    make(chan struct{}, parallel),
+		groups: map[string]chan struct{}{},
+	}
+	for _, group := range groups {
+		if group.Name == "" {
+			return nil, errors.New("concurrency groups must have a name")
+		}
+		if _, ok := l.groups[group.Name]; ok {
+			return nil, fmt.Errorf("concurrency group %s is defined more than once", group.Name)
+		}
+		if group.Limit <= 0 {
+			return nil, fmt.Errorf("concurrency group %s must have a limit of at least 1", group.Name)
+		}
+		l.groups[group.Name] = make(chan struct{}, group.Limit)
+	}
+	return l, nil
+}
+
+// acquire waits until a test of the groups can run and returns a function to call when the test has finished.
+// Groups are acquired in the order of their names, so that tests of overlapping groups cannot deadlock.
+func (l *concurrencyLimiter) acquire(groups []string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	sorted := append([]string{}, groups...)
+	sort.Strings(sorted)
+
+	acquired := []chan struct{}{}
+	for i, name := range sorted {
+		if i > 0 && name == sorted[i-1] {
+			continue
+		}
+		sem, ok := l.groups[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown concurrency group %s", name)
+		}
+		acquired = append(acquired, sem)
+	}
+	acquired = append(acquired, l.all)
+
+	for _, sem := range acquired {
+		sem <- struct{}{}
+	}
+	return func() {
+		for _, sem := range acquired {
+			<-sem
+		}
+	}, nil
+}
+
+// ConcurrencyGroups returns the concurrency groups of the steps of the test case.
+func (t *Case) ConcurrencyGroups() []string {
+	seen := map[string]bool{}
+	groups := []string{}
+	for _, step := range t.Steps {
+		if step.Step == nil {
+			continue
+		}
+		for _, group := range step.Step.ConcurrencyGroups {
+			if !seen[group] {
+				seen[group] = true
+				groups = append(groups, group)
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestNewConcurrencyLimiter(t *testing.T) {
+	l, err := newConcurrencyLimiter(4, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+
+	release, err := l.acquire([]string{"any"})
+	assert.NoError(t, err)
+	release()
+
+	l, err = newConcurrencyLimiter(0, []harness.ConcurrencyGroup{{Name: "uses-gpu", Limit: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultParallel, cap(l.all))
+
+	_, err = newConcurrencyLimiter(4, []harness.ConcurrencyGroup{{Name: "uses-gpu", Limit: 1}, {Name: "uses-gpu", Limit: 2}})
+	assert.EqualError(t, err, "concurrency group uses-gpu is defined more than once")
+	_, err = newConcurrencyLimiter(4, []harness.ConcurrencyGroup{{Name: "uses-gpu"}})
+	assert.EqualError(t, err, "concurrency group uses-gpu must have a limit of at least 1")
+	_, err = newConcurrencyLimiter(4, []harness.ConcurrencyGroup{{Limit: 1}})
+	assert.EqualError(t, err, "concurrency groups must have a name")
+
+	_, err = l.acquire([]string{"missing"})
+	assert.EqualError(t, err, "unknown concurrency group missing")
+}
+
+func TestConcurrencyLimiterAcquire(t *testing.T) {
+	l, err := newConcurrencyLimiter(3, []harness.ConcurrencyGroup{{Name: "uses-gpu", Limit: 1}, {Name: "slow", Limit: 2}})
+	assert.NoError(t, err)
+
+	var lock sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+	run := func(groups ...string) {
+		release, err := l.acquire(groups)
+		assert.NoError(t, err)
+		defer release()
+
+		counted := map[string]bool{"": true}
+		for _, group := range groups {
+			counted[group] = true
+		}
+
+		lock.Lock()
+		for group := range counted {
+			running[group]++
+			maxRunning[group] = max(maxRunning[group], running[group])
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		for group := range counted {
+			running[group]--
+		}
+		lock.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for _, groups := range [][]string{
+		{"uses-gpu"}, {"uses-gpu", "slow"}, {"uses-gpu", "uses-gpu"}, {"slow"}, {"slow"}, {"slow", "uses-gpu"}, {}, {}, {}, {},
+	} {
+		groups := groups
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(groups...)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, maxRunning["uses-gpu"])
+	assert.LessOrEqual(t, maxRunning["slow"], 2)
+	assert.LessOrEqual(t, maxRunning[""], 3)
+}
+
+func TestCaseConcurrencyGroups(t *testing.T) {
+	c := &Case{Steps: []*Step{
+		{Step: &harness.TestStep{ConcurrencyGroups: []string{"uses-gpu", "slow"}}},
+		{},
+		{Step: &harness.TestStep{ConcurrencyGroups: []string{"slow"}}},
+	}}
+	assert.Equal(t, []string{"slow", "uses-gpu"}, c.ConcurrencyGroups())
+	assert.Equal(t, []string{}, (&Case{}).ConcurrencyGroups())
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"strconv"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// The environment variables of the test context of commands, they are also the external variables of the Jsonnet files
+// of the steps.  The variables of the context which are not known are not set, ex. KUTTL_TEST for the commands of the
+// test suite.
+const (
+	// SuiteEnv is set to the test directory of the test suite of the test (ex. "tests/e2e").
+	SuiteEnv = "KUTTL_SUITE"
+	// TestEnv is set to the name of the test.
+	TestEnv = "KUTTL_TEST"
+	// StepEnv is set to the index of the step.
+	StepEnv = "KUTTL_STEP"
+	// NamespaceEnv is set to the namespace of the test, like NAMESPACE.
+	NamespaceEnv = "KUTTL_NAMESPACE"
+	// ArtifactsDirEnv is set to the artifacts directory of the test suite.
+	ArtifactsDirEnv = "KUTTL_ARTIFACTS_DIR"
+	// SeedEnv is set to the random seed of the run, which names the namespaces of the tests and orders them.
+	SeedEnv = "KUTTL_SEED"
+)
+
+// TestContext describes where a command runs or a file is rendered, it is set in the KUTTL_* environment variables of
+// commands and in the external variables of Jsonnet files.
+type TestContext struct {
+	// Suite is the test directory of the test suite of the test.
+	Suite string
+	// Test is the name of the test.
+	Test string
+	// Step is the index of the step, it is nil outside of steps.
+	Step *int
+	// Namespace is the namespace of the test.
+	Namespace string
+	// Cluster is the name of the cluster kuttl started, it is empty for an existing cluster.
+	Cluster string
+	// ArtifactsDir is the artifacts directory of the test suite.
+	ArtifactsDir string
+	// Seed is the random seed of the run.
+	Seed int64
+}
+
+// Env returns the environment variables of the fields of the context which are set.
+func (c TestContext) Env() map[string]string {
+	env := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	set(SuiteEnv, c.Suite)
+	set(TestEnv, c.Test)
+	if c.Step != nil {
+		set(StepEnv, strconv.Itoa(*c.Step))
+	}
+	set(NamespaceEnv, c.Namespace)
+	set(ClusterNameEnv, c.Cluster)
+	set(ArtifactsDirEnv, c.ArtifactsDir)
+	if c.Seed != 0 {
+		set(SeedEnv, strconv.FormatInt(c.Seed, 10))
+	}
+	return env
+}
+
+// forStep returns the context of a step of the test.
+func (c TestContext) forStep(index int) TestContext {
+	c.Step = &index
+	return c
+}
+
+// testContext returns the context of the commands of the test suite, the tests add their own fields to it.
+func (h *Harness) testContext() TestContext {
+	return TestContext{Cluster: h.clusterName(), ArtifactsDir: h.TestSuite.ArtifactsDir, Seed: h.seed}
+}
+
+// clusterName returns the name of the cluster kuttl starts, it is empty if the tests run in an existing cluster or in a
+// mocked control plane.
+func (h *Harness) clusterName() string {
+	switch {
+	case h.TestSuite.Config != nil, h.TestSuite.StartControlPlane:
+		return ""
+	case h.TestSuite.StartCluster != nil:
+		if h.TestSuite.StartCluster.Name != "" {
+			return h.TestSuite.StartCluster.Name
+		}
+		return defaultClusterName
+	case h.TestSuite.StartKIND:
+		if h.TestSuite.KINDContext != "" {
+			return h.TestSuite.KINDContext
+		}
+		return harness.DefaultKINDContext
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestTestContextEnv(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		ClusterNameEnv:  "kind",
+		ArtifactsDirEnv: "artifacts",
+		SeedEnv:         "42",
+	}, TestContext{Cluster: "kind", ArtifactsDir: "artifacts", Seed: 42}.Env())
+
+	assert.Equal(t, map[string]string{
+		SuiteEnv:     "tests/e2e",
+		TestEnv:      "install",
+		StepEnv:      "0",
+		NamespaceEnv: "kuttl-test-install",
+	}, TestContext{Suite: "tests/e2e", Test: "install", Namespace: "kuttl-test-install"}.forStep(0).Env())
+}
+
+func TestStepJsonnetVars(t *testing.T) {
+	step := &Step{
+		JsonnetVars: map[string]string{"env": "ci", TestEnv: "suite"},
+		Context:     TestContext{Test: "install", Namespace: "kuttl-test-install"},
+	}
+	// the test context overrides the variables of the test suite
+	assert.Equal(t, map[string]string{"env": "ci", TestEnv: "install", NamespaceEnv: "kuttl-test-install"}, step.jsonnetVars())
+}
+
+func TestClusterName(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		suite    harness.TestSuite
+		expected string
+	}{
+		{name: "existing cluster", expected: ""},
+		{name: "kind", suite: harness.TestSuite{StartKIND: true}, expected: harness.DefaultKINDContext},
+		{name: "kind context", suite: harness.TestSuite{StartKIND: true, KINDContext: "e2e"}, expected: "e2e"},
+		{name: "cluster provider", suite: harness.TestSuite{StartCluster: &harness.ClusterProvider{}}, expected: defaultClusterName},
+		{name: "mocked control plane", suite: harness.TestSuite{StartControlPlane: true}, expected: ""},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			h := &Harness{TestSuite: test.suite}
+			assert.Equal(t, test.expected, h.clusterName())
+		})
+	}
+}
+
+func TestRunStepContext(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "context")
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	c := &Case{
+		Name: "context",
+		Steps: []*Step{{
+			Name:  "print",
+			Index: 3,
+			Step: &harness.TestStep{Commands: []harness.Command{{
+				Script: `echo "$KUTTL_SUITE $KUTTL_TEST $KUTTL_STEP $KUTTL_NAMESPACE $KUTTL_SEED" > ` + out,
+			}}},
+		}},
+		PreferredNamespace: testNamespace,
+		SkipDelete:         true,
+		Suppress:           []string{"events"},
+		Timeout:            1,
+		Context:            TestContext{Suite: "tests/e2e", Seed: 42},
+		Logger:             testutils.NewTestLogger(t, "context"),
+		Client:             func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient:    func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+
+	tc := report.NewCase(c.Name)
+	c.Run(t, tc)
+	require.Nil(t, tc.Failure)
+
+	printed, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "tests/e2e context 3 "+testNamespace+" 42\n", string(printed))
+}
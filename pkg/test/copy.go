@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// uncopiedMetadata are the fields of the metadata of an object which are not copied, the API server maintains them or
+// they tie the object to its original namespace.
+var uncopiedMetadata = []string{
+	"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp", "deletionGracePeriodSeconds",
+	"managedFields", "selfLink", "ownerReferences", "finalizers",
+}
+
+// CopyObjects copies the objects of the CopyFrom list of the step into the test namespace.
+func (s *Step) CopyObjects(namespace string) error {
+	if s.Step == nil || len(s.Step.CopyFrom) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return err
+	}
+	dClient, err := s.DiscoveryClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Duration(s.GetTimeout())*time.Second)
+	defer cancel()
+	for _, copyFrom := range s.Step.CopyFrom {
+		gvk := schema.FromAPIVersionAndKind(copyFrom.APIVersion, copyFrom.Kind)
+		resource, err := testutils.GetAPIResource(dClient, gvk)
+		if err != nil {
+			return fmt.Errorf("copying %s %s: %w", copyFrom.Kind, copyFrom.Name, err)
+		}
+		if !resource.Namespaced {
+			return fmt.Errorf("copying %s %s: only namespaced objects can be copied", copyFrom.Kind, copyFrom.Name)
+		}
+		if err := s.copyObject(ctx, cl, copyFrom, gvk, namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyObject creates or updates the copy of the object of copyFrom in namespace.
+func (s *Step) copyObject(ctx context.Context, cl client.Client, copyFrom harness.CopyFrom, gvk schema.GroupVersionKind, namespace string) error {
+	source := &unstructured.Unstructured{}
+	source.SetGroupVersionKind(gvk)
+	key := client.ObjectKey{Namespace: copyFrom.Namespace, Name: copyFrom.Name}
+	if key.Namespace == "" {
+		key.Namespace = namespace
+	}
+	name := copyFrom.ToName
+	if name == "" {
+		name = copyFrom.Name
+	}
+	if key.Namespace == namespace && key.Name == name {
+		return fmt.Errorf("copying %s %s: the copy would replace the object, set namespace or toName", gvk.Kind, key)
+	}
+
+	if err := cl.Get(ctx, key, source); err != nil {
+		return fmt.Errorf("getting %s %s to copy: %w", gvk.Kind, key, err)
+	}
+
+	obj, err := copyContent(source, copyFrom.Patch)
+	if err != nil {
+		return fmt.Errorf("copying %s %s: %w", gvk.Kind, key, err)
+	}
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+
+	if _, err := testutils.CreateOrUpdate(ctx, cl, obj, true); err != nil {
+		return fmt.Errorf("copying %s %s: %w", gvk.Kind, key, err)
+	}
+	// the objects are described by their kind and names only, copied Secrets are not logged
+	s.Logger.Logf("copied %s %s to %s/%s", gvk.Kind, key, namespace, name)
+	return nil
+}
+
+// copyContent returns the copy of source without the status and the metadata which are not copied, patched with the
+// JSON merge patch in YAML or JSON if it is set.
+func copyContent(source *unstructured.Unstructured, patch string) (*unstructured.Unstructured, error) {
+	obj := source.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range uncopiedMetadata {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", lastAppliedAnnotation)
+	if len(obj.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	}
+
+	if patch == "" {
+		return obj, nil
+	}
+	mergePatch, err := yaml.ToJSON([]byte(patch))
+	if err != nil {
+		return nil, fmt.Errorf("parsing patch: %w", err)
+	}
+	doc, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = jsonpatch.MergePatch(doc, mergePatch); err != nil {
+		return nil, fmt.Errorf("applying patch: %w", err)
+	}
+	patched := &unstructured.Unstructured{}
+	if err := patched.UnmarshalJSON(doc); err != nil {
+		return nil, fmt.Errorf("applying patch: %w", err)
+	}
+	return patched, nil
+}
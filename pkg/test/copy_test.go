@@ -0,0 +1,128 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCopyObjects(t *testing.T) {
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "registry",
+			Namespace:       "shared",
+			Labels:          map[string]string{"team": "platform"},
+			Annotations:     map[string]string{lastAppliedAnnotation: "{}"},
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "1234"}},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+	}
+	secretRef := harness.CopyFrom{APIVersion: "v1", Kind: "Secret", Name: "registry", Namespace: "shared"}
+
+	for _, test := range []struct {
+		name     string
+		copyFrom harness.CopyFrom
+		existing []client.Object
+		expected *corev1.Secret
+		err      string
+	}{
+		{
+			name:     "copy",
+			copyFrom: secretRef,
+			expected: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: testNamespace, Labels: map[string]string{"team": "platform"}},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data:       pullSecret.Data,
+			},
+		},
+		{
+			name: "renamed and patched",
+			copyFrom: harness.CopyFrom{APIVersion: "v1", Kind: "Secret", Name: "registry", Namespace: "shared", ToName: "pull-secret",
+				Patch: "metadata: {labels: {team: null, app: web}}"},
+			expected: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: testNamespace, Labels: map[string]string{"app": "web"}},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data:       pullSecret.Data,
+			},
+		},
+		{
+			name:     "update of an earlier copy",
+			copyFrom: secretRef,
+			existing: []client.Object{&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: testNamespace},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{}`)},
+			}},
+			expected: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: testNamespace, Labels: map[string]string{"team": "platform"}},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data:       pullSecret.Data,
+			},
+		},
+		{
+			name:     "missing",
+			copyFrom: harness.CopyFrom{APIVersion: "v1", Kind: "Secret", Name: "missing", Namespace: "shared"},
+			err:      `getting Secret shared/missing to copy: secrets "missing" not found`,
+		},
+		{
+			name:     "onto itself",
+			copyFrom: harness.CopyFrom{APIVersion: "v1", Kind: "Secret", Name: "registry", Namespace: testNamespace},
+			err:      "copying Secret world/registry: the copy would replace the object, set namespace or toName",
+		},
+		{
+			name:     "cluster-scoped",
+			copyFrom: harness.CopyFrom{APIVersion: "v1", Kind: "Namespace", Name: "shared"},
+			err:      "copying Namespace shared: only namespaced objects can be copied",
+		},
+		{
+			name:     "invalid patch",
+			copyFrom: harness.CopyFrom{APIVersion: "v1", Kind: "Secret", Name: "registry", Namespace: "shared", Patch: "["},
+			err:      "copying Secret shared/registry: parsing patch",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(append(test.existing, pullSecret.DeepCopy())...).Build()
+			step := &Step{
+				Step:            &harness.TestStep{CopyFrom: []harness.CopyFrom{test.copyFrom}},
+				Timeout:         1,
+				Logger:          testutils.NewTestLogger(t, test.name),
+				Client:          func(bool) (client.Client, error) { return cl, nil },
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			err := step.CopyObjects(testNamespace)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			actual := &corev1.Secret{}
+			require.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(test.expected), actual))
+			assert.Equal(t, test.expected.Labels, actual.Labels)
+			assert.Empty(t, actual.Annotations)
+			assert.Empty(t, actual.OwnerReferences)
+			assert.Equal(t, test.expected.Type, actual.Type)
+			assert.Equal(t, test.expected.Data, actual.Data)
+
+			// the original is unchanged
+			original := &corev1.Secret{}
+			require.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(pullSecret), original))
+			assert.Equal(t, pullSecret.Labels, original.Labels)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// expectedCount returns a copy of expected without the count annotation, as well as the number of objects which
+// must match it.  If the annotation is not set, expected is returned unmodified with a count of -1.
+func expectedCount(expected runtime.Object) (runtime.Object, int, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, ok := m.GetAnnotations()[harness.CountAnnotation]
+	if !ok {
+		return expected, -1, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return nil, 0, fmt.Errorf("annotation %s: %q is not a non-negative number", harness.CountAnnotation, value)
+	}
+	if m.GetName() != "" {
+		return nil, 0, fmt.Errorf("annotation %s can only be used on objects without a name, got %q", harness.CountAnnotation, m.GetName())
+	}
+
+	copied, err := withoutAnnotation(expected, harness.CountAnnotation)
+	if err != nil {
+		return nil, 0, err
+	}
+	return copied, count, nil
+}
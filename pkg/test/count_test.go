@@ -0,0 +1,117 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedCount(t *testing.T) {
+	expected := testutils.SetAnnotation(testutils.NewPod("", ""), harness.CountAnnotation, "3")
+
+	stripped, count, err := expectedCount(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+	// the original object is not modified
+	assert.Contains(t, expected.GetAnnotations(), harness.CountAnnotation)
+
+	unannotated := testutils.NewPod("", "")
+	stripped, count, err = expectedCount(unannotated)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, count)
+	assert.Equal(t, unannotated, stripped)
+
+	_, _, err = expectedCount(testutils.SetAnnotation(testutils.NewPod("", ""), harness.CountAnnotation, "three"))
+	assert.Error(t, err)
+	_, _, err = expectedCount(testutils.SetAnnotation(testutils.NewPod("", ""), harness.CountAnnotation, "-1"))
+	assert.Error(t, err)
+	_, _, err = expectedCount(testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.CountAnnotation, "1"))
+	assert.Error(t, err)
+}
+
+func TestCheckResourceCount(t *testing.T) {
+	replica := func(name string, app string) *unstructured.Unstructured {
+		pod := testutils.NewPod(name, testNamespace)
+		pod.SetLabels(map[string]string{"app": app})
+		return pod
+	}
+	counted := func(count string) *unstructured.Unstructured {
+		pod := testutils.NewPod("", "")
+		pod.SetLabels(map[string]string{"app": "web"})
+		return testutils.SetAnnotation(pod, harness.CountAnnotation, count)
+	}
+
+	for _, test := range []struct {
+		testName    string
+		actual      []runtime.Object
+		expected    runtime.Object
+		shouldError bool
+	}{
+		{
+			testName: "count matches",
+			actual:   []runtime.Object{replica("web-1", "web"), replica("web-2", "web"), replica("db-1", "db")},
+			expected: counted("2"),
+		},
+		{
+			testName:    "too few",
+			actual:      []runtime.Object{replica("web-1", "web"), replica("db-1", "db")},
+			expected:    counted("2"),
+			shouldError: true,
+		},
+		{
+			testName:    "too many",
+			actual:      []runtime.Object{replica("web-1", "web"), replica("web-2", "web"), replica("web-3", "web")},
+			expected:    counted("2"),
+			shouldError: true,
+		},
+		{
+			testName: "zero",
+			actual:   []runtime.Object{replica("db-1", "db")},
+			expected: counted("0"),
+		},
+		{
+			testName: "only matching objects are counted",
+			actual: []runtime.Object{
+				replica("web-1", "web"),
+				testutils.WithSpec(t, replica("web-2", "web"), map[string]interface{}{"nodeName": "node-1"}),
+			},
+			expected: testutils.WithSpec(t, counted("1"), map[string]interface{}{"nodeName": "node-1"}),
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			step := Step{
+				Logger: testutils.NewTestLogger(t, ""),
+				Client: func(bool) (client.Client, error) {
+					return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(test.actual...).Build(), nil
+				},
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			errors := step.CheckResource(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NotEqual(t, []error{}, errors)
+			} else {
+				assert.Equal(t, []error{}, errors)
+			}
+
+			absentErr := step.CheckResourceAbsent(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NoError(t, absentErr)
+			} else {
+				assert.Error(t, absentErr)
+			}
+		})
+	}
+}
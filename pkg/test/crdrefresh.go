@@ -0,0 +1,64 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// crdGroupKind is the group and kind of CustomResourceDefinitions, of any version.
+var crdGroupKind = schema.GroupKind{Group: apiextv1.GroupName, Kind: "CustomResourceDefinition"}
+
+// isCRD returns true if obj is a CustomResourceDefinition.
+func isCRD(obj runtime.Object) bool {
+	return obj.GetObjectKind().GroupVersionKind().GroupKind() == crdGroupKind
+}
+
+// waitForCRDs waits for the named CRDs to be established, or to be removed if deleted is set.
+func waitForCRDs(ctx context.Context, cl client.Client, names []string, deleted bool, timeout time.Duration) error {
+	return wait.PollImmediate(100*time.Millisecond, timeout, func() (bool, error) {
+		for _, name := range names {
+			crd := &apiextv1.CustomResourceDefinition{}
+			err := cl.Get(ctx, client.ObjectKey{Name: name}, crd)
+			switch {
+			case k8serrors.IsNotFound(err):
+				if !deleted {
+					return false, nil
+				}
+			case err != nil:
+				return false, err
+			case deleted || !crdEstablished(crd):
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// refreshCRDs waits for the CRDs applied or deleted by the step and then creates a new client, so that the REST
+// mapper of the client knows the kinds of the CRDs in the rest of the step and the following steps.
+func (s *Step) refreshCRDs(names []string, deleted bool) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return err
+	}
+	if err := waitForCRDs(context.TODO(), cl, names, deleted, time.Duration(s.GetTimeout())*time.Second); err != nil {
+		return fmt.Errorf("waiting for CRDs %s: %w", strings.Join(names, ", "), err)
+	}
+
+	s.Logger.Logf("refreshing API resources after changes to CRDs %s", strings.Join(names, ", "))
+	_, err = s.Client(true)
+	return err
+}
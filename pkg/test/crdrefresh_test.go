@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// noMatchClient fails to get objects like a client whose REST mapper does not know their kind yet.
+type noMatchClient struct {
+	client.Client
+}
+
+func (c *noMatchClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return &meta.NoKindMatchError{GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind()}
+}
+
+func newCRD(name string, established bool) *apiextv1.CustomResourceDefinition {
+	crd := &apiextv1.CustomResourceDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	if established {
+		crd.Status.Conditions = []apiextv1.CustomResourceDefinitionCondition{
+			{Type: apiextv1.Established, Status: apiextv1.ConditionTrue},
+		}
+	}
+	return crd
+}
+
+func TestWaitForCRDs(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(testutils.Scheme()).WithObjects(
+		newCRD("established.example.com", true),
+		newCRD("pending.example.com", false),
+	).Build()
+
+	for _, test := range []struct {
+		name    string
+		crds    []string
+		deleted bool
+		err     bool
+	}{
+		{name: "established", crds: []string{"established.example.com"}},
+		{name: "not established", crds: []string{"established.example.com", "pending.example.com"}, err: true},
+		{name: "not created", crds: []string{"missing.example.com"}, err: true},
+		{name: "deleted", crds: []string{"missing.example.com"}, deleted: true},
+		{name: "not deleted", crds: []string{"established.example.com"}, deleted: true, err: true},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := waitForCRDs(context.TODO(), cl, test.crds, test.deleted, 300*time.Millisecond)
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCreateRefreshesClientAfterCRDs(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(testutils.Scheme()).Build()
+	forced := 0
+	step := &Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(forceNew bool) (client.Client, error) {
+			if forceNew {
+				forced++
+			}
+			return cl, nil
+		},
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+		Step:            &harness.TestStep{},
+		Apply: []client.Object{
+			newCRD("things.example.com", true),
+			testutils.NewResource("v1", "ConfigMap", "config", ""),
+		},
+		Timeout: 1,
+	}
+
+	assert.Empty(t, step.Create(t, testNamespace))
+	// the apply client, the refresh after the CRD and the apply client of the custom resources
+	assert.Equal(t, 3, forced)
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Name: "config", Namespace: testNamespace}, &corev1.ConfigMap{}))
+
+	t.Run("no kind match", func(t *testing.T) {
+		clients := []client.Client{&noMatchClient{Client: cl}, cl}
+		step := &Step{
+			Logger: testutils.NewTestLogger(t, ""),
+			Client: func(bool) (client.Client, error) {
+				next := clients[0]
+				clients = clients[1:]
+				return next, nil
+			},
+			DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			Apply:           []client.Object{testutils.NewResource("v1", "ConfigMap", "other", "")},
+		}
+
+		assert.Empty(t, step.Create(t, testNamespace))
+		assert.Empty(t, clients)
+		assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Name: "other", Namespace: testNamespace}, &corev1.ConfigMap{}))
+	})
+}
+
+func TestDeleteExistingRefreshesClientAfterCRDs(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(testutils.Scheme()).WithObjects(newCRD("things.example.com", true)).Build()
+	forced := 0
+	step := &Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(forceNew bool) (client.Client, error) {
+			if forceNew {
+				forced++
+			}
+			return cl, nil
+		},
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+		Step: &harness.TestStep{Delete: []harness.ObjectReference{{
+			ObjectReference: corev1.ObjectReference{
+				APIVersion: "apiextensions.k8s.io/v1",
+				Kind:       "CustomResourceDefinition",
+				Name:       "things.example.com",
+			},
+		}}},
+		Timeout: 1,
+	}
+
+	assert.NoError(t, step.DeleteExisting(testNamespace))
+	assert.Equal(t, 1, forced)
+	require.Error(t, cl.Get(context.TODO(), client.ObjectKey{Name: "things.example.com"}, &apiextv1.CustomResourceDefinition{}))
+}
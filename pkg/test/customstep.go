@@ -0,0 +1,176 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// builtinKinds are the kinds of the kuttl.dev group which are handled by the harness itself, all other kinds of the
+// group in step files are custom step kinds.
+var builtinKinds = map[string]bool{
+	"TestFile":   true,
+	"TestStep":   true,
+	"TestAssert": true,
+	"TestSuite":  true,
+}
+
+// StepContext is passed to a StepHandler, it contains the details of the test step running the custom step.
+type StepContext struct {
+	// Namespace is the test namespace.
+	Namespace string
+	// Dir is the directory of the test case.
+	Dir string
+	// Kubeconfig is the kubeconfig of the step or of the test, empty for the default kubeconfig.
+	Kubeconfig string
+	// Timeout is the timeout of the step in seconds.
+	Timeout int
+	// Context is the test context of the step.
+	Context TestContext
+	Client  func(forceNew bool) (client.Client, error)
+	Logger  testutils.Logger
+}
+
+// A StepHandler runs objects of a custom step kind (ex. kuttl.dev/v1beta1/LoadTest) found in the files of a test step.
+// Handlers are registered by kind in Harness.StepHandlers or as executables in the stepPlugins of the TestSuite.
+type StepHandler interface {
+	RunStep(ctx context.Context, obj client.Object, sc StepContext) error
+}
+
+// StepHandlerFunc is a function implementing StepHandler.
+type StepHandlerFunc func(ctx context.Context, obj client.Object, sc StepContext) error
+
+// RunStep implements StepHandler.
+func (f StepHandlerFunc) RunStep(ctx context.Context, obj client.Object, sc StepContext) error {
+	return f(ctx, obj, sc)
+}
+
+// isCustomStep returns true if obj is of a kind of the kuttl.dev group which is not handled by the harness itself.
+func isCustomStep(obj client.Object) bool {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return gvk.Group == "kuttl.dev" && !builtinKinds[gvk.Kind]
+}
+
+// execStepHandler runs an executable for a custom step kind, the object is passed to it as YAML on stdin and the
+// NAMESPACE, KUBECONFIG and test context environment variables are set like for commands.
+type execStepHandler struct {
+	command string
+}
+
+// RunStep implements StepHandler.
+func (h execStepHandler) RunStep(ctx context.Context, obj client.Object, sc StepContext) error {
+	stdin := &bytes.Buffer{}
+	if err := testutils.MarshalObject(obj, stdin); err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	kubeconfig := sc.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = filepath.Join(cwd, "kubeconfig")
+	}
+	envMap := sc.Context.Env()
+	envMap["NAMESPACE"] = sc.Namespace
+	envMap["KUBECONFIG"] = kubeconfig
+
+	cmd, err := testutils.GetArgs(ctx, harness.Command{Command: h.command}, sc.Namespace, envMap)
+	if err != nil {
+		return fmt.Errorf("processing step plugin %q: %w", h.command, err)
+	}
+
+	sc.Logger.Logf("running step plugin: %v", cmd.Args)
+
+	// the plugin runs in the test directory, a relative path to it is relative to the working directory like the
+	// other paths of the test suite
+	if strings.ContainsRune(cmd.Path, '/') && !filepath.IsAbs(cmd.Path) {
+		cmd.Path = filepath.Join(cwd, cmd.Path)
+	}
+	cmd.Dir = sc.Dir
+	cmd.Stdin = stdin
+	cmd.Stdout = sc.Logger
+	cmd.Stderr = sc.Logger
+	cmd.Env = os.Environ()
+	for key, value := range envMap {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("step plugin %q: %w", h.command, err)
+	}
+	return nil
+}
+
+// stepHandlers returns the handlers of custom step kinds, the step plugins of the TestSuite override handlers
+// registered in StepHandlers.
+func (h *Harness) stepHandlers() map[string]StepHandler {
+	handlers := map[string]StepHandler{}
+	for kind, handler := range h.StepHandlers {
+		handlers[kind] = handler
+	}
+	for kind, command := range h.TestSuite.StepPlugins {
+		handlers[kind] = execStepHandler{command: command}
+	}
+	return handlers
+}
+
+// kubeconfig returns the kubeconfig of the step, or the kubeconfig of the test in its CommandEnv.
+func (s *Step) kubeconfig() string {
+	if s.Kubeconfig != "" {
+		return s.Kubeconfig
+	}
+	return s.CommandEnv["KUBECONFIG"]
+}
+
+// RunCustom runs the objects of custom step kinds with their registered handlers.
+func (s *Step) RunCustom(ctx context.Context, namespace string) []error {
+	testErrors := []error{}
+
+	sc := StepContext{
+		Namespace:  namespace,
+		Dir:        s.Dir,
+		Kubeconfig: s.kubeconfig(),
+		Timeout:    s.Timeout,
+		Context:    s.Context,
+		Client:     s.Client,
+		Logger:     s.Logger,
+	}
+
+	for _, obj := range s.Custom {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+
+		handler, ok := s.StepHandlers[kind]
+		if !ok {
+			testErrors = append(testErrors, fmt.Errorf("no handler registered for step kind %s, registered kinds: %v",
+				obj.GetObjectKind().GroupVersionKind(), registeredKinds(s.StepHandlers)))
+			continue
+		}
+
+		s.Logger.Logf("running custom step %s", testutils.ResourceID(obj))
+		if err := handler.RunStep(ctx, obj, sc); err != nil {
+			testErrors = append(testErrors, fmt.Errorf("step %s: %w", testutils.ResourceID(obj), err))
+		}
+	}
+
+	return testErrors
+}
+
+func registeredKinds(handlers map[string]StepHandler) []string {
+	kinds := []string{}
+	for kind := range handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestLoadCustomStep(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "00-load.yaml"), []byte(`apiVersion: kuttl.dev/v1beta1
+kind: LoadTest
+metadata:
+  name: load
+spec:
+  rps: 100
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`), 0600))
+
+	step := Step{Dir: dir}
+	assert.NoError(t, step.LoadYAML(filepath.Join(dir, "00-load.yaml")))
+
+	assert.Len(t, step.Custom, 1)
+	assert.Equal(t, "LoadTest", step.Custom[0].GetObjectKind().GroupVersionKind().Kind)
+	assert.Len(t, step.Apply, 1)
+	assert.Equal(t, "ConfigMap", step.Apply[0].GetObjectKind().GroupVersionKind().Kind)
+}
+
+func TestRunCustom(t *testing.T) {
+	loadTest := testutils.NewResource("kuttl.dev/v1beta1", "LoadTest", "load", "")
+
+	var ran []string
+	step := Step{
+		Dir:    "test_data",
+		Custom: []client.Object{loadTest},
+		Logger: testutils.NewTestLogger(t, ""),
+		StepHandlers: map[string]StepHandler{
+			"LoadTest": StepHandlerFunc(func(_ context.Context, obj client.Object, sc StepContext) error {
+				ran = append(ran, obj.GetName()+"/"+sc.Namespace)
+				return nil
+			}),
+		},
+	}
+
+	assert.Equal(t, []error{}, step.RunCustom(context.TODO(), testNamespace))
+	assert.Equal(t, []string{"load/" + testNamespace}, ran)
+
+	step.StepHandlers["LoadTest"] = StepHandlerFunc(func(context.Context, client.Object, StepContext) error {
+		return errors.New("too slow")
+	})
+	assert.Len(t, step.RunCustom(context.TODO(), testNamespace), 1)
+
+	step.StepHandlers = nil
+	testErrors := step.RunCustom(context.TODO(), testNamespace)
+	assert.Len(t, testErrors, 1)
+	assert.Contains(t, testErrors[0].Error(), "no handler registered for step kind")
+}
+
+func TestExecStepHandler(t *testing.T) {
+	loadTest := testutils.NewResource("kuttl.dev/v1beta1", "LoadTest", "load", "")
+	sc := StepContext{Namespace: testNamespace, Dir: t.TempDir(), Logger: testutils.NewTestLogger(t, "")}
+
+	handler := execStepHandler{command: `sh -c 'grep -q "kind: LoadTest" && test "$NAMESPACE" = world'`}
+	assert.NoError(t, handler.RunStep(context.TODO(), loadTest, sc))
+
+	handler = execStepHandler{command: `sh -c 'grep -q "kind: StatefulSet"'`}
+	assert.Error(t, handler.RunStep(context.TODO(), loadTest, sc))
+
+	// a relative path to the plugin is relative to the working directory, not to the test directory
+	plugin := filepath.Join(t.TempDir(), "load-test")
+	require.NoError(t, os.WriteFile(plugin, []byte("#!/bin/sh\ngrep -q 'kind: LoadTest'\n"), 0755))
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	relative, err := filepath.Rel(cwd, plugin)
+	require.NoError(t, err)
+	sc.Dir = filepath.Join(t.TempDir(), "tests", "e2e", "load", "steps", "nested")
+	require.NoError(t, os.MkdirAll(sc.Dir, 0755))
+	handler = execStepHandler{command: relative + " --rate 10"}
+	assert.NoError(t, handler.RunStep(context.TODO(), loadTest, sc))
+}
+
+func TestStepHandlers(t *testing.T) {
+	goHandler := StepHandlerFunc(func(context.Context, client.Object, StepContext) error { return nil })
+	h := Harness{
+		TestSuite: harness.TestSuite{StepPlugins: map[string]string{"Chaos": "./bin/chaos"}},
+		StepHandlers: map[string]StepHandler{
+			"LoadTest": goHandler,
+			"Chaos":    goHandler,
+		},
+	}
+
+	handlers := h.stepHandlers()
+	assert.Len(t, handlers, 2)
+	assert.Equal(t, execStepHandler{command: "./bin/chaos"}, handlers["Chaos"])
+	assert.Equal(t, []string{"Chaos", "LoadTest"}, registeredKinds(handlers))
+}
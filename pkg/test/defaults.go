@@ -0,0 +1,264 @@
+package test
+
+import (
+	"reflect"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// defaultingScheme sets the defaults the API server sets on the fields of built-in objects which are commonly omitted,
+// the scheme of client-go does not register the defaulting functions of the API server.
+var defaultingScheme = newDefaultingScheme()
+
+func newDefaultingScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	s.AddTypeDefaultingFunc(&corev1.Pod{}, func(obj interface{}) { setPodSpecDefaults(&obj.(*corev1.Pod).Spec) })
+	s.AddTypeDefaultingFunc(&corev1.Service{}, func(obj interface{}) { setServiceDefaults(obj.(*corev1.Service)) })
+	s.AddTypeDefaultingFunc(&appsv1.Deployment{}, func(obj interface{}) {
+		deployment := obj.(*appsv1.Deployment)
+		setReplicasDefaults(&deployment.Spec.Replicas, &deployment.Spec.RevisionHistoryLimit)
+		if deployment.Spec.Strategy.Type == "" {
+			deployment.Spec.Strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+		}
+		if deployment.Spec.Strategy.Type == appsv1.RollingUpdateDeploymentStrategyType && deployment.Spec.Strategy.RollingUpdate == nil {
+			quarter := intstr.FromString("25%")
+			deployment.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{MaxUnavailable: &quarter, MaxSurge: &quarter}
+		}
+		if deployment.Spec.ProgressDeadlineSeconds == nil {
+			deployment.Spec.ProgressDeadlineSeconds = int32Ptr(600)
+		}
+		setPodSpecDefaults(&deployment.Spec.Template.Spec)
+	})
+	s.AddTypeDefaultingFunc(&appsv1.StatefulSet{}, func(obj interface{}) {
+		statefulSet := obj.(*appsv1.StatefulSet)
+		setReplicasDefaults(&statefulSet.Spec.Replicas, &statefulSet.Spec.RevisionHistoryLimit)
+		if statefulSet.Spec.PodManagementPolicy == "" {
+			statefulSet.Spec.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+		}
+		if statefulSet.Spec.UpdateStrategy.Type == "" {
+			statefulSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+		}
+		setPodSpecDefaults(&statefulSet.Spec.Template.Spec)
+	})
+	s.AddTypeDefaultingFunc(&appsv1.DaemonSet{}, func(obj interface{}) {
+		daemonSet := obj.(*appsv1.DaemonSet)
+		if daemonSet.Spec.RevisionHistoryLimit == nil {
+			daemonSet.Spec.RevisionHistoryLimit = int32Ptr(10)
+		}
+		if daemonSet.Spec.UpdateStrategy.Type == "" {
+			daemonSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateDaemonSetStrategyType
+		}
+		setPodSpecDefaults(&daemonSet.Spec.Template.Spec)
+	})
+	s.AddTypeDefaultingFunc(&appsv1.ReplicaSet{}, func(obj interface{}) {
+		replicaSet := obj.(*appsv1.ReplicaSet)
+		if replicaSet.Spec.Replicas == nil {
+			replicaSet.Spec.Replicas = int32Ptr(1)
+		}
+		setPodSpecDefaults(&replicaSet.Spec.Template.Spec)
+	})
+	s.AddTypeDefaultingFunc(&batchv1.Job{}, func(obj interface{}) { setJobDefaults(&obj.(*batchv1.Job).Spec) })
+	s.AddTypeDefaultingFunc(&batchv1.CronJob{}, func(obj interface{}) {
+		cronJob := obj.(*batchv1.CronJob)
+		if cronJob.Spec.ConcurrencyPolicy == "" {
+			cronJob.Spec.ConcurrencyPolicy = batchv1.AllowConcurrent
+		}
+		if cronJob.Spec.Suspend == nil {
+			suspend := false
+			cronJob.Spec.Suspend = &suspend
+		}
+		if cronJob.Spec.SuccessfulJobsHistoryLimit == nil {
+			cronJob.Spec.SuccessfulJobsHistoryLimit = int32Ptr(3)
+		}
+		if cronJob.Spec.FailedJobsHistoryLimit == nil {
+			cronJob.Spec.FailedJobsHistoryLimit = int32Ptr(1)
+		}
+		setJobDefaults(&cronJob.Spec.JobTemplate.Spec)
+	})
+	return s
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func setReplicasDefaults(replicas, revisionHistoryLimit **int32) {
+	if *replicas == nil {
+		*replicas = int32Ptr(1)
+	}
+	if *revisionHistoryLimit == nil {
+		*revisionHistoryLimit = int32Ptr(10)
+	}
+}
+
+func setJobDefaults(spec *batchv1.JobSpec) {
+	if spec.Completions == nil && spec.Parallelism == nil {
+		spec.Completions = int32Ptr(1)
+		spec.Parallelism = int32Ptr(1)
+	}
+	if spec.BackoffLimit == nil {
+		spec.BackoffLimit = int32Ptr(6)
+	}
+	setPodSpecDefaults(&spec.Template.Spec)
+}
+
+func setPodSpecDefaults(spec *corev1.PodSpec) {
+	if spec.RestartPolicy == "" {
+		spec.RestartPolicy = corev1.RestartPolicyAlways
+	}
+	if spec.DNSPolicy == "" {
+		spec.DNSPolicy = corev1.DNSClusterFirst
+	}
+	if spec.SchedulerName == "" {
+		spec.SchedulerName = corev1.DefaultSchedulerName
+	}
+	if spec.TerminationGracePeriodSeconds == nil {
+		period := int64(corev1.DefaultTerminationGracePeriodSeconds)
+		spec.TerminationGracePeriodSeconds = &period
+	}
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	for i := range spec.InitContainers {
+		setContainerDefaults(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		setContainerDefaults(&spec.Containers[i])
+	}
+}
+
+func setContainerDefaults(container *corev1.Container) {
+	if container.TerminationMessagePath == "" {
+		container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+	}
+	if container.TerminationMessagePolicy == "" {
+		container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	}
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = corev1.PullIfNotPresent
+		// images without a tag or digest are pulled like the latest tag
+		name := container.Image[strings.LastIndex(container.Image, "/")+1:]
+		if !strings.Contains(container.Image, "@") && (!strings.Contains(name, ":") || strings.HasSuffix(name, ":latest")) {
+			container.ImagePullPolicy = corev1.PullAlways
+		}
+	}
+	for i := range container.Ports {
+		if container.Ports[i].Protocol == "" {
+			container.Ports[i].Protocol = corev1.ProtocolTCP
+		}
+	}
+}
+
+func setServiceDefaults(service *corev1.Service) {
+	if service.Spec.Type == "" {
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+	}
+	if service.Spec.SessionAffinity == "" {
+		service.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	}
+	for i := range service.Spec.Ports {
+		port := &service.Spec.Ports[i]
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort == intstr.FromInt(0) || port.TargetPort == intstr.FromString("") {
+			port.TargetPort = intstr.FromInt(int(port.Port))
+		}
+	}
+}
+
+// withDefaults returns a copy of the expected object in which the fields set by the defaulting of the API server that
+// it omits are set to the defaults which the actual object also has, so that they are not reported as differences.
+// The defaults which the actual object does not have are not added, as omitted fields are not compared.  Objects of
+// kinds without defaults are returned unmodified.
+func withDefaults(expected runtime.Object, actual map[string]interface{}) runtime.Object {
+	typed, err := scheme.Scheme.New(expected.GetObjectKind().GroupVersionKind())
+	if err != nil {
+		return expected
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(expected)
+	if err != nil {
+		return expected
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, typed); err != nil {
+		return expected
+	}
+	before, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return expected
+	}
+	defaultingScheme.Default(typed)
+	after, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil || reflect.DeepEqual(before, after) {
+		return expected
+	}
+
+	content = runtime.DeepCopyJSON(content)
+	mergeDefaults(content, before, after, actual)
+	return &unstructured.Unstructured{Object: content}
+}
+
+// mergeDefaults sets the defaults of after which are not in before and omitted in expected, if actual has the same
+// values.  The slices of expected are merged element by element.
+func mergeDefaults(expected, before, after, actual interface{}) {
+	switch expectedValue := expected.(type) {
+	case map[string]interface{}:
+		beforeMap, _ := before.(map[string]interface{})
+		afterMap, _ := after.(map[string]interface{})
+		actualMap, _ := actual.(map[string]interface{})
+		for key, afterValue := range afterMap {
+			if value, ok := expectedValue[key]; ok {
+				mergeDefaults(value, beforeMap[key], afterValue, actualMap[key])
+				continue
+			}
+			defaults := defaultsOnly(beforeMap[key], afterValue)
+			if defaults == nil {
+				continue
+			}
+			if actualValue, ok := actualMap[key]; ok && testutils.IsSubset(defaults, actualValue) == nil {
+				expectedValue[key] = defaults
+			}
+		}
+	case []interface{}:
+		beforeSlice, _ := before.([]interface{})
+		afterSlice, _ := after.([]interface{})
+		actualSlice, _ := actual.([]interface{})
+		for i := range expectedValue {
+			if i < len(beforeSlice) && i < len(afterSlice) && i < len(actualSlice) {
+				mergeDefaults(expectedValue[i], beforeSlice[i], afterSlice[i], actualSlice[i])
+			}
+		}
+	}
+}
+
+// defaultsOnly returns the part of after which differs from before, or nil if they do not differ.
+func defaultsOnly(before, after interface{}) interface{} {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	beforeMap, beforeOk := before.(map[string]interface{})
+	afterMap, afterOk := after.(map[string]interface{})
+	if !beforeOk || !afterOk {
+		return after
+	}
+
+	defaults := map[string]interface{}{}
+	for key, value := range afterMap {
+		if d := defaultsOnly(beforeMap[key], value); d != nil {
+			defaults[key] = d
+		}
+	}
+	if len(defaults) == 0 {
+		return nil
+	}
+	return defaults
+}
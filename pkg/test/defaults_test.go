@@ -0,0 +1,93 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestWithDefaults(t *testing.T) {
+	expected := testutils.NewResource("apps/v1", "Deployment", "web", "")
+	expected.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "nginx", "image": "nginx:1.25", "ports": []interface{}{map[string]interface{}{"containerPort": int64(80)}}},
+				},
+			},
+		},
+	}
+	actual := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": testNamespace, "uid": "1234"},
+		"spec": map[string]interface{}{
+			"replicas":                int64(3),
+			"revisionHistoryLimit":    int64(10),
+			"progressDeadlineSeconds": int64(600),
+			"strategy":                map[string]interface{}{"type": "Recreate"},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"restartPolicy":                 "Always",
+					"dnsPolicy":                     "ClusterFirst",
+					"schedulerName":                 "default-scheduler",
+					"terminationGracePeriodSeconds": int64(30),
+					"securityContext":               map[string]interface{}{},
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":                     "nginx",
+							"image":                    "nginx:1.25",
+							"imagePullPolicy":          "IfNotPresent",
+							"terminationMessagePath":   "/dev/termination-log",
+							"terminationMessagePolicy": "File",
+							"ports":                    []interface{}{map[string]interface{}{"containerPort": int64(80), "protocol": "TCP"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	defaulted := withDefaults(expected, actual).(*unstructured.Unstructured)
+	// the defaults the actual object has are added, the others are not
+	assert.Equal(t, map[string]interface{}{
+		"revisionHistoryLimit":    int64(10),
+		"progressDeadlineSeconds": int64(600),
+		"template":                actual["spec"].(map[string]interface{})["template"],
+	}, defaulted.Object["spec"])
+	assert.NoError(t, testutils.IsSubset(defaulted.Object, actual))
+	// the expected object is not modified
+	assert.NotContains(t, expected.Object["spec"], "revisionHistoryLimit")
+
+	diff, err := testutils.PrettyDiff(defaulted, &unstructured.Unstructured{Object: actual})
+	require.NoError(t, err)
+	assert.NotContains(t, diff, "protocol")
+	assert.Contains(t, diff, "+  replicas: 3")
+
+	// kinds without defaults are not modified
+	crd := testutils.NewResource("example.com/v1", "Database", "db", "")
+	assert.Equal(t, runtime.Object(crd), withDefaults(crd, map[string]interface{}{}))
+	configMap := testutils.NewResource("v1", "ConfigMap", "config", "")
+	assert.Equal(t, runtime.Object(configMap), withDefaults(configMap, configMap.Object))
+}
+
+func TestContainerImagePullPolicyDefault(t *testing.T) {
+	for image, policy := range map[string]corev1.PullPolicy{
+		"nginx":                    corev1.PullAlways,
+		"nginx:latest":             corev1.PullAlways,
+		"nginx:1.25":               corev1.PullIfNotPresent,
+		"registry:5000/nginx":      corev1.PullAlways,
+		"registry:5000/nginx:1.25": corev1.PullIfNotPresent,
+		"nginx@sha256:0123":        corev1.PullIfNotPresent,
+	} {
+		container := corev1.Container{Image: image}
+		setContainerDefaults(&container)
+		assert.Equal(t, policy, container.ImagePullPolicy, image)
+	}
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ClusterDomainEnv is the environment variable of commands and the Jsonnet external variable set to the DNS domain
+	// of the cluster (ex. "cluster.local"), the DNS name of a service is <service>.$NAMESPACE.svc.$KUTTL_CLUSTER_DOMAIN.
+	ClusterDomainEnv = "KUTTL_CLUSTER_DOMAIN"
+	// APIServerEnv is the environment variable of commands and the Jsonnet external variable set to the in-cluster
+	// address of the API server.
+	APIServerEnv = "KUTTL_API_SERVER"
+)
+
+// defaultClusterDomain is the cluster domain if it is not set and cannot be detected.
+const defaultClusterDomain = "cluster.local"
+
+// kubeletConfigz is the part of the configuration of a kubelet read from its configz endpoint which is used.
+type kubeletConfigz struct {
+	KubeletConfig struct {
+		ClusterDomain string `json:"clusterDomain"`
+	} `json:"kubeletconfig"`
+}
+
+// detectClusterDomain returns the cluster domain configured in the kubelet of a node of the cluster.
+func detectClusterDomain(ctx context.Context, clientset kubernetes.Interface) (string, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return "", err
+	}
+	if len(nodes.Items) == 0 {
+		return "", errors.New("the cluster has no nodes")
+	}
+
+	name := nodes.Items[0].Name
+	raw, err := clientset.CoreV1().RESTClient().Get().AbsPath("/api/v1/nodes", name, "proxy", "configz").DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("reading the kubelet configuration of node %s: %w", name, err)
+	}
+	configz := kubeletConfigz{}
+	if err := json.Unmarshal(raw, &configz); err != nil {
+		return "", fmt.Errorf("decoding the kubelet configuration of node %s: %w", name, err)
+	}
+	if configz.KubeletConfig.ClusterDomain == "" {
+		return "", fmt.Errorf("the kubelet of node %s has no cluster domain", name)
+	}
+	return configz.KubeletConfig.ClusterDomain, nil
+}
+
+// apiServerAddress returns the in-cluster address of the API server of a cluster with the domain.
+func apiServerAddress(domain string) string {
+	return "https://kubernetes.default.svc." + domain
+}
+
+// setupClusterDNS sets the cluster domain and the address of the API server in the environment of the commands, the
+// cluster domain of the test suite is used if it is set, or else the one detected from the kubelets.
+func (h *Harness) setupClusterDNS() error {
+	h.clusterDomain = h.TestSuite.ClusterDomain
+	if h.clusterDomain == "" {
+		h.clusterDomain = defaultClusterDomain
+
+		cfg, err := h.Config()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			return err
+		}
+		if domain, err := detectClusterDomain(context.TODO(), clientset); err != nil {
+			h.T.Logf("detecting the cluster domain failed: %v", err)
+		} else {
+			h.clusterDomain = domain
+		}
+	}
+
+	h.T.Logf("using cluster domain %s", h.clusterDomain)
+	if err := os.Setenv(ClusterDomainEnv, h.clusterDomain); err != nil {
+		return err
+	}
+	return os.Setenv(APIServerEnv, apiServerAddress(h.clusterDomain))
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestDetectClusterDomain(t *testing.T) {
+	configz := `{"kubeletconfig":{"clusterDomain":"k8s.example"}}`
+	nodes := `{"kind":"NodeList","apiVersion":"v1","items":[{"metadata":{"name":"worker"}}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/nodes":
+			_, _ = w.Write([]byte(nodes))
+		case "/api/v1/nodes/worker/proxy/configz":
+			_, _ = w.Write([]byte(configz))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	domain, err := detectClusterDomain(context.TODO(), clientset)
+	require.NoError(t, err)
+	assert.Equal(t, "k8s.example", domain)
+
+	configz = `{"kubeletconfig":{}}`
+	_, err = detectClusterDomain(context.TODO(), clientset)
+	assert.EqualError(t, err, "the kubelet of node worker has no cluster domain")
+
+	nodes = `{"kind":"NodeList","apiVersion":"v1","items":[]}`
+	_, err = detectClusterDomain(context.TODO(), clientset)
+	assert.EqualError(t, err, "the cluster has no nodes")
+}
+
+func TestClusterDNSJsonnetVars(t *testing.T) {
+	h := &Harness{clusterDomain: "k8s.example"}
+	h.TestSuite.JsonnetVars = map[string]string{"env": "ci"}
+
+	assert.Equal(t, map[string]string{
+		"env":                  "ci",
+		"KUTTL_CLUSTER_DOMAIN": "k8s.example",
+		"KUTTL_API_SERVER":     "https://kubernetes.default.svc.k8s.example",
+	}, h.jsonnetVars())
+}
@@ -0,0 +1,236 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// driftChanges is the number of changes between match and mismatch of an asserted object, or of the writer of one of
+// its asserted fields, after which the object is reported as drifting.
+const driftChanges = 2
+
+// drift is what was observed of an asserted object while the asserts of a step were retried.
+type drift struct {
+	observed bool
+	matched  bool
+	// flips are the changes between match and mismatch.
+	flips int
+	// owners are the managers of the asserted fields in the last observation.
+	owners map[string]string
+	// ownerChanges are the changes of the managers of asserted fields.
+	ownerChanges int
+	// writers are the operations and the asserted fields of each manager which wrote the object.
+	writers  map[string]*fieldWriter
+	reported bool
+}
+
+// fieldWriter is a field manager which wrote asserted fields of an object.
+type fieldWriter struct {
+	operation metav1.ManagedFieldsOperationType
+	fields    map[string]bool
+}
+
+// observeDrift records whether each asserted object with a name matched in the last check of the asserts and the
+// managers of its asserted fields, and logs the writers of objects which keep changing.
+func (s *Step) observeDrift(namespace string) {
+	if s.drifts == nil {
+		s.drifts = map[string]*drift{}
+	}
+	unsatisfied := map[string]bool{}
+	for _, id := range s.unsatisfied {
+		unsatisfied[id] = true
+	}
+
+	for _, expected := range s.Asserts {
+		if expected.GetName() == "" {
+			continue
+		}
+		id := assertID(expected)
+		d, ok := s.drifts[id]
+		if !ok {
+			d = &drift{writers: map[string]*fieldWriter{}}
+			s.drifts[id] = d
+		}
+
+		matched := !unsatisfied[id]
+		if d.observed && matched != d.matched {
+			d.flips++
+		}
+		d.observed, d.matched = true, matched
+
+		actual, err := s.driftActual(expected, namespace)
+		if err != nil {
+			continue
+		}
+		d.observeOwners(assertedFields(expected), actual.GetManagedFields())
+
+		if !d.reported && (d.flips >= driftChanges || d.ownerChanges >= driftChanges) {
+			d.reported = true
+			s.Logger.Logf("drift: %s changed between match and mismatch %d time(s) and the writer of its asserted fields %d time(s), they were written by %s",
+				id, d.flips, d.ownerChanges, d.describeWriters())
+		}
+	}
+}
+
+// driftActual returns the object matching expected.
+func (s *Step) driftActual(expected client.Object, namespace string) (*unstructured.Unstructured, error) {
+	cl, err := s.Client(false)
+	if err != nil {
+		return nil, err
+	}
+	dClient, err := s.DiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// the namespace is set on a copy, the expected object is checked again
+	copied := expected.DeepCopyObject()
+	name, namespace, err := testutils.Namespaced(dClient, copied, namespace)
+	if err != nil {
+		return nil, err
+	}
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(expected.GetObjectKind().GroupVersionKind())
+	err = cl.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, actual)
+	return actual, err
+}
+
+// observeOwners records the managers of the asserted fields in the managed fields of the object.
+func (d *drift) observeOwners(asserted map[string]bool, managedFields []metav1.ManagedFieldsEntry) {
+	owners := map[string]string{}
+	for _, entry := range managedFields {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		for field := range managedFieldPaths("", fields) {
+			if !asserted[field] {
+				continue
+			}
+			owners[field] = entry.Manager
+			writer, ok := d.writers[entry.Manager]
+			if !ok {
+				writer = &fieldWriter{fields: map[string]bool{}}
+				d.writers[entry.Manager] = writer
+			}
+			writer.operation = entry.Operation
+			writer.fields[field] = true
+		}
+	}
+
+	if d.owners != nil {
+		for field, owner := range owners {
+			if previous, ok := d.owners[field]; ok && previous != owner {
+				d.ownerChanges++
+				break
+			}
+		}
+	}
+	d.owners = owners
+}
+
+// describeWriters describes the managers which wrote asserted fields with the fields, ex.
+// "my-operator (Update: .spec.replicas), hpa (Update: .spec.replicas)".
+func (d *drift) describeWriters() string {
+	if len(d.writers) == 0 {
+		return "no field manager of the asserted fields"
+	}
+	managers := make([]string, 0, len(d.writers))
+	for manager := range d.writers {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+
+	descriptions := make([]string, 0, len(managers))
+	for _, manager := range managers {
+		writer := d.writers[manager]
+		fields := make([]string, 0, len(writer.fields))
+		for field := range writer.fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s: %s)", manager, writer.operation, strings.Join(fields, ", ")))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// assertedFields returns the paths of the fields of an expected object, ex. ".spec.replicas".  The elements of lists
+// are denoted by "[]", and its identity and the kuttl annotations are left out.
+func assertedFields(expected runtime.Object) map[string]bool {
+	fields := map[string]bool{}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(expected)
+	if err != nil {
+		return fields
+	}
+
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for key, v := range value {
+				walk(path+"."+key, v)
+			}
+		case []interface{}:
+			for _, v := range value {
+				walk(path+"[]", v)
+			}
+		default:
+			fields[path] = true
+		}
+	}
+	for key, value := range content {
+		if key == "apiVersion" || key == "kind" {
+			continue
+		}
+		walk("."+key, value)
+	}
+
+	for field := range fields {
+		if field == ".metadata.name" || field == ".metadata.namespace" || strings.HasPrefix(field, ".metadata.annotations.kuttl.dev/") {
+			delete(fields, field)
+		}
+	}
+	return fields
+}
+
+// managedFieldPaths returns the paths of the fields of managed fields in the FieldsV1 format, in the form of
+// assertedFields.
+func managedFieldPaths(path string, fields map[string]interface{}) map[string]bool {
+	paths := map[string]bool{}
+	for key, value := range fields {
+		var child string
+		switch {
+		case key == ".":
+			continue
+		case strings.HasPrefix(key, "f:"):
+			child = path + "." + strings.TrimPrefix(key, "f:")
+		case strings.HasPrefix(key, "k:"), strings.HasPrefix(key, "v:"), strings.HasPrefix(key, "i:"):
+			child = path + "[]"
+		default:
+			continue
+		}
+
+		nested, _ := value.(map[string]interface{})
+		if len(nested) == 0 {
+			paths[child] = true
+			continue
+		}
+		for p := range managedFieldPaths(child, nested) {
+			paths[p] = true
+		}
+	}
+	return paths
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestAssertedFields(t *testing.T) {
+	expected := testutils.SetAnnotation(testutils.NewResource("apps/v1", "Deployment", "web", "default"), harness.TimeoutAnnotation, "60")
+	expected.Object["spec"] = map[string]interface{}{
+		"replicas": int64(3),
+		"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx"}},
+		}},
+	}
+
+	assert.Equal(t, map[string]bool{
+		".spec.replicas":                         true,
+		".spec.template.spec.containers[].name":  true,
+		".spec.template.spec.containers[].image": true,
+	}, assertedFields(expected))
+}
+
+func TestManagedFieldPaths(t *testing.T) {
+	fields := map[string]interface{}{
+		"f:metadata": map[string]interface{}{"f:finalizers": map[string]interface{}{`v:"example.com/cleanup"`: map[string]interface{}{}}},
+		"f:spec": map[string]interface{}{
+			"f:replicas": map[string]interface{}{},
+			"f:template": map[string]interface{}{"f:spec": map[string]interface{}{"f:containers": map[string]interface{}{
+				`k:{"name":"web"}`: map[string]interface{}{".": map[string]interface{}{}, "f:image": map[string]interface{}{}, "f:name": map[string]interface{}{}},
+			}}},
+		},
+	}
+
+	assert.Equal(t, map[string]bool{
+		".metadata.finalizers[]":                 true,
+		".spec.replicas":                         true,
+		".spec.template.spec.containers[].image": true,
+		".spec.template.spec.containers[].name":  true,
+	}, managedFieldPaths("", fields))
+}
+
+// driftStep returns a step asserting the replicas of the web Deployment, its client and the logged messages.
+func driftStep() (*Step, client.Client, *recordingLogger) {
+	actual := testutils.NewResource("apps/v1", "Deployment", "web", testNamespace)
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(actual).Build()
+	expected := testutils.NewResource("apps/v1", "Deployment", "web", "")
+	expected.Object["spec"] = map[string]interface{}{"replicas": int64(3)}
+
+	logger := &recordingLogger{}
+	return &Step{
+		Logger:          logger,
+		Asserts:         []client.Object{expected},
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}, cl, logger
+}
+
+// writeReplicas sets the replicas of the web Deployment as written by the manager.
+func writeReplicas(t *testing.T, cl client.Client, manager string) {
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(testutils.NewResource("apps/v1", "Deployment", "", "").GroupVersionKind())
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "web"}, actual))
+	actual.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager:    manager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+	}})
+	require.NoError(t, cl.Update(context.TODO(), actual))
+}
+
+func TestObserveDrift(t *testing.T) {
+	step, cl, logger := driftStep()
+	id := assertID(step.Asserts[0])
+
+	// the operator and the autoscaler keep taking over the replicas
+	for i, manager := range []string{"operator", "autoscaler", "operator"} {
+		writeReplicas(t, cl, manager)
+		step.unsatisfied = nil
+		if i%2 == 1 {
+			step.unsatisfied = []string{id}
+		}
+		step.observeDrift(testNamespace)
+	}
+	assert.Equal(t, []string{
+		"drift: Deployment:/web changed between match and mismatch 2 time(s) and the writer of its asserted fields 2 time(s), they were written by autoscaler (Update: .spec.replicas), operator (Update: .spec.replicas)",
+	}, logger.messages)
+
+	// the drift of an object is reported once
+	writeReplicas(t, cl, "autoscaler")
+	step.unsatisfied = []string{id}
+	step.observeDrift(testNamespace)
+	assert.Len(t, logger.messages, 1)
+}
+
+func TestObserveDriftSteady(t *testing.T) {
+	step, cl, logger := driftStep()
+
+	// the operator converges, the object does not drift
+	writeReplicas(t, cl, "operator")
+	step.unsatisfied = []string{assertID(step.Asserts[0])}
+	step.observeDrift(testNamespace)
+	for i := 0; i < 3; i++ {
+		step.unsatisfied = nil
+		step.observeDrift(testNamespace)
+	}
+	assert.Empty(t, logger.messages)
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// duplicateKey identifies an applied object, the version of its kind is ignored as it is the same object.
+func duplicateKey(obj client.Object) string {
+	return fmt.Sprintf("%s/%s/%s", obj.GetObjectKind().GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// resolveDuplicates handles the objects applied more than once by the files of the step according to the policy.
+// With lastWins, the last definition of an object replaces the first one, so that the order of the objects is kept,
+// and the objects which were replaced are returned.  Objects without a name are never duplicates.
+func (s *Step) resolveDuplicates(policy harness.DuplicateObjectPolicy) ([]string, error) {
+	switch policy {
+	case "", harness.DuplicateObjectsError, harness.DuplicateObjectsLastWins:
+	case harness.DuplicateObjectsApplyAll:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown duplicateObjects policy %q, it must be one of %s, %s or %s", policy,
+			harness.DuplicateObjectsError, harness.DuplicateObjectsLastWins, harness.DuplicateObjectsApplyAll)
+	}
+
+	first := map[string]int{}
+	applies := []client.Object{}
+	replaced := []string{}
+	for _, obj := range s.Apply {
+		if obj.GetName() == "" {
+			applies = append(applies, obj)
+			continue
+		}
+
+		key := duplicateKey(obj)
+		i, ok := first[key]
+		if !ok {
+			first[key] = len(applies)
+			applies = append(applies, obj)
+			continue
+		}
+
+		if policy != harness.DuplicateObjectsLastWins {
+			return nil, fmt.Errorf("step %s applies %s more than once%s, set duplicateObjects to %s or %s to allow it",
+				s.String(), testutils.ResourceID(obj), duplicateSources(applies[i], obj),
+				harness.DuplicateObjectsLastWins, harness.DuplicateObjectsApplyAll)
+		}
+		replaced = append(replaced, testutils.ResourceID(applies[i])+testutils.DescribeSource(applies[i]))
+		applies[i] = obj
+	}
+
+	s.Apply = applies
+	return replaced, nil
+}
+
+// duplicateSources returns ", in <source> and <source>" if the locations of both definitions of an object are known
+// and an empty string otherwise.
+func duplicateSources(first, second client.Object) string {
+	firstSource, ok := testutils.SourceOf(first)
+	if !ok {
+		return ""
+	}
+	secondSource, ok := testutils.SourceOf(second)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(", in %s and %s", firstSource, secondSource)
+}
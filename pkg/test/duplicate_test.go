@@ -0,0 +1,76 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestResolveDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"00-install.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: first
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: hello
+`,
+		"00-patch.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: second
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  generateName: hello-
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  generateName: hello-
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	c := &Case{Dir: dir}
+	err := c.LoadTestSteps()
+	assert.EqualError(t, err, "step 0-install applies ConfigMap:/settings more than once, in 00-install.yaml#doc1 (line 1) "+
+		"and 00-patch.yaml#doc1 (line 1), set duplicateObjects to lastWins or applyAll to allow it")
+
+	c = &Case{Dir: dir, DuplicateObjects: harness.DuplicateObjectsLastWins, Logger: testutils.NewTestLogger(t, "")}
+	require.NoError(t, c.LoadTestSteps())
+	require.Len(t, c.Steps, 1)
+	applies := c.Steps[0].Apply
+	// the last definition takes the place of the first one, objects with generated names are all applied
+	require.Len(t, applies, 4)
+	assert.Equal(t, "settings", applies[0].GetName())
+	mode, _, _ := unstructured.NestedString(applies[0].(*unstructured.Unstructured).Object, "data", "mode")
+	assert.Equal(t, "second", mode)
+	assert.Equal(t, "hello", applies[1].GetName())
+	assert.Equal(t, "hello-", applies[2].GetGenerateName())
+
+	c = &Case{Dir: dir, DuplicateObjects: harness.DuplicateObjectsApplyAll}
+	require.NoError(t, c.LoadTestSteps())
+	assert.Len(t, c.Steps[0].Apply, 5)
+
+	c = &Case{Dir: dir, DuplicateObjects: "merge"}
+	assert.EqualError(t, c.LoadTestSteps(), `unknown duplicateObjects policy "merge", it must be one of error, lastWins or applyAll`)
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"fmt"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// defaultEscalationPercent is the share of the timeout of a step at which the collectors of an escalation run if it
+// does not set one.
+const defaultEscalationPercent = 80
+
+// validateEscalation returns an error if the share of the timeout of the escalation is out of range.
+func validateEscalation(escalation *harness.AssertEscalation) error {
+	if escalation == nil {
+		return nil
+	}
+	if escalation.Percent < 0 || escalation.Percent >= 100 {
+		return fmt.Errorf("percent %d is not between 1 and 99", escalation.Percent)
+	}
+	return nil
+}
+
+// escalate runs the collectors of the escalation of the TestAssert of the step if the asserts have been failing for its
+// share of the timeout, it returns whether it ran them so that they run once.
+func (s *Step) escalate(namespace string, elapsed, timeout float64) bool {
+	if s.Assert == nil || s.Assert.Escalation == nil || len(s.Assert.Escalation.Collectors) == 0 {
+		return false
+	}
+	percent := s.Assert.Escalation.Percent
+	if percent == 0 {
+		percent = defaultEscalationPercent
+	}
+	if elapsed < timeout*float64(percent)/100 {
+		return false
+	}
+
+	s.Logger.Logf("asserts of step %s still failing after %.0fs, %d%% of the timeout of %.0fs: collecting diagnostics",
+		s.String(), elapsed, percent, timeout)
+	s.runCollectors(namespace, s.Assert.Escalation.Collectors)
+	s.Logger.Flush()
+	return true
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestEscalate(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "diagnostics")
+	collectors := []*harness.TestCollector{{Cmd: "touch " + marker}}
+
+	for _, test := range []struct {
+		name       string
+		escalation *harness.AssertEscalation
+		elapsed    float64
+		escalated  bool
+	}{
+		{name: "no escalation", elapsed: 29},
+		{name: "no collectors", escalation: &harness.AssertEscalation{}, elapsed: 29},
+		{name: "before the default percent", escalation: &harness.AssertEscalation{Collectors: collectors}, elapsed: 23},
+		{name: "at the default percent", escalation: &harness.AssertEscalation{Collectors: collectors}, elapsed: 24, escalated: true},
+		{name: "before the percent", escalation: &harness.AssertEscalation{Percent: 50, Collectors: collectors}, elapsed: 14},
+		{name: "after the percent", escalation: &harness.AssertEscalation{Percent: 50, Collectors: collectors}, elapsed: 16, escalated: true},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			defer os.Remove(marker)
+			step := &Step{
+				Assert: &harness.TestAssert{Escalation: test.escalation},
+				Logger: testutils.NewTestLogger(t, test.name),
+				Dir:    dir,
+			}
+
+			assert.Equal(t, test.escalated, step.escalate(testNamespace, test.elapsed, 30))
+			if test.escalated {
+				assert.FileExists(t, marker)
+			} else {
+				assert.NoFileExists(t, marker)
+			}
+		})
+	}
+}
+
+func TestValidateEscalation(t *testing.T) {
+	assert.NoError(t, validateEscalation(nil))
+	assert.NoError(t, validateEscalation(&harness.AssertEscalation{}))
+	assert.NoError(t, validateEscalation(&harness.AssertEscalation{Percent: 90}))
+	assert.EqualError(t, validateEscalation(&harness.AssertEscalation{Percent: 100}), "percent 100 is not between 1 and 99")
+}
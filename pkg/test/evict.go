@@ -0,0 +1,158 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// evictionInterval is the interval at which denied evictions expected to be allowed are retried.
+var evictionInterval = time.Second
+
+// Evict evicts the pods of the evictions of the step and checks that each eviction had the expected result.
+func (s *Step) Evict(namespace string) []error {
+	if s.Step == nil || len(s.Step.Evictions) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, eviction := range s.Step.Evictions {
+		errs = append(errs, s.evict(cl, eviction, namespace)...)
+	}
+	return errs
+}
+
+// evict evicts the pods of the eviction, the pods which are already being deleted are skipped.
+func (s *Step) evict(cl client.Client, eviction harness.Eviction, namespace string) []error {
+	pods, err := evictionPods(cl, eviction, namespace)
+	if err != nil {
+		return []error{err}
+	}
+
+	expect := eviction.Expect
+	switch expect {
+	case "":
+		expect = harness.EvictionAllowed
+	case harness.EvictionAllowed, harness.EvictionDenied:
+	default:
+		return []error{fmt.Errorf("unknown expected eviction result %q, it must be %s or %s", expect, harness.EvictionAllowed, harness.EvictionDenied)}
+	}
+
+	timeout := eviction.Timeout
+	if timeout == 0 {
+		timeout = s.GetTimeout()
+	}
+
+	errs := []error{}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if !eviction.DryRun {
+			if err := s.checkSafe("evict", pod); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if err := s.evictPod(cl, pod, expect, eviction.DryRun, time.Duration(timeout)*time.Second); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// evictPod evicts the pod and fails unless the result is the expected one.  Denied evictions are retried until the
+// timeout if they are expected to be allowed.
+func (s *Step) evictPod(cl client.Client, pod *corev1.Pod, expect harness.EvictionResult, dryRun bool, timeout time.Duration) error {
+	id := fmt.Sprintf("pod %s/%s", pod.Namespace, pod.Name)
+	var denied error
+	err := wait.PollImmediate(evictionInterval, timeout, func() (bool, error) {
+		err := cl.SubResource("eviction").Create(context.TODO(), pod, evictionFor(pod, dryRun))
+		switch {
+		case err == nil:
+			return true, nil
+		case k8serrors.IsTooManyRequests(err):
+			denied = err
+			return expect == harness.EvictionDenied, nil
+		default:
+			return false, err
+		}
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("eviction of %s was denied: %v", id, denied)
+	}
+	if err != nil {
+		return fmt.Errorf("evicting %s: %w", id, err)
+	}
+
+	if expect == harness.EvictionDenied {
+		if denied == nil {
+			return fmt.Errorf("eviction of %s was allowed, expected it to be denied", id)
+		}
+		s.Logger.Logf("eviction of %s was denied as expected: %v", id, denied)
+		return nil
+	}
+	s.Logger.Logf("evicted %s", id)
+	return nil
+}
+
+// evictionFor returns the eviction of the pod.
+func evictionFor(pod *corev1.Pod, dryRun bool) *policyv1.Eviction {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	}
+	if dryRun {
+		eviction.DeleteOptions = &metav1.DeleteOptions{DryRun: []string{metav1.DryRunAll}}
+	}
+	return eviction
+}
+
+// evictionPods returns the pods the eviction evicts.
+func evictionPods(cl client.Client, eviction harness.Eviction, namespace string) ([]corev1.Pod, error) {
+	if eviction.Namespace != "" {
+		namespace = eviction.Namespace
+	}
+
+	if eviction.Name != "" {
+		if eviction.Selector != "" {
+			return nil, fmt.Errorf("eviction of pod %s has a selector, only one of name or selector may be set", eviction.Name)
+		}
+		pod := corev1.Pod{}
+		if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: eviction.Name}, &pod); err != nil {
+			return nil, fmt.Errorf("getting pod %s/%s to evict: %w", namespace, eviction.Name, err)
+		}
+		return []corev1.Pod{pod}, nil
+	}
+
+	if eviction.Selector == "" {
+		return nil, fmt.Errorf("eviction has neither a name nor a selector")
+	}
+	selector, err := labels.Parse(eviction.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid eviction selector %q: %w", eviction.Selector, err)
+	}
+	pods := &corev1.PodList{}
+	if err := cl.List(context.TODO(), pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("listing pods to evict: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods to evict match selector %q in namespace %s", eviction.Selector, namespace)
+	}
+	return pods.Items, nil
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// evictingClient serves the eviction subresource of pods, which the fake client does not support.  Pods labeled
+// protected=true are protected by a PodDisruptionBudget for the first denials evictions, the others are deleted.
+type evictingClient struct {
+	client.Client
+	denials int
+}
+
+func (c *evictingClient) SubResource(subResource string) client.SubResourceClient {
+	return &evictor{client: c}
+}
+
+type evictor struct {
+	client.SubResourceClient
+	client *evictingClient
+}
+
+func (e *evictor) Create(ctx context.Context, obj, subResource client.Object, _ ...client.SubResourceCreateOption) error {
+	if obj.GetLabels()["protected"] == "true" && e.client.denials > 0 {
+		e.client.denials--
+		return k8serrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+	}
+	if eviction := subResource.(*policyv1.Eviction); eviction.DeleteOptions != nil && len(eviction.DeleteOptions.DryRun) > 0 {
+		return nil
+	}
+	return e.client.Delete(ctx, obj)
+}
+
+func TestEvict(t *testing.T) {
+	evictionInterval = 10 * time.Millisecond
+	defer func() { evictionInterval = time.Second }()
+
+	pods := []client.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: testNamespace, Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: testNamespace, Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: testNamespace, Labels: map[string]string{"app": "db", "protected": "true"}}},
+	}
+
+	for _, test := range []struct {
+		name     string
+		eviction harness.Eviction
+		denials  int
+		errs     []string
+		evicted  []string
+	}{
+		{
+			name:     "allowed",
+			eviction: harness.Eviction{Selector: "app=web"},
+			evicted:  []string{"web-0", "web-1"},
+		},
+		{
+			name:     "allowed once the budget is up to date",
+			eviction: harness.Eviction{Name: "db-0"},
+			denials:  2,
+			evicted:  []string{"db-0"},
+		},
+		{
+			name:     "denied",
+			eviction: harness.Eviction{Name: "db-0", Expect: harness.EvictionDenied},
+			denials:  100,
+		},
+		{
+			name:     "dry run",
+			eviction: harness.Eviction{Selector: "app=web", DryRun: true},
+		},
+		{
+			name:     "unexpectedly denied",
+			eviction: harness.Eviction{Selector: "app=db", Timeout: 1},
+			denials:  1000,
+			errs:     []string{"eviction of pod world/db-0 was denied: Cannot evict pod as it would violate the pod's disruption budget."},
+		},
+		{
+			name:     "unexpectedly allowed",
+			eviction: harness.Eviction{Selector: "app=web", Expect: harness.EvictionDenied},
+			errs: []string{
+				"eviction of pod world/web-0 was allowed, expected it to be denied",
+				"eviction of pod world/web-1 was allowed, expected it to be denied",
+			},
+			evicted: []string{"web-0", "web-1"},
+		},
+		{
+			name:     "no pods",
+			eviction: harness.Eviction{Selector: "app=cache"},
+			errs:     []string{`no pods to evict match selector "app=cache" in namespace world`},
+		},
+		{
+			name:     "missing pod",
+			eviction: harness.Eviction{Name: "cache-0"},
+			errs:     []string{`getting pod world/cache-0 to evict: pods "cache-0" not found`},
+		},
+		{
+			name:     "name and selector",
+			eviction: harness.Eviction{Name: "web-0", Selector: "app=web"},
+			errs:     []string{"eviction of pod web-0 has a selector, only one of name or selector may be set"},
+		},
+		{
+			name:     "unknown result",
+			eviction: harness.Eviction{Name: "web-0", Expect: "maybe"},
+			errs:     []string{`unknown expected eviction result "maybe", it must be allowed or denied`},
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := &evictingClient{
+				Client:  fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(pods...).Build(),
+				denials: test.denials,
+			}
+
+			step := &Step{
+				Timeout: 5,
+				Logger:  testutils.NewTestLogger(t, ""),
+				Client:  func(bool) (client.Client, error) { return cl, nil },
+				Step:    &harness.TestStep{Evictions: []harness.Eviction{test.eviction}},
+			}
+
+			errs := step.Evict(testNamespace)
+			messages := []string{}
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+			if test.errs == nil {
+				test.errs = []string{}
+			}
+			assert.Equal(t, test.errs, messages)
+
+			evicted := []string{}
+			for _, pod := range pods {
+				err := cl.Get(context.TODO(), client.ObjectKeyFromObject(pod), &corev1.Pod{})
+				if k8serrors.IsNotFound(err) {
+					evicted = append(evicted, pod.GetName())
+				} else {
+					require.NoError(t, err)
+				}
+			}
+			if test.evicted == nil {
+				test.evicted = []string{}
+			}
+			assert.Equal(t, test.evicted, evicted)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+// expectedFailure returns why the step is expected to fail, it is nil if the step is expected to pass.
+func (s *Step) expectedFailure() *harness.ExpectedFailure {
+	if s.Step == nil {
+		return nil
+	}
+	return s.Step.ExpectedFailure
+}
+
+// describeExpectedFailure returns the reason and the tracking link of an expected failure.
+func describeExpectedFailure(e *harness.ExpectedFailure) string {
+	parts := []string{}
+	if e.Reason != "" {
+		parts = append(parts, e.Reason)
+	}
+	if e.Link != "" {
+		parts = append(parts, fmt.Sprintf("(%s)", e.Link))
+	}
+	if len(parts) == 0 {
+		return "no reason given"
+	}
+	return strings.Join(parts, " ")
+}
+
+// checkExpectedFailure records the result of a step which is expected to fail in the report of the test: failing is
+// reported as an expected failure, passing is an unexpected pass and returns the error failing the test.
+func (t *Case) checkExpectedFailure(tc *report.Testcase, step *Step, errs []error) error {
+	reason := describeExpectedFailure(step.expectedFailure())
+
+	if len(errs) > 0 {
+		t.Logger.Logf("step %s failed as expected: %s", step.String(), reason)
+		for _, err := range errs {
+			t.Logger.Log(err)
+		}
+		tc.Skipped = &report.Skipped{Message: fmt.Sprintf("expected failure in step %s: %s", step.String(), reason), Type: report.ExpectedFailure}
+		return nil
+	}
+
+	caseErr := fmt.Errorf("step %s passed unexpectedly, it is expected to fail: %s", step.String(), reason)
+	tc.Failure = &report.Failure{Message: caseErr.Error(), Type: report.UnexpectedPass}
+	return caseErr
+}
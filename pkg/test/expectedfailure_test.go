@@ -0,0 +1,43 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestDescribeExpectedFailure(t *testing.T) {
+	assert.Equal(t, "no reason given", describeExpectedFailure(&harness.ExpectedFailure{}))
+	assert.Equal(t, "flaky webhook", describeExpectedFailure(&harness.ExpectedFailure{Reason: "flaky webhook"}))
+	assert.Equal(t, "flaky webhook (https://github.com/org/repo/issues/1)",
+		describeExpectedFailure(&harness.ExpectedFailure{Reason: "flaky webhook", Link: "https://github.com/org/repo/issues/1"}))
+}
+
+func TestCheckExpectedFailure(t *testing.T) {
+	step := &Step{
+		Index: 1,
+		Name:  "upgrade",
+		Step:  &harness.TestStep{ExpectedFailure: &harness.ExpectedFailure{Reason: "known bug", Link: "#123"}},
+	}
+	assert.Nil(t, (&Step{}).expectedFailure())
+	assert.NotNil(t, step.expectedFailure())
+
+	tcase := &Case{Logger: testutils.NewTestLogger(t, "")}
+
+	tc := report.NewCase("upgrade")
+	require.NoError(t, tcase.checkExpectedFailure(tc, step, []error{errors.New("deployment not ready")}))
+	assert.Nil(t, tc.Failure)
+	assert.Equal(t, &report.Skipped{Message: "expected failure in step 1-upgrade: known bug (#123)", Type: report.ExpectedFailure}, tc.Skipped)
+
+	tc = report.NewCase("upgrade")
+	err := tcase.checkExpectedFailure(tc, step, nil)
+	assert.EqualError(t, err, "step 1-upgrade passed unexpectedly, it is expected to fail: known bug (#123)")
+	assert.Nil(t, tc.Skipped)
+	assert.Equal(t, &report.Failure{Message: err.Error(), Type: report.UnexpectedPass}, tc.Failure)
+}
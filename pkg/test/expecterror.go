@@ -0,0 +1,77 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// patchTypes are the patch types of expected errors by name.
+var patchTypes = map[string]types.PatchType{
+	"":          types.MergePatchType,
+	"merge":     types.MergePatchType,
+	"strategic": types.StrategicMergePatchType,
+	"json":      types.JSONPatchType,
+}
+
+// CheckExpectErrors applies the patches of the TestAssert of the step which the API server is expected to reject.  The
+// patches are dry runs, so that an accepted patch changes nothing.
+func (s *Step) CheckExpectErrors(namespace string) []error {
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, expected := range s.Assert.ExpectErrors {
+		if err := checkExpectError(context.TODO(), cl, expected, namespace); err != nil {
+			errs = append(errs, fmt.Errorf("expected error of %s %s: %w", expected.Kind, expected.Name, err))
+		}
+	}
+	return errs
+}
+
+// checkExpectError applies the patch of expected and returns an error unless the API server rejects it with a matching
+// message.  A missing object is an error, rather than a rejection of the patch.
+func checkExpectError(ctx context.Context, cl client.Client, expected harness.ExpectError, namespace string) error {
+	patchType, ok := patchTypes[expected.Type]
+	if !ok {
+		return fmt.Errorf("unknown patch type %q", expected.Type)
+	}
+	message, err := regexp.Compile(expected.Message)
+	if err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	patch, err := yaml.ToJSON([]byte(expected.Patch))
+	if err != nil {
+		return fmt.Errorf("invalid patch: %w", err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(expected.APIVersion, expected.Kind))
+	obj.SetName(expected.Name)
+	obj.SetNamespace(expected.Namespace)
+	if expected.Namespace == "" {
+		obj.SetNamespace(namespace)
+	}
+
+	err = cl.Patch(ctx, obj, client.RawPatch(patchType, patch), client.DryRunAll)
+	switch {
+	case err == nil:
+		return fmt.Errorf("the patch was accepted, expected an error matching %q", expected.Message)
+	case k8serrors.IsNotFound(err):
+		return err
+	case !message.MatchString(err.Error()):
+		return fmt.Errorf("the patch was rejected with %q, expected an error matching %q", err.Error(), expected.Message)
+	}
+	return nil
+}
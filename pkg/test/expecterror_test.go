@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// immutableClient rejects patches changing the storage class of PersistentVolumeClaims like the API server, which the
+// fake client does not validate.
+type immutableClient struct {
+	client.Client
+}
+
+func (c *immutableClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if len((&client.PatchOptions{}).ApplyOptions(opts).DryRun) == 0 {
+		return k8serrors.NewBadRequest("not a dry run")
+	}
+	if err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj.DeepCopyObject().(client.Object)); err != nil {
+		return err
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(data), "storageClassName") {
+		return k8serrors.NewInvalid(schema.GroupKind{Kind: "PersistentVolumeClaim"}, obj.GetName(), field.ErrorList{
+			field.Forbidden(field.NewPath("spec"), "spec is immutable after creation except resources.requests for bound claims"),
+		})
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestCheckExpectErrors(t *testing.T) {
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: testNamespace},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "volume"},
+	}
+	cl := &immutableClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(claim).Build()}
+	expectError := func(patch, message string) harness.ExpectError {
+		return harness.ExpectError{APIVersion: "v1", Kind: "PersistentVolumeClaim", Name: "data", Patch: patch, Message: message}
+	}
+
+	for _, test := range []struct {
+		name     string
+		expected harness.ExpectError
+		err      string
+	}{
+		{
+			name:     "rejected",
+			expected: expectError("spec: {storageClassName: other}", "spec is immutable"),
+		},
+		{
+			name:     "any message",
+			expected: expectError(`{"spec": {"storageClassName": "other"}}`, ""),
+		},
+		{
+			name:     "other message",
+			expected: expectError("spec: {storageClassName: other}", "must be positive"),
+			err:      `the patch was rejected with "PersistentVolumeClaim \"data\" is invalid: spec: Forbidden: spec is immutable after creation except resources.requests for bound claims", expected an error matching "must be positive"`,
+		},
+		{
+			name:     "accepted",
+			expected: expectError("spec: {volumeName: other}", "spec is immutable"),
+			err:      `the patch was accepted, expected an error matching "spec is immutable"`,
+		},
+		{
+			name: "json patch",
+			expected: func() harness.ExpectError {
+				expected := expectError(`[{"op": "replace", "path": "/spec/storageClassName", "value": "other"}]`, "immutable")
+				expected.Type = "json"
+				return expected
+			}(),
+		},
+		{
+			name: "not found",
+			expected: func() harness.ExpectError {
+				expected := expectError("spec: {storageClassName: other}", "")
+				expected.Namespace = "other"
+				return expected
+			}(),
+			err: `persistentvolumeclaims "data" not found`,
+		},
+		{
+			name: "unknown type",
+			expected: func() harness.ExpectError {
+				expected := expectError("spec: {}", "")
+				expected.Type = "apply"
+				return expected
+			}(),
+			err: `unknown patch type "apply"`,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := checkExpectError(context.TODO(), cl, test.expected, testNamespace)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+
+	// the accepted patch is a dry run
+	actual := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(claim), actual))
+	assert.Equal(t, "volume", actual.Spec.VolumeName)
+
+	step := Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) { return cl, nil },
+		Assert: &harness.TestAssert{ExpectErrors: []harness.ExpectError{
+			expectError("spec: {storageClassName: other}", "immutable"),
+			expectError("spec: {volumeName: other}", "immutable"),
+		}},
+	}
+	errs := step.CheckExpectErrors(testNamespace)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `expected error of PersistentVolumeClaim data: the patch was accepted, expected an error matching "immutable"`)
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/thoas/go-funk"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// expressionSelf is the variable of the whole object in the CEL expressions of asserts.
+const expressionSelf = "self"
+
+// expressionFields are the top-level fields of objects which are variables of every expression, so that an expression
+// using a field the object does not have yet, ex. status, fails when it is evaluated rather than when it is compiled.
+var expressionFields = []string{"apiVersion", "kind", "metadata", "spec", "status"}
+
+// expressionEnv returns the CEL environment of the expressions of asserts, with variables for the given fields of
+// the object.
+func expressionEnv(fields []string) (*cel.Env, error) {
+	opts := []cel.EnvOption{ext.Strings(), cel.CrossTypeNumericComparisons(true), cel.Variable(expressionSelf, cel.DynType)}
+	for _, field := range fields {
+		opts = append(opts, cel.Variable(field, cel.DynType))
+	}
+	return cel.NewEnv(opts...)
+}
+
+// validateExpressionAssertions returns an error if the expression of an expression assertion does not parse, so that
+// it fails when the step is loaded rather than when its asserts time out.  The expressions are only type checked
+// against the fetched objects, whose fields are not known before.
+func validateExpressionAssertions(assertions []harness.ExpressionAssertion) error {
+	env, err := expressionEnv(expressionFields)
+	if err != nil {
+		return err
+	}
+	for _, assertion := range assertions {
+		if assertion.Expression == "" {
+			return fmt.Errorf("expression assertion of %s %s: the expression is empty", assertion.Kind, assertion.Name)
+		}
+		if _, issues := env.Parse(assertion.Expression); issues.Err() != nil {
+			return fmt.Errorf("expression assertion of %s %s: %w", assertion.Kind, assertion.Name, issues.Err())
+		}
+	}
+	return nil
+}
+
+// CheckExpressionAssertions checks the expression assertions of the TestAssert of the step.
+func (s *Step) CheckExpressionAssertions(namespace string) []error {
+	if s.Assert == nil || len(s.Assert.Expressions) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, assertion := range s.Assert.Expressions {
+		if err := checkExpressionAssertion(context.TODO(), cl, assertion, namespace); err != nil {
+			s.unsatisfied = append(s.unsatisfied, expressionAssertionID(assertion, namespace))
+			errs = append(errs, fmt.Errorf("expression %q of %s %s: %w", assertion.Expression, assertion.Kind, assertion.Name, err))
+		}
+	}
+	return errs
+}
+
+// expressionAssertionID identifies an expression assertion in the progress of the asserts.
+func expressionAssertionID(assertion harness.ExpressionAssertion, namespace string) string {
+	if assertion.Namespace != "" {
+		namespace = assertion.Namespace
+	}
+	return fmt.Sprintf("%s:%s/%s %s", assertion.Kind, namespace, assertion.Name, assertion.Expression)
+}
+
+// checkExpressionAssertion gets the object of the assertion and evaluates its expression against it, the expression
+// must be true.
+func checkExpressionAssertion(ctx context.Context, cl client.Client, assertion harness.ExpressionAssertion, namespace string) error {
+	obj, err := getAssertedObject(ctx, cl, assertion.APIVersion, assertion.Kind, assertion.Name, assertion.Namespace, namespace)
+	if err != nil {
+		return err
+	}
+
+	// the fields of the object are variables, a field named self is only available as self.self
+	variables := map[string]interface{}{expressionSelf: obj.Object}
+	fields := append([]string{}, expressionFields...)
+	for field, value := range obj.Object {
+		if field == expressionSelf {
+			continue
+		}
+		variables[field] = value
+		if !funk.ContainsString(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	env, err := expressionEnv(fields)
+	if err != nil {
+		return err
+	}
+	ast, issues := env.Compile(assertion.Expression)
+	if issues.Err() != nil {
+		return issues.Err()
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return err
+	}
+
+	result, _, err := program.Eval(variables)
+	if err != nil {
+		return err
+	}
+	passed, ok := result.Value().(bool)
+	if !ok {
+		return fmt.Errorf("the expression evaluates to %v, expected true or false", result.Value())
+	}
+	if !passed {
+		if assertion.Message != "" {
+			return errors.New(assertion.Message)
+		}
+		return errors.New("the expression is false")
+	}
+	return nil
+}
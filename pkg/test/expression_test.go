@@ -0,0 +1,91 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCheckExpressionAssertions(t *testing.T) {
+	replicas := int32(3)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ReadyReplicas: 3,
+			Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable"},
+			},
+		},
+	}
+	pod := testutils.NewV1Pod("db", testNamespace, "")
+	pod.Status.Phase = corev1.PodRunning
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment, pod).Build()
+	expression := func(expression string) harness.ExpressionAssertion {
+		return harness.ExpressionAssertion{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Expression: expression}
+	}
+
+	for _, test := range []struct {
+		name      string
+		assertion harness.ExpressionAssertion
+		err       string
+	}{
+		{name: "relation", assertion: expression("status.readyReplicas == spec.replicas")},
+		{name: "and", assertion: expression("status.readyReplicas == spec.replicas && status.conditions.exists(c, c.type == 'Available' && c.status == 'True')")},
+		{name: "or", assertion: expression("status.readyReplicas == 0 || status.readyReplicas >= spec.replicas")},
+		{name: "arithmetic", assertion: expression("status.readyReplicas * 2 > spec.replicas + 1")},
+		{name: "strings", assertion: expression("self.metadata.name.startsWith('w') && metadata.name.upperAscii() == 'WEB'")},
+		{name: "phase", assertion: harness.ExpressionAssertion{APIVersion: "v1", Kind: "Pod", Name: "db",
+			Expression: "status.phase != 'Failed' && status.phase in ['Pending', 'Running']"}},
+		{name: "false", assertion: expression("status.readyReplicas < spec.replicas"), err: "the expression is false"},
+		{name: "message", assertion: harness.ExpressionAssertion{APIVersion: "apps/v1", Kind: "Deployment", Name: "web",
+			Expression: "status.readyReplicas > spec.replicas", Message: "too few replicas are ready"}, err: "too few replicas are ready"},
+		{name: "missing field", assertion: expression("status.unavailableReplicas == 0"), err: "no such key: unavailableReplicas"},
+		{name: "not a boolean", assertion: expression("spec.replicas"), err: "the expression evaluates to 3, expected true or false"},
+		{name: "undeclared field", assertion: expression("data.key == 'value'"), err: "undeclared reference to 'data'"},
+		{name: "missing object", assertion: harness.ExpressionAssertion{APIVersion: "apps/v1", Kind: "Deployment", Name: "api", Expression: "true"},
+			err: `deployments.apps "api" not found`},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			step := &Step{
+				Assert: &harness.TestAssert{Expressions: []harness.ExpressionAssertion{test.assertion}},
+				Logger: testutils.NewTestLogger(t, test.name),
+				Client: func(bool) (client.Client, error) { return cl, nil },
+			}
+
+			errs := step.CheckExpressionAssertions(testNamespace)
+			if test.err == "" {
+				assert.Empty(t, errs)
+				assert.Empty(t, step.unsatisfied)
+			} else {
+				require.Len(t, errs, 1)
+				assert.ErrorContains(t, errs[0], test.err)
+				assert.Equal(t, []string{test.assertion.Kind + ":" + testNamespace + "/" + test.assertion.Name + " " + test.assertion.Expression}, step.unsatisfied)
+			}
+		})
+	}
+}
+
+func TestValidateExpressionAssertions(t *testing.T) {
+	expression := func(expression string) []harness.ExpressionAssertion {
+		return []harness.ExpressionAssertion{{Kind: "Deployment", Name: "web", Expression: expression}}
+	}
+
+	assert.NoError(t, validateExpressionAssertions(expression("status.readyReplicas == spec.replicas && status.phase != 'Failed'")))
+	// the fields are only known once the object is fetched
+	assert.NoError(t, validateExpressionAssertions(expression("data.key == 'value'")))
+	assert.EqualError(t, validateExpressionAssertions(expression("")), "expression assertion of Deployment web: the expression is empty")
+	assert.ErrorContains(t, validateExpressionAssertions(expression("status.readyReplicas ==")), "expression assertion of Deployment web: ERROR")
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	coretesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// clusterScopedKinds are the built-in kinds without a namespace which fixtures may contain, the kinds of fixtures
+// which are not known to the fake discovery client are namespaced unless a CRD fixture defines them.
+var clusterScopedKinds = map[string]bool{
+	"Node":               true,
+	"PersistentVolume":   true,
+	"ClusterRole":        true,
+	"ClusterRoleBinding": true,
+	"StorageClass":       true,
+	"IngressClass":       true,
+	"PriorityClass":      true,
+}
+
+// A FakeCluster is an in-memory cluster holding fixture objects, the asserts and errors of steps can be checked against
+// it without an API server, ex. to unit test assert files in milliseconds.  Its discovery serves the kinds of the fake
+// discovery client of the unit tests of kuttl and those of the fixtures.
+type FakeCluster struct {
+	client    client.WithWatch
+	discovery discovery.DiscoveryInterface
+}
+
+// NewFakeCluster returns a fake cluster holding the fixtures, the namespaced fixtures without a namespace are created
+// in the namespace.
+func NewFakeCluster(namespace string, fixtures ...client.Object) (*FakeCluster, error) {
+	base, ok := testutils.FakeDiscoveryClient().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		return nil, errors.New("the fake discovery client has an unexpected type")
+	}
+	resources := map[string]*metav1.APIResourceList{}
+	order := []string{}
+	for _, list := range base.Resources {
+		copied := list.DeepCopy()
+		resources[list.GroupVersion] = copied
+		order = append(order, list.GroupVersion)
+	}
+	known := func(gvk schema.GroupVersionKind) bool {
+		if list, ok := resources[gvk.GroupVersion().String()]; ok {
+			for _, resource := range list.APIResources {
+				if resource.Kind == gvk.Kind {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	add := func(gvk schema.GroupVersionKind, namespaced bool) {
+		if known(gvk) {
+			return
+		}
+		groupVersion := gvk.GroupVersion().String()
+		list, ok := resources[groupVersion]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: groupVersion}
+			resources[groupVersion] = list
+			order = append(order, groupVersion)
+		}
+		plural, _ := meta.UnsafeGuessKindToResource(gvk)
+		list.APIResources = append(list.APIResources, metav1.APIResource{Name: plural.Resource, Namespaced: namespaced, Kind: gvk.Kind})
+	}
+
+	// the kinds defined by CRD fixtures have the scope of their definition
+	for _, fixture := range fixtures {
+		if fixture.GetObjectKind().GroupVersionKind().Kind != "CustomResourceDefinition" {
+			continue
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(fixture)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", testutils.ResourceID(fixture), err)
+		}
+		group, _, _ := unstructured.NestedString(content, "spec", "group")
+		kind, _, _ := unstructured.NestedString(content, "spec", "names", "kind")
+		scope, _, _ := unstructured.NestedString(content, "spec", "scope")
+		versions, _, _ := unstructured.NestedSlice(content, "spec", "versions")
+		for _, version := range versions {
+			version, _ := version.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(version, "name")
+			add(schema.GroupVersionKind{Group: group, Version: name, Kind: kind}, scope != "Cluster")
+		}
+	}
+	for _, fixture := range fixtures {
+		gvk := fixture.GetObjectKind().GroupVersionKind()
+		add(gvk, !clusterScopedKinds[gvk.Kind])
+	}
+
+	fakeDiscovery := &fakediscovery.FakeDiscovery{Fake: &coretesting.Fake{}}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, groupVersion := range order {
+		list := resources[groupVersion]
+		fakeDiscovery.Resources = append(fakeDiscovery.Resources, list)
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range list.APIResources {
+			scope := meta.RESTScopeRoot
+			if resource.Namespaced {
+				scope = meta.RESTScopeNamespace
+			}
+			mapper.Add(gv.WithKind(resource.Kind), scope)
+		}
+	}
+
+	objects := make([]client.Object, 0, len(fixtures))
+	for _, fixture := range fixtures {
+		copied, ok := fixture.DeepCopyObject().(client.Object)
+		if !ok {
+			return nil, fmt.Errorf("fixture %s is not an object", testutils.ResourceID(fixture))
+		}
+		if _, _, err := testutils.Namespaced(fakeDiscovery, copied, namespace); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", testutils.ResourceID(fixture), err)
+		}
+		objects = append(objects, copied)
+	}
+	cl := fake.NewClientBuilder().WithScheme(testutils.Scheme()).WithRESTMapper(mapper).Build()
+	for _, obj := range objects {
+		if err := cl.Create(context.TODO(), obj); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", testutils.ResourceID(obj), err)
+		}
+	}
+
+	return &FakeCluster{client: cl, discovery: fakeDiscovery}, nil
+}
+
+// LoadFakeCluster returns a fake cluster holding the objects of the fixture files or directories.
+func LoadFakeCluster(namespace string, paths ...string) (*FakeCluster, error) {
+	fixtures := []client.Object{}
+	for _, path := range paths {
+		objs, err := ObjectsFromPath(path, "")
+		if err != nil {
+			return nil, fmt.Errorf("loading fixtures %s: %w", path, err)
+		}
+		fixtures = append(fixtures, objs...)
+	}
+	return NewFakeCluster(namespace, fixtures...)
+}
+
+// Client returns the client of the fake cluster, in the form of the Client of a Step.
+func (c *FakeCluster) Client(bool) (client.Client, error) {
+	return c.client, nil
+}
+
+// DiscoveryClient returns the discovery client of the fake cluster, in the form of the DiscoveryClient of a Step.
+func (c *FakeCluster) DiscoveryClient() (discovery.DiscoveryInterface, error) {
+	return c.discovery, nil
+}
+
+// Assert returns the errors of the expected objects of an assert file in the namespace of the fake cluster, the
+// objects are checked once.
+func (c *FakeCluster) Assert(namespace string, expected ...client.Object) []error {
+	s := &Step{Client: c.Client, DiscoveryClient: c.DiscoveryClient}
+	testErrors := []error{}
+	for _, obj := range expected {
+		testErrors = append(testErrors, s.CheckResource(obj, namespace)...)
+	}
+	return testErrors
+}
+
+// Errors returns the errors of the objects of an errors file which exist in the namespace of the fake cluster.
+func (c *FakeCluster) Errors(namespace string, expected ...client.Object) []error {
+	s := &Step{Client: c.Client, DiscoveryClient: c.DiscoveryClient}
+	testErrors := []error{}
+	for _, obj := range expected {
+		if err := s.CheckResourceAbsent(obj, namespace); err != nil {
+			testErrors = append(testErrors, err)
+		}
+	}
+	return testErrors
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestFakeCluster(t *testing.T) {
+	pod := testutils.WithStatus(t, testutils.NewPod("web", ""), map[string]interface{}{"phase": "Running"})
+	database := testutils.WithSpec(t, testutils.NewResource("example.com/v1", "Database", "orders", ""), map[string]interface{}{"replicas": int64(3)})
+	backup := testutils.NewResource("example.com/v1", "Backup", "nightly", "")
+	crd := testutils.NewResource("apiextensions.k8s.io/v1", "CustomResourceDefinition", "backups.example.com", "")
+	crd = testutils.WithSpec(t, crd, map[string]interface{}{
+		"group":    "example.com",
+		"scope":    "Cluster",
+		"names":    map[string]interface{}{"kind": "Backup", "plural": "backups"},
+		"versions": []interface{}{map[string]interface{}{"name": "v1"}},
+	})
+
+	cluster, err := NewFakeCluster(testNamespace, pod, database, crd, backup)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name     string
+		expected client.Object
+		errors   int
+	}{
+		{name: "pod", expected: testutils.WithStatus(t, testutils.NewPod("web", ""), map[string]interface{}{"phase": "Running"})},
+		{name: "custom resource", expected: testutils.WithSpec(t, testutils.NewResource("example.com/v1", "Database", "orders", ""), map[string]interface{}{"replicas": int64(3)})},
+		{name: "cluster scoped custom resource", expected: testutils.NewResource("example.com/v1", "Backup", "nightly", "")},
+		{name: "mismatch", expected: testutils.WithStatus(t, testutils.NewPod("web", ""), map[string]interface{}{"phase": "Pending"}), errors: 2},
+		{name: "missing", expected: testutils.NewPod("api", ""), errors: 1},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			assert.Len(t, cluster.Assert(testNamespace, test.expected), test.errors)
+		})
+	}
+
+	assert.Len(t, cluster.Errors(testNamespace, testutils.NewPod("web", "")), 1)
+	assert.Empty(t, cluster.Errors(testNamespace, testutils.NewPod("api", "")))
+}
+
+func TestLoadFakeCluster(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pod.yaml"), []byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+status:
+  phase: Running
+`), 0600))
+
+	cluster, err := LoadFakeCluster(testNamespace, dir)
+	require.NoError(t, err)
+	assert.Empty(t, cluster.Assert(testNamespace, testutils.WithStatus(t, testutils.NewPod("web", ""), map[string]interface{}{"phase": "Running"})))
+
+	_, err = LoadFakeCluster(testNamespace, filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "loading fixtures "+filepath.Join(dir, "missing"))
+}
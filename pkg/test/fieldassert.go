@@ -0,0 +1,241 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// The operators of field assertions.
+const (
+	fieldEquals      = "equals"
+	fieldNotEquals   = "notEquals"
+	fieldContains    = "contains"
+	fieldMatches     = "matches"
+	fieldGreaterThan = "greaterThan"
+	fieldLessThan    = "lessThan"
+	fieldExists      = "exists"
+	fieldNotExists   = "notExists"
+)
+
+// fieldOperators are the operators of field assertions, an empty operator is equals.
+var fieldOperators = map[string]bool{
+	"":               true,
+	fieldEquals:      true,
+	fieldNotEquals:   true,
+	fieldContains:    true,
+	fieldMatches:     true,
+	fieldGreaterThan: true,
+	fieldLessThan:    true,
+	fieldExists:      true,
+	fieldNotExists:   true,
+}
+
+// validateFieldAssertions returns an error if a field assertion has an unknown operator, an invalid path or a value
+// its operator cannot compare to, so that it fails when the step is loaded rather than when its asserts time out.
+func validateFieldAssertions(assertions []harness.FieldAssertion) error {
+	for _, assertion := range assertions {
+		if err := validateFieldAssertion(assertion); err != nil {
+			return fmt.Errorf("field assertion %s of %s %s: %w", assertion.Path, assertion.Kind, assertion.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateFieldAssertion(assertion harness.FieldAssertion) error {
+	if !fieldOperators[assertion.Operator] {
+		return fmt.Errorf("unknown operator %q", assertion.Operator)
+	}
+	if _, err := parseJSONPath(assertion.Path); err != nil {
+		return err
+	}
+	switch assertion.Operator {
+	case fieldMatches:
+		if _, err := regexp.Compile(assertion.Value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+	case fieldGreaterThan, fieldLessThan:
+		if _, err := resource.ParseQuantity(assertion.Value); err != nil {
+			return fmt.Errorf("invalid value %q: %w", assertion.Value, err)
+		}
+	}
+	return nil
+}
+
+// parseJSONPath parses the JSONPath expression of a field assertion, it adds the braces kubectl requires if they are
+// missing.
+func parseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	expression := strings.TrimSpace(path)
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	parser := jsonpath.New("fieldAssertion")
+	parser.AllowMissingKeys(true)
+	if err := parser.Parse(expression); err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	return parser, nil
+}
+
+// CheckFieldAssertions checks the field assertions of the TestAssert of the step.
+func (s *Step) CheckFieldAssertions(namespace string) []error {
+	if s.Assert == nil || len(s.Assert.FieldAssertions) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, assertion := range s.Assert.FieldAssertions {
+		if err := checkFieldAssertion(context.TODO(), cl, assertion, namespace); err != nil {
+			s.unsatisfied = append(s.unsatisfied, fieldAssertionID(assertion, namespace))
+			errs = append(errs, fmt.Errorf("field assertion %s of %s %s: %w", assertion.Path, assertion.Kind, assertion.Name, err))
+		}
+	}
+	return errs
+}
+
+// fieldAssertionID identifies a field assertion in the progress of the asserts.
+func fieldAssertionID(assertion harness.FieldAssertion, namespace string) string {
+	if assertion.Namespace != "" {
+		namespace = assertion.Namespace
+	}
+	return fmt.Sprintf("%s:%s/%s %s", assertion.Kind, namespace, assertion.Name, assertion.Path)
+}
+
+// checkFieldAssertion gets the object of the assertion and compares the values its path selects to its value.
+func checkFieldAssertion(ctx context.Context, cl client.Client, assertion harness.FieldAssertion, namespace string) error {
+	parser, err := parseJSONPath(assertion.Path)
+	if err != nil {
+		return err
+	}
+
+	obj, err := getAssertedObject(ctx, cl, assertion.APIVersion, assertion.Kind, assertion.Name, assertion.Namespace, namespace)
+	if err != nil {
+		return err
+	}
+
+	results, err := parser.FindResults(obj.Object)
+	if err != nil {
+		return err
+	}
+	values := []string{}
+	for _, result := range results {
+		for _, value := range result {
+			formatted, err := formatFieldValue(value.Interface())
+			if err != nil {
+				return err
+			}
+			values = append(values, formatted)
+		}
+	}
+	return compareFieldValues(assertion.Operator, assertion.Value, values)
+}
+
+// getAssertedObject gets the object an assertion checks, it is in the namespace of the test unless objNamespace is set.
+func getAssertedObject(ctx context.Context, cl client.Client, apiVersion, kind, name, objNamespace, namespace string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(apiVersion, kind))
+	key := client.ObjectKey{Namespace: objNamespace, Name: name}
+	if key.Namespace == "" {
+		key.Namespace = namespace
+	}
+	if err := cl.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// formatFieldValue returns a string as it is and other values in JSON.
+func formatFieldValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// compareFieldValues compares the values selected by the path of a field assertion to its value with operator.
+func compareFieldValues(operator, expected string, values []string) error {
+	switch operator {
+	case fieldExists:
+		if len(values) == 0 {
+			return errors.New("the path selects no value")
+		}
+		return nil
+	case fieldNotExists:
+		if len(values) > 0 {
+			return fmt.Errorf("the path selects %q, expected no value", values)
+		}
+		return nil
+	case fieldContains:
+		for _, value := range values {
+			if strings.Contains(value, expected) {
+				return nil
+			}
+		}
+		return fmt.Errorf("the values %q do not contain %q", values, expected)
+	}
+
+	if len(values) == 0 {
+		return errors.New("the path selects no value")
+	}
+	if len(values) > 1 {
+		return fmt.Errorf("the path selects %d values %q, expected a single value", len(values), values)
+	}
+	value := values[0]
+
+	switch operator {
+	case "", fieldEquals:
+		if value != expected {
+			return fmt.Errorf("the value is %q, expected %q", value, expected)
+		}
+	case fieldNotEquals:
+		if value == expected {
+			return fmt.Errorf("the value is %q, expected another value", value)
+		}
+	case fieldMatches:
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("the value %q does not match %q", value, expected)
+		}
+	case fieldGreaterThan, fieldLessThan:
+		actual, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("the value %q is not a quantity", value)
+		}
+		bound, err := resource.ParseQuantity(expected)
+		if err != nil {
+			return fmt.Errorf("invalid value %q: %w", expected, err)
+		}
+		if operator == fieldGreaterThan && actual.Cmp(bound) <= 0 {
+			return fmt.Errorf("the value is %s, expected greater than %s", value, expected)
+		}
+		if operator == fieldLessThan && actual.Cmp(bound) >= 0 {
+			return fmt.Errorf("the value is %s, expected less than %s", value, expected)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", operator)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCheckFieldAssertions(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Status: appsv1.DeploymentStatus{
+			ReadyReplicas: 3,
+			Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable"},
+				{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable"},
+			},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment).Build()
+	field := func(path, operator, value string) harness.FieldAssertion {
+		return harness.FieldAssertion{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Path: path, Operator: operator, Value: value}
+	}
+
+	for _, test := range []struct {
+		name      string
+		assertion harness.FieldAssertion
+		err       string
+	}{
+		{name: "equals", assertion: field(".status.readyReplicas", "", "3")},
+		{name: "equals with braces", assertion: field("{.status.readyReplicas}", "equals", "3")},
+		{name: "filter", assertion: field(`.status.conditions[?(@.type=="Available")].reason`, "equals", "MinimumReplicasAvailable")},
+		{name: "not equal", assertion: field(".status.readyReplicas", "equals", "2"), err: `the value is "3", expected "2"`},
+		{name: "not equals", assertion: field(".status.readyReplicas", "notEquals", "2")},
+		{name: "contains", assertion: field(".status.conditions[*].type", "contains", "Available")},
+		{name: "does not contain", assertion: field(".status.conditions[*].type", "contains", "ReplicaFailure"),
+			err: `the values ["Progressing" "Available"] do not contain "ReplicaFailure"`},
+		{name: "matches", assertion: field(".status.conditions[0].reason", "matches", "^NewReplicaSet")},
+		{name: "greater than", assertion: field(".status.readyReplicas", "greaterThan", "2")},
+		{name: "not less than", assertion: field(".status.readyReplicas", "lessThan", "3"), err: "the value is 3, expected less than 3"},
+		{name: "exists", assertion: field(".status.conditions", "exists", "")},
+		{name: "not exists", assertion: field(".status.unavailableReplicas", "notExists", "")},
+		{name: "missing", assertion: field(".status.unavailableReplicas", "equals", "0"), err: "the path selects no value"},
+		{name: "several values", assertion: field(".status.conditions[*].status", "equals", "True"),
+			err: `the path selects 2 values ["True" "True"], expected a single value`},
+		{name: "missing object", assertion: harness.FieldAssertion{APIVersion: "apps/v1", Kind: "Deployment", Name: "api", Path: ".status"},
+			err: `deployments.apps "api" not found`},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			step := &Step{
+				Assert: &harness.TestAssert{FieldAssertions: []harness.FieldAssertion{test.assertion}},
+				Logger: testutils.NewTestLogger(t, test.name),
+				Client: func(bool) (client.Client, error) { return cl, nil },
+			}
+
+			errs := step.CheckFieldAssertions(testNamespace)
+			if test.err == "" {
+				assert.Empty(t, errs)
+				assert.Empty(t, step.unsatisfied)
+			} else {
+				require.Len(t, errs, 1)
+				assert.ErrorContains(t, errs[0], test.err)
+				assert.Equal(t, []string{"Deployment:" + testNamespace + "/" + test.assertion.Name + " " + test.assertion.Path}, step.unsatisfied)
+			}
+		})
+	}
+}
+
+func TestValidateFieldAssertions(t *testing.T) {
+	field := func(path, operator, value string) []harness.FieldAssertion {
+		return []harness.FieldAssertion{{Kind: "Deployment", Name: "web", Path: path, Operator: operator, Value: value}}
+	}
+
+	assert.NoError(t, validateFieldAssertions(field(".status.readyReplicas", "greaterThan", "1")))
+	assert.EqualError(t, validateFieldAssertions(field(".status", "above", "1")), `field assertion .status of Deployment web: unknown operator "above"`)
+	assert.ErrorContains(t, validateFieldAssertions(field(".status[", "", "")), "invalid path")
+	assert.ErrorContains(t, validateFieldAssertions(field(".status", "matches", "(")), "invalid value")
+	assert.ErrorContains(t, validateFieldAssertions(field(".status", "lessThan", "many")), `invalid value "many"`)
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// testPattern matches the names of tests, it is a glob or a regular expression between slashes.
+type testPattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+func parseTestPattern(pattern string) (testPattern, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return testPattern{}, fmt.Errorf("invalid test pattern %q: %w", pattern, err)
+		}
+		return testPattern{re: re}, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return testPattern{}, fmt.Errorf("invalid test pattern %q: %w", pattern, err)
+	}
+	return testPattern{glob: pattern}, nil
+}
+
+// match returns true if the pattern matches the name of the test, or its path for globs containing a slash.  Regular
+// expressions match the name unless they contain a slash, they are not anchored.
+func (p testPattern) match(name, testPath string) bool {
+	if p.re != nil {
+		if strings.Contains(p.re.String(), "/") {
+			return p.re.MatchString(testPath)
+		}
+		return p.re.MatchString(name)
+	}
+	if strings.Contains(p.glob, "/") {
+		matched, _ := path.Match(p.glob, testPath)
+		return matched
+	}
+	matched, _ := path.Match(p.glob, name)
+	return matched
+}
+
+// testFilter selects the tests to run, the tests matching one of the included patterns (all tests if there are
+// none) and none of the excluded patterns.
+type testFilter struct {
+	include []testPattern
+	exclude []testPattern
+}
+
+func newTestFilter(include, exclude []string) (*testFilter, error) {
+	f := &testFilter{}
+	for _, pattern := range include {
+		p, err := parseTestPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, p)
+	}
+	for _, pattern := range exclude {
+		p, err := parseTestPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, p)
+	}
+	return f, nil
+}
+
+// selects returns true if the test with the name in the test directory is run.
+func (f *testFilter) selects(testDir, name string) bool {
+	testPath := filepath.ToSlash(filepath.Join(testDir, name))
+	for _, p := range f.exclude {
+		if p.match(name, testPath) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if p.match(name, testPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the tests of the test directory which are run.
+func (f *testFilter) filter(testDir string, tests []*Case) []*Case {
+	selected := []*Case{}
+	for _, test := range tests {
+		if f.selects(testDir, test.Name) {
+			selected = append(selected, test)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestTestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		selected []string
+	}{
+		{name: "all", selected: []string{"upgrade-v1", "upgrade-v2-slow", "install", "backup"}},
+		{name: "exact name", include: []string{"install"}, selected: []string{"install"}},
+		{name: "glob", include: []string{"upgrade-*"}, selected: []string{"upgrade-v1", "upgrade-v2-slow"}},
+		{name: "regular expression", include: []string{"/^upgrade-v[0-9]+$/"}, selected: []string{"upgrade-v1"}},
+		{name: "multiple patterns", include: []string{"install", "/^back/"}, selected: []string{"install", "backup"}},
+		{name: "excluded", exclude: []string{"/-slow$/", "backup"}, selected: []string{"upgrade-v1", "install"}},
+		{name: "included and excluded", include: []string{"upgrade-*"}, exclude: []string{"*-slow"}, selected: []string{"upgrade-v1"}},
+		{name: "test directory", include: []string{"test/e2e/*"}, selected: []string{"upgrade-v1", "upgrade-v2-slow", "install", "backup"}},
+		{name: "other test directory", include: []string{"test/integration/*"}, selected: []string{}},
+		{name: "test directory regular expression", include: []string{"/e2e/in/"}, selected: []string{"install"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newTestFilter(tt.include, tt.exclude)
+			require.NoError(t, err)
+
+			cases := []*Case{{Name: "upgrade-v1"}, {Name: "upgrade-v2-slow"}, {Name: "install"}, {Name: "backup"}}
+			selected := []string{}
+			for _, c := range f.filter("./test/e2e", cases) {
+				selected = append(selected, c.Name)
+			}
+			assert.Equal(t, tt.selected, selected)
+		})
+	}
+
+	_, err := newTestFilter([]string{"[upgrade"}, nil)
+	assert.EqualError(t, err, `invalid test pattern "[upgrade": syntax error in pattern`)
+	_, err = newTestFilter(nil, []string{"/(upgrade/"})
+	assert.EqualError(t, err, "invalid test pattern \"/(upgrade/\": error parsing regexp: missing closing ): `(upgrade`")
+}
+
+func TestRunFromStep(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	steps := []*Step{}
+	for i, name := range []string{"zero", "one", "two"} {
+		steps = append(steps, &Step{
+			Name:  name,
+			Index: i,
+			Apply: []client.Object{testutils.NewPod(name, "")},
+		})
+	}
+	c := &Case{
+		Name:               "from-step",
+		Steps:              steps,
+		PreferredNamespace: testNamespace,
+		SkipDelete:         true,
+		Suppress:           []string{"events"},
+		FromStep:           1,
+		Timeout:            1,
+		Logger:             testutils.NewTestLogger(t, "from-step"),
+		Client:             func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient:    func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+
+	tc := report.NewCase(c.Name)
+	c.Run(t, tc)
+	assert.Nil(t, tc.Failure)
+
+	for name, applied := range map[string]bool{"zero": false, "one": true, "two": true} {
+		actual := &unstructured.Unstructured{}
+		actual.SetAPIVersion("v1")
+		actual.SetKind("Pod")
+		err := cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: name}, actual)
+		if applied {
+			assert.NoError(t, err, name)
+		} else {
+			assert.True(t, k8serrors.IsNotFound(err), name)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/http"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// fixtureVersion is replaced with the version of a fixture in the URLs of its manifests.
+const fixtureVersion = "{version}"
+
+var (
+	// fixtureTimeout is how long the workloads of a fixture may take to roll out.
+	fixtureTimeout = 5 * time.Minute
+	// fixtureInterval is the interval at which the rollout of the workloads of a fixture is checked.
+	fixtureInterval = time.Second
+)
+
+// fixture is a well-known test fixture which can be installed into kind clusters.
+type fixture struct {
+	// version is the default version of the fixture.
+	version   string
+	manifests []string
+}
+
+// fixtures are the well-known test fixtures by name.
+var fixtures = map[string]fixture{
+	// https://github.com/kubernetes-csi/csi-driver-host-path/blob/master/docs/deploy-1.17-and-later.md, the RBAC rules
+	// of the sidecars are those of the sidecar versions of the driver release.
+	"csi-hostpath": {
+		version: "v1.12.1",
+		manifests: []string{
+			"https://raw.githubusercontent.com/kubernetes-csi/external-provisioner/v3.6.2/deploy/kubernetes/rbac.yaml",
+			"https://raw.githubusercontent.com/kubernetes-csi/external-attacher/v4.4.2/deploy/kubernetes/rbac.yaml",
+			"https://raw.githubusercontent.com/kubernetes-csi/external-resizer/v1.9.2/deploy/kubernetes/rbac.yaml",
+			"https://raw.githubusercontent.com/kubernetes-csi/external-snapshotter/v6.3.2/deploy/kubernetes/csi-snapshotter/rbac-csi-snapshotter.yaml",
+			"https://raw.githubusercontent.com/kubernetes-csi/external-health-monitor/v0.10.0/deploy/kubernetes/external-health-monitor-controller/rbac.yaml",
+			"https://raw.githubusercontent.com/kubernetes-csi/csi-driver-host-path/{version}/deploy/kubernetes-latest/hostpath/csi-hostpath-driverinfo.yaml",
+			"https://raw.githubusercontent.com/kubernetes-csi/csi-driver-host-path/{version}/deploy/kubernetes-latest/hostpath/csi-hostpath-plugin.yaml",
+			"https://raw.githubusercontent.com/kubernetes-csi/csi-driver-host-path/{version}/examples/csi-storageclass.yaml",
+		},
+	},
+	// kind ships the local path provisioner, the upstream manifest replaces it with the pinned version.
+	"local-path-provisioner": {
+		version:   "v0.0.26",
+		manifests: []string{"https://raw.githubusercontent.com/rancher/local-path-provisioner/{version}/deploy/local-path-storage.yaml"},
+	},
+	// the device plugin of the Kubernetes e2e tests.
+	"sample-device-plugin": {
+		version:   "v1.29.0",
+		manifests: []string{"https://raw.githubusercontent.com/kubernetes/kubernetes/{version}/test/e2e/testing-manifests/sample-device-plugin/sample-device-plugin.yaml"},
+	},
+}
+
+// fixtureNames returns the names of the well-known fixtures, sorted.
+func fixtureNames() []string {
+	names := make([]string, 0, len(fixtures))
+	for name := range fixtures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fixtureManifests returns the URLs or paths of the manifests of the fixture, with its version.
+func fixtureManifests(f harness.KINDFixture) ([]string, error) {
+	if f.Name == "" {
+		return nil, errors.New("kind fixture has no name")
+	}
+	known, ok := fixtures[f.Name]
+	manifests := f.Manifests
+	if len(manifests) == 0 {
+		if !ok {
+			return nil, fmt.Errorf("unknown kind fixture %q, it must be one of %s or set its manifests", f.Name, strings.Join(fixtureNames(), ", "))
+		}
+		manifests = known.manifests
+	}
+
+	version := f.Version
+	if version == "" {
+		version = known.version
+	}
+	resolved := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		if strings.Contains(manifest, fixtureVersion) && version == "" {
+			return nil, fmt.Errorf("kind fixture %s has no version for its manifest %s", f.Name, manifest)
+		}
+		resolved = append(resolved, strings.ReplaceAll(manifest, fixtureVersion, version))
+	}
+	return resolved, nil
+}
+
+// loadFixtureManifest loads the objects of a manifest of a fixture from its URL or path.
+func loadFixtureManifest(manifest string) ([]client.Object, error) {
+	if http.IsURL(manifest) {
+		return http.ToObjects(manifest)
+	}
+	return testutils.LoadYAMLFromFile(manifest)
+}
+
+// installFixtures installs the kind fixtures of the test suite and waits until their workloads rolled out.
+func (h *Harness) installFixtures(ctx context.Context, cl client.Client, dClient discovery.DiscoveryInterface) error {
+	if len(h.TestSuite.KINDFixtures) == 0 {
+		return nil
+	}
+	if !h.TestSuite.StartKIND {
+		return errors.New("kindFixtures can only be installed into a kind cluster started with startKIND")
+	}
+
+	for _, f := range h.TestSuite.KINDFixtures {
+		manifests, err := fixtureManifests(f)
+		if err != nil {
+			return err
+		}
+		h.T.Logf("installing kind fixture %s", f.Name)
+
+		workloads := []client.Object{}
+		for _, manifest := range manifests {
+			objs, err := loadFixtureManifest(manifest)
+			if err != nil {
+				return fmt.Errorf("loading manifest %s of kind fixture %s: %w", manifest, f.Name, err)
+			}
+			for _, obj := range objs {
+				if _, _, err := testutils.Namespaced(dClient, obj, "default"); err != nil {
+					return err
+				}
+				if _, err := testutils.CreateOrUpdate(ctx, cl, obj, true); err != nil {
+					return fmt.Errorf("installing %s of kind fixture %s: %w", testutils.ResourceID(obj), f.Name, err)
+				}
+				switch obj.GetObjectKind().GroupVersionKind().Kind {
+				case "Deployment", "StatefulSet", "DaemonSet":
+					workloads = append(workloads, obj)
+				}
+			}
+		}
+
+		for _, workload := range workloads {
+			if err := waitForFixture(ctx, cl, workload); err != nil {
+				return fmt.Errorf("kind fixture %s: %w", f.Name, err)
+			}
+		}
+		h.T.Logf("kind fixture %s is ready", f.Name)
+	}
+	return nil
+}
+
+// waitForFixture waits until the rollout of a workload of a fixture is complete.
+func waitForFixture(ctx context.Context, cl client.Client, workload client.Object) error {
+	id := testutils.ResourceID(workload)
+	status := "not checked"
+	err := wait.PollImmediate(fixtureInterval, fixtureTimeout, func() (bool, error) {
+		actual := &unstructured.Unstructured{}
+		actual.SetGroupVersionKind(workload.GetObjectKind().GroupVersionKind())
+		if err := cl.Get(ctx, testutils.ObjectKey(workload), actual); err != nil {
+			return false, err
+		}
+
+		var done bool
+		var err error
+		done, status, err = rolledOut(actual)
+		return done, err
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for %s to roll out: %s", id, status)
+	}
+	if err != nil {
+		return fmt.Errorf("waiting for %s to roll out: %w", id, err)
+	}
+	return nil
+}
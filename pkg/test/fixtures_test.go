@@ -0,0 +1,112 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestFixtureManifests(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		fixture   harness.KINDFixture
+		manifests []string
+		err       string
+	}{
+		{
+			name:      "default version",
+			fixture:   harness.KINDFixture{Name: "local-path-provisioner"},
+			manifests: []string{"https://raw.githubusercontent.com/rancher/local-path-provisioner/v0.0.26/deploy/local-path-storage.yaml"},
+		},
+		{
+			name:      "pinned version",
+			fixture:   harness.KINDFixture{Name: "local-path-provisioner", Version: "v0.0.24"},
+			manifests: []string{"https://raw.githubusercontent.com/rancher/local-path-provisioner/v0.0.24/deploy/local-path-storage.yaml"},
+		},
+		{
+			name:      "mirrored manifests",
+			fixture:   harness.KINDFixture{Name: "sample-device-plugin", Manifests: []string{"https://mirror.example.com/device-plugin/{version}.yaml"}},
+			manifests: []string{"https://mirror.example.com/device-plugin/v1.29.0.yaml"},
+		},
+		{
+			name:      "custom fixture",
+			fixture:   harness.KINDFixture{Name: "gpu-operator", Manifests: []string{"fixtures/gpu-operator.yaml"}},
+			manifests: []string{"fixtures/gpu-operator.yaml"},
+		},
+		{
+			name:    "custom fixture without version",
+			fixture: harness.KINDFixture{Name: "gpu-operator", Manifests: []string{"https://example.com/{version}/gpu-operator.yaml"}},
+			err:     "kind fixture gpu-operator has no version for its manifest https://example.com/{version}/gpu-operator.yaml",
+		},
+		{
+			name:    "unknown fixture",
+			fixture: harness.KINDFixture{Name: "gpu-operator"},
+			err:     `unknown kind fixture "gpu-operator", it must be one of csi-hostpath, local-path-provisioner, sample-device-plugin or set its manifests`,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			manifests, err := fixtureManifests(test.fixture)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.manifests, manifests)
+		})
+	}
+}
+
+func TestInstallFixtures(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "fixture.yaml")
+	require.NoError(t, os.WriteFile(manifest, []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: fixture-config
+  namespace: fixture
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: fixture
+spec:
+  replicas: 0
+  selector:
+    matchLabels:
+      app: fixture
+  template:
+    metadata:
+      labels:
+        app: fixture
+    spec:
+      containers:
+      - name: fixture
+        image: fixture:v1
+`), 0600))
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	h := &Harness{T: t, TestSuite: harness.TestSuite{
+		StartKIND:    true,
+		KINDFixtures: []harness.KINDFixture{{Name: "fixture", Manifests: []string{manifest}}},
+	}}
+	require.NoError(t, h.installFixtures(context.TODO(), cl, testutils.FakeDiscoveryClient()))
+
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: "fixture", Name: "fixture-config"}, &corev1.ConfigMap{}))
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "fixture"}, &appsv1.Deployment{}))
+
+	h.TestSuite.StartKIND = false
+	assert.EqualError(t, h.installFixtures(context.TODO(), cl, testutils.FakeDiscoveryClient()), "kindFixtures can only be installed into a kind cluster started with startKIND")
+}
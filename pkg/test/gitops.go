@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+const (
+	// fluxReconcileAnnotation requests a reconciliation of a Flux object, like `flux reconcile`.
+	fluxReconcileAnnotation = "reconcile.fluxcd.io/requestedAt"
+	// argoRefreshAnnotation requests a refresh of an Argo CD Application from its source.
+	argoRefreshAnnotation = "argocd.argoproj.io/refresh"
+	// argoInitiator is the user Argo CD sync operations started by kuttl are initiated by.
+	argoInitiator = "kuttl"
+)
+
+// gitOpsKinds are the kinds which can be synced by their default API versions.
+var gitOpsKinds = map[string]string{
+	"Kustomization": "kustomize.toolkit.fluxcd.io/v1",
+	"HelmRelease":   "helm.toolkit.fluxcd.io/v2beta1",
+	"Application":   "argoproj.io/v1alpha1",
+}
+
+// syncInterval is the interval at which the status of synced objects is checked.
+var syncInterval = time.Second
+
+// Sync triggers the GitOps syncs of the step and waits until the synced objects are ready.
+func (s *Step) Sync(namespace string) []error {
+	if s.Step == nil || len(s.Step.Syncs) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, sync := range s.Step.Syncs {
+		if err := s.sync(cl, sync, namespace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// sync triggers a reconciliation of the object of sync and waits until it succeeded.
+func (s *Step) sync(cl client.Client, sync harness.GitOpsSync, namespace string) error {
+	obj, err := gitOpsObject(sync, namespace)
+	if err != nil {
+		return err
+	}
+	id := testutils.ResourceID(obj)
+
+	timeout := sync.Timeout
+	if timeout == 0 {
+		timeout = s.GetTimeout()
+	}
+
+	requested := time.Now()
+	if err := cl.Patch(context.TODO(), obj, client.RawPatch(types.MergePatchType, syncPatch(obj, requested))); err != nil {
+		return fmt.Errorf("triggering sync of %s: %w", id, err)
+	}
+	s.Logger.Logf("triggered sync of %s", id)
+
+	status := "not checked"
+	err = wait.PollImmediate(syncInterval, time.Duration(timeout)*time.Second, func() (bool, error) {
+		actual := &unstructured.Unstructured{}
+		actual.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := cl.Get(context.TODO(), testutils.ObjectKey(obj), actual); err != nil {
+			return false, err
+		}
+
+		var done bool
+		var err error
+		if actual.GetKind() == "Application" {
+			done, status, err = argoSynced(actual, requested)
+		} else {
+			done, status, err = fluxSynced(actual, requested)
+		}
+		return done, err
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out syncing %s: %s", id, status)
+	}
+	if err != nil {
+		return fmt.Errorf("syncing %s: %w", id, err)
+	}
+
+	s.Logger.Logf("%s synced", id)
+	return nil
+}
+
+// gitOpsObject returns the object to sync.
+func gitOpsObject(sync harness.GitOpsSync, namespace string) (*unstructured.Unstructured, error) {
+	apiVersion, ok := gitOpsKinds[sync.Kind]
+	if !ok {
+		return nil, fmt.Errorf("cannot sync kind %q, it must be one of Kustomization, HelmRelease or Application", sync.Kind)
+	}
+	if sync.APIVersion != "" {
+		apiVersion = sync.APIVersion
+	}
+	if sync.Name == "" {
+		return nil, fmt.Errorf("sync of %s has no name", sync.Kind)
+	}
+	if sync.Namespace != "" {
+		namespace = sync.Namespace
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(sync.Kind)
+	obj.SetName(sync.Name)
+	obj.SetNamespace(namespace)
+	return obj, nil
+}
+
+// syncPatch returns the merge patch triggering a reconciliation of obj requested at the time.  Flux objects are
+// annotated like by `flux reconcile`, Argo CD Applications are refreshed and given a sync operation like by
+// `argocd app sync`.
+func syncPatch(obj *unstructured.Unstructured, requested time.Time) []byte {
+	var patch map[string]interface{}
+	if obj.GetKind() == "Application" {
+		patch = map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{argoRefreshAnnotation: "normal"},
+			},
+			"operation": map[string]interface{}{
+				"initiatedBy": map[string]interface{}{"username": argoInitiator},
+				"sync":        map[string]interface{}{},
+			},
+		}
+	} else {
+		patch = map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{fluxReconcileAnnotation: requested.Format(time.RFC3339Nano)},
+			},
+		}
+	}
+	// the patch only has strings and maps, it cannot fail to marshal
+	data, _ := json.Marshal(patch)
+	return data
+}
+
+// fluxSynced returns true if the reconciliation of the Flux object requested at the time was handled and the object
+// is ready.  It also returns a description of the status and fails if the object is stalled.
+func fluxSynced(obj *unstructured.Unstructured, requested time.Time) (bool, string, error) {
+	handled, _, _ := unstructured.NestedString(obj.Object, "status", "lastHandledReconcileAt")
+	if handled != requested.Format(time.RFC3339Nano) {
+		return false, "reconciliation was not handled yet", nil
+	}
+
+	if status, message := statusCondition(obj, "Stalled"); status == "True" {
+		return false, "", fmt.Errorf("stalled: %s", message)
+	}
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observed < obj.GetGeneration() {
+		return false, fmt.Sprintf("generation %d was not observed yet", obj.GetGeneration()), nil
+	}
+	status, message := statusCondition(obj, "Ready")
+	if status != "True" {
+		return false, fmt.Sprintf("not ready: %s", message), nil
+	}
+	return true, "ready", nil
+}
+
+// argoSynced returns true if the sync operation of the Argo CD Application started at the time succeeded and the
+// Application is synced and healthy.  It also returns a description of the status and fails if the operation failed.
+func argoSynced(obj *unstructured.Unstructured, requested time.Time) (bool, string, error) {
+	if _, ok := obj.Object["operation"]; ok {
+		return false, "sync operation was not started yet", nil
+	}
+
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "operationState", "phase")
+	message, _, _ := unstructured.NestedString(obj.Object, "status", "operationState", "message")
+	startedAt, _, _ := unstructured.NestedString(obj.Object, "status", "operationState", "startedAt")
+	// startedAt has a precision of seconds
+	if started, err := time.Parse(time.RFC3339, startedAt); err != nil || started.Before(requested.Truncate(time.Second)) {
+		return false, "sync operation was not started yet", nil
+	}
+
+	switch phase {
+	case "Succeeded":
+	case "Failed", "Error":
+		return false, "", fmt.Errorf("sync operation %s: %s", phase, message)
+	default:
+		return false, fmt.Sprintf("sync operation %s: %s", phase, message), nil
+	}
+
+	syncStatus, _, _ := unstructured.NestedString(obj.Object, "status", "sync", "status")
+	healthStatus, _, _ := unstructured.NestedString(obj.Object, "status", "health", "status")
+	if syncStatus != "Synced" || healthStatus != "Healthy" {
+		return false, fmt.Sprintf("sync status %s, health status %s", syncStatus, healthStatus), nil
+	}
+	return true, "synced and healthy", nil
+}
+
+// statusCondition returns the status and message of the status condition of the type of obj.
+func statusCondition(obj *unstructured.Unstructured, conditionType string) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		message, _ := condition["message"].(string)
+		return status, message
+	}
+	return "", "no " + conditionType + " condition"
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// reconcilingClient simulates the GitOps controllers by reconciling objects when they are patched.
+type reconcilingClient struct {
+	client.Client
+	reconcile func(obj *unstructured.Unstructured)
+}
+
+func (c *reconcilingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	actual := obj.(*unstructured.Unstructured)
+	c.reconcile(actual)
+	return c.Client.Update(ctx, actual)
+}
+
+func gitOpsTestObject(apiVersion, kind, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace(testNamespace)
+	return obj
+}
+
+func TestSync(t *testing.T) {
+	syncInterval = 10 * time.Millisecond
+	defer func() { syncInterval = time.Second }()
+
+	flux := func(obj *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(obj.Object, obj.GetAnnotations()[fluxReconcileAnnotation], "status", "lastHandledReconcileAt")
+		_ = unstructured.SetNestedSlice(obj.Object, []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}}, "status", "conditions")
+	}
+	argo := func(obj *unstructured.Unstructured) {
+		delete(obj.Object, "operation")
+		_ = unstructured.SetNestedMap(obj.Object, map[string]interface{}{
+			"phase":     "Succeeded",
+			"startedAt": time.Now().UTC().Format(time.RFC3339),
+		}, "status", "operationState")
+		_ = unstructured.SetNestedField(obj.Object, "Synced", "status", "sync", "status")
+		_ = unstructured.SetNestedField(obj.Object, "Healthy", "status", "health", "status")
+	}
+	stalled := func(obj *unstructured.Unstructured) {
+		flux(obj)
+		_ = unstructured.SetNestedSlice(obj.Object, []interface{}{map[string]interface{}{"type": "Stalled", "status": "True", "message": "invalid chart"}}, "status", "conditions")
+	}
+
+	for _, test := range []struct {
+		name      string
+		obj       *unstructured.Unstructured
+		sync      harness.GitOpsSync
+		reconcile func(obj *unstructured.Unstructured)
+		err       string
+	}{
+		{
+			name:      "flux kustomization",
+			obj:       gitOpsTestObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "operator"),
+			sync:      harness.GitOpsSync{Kind: "Kustomization", Name: "operator"},
+			reconcile: flux,
+		},
+		{
+			name:      "argo application",
+			obj:       gitOpsTestObject("argoproj.io/v1alpha1", "Application", "operator"),
+			sync:      harness.GitOpsSync{Kind: "Application", Name: "operator"},
+			reconcile: argo,
+		},
+		{
+			name:      "stalled helm release",
+			obj:       gitOpsTestObject("helm.toolkit.fluxcd.io/v2beta2", "HelmRelease", "operator"),
+			sync:      harness.GitOpsSync{Kind: "HelmRelease", APIVersion: "helm.toolkit.fluxcd.io/v2beta2", Name: "operator"},
+			reconcile: stalled,
+			err:       "syncing HelmRelease:world/operator: stalled: invalid chart",
+		},
+		{
+			name:      "not reconciled",
+			obj:       gitOpsTestObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "operator"),
+			sync:      harness.GitOpsSync{Kind: "Kustomization", Name: "operator", Timeout: 1},
+			reconcile: func(obj *unstructured.Unstructured) {},
+			err:       "timed out syncing Kustomization:world/operator: reconciliation was not handled yet",
+		},
+		{
+			name: "unknown kind",
+			sync: harness.GitOpsSync{Kind: "GitRepository", Name: "operator"},
+			err:  `cannot sync kind "GitRepository", it must be one of Kustomization, HelmRelease or Application`,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+			if test.obj != nil {
+				builder = builder.WithObjects(test.obj)
+			}
+			cl := &reconcilingClient{Client: builder.Build(), reconcile: test.reconcile}
+
+			step := &Step{
+				Timeout: 5,
+				Logger:  testutils.NewTestLogger(t, ""),
+				Client:  func(bool) (client.Client, error) { return cl, nil },
+				Step:    &harness.TestStep{Syncs: []harness.GitOpsSync{test.sync}},
+			}
+
+			errs := step.Sync(testNamespace)
+			if test.err == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], test.err)
+		})
+	}
+}
+
+func TestArgoSynced(t *testing.T) {
+	requested := time.Date(2023, 3, 1, 12, 0, 0, 500, time.UTC)
+
+	for _, test := range []struct {
+		name   string
+		status map[string]interface{}
+		done   bool
+		err    string
+	}{
+		{
+			name: "previous operation",
+			status: map[string]interface{}{
+				"operationState": map[string]interface{}{"phase": "Succeeded", "startedAt": "2023-03-01T11:59:00Z"},
+			},
+		},
+		{
+			name: "running",
+			status: map[string]interface{}{
+				"operationState": map[string]interface{}{"phase": "Running", "startedAt": "2023-03-01T12:00:00Z"},
+			},
+		},
+		{
+			name: "failed",
+			status: map[string]interface{}{
+				"operationState": map[string]interface{}{"phase": "Failed", "startedAt": "2023-03-01T12:00:00Z", "message": "one or more objects failed to apply"},
+			},
+			err: "sync operation Failed: one or more objects failed to apply",
+		},
+		{
+			name: "degraded",
+			status: map[string]interface{}{
+				"operationState": map[string]interface{}{"phase": "Succeeded", "startedAt": "2023-03-01T12:00:00Z"},
+				"sync":           map[string]interface{}{"status": "Synced"},
+				"health":         map[string]interface{}{"status": "Degraded"},
+			},
+		},
+		{
+			name: "healthy",
+			status: map[string]interface{}{
+				"operationState": map[string]interface{}{"phase": "Succeeded", "startedAt": "2023-03-01T12:00:01Z"},
+				"sync":           map[string]interface{}{"status": "Synced"},
+				"health":         map[string]interface{}{"status": "Healthy"},
+			},
+			done: true,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			obj := gitOpsTestObject("argoproj.io/v1alpha1", "Application", "operator")
+			obj.Object["status"] = test.status
+
+			done, _, err := argoSynced(obj, requested)
+			assert.Equal(t, test.done, done)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
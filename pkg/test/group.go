@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/env"
+)
+
+// An AssertGroup is a loaded harness.TestAssertGroup, it contains the expected objects and the commands of the group.
+type AssertGroup struct {
+	Asserts  []client.Object
+	Commands []harness.TestAssertCommand
+	// Timeout of the group in seconds, 0 uses the timeout of the test step.
+	Timeout int
+}
+
+// loadAssertGroups loads the expected objects of the assertion groups, files are relative to the test step directory.
+func (s *Step) loadAssertGroups(groups []harness.TestAssertGroup) ([]AssertGroup, error) {
+	var loaded []AssertGroup
+
+	for i, group := range groups {
+		asserts := []client.Object{}
+		for _, file := range group.Files {
+			exFile := env.Expand(file)
+			objs, err := objectsFromPath(exFile, s.Dir, s.jsonnetVars())
+			if err != nil {
+				return nil, fmt.Errorf("assert group %d path %s: %w", i, exFile, err)
+			}
+			asserts = append(asserts, objs...)
+		}
+		loaded = append(loaded, AssertGroup{
+			Asserts:  asserts,
+			Commands: group.Commands,
+			Timeout:  group.Timeout,
+		})
+	}
+
+	return loaded, nil
+}
+
+// groupTimeout returns the timeout of an assertion group.
+func (s *Step) groupTimeout(group AssertGroup) int {
+	if group.Timeout != 0 {
+		return group.Timeout
+	}
+	return s.GetTimeout()
+}
+
+// maxTimeout returns the time the asserts of a test step may take, the longest of the step, object and group timeouts.
+func (s *Step) maxTimeout() int {
+	timeout := max(s.GetTimeout(), s.maxObjectTimeout())
+	for _, groups := range [][]AssertGroup{s.AnyOf, s.AllOf} {
+		for _, group := range groups {
+			if t := s.groupTimeout(group); t > timeout {
+				timeout = t
+			}
+		}
+	}
+	return timeout
+}
+
+// checkGroup checks the expected objects and the commands of an assertion group.
+func (s *Step) checkGroup(group AssertGroup, namespace string, timeout int) []error {
+	testErrors := []error{}
+
+	for _, expected := range group.Asserts {
+		testErrors = append(testErrors, s.CheckResource(expected, namespace)...)
+	}
+	testErrors = append(testErrors, s.CheckAssertCommands(context.TODO(), namespace, group.Commands, timeout)...)
+
+	return testErrors
+}
+
+// CheckGroups checks the anyOf and allOf assertion groups, elapsed is the number of seconds the step has been asserting.
+// The returned bool is true when retrying cannot make the groups pass anymore because groups that did not pass ran
+// out of time: all of the anyOf groups, or any of the allOf groups.
+func (s *Step) CheckGroups(namespace string, elapsed float64) ([]error, bool) {
+	testErrors := []error{}
+	expired := false
+
+	if len(s.AnyOf) > 0 {
+		anyOfErrors := []error{}
+		live := 0
+		passed := false
+
+		for i, group := range s.AnyOf {
+			timeout := float64(s.groupTimeout(group))
+			if elapsed >= timeout {
+				anyOfErrors = append(anyOfErrors, fmt.Errorf("anyOf group %d: timed out after %v seconds", i, timeout))
+				continue
+			}
+			live++
+
+			errs := s.checkGroup(group, namespace, remainingTimeout(timeout, elapsed))
+			if len(errs) == 0 {
+				passed = true
+				break
+			}
+			for _, err := range errs {
+				anyOfErrors = append(anyOfErrors, fmt.Errorf("anyOf group %d: %w", i, err))
+			}
+		}
+
+		if !passed {
+			testErrors = append(testErrors, anyOfErrors...)
+			expired = live == 0
+		}
+	}
+
+	for i, group := range s.AllOf {
+		timeout := float64(s.groupTimeout(group))
+
+		errs := s.checkGroup(group, namespace, remainingTimeout(timeout, elapsed))
+		if len(errs) != 0 && elapsed >= timeout {
+			expired = true
+		}
+		for _, err := range errs {
+			testErrors = append(testErrors, fmt.Errorf("allOf group %d: %w", i, err))
+		}
+	}
+
+	return testErrors, expired
+}
+
+// remainingTimeout returns the number of seconds left of timeout, it is always at least a second so that
+// commands run after a timeout passed are not run without a timeout.
+func remainingTimeout(timeout, elapsed float64) int {
+	return max(int(timeout-elapsed), 1)
+}
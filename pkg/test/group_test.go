@@ -0,0 +1,177 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCheckGroups(t *testing.T) {
+	deployment := AssertGroup{Asserts: []client.Object{testutils.NewResource("apps/v1", "Deployment", "app", "")}}
+	statefulSet := AssertGroup{Asserts: []client.Object{testutils.NewResource("apps/v1", "StatefulSet", "app", "")}}
+	pod := AssertGroup{Asserts: []client.Object{testutils.NewPod("hello", "")}}
+	withTimeout := func(group AssertGroup, timeout int) AssertGroup {
+		group.Timeout = timeout
+		return group
+	}
+
+	for _, test := range []struct {
+		testName     string
+		actual       []runtime.Object
+		anyOf        []AssertGroup
+		allOf        []AssertGroup
+		elapsed      float64
+		shouldError  bool
+		shouldExpire bool
+	}{
+		{
+			testName: "no groups",
+		},
+		{
+			testName: "anyOf first group matches",
+			actual:   []runtime.Object{testutils.NewResource("apps/v1", "Deployment", "app", testNamespace)},
+			anyOf:    []AssertGroup{deployment, statefulSet},
+		},
+		{
+			testName: "anyOf second group matches",
+			actual:   []runtime.Object{testutils.NewResource("apps/v1", "StatefulSet", "app", testNamespace)},
+			anyOf:    []AssertGroup{deployment, statefulSet},
+		},
+		{
+			testName:    "anyOf no group matches",
+			anyOf:       []AssertGroup{deployment, statefulSet},
+			shouldError: true,
+		},
+		{
+			testName:     "anyOf all groups timed out",
+			actual:       []runtime.Object{testutils.NewResource("apps/v1", "StatefulSet", "app", testNamespace)},
+			anyOf:        []AssertGroup{withTimeout(deployment, 2), withTimeout(statefulSet, 2)},
+			elapsed:      3,
+			shouldError:  true,
+			shouldExpire: true,
+		},
+		{
+			testName:    "anyOf one group timed out",
+			anyOf:       []AssertGroup{withTimeout(deployment, 2), statefulSet},
+			elapsed:     3,
+			shouldError: true,
+		},
+		{
+			testName: "allOf all groups match",
+			actual: []runtime.Object{
+				testutils.NewResource("apps/v1", "Deployment", "app", testNamespace),
+				testutils.NewPod("hello", testNamespace),
+			},
+			allOf: []AssertGroup{deployment, pod},
+		},
+		{
+			testName:    "allOf one group does not match",
+			actual:      []runtime.Object{testutils.NewPod("hello", testNamespace)},
+			allOf:       []AssertGroup{deployment, pod},
+			shouldError: true,
+		},
+		{
+			testName:     "allOf failing group timed out",
+			actual:       []runtime.Object{testutils.NewPod("hello", testNamespace)},
+			allOf:        []AssertGroup{withTimeout(deployment, 2), pod},
+			elapsed:      3,
+			shouldError:  true,
+			shouldExpire: true,
+		},
+		{
+			testName: "allOf passing group timed out",
+			actual: []runtime.Object{
+				testutils.NewResource("apps/v1", "Deployment", "app", testNamespace),
+				testutils.NewPod("hello", testNamespace),
+			},
+			allOf:   []AssertGroup{withTimeout(deployment, 2), pod},
+			elapsed: 3,
+		},
+		{
+			testName:    "anyOf and allOf",
+			actual:      []runtime.Object{testutils.NewResource("apps/v1", "Deployment", "app", testNamespace)},
+			anyOf:       []AssertGroup{deployment, statefulSet},
+			allOf:       []AssertGroup{pod},
+			shouldError: true,
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			step := Step{
+				Timeout: 30,
+				AnyOf:   test.anyOf,
+				AllOf:   test.allOf,
+				Logger:  testutils.NewTestLogger(t, ""),
+				Client: func(bool) (client.Client, error) {
+					return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(test.actual...).Build(), nil
+				},
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			errors, expired := step.CheckGroups(testNamespace, test.elapsed)
+			if test.shouldError {
+				assert.NotEqual(t, []error{}, errors)
+			} else {
+				assert.Equal(t, []error{}, errors)
+			}
+			assert.Equal(t, test.shouldExpire, expired)
+		})
+	}
+}
+
+func TestMaxTimeout(t *testing.T) {
+	step := Step{
+		Timeout: 30,
+		AnyOf:   []AssertGroup{{Timeout: 10}, {}},
+		AllOf:   []AssertGroup{{Timeout: 60}},
+	}
+	assert.Equal(t, 60, step.maxTimeout())
+	assert.Equal(t, 10, step.groupTimeout(step.AnyOf[0]))
+	assert.Equal(t, 30, step.groupTimeout(step.AnyOf[1]))
+}
+
+func TestLoadAssertGroups(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "variants"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "variants", "deployment.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "00-assert.yaml"), []byte(`apiVersion: kuttl.dev/v1beta1
+kind: TestAssert
+anyOf:
+- files:
+  - variants/deployment.yaml
+allOf:
+- timeout: 5
+  commands:
+  - command: "true"
+`), 0600))
+
+	step := Step{Dir: dir}
+	assert.NoError(t, step.LoadYAML(filepath.Join(dir, "00-assert.yaml")))
+
+	assert.Len(t, step.AnyOf, 1)
+	assert.Len(t, step.AnyOf[0].Asserts, 1)
+	assert.Equal(t, "Deployment", step.AnyOf[0].Asserts[0].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, []AssertGroup{{
+		Asserts:  []client.Object{},
+		Commands: []harness.TestAssertCommand{{Command: "true"}},
+		Timeout:  5,
+	}}, step.AllOf)
+
+	_, err := step.loadAssertGroups([]harness.TestAssertGroup{{Files: []string{"missing.yaml"}}})
+	assert.Error(t, err)
+}
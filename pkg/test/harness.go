@@ -5,11 +5,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -21,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -31,6 +34,7 @@ import (
 	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
 	"github.com/kudobuilder/kuttl/pkg/file"
 	"github.com/kudobuilder/kuttl/pkg/http"
+	"github.com/kudobuilder/kuttl/pkg/metrics"
 	"github.com/kudobuilder/kuttl/pkg/report"
 	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
 )
@@ -48,17 +52,54 @@ type Harness struct {
 	dclient       discovery.DiscoveryInterface
 	env           *envtest.Environment
 	kind          *kind
+	cluster       ClusterProvider
+	registry      *registry
+	secrets       map[string]string
+	clusterDomain string
+	suppressions  *Suppressions
+	snapshot      clusterSnapshot
 	tempPath      string
+	kubeconfig    string
 	clientLock    sync.Mutex
 	configLock    sync.Mutex
 	stopping      bool
 	bgProcesses   []*exec.Cmd
 	report        *report.Testsuites
+	metrics       *metrics.Metrics
+	metricsServer io.Closer
+	seed          int64
+	random        *rand.Rand
+	logs          *logStreamer
+	tracker       *runTracker
+	suiteTimer    *time.Timer
+	abortOnce     sync.Once
+	// ctx is the context of the run, cancel cancels it with the reason of the abort.
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	// abortLock guards the reason of the abort and what was running, they are reported on the goroutine of T.
+	abortLock     sync.Mutex
+	abortReason   string
+	abortMessages []string
+	abortReported bool
+	failures      int32
+	timings       *timingTable
 	RunLabels     labels.Set
+	// StepHandlers are the handlers of custom step kinds of the kuttl.dev group by kind.
+	StepHandlers map[string]StepHandler
+	// SharedKIND is the kind cluster shared with the other test suites of the invocation, it is used if the suite
+	// starts kind and sets shareKindCluster.
+	SharedKIND *SharedKIND
 }
 
 // LoadTests loads all of the tests in a given directory.
 func (h *Harness) LoadTests(dir string) ([]*Case, error) {
+	h.T.Logf("going to run test suite with timeout of %d seconds for each step", h.GetTimeout())
+	return h.loadTests(dir)
+}
+
+// loadTests returns the test cases of the directory, their steps are not loaded yet.
+func (h *Harness) loadTests(dir string) ([]*Case, error) {
+	suite := dir
 	dir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, err
@@ -72,8 +113,8 @@ func (h *Harness) LoadTests(dir string) ([]*Case, error) {
 	var tests []*Case
 
 	timeout := h.GetTimeout()
-	h.T.Logf("going to run test suite with timeout of %d seconds for each step", timeout)
-
+	suiteContext := h.testContext()
+	suiteContext.Suite = suite
 	for _, file := range files {
 		if !file.IsDir() {
 			continue
@@ -88,6 +129,27 @@ func (h *Harness) LoadTests(dir string) ([]*Case, error) {
 			SkipDelete:         h.TestSuite.SkipDelete,
 			Suppress:           h.TestSuite.Suppress,
 			RunLabels:          h.RunLabels,
+			Seed:               h.seed,
+			FromStep:           h.TestSuite.FromStep,
+			Stage:              h.TestSuite.Stage,
+			ReportColors:       h.TestSuite.ReportColors,
+			Context:            suiteContext,
+			JsonnetVars:        h.jsonnetVars(),
+			DuplicateObjects:   h.TestSuite.DuplicateObjects,
+			Overlays:           h.TestSuite.Overlays,
+			NamespaceQuota:     h.TestSuite.NamespaceQuota,
+			EventJournal:       h.TestSuite.EventJournal,
+			Suppressions:       h.suppressions,
+
+			AllowHelperPodTraffic: h.TestSuite.AllowHelperPodTraffic,
+			FailOnDeprecatedAPIs:  h.TestSuite.FailOnDeprecatedAPIs,
+			Hermetic:              h.TestSuite.Hermetic,
+			ProbeImage:            h.TestSuite.ProbeImage,
+			HelperPods:            h.TestSuite.HelperPods,
+			StepHandlers:          h.stepHandlers(),
+			SafeMode:              h.TestSuite.SafeMode,
+			DetectDrift:           h.TestSuite.DetectDrift,
+			IPFamily:              h.TestSuite.IPFamily,
 		})
 	}
 
@@ -112,6 +174,24 @@ func (h *Harness) GetTimeout() int {
 	return timeout
 }
 
+// EffectiveTestSuite returns the test suite of the harness as the tests are run with it, the defaults are applied to
+// the settings which are not set.
+func (h *Harness) EffectiveTestSuite() harness.TestSuite {
+	suite := *h.TestSuite.DeepCopy()
+	suite.Timeout = h.GetTimeout()
+	if suite.Parallel <= 0 {
+		suite.Parallel = defaultParallel
+	}
+	if suite.CleanupParallel <= 0 {
+		suite.CleanupParallel = suite.Parallel
+	}
+	if suite.KINDContext == "" {
+		suite.KINDContext = harness.DefaultKINDContext
+	}
+	suite.ReportName = h.reportName()
+	return suite
+}
+
 // RunKIND starts a KIND cluster.
 func (h *Harness) RunKIND() (*rest.Config, error) {
 	if h.kind == nil {
@@ -145,6 +225,14 @@ func (h *Harness) RunKIND() (*rest.Config, error) {
 			}
 		}
 
+		applyKindFeatures(kindCfg, h.TestSuite.KINDFeatureGates, h.TestSuite.KINDRuntimeConfig)
+		if h.TestSuite.IPFamily != "" {
+			kindCfg.Networking.IPFamily = kindConfig.ClusterIPFamily(h.TestSuite.IPFamily)
+		}
+		if err := validateKindFeatures(kindCfg); err != nil {
+			return nil, fmt.Errorf("invalid kind configuration: %w", err)
+		}
+
 		dockerClient, err := h.DockerClient()
 		if err != nil {
 			return nil, err
@@ -155,12 +243,36 @@ func (h *Harness) RunKIND() (*rest.Config, error) {
 
 		h.addNodeCaches(dockerClient, kindCfg)
 
+		if h.TestSuite.KINDRegistry {
+			h.registry = newRegistry(h.TestSuite.KINDContext, h.TestSuite.KINDRegistryPort)
+			h.registry.configure(kindCfg)
+			h.T.Logf("starting local container registry %s on %s", h.registry.name, h.registry.address)
+			if err := h.registry.start(context.TODO(), dockerClient); err != nil {
+				return nil, err
+			}
+		}
+
 		h.T.Log("Starting KIND cluster")
 		if err := h.kind.Run(kindCfg); err != nil {
 			return nil, err
 		}
 
-		if err := h.kind.AddContainers(dockerClient, h.TestSuite.KINDContainers, h.T); err != nil {
+		if h.registry != nil {
+			if err := h.registry.connect(context.TODO(), dockerClient); err != nil {
+				return nil, err
+			}
+			// commands inherit the environment of the harness
+			if err := os.Setenv(RegistryEnv, h.registry.address); err != nil {
+				return nil, err
+			}
+		}
+
+		built, err := h.buildImages(dockerClient)
+		if err != nil {
+			return nil, err
+		}
+		containers := append(append([]string{}, h.TestSuite.KINDContainers...), built...)
+		if err := h.kind.AddContainers(dockerClient, containers, h.T); err != nil {
 			return nil, err
 		}
 	}
@@ -215,7 +327,10 @@ func (h *Harness) addNodeCaches(dockerClient testutils.DockerClient, kindCfg *ki
 func (h *Harness) RunTestEnv() (*rest.Config, error) {
 	started := time.Now()
 
-	testenv, err := testutils.StartTestEnvironment(h.TestSuite.AttachControlPlaneOutput)
+	testenv, err := testutils.StartTestEnvironmentWithOptions(testutils.TestEnvironmentOptions{
+		AttachControlPlaneOutput: h.TestSuite.AttachControlPlaneOutput,
+		IPFamily:                 h.TestSuite.IPFamily,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +346,7 @@ func (h *Harness) RunTestEnv() (*rest.Config, error) {
 // Config returns the current Kubernetes configuration - either from the environment
 // or from the created temporary control plane.
 // As a side effect, on first successful call this method also writes a kubernetes client config file in YAML format
-// to a file called "kubeconfig" in the current directory.
+// to a file called "kuttl-kubeconfig" in the temp folder of the harness, the commands of the test suite use it.
 func (h *Harness) Config() (*rest.Config, error) {
 	h.configLock.Lock()
 	defer h.configLock.Unlock()
@@ -248,9 +363,16 @@ func (h *Harness) Config() (*rest.Config, error) {
 	case h.TestSuite.StartControlPlane:
 		h.T.Log("running tests with a mocked control plane (kube-apiserver and etcd).")
 		h.config, err = h.RunTestEnv()
+	case h.TestSuite.StartCluster != nil:
+		h.T.Log("running tests with a cluster of the startCluster provider.")
+		h.config, err = h.RunCluster()
 	case h.TestSuite.StartKIND:
 		h.T.Log("running tests with KIND.")
-		h.config, err = h.RunKIND()
+		if shared := h.sharedKIND(); shared != nil {
+			h.config, err = shared.start(h)
+		} else {
+			h.config, err = h.RunKIND()
+		}
 	default:
 		h.T.Log("running tests using configured kubeconfig.")
 		h.config, err = config.GetConfig()
@@ -280,14 +402,24 @@ func (h *Harness) Config() (*rest.Config, error) {
 
 	// The creation of the "kubeconfig" is necessary for out of cluster execution of kubectl,
 	// as well as in-cluster when the supplied KUBECONFIG is some *other* cluster.
-	f, err := os.Create("kubeconfig")
-	if err != nil {
+	// It is written into the temp folder, harnesses running in the same working directory must not share it.
+	if err := h.initTempPath(); err != nil {
 		return nil, err
 	}
+	h.kubeconfig = filepath.Join(h.tempPath, "kuttl-kubeconfig")
+
+	return h.config, writeKubeconfig(h.config, h.kubeconfig)
+}
 
+// writeKubeconfig writes a kubeconfig for cfg to the file at path.
+func writeKubeconfig(cfg *rest.Config, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
 	defer f.Close()
 
-	return h.config, testutils.Kubeconfig(h.config, f)
+	return testutils.Kubeconfig(cfg, f)
 }
 
 func (h *Harness) waitForFunctionalCluster() error {
@@ -320,6 +452,9 @@ func (h *Harness) Client(forceNew bool) (client.Client, error) {
 		return nil, err
 	}
 
+	if testutils.V(testutils.LogClient, 1) {
+		h.GetLogger().Logf("creating Kubernetes client (forced: %t)", forceNew)
+	}
 	h.client, err = testutils.NewRetryClient(cfg, client.Options{
 		Scheme: testutils.Scheme(),
 	})
@@ -340,6 +475,9 @@ func (h *Harness) DiscoveryClient() (discovery.DiscoveryInterface, error) {
 		return nil, err
 	}
 
+	if testutils.V(testutils.LogDiscovery, 1) {
+		h.GetLogger().Log("creating discovery client")
+	}
 	h.dclient, err = discovery.NewDiscoveryClientForConfig(cfg)
 	return h.dclient, err
 }
@@ -361,30 +499,74 @@ func (h *Harness) RunTests() {
 	// cleanup after running tests
 	h.T.Cleanup(h.Stop)
 	h.T.Log("running tests")
+	h.tracker.setPhase("running tests")
 
 	testDirs := h.testPreProcessing()
 
 	//todo: testsuite + testsuites (extend case to have what we need (need testdir here)
 	// TestSuite is a TestSuiteCollection and should be renamed for v1beta2
+	filter, err := newTestFilter(h.TestSuite.Tests, h.TestSuite.SkipTests)
+	if err != nil {
+		h.T.Fatal(err)
+	}
+
 	realTestSuite := make(map[string][]*Case)
 	for _, testDir := range testDirs {
 		tempTests, err := h.LoadTests(testDir)
 		if err != nil {
 			h.T.Fatal(err)
 		}
+		if selected := filter.filter(testDir, tempTests); len(selected) != len(tempTests) {
+			h.T.Logf("testsuite: %s selected %d of %d tests", testDir, len(selected), len(tempTests))
+			tempTests = selected
+		}
 		h.T.Logf("testsuite: %s has %d tests", testDir, len(tempTests))
+		if err := h.checkHermeticTests(tempTests); err != nil {
+			h.T.Fatal(err)
+		}
+		if h.TestSuite.Shuffle {
+			shuffleTests(tempTests, h.random)
+		}
 		// array of test cases tied to testsuite (by testdir)
 		realTestSuite[testDir] = tempTests
 	}
 
+	allTests := []*Case{}
+	for _, testDir := range testDirs {
+		allTests = append(allTests, realTestSuite[testDir]...)
+	}
+	if err := h.checkCollisions(allTests); err != nil {
+		h.T.Fatal(err)
+	}
+	if err := h.snapshotCluster(); err != nil {
+		h.T.Fatal(err)
+	}
+
+	limiter, err := newConcurrencyLimiter(h.TestSuite.Parallel, h.TestSuite.ConcurrencyGroups)
+	if err != nil {
+		h.T.Fatal(err)
+	}
+
+	cleanupParallel := h.TestSuite.CleanupParallel
+	if cleanupParallel <= 0 {
+		cleanupParallel = h.TestSuite.Parallel
+	}
+	cleanups := newCleanupPool(cleanupParallel)
+
 	h.T.Run("harness", func(t *testing.T) {
-		for testDir, tests := range realTestSuite {
+		// test dirs are iterated in order so that the test order is reproducible
+		for _, testDir := range testDirs {
+			tests := realTestSuite[testDir]
 			suite := h.report.NewSuite(testDir)
 			for _, test := range tests {
 				test := test
 
 				test.Client = h.Client
 				test.DiscoveryClient = h.DiscoveryClient
+				test.Config = h.Config
+				test.DeferCleanup = cleanups.submit
+				test.RunContext = h.runContext()
+				test.timings = h.timings
 
 				t.Run(test.Name, func(t *testing.T) {
 					// testing.T.Parallel may block, so run it before we read time for our
@@ -396,18 +578,85 @@ func (h *Harness) RunTests() {
 					if err := test.LoadTestSteps(); err != nil {
 						t.Fatal(err)
 					}
+					// the steps of finished tests are released, so that the objects of suites with thousands of tests
+					// are not kept in memory until the end of the run
+					defer test.releaseSteps()
+
+					release, err := limiter.acquire(test.ConcurrencyGroups())
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer release()
+
+					// the tests which did not start before the run was aborted are skipped
+					if err := abortErr(h.runContext()); err != nil {
+						tc := report.NewCase(test.Name)
+						tc.Skipped = &report.Skipped{Message: err.Error()}
+						h.tracker.addSkipped(suite, tc)
+						t.Skip(err)
+					}
+
+					if err := h.logs.StartTest(test.Name); err != nil {
+						t.Fatal(err)
+					}
+					defer h.logs.EndTest(test.Name)
 
 					tc := report.NewCase(test.Name)
+					name := filepath.Join(testDir, test.Name)
+					h.tracker.start(name, suite, tc)
+					test.Progress = h.tracker.progress(name)
+					timer := h.startTimeout(h.TestSuite.TestTimeout, fmt.Sprintf("test %s exceeded the test timeout of %ds", name, h.TestSuite.TestTimeout))
+					// the test is tracked until its cleanups have run, its namespace is deleted in the background afterwards
+					t.Cleanup(func() {
+						if timer != nil {
+							timer.Stop()
+						}
+						h.tracker.end(name)
+					})
+
 					test.Run(t, tc)
-					suite.AddTestcase(tc)
+					h.tracker.addTestcase(name, suite, tc)
+					if tc.Skipped != nil {
+						t.Skip(tc.Skipped.Message)
+					}
+					h.metrics.AddTest(testDir, test.Name, time.Since(tc.Timestamp), t.Failed(), test.Retries())
+					if t.Failed() {
+						h.countFailure()
+					}
 				})
 			}
 		}
 	})
+	h.reportAbort()
+
+	if running := cleanups.running(); running > 0 {
+		h.T.Logf("waiting for %d namespace deletions", running)
+	}
+	for _, err := range cleanups.wait() {
+		h.T.Error(err)
+	}
+	// the cluster is compared once the namespaces of the tests are deleted
+	differences, err := h.checkClusterSnapshot()
+	if err != nil {
+		h.T.Error(err)
+	}
+	for _, difference := range differences {
+		h.T.Errorf("cluster snapshot: %s", difference)
+	}
+	for _, suppression := range h.suppressions.Unused() {
+		h.T.Logf("suppression of %s did not suppress any difference, it can be removed from %s", suppression, h.TestSuite.SuppressionsFile)
+	}
 
 	h.T.Log("run tests finished")
 }
 
+// shuffleTests shuffles the tests in an order determined by the random source.
+func shuffleTests(tests []*Case, r *rand.Rand) {
+	// sort first so that the order does not depend on the order of the directory listing
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+	r.Shuffle(len(tests), func(i, j int) { tests[i], tests[j] = tests[j], tests[i] })
+}
+
 // testPreProcessing provides preprocessing bring all tests suites local if there are any refers to URLs
 func (h *Harness) testPreProcessing() []string {
 	testDirs := []string{}
@@ -438,7 +687,11 @@ func (h *Harness) testPreProcessing() []string {
 			testDirs = append(testDirs, dir)
 		}
 	}
-	return testDirs
+	helmDirs, err := h.helmTestDirs()
+	if err != nil {
+		h.T.Fatal(err)
+	}
+	return append(testDirs, helmDirs...)
 }
 
 // Run the test harness - start the control plane and then run the tests.
@@ -460,10 +713,55 @@ func (h *Harness) Run() {
 // Setup spins up the test env based on configuration
 // It can be used to start env which can than be modified prior to running tests, otherwise use Run().
 func (h *Harness) Setup() {
-	rand.Seed(time.Now().UTC().UnixNano())
+	h.seed = h.TestSuite.Seed
+	if h.seed == 0 {
+		h.seed = time.Now().UTC().UnixNano()
+	}
+	h.T.Logf("using random seed %d, rerun with --seed %d to reproduce namespace names and test order", h.seed, h.seed)
+	h.random = rand.New(rand.NewSource(h.seed))
+	h.ctx, h.cancel = context.WithCancelCause(context.Background())
 	h.report = report.NewSuiteCollection(h.TestSuite.Name)
+	runMetrics, err := metrics.New(h.RunLabels)
+	if err != nil {
+		h.fatal(fmt.Errorf("invalid run labels: %w", err))
+	}
+	h.metrics = runMetrics
+	h.tracker = newRunTracker()
+	switch h.TestSuite.TimingsFormat {
+	case "":
+	case TimingsCSV, TimingsJSON:
+		h.timings = &timingTable{}
+	default:
+		h.fatal(fmt.Errorf("unknown timings format %q, it must be %s or %s", h.TestSuite.TimingsFormat, TimingsCSV, TimingsJSON))
+	}
+	h.suiteTimer = h.startTimeout(h.TestSuite.SuiteTimeout, fmt.Sprintf("test suite exceeded the suite timeout of %ds", h.TestSuite.SuiteTimeout))
 	h.T.Log("starting setup")
 
+	if err := h.checkHermeticSuite(); err != nil {
+		h.fatal(err)
+	}
+
+	if err := h.resolveSecrets(); err != nil {
+		h.fatal(fmt.Errorf("fatal error resolving secrets: %v", err))
+	}
+
+	if h.TestSuite.SuppressionsFile != "" {
+		suppressions, err := LoadSuppressions(h.TestSuite.SuppressionsFile)
+		if err != nil {
+			h.fatal(fmt.Errorf("fatal error loading suppressions: %v", err))
+		}
+		h.suppressions = suppressions
+	}
+
+	if h.TestSuite.MetricsAddress != "" {
+		server, err := h.metrics.Serve(h.TestSuite.MetricsAddress)
+		if err != nil {
+			h.fatal(fmt.Errorf("fatal error exposing metrics: %v", err))
+		}
+		h.metricsServer = server
+		h.T.Logf("exposing run metrics on %s/metrics", h.TestSuite.MetricsAddress)
+	}
+
 	cl, err := h.Client(false)
 	if err != nil {
 		h.fatal(fmt.Errorf("fatal error getting client: %v", err))
@@ -474,6 +772,33 @@ func (h *Harness) Setup() {
 		h.fatal(fmt.Errorf("fatal error getting discovery client: %v", err))
 	}
 
+	if err := h.setupClusterDNS(); err != nil {
+		h.fatal(fmt.Errorf("fatal error setting up the cluster domain: %v", err))
+	}
+
+	testutils.SetFragmentsDir(h.TestSuite.FragmentsDir)
+	// the registry is started with the cluster
+	if h.registry != nil {
+		if err := h.registry.document(context.TODO(), cl); err != nil {
+			h.fatal(fmt.Errorf("fatal error documenting local registry: %v", err))
+		}
+	}
+
+	if len(h.TestSuite.LogStreams) > 0 {
+		cfg, err := h.Config()
+		if err != nil {
+			h.fatal(fmt.Errorf("fatal error getting config for log streams: %v", err))
+		}
+		clientset, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			h.fatal(fmt.Errorf("fatal error getting clientset for log streams: %v", err))
+		}
+		h.logs = newLogStreamer(clientset, h.TestSuite.LogStreams, filepath.Join(h.TestSuite.ArtifactsDir, "logs"), h.GetLogger())
+		if err := h.logs.Start(); err != nil {
+			h.fatal(fmt.Errorf("fatal error starting log streams: %v", err))
+		}
+	}
+
 	// Install CRDs
 	crdKinds := []runtime.Object{
 		testutils.NewResource("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", ""),
@@ -497,28 +822,62 @@ func (h *Harness) Setup() {
 		h.fatal(fmt.Errorf("fatal error getting client after crd update: %v", err))
 	}
 
+	if err := h.installFixtures(context.TODO(), cl, dClient); err != nil {
+		h.fatal(fmt.Errorf("fatal error installing kind fixtures: %v", err))
+	}
+
 	// Install required manifests.
 	for _, manifestDir := range h.TestSuite.ManifestDirs {
 		if _, err := testutils.InstallManifests(context.TODO(), cl, dClient, manifestDir); err != nil {
 			h.fatal(fmt.Errorf("fatal error installing manifests: %v", err))
 		}
 	}
-	bgs, err := testutils.RunCommands(context.TODO(), h.GetLogger(), "default", h.TestSuite.Commands, "", h.TestSuite.Timeout, "")
+	ctx := testutils.WithCommandEnv(context.TODO(), h.testContext().Env())
+	if h.TestSuite.Hermetic {
+		ctx = testutils.WithBuiltinCommands(ctx)
+	}
+	bgs, err := testutils.RunCommands(ctx, h.GetLogger(), "default", h.TestSuite.Commands, "", h.TestSuite.Timeout, h.kubeconfig)
 	// assign any background processes first for cleanup in case of any errors
 	h.bgProcesses = append(h.bgProcesses, bgs...)
 	if err != nil {
 		h.fatal(fmt.Errorf("fatal error running commands: %v", err))
 	}
+
+	// commands may build or load the required images
+	if err := h.checkRequiredImages(context.TODO(), cl, "default"); err != nil {
+		h.fatal(fmt.Errorf("fatal error checking required images: %v", err))
+	}
 }
 
 // Stop the test environment and clean up the harness.
 func (h *Harness) Stop() {
 	h.T.Log("cleaning up")
+	if h.suiteTimer != nil {
+		h.suiteTimer.Stop()
+	}
+	h.reportAbort()
+	h.tracker.setPhase("cleanup")
 	if h.managerStopCh != nil {
 		close(h.managerStopCh)
 		h.managerStopCh = nil
 	}
 
+	// the shared kind cluster is only collected and deleted by the last test suite using it
+	keep := h.keepCluster()
+	shared := h.sharedKIND()
+	if shared != nil {
+		last, keepShared := shared.release(h)
+		if last {
+			keep = keepShared
+		} else {
+			// the cluster is kept for the next test suites, this suite only removes its own temp folder
+			h.T.Log("keeping the shared kind cluster for the next test suites")
+			h.kind = nil
+			h.registry = nil
+			keep = false
+		}
+	}
+
 	if h.kind != nil {
 		logDir := filepath.Join(h.TestSuite.ArtifactsDir, fmt.Sprintf("kind-logs-%d", time.Now().Unix()))
 
@@ -529,6 +888,14 @@ func (h *Harness) Stop() {
 		}
 	}
 
+	if h.cluster != nil {
+		logDir := filepath.Join(h.TestSuite.ArtifactsDir, fmt.Sprintf("cluster-logs-%d", time.Now().Unix()))
+
+		if err := h.cluster.CollectLogs(context.TODO(), logDir); err != nil {
+			h.T.Log("error collecting cluster logs", err)
+		}
+	}
+
 	if h.bgProcesses != nil {
 		for _, p := range h.bgProcesses {
 			h.T.Logf("killing process %q", p)
@@ -546,17 +913,36 @@ func (h *Harness) Stop() {
 		}
 	}
 
+	h.logs.Stop()
 	h.Report()
+	h.writeTimings()
+	h.PushMetrics()
 
-	if h.TestSuite.SkipClusterDelete {
-		cwd, err := os.Getwd()
-		if err != nil {
-			h.T.Logf("issue getting work directory %v", err)
+	if h.metricsServer != nil {
+		if err := h.metricsServer.Close(); err != nil {
+			h.T.Log("error closing metrics server", err)
 		}
-		kubeconfig := filepath.Join(cwd, "kubeconfig")
+		h.metricsServer = nil
+	}
 
-		h.T.Log("skipping cluster tear down")
-		h.T.Logf("to connect to the cluster, run: export KUBECONFIG=\"%s\"", kubeconfig)
+	if keep {
+		if h.TestSuite.SkipClusterDelete {
+			h.T.Log("skipping cluster tear down")
+		} else {
+			h.T.Log("tests failed, keeping cluster for debugging")
+		}
+		if h.kind != nil {
+			h.T.Logf("the kind cluster can be deleted with: kind delete cluster --name %s", h.kind.context)
+		}
+		if h.cluster != nil {
+			h.T.Logf("the cluster can be deleted with: %s", h.cluster.DeleteCommand())
+		}
+		if h.registry != nil && h.registry.created {
+			h.T.Logf("the local registry can be deleted with: docker rm -f %s", h.registry.name)
+		}
+		if h.kubeconfig != "" {
+			h.T.Logf("to connect to the cluster, run: export KUBECONFIG=\"%s\"", h.kubeconfig)
+		}
 
 		return
 	}
@@ -570,6 +956,16 @@ func (h *Harness) Stop() {
 		h.env = nil
 	}
 
+	// the kubeconfig of the cluster in the temp folder may be needed to delete it
+	if h.cluster != nil {
+		h.T.Log("tearing down cluster")
+		if err := h.cluster.Stop(context.TODO()); err != nil {
+			h.T.Log("error tearing down cluster", err)
+		}
+
+		h.cluster = nil
+	}
+
 	h.T.Logf("removing temp folder: %q", h.tempPath)
 	if err := os.RemoveAll(h.tempPath); err != nil {
 		h.T.Log("error removing temporary directory", err)
@@ -582,7 +978,40 @@ func (h *Harness) Stop() {
 		}
 
 		h.kind = nil
+		if shared != nil {
+			shared.cleanup(h)
+		}
+	}
+
+	if h.registry != nil {
+		h.T.Log("removing local container registry")
+		dockerClient, err := h.DockerClient()
+		if err == nil {
+			err = h.registry.remove(context.TODO(), dockerClient)
+		}
+		if err != nil {
+			h.T.Log("error removing local container registry", err)
+		}
+
+		h.registry = nil
+	}
+}
+
+// keepCluster returns true if the mocked control plane, kind cluster or cluster of the startCluster provider should not
+// be torn down.
+func (h *Harness) keepCluster() bool {
+	if h.TestSuite.SkipClusterDelete {
+		return true
+	}
+	return h.TestSuite.KeepClusterOnFailure && h.failed()
+}
+
+// failed returns true if the harness setup or any of the tests failed.
+func (h *Harness) failed() bool {
+	if h.report != nil && h.report.Failure != nil {
+		return true
 	}
+	return h.T.Failed()
 }
 
 // wraps Test.Fatal in order to clean up harness
@@ -599,6 +1028,9 @@ func (h *Harness) fatal(err error) {
 }
 
 func (h *Harness) kubeconfigPath() string {
+	if shared := h.sharedKIND(); shared != nil {
+		return shared.kubeconfigPath()
+	}
 	return filepath.Join(h.tempPath, "kubeconfig")
 }
 
@@ -618,7 +1050,27 @@ func (h *Harness) reportName() string {
 	if h.TestSuite.ReportName != "" {
 		return h.TestSuite.ReportName
 	}
-	return "kuttl-report"
+	return report.DefaultName
+}
+
+// PushMetrics pushes the run metrics to the configured Prometheus Pushgateway.  If no Pushgateway is configured it is skipped.
+// A failure to push is logged but does not fail the test run.
+func (h *Harness) PushMetrics() {
+	if h.TestSuite.MetricsPushgatewayURL == "" || h.metrics == nil {
+		return
+	}
+	h.T.Logf("pushing run metrics to %s", h.TestSuite.MetricsPushgatewayURL)
+	if err := h.metrics.Push(context.TODO(), h.TestSuite.MetricsPushgatewayURL, h.metricsJob()); err != nil {
+		h.T.Log("error pushing run metrics", err)
+	}
+}
+
+// metricsJob returns the Pushgateway job name, which is the test suite name if set.
+func (h *Harness) metricsJob() string {
+	if h.TestSuite.Name != "" {
+		return h.TestSuite.Name
+	}
+	return "kuttl"
 }
 
 func (h *Harness) loadKindConfig(path string) (*kindConfig.Cluster, error) {
@@ -2,14 +2,23 @@ package test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"strings"
 	"testing"
 
 	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
 	kindConfig "sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
 )
 
 func TestGetTimeout(t *testing.T) {
@@ -28,9 +37,39 @@ func TestGetReportName(t *testing.T) {
 	assert.Equal(t, "special-kuttl-report", h.reportName())
 }
 
+func TestKeepCluster(t *testing.T) {
+	h := Harness{T: t, report: report.NewSuiteCollection("")}
+	assert.False(t, h.keepCluster())
+
+	h.TestSuite.KeepClusterOnFailure = true
+	assert.False(t, h.keepCluster())
+
+	h.report.SetFailure("setup failed")
+	assert.True(t, h.keepCluster())
+
+	h = Harness{T: t, report: report.NewSuiteCollection("")}
+	h.TestSuite.SkipClusterDelete = true
+	assert.True(t, h.keepCluster())
+}
+
 type dockerMock struct {
 	ImageWriter *io.PipeWriter
 	imageReader *io.PipeReader
+
+	// existing is the state of an existing container, connectErr the error connecting containers to networks.
+	existing   *dockertypes.ContainerState
+	connectErr error
+
+	pulled    []string
+	created   []*container.HostConfig
+	started   []string
+	connected []string
+	removed   []string
+
+	// images are the labels of the existing images by name, buildOutput the output of builds.
+	images      map[string]map[string]string
+	buildOutput string
+	built       []dockertypes.ImageBuildOptions
 }
 
 func newDockerMock() *dockerMock {
@@ -54,6 +93,64 @@ func (d *dockerMock) ImageSave(_ context.Context, _ []string) (io.ReadCloser, er
 	return d.imageReader, nil
 }
 
+func (d *dockerMock) ImagePull(_ context.Context, image string, _ dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+	d.pulled = append(d.pulled, image)
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image"}`)), nil
+}
+
+func (d *dockerMock) ImageBuild(_ context.Context, buildContext io.Reader, options dockertypes.ImageBuildOptions) (dockertypes.ImageBuildResponse, error) {
+	if _, err := io.Copy(io.Discard, buildContext); err != nil {
+		return dockertypes.ImageBuildResponse{}, err
+	}
+	d.built = append(d.built, options)
+	if d.images == nil {
+		d.images = map[string]map[string]string{}
+	}
+	for _, tag := range options.Tags {
+		d.images[tag] = options.Labels
+	}
+	output := d.buildOutput
+	if output == "" {
+		output = `{"stream":"Successfully built\n"}`
+	}
+	return dockertypes.ImageBuildResponse{Body: io.NopCloser(strings.NewReader(output))}, nil
+}
+
+func (d *dockerMock) ImageInspectWithRaw(_ context.Context, image string) (dockertypes.ImageInspect, []byte, error) {
+	labels, ok := d.images[image]
+	if !ok {
+		return dockertypes.ImageInspect{}, nil, errdefs.NotFound(errors.New("no such image: " + image))
+	}
+	return dockertypes.ImageInspect{Config: &container.Config{Labels: labels}}, nil, nil
+}
+
+func (d *dockerMock) ContainerInspect(_ context.Context, name string) (dockertypes.ContainerJSON, error) {
+	if d.existing == nil {
+		return dockertypes.ContainerJSON{}, errdefs.NotFound(errors.New("no such container: " + name))
+	}
+	return dockertypes.ContainerJSON{ContainerJSONBase: &dockertypes.ContainerJSONBase{ID: "existing", State: d.existing}}, nil
+}
+
+func (d *dockerMock) ContainerCreate(_ context.Context, _ *container.Config, hostConfig *container.HostConfig, _ *network.NetworkingConfig, _ *specs.Platform, _ string) (container.ContainerCreateCreatedBody, error) {
+	d.created = append(d.created, hostConfig)
+	return container.ContainerCreateCreatedBody{ID: "created"}, nil
+}
+
+func (d *dockerMock) ContainerStart(_ context.Context, id string, _ dockertypes.ContainerStartOptions) error {
+	d.started = append(d.started, id)
+	return nil
+}
+
+func (d *dockerMock) ContainerRemove(_ context.Context, id string, _ dockertypes.ContainerRemoveOptions) error {
+	d.removed = append(d.removed, id)
+	return nil
+}
+
+func (d *dockerMock) NetworkConnect(_ context.Context, network, id string, _ *network.EndpointSettings) error {
+	d.connected = append(d.connected, network+"/"+id)
+	return d.connectErr
+}
+
 func TestAddNodeCaches(t *testing.T) {
 	h := Harness{
 		T:      t,
@@ -89,3 +186,29 @@ func TestAddNodeCaches(t *testing.T) {
 	assert.Equal(t, "/var/lib/docker/data/kind-0", kindCfg.Nodes[0].ExtraMounts[0].HostPath)
 	assert.Equal(t, "/var/lib/docker/data/kind-1", kindCfg.Nodes[1].ExtraMounts[0].HostPath)
 }
+
+func TestShuffleTests(t *testing.T) {
+	names := func(tests []*Case) []string {
+		n := []string{}
+		for _, test := range tests {
+			n = append(n, test.Name)
+		}
+		return n
+	}
+	load := func(order ...string) []*Case {
+		tests := []*Case{}
+		for _, name := range order {
+			tests = append(tests, &Case{Name: name})
+		}
+		return tests
+	}
+
+	a := load("a", "b", "c", "d", "e", "f")
+	b := load("f", "e", "d", "c", "b", "a")
+	shuffleTests(a, rand.New(rand.NewSource(42)))
+	shuffleTests(b, rand.New(rand.NewSource(42)))
+
+	// the order only depends on the seed
+	assert.Equal(t, names(a), names(b))
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d", "e", "f"}, names(a))
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// defaultHelmValues is the glob of the test values files of a chart if none are set.
+const defaultHelmValues = "ci/*-values.yaml"
+
+// helmBinary is the helm binary the test cases of charts run, it is replaced in tests.
+var helmBinary = "helm"
+
+// helmValuesFiles returns the test values files of the chart, sorted.
+func helmValuesFiles(chart harness.HelmChartTests) ([]string, error) {
+	globs := chart.Values
+	if len(globs) == 0 {
+		globs = []string{defaultHelmValues}
+	}
+
+	seen := map[string]bool{}
+	files := []string{}
+	for _, glob := range globs {
+		matches, err := filepath.Glob(filepath.Join(chart.Chart, glob))
+		if err != nil {
+			return nil, fmt.Errorf("chart %s: values %s: %w", chart.Chart, glob, err)
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("chart %s has no test values files matching %s", chart.Chart, strings.Join(globs, ", "))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// helmTestName returns the name of the test case of a values file, ex. "ha" for "ci/ha-values.yaml".
+func helmTestName(valuesFile string) string {
+	name := filepath.Base(valuesFile)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if trimmed := strings.TrimSuffix(name, "-values"); trimmed != "" {
+		name = trimmed
+	}
+	return name
+}
+
+// generateHelmTests writes the test cases of the values files of the chart to dir, each in a directory named after
+// its values file.
+func generateHelmTests(chart harness.HelmChartTests, dir string) error {
+	chartDir, err := filepath.Abs(chart.Chart)
+	if err != nil {
+		return err
+	}
+	release := chart.Release
+	if release == "" {
+		release = filepath.Base(chartDir)
+	}
+	valuesFiles, err := helmValuesFiles(chart)
+	if err != nil {
+		return err
+	}
+
+	for _, valuesFile := range valuesFiles {
+		valuesFile, err := filepath.Abs(valuesFile)
+		if err != nil {
+			return err
+		}
+		name := helmTestName(valuesFile)
+		testDir := filepath.Join(dir, name)
+		if err := os.Mkdir(testDir, 0755); err != nil {
+			return fmt.Errorf("chart %s: values %s: %w", chart.Chart, valuesFile, err)
+		}
+
+		install := fmt.Sprintf(`apiVersion: kuttl.dev/v1beta1
+kind: TestStep
+commands:
+- command: %s
+  namespaced: true
+cleanup:
+- command: %s
+  namespaced: true
+  ignoreFailure: true
+`, strconv.Quote(helmCommand("upgrade", "--install", release, chartDir, "--values", valuesFile)),
+			strconv.Quote(helmCommand("uninstall", release)))
+		if err := os.WriteFile(filepath.Join(testDir, "00-install.yaml"), []byte(install), 0644); err != nil {
+			return err
+		}
+
+		assert, err := helmAssert(release, chartDir, valuesFile)
+		if err != nil {
+			return fmt.Errorf("chart %s: values %s: %w", chart.Chart, valuesFile, err)
+		}
+		if err := os.WriteFile(filepath.Join(testDir, "00-assert.yaml"), assert, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// helmCommand returns the helm command line with the arguments, quoting those containing spaces.
+func helmCommand(args ...string) string {
+	quoted := []string{helmBinary}
+	for _, arg := range args {
+		if strings.ContainsAny(arg, " \t'\"") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// helmAssert returns the assert of a values file, which expects the Deployments, StatefulSets and DaemonSets the chart
+// renders with the values to roll out, followed by the objects of the assert file next to the values file.
+func helmAssert(release, chartDir, valuesFile string) ([]byte, error) {
+	cmd := exec.Command(helmBinary, "template", release, chartDir, "--values", valuesFile)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("helm template: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	rendered, err := testutils.LoadYAML(valuesFile, &stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	var assert bytes.Buffer
+	for _, obj := range rendered {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		switch gvk.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet":
+		default:
+			continue
+		}
+		if assert.Len() > 0 {
+			assert.WriteString("---\n")
+		}
+		fmt.Fprintf(&assert, "apiVersion: %s\nkind: %s\nmetadata:\n  name: %s\n  annotations:\n    %s: \"true\"\n",
+			gvk.GroupVersion(), gvk.Kind, strconv.Quote(obj.GetName()), harness.RolledOutAnnotation)
+	}
+
+	extra, err := os.ReadFile(filepath.Join(filepath.Dir(valuesFile), helmTestName(valuesFile)+"-assert.yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(extra) > 0 {
+		if assert.Len() > 0 {
+			assert.WriteString("---\n")
+		}
+		assert.Write(extra)
+	}
+	if assert.Len() == 0 {
+		return nil, fmt.Errorf("the chart renders no Deployment, StatefulSet or DaemonSet to assert and there is no %s-assert.yaml", helmTestName(valuesFile))
+	}
+	return assert.Bytes(), nil
+}
+
+// helmTestDirs generates the test cases of the Helm charts of the suite and returns their test directories.
+func (h *Harness) helmTestDirs() ([]string, error) {
+	testDirs := []string{}
+	for _, chart := range h.TestSuite.HelmCharts {
+		if err := h.initTempPath(); err != nil {
+			return nil, err
+		}
+		h.T.Logf("generating the test cases of chart %s", chart.Chart)
+		dir, err := os.MkdirTemp(h.tempPath, "helm-"+filepath.Base(chart.Chart))
+		if err != nil {
+			return nil, err
+		}
+		if err := generateHelmTests(chart, dir); err != nil {
+			return nil, err
+		}
+		testDirs = append(testDirs, dir)
+	}
+	return testDirs, nil
+}
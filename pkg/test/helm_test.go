@@ -0,0 +1,98 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestHelmTestName(t *testing.T) {
+	assert.Equal(t, "ha", helmTestName("ci/ha-values.yaml"))
+	assert.Equal(t, "minimal", helmTestName("ci/minimal.yml"))
+	assert.Equal(t, "values", helmTestName("ci/values.yaml"))
+	assert.Equal(t, "-values", helmTestName("ci/-values.yaml"))
+}
+
+func TestGenerateHelmTests(t *testing.T) {
+	dir := t.TempDir()
+
+	// the fake helm binary renders a workload of each kind and a config map
+	binary := filepath.Join(dir, "helm")
+	script := `#!/bin/sh
+cat <<EOF
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: $2-web
+---
+# Source: web/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: $2-config
+---
+# Source: web/templates/statefulset.yaml
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: $2-db
+EOF
+`
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+	defer func(previous string) { helmBinary = previous }(helmBinary)
+	helmBinary = binary
+
+	chart := filepath.Join(dir, "web")
+	require.NoError(t, os.MkdirAll(filepath.Join(chart, "ci"), 0755))
+	for name, content := range map[string]string{
+		"ci/default-values.yaml": "replicas: 1\n",
+		"ci/ha-values.yaml":      "replicas: 3\n",
+		"ci/ha-assert.yaml":      "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web-web\nstatus:\n  readyReplicas: 3\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(chart, name), []byte(content), 0600))
+	}
+
+	testDir := filepath.Join(dir, "tests")
+	require.NoError(t, os.Mkdir(testDir, 0755))
+	require.NoError(t, generateHelmTests(harness.HelmChartTests{Chart: chart}, testDir))
+
+	tests, err := (&Harness{}).loadTests(testDir)
+	require.NoError(t, err)
+	require.Len(t, tests, 2)
+	assert.Equal(t, "default", tests[0].Name)
+	assert.Equal(t, "ha", tests[1].Name)
+
+	for _, test := range tests {
+		test.Logger = discardLogger{}
+		require.NoError(t, test.LoadTestSteps())
+		require.Len(t, test.Steps, 1)
+	}
+
+	step := tests[1].Steps[0]
+	require.Len(t, step.Step.Commands, 1)
+	assert.Equal(t, binary+" upgrade --install web "+chart+" --values "+filepath.Join(chart, "ci/ha-values.yaml"), step.Step.Commands[0].Command)
+	assert.True(t, step.Step.Commands[0].Namespaced)
+	require.Len(t, step.Step.Cleanup, 1)
+	assert.Equal(t, binary+" uninstall web", step.Step.Cleanup[0].Command)
+	assert.True(t, step.Step.Cleanup[0].IgnoreFailure)
+
+	require.Len(t, step.Asserts, 3)
+	assert.Equal(t, "web-web", step.Asserts[0].GetName())
+	assert.Equal(t, map[string]string{harness.RolledOutAnnotation: "true"}, step.Asserts[0].GetAnnotations())
+	assert.Equal(t, "StatefulSet", step.Asserts[1].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "web-db", step.Asserts[1].GetName())
+	// the objects of the assert file next to the values file are appended
+	assert.Equal(t, "web-web", step.Asserts[2].GetName())
+	assert.Empty(t, step.Asserts[2].GetAnnotations())
+	assert.Len(t, tests[0].Steps[0].Asserts, 2)
+
+	err = generateHelmTests(harness.HelmChartTests{Chart: chart, Values: []string{"ci/*-missing.yaml"}}, t.TempDir())
+	assert.EqualError(t, err, "chart "+chart+" has no test values files matching ci/*-missing.yaml")
+}
@@ -0,0 +1,27 @@
+package test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// scheduleHelperPod adds the scheduling constraints of the helper pods of the test suite to the spec of a helper pod,
+// scheduling may be nil.  The node selector does not apply to pods bound to a node.
+func scheduleHelperPod(spec *corev1.PodSpec, scheduling *harness.HelperPodScheduling) {
+	if scheduling == nil {
+		return
+	}
+	if spec.NodeName == "" && len(scheduling.NodeSelector) > 0 {
+		if spec.NodeSelector == nil {
+			spec.NodeSelector = map[string]string{}
+		}
+		for key, value := range scheduling.NodeSelector {
+			spec.NodeSelector[key] = value
+		}
+	}
+	spec.Tolerations = append(spec.Tolerations, scheduling.Tolerations...)
+	if scheduling.PriorityClassName != "" {
+		spec.PriorityClassName = scheduling.PriorityClassName
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestScheduleHelperPod(t *testing.T) {
+	scheduling := &harness.HelperPodScheduling{
+		NodeSelector:      map[string]string{"pool": "ci"},
+		Tolerations:       []corev1.Toleration{{Key: "ci", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+		PriorityClassName: "ci-high",
+	}
+
+	for _, test := range []struct {
+		name       string
+		pod        *corev1.Pod
+		scheduling *harness.HelperPodScheduling
+		expected   corev1.PodSpec
+	}{
+		{
+			name:     "no constraints",
+			pod:      imagePod("nginx", "", testNamespace, nil),
+			expected: imagePod("nginx", "", testNamespace, nil).Spec,
+		},
+		{
+			name:       "any node",
+			pod:        &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: map[string]string{"kubernetes.io/os": "linux"}}},
+			scheduling: scheduling,
+			expected: corev1.PodSpec{
+				NodeSelector:      map[string]string{"kubernetes.io/os": "linux", "pool": "ci"},
+				Tolerations:       scheduling.Tolerations,
+				PriorityClassName: "ci-high",
+			},
+		},
+		{
+			name:       "bound to a node",
+			pod:        &corev1.Pod{Spec: corev1.PodSpec{NodeName: "worker", Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}}}},
+			scheduling: scheduling,
+			expected: corev1.PodSpec{
+				NodeName:          "worker",
+				Tolerations:       append([]corev1.Toleration{{Operator: corev1.TolerationOpExists}}, scheduling.Tolerations...),
+				PriorityClassName: "ci-high",
+			},
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			scheduleHelperPod(&test.pod.Spec, test.scheduling)
+			assert.Equal(t, test.expected, test.pod.Spec)
+		})
+	}
+
+	// the constraints of the test suite are not modified
+	assert.Equal(t, map[string]string{"pool": "ci"}, scheduling.NodeSelector)
+	assert.Equal(t, "worker", imagePod("nginx", "worker", testNamespace, scheduling).Spec.NodeName)
+	assert.Empty(t, imagePod("nginx", "worker", testNamespace, scheduling).Spec.NodeSelector)
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/shlex"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// hostTool returns the host binary cmd relies on.  It is empty if cmd has a built-in implementation or runs an
+// executable of the suite, given as a relative path (ex. "./bin/tool").
+func hostTool(cmd harness.Command) (string, error) {
+	if cmd.Script != "" {
+		return testutils.ScriptShell()[0], nil
+	}
+
+	args, err := shlex.Split(cmd.Command)
+	if err != nil {
+		return "", fmt.Errorf("parsing command %q: %w", cmd.Command, err)
+	}
+	if len(args) == 0 || testutils.IsBuiltinCommand(args) {
+		return "", nil
+	}
+	return executableTool(args[0]), nil
+}
+
+// executableTool returns the host binary of an executable, it is empty for relative paths.
+func executableTool(executable string) string {
+	if strings.ContainsRune(executable, '/') && !filepath.IsAbs(executable) {
+		return ""
+	}
+	return executable
+}
+
+// undeclaredTools returns a description of each command which relies on a host binary not in tools.
+// where describes the location of the commands (ex. "test foo step 1").
+func undeclaredTools(where string, commands []harness.Command, tools map[string]bool) []string {
+	undeclared := []string{}
+	for _, cmd := range commands {
+		if !testutils.MatchesPlatform(cmd.Platforms) {
+			continue
+		}
+		tool, err := hostTool(cmd)
+		if err != nil {
+			undeclared = append(undeclared, fmt.Sprintf("%s: %v", where, err))
+			continue
+		}
+		if tool != "" && !tools[tool] {
+			undeclared = append(undeclared, fmt.Sprintf("%s: command %q relies on %s, which is not in tools", where, commandName(cmd), tool))
+		}
+	}
+	return undeclared
+}
+
+// commandName returns the command or the script of cmd for messages.
+func commandName(cmd harness.Command) string {
+	if cmd.Command != "" {
+		return cmd.Command
+	}
+	return cmd.Script
+}
+
+// stepCommands returns the commands of the step, its asserts, assertion groups and collectors.
+func stepCommands(s *Step) []harness.Command {
+	commands := []harness.Command{}
+	if s.Step != nil {
+		commands = append(commands, s.Step.Commands...)
+	}
+	assertCommands := func(asserts []harness.TestAssertCommand) {
+		for _, assert := range asserts {
+			commands = append(commands, harness.Command{Command: assert.Command, Script: assert.Script, Platforms: assert.Platforms})
+		}
+	}
+	if s.Assert != nil {
+		assertCommands(s.Assert.Commands)
+		for _, collector := range s.Assert.Collectors {
+			if cmd := collector.Command(); cmd != nil {
+				commands = append(commands, *cmd)
+			}
+		}
+	}
+	for _, group := range append(append([]AssertGroup{}, s.AnyOf...), s.AllOf...) {
+		assertCommands(group.Commands)
+	}
+	return commands
+}
+
+// hermeticTools returns the declared tools of the test suite, it fails if one of them is not found on the host.
+func (h *Harness) hermeticTools() (map[string]bool, error) {
+	tools := map[string]bool{}
+	for _, tool := range h.TestSuite.Tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			return nil, fmt.Errorf("tool %s is not available: %w", tool, err)
+		}
+		tools[tool] = true
+	}
+	return tools, nil
+}
+
+// checkHermeticSuite fails if the commands or step plugins of the test suite rely on host binaries not in tools.
+func (h *Harness) checkHermeticSuite() error {
+	if !h.TestSuite.Hermetic {
+		return nil
+	}
+	tools, err := h.hermeticTools()
+	if err != nil {
+		return err
+	}
+
+	undeclared := undeclaredTools("test suite", h.TestSuite.Commands, tools)
+	kinds := make([]string, 0, len(h.TestSuite.StepPlugins))
+	for kind := range h.TestSuite.StepPlugins {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		if tool := executableTool(h.TestSuite.StepPlugins[kind]); tool != "" && !tools[tool] {
+			undeclared = append(undeclared, fmt.Sprintf("test suite: step plugin %s relies on %s, which is not in tools", kind, tool))
+		}
+	}
+	return hermeticError(undeclared)
+}
+
+// checkHermeticTests fails if the commands of the steps of the tests rely on host binaries not in tools.
+func (h *Harness) checkHermeticTests(tests []*Case) error {
+	if !h.TestSuite.Hermetic {
+		return nil
+	}
+	tools, err := h.hermeticTools()
+	if err != nil {
+		return err
+	}
+
+	undeclared := []string{}
+	for _, test := range tests {
+		if err := test.LoadTestSteps(); err != nil {
+			return err
+		}
+		for _, step := range test.Steps {
+			undeclared = append(undeclared, undeclaredTools(fmt.Sprintf("test %s step %s", test.Name, step.String()), stepCommands(step), tools)...)
+		}
+		// the steps are loaded again when the test runs, they are not kept meanwhile
+		test.Steps = nil
+	}
+	return hermeticError(undeclared)
+}
+
+// hermeticError returns an error listing the undeclared tools, it is nil if there are none.
+func hermeticError(undeclared []string) error {
+	if len(undeclared) == 0 {
+		return nil
+	}
+	return fmt.Errorf("hermetic mode, host binaries must be declared in tools:\n%s", strings.Join(undeclared, "\n"))
+}
+
+// commandContext returns a context for the commands of the step with its test context, CommandEnv and Capture, built-in
+// commands are used in hermetic mode.
+func (s *Step) commandContext(ctx context.Context) context.Context {
+	ctx = testutils.WithCommandEnv(ctx, s.Context.Env())
+	ctx = testutils.WithCommandEnv(ctx, s.CommandEnv)
+	ctx = testutils.WithCommandCapture(ctx, s.Capture)
+	if s.Hermetic {
+		return testutils.WithBuiltinCommands(ctx)
+	}
+	return ctx
+}
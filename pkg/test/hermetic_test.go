@@ -0,0 +1,100 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestHostTool(t *testing.T) {
+	for _, test := range []struct {
+		cmd  harness.Command
+		tool string
+	}{
+		{cmd: harness.Command{Command: "kubectl apply -f pod.yaml", Namespaced: true}},
+		{cmd: harness.Command{Command: "kubectl wait pod/hello --for=condition=Ready"}},
+		{cmd: harness.Command{Command: "kubectl exec hello -- ls"}, tool: "kubectl"},
+		{cmd: harness.Command{Command: "helm install chart"}, tool: "helm"},
+		{cmd: harness.Command{Command: "/usr/bin/curl localhost"}, tool: "/usr/bin/curl"},
+		{cmd: harness.Command{Command: "./bin/tool --flag"}},
+		{cmd: harness.Command{Script: "echo hello"}, tool: testutils.ScriptShell()[0]},
+	} {
+		tool, err := hostTool(test.cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, test.tool, tool, test.cmd)
+	}
+
+	_, err := hostTool(harness.Command{Command: `echo "unterminated`})
+	assert.Error(t, err)
+}
+
+func TestUndeclaredTools(t *testing.T) {
+	commands := []harness.Command{
+		{Command: "kubectl apply -f pod.yaml"},
+		{Command: "helm install chart"},
+		{Command: "kubectl exec hello -- ls"},
+		{Command: "jq .", Platforms: []string{"plan9"}},
+	}
+
+	assert.Equal(t, []string{
+		`test foo step 1: command "kubectl exec hello -- ls" relies on kubectl, which is not in tools`,
+	}, undeclaredTools("test foo step 1", commands, map[string]bool{"helm": true}))
+	assert.Equal(t, []string{}, undeclaredTools("test foo step 1", commands, map[string]bool{"helm": true, "kubectl": true}))
+}
+
+func TestStepCommands(t *testing.T) {
+	step := &Step{
+		Step: &harness.TestStep{Commands: []harness.Command{{Command: "helm install chart"}}},
+		Assert: &harness.TestAssert{
+			Commands:   []harness.TestAssertCommand{{Command: "curl localhost"}},
+			Collectors: []*harness.TestCollector{{Type: "pod", Pod: "hello"}},
+		},
+		AnyOf: []AssertGroup{{Commands: []harness.TestAssertCommand{{Script: "test -f ready"}}}},
+	}
+
+	commands := stepCommands(step)
+	assert.Len(t, commands, 4)
+	assert.Equal(t, "helm install chart", commands[0].Command)
+	assert.Equal(t, "curl localhost", commands[1].Command)
+	assert.Contains(t, commands[2].Command, "kubectl logs")
+	assert.Equal(t, "test -f ready", commands[3].Script)
+}
+
+func TestCheckHermeticSuite(t *testing.T) {
+	h := Harness{TestSuite: harness.TestSuite{
+		Commands:    []harness.Command{{Command: "helm install chart"}},
+		StepPlugins: map[string]string{"LoadTest": "./bin/load-test", "Chaos": "chaos"},
+	}}
+	assert.NoError(t, h.checkHermeticSuite())
+
+	h.TestSuite.Hermetic = true
+	assert.EqualError(t, h.checkHermeticSuite(), `hermetic mode, host binaries must be declared in tools:
+test suite: command "helm install chart" relies on helm, which is not in tools
+test suite: step plugin Chaos relies on chaos, which is not in tools`)
+
+	h.TestSuite.Tools = []string{"kuttl-missing-tool"}
+	assert.ErrorContains(t, h.checkHermeticSuite(), "tool kuttl-missing-tool is not available")
+}
+
+func TestCheckHermeticTests(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00-install.yaml"), []byte(`apiVersion: kuttl.dev/v1beta1
+kind: TestStep
+commands:
+- command: helm install chart
+- command: kubectl apply -f pod.yaml
+`), 0600))
+	test := &Case{Name: "install", Dir: dir, Logger: testutils.NewTestLogger(t, "install")}
+	h := Harness{TestSuite: harness.TestSuite{Hermetic: true}}
+
+	assert.EqualError(t, h.checkHermeticTests([]*Case{test}), `hermetic mode, host binaries must be declared in tools:
+test install step 0-install: command "helm install chart" relies on helm, which is not in tools`)
+	// the steps are loaded again when the test runs
+	assert.Nil(t, test.Steps)
+}
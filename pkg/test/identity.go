@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// useIdentity points the kubeconfig of the step to a temporary kubeconfig which authenticates as the identity
+// declared in the step, so that applies, asserts and commands all use that identity.
+func (t *Case) useIdentity(test *testing.T, step *Step, namespace string) error {
+	var base *rest.Config
+	var cl client.Client
+	var err error
+
+	if step.Kubeconfig != "" {
+		if base, err = clientcmd.BuildConfigFromFlags("", step.Kubeconfig); err != nil {
+			return err
+		}
+		if cl, err = newClient(step.Kubeconfig)(false); err != nil {
+			return err
+		}
+	} else {
+		if t.Config == nil {
+			return errors.New("no cluster configuration available to derive the step identity from")
+		}
+		if base, err = t.Config(); err != nil {
+			return err
+		}
+		if cl, err = t.Client(false); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := identityConfig(base, step.Kubeconfig, step.Step.Identity, cl, namespace)
+	if err != nil {
+		return fmt.Errorf("step identity: %w", err)
+	}
+
+	kubeconfig, err := writeIdentityKubeconfig(cfg)
+	if err != nil {
+		return fmt.Errorf("writing kubeconfig for step identity: %w", err)
+	}
+	test.Cleanup(func() {
+		if err := os.Remove(kubeconfig); err != nil && !os.IsNotExist(err) {
+			test.Log("error removing step identity kubeconfig", err)
+		}
+	})
+
+	step.Kubeconfig = kubeconfig
+	return nil
+}
+
+// identityConfig returns a copy of the base config which authenticates as the provided identity.
+// kubeconfig is the kubeconfig of the step (empty for the default kubeconfig), it is used to look up users.
+// cl is used to read token Secrets and namespace is the test namespace.
+func identityConfig(base *rest.Config, kubeconfig string, identity *harness.Identity, cl client.Client, namespace string) (*rest.Config, error) {
+	set := 0
+	for _, v := range []string{identity.User, identity.TokenSecret, identity.ServiceAccount} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of user, tokenSecret or serviceAccount must be set for an identity")
+	}
+
+	switch {
+	case identity.User != "":
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		if kubeconfig != "" {
+			rules.ExplicitPath = kubeconfig
+		}
+		cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+			Context: clientcmdapi.Context{AuthInfo: identity.User},
+		}).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("loading user %q from kubeconfig: %w", identity.User, err)
+		}
+		return cfg, nil
+	case identity.TokenSecret != "":
+		ns, name := splitNamespacedName(identity.TokenSecret, namespace)
+
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: name}, secret); err != nil {
+			return nil, fmt.Errorf("retrieving token secret %s/%s: %w", ns, name, err)
+		}
+		token, ok := secret.Data[corev1.ServiceAccountTokenKey]
+		if !ok || len(token) == 0 {
+			return nil, fmt.Errorf("token secret %s/%s has no %q key", ns, name, corev1.ServiceAccountTokenKey)
+		}
+
+		cfg := rest.AnonymousClientConfig(base)
+		cfg.BearerToken = string(token)
+		return cfg, nil
+	default:
+		ns, name := splitNamespacedName(identity.ServiceAccount, namespace)
+
+		cfg := rest.CopyConfig(base)
+		cfg.Impersonate = rest.ImpersonationConfig{
+			UserName: fmt.Sprintf("system:serviceaccount:%s:%s", ns, name),
+		}
+		return cfg, nil
+	}
+}
+
+// writeIdentityKubeconfig writes a kubeconfig for cfg into a temporary file and returns its path.
+// The kubeconfig allows the commands of a step to run with the same identity as the step.
+func writeIdentityKubeconfig(cfg *rest.Config) (string, error) {
+	f, err := os.CreateTemp("", "kuttl-kubeconfig-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := testutils.Kubeconfig(cfg, f); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// splitNamespacedName splits "<namespace>/<name>" into its parts, only a name uses the default namespace.
+func splitNamespacedName(value, defaultNamespace string) (string, string) {
+	if ns, name, found := strings.Cut(value, "/"); found {
+		return ns, name
+	}
+	return defaultNamespace, value
+}
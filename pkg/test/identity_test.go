@@ -0,0 +1,122 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestIdentityConfig(t *testing.T) {
+	base := &rest.Config{Host: "https://cluster:6443", BearerToken: "admin-token"}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader-token", Namespace: testNamespace},
+		Data:       map[string][]byte{corev1.ServiceAccountTokenKey: []byte("reader")},
+	}
+	empty := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "other"}}
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret, empty).Build()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	assert.NoError(t, clientcmd.WriteToFile(clientcmdapi.Config{
+		Clusters:       map[string]*clientcmdapi.Cluster{"cluster": {Server: "https://cluster:6443"}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"admin": {Token: "admin-token"}, "viewer": {Token: "viewer-token"}},
+		Contexts:       map[string]*clientcmdapi.Context{"admin": {Cluster: "cluster", AuthInfo: "admin"}},
+		CurrentContext: "admin",
+	}, kubeconfig))
+
+	for _, test := range []struct {
+		testName    string
+		identity    harness.Identity
+		token       string
+		impersonate string
+		shouldError bool
+	}{
+		{
+			testName: "user from kubeconfig",
+			identity: harness.Identity{User: "viewer"},
+			token:    "viewer-token",
+		},
+		{
+			testName:    "unknown user",
+			identity:    harness.Identity{User: "nobody"},
+			shouldError: true,
+		},
+		{
+			testName: "token secret in test namespace",
+			identity: harness.Identity{TokenSecret: "reader-token"},
+			token:    "reader",
+		},
+		{
+			testName:    "token secret without token",
+			identity:    harness.Identity{TokenSecret: "other/empty"},
+			shouldError: true,
+		},
+		{
+			testName:    "missing token secret",
+			identity:    harness.Identity{TokenSecret: "missing"},
+			shouldError: true,
+		},
+		{
+			testName:    "service account in test namespace",
+			identity:    harness.Identity{ServiceAccount: "default"},
+			token:       "admin-token",
+			impersonate: "system:serviceaccount:world:default",
+		},
+		{
+			testName:    "service account in other namespace",
+			identity:    harness.Identity{ServiceAccount: "kube-system/controller"},
+			token:       "admin-token",
+			impersonate: "system:serviceaccount:kube-system:controller",
+		},
+		{
+			testName:    "no identity",
+			shouldError: true,
+		},
+		{
+			testName:    "multiple identities",
+			identity:    harness.Identity{User: "viewer", ServiceAccount: "default"},
+			shouldError: true,
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			identity := test.identity
+			cfg, err := identityConfig(base, kubeconfig, &identity, cl, testNamespace)
+			if test.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "https://cluster:6443", cfg.Host)
+			assert.Equal(t, test.token, cfg.BearerToken)
+			assert.Equal(t, test.impersonate, cfg.Impersonate.UserName)
+		})
+	}
+
+	// the base config is never modified
+	assert.Equal(t, "admin-token", base.BearerToken)
+	assert.Empty(t, base.Impersonate.UserName)
+}
+
+func TestWriteIdentityKubeconfig(t *testing.T) {
+	path, err := writeIdentityKubeconfig(&rest.Config{Host: "https://cluster:6443", BearerToken: "reader"})
+	assert.NoError(t, err)
+	defer os.Remove(path)
+
+	cfg, err := clientcmd.BuildConfigFromFlags("", path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cluster:6443", cfg.Host)
+	assert.Equal(t, "reader", cfg.BearerToken)
+}
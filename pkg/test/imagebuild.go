@@ -0,0 +1,176 @@
+package test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dockertypes "github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// buildHashLabel is the label of the images built by kuttl set to the hash of their build, an image is only built again
+// if the hash of its build changed.
+const buildHashLabel = "dev.kuttl.build-hash"
+
+// buildMessage is a message of the JSON stream of the output of a Docker build.
+type buildMessage struct {
+	Stream string `json:"stream"`
+	Error  string `json:"error"`
+}
+
+// buildContext returns the tar archive of the build context directory and its hash, which includes the names, modes and
+// contents of the files.
+func buildContext(dir string) (*bytes.Buffer, string, error) {
+	archive := &bytes.Buffer{}
+	writer := tar.NewWriter(archive)
+	hash := sha256.New()
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil || name == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s %o %s\n", header.Name, header.Mode, link)
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(io.MultiWriter(writer, hash), file)
+		return err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("archiving build context %s: %w", dir, err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return archive, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// buildHash returns the hash of a build with the hash of its context.
+func buildHash(build harness.ImageBuild, contextHash string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n", build.Image, build.Dockerfile, contextHash)
+	args := make([]string, 0, len(build.BuildArgs))
+	for name := range build.BuildArgs {
+		args = append(args, name)
+	}
+	sort.Strings(args)
+	for _, name := range args {
+		fmt.Fprintf(hash, "%s=%s\n", name, build.BuildArgs[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// buildImage builds the image of build, unless an image with the same build hash exists already.
+func buildImage(ctx context.Context, dockerClient testutils.DockerClient, build harness.ImageBuild, logger testutils.Logger) error {
+	if build.Image == "" || build.Context == "" {
+		return errors.New("image builds require an image and a context")
+	}
+	archive, contextHash, err := buildContext(build.Context)
+	if err != nil {
+		return err
+	}
+	hash := buildHash(build, contextHash)
+
+	existing, _, err := dockerClient.ImageInspectWithRaw(ctx, build.Image)
+	switch {
+	case err == nil && existing.Config != nil && existing.Config.Labels[buildHashLabel] == hash:
+		logger.Logf("image %s is up to date", build.Image)
+		return nil
+	case err != nil && !docker.IsErrNotFound(err):
+		return fmt.Errorf("inspecting image %s: %w", build.Image, err)
+	}
+
+	buildArgs := map[string]*string{}
+	for name, value := range build.BuildArgs {
+		value := value
+		buildArgs[name] = &value
+	}
+
+	logger.Logf("building image %s from %s", build.Image, build.Context)
+	response, err := dockerClient.ImageBuild(ctx, archive, dockertypes.ImageBuildOptions{
+		Tags:       []string{build.Image},
+		Dockerfile: build.Dockerfile,
+		BuildArgs:  buildArgs,
+		Labels:     map[string]string{buildHashLabel: hash},
+		Remove:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("building image %s: %w", build.Image, err)
+	}
+	defer response.Body.Close()
+
+	// the build completes once its output has been read, its errors are reported in the output
+	decoder := json.NewDecoder(response.Body)
+	for {
+		message := buildMessage{}
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("building image %s: %w", build.Image, err)
+		}
+		if message.Error != "" {
+			return fmt.Errorf("building image %s: %s", build.Image, message.Error)
+		}
+		if line := strings.TrimSpace(message.Stream); line != "" {
+			logger.Log(line)
+		}
+	}
+	return nil
+}
+
+// buildImages builds the kindBuilds images of the test suite and returns their names.
+func (h *Harness) buildImages(dockerClient testutils.DockerClient) ([]string, error) {
+	images := []string{}
+	for _, build := range h.TestSuite.KINDBuilds {
+		if err := buildImage(context.TODO(), dockerClient, build, h.GetLogger()); err != nil {
+			return nil, err
+		}
+		images = append(images, build.Image)
+	}
+	return images, nil
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestBuildImage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\nCOPY manager /\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manager"), []byte("v1"), 0600))
+
+	docker := newDockerMock()
+	logger := testutils.NewTestLogger(t, "")
+	build := harness.ImageBuild{Image: "example.com/operator:dev", Context: dir, BuildArgs: map[string]string{"VERSION": "dev"}}
+
+	require.NoError(t, buildImage(context.TODO(), docker, build, logger))
+	require.Len(t, docker.built, 1)
+	assert.Equal(t, []string{"example.com/operator:dev"}, docker.built[0].Tags)
+	assert.Equal(t, "dev", *docker.built[0].BuildArgs["VERSION"])
+	assert.NotEmpty(t, docker.built[0].Labels[buildHashLabel])
+
+	// the image is not built again unless the build changes
+	require.NoError(t, buildImage(context.TODO(), docker, build, logger))
+	assert.Len(t, docker.built, 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manager"), []byte("v2"), 0600))
+	require.NoError(t, buildImage(context.TODO(), docker, build, logger))
+	assert.Len(t, docker.built, 2)
+	build.BuildArgs["VERSION"] = "next"
+	require.NoError(t, buildImage(context.TODO(), docker, build, logger))
+	assert.Len(t, docker.built, 3)
+
+	build.BuildArgs["VERSION"] = "failing"
+	docker.buildOutput = `{"stream":"Step 1/2 : FROM scratch\n"}{"error":"COPY failed: file not found"}`
+	assert.EqualError(t, buildImage(context.TODO(), docker, build, logger), "building image example.com/operator:dev: COPY failed: file not found")
+
+	assert.EqualError(t, buildImage(context.TODO(), docker, harness.ImageBuild{Context: dir}, logger), "image builds require an image and a context")
+}
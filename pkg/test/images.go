@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// imagePollInterval is the interval at which the pods pulling the required images are checked.
+var imagePollInterval = time.Second
+
+// imagePullFailures are the reasons of waiting containers whose image cannot be pulled.
+var imagePullFailures = map[string]bool{
+	"ErrImagePull":      true,
+	"ImagePullBackOff":  true,
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
+}
+
+// imagePod returns a helper pod pulling the image on the node, or on any node if node is empty.  It runs true instead
+// of the entrypoint of the image, the image is pulled even if it has no true command.  The scheduling constraints of
+// the helper pods may be nil.
+func imagePod(image, node, namespace string, scheduling *harness.HelperPodScheduling) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kuttl-image-",
+			Namespace:    namespace,
+			Labels:       map[string]string{harness.HelperPodLabel: "true"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:            "image",
+				Image:           image,
+				Command:         []string{"true"},
+				ImagePullPolicy: corev1.PullIfNotPresent,
+			}},
+		},
+	}
+	if node != "" {
+		// the image is pulled on tainted nodes too, the tests may run on them
+		pod.Spec.NodeName = node
+		pod.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	}
+	scheduleHelperPod(&pod.Spec, scheduling)
+	return pod
+}
+
+// imagePulled returns true once the image of the helper pod was pulled, it fails if the image cannot be pulled.
+func imagePulled(pod *corev1.Pod) (bool, error) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.ImageID != "" || status.State.Running != nil || status.State.Terminated != nil {
+			return true, nil
+		}
+		if waiting := status.State.Waiting; waiting != nil && imagePullFailures[waiting.Reason] {
+			return false, fmt.Errorf("%s: %s", waiting.Reason, waiting.Message)
+		}
+	}
+	return false, nil
+}
+
+// imageNodes returns the nodes the required images are pulled on, a single empty name for any node unless the images
+// are pre-pulled.
+func (h *Harness) imageNodes(ctx context.Context, cl client.Client) ([]string, error) {
+	if !h.TestSuite.PrePullImages {
+		return []string{""}, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := cl.List(ctx, nodes); err != nil {
+		return nil, fmt.Errorf("listing nodes to pre-pull images on: %w", err)
+	}
+	names := []string{}
+	for _, node := range nodes.Items {
+		if !node.Spec.Unschedulable {
+			names = append(names, node.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no schedulable nodes to pre-pull images on")
+	}
+	return names, nil
+}
+
+// checkRequiredImages pulls the required images of the test suite with helper pods in the namespace, on every node if
+// they are pre-pulled.  It fails with the images which cannot be pulled, so that the suite fails at once instead of
+// every test failing on ImagePullBackOff after its timeout.
+func (h *Harness) checkRequiredImages(ctx context.Context, cl client.Client, namespace string) error {
+	if len(h.TestSuite.RequiredImages) == 0 {
+		return nil
+	}
+	nodes, err := h.imageNodes(ctx, cl)
+	if err != nil {
+		return err
+	}
+
+	pods := map[*corev1.Pod]string{}
+	defer func() {
+		for pod := range pods {
+			if err := cl.Delete(ctx, pod, client.GracePeriodSeconds(0)); err != nil {
+				h.T.Logf("error deleting image pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			}
+		}
+	}()
+	for _, image := range h.TestSuite.RequiredImages {
+		for _, node := range nodes {
+			pod := imagePod(image, node, namespace, h.TestSuite.HelperPods)
+			if err := cl.Create(ctx, pod); err != nil {
+				return fmt.Errorf("creating pod pulling image %s: %w", image, err)
+			}
+			pods[pod] = image
+		}
+	}
+	h.T.Logf("pulling %d required image(s) on %d node(s)", len(h.TestSuite.RequiredImages), len(nodes))
+
+	failures := map[string]string{}
+	pending := len(pods)
+	deadline := time.Now().Add(time.Duration(h.GetTimeout()) * time.Second)
+	done := map[*corev1.Pod]bool{}
+	for pending > 0 {
+		for pod, image := range pods {
+			if done[pod] {
+				continue
+			}
+			if err := cl.Get(ctx, client.ObjectKeyFromObject(pod), pod); err != nil {
+				return err
+			}
+			pulled, err := imagePulled(pod)
+			if err != nil {
+				failures[image] = fmt.Sprintf("image %s cannot be pulled%s: %v", image, onNode(pod), err)
+			}
+			if pulled || err != nil {
+				done[pod] = true
+				pending--
+			}
+		}
+		if pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			for pod, image := range pods {
+				if !done[pod] && failures[image] == "" {
+					failures[image] = fmt.Sprintf("image %s was not pulled%s in %ds", image, onNode(pod), h.GetTimeout())
+				}
+			}
+			break
+		}
+		time.Sleep(imagePollInterval)
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	messages := []string{}
+	for _, image := range h.TestSuite.RequiredImages {
+		if message, ok := failures[image]; ok {
+			messages = append(messages, message)
+		}
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// onNode returns " on node <name>" if the pod is pinned to a node.
+func onNode(pod *corev1.Pod) string {
+	if pod.Spec.NodeName == "" {
+		return ""
+	}
+	return " on node " + pod.Spec.NodeName
+}
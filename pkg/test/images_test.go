@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// pullingClient sets the container statuses of the pods it gets as if the kubelet pulled their images, images
+// starting with "missing" cannot be pulled and images starting with "slow" are never pulled.
+type pullingClient struct {
+	client.Client
+}
+
+func (c *pullingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.Client.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	image := pod.Spec.Containers[0].Image
+	status := corev1.ContainerStatus{Name: "image", Image: image}
+	switch {
+	case strings.HasPrefix(image, "missing"):
+		status.State.Waiting = &corev1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "manifest unknown"}
+	case strings.HasPrefix(image, "slow"):
+		status.State.Waiting = &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}
+	default:
+		status.ImageID = "docker.io/library/" + image + "@sha256:0"
+	}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{status}
+	return nil
+}
+
+func TestCheckRequiredImages(t *testing.T) {
+	defer func(interval time.Duration) { imagePollInterval = interval }(imagePollInterval)
+	imagePollInterval = time.Millisecond
+
+	nodes := []client.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker2"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cordoned"}, Spec: corev1.NodeSpec{Unschedulable: true}},
+	}
+
+	tests := []struct {
+		name    string
+		suite   harness.TestSuite
+		pods    int
+		wantErr string
+	}{
+		{
+			name:  "no images",
+			suite: harness.TestSuite{},
+		},
+		{
+			name:  "available",
+			suite: harness.TestSuite{RequiredImages: []string{"nginx:1.25", "busybox"}},
+			pods:  2,
+		},
+		{
+			name:  "pre-pulled on schedulable nodes",
+			suite: harness.TestSuite{RequiredImages: []string{"nginx:1.25"}, PrePullImages: true},
+			pods:  2,
+		},
+		{
+			name:    "missing",
+			suite:   harness.TestSuite{RequiredImages: []string{"nginx:1.25", "missing:v9", "missing:v10"}, PrePullImages: true},
+			pods:    6,
+			wantErr: "image missing:v9 cannot be pulled on node worker", // the node depends on the order of the pods
+		},
+		{
+			name:    "timeout",
+			suite:   harness.TestSuite{RequiredImages: []string{"slow:1"}, Timeout: 1},
+			pods:    1,
+			wantErr: "image slow:1 was not pulled in 1s",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cl := &pullingClient{fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodes...).Build()}
+			h := &Harness{T: t, TestSuite: tt.suite}
+
+			created := 0
+			err := h.checkRequiredImages(context.TODO(), &countingClient{Client: cl, created: &created}, "default")
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+			assert.Equal(t, tt.pods, created)
+
+			pods := &corev1.PodList{}
+			require.NoError(t, cl.List(context.TODO(), pods))
+			assert.Empty(t, pods.Items, "the image pods are deleted")
+		})
+	}
+}
+
+func TestCheckRequiredImagesErrors(t *testing.T) {
+	defer func(interval time.Duration) { imagePollInterval = interval }(imagePollInterval)
+	imagePollInterval = time.Millisecond
+
+	cl := &pullingClient{fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	h := &Harness{T: t, TestSuite: harness.TestSuite{RequiredImages: []string{"missing:v9", "nginx", "missing:v10"}}}
+
+	err := h.checkRequiredImages(context.TODO(), cl, "default")
+	assert.EqualError(t, err, "image missing:v9 cannot be pulled: ErrImagePull: manifest unknown; "+
+		"image missing:v10 cannot be pulled: ErrImagePull: manifest unknown")
+
+	h.TestSuite.PrePullImages = true
+	err = h.checkRequiredImages(context.TODO(), cl, "default")
+	assert.EqualError(t, err, "no schedulable nodes to pre-pull images on")
+}
+
+// countingClient counts the objects it creates.
+type countingClient struct {
+	client.Client
+	created *int
+}
+
+func (c *countingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	*c.created++
+	return c.Client.Create(ctx, obj, opts...)
+}
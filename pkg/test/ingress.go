@@ -0,0 +1,285 @@
+package test
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// gatewayGroup is the API group of the Gateway API.
+const gatewayGroup = "gateway.networking.k8s.io"
+
+// reachableTimeout is the timeout of each request checking that an Ingress or Gateway is reachable.
+var reachableTimeout = 5 * time.Second
+
+// ingressReadiness is the readiness an Ingress, a Gateway or a route of the Gateway API is expected to have, as
+// declared by the ready, address-assigned and reachable annotations.
+type ingressReadiness struct {
+	ready           bool
+	addressAssigned bool
+	// reachable is the path requested through the assigned address, it is not requested if empty.
+	reachable string
+}
+
+// expectedIngress returns a copy of expected without the ready, address-assigned and reachable annotations, as well as
+// the readiness declared by them.  If none of the annotations is set, expected is returned unmodified.
+func expectedIngress(expected runtime.Object) (runtime.Object, ingressReadiness, error) {
+	readiness := ingressReadiness{}
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, readiness, err
+	}
+	annotations := m.GetAnnotations()
+	gvk := expected.GetObjectKind().GroupVersionKind()
+	addressed := gvk.Kind == "Ingress" || gvk.Group == gatewayGroup && gvk.Kind == "Gateway"
+	route := gvk.Group == gatewayGroup && strings.HasSuffix(gvk.Kind, "Route")
+
+	for _, annotation := range []string{harness.ReadyAnnotation, harness.AddressAssignedAnnotation} {
+		value, ok := annotations[annotation]
+		if !ok {
+			continue
+		}
+		set, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, readiness, fmt.Errorf("annotation %s: %q is not a boolean", annotation, value)
+		}
+		if annotation == harness.ReadyAnnotation {
+			if !addressed && !route {
+				return nil, readiness, fmt.Errorf("annotation %s: it can only be set on Ingresses, Gateways and the routes of the Gateway API, not on %s", annotation, gvk.Kind)
+			}
+			readiness.ready = set
+		} else {
+			if !addressed {
+				return nil, readiness, fmt.Errorf("annotation %s: it can only be set on Ingresses and Gateways, not on %s", annotation, gvk.Kind)
+			}
+			readiness.addressAssigned = set
+		}
+		if expected, err = withoutAnnotation(expected, annotation); err != nil {
+			return nil, readiness, err
+		}
+	}
+
+	if value, ok := annotations[harness.ReachableAnnotation]; ok {
+		if !addressed {
+			return nil, readiness, fmt.Errorf("annotation %s: it can only be set on Ingresses and Gateways, not on %s", harness.ReachableAnnotation, gvk.Kind)
+		}
+		if !strings.HasPrefix(value, "/") {
+			return nil, readiness, fmt.Errorf("annotation %s: %q is not a path starting with /", harness.ReachableAnnotation, value)
+		}
+		readiness.reachable = value
+		if expected, err = withoutAnnotation(expected, harness.ReachableAnnotation); err != nil {
+			return nil, readiness, err
+		}
+	}
+
+	return expected, readiness, nil
+}
+
+// check verifies the readiness of actual, the reachable path is requested last.
+func (r ingressReadiness) check(actual *unstructured.Unstructured) error {
+	if r.ready {
+		if err := ingressReady(actual); err != nil {
+			return err
+		}
+	}
+	if !r.addressAssigned && r.reachable == "" {
+		return nil
+	}
+
+	endpoints := ingressEndpoints(actual)
+	if len(endpoints) == 0 {
+		return errors.New("no address was assigned yet")
+	}
+	if r.reachable == "" {
+		return nil
+	}
+	return reachable(endpoints[0], r.reachable)
+}
+
+// ingressReady fails if an Ingress has no address, a Gateway is not accepted and programmed, or a route is not
+// accepted by all its parents with all its references resolved.
+func ingressReady(actual *unstructured.Unstructured) error {
+	switch actual.GetKind() {
+	case "Ingress":
+		if len(ingressEndpoints(actual)) == 0 {
+			return errors.New("the ingress is not ready, no address was assigned yet")
+		}
+		return nil
+	case "Gateway":
+		conditions, _, _ := unstructured.NestedSlice(actual.Object, "status", "conditions")
+		return conditionsTrue("the gateway", actual.GetGeneration(), conditions, "Accepted", "Programmed")
+	default:
+		parents, _, _ := unstructured.NestedSlice(actual.Object, "status", "parents")
+		if len(parents) == 0 {
+			return errors.New("the route is not ready, no parent reported its status yet")
+		}
+		for _, parent := range parents {
+			parent, _ := parent.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(parent, "parentRef", "name")
+			conditions, _, _ := unstructured.NestedSlice(parent, "conditions")
+			if err := conditionsTrue("the route in parent "+name, actual.GetGeneration(), conditions, "Accepted", "ResolvedRefs"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// conditionsTrue fails if one of the types of conditions is not true for the generation.
+func conditionsTrue(subject string, generation int64, conditions []interface{}, types ...string) error {
+	for _, conditionType := range types {
+		var found map[string]interface{}
+		for _, condition := range conditions {
+			if condition, ok := condition.(map[string]interface{}); ok && condition["type"] == conditionType {
+				found = condition
+			}
+		}
+		if found == nil {
+			return fmt.Errorf("%s is not ready, it has no %s condition yet", subject, conditionType)
+		}
+		if observed, ok, _ := unstructured.NestedInt64(found, "observedGeneration"); ok && observed < generation {
+			return fmt.Errorf("%s is not ready, its %s condition is for generation %d, not %d", subject, conditionType, observed, generation)
+		}
+		if status, _, _ := unstructured.NestedString(found, "status"); status != "True" {
+			message, _, _ := unstructured.NestedString(found, "message")
+			return fmt.Errorf("%s is not ready, %s is %s: %s", subject, conditionType, status, message)
+		}
+	}
+	return nil
+}
+
+// ingressEndpoint is an assigned address of an Ingress or Gateway and how it is requested.
+type ingressEndpoint struct {
+	address string
+	port    int64
+	// host is the host requests are sent to, the address if empty.
+	host  string
+	https bool
+}
+
+// ingressEndpoints returns the endpoints of the assigned addresses of an Ingress or Gateway.  Requests are sent to the
+// host of the first rule of an Ingress, with HTTPS if it is a TLS host, or to the first HTTP or HTTPS listener of a
+// Gateway.
+func ingressEndpoints(actual *unstructured.Unstructured) []ingressEndpoint {
+	var addresses []string
+	template := ingressEndpoint{port: 80}
+
+	if actual.GetKind() == "Ingress" {
+		ingresses, _, _ := unstructured.NestedSlice(actual.Object, "status", "loadBalancer", "ingress")
+		for _, ingress := range ingresses {
+			ingress, _ := ingress.(map[string]interface{})
+			if ip, _, _ := unstructured.NestedString(ingress, "ip"); ip != "" {
+				addresses = append(addresses, ip)
+			} else if hostname, _, _ := unstructured.NestedString(ingress, "hostname"); hostname != "" {
+				addresses = append(addresses, hostname)
+			}
+		}
+		rules, _, _ := unstructured.NestedSlice(actual.Object, "spec", "rules")
+		if len(rules) > 0 {
+			rule, _ := rules[0].(map[string]interface{})
+			template.host, _, _ = unstructured.NestedString(rule, "host")
+		}
+		tlsEntries, _, _ := unstructured.NestedSlice(actual.Object, "spec", "tls")
+		for _, entry := range tlsEntries {
+			entry, _ := entry.(map[string]interface{})
+			hosts, _, _ := unstructured.NestedStringSlice(entry, "hosts")
+			for _, host := range hosts {
+				if host == template.host {
+					template.https, template.port = true, 443
+				}
+			}
+		}
+	} else {
+		statusAddresses, _, _ := unstructured.NestedSlice(actual.Object, "status", "addresses")
+		for _, address := range statusAddresses {
+			address, _ := address.(map[string]interface{})
+			if value, _, _ := unstructured.NestedString(address, "value"); value != "" {
+				addresses = append(addresses, value)
+			}
+		}
+		listeners, _, _ := unstructured.NestedSlice(actual.Object, "spec", "listeners")
+		for _, listener := range listeners {
+			listener, _ := listener.(map[string]interface{})
+			protocol, _, _ := unstructured.NestedString(listener, "protocol")
+			if protocol != "HTTP" && protocol != "HTTPS" {
+				continue
+			}
+			template.https = protocol == "HTTPS"
+			if port, ok, _ := unstructured.NestedInt64(listener, "port"); ok {
+				template.port = port
+			}
+			template.host, _, _ = unstructured.NestedString(listener, "hostname")
+			break
+		}
+	}
+
+	// wildcard hosts cannot be requested, the address is requested instead
+	if strings.HasPrefix(template.host, "*") {
+		template.host = ""
+	}
+	endpoints := make([]ingressEndpoint, 0, len(addresses))
+	for _, address := range addresses {
+		endpoint := template
+		endpoint.address = address
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// reachable fails if a GET request of the path through the endpoint does not succeed with a 2xx or 3xx status.  The
+// certificates of HTTPS endpoints are not verified, they are usually issued for the test.
+func reachable(endpoint ingressEndpoint, path string) error {
+	scheme := "http"
+	if endpoint.https {
+		scheme = "https"
+	}
+	url := fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(endpoint.address, strconv.FormatInt(endpoint.port, 10)), path)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	serverName := endpoint.address
+	if endpoint.host != "" {
+		req.Host = endpoint.host
+		serverName = endpoint.host
+	}
+	client := &http.Client{
+		Timeout: reachableTimeout,
+		Transport: &http.Transport{
+			// #nosec G402 the certificates of test ingresses are not trusted
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: serverName},
+		},
+		// redirects are a response of the ingress, they are not followed
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s is not reachable: %w", describeRequest(url, endpoint.host), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s is not reachable: %s", describeRequest(url, endpoint.host), resp.Status)
+	}
+	return nil
+}
+
+// describeRequest describes the request of url with the host, ex. "http://10.0.0.1:80/healthz (host web.example.com)".
+func describeRequest(url, host string) string {
+	if host == "" {
+		return url
+	}
+	return fmt.Sprintf("%s (host %s)", url, host)
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedIngress(t *testing.T) {
+	gateway := testutils.NewResource("gateway.networking.k8s.io/v1", "Gateway", "web", "")
+	gateway = testutils.SetAnnotation(gateway, harness.ReadyAnnotation, "true")
+	gateway = testutils.SetAnnotation(gateway, harness.ReachableAnnotation, "/healthz")
+
+	stripped, readiness, err := expectedIngress(gateway)
+	assert.NoError(t, err)
+	assert.Equal(t, ingressReadiness{ready: true, reachable: "/healthz"}, readiness)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+	// the original object is not modified
+	assert.Contains(t, gateway.GetAnnotations(), harness.ReadyAnnotation)
+
+	route := testutils.SetAnnotation(testutils.NewResource("gateway.networking.k8s.io/v1", "HTTPRoute", "web", ""), harness.ReadyAnnotation, "true")
+	_, readiness, err = expectedIngress(route)
+	assert.NoError(t, err)
+	assert.True(t, readiness.ready)
+
+	unannotated := testutils.NewResource("networking.k8s.io/v1", "Ingress", "web", "")
+	stripped, readiness, err = expectedIngress(unannotated)
+	assert.NoError(t, err)
+	assert.Equal(t, ingressReadiness{}, readiness)
+	assert.Equal(t, unannotated, stripped)
+
+	for _, test := range []struct {
+		obj *unstructured.Unstructured
+		err string
+	}{
+		{
+			obj: testutils.SetAnnotation(testutils.NewResource("networking.k8s.io/v1", "Ingress", "web", ""), harness.AddressAssignedAnnotation, "yes please"),
+			err: `annotation kuttl.dev/address-assigned: "yes please" is not a boolean`,
+		},
+		{
+			obj: testutils.SetAnnotation(testutils.NewPod("web", ""), harness.ReadyAnnotation, "true"),
+			err: "annotation kuttl.dev/ready: it can only be set on Ingresses, Gateways and the routes of the Gateway API, not on Pod",
+		},
+		{
+			obj: testutils.SetAnnotation(route.DeepCopy(), harness.AddressAssignedAnnotation, "true"),
+			err: "annotation kuttl.dev/address-assigned: it can only be set on Ingresses and Gateways, not on HTTPRoute",
+		},
+		{
+			obj: testutils.SetAnnotation(testutils.NewResource("networking.k8s.io/v1", "Ingress", "web", ""), harness.ReachableAnnotation, "healthz"),
+			err: `annotation kuttl.dev/reachable: "healthz" is not a path starting with /`,
+		},
+	} {
+		_, _, err := expectedIngress(test.obj)
+		assert.EqualError(t, err, test.err)
+	}
+}
+
+func TestIngressReady(t *testing.T) {
+	condition := func(conditionType, status string, generation int64) interface{} {
+		return map[string]interface{}{"type": conditionType, "status": status, "observedGeneration": generation, "message": "waiting"}
+	}
+
+	for _, test := range []struct {
+		name   string
+		obj    *unstructured.Unstructured
+		status map[string]interface{}
+		err    string
+	}{
+		{
+			name: "ingress without address",
+			obj:  testutils.NewResource("networking.k8s.io/v1", "Ingress", "web", testNamespace),
+			err:  "the ingress is not ready, no address was assigned yet",
+		},
+		{
+			name:   "ingress with hostname",
+			obj:    testutils.NewResource("networking.k8s.io/v1", "Ingress", "web", testNamespace),
+			status: map[string]interface{}{"loadBalancer": map[string]interface{}{"ingress": []interface{}{map[string]interface{}{"hostname": "lb.example.com"}}}},
+		},
+		{
+			name:   "programmed gateway",
+			obj:    testutils.NewResource("gateway.networking.k8s.io/v1", "Gateway", "web", testNamespace),
+			status: map[string]interface{}{"conditions": []interface{}{condition("Accepted", "True", 1), condition("Programmed", "True", 1)}},
+		},
+		{
+			name:   "gateway not programmed",
+			obj:    testutils.NewResource("gateway.networking.k8s.io/v1", "Gateway", "web", testNamespace),
+			status: map[string]interface{}{"conditions": []interface{}{condition("Accepted", "True", 1), condition("Programmed", "False", 1)}},
+			err:    "the gateway is not ready, Programmed is False: waiting",
+		},
+		{
+			name:   "gateway conditions of a previous generation",
+			obj:    withGeneration(testutils.NewResource("gateway.networking.k8s.io/v1", "Gateway", "web", testNamespace), 2),
+			status: map[string]interface{}{"conditions": []interface{}{condition("Accepted", "True", 1), condition("Programmed", "True", 1)}},
+			err:    "the gateway is not ready, its Accepted condition is for generation 1, not 2",
+		},
+		{
+			name: "route without parents",
+			obj:  testutils.NewResource("gateway.networking.k8s.io/v1", "HTTPRoute", "web", testNamespace),
+			err:  "the route is not ready, no parent reported its status yet",
+		},
+		{
+			name: "route with unresolved references",
+			obj:  testutils.NewResource("gateway.networking.k8s.io/v1", "HTTPRoute", "web", testNamespace),
+			status: map[string]interface{}{"parents": []interface{}{map[string]interface{}{
+				"parentRef":  map[string]interface{}{"name": "public"},
+				"conditions": []interface{}{condition("Accepted", "True", 1)},
+			}}},
+			err: "the route in parent public is not ready, it has no ResolvedRefs condition yet",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			obj := test.obj
+			if test.status != nil {
+				obj = testutils.WithStatus(t, obj, test.status)
+			}
+			err := ingressReady(obj)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func withGeneration(obj *unstructured.Unstructured, generation int64) *unstructured.Unstructured {
+	obj.SetGeneration(generation)
+	return obj
+}
+
+func TestIngressReachable(t *testing.T) {
+	hosts := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.ParseInt(port, 10, 64)
+	require.NoError(t, err)
+
+	gateway := testutils.WithSpec(t, testutils.NewResource("gateway.networking.k8s.io/v1", "Gateway", "web", testNamespace), map[string]interface{}{
+		"listeners": []interface{}{
+			map[string]interface{}{"name": "tls", "protocol": "TLS", "port": int64(8443)},
+			map[string]interface{}{"name": "http", "protocol": "HTTP", "port": portNumber, "hostname": "web.example.com"},
+		},
+	})
+
+	readiness := ingressReadiness{addressAssigned: true, reachable: "/healthz"}
+	assert.EqualError(t, readiness.check(gateway), "no address was assigned yet")
+
+	gateway = testutils.WithStatus(t, gateway, map[string]interface{}{"addresses": []interface{}{map[string]interface{}{"value": host}}})
+	assert.NoError(t, readiness.check(gateway))
+	assert.Equal(t, "web.example.com", <-hosts)
+
+	readiness.reachable = "/ready"
+	assert.EqualError(t, readiness.check(gateway), "http://"+server.Listener.Addr().String()+"/ready (host web.example.com) is not reachable: 503 Service Unavailable")
+	<-hosts
+}
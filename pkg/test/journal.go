@@ -0,0 +1,277 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// EventJournalEnv is the environment variable of the commands of a test set to the path of its event journal.
+const EventJournalEnv = "KUTTL_EVENT_JOURNAL"
+
+// defaultJournalMaxEvents is the number of watch events kept in an event journal if maxEvents is not set.
+const defaultJournalMaxEvents = 1000
+
+// journalRetryInterval is the interval between attempts to resume a watch which the API server ended.
+var journalRetryInterval = time.Second
+
+// journalEntry is a watch event of the event journal, the journal has one entry per line.
+type journalEntry struct {
+	Time       time.Time              `json:"time"`
+	Type       watch.EventType        `json:"type"`
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Name       string                 `json:"name"`
+	Object     map[string]interface{} `json:"object"`
+}
+
+// eventJournal records the watch events of the objects in the namespace of a test to a file.  The file is replaced
+// whenever an event is recorded, so that commands reading it never see a partially written entry.
+type eventJournal struct {
+	path      string
+	namespace string
+	maxEvents int
+	client    client.WithWatch
+	logger    testutils.Logger
+	ctx       context.Context
+
+	lock    sync.Mutex
+	stopped bool
+	watches map[schema.GroupVersionKind]watch.Interface
+	lines   [][]byte
+	wg      sync.WaitGroup
+}
+
+// startEventJournal starts the event journal of the test in namespace, it is stopped when the test ends.  The kinds of
+// the event journal settings are watched at once, the kinds of the steps are watched when the steps start.
+func (t *Case) startEventJournal(test *testing.T, cl client.Client, namespace string) (*eventJournal, error) {
+	watchClient, ok := cl.(client.WithWatch)
+	if !ok {
+		return nil, errors.New("the client of the test cannot watch objects")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &eventJournal{
+		path:      filepath.Join(test.TempDir(), "events.jsonl"),
+		namespace: namespace,
+		maxEvents: t.EventJournal.MaxEvents,
+		client:    watchClient,
+		logger:    t.Logger,
+		ctx:       ctx,
+		watches:   map[schema.GroupVersionKind]watch.Interface{},
+	}
+	if j.maxEvents <= 0 {
+		j.maxEvents = defaultJournalMaxEvents
+	}
+	if err := j.write(); err != nil {
+		cancel()
+		return nil, err
+	}
+	test.Cleanup(func() {
+		cancel()
+		j.stop()
+	})
+
+	if len(t.EventJournal.Kinds) == 0 {
+		j.watch(schema.GroupVersionKind{Version: "v1", Kind: "Event"})
+	}
+	for _, kind := range t.EventJournal.Kinds {
+		j.watch(schema.FromAPIVersionAndKind(kind.APIVersion, kind.Kind))
+	}
+	return j, nil
+}
+
+// watchStep watches the kinds of the objects applied and asserted by the step, unless the event journal settings list
+// the kinds to watch.
+func (j *eventJournal) watchStep(settings *harness.EventJournal, step *Step) {
+	if len(settings.Kinds) > 0 {
+		return
+	}
+	for _, objects := range [][]client.Object{step.Apply, step.Asserts, step.Errors} {
+		for _, obj := range objects {
+			j.watch(obj.GetObjectKind().GroupVersionKind())
+		}
+	}
+}
+
+// watch starts watching the objects of a kind in the namespace if they are not watched yet.  If the watch cannot be
+// started (ex. the CRD of the kind is not installed yet), it is attempted again when the next step starts.
+func (j *eventJournal) watch(gvk schema.GroupVersionKind) {
+	j.lock.Lock()
+	_, watched := j.watches[gvk]
+	j.lock.Unlock()
+	if watched {
+		return
+	}
+
+	mapping, err := j.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		j.logger.Logf("not recording %s in the event journal yet: %v", gvk.Kind, err)
+		return
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return
+	}
+
+	w, err := j.start(gvk, "")
+	if err != nil {
+		j.logger.Logf("not recording %s in the event journal yet: %v", gvk.Kind, err)
+		return
+	}
+	if !j.setWatch(gvk, w) {
+		return
+	}
+	j.wg.Add(1)
+	go j.run(gvk, w)
+}
+
+// start starts a watch of the objects of a kind in the namespace, from resourceVersion if it is set.
+func (j *eventJournal) start(gvk schema.GroupVersionKind, resourceVersion string) (watch.Interface, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	return j.client.Watch(j.ctx, list, client.InNamespace(j.namespace), &client.ListOptions{
+		Raw: &metav1.ListOptions{ResourceVersion: resourceVersion, AllowWatchBookmarks: true},
+	})
+}
+
+// setWatch sets the current watch of a kind, it returns false and stops the watch if the journal is stopped.
+func (j *eventJournal) setWatch(gvk schema.GroupVersionKind, w watch.Interface) bool {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.stopped {
+		w.Stop()
+		return false
+	}
+	j.watches[gvk] = w
+	return true
+}
+
+// run records the events of a watch until the journal is stopped.  The API server ends watches after a while, they are
+// resumed from the last recorded event, or from the current state if it is too old.
+func (j *eventJournal) run(gvk schema.GroupVersionKind, w watch.Interface) {
+	defer j.wg.Done()
+
+	resourceVersion := ""
+	for {
+		for event := range w.ResultChan() {
+			if event.Type == watch.Error {
+				resourceVersion = ""
+				continue
+			}
+			obj, err := journalObject(gvk, event.Object)
+			if err != nil {
+				continue
+			}
+			resourceVersion = obj.GetResourceVersion()
+			if event.Type != watch.Bookmark {
+				j.record(event.Type, obj)
+			}
+		}
+		w.Stop()
+
+		for {
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-time.After(journalRetryInterval):
+			}
+			var err error
+			if w, err = j.start(gvk, resourceVersion); err == nil {
+				break
+			}
+			resourceVersion = ""
+		}
+		if !j.setWatch(gvk, w) {
+			return
+		}
+	}
+}
+
+// journalObject returns the object of a watch event as an unstructured object of the watched kind.
+func journalObject(gvk schema.GroupVersionKind, obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+// record adds a watch event to the journal, dropping the oldest events beyond maxEvents.
+func (j *eventJournal) record(eventType watch.EventType, obj *unstructured.Unstructured) {
+	line, err := json.Marshal(journalEntry{
+		Time:       time.Now(),
+		Type:       eventType,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Object:     obj.Object,
+	})
+	if err != nil {
+		j.logger.Log("error encoding a watch event for the event journal:", err)
+		return
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.stopped {
+		return
+	}
+	j.lines = append(j.lines, line)
+	if len(j.lines) > j.maxEvents {
+		j.lines = j.lines[len(j.lines)-j.maxEvents:]
+	}
+	if err := j.write(); err != nil {
+		j.logger.Log("error writing the event journal:", err)
+	}
+}
+
+// write replaces the journal file with the recorded events, the caller must hold the lock once events are recorded.
+func (j *eventJournal) write() error {
+	var content bytes.Buffer
+	for _, line := range j.lines {
+		content.Write(line)
+		content.WriteByte('\n')
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, content.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// stop stops the watches of the journal, the events delivered afterwards are not recorded.
+func (j *eventJournal) stop() {
+	j.lock.Lock()
+	j.stopped = true
+	for _, w := range j.watches {
+		w.Stop()
+	}
+	j.lock.Unlock()
+
+	j.wg.Wait()
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// readJournal returns the entries of the event journal at path.
+func readJournal(t *testing.T, path string) []journalEntry {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := journalEntry{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+func TestEventJournal(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Event"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).Build()
+	c := &Case{EventJournal: &harness.EventJournal{MaxEvents: 2}, Logger: testutils.NewTestLogger(t, "")}
+
+	t.Run("journal", func(t *testing.T) {
+		journal, err := c.startEventJournal(t, cl, testNamespace)
+		require.NoError(t, err)
+		assert.Empty(t, readJournal(t, journal.path))
+
+		// cluster-scoped kinds are not watched
+		step := &Step{Apply: []client.Object{
+			testutils.NewResource("v1", "ConfigMap", "config", ""),
+			testutils.NewResource("v1", "Namespace", "other", ""),
+		}}
+		journal.watchStep(c.EventJournal, step)
+		// the kinds of later steps which are already watched are not watched again
+		journal.watchStep(c.EventJournal, step)
+
+		for _, name := range []string{"first", "second", "third"} {
+			require.NoError(t, cl.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace}}))
+		}
+		// the objects of other namespaces are not recorded
+		require.NoError(t, cl.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}}))
+
+		var entries []journalEntry
+		lastEntry := func(name string) func() (bool, error) {
+			return func() (bool, error) {
+				entries = readJournal(t, journal.path)
+				return len(entries) > 0 && entries[len(entries)-1].Name == name, nil
+			}
+		}
+		require.NoError(t, wait.PollImmediate(10*time.Millisecond, 5*time.Second, lastEntry("third")))
+		require.NoError(t, cl.Create(context.TODO(), &corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "event", Namespace: testNamespace}, Reason: "Created"}))
+		require.NoError(t, wait.PollImmediate(10*time.Millisecond, 5*time.Second, lastEntry("event")))
+
+		// only the latest events are kept
+		require.Len(t, entries, 2)
+		assert.Equal(t, "ConfigMap", entries[0].Kind)
+		assert.Equal(t, "third", entries[0].Name)
+		assert.Equal(t, "v1", entries[1].APIVersion)
+		assert.Equal(t, "event", entries[1].Name)
+		assert.Equal(t, "Created", entries[1].Object["reason"])
+		for _, entry := range entries {
+			assert.EqualValues(t, "ADDED", entry.Type)
+		}
+		assert.Len(t, journal.watches, 2)
+	})
+}
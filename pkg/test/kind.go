@@ -2,9 +2,14 @@ package test
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/apimachinery/pkg/version"
+	"sigs.k8s.io/kind/pkg/apis/config/defaults"
 	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 	"sigs.k8s.io/kind/pkg/cluster"
 	"sigs.k8s.io/kind/pkg/cluster/nodes"
@@ -109,3 +114,108 @@ func IsMinVersion(ver string) bool {
 	comp := version.CompareKubeAwareVersionStrings(minVersion, ver)
 	return comp != -1
 }
+
+var (
+	featureGateRegex   = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	apiVersionRegex    = regexp.MustCompile(`^v[1-9][0-9]*((alpha|beta)[1-9][0-9]*)?$`)
+	runtimeConfigMinor = map[string]uint{
+		// api/alpha and api/beta were added in Kubernetes 1.24.
+		"api/alpha": 24,
+		"api/beta":  24,
+	}
+)
+
+// applyKindFeatures merges the feature gates and runtime-config of the test suite into the kind configuration.
+func applyKindFeatures(config *v1alpha4.Cluster, featureGates map[string]bool, runtimeConfig map[string]string) {
+	if len(featureGates) > 0 && config.FeatureGates == nil {
+		config.FeatureGates = map[string]bool{}
+	}
+	for name, enabled := range featureGates {
+		config.FeatureGates[name] = enabled
+	}
+
+	if len(runtimeConfig) > 0 && config.RuntimeConfig == nil {
+		config.RuntimeConfig = map[string]string{}
+	}
+	for key, value := range runtimeConfig {
+		config.RuntimeConfig[key] = value
+	}
+}
+
+// validateKindFeatures checks the IP family, feature gates and runtime-config of the kind configuration, runtime-config
+// keys are checked against the Kubernetes version of the node images.
+func validateKindFeatures(config *v1alpha4.Cluster) error {
+	switch config.Networking.IPFamily {
+	case "", v1alpha4.IPv4Family, v1alpha4.IPv6Family, v1alpha4.DualStackFamily:
+	default:
+		return fmt.Errorf("invalid IP family %q, must be ipv4, ipv6 or dual", config.Networking.IPFamily)
+	}
+
+	for name := range config.FeatureGates {
+		if !featureGateRegex.MatchString(name) {
+			return fmt.Errorf("invalid feature gate %q", name)
+		}
+	}
+
+	versions := nodeVersions(config)
+
+	for key, value := range config.RuntimeConfig {
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid value %q for runtime-config %q, must be true or false", value, key)
+		}
+
+		switch key {
+		case "api/all", "api/ga", "api/beta", "api/alpha":
+		default:
+			group, apiVersion, found := strings.Cut(key, "/")
+			if !found || group == "" || !apiVersionRegex.MatchString(apiVersion) {
+				return fmt.Errorf("invalid runtime-config %q, must be api/<all|ga|beta|alpha> or <group>/<version>", key)
+			}
+		}
+
+		minor, ok := runtimeConfigMinor[key]
+		if !ok {
+			continue
+		}
+		for image, v := range versions {
+			if v.Major() == 1 && v.Minor() < minor {
+				return fmt.Errorf("runtime-config %q requires Kubernetes 1.%d or later, node image %s is %s", key, minor, image, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// nodeVersions returns the Kubernetes versions of the node images of the kind configuration by image.
+// Images without a version tag are skipped.
+func nodeVersions(config *v1alpha4.Cluster) map[string]*utilversion.Version {
+	images := []string{}
+	for _, node := range config.Nodes {
+		images = append(images, node.Image)
+	}
+	if len(images) == 0 {
+		images = append(images, "")
+	}
+
+	versions := map[string]*utilversion.Version{}
+	for _, image := range images {
+		if image == "" {
+			image = defaults.Image
+		}
+		// strip the digest, the tag is after the last colon
+		image, _, _ = strings.Cut(image, "@")
+		i := strings.LastIndex(image, ":")
+		if i < 0 || strings.Contains(image[i:], "/") {
+			continue
+		}
+
+		v, err := utilversion.ParseSemantic(image[i+1:])
+		if err != nil {
+			continue
+		}
+		versions[image] = v
+	}
+
+	return versions
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/spf13/pflag"
 	"sigs.k8s.io/kind/pkg/log"
@@ -15,6 +16,7 @@ var verbosity level
 
 func SetFlags(flags *pflag.FlagSet) {
 	flags.VarP(&verbosity, "v", "v", "Logging verbosity level. 0=normal, 1=verbose, 2=detailed, 3+=trace.")
+	flags.Var(moduleVerbosity{}, "vmodule", "Logging verbosity levels of harness internals overriding -v, in the form <module>=<level>,... (modules: "+strings.Join(testutils.LogModules(), ", ")+").")
 }
 
 func (l *level) Get() interface{} {
@@ -31,6 +33,7 @@ func (l *level) Set(value string) error {
 		return err
 	}
 	*l = level(v)
+	testutils.SetVerbosity(v)
 	return nil
 }
 
@@ -38,6 +41,21 @@ func (l *level) Type() string {
 	return string(*l)
 }
 
+// moduleVerbosity is the flag setting the verbosity levels of log modules.
+type moduleVerbosity struct{}
+
+func (moduleVerbosity) String() string {
+	return testutils.ModuleVerbosity()
+}
+
+func (moduleVerbosity) Set(value string) error {
+	return testutils.SetModuleVerbosity(value)
+}
+
+func (moduleVerbosity) Type() string {
+	return "string"
+}
+
 // kindLogger lets KIND log to the kuttl logger.
 // KIND log level N corresponds to kuttl log level N+1, such that
 // using the default 0 kuttl log level produces no KIND output.
@@ -46,7 +64,7 @@ type kindLogger struct {
 }
 
 func (k kindLogger) V(level log.Level) log.InfoLogger {
-	if int(level) >= int(verbosity) {
+	if !testutils.V(testutils.LogKind, int(level)+1) {
 		return &nopLogger{}
 	}
 	return k
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 )
 
 func TestCheckVersion(t *testing.T) {
@@ -42,3 +43,99 @@ func TestCheckVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyKindFeatures(t *testing.T) {
+	cfg := &v1alpha4.Cluster{
+		FeatureGates:  map[string]bool{"EphemeralContainers": false, "JobTrackingWithFinalizers": true},
+		RuntimeConfig: map[string]string{"api/beta": "false"},
+	}
+	applyKindFeatures(cfg, map[string]bool{"EphemeralContainers": true}, map[string]string{"api/alpha": "true"})
+
+	assert.Equal(t, map[string]bool{"EphemeralContainers": true, "JobTrackingWithFinalizers": true}, cfg.FeatureGates)
+	assert.Equal(t, map[string]string{"api/alpha": "true", "api/beta": "false"}, cfg.RuntimeConfig)
+
+	empty := &v1alpha4.Cluster{}
+	applyKindFeatures(empty, nil, nil)
+	assert.Nil(t, empty.FeatureGates)
+	assert.Nil(t, empty.RuntimeConfig)
+}
+
+func TestValidateKindFeatures(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         v1alpha4.Cluster
+		shouldError bool
+	}{
+		{
+			name: `empty`,
+		},
+		{
+			name: `valid with default image`,
+			cfg: v1alpha4.Cluster{
+				FeatureGates:  map[string]bool{"DynamicResourceAllocation": true},
+				RuntimeConfig: map[string]string{"api/alpha": "true", "resource.k8s.io/v1alpha1": "true"},
+			},
+		},
+		{
+			name: `dual-stack`,
+			cfg: v1alpha4.Cluster{
+				Networking: v1alpha4.Networking{IPFamily: v1alpha4.DualStackFamily},
+			},
+		},
+		{
+			name: `invalid IP family`,
+			cfg: v1alpha4.Cluster{
+				Networking: v1alpha4.Networking{IPFamily: "ipv5"},
+			},
+			shouldError: true,
+		},
+		{
+			name: `invalid feature gate`,
+			cfg: v1alpha4.Cluster{
+				FeatureGates: map[string]bool{"dynamic-resource-allocation": true},
+			},
+			shouldError: true,
+		},
+		{
+			name: `invalid runtime-config value`,
+			cfg: v1alpha4.Cluster{
+				RuntimeConfig: map[string]string{"api/alpha": "yes"},
+			},
+			shouldError: true,
+		},
+		{
+			name: `invalid runtime-config group version`,
+			cfg: v1alpha4.Cluster{
+				RuntimeConfig: map[string]string{"resource.k8s.io/alpha": "true"},
+			},
+			shouldError: true,
+		},
+		{
+			name: `api/alpha on an old node image`,
+			cfg: v1alpha4.Cluster{
+				Nodes:         []v1alpha4.Node{{Image: "kindest/node:v1.25.3"}, {Image: "kindest/node:v1.23.13@sha256:ef453bb7c79f0e3caba88d2067d4196f427794086a7d0df8df4f019d5e336b61"}},
+				RuntimeConfig: map[string]string{"api/alpha": "true"},
+			},
+			shouldError: true,
+		},
+		{
+			name: `api/alpha on an untagged node image`,
+			cfg: v1alpha4.Cluster{
+				Nodes:         []v1alpha4.Node{{Image: "localhost:5000/kind-node"}},
+				RuntimeConfig: map[string]string{"api/alpha": "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKindFeatures(&tt.cfg)
+			if tt.shouldError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
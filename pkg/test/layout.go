@@ -0,0 +1,126 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// stepsDir is the subdirectory of a test case whose step files are collected like those of the test case directory.
+const stepsDir = "steps"
+
+// frontMatterObjects returns the objects of a file whose name does not start with an index, if it may declare kuttl
+// objects.  Other files, ex. the inputs of commands, are not loaded and have no objects.
+func frontMatterObjects(path string) ([]client.Object, error) {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(data, []byte(harness.GroupVersion.Group)) {
+		return nil, nil
+	}
+	objs, err := testutils.LoadYAMLFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+	return objs, nil
+}
+
+// frontMatterIndex returns the step index set by the TestFile of a file whose name does not start with an index, it is
+// -1 if the file does not set one.
+func frontMatterIndex(path string) (int64, error) {
+	objs, err := frontMatterObjects(path)
+	if err != nil {
+		return -1, err
+	}
+	for _, obj := range objs {
+		if testFile, ok := obj.(*harness.TestFile); ok && testFile.Step != nil {
+			if *testFile.Step < 0 {
+				return -1, fmt.Errorf("the TestFile of %s has a negative step %d", path, *testFile.Step)
+			}
+			return int64(*testFile.Step), nil
+		}
+	}
+	return -1, nil
+}
+
+// testCaseListing returns the TestCase of the directory of the test case, it is nil if there is none.
+func (t *Case) testCaseListing() (*harness.TestCase, error) {
+	files, err := os.ReadDir(t.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var listing *harness.TestCase
+	for _, file := range files {
+		if file.IsDir() || testStepRegex.MatchString(file.Name()) {
+			continue
+		}
+		path := filepath.Join(t.Dir, file.Name())
+		objs, err := frontMatterObjects(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			testCase, ok := obj.(*harness.TestCase)
+			if !ok {
+				continue
+			}
+			if listing != nil {
+				return nil, fmt.Errorf("more than 1 TestCase not allowed in test case %s", t.Dir)
+			}
+			listing = testCase
+		}
+	}
+	return listing, nil
+}
+
+// listedStepFiles returns the step files listed by the TestCase by step index.
+func (t *Case) listedStepFiles(testCase *harness.TestCase) (map[int64][]string, error) {
+	testStepFiles := map[int64][]string{}
+	for index, step := range testCase.Steps {
+		files := []string{}
+		for _, file := range step.Files {
+			path := filepath.Join(t.Dir, file)
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("step %d of the TestCase: %w", index, err)
+			}
+			if !info.IsDir() {
+				files = append(files, path)
+				continue
+			}
+			stepDirFiles, err := dirFiles(path)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, stepDirFiles...)
+		}
+		testStepFiles[int64(index)] = files
+	}
+	return testStepFiles, nil
+}
+
+// dirFiles returns the paths of the entries of a step directory in name order.
+func dirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	return files, nil
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// lifecycle is the deletion state and the finalizers an object is expected to have, as declared by the terminating
+// and finalizers annotations.
+type lifecycle struct {
+	// terminating is nil if the deletion state is not checked.
+	terminating *bool
+	finalizers  []string
+	// absentFinalizers are the finalizers the object must not hold.
+	absentFinalizers []string
+}
+
+// expectedLifecycle returns a copy of expected without the terminating and finalizers annotations, as well as the
+// lifecycle declared by them.  If neither annotation is set, expected is returned unmodified.
+func expectedLifecycle(expected runtime.Object) (runtime.Object, lifecycle, error) {
+	expectations := lifecycle{}
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, expectations, err
+	}
+	annotations := m.GetAnnotations()
+
+	if value, ok := annotations[harness.TerminatingAnnotation]; ok {
+		terminating, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, expectations, fmt.Errorf("annotation %s: %q is not a boolean", harness.TerminatingAnnotation, value)
+		}
+		expectations.terminating = &terminating
+		if expected, err = withoutAnnotation(expected, harness.TerminatingAnnotation); err != nil {
+			return nil, expectations, err
+		}
+	}
+
+	if value, ok := annotations[harness.FinalizersAnnotation]; ok {
+		for _, finalizer := range strings.Split(value, ",") {
+			finalizer = strings.TrimSpace(finalizer)
+			absent := strings.HasPrefix(finalizer, "!")
+			finalizer = strings.TrimPrefix(finalizer, "!")
+			if finalizer == "" {
+				return nil, expectations, fmt.Errorf("annotation %s: %q contains an empty finalizer", harness.FinalizersAnnotation, value)
+			}
+			if absent {
+				expectations.absentFinalizers = append(expectations.absentFinalizers, finalizer)
+			} else {
+				expectations.finalizers = append(expectations.finalizers, finalizer)
+			}
+		}
+		if expected, err = withoutAnnotation(expected, harness.FinalizersAnnotation); err != nil {
+			return nil, expectations, err
+		}
+	}
+
+	return expected, expectations, nil
+}
+
+// check verifies the deletion state and the finalizers of actual.
+func (l lifecycle) check(actual *unstructured.Unstructured) error {
+	if l.terminating != nil {
+		deletion := actual.GetDeletionTimestamp()
+		if *l.terminating && deletion == nil {
+			return errors.New("expected the object to be terminating, it has no deletionTimestamp")
+		}
+		if !*l.terminating && deletion != nil {
+			return fmt.Errorf("expected the object not to be terminating, it is being deleted since %s", deletion.UTC().Format("15:04:05"))
+		}
+	}
+
+	held := map[string]bool{}
+	for _, finalizer := range actual.GetFinalizers() {
+		held[finalizer] = true
+	}
+	for _, finalizer := range l.finalizers {
+		if !held[finalizer] {
+			return fmt.Errorf("expected finalizer %s, the object has finalizers %v", finalizer, actual.GetFinalizers())
+		}
+	}
+	for _, finalizer := range l.absentFinalizers {
+		if held[finalizer] {
+			return fmt.Errorf("expected no finalizer %s, the object has finalizers %v", finalizer, actual.GetFinalizers())
+		}
+	}
+	return nil
+}
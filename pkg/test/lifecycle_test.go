@@ -0,0 +1,124 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedLifecycle(t *testing.T) {
+	expected := testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.TerminatingAnnotation, "true")
+	expected = testutils.SetAnnotation(expected, harness.FinalizersAnnotation, "example.com/protection, !example.com/cleanup")
+
+	stripped, lifecycle, err := expectedLifecycle(expected)
+	assert.NoError(t, err)
+	assert.True(t, *lifecycle.terminating)
+	assert.Equal(t, []string{"example.com/protection"}, lifecycle.finalizers)
+	assert.Equal(t, []string{"example.com/cleanup"}, lifecycle.absentFinalizers)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+	// the original object is not modified
+	assert.Contains(t, expected.GetAnnotations(), harness.TerminatingAnnotation)
+
+	unannotated := testutils.NewPod("hello", "")
+	stripped, lifecycle, err = expectedLifecycle(unannotated)
+	assert.NoError(t, err)
+	assert.Nil(t, lifecycle.terminating)
+	assert.Equal(t, unannotated, stripped)
+
+	_, _, err = expectedLifecycle(testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.TerminatingAnnotation, "soon"))
+	assert.EqualError(t, err, `annotation kuttl.dev/terminating: "soon" is not a boolean`)
+	_, _, err = expectedLifecycle(testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.FinalizersAnnotation, "example.com/protection,!"))
+	assert.EqualError(t, err, `annotation kuttl.dev/finalizers: "example.com/protection,!" contains an empty finalizer`)
+}
+
+func TestCheckResourceLifecycle(t *testing.T) {
+	terminating := testutils.NewPod("terminating", testNamespace)
+	terminating.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	terminating.SetFinalizers([]string{"example.com/protection"})
+	running := testutils.NewPod("running", testNamespace)
+	running.SetFinalizers([]string{"example.com/cleanup"})
+
+	expect := func(name, annotation, value string) runtime.Object {
+		return testutils.SetAnnotation(testutils.NewPod(name, ""), annotation, value)
+	}
+
+	for _, test := range []struct {
+		testName    string
+		expected    runtime.Object
+		shouldError bool
+	}{
+		{
+			testName: "terminating",
+			expected: expect("terminating", harness.TerminatingAnnotation, "true"),
+		},
+		{
+			testName:    "not terminating",
+			expected:    expect("running", harness.TerminatingAnnotation, "true"),
+			shouldError: true,
+		},
+		{
+			testName: "running",
+			expected: expect("running", harness.TerminatingAnnotation, "false"),
+		},
+		{
+			testName:    "unexpectedly terminating",
+			expected:    expect("terminating", harness.TerminatingAnnotation, "false"),
+			shouldError: true,
+		},
+		{
+			testName: "finalizer held",
+			expected: expect("terminating", harness.FinalizersAnnotation, "example.com/protection,!example.com/cleanup"),
+		},
+		{
+			testName:    "finalizer missing",
+			expected:    expect("running", harness.FinalizersAnnotation, "example.com/protection"),
+			shouldError: true,
+		},
+		{
+			testName:    "finalizer unexpectedly held",
+			expected:    expect("running", harness.FinalizersAnnotation, "!example.com/cleanup"),
+			shouldError: true,
+		},
+		{
+			testName: "one of the listed resources is terminating",
+			expected: expect("", harness.TerminatingAnnotation, "true"),
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			step := Step{
+				Logger: testutils.NewTestLogger(t, ""),
+				Client: func(bool) (client.Client, error) {
+					return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(terminating.DeepCopy(), running.DeepCopy()).Build(), nil
+				},
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			errors := step.CheckResource(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NotEqual(t, []error{}, errors)
+			} else {
+				assert.Equal(t, []error{}, errors)
+			}
+
+			absentErr := step.CheckResourceAbsent(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NoError(t, absentErr)
+			} else {
+				assert.Error(t, absentErr)
+			}
+		})
+	}
+}
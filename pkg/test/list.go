@@ -0,0 +1,93 @@
+package test
+
+import (
+	"fmt"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/http"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// ListedTest describes a test case of a test suite as it would run, for kuttl test --list.
+type ListedTest struct {
+	// Suite is the name of the test suite.
+	Suite string `json:"suite,omitempty"`
+	// TestDir is the test directory the test case is in.
+	TestDir string `json:"testDir"`
+	Name    string `json:"name"`
+	// ConcurrencyGroups of the steps of the test case.
+	ConcurrencyGroups []string `json:"concurrencyGroups,omitempty"`
+	// Preconditions of the steps of the test case, the test case is skipped if one of them is not met.
+	Preconditions []harness.Preconditions `json:"preconditions,omitempty"`
+	// Owner, Issue and Docs are the fields of the TestMetadata of the test case.
+	Owner string       `json:"owner,omitempty"`
+	Issue string       `json:"issue,omitempty"`
+	Docs  string       `json:"docs,omitempty"`
+	Steps []ListedStep `json:"steps"`
+}
+
+// ListedStep describes a step of a listed test case.
+type ListedStep struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Timeout of the assertions of the step in seconds.
+	Timeout int `json:"timeout"`
+}
+
+// discardLogger is the logger of test cases which are only loaded, not run.
+type discardLogger struct{}
+
+func (discardLogger) Log(args ...interface{})                 {}
+func (discardLogger) Logf(format string, args ...interface{}) {}
+func (l discardLogger) WithPrefix(string) testutils.Logger    { return l }
+func (discardLogger) Write(p []byte) (n int, err error)       { return len(p), nil }
+func (discardLogger) Flush()                                  {}
+
+// ListTests loads the test cases selected by the test suite and their steps without running them or connecting to a
+// cluster.  Test directories given as URLs cannot be listed, they are only downloaded when the tests run.
+func (h *Harness) ListTests() ([]ListedTest, error) {
+	filter, err := newTestFilter(h.TestSuite.Tests, h.TestSuite.SkipTests)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := []ListedTest{}
+	for _, testDir := range h.TestSuite.TestDirs {
+		if http.IsURL(testDir) {
+			return nil, fmt.Errorf("cannot list the tests of %s, test directories given as URLs are only downloaded when the tests run", testDir)
+		}
+		tests, err := h.loadTests(testDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, test := range filter.filter(testDir, tests) {
+			test.Logger = discardLogger{}
+			if err := test.LoadTestSteps(); err != nil {
+				return nil, fmt.Errorf("loading test %s: %w", test.Name, err)
+			}
+			listed = append(listed, h.listTest(testDir, test))
+		}
+	}
+	return listed, nil
+}
+
+// listTest describes the loaded test case of the test directory.
+func (h *Harness) listTest(testDir string, test *Case) ListedTest {
+	listed := ListedTest{
+		Suite:             h.TestSuite.Name,
+		TestDir:           testDir,
+		Name:              test.Name,
+		ConcurrencyGroups: test.ConcurrencyGroups(),
+		Steps:             []ListedStep{},
+	}
+	if metadata := test.Metadata(); metadata != nil {
+		listed.Owner, listed.Issue, listed.Docs = metadata.Owner, metadata.Issue, metadata.Docs
+	}
+	for _, step := range test.Steps {
+		if step.Step != nil && step.Step.Preconditions != nil {
+			listed.Preconditions = append(listed.Preconditions, *step.Step.Preconditions)
+		}
+		listed.Steps = append(listed.Steps, ListedStep{Index: step.Index, Name: step.Name, Timeout: step.GetTimeout()})
+	}
+	return listed
+}
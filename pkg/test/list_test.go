@@ -0,0 +1,75 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestListTests(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"install/00-install.yaml": `apiVersion: kuttl.dev/v1beta1
+kind: TestStep
+concurrencyGroups: [db]
+preconditions:
+  minNodes: 2
+---
+apiVersion: kuttl.dev/v1beta1
+kind: TestMetadata
+owner: team-db
+`,
+		"install/01-assert.yaml": `apiVersion: kuttl.dev/v1beta1
+kind: TestAssert
+timeout: 90
+`,
+		"upgrade/00-upgrade.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`,
+		"upgrade/README.md": "not a step",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	h := Harness{TestSuite: harness.TestSuite{ObjectMeta: metav1.ObjectMeta{Name: "db"}, TestDirs: []string{dir}, Timeout: 20}}
+	listed, err := h.ListTests()
+	require.NoError(t, err)
+	assert.Equal(t, []ListedTest{
+		{
+			Suite:             "db",
+			TestDir:           dir,
+			Name:              "install",
+			ConcurrencyGroups: []string{"db"},
+			Preconditions:     []harness.Preconditions{{MinNodes: 2}},
+			Owner:             "team-db",
+			Steps:             []ListedStep{{Index: 0, Name: "install", Timeout: 20}, {Index: 1, Timeout: 90}},
+		},
+		{
+			Suite:             "db",
+			TestDir:           dir,
+			Name:              "upgrade",
+			ConcurrencyGroups: []string{},
+			Steps:             []ListedStep{{Index: 0, Name: "upgrade", Timeout: 20}},
+		},
+	}, listed)
+
+	h.TestSuite.SkipTests = []string{"upgrade"}
+	listed, err = h.ListTests()
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "install", listed[0].Name)
+
+	h.TestSuite.TestDirs = []string{"https://example.com/tests.tgz"}
+	_, err = h.ListTests()
+	assert.EqualError(t, err, "cannot list the tests of https://example.com/tests.tgz, test directories given as URLs are only downloaded when the tests run")
+}
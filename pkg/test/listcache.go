@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// listCache is a client serving the Gets and Lists of unstructured objects from a single List of each kind and
+// namespace, so that checking many expected objects makes one request per kind and namespace instead of one per
+// object.  The lists are read when a kind and namespace is first used, a listCache is used for one assert attempt.
+type listCache struct {
+	client.Client
+
+	lock  sync.Mutex
+	lists map[listKey]*cachedList
+}
+
+type listKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+}
+
+type cachedList struct {
+	items []unstructured.Unstructured
+	// uncached is set if the objects could not be listed (ex. for lack of permissions), they are read directly then
+	uncached bool
+}
+
+func newListCache(cl client.Client) *listCache {
+	return &listCache{Client: cl, lists: map[listKey]*cachedList{}}
+}
+
+// Get gets unstructured objects from the list of their kind and namespace.
+func (c *listCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || len(opts) > 0 {
+		return c.Client.Get(ctx, key, obj, opts...)
+	}
+
+	gvk := u.GroupVersionKind()
+	cached := c.list(ctx, gvk, key.Namespace)
+	if cached.uncached {
+		return c.Client.Get(ctx, key, obj)
+	}
+	for i := range cached.items {
+		if cached.items[i].GetName() == key.Name && cached.items[i].GetNamespace() == key.Namespace {
+			cached.items[i].DeepCopyInto(u)
+			return nil
+		}
+	}
+
+	mapping, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return c.Client.Get(ctx, key, obj)
+	}
+	return k8serrors.NewNotFound(mapping.Resource.GroupResource(), key.Name)
+}
+
+// List lists unstructured objects from the list of their kind and namespace, filtered by the label selector.
+func (c *listCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	u, ok := list.(*unstructured.UnstructuredList)
+	if !ok {
+		return c.Client.List(ctx, list, opts...)
+	}
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if listOpts.FieldSelector != nil || listOpts.Limit > 0 || listOpts.Continue != "" {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	gvk := u.GroupVersionKind()
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	cached := c.list(ctx, gvk, listOpts.Namespace)
+	if cached.uncached {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	items := []unstructured.Unstructured{}
+	for i := range cached.items {
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(cached.items[i].GetLabels())) {
+			continue
+		}
+		items = append(items, *cached.items[i].DeepCopy())
+	}
+	u.Items = items
+	return nil
+}
+
+// list returns the objects of the kind in the namespace, listing them on first use.
+func (c *listCache) list(ctx context.Context, gvk schema.GroupVersionKind, namespace string) *cachedList {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := listKey{gvk: gvk, namespace: namespace}
+	if cached, ok := c.lists[key]; ok {
+		return cached
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk)
+	cached := &cachedList{}
+	if err := c.Client.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		cached.uncached = true
+	} else {
+		cached.items = list.Items
+	}
+	c.lists[key] = cached
+	return cached
+}
+
+// cacheLists makes the step read unstructured objects through a listCache until the returned function is called.  It
+// is called for each assert attempt, so that every attempt reads the current state of the cluster.
+func (s *Step) cacheLists() func() {
+	getClient := s.Client
+	var cache *listCache
+
+	s.Client = func(forceNew bool) (client.Client, error) {
+		if forceNew {
+			return getClient(forceNew)
+		}
+		if cache == nil {
+			cl, err := getClient(false)
+			if err != nil {
+				return nil, err
+			}
+			cache = newListCache(cl)
+		}
+		return cache, nil
+	}
+
+	return func() {
+		s.Client = getClient
+	}
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// requestCounter counts the Gets and Lists by kind, listErr fails all Lists.
+type requestCounter struct {
+	client.Client
+	gets    map[string]int
+	lists   map[string]int
+	listErr error
+}
+
+func newRequestCounter(objs ...runtime.Object) *requestCounter {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	return &requestCounter{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithRuntimeObjects(objs...).Build(),
+		gets:   map[string]int{},
+		lists:  map[string]int{},
+	}
+}
+
+func (c *requestCounter) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.gets[obj.GetObjectKind().GroupVersionKind().Kind]++
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *requestCounter) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists[list.GetObjectKind().GroupVersionKind().Kind]++
+	if c.listErr != nil {
+		return c.listErr
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestListCache(t *testing.T) {
+	labeled := func(pod *unstructured.Unstructured, app string) *unstructured.Unstructured {
+		pod.SetLabels(map[string]string{"app": app})
+		return pod
+	}
+	counter := newRequestCounter(
+		labeled(testutils.NewPod("web-1", testNamespace), "web"),
+		labeled(testutils.NewPod("web-2", testNamespace), "web"),
+		labeled(testutils.NewPod("db-1", testNamespace), "db"),
+		testutils.NewPod("web-1", "other"),
+	)
+	cache := newListCache(counter)
+
+	pod := &unstructured.Unstructured{}
+	pod.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+	require.NoError(t, cache.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "web-1"}, pod))
+	assert.Equal(t, "web-1", pod.GetName())
+	assert.Equal(t, "web", pod.GetLabels()["app"])
+
+	err := cache.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "web-3"}, pod)
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.EqualError(t, err, `pods "web-3" not found`)
+
+	matches, err := list(cache, pod.GroupVersionKind(), testNamespace, map[string]string{"app": "web"})
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+
+	// the kind and namespace was listed once, the other namespace is listed separately
+	assert.Equal(t, 1, counter.lists["Pod"])
+	require.NoError(t, cache.Get(context.TODO(), client.ObjectKey{Namespace: "other", Name: "web-1"}, pod))
+	assert.Equal(t, "other", pod.GetNamespace())
+	assert.Equal(t, 2, counter.lists["Pod"])
+	assert.Equal(t, 0, counter.gets["Pod"])
+
+	// objects are read directly if they cannot be listed
+	counter = newRequestCounter(testutils.NewPod("web-1", testNamespace))
+	counter.listErr = k8serrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", nil)
+	cache = newListCache(counter)
+	require.NoError(t, cache.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "web-1"}, pod))
+	require.NoError(t, cache.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "web-1"}, pod))
+	assert.Equal(t, 1, counter.lists["Pod"])
+	assert.Equal(t, 2, counter.gets["Pod"])
+}
+
+func TestCheckListsOncePerAttempt(t *testing.T) {
+	counter := newRequestCounter(testutils.NewPod("pod-1", testNamespace), testutils.NewPod("pod-2", testNamespace))
+	step := Step{
+		Asserts: []client.Object{
+			testutils.NewPod("pod-1", ""),
+			testutils.NewPod("pod-2", ""),
+			testutils.NewPod("pod-3", ""),
+		},
+		Errors: []client.Object{testutils.NewPod("pod-4", "")},
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) { return counter, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) {
+			return testutils.FakeDiscoveryClient(), nil
+		},
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		restore := step.cacheLists()
+		errs := step.Check(testNamespace, 30)
+		restore()
+
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), `pods "pod-3" not found`)
+		assert.Equal(t, attempt, counter.lists["Pod"])
+		assert.Equal(t, 0, counter.gets["Pod"])
+	}
+
+	// the client of the step is restored
+	cl, err := step.Client(false)
+	require.NoError(t, err)
+	assert.Equal(t, counter, cl)
+}
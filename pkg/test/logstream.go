@@ -0,0 +1,270 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// logPollInterval is the interval in which new pods of the streamed deployments are looked up.
+const logPollInterval = 2 * time.Second
+
+// logStreamer streams the logs of the pods of deployments into a file for the suite and into a file for each test
+// which is running while the lines are received.  All methods can be called on a nil logStreamer, which does nothing.
+type logStreamer struct {
+	clientset kubernetes.Interface
+	streams   []harness.LogStream
+	dir       string
+	logger    testutils.Logger
+	interval  time.Duration
+
+	lock   sync.Mutex
+	suite  map[harness.LogStream]*os.File
+	tests  map[string]map[harness.LogStream]*os.File
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newLogStreamer returns a logStreamer writing the logs of streams into dir.
+func newLogStreamer(clientset kubernetes.Interface, streams []harness.LogStream, dir string, logger testutils.Logger) *logStreamer {
+	return &logStreamer{
+		clientset: clientset,
+		streams:   streams,
+		dir:       dir,
+		logger:    logger,
+		interval:  logPollInterval,
+		suite:     map[harness.LogStream]*os.File{},
+		tests:     map[string]map[harness.LogStream]*os.File{},
+	}
+}
+
+// logFileName returns the name of the log file of a stream.
+func logFileName(stream harness.LogStream) string {
+	return fmt.Sprintf("%s-%s.log", stream.Namespace, stream.Deployment)
+}
+
+// Start opens the suite log files and starts streaming the logs until Stop is called.
+func (l *logStreamer) Start() error {
+	if l == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+	for _, stream := range l.streams {
+		f, err := os.Create(filepath.Join(l.dir, logFileName(stream)))
+		if err != nil {
+			return err
+		}
+		l.suite[stream] = f
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	for _, stream := range l.streams {
+		stream := stream
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			l.follow(ctx, stream)
+		}()
+	}
+	return nil
+}
+
+// Stop stops streaming the logs and closes all log files.
+func (l *logStreamer) Stop() {
+	if l == nil || l.cancel == nil {
+		return
+	}
+	l.cancel()
+	l.wg.Wait()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, f := range l.suite {
+		f.Close()
+	}
+	for _, files := range l.tests {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	l.suite = map[harness.LogStream]*os.File{}
+	l.tests = map[string]map[harness.LogStream]*os.File{}
+}
+
+// StartTest starts writing the logs into the log files of the test name.
+func (l *logStreamer) StartTest(name string) error {
+	if l == nil {
+		return nil
+	}
+
+	dir := filepath.Join(l.dir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files := map[harness.LogStream]*os.File{}
+	for _, stream := range l.streams {
+		f, err := os.Create(filepath.Join(dir, logFileName(stream)))
+		if err != nil {
+			for _, opened := range files {
+				opened.Close()
+			}
+			return err
+		}
+		files[stream] = f
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.tests[name] = files
+	return nil
+}
+
+// EndTest stops writing the logs into the log files of the test name.
+func (l *logStreamer) EndTest(name string) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, f := range l.tests[name] {
+		f.Close()
+	}
+	delete(l.tests, name)
+}
+
+// write writes a log line of stream into the suite file and the files of all running tests.
+func (l *logStreamer) write(stream harness.LogStream, line string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if f, ok := l.suite[stream]; ok {
+		if _, err := f.WriteString(line); err != nil {
+			l.logger.Logf("error writing logs of deployment %s/%s: %v", stream.Namespace, stream.Deployment, err)
+		}
+	}
+	for _, files := range l.tests {
+		if f, ok := files[stream]; ok {
+			if _, err := f.WriteString(line); err != nil {
+				l.logger.Logf("error writing logs of deployment %s/%s: %v", stream.Namespace, stream.Deployment, err)
+			}
+		}
+	}
+}
+
+// follow looks up the pods of the deployment of stream until ctx is done and streams the logs of their containers.
+// The logs of a container are streamed again from the time its last stream ended, ex. when it was restarted.
+func (l *logStreamer) follow(ctx context.Context, stream harness.LogStream) {
+	var lock sync.Mutex
+	active := map[string]bool{}
+	ended := map[string]time.Time{}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		pods, err := l.pods(ctx, stream)
+		if err != nil && ctx.Err() == nil {
+			l.logger.Logf("error looking up pods of deployment %s/%s: %v", stream.Namespace, stream.Deployment, err)
+		}
+
+		for _, pod := range pods {
+			for _, container := range pod.Spec.Containers {
+				if stream.Container != "" && container.Name != stream.Container {
+					continue
+				}
+
+				key := pod.Name + "/" + container.Name
+				lock.Lock()
+				if active[key] {
+					lock.Unlock()
+					continue
+				}
+				active[key] = true
+				var since *metav1.Time
+				if end, ok := ended[key]; ok {
+					since = &metav1.Time{Time: end}
+				}
+				lock.Unlock()
+
+				pod, container := pod.Name, container.Name
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					l.streamContainer(ctx, stream, pod, container, since)
+
+					lock.Lock()
+					defer lock.Unlock()
+					delete(active, key)
+					ended[key] = time.Now()
+				}()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pods returns the pods of the deployment of stream.
+func (l *logStreamer) pods(ctx context.Context, stream harness.LogStream) ([]corev1.Pod, error) {
+	deployment, err := l.clientset.AppsV1().Deployments(stream.Namespace).Get(ctx, stream.Deployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := l.clientset.CoreV1().Pods(stream.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// streamContainer streams the logs of a container since the provided time until the stream ends or ctx is done.
+func (l *logStreamer) streamContainer(ctx context.Context, stream harness.LogStream, pod, container string, since *metav1.Time) {
+	rc, err := l.clientset.CoreV1().Pods(stream.Namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    true,
+		SinceTime: since,
+	}).Stream(ctx)
+	if err != nil {
+		// the container may not be started yet, it is retried with the next lookup
+		if testutils.V(testutils.LogWatch, 2) {
+			l.logger.Logf("cannot stream logs of %s/%s container %s yet: %v", stream.Namespace, pod, container, err)
+		}
+		return
+	}
+	defer rc.Close()
+	if testutils.V(testutils.LogWatch, 1) {
+		l.logger.Logf("streaming logs of %s/%s container %s", stream.Namespace, pod, container)
+	}
+
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		l.write(stream, fmt.Sprintf("%s/%s: %s\n", pod, container, scanner.Text()))
+	}
+}
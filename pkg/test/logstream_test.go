@@ -0,0 +1,68 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestLogStreamer(t *testing.T) {
+	labels := map[string]string{"app": "operator"}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: "system"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator-1234", Namespace: "system", Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "manager"}, {Name: "proxy"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "system"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "manager"}}},
+		},
+	)
+
+	dir := t.TempDir()
+	stream := harness.LogStream{Namespace: "system", Deployment: "operator", Container: "manager"}
+	logs := newLogStreamer(clientset, []harness.LogStream{stream}, dir, testutils.NewTestLogger(t, ""))
+	logs.interval = 10 * time.Millisecond
+
+	assert.NoError(t, logs.StartTest("a-test"))
+	assert.NoError(t, logs.Start())
+
+	testLog := filepath.Join(dir, "a-test", "system-operator.log")
+	assert.Eventually(t, func() bool {
+		content, err := os.ReadFile(testLog)
+		return err == nil && len(content) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	logs.EndTest("a-test")
+	logs.Stop()
+
+	for _, path := range []string{testLog, filepath.Join(dir, "system-operator.log")} {
+		content, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		// the fake clientset returns "fake logs" for every container
+		assert.Contains(t, string(content), "operator-1234/manager: fake logs\n")
+		assert.NotContains(t, string(content), "proxy")
+		assert.NotContains(t, string(content), "other")
+	}
+
+	// a nil logStreamer does nothing
+	var disabled *logStreamer
+	assert.NoError(t, disabled.Start())
+	assert.NoError(t, disabled.StartTest("a-test"))
+	disabled.EndTest("a-test")
+	disabled.Stop()
+}
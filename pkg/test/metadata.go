@@ -0,0 +1,59 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+)
+
+// Metadata returns the TestMetadata declared by the steps of the test case, it is nil if there is none.
+func (t *Case) Metadata() *harness.TestMetadata {
+	for _, step := range t.Steps {
+		if step.Metadata != nil {
+			return step.Metadata
+		}
+	}
+	return nil
+}
+
+// metadataProperties returns the report properties of the fields of metadata which are set.
+func metadataProperties(metadata *harness.TestMetadata) []report.Property {
+	properties := []report.Property{}
+	for _, property := range []report.Property{
+		{Name: "owner", Value: metadata.Owner},
+		{Name: "issue", Value: metadata.Issue},
+		{Name: "docs", Value: metadata.Docs},
+	} {
+		if property.Value != "" {
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
+
+// reportMetadata adds the metadata of the test case to its report and logs it when the test ends if it failed, so
+// that the output of a failed test says whom to contact.
+func (t *Case) reportMetadata(test *testing.T, tc *report.Testcase) {
+	metadata := t.Metadata()
+	if metadata == nil {
+		return
+	}
+
+	properties := metadataProperties(metadata)
+	fields := []string{}
+	for _, property := range properties {
+		tc.AddProperty(property)
+		fields = append(fields, property.Name+": "+property.Value)
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	test.Cleanup(func() {
+		if test.Failed() {
+			t.Logger.Logf("test %s failed, %s", t.Name, strings.Join(fields, ", "))
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestMetadata(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00-metadata.yaml"), []byte(`apiVersion: kuttl.dev/v1beta1
+kind: TestMetadata
+owner: storage-team
+issue: https://github.com/kudobuilder/kuttl/issues/1
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "01-install.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`), 0600))
+
+	test := &Case{Name: "metadata", Dir: dir, Logger: testutils.NewTestLogger(t, "")}
+	require.NoError(t, test.LoadTestSteps())
+	require.Len(t, test.Steps, 2)
+	assert.Empty(t, test.Steps[0].Apply)
+
+	metadata := test.Metadata()
+	require.NotNil(t, metadata)
+	assert.Equal(t, "storage-team", metadata.Owner)
+
+	tc := report.NewCase(test.Name)
+	test.reportMetadata(t, tc)
+	assert.Equal(t, &report.Properties{Property: []report.Property{
+		{Name: "owner", Value: "storage-team"},
+		{Name: "issue", Value: "https://github.com/kudobuilder/kuttl/issues/1"},
+	}}, tc.Properties)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00-other.yaml"), []byte(`apiVersion: kuttl.dev/v1beta1
+kind: TestMetadata
+owner: other-team
+`), 0600))
+	assert.EqualError(t, test.LoadTestSteps(), `more than 1 TestMetadata not allowed in step "metadata"`)
+}
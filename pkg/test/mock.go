@@ -0,0 +1,336 @@
+package test
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/mock"
+)
+
+const (
+	// mockLabel is the label of the helper pods serving a mock service, set to the name of the mock.
+	mockLabel = "kuttl.dev/mock"
+	// mockContainerPort is the port the helper pods serving mock services listen on.
+	mockContainerPort = 8080
+	// mockRoutesDir and mockTLSDir are the directories the routes, or the gRPC methods and descriptors, and the
+	// certificate of a mock are mounted at.
+	mockRoutesDir = "/etc/kuttl-mock"
+	mockTLSDir    = "/etc/kuttl-mock-tls"
+)
+
+// StartMocks starts the mock services of the step in the namespace, in helper pods or on the host, and waits for them
+// to be ready.  The mocks on the host are stopped as a cleanup of test.
+func (s *Step) StartMocks(test *testing.T, namespace string) error {
+	if s.Step == nil || len(s.Step.MockServices) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return err
+	}
+	ctx := context.TODO()
+
+	for _, service := range s.Step.MockServices {
+		if service.Name == "" {
+			return errors.New("a mock service needs a name")
+		}
+		if err := s.startMock(ctx, test, cl, service, namespace); err != nil {
+			return fmt.Errorf("mock service %s: %w", service.Name, err)
+		}
+	}
+	return nil
+}
+
+// startMock starts the mock service, with a certificate stored in a Secret of the namespace if it is served over TLS.
+func (s *Step) startMock(ctx context.Context, test *testing.T, cl client.Client, service harness.MockService, namespace string) error {
+	if service.Host && service.Port == 0 {
+		return errors.New("a mock service on the host needs a port")
+	}
+	if err := mock.Validate(service.Routes); err != nil {
+		return err
+	}
+	descriptors, err := s.mockDescriptors(service)
+	if err != nil {
+		return err
+	}
+
+	var cert *mock.Certificate
+	if service.TLS {
+		if cert, err = mock.NewCertificate(mockHosts(service, namespace)); err != nil {
+			return err
+		}
+		if err := cl.Create(ctx, mockSecret(service, namespace, cert)); err != nil {
+			return err
+		}
+	}
+
+	if service.Host {
+		return s.serveMock(test, service, cert, descriptors)
+	}
+	return s.deployMock(ctx, cl, service, namespace, cert != nil, descriptors)
+}
+
+// mockDescriptors reads the descriptors of the services of a gRPC mock and checks its methods, it returns nil if the
+// mock has no descriptors.
+func (s *Step) mockDescriptors(service harness.MockService) ([]byte, error) {
+	if service.GRPC == nil {
+		return nil, nil
+	}
+	if len(service.Routes) > 0 {
+		return nil, errors.New("a gRPC mock service has no routes")
+	}
+	var descriptors []byte
+	if service.GRPC.Descriptors != "" {
+		var err error
+		if descriptors, err = os.ReadFile(cleanPath(service.GRPC.Descriptors, s.Dir)); err != nil {
+			return nil, fmt.Errorf("reading descriptors: %w", err)
+		}
+	}
+	if _, err := mock.GRPCHandler(*service.GRPC, descriptors, nil); err != nil {
+		return nil, err
+	}
+	return descriptors, nil
+}
+
+// mockHosts returns the names the certificate of the mock service is valid for.
+func mockHosts(service harness.MockService, namespace string) []string {
+	if service.Host {
+		return []string{"localhost", "127.0.0.1", "::1"}
+	}
+	domain := os.Getenv(ClusterDomainEnv)
+	if domain == "" {
+		domain = defaultClusterDomain
+	}
+	svc := service.Name + "." + namespace + ".svc"
+	return []string{service.Name, service.Name + "." + namespace, svc, svc + "." + domain}
+}
+
+// loopbackAddress returns the loopback address of the IP family, the IPv4 address unless the family is ipv6.
+func loopbackAddress(ipFamily string) string {
+	if ipFamily == "ipv6" {
+		return "::1"
+	}
+	return "127.0.0.1"
+}
+
+// mockPort returns the port the mock service is exposed on.
+func mockPort(service harness.MockService) int {
+	switch {
+	case service.Port != 0:
+		return service.Port
+	case service.TLS:
+		return 443
+	default:
+		return 80
+	}
+}
+
+// mockPortName returns the name of the port of the mock service, which tells its protocol.
+func mockPortName(service harness.MockService) string {
+	if service.GRPC != nil {
+		return "grpc"
+	}
+	return "http"
+}
+
+// mockSecret returns the TLS Secret of the mock service, which also has the certificate of its CA.
+func mockSecret(service harness.MockService, namespace string, cert *mock.Certificate) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: service.Name + "-tls", Namespace: namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       cert.Cert,
+			corev1.TLSPrivateKeyKey: cert.Key,
+			"ca.crt":                cert.CA,
+		},
+	}
+}
+
+// serveMock serves the mock service on the host until test ends.
+func (s *Step) serveMock(test *testing.T, service harness.MockService, cert *mock.Certificate, descriptors []byte) error {
+	logger := s.Logger.WithPrefix("mock " + service.Name)
+	server := &http.Server{Handler: mock.Handler(service.Routes, logger.Logf)}
+	if service.GRPC != nil {
+		handler, err := mock.GRPCHandler(*service.GRPC, descriptors, logger.Logf)
+		if err != nil {
+			return err
+		}
+		server.Handler = handler
+	}
+	if cert != nil {
+		pair, err := tls.X509KeyPair(cert.Cert, cert.Key)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12}
+	}
+	if service.GRPC != nil {
+		if err := mock.ServeGRPC(server); err != nil {
+			return err
+		}
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(loopbackAddress(s.IPFamily), strconv.Itoa(service.Port)))
+	if err != nil {
+		return err
+	}
+	if server.TLSConfig != nil {
+		listener = tls.NewListener(listener, server.TLSConfig)
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Logf("serving failed: %v", err)
+		}
+	}()
+	test.Cleanup(func() {
+		if err := server.Close(); err != nil {
+			test.Errorf("stopping mock service %s: %v", service.Name, err)
+		}
+	})
+	s.Logger.Logf("serving mock service %s at %s", service.Name, listener.Addr())
+	return nil
+}
+
+// deployMock creates the helper pod serving the mock service in the namespace and its Service, and waits for the pod
+// to be ready.
+func (s *Step) deployMock(ctx context.Context, cl client.Client, service harness.MockService, namespace string, withTLS bool, descriptors []byte) error {
+	name := "kuttl-mock-" + service.Name
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if service.GRPC != nil {
+		methods, err := json.Marshal(service.GRPC)
+		if err != nil {
+			return err
+		}
+		configMap.Data = map[string]string{"grpc.json": string(methods)}
+		if descriptors != nil {
+			configMap.BinaryData = map[string][]byte{"descriptors.pb": descriptors}
+		}
+	} else {
+		routes, err := json.Marshal(service.Routes)
+		if err != nil {
+			return err
+		}
+		configMap.Data = map[string]string{"routes.json": string(routes)}
+	}
+	if err := cl.Create(ctx, configMap); err != nil {
+		return err
+	}
+
+	pod := s.mockPod(service, name, namespace, withTLS)
+	if err := cl.Create(ctx, pod); err != nil {
+		return err
+	}
+	if err := cl.Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: service.Name, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{mockLabel: service.Name},
+			Ports: []corev1.ServicePort{{
+				Name:       mockPortName(service),
+				Port:       int32(mockPort(service)),
+				TargetPort: intstr.FromInt(mockContainerPort),
+			}},
+		},
+	}); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(time.Duration(s.GetTimeout())*time.Second + probeStartMargin)
+	if err := waitForReady(ctx, cl, pod, deadline); err != nil {
+		return err
+	}
+	s.Logger.Logf("serving mock service %s at %s.%s.svc:%d", service.Name, service.Name, namespace, mockPort(service))
+	return nil
+}
+
+// mockPod returns the helper pod serving the mock service with the routes of the ConfigMap of the same name.
+func (s *Step) mockPod(service harness.MockService, name, namespace string, withTLS bool) *corev1.Pod {
+	command := []string{"kubectl-kuttl", "mock", "--routes", mockRoutesDir + "/routes.json", "--listen", fmt.Sprintf(":%d", mockContainerPort)}
+	if service.GRPC != nil {
+		command = []string{"kubectl-kuttl", "mock", "--grpc", mockRoutesDir + "/grpc.json", "--listen", fmt.Sprintf(":%d", mockContainerPort)}
+		if service.GRPC.Descriptors != "" {
+			command = append(command, "--descriptors", mockRoutesDir+"/descriptors.pb")
+		}
+	}
+	mounts := []corev1.VolumeMount{{Name: "routes", MountPath: mockRoutesDir, ReadOnly: true}}
+	volumes := []corev1.Volume{{
+		Name:         "routes",
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}}},
+	}}
+	if withTLS {
+		command = append(command, "--tls-cert", mockTLSDir+"/"+corev1.TLSCertKey, "--tls-key", mockTLSDir+"/"+corev1.TLSPrivateKeyKey)
+		mounts = append(mounts, corev1.VolumeMount{Name: "tls", MountPath: mockTLSDir, ReadOnly: true})
+		volumes = append(volumes, corev1.Volume{
+			Name:         "tls",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: service.Name + "-tls"}},
+		})
+	}
+
+	image := s.ProbeImage
+	if image == "" {
+		image = defaultProbeImage()
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{harness.HelperPodLabel: "true", mockLabel: service.Name},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:         "mock",
+				Image:        image,
+				Command:      command,
+				Ports:        []corev1.ContainerPort{{Name: mockPortName(service), ContainerPort: mockContainerPort}},
+				VolumeMounts: mounts,
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(mockContainerPort)}},
+				},
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			}},
+			Volumes: volumes,
+		},
+	}
+	scheduleHelperPod(&pod.Spec, s.HelperPods)
+	return pod
+}
+
+// waitForReady waits until the helper pod is ready, it fails if the pod has terminated or is not ready by the
+// deadline.
+func waitForReady(ctx context.Context, cl client.Client, pod *corev1.Pod, deadline time.Time) error {
+	for {
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(pod), pod); err != nil {
+			return err
+		}
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return fmt.Errorf("pod %s/%s terminated: %s", pod.Namespace, pod.Name, probeMessage(pod))
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pod %s/%s was not ready in time: %s", pod.Namespace, pod.Name, probeMessage(pod))
+		}
+		time.Sleep(probePollInterval)
+	}
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// readyClient marks the pods it gets as ready, as if the kubelet started them.
+type readyClient struct {
+	client.Client
+}
+
+func (c *readyClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.Client.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	if pod, ok := obj.(*corev1.Pod); ok {
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	}
+	return nil
+}
+
+// freePort returns a local port which is not in use.
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestStartMocksOnHost(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	port := freePort(t)
+
+	t.Run("test case", func(t *testing.T) {
+		step := &Step{
+			Logger: testutils.NewTestLogger(t, ""),
+			Client: func(bool) (client.Client, error) { return cl, nil },
+			Step: &harness.TestStep{MockServices: []harness.MockService{{
+				Name:   "license",
+				Port:   port,
+				Host:   true,
+				TLS:    true,
+				Routes: []harness.MockRoute{{Path: "/v1/license", Body: "valid"}},
+			}}},
+		}
+		require.NoError(t, step.StartMocks(t, testNamespace))
+
+		secret := &corev1.Secret{}
+		require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "license-tls"}, secret))
+		roots := x509.NewCertPool()
+		require.True(t, roots.AppendCertsFromPEM(secret.Data["ca.crt"]))
+
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}}}
+		resp, err := httpClient.Get(fmt.Sprintf("https://localhost:%d/v1/license", port))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "valid", string(body))
+	})
+
+	// the mock is stopped when the test case ends
+	_, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/v1/license", port))
+	assert.Error(t, err)
+}
+
+func TestLoopbackAddress(t *testing.T) {
+	assert.Equal(t, "127.0.0.1", loopbackAddress(""))
+	assert.Equal(t, "127.0.0.1", loopbackAddress("dual"))
+	assert.Equal(t, "::1", loopbackAddress("ipv6"))
+}
+
+func TestStartMocksInCluster(t *testing.T) {
+	cl := &readyClient{fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	step := &Step{
+		Timeout:    5,
+		ProbeImage: "kuttl:dev",
+		Logger:     testutils.NewTestLogger(t, ""),
+		Client:     func(bool) (client.Client, error) { return cl, nil },
+		Step: &harness.TestStep{MockServices: []harness.MockService{
+			{Name: "cloud", TLS: true, Routes: []harness.MockRoute{{Method: "POST", Path: "/v1/instances", Status: 201}}},
+		}},
+	}
+	require.NoError(t, step.StartMocks(t, testNamespace))
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "kuttl-mock-cloud"}, configMap))
+	assert.JSONEq(t, `[{"method":"POST","path":"/v1/instances","status":201}]`, configMap.Data["routes.json"])
+
+	pod := &corev1.Pod{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "kuttl-mock-cloud"}, pod))
+	assert.Equal(t, map[string]string{harness.HelperPodLabel: "true", mockLabel: "cloud"}, pod.Labels)
+	assert.Equal(t, "kuttl:dev", pod.Spec.Containers[0].Image)
+	assert.Equal(t, []string{"kubectl-kuttl", "mock", "--routes", "/etc/kuttl-mock/routes.json", "--listen", ":8080",
+		"--tls-cert", "/etc/kuttl-mock-tls/tls.crt", "--tls-key", "/etc/kuttl-mock-tls/tls.key"}, pod.Spec.Containers[0].Command)
+
+	service := &corev1.Service{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "cloud"}, service))
+	assert.Equal(t, int32(443), service.Spec.Ports[0].Port)
+	assert.Equal(t, map[string]string{mockLabel: "cloud"}, service.Spec.Selector)
+
+	assert.Equal(t, []string{"cloud", "cloud.world", "cloud.world.svc", "cloud.world.svc.cluster.local"},
+		mockHosts(harness.MockService{Name: "cloud"}, testNamespace))
+}
+
+func TestStartGRPCMockInCluster(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "services.pb"), []byte{}, 0600))
+	cl := &readyClient{fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	step := &Step{
+		Dir:     dir,
+		Timeout: 5,
+		Logger:  testutils.NewTestLogger(t, ""),
+		Client:  func(bool) (client.Client, error) { return cl, nil },
+		Step: &harness.TestStep{MockServices: []harness.MockService{{Name: "billing", Port: 9090, GRPC: &harness.MockGRPC{
+			Descriptors: "services.pb",
+			Methods:     []harness.MockGRPCMethod{{Method: "billing.v1.Accounts/Close", Code: 7}},
+		}}}},
+	}
+	require.NoError(t, step.StartMocks(t, testNamespace))
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "kuttl-mock-billing"}, configMap))
+	assert.JSONEq(t, `{"descriptors":"services.pb","methods":[{"method":"billing.v1.Accounts/Close","code":7}]}`, configMap.Data["grpc.json"])
+	assert.Contains(t, configMap.BinaryData, "descriptors.pb")
+
+	pod := &corev1.Pod{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "kuttl-mock-billing"}, pod))
+	assert.Equal(t, []string{"kubectl-kuttl", "mock", "--grpc", "/etc/kuttl-mock/grpc.json", "--listen", ":8080",
+		"--descriptors", "/etc/kuttl-mock/descriptors.pb"}, pod.Spec.Containers[0].Command)
+
+	service := &corev1.Service{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "billing"}, service))
+	assert.Equal(t, "grpc", service.Spec.Ports[0].Name)
+	assert.Equal(t, int32(9090), service.Spec.Ports[0].Port)
+}
+
+func TestStartMocksErrors(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for service, message := range map[*harness.MockService]string{
+		{Routes: []harness.MockRoute{{Path: "/"}}}:                                             "a mock service needs a name",
+		{Name: "license", Host: true}:                                                          "mock service license: a mock service on the host needs a port",
+		{Name: "license", Routes: []harness.MockRoute{{Path: "/", Status: 1000}}}:              "mock service license: route 1: invalid status code 1000",
+		{Name: "billing", Routes: []harness.MockRoute{{Path: "/"}}, GRPC: &harness.MockGRPC{}}: "mock service billing: a gRPC mock service has no routes",
+		{Name: "billing", GRPC: &harness.MockGRPC{Health: map[string]string{"": "OK"}}}:        `mock service billing: invalid health status "OK" of service "", must be SERVING, NOT_SERVING or UNKNOWN`,
+	} {
+		step := &Step{
+			Logger: testutils.NewTestLogger(t, ""),
+			Client: func(bool) (client.Client, error) { return cl, nil },
+			Step:   &harness.TestStep{MockServices: []harness.MockService{*service}},
+		}
+		assert.EqualError(t, step.StartMocks(t, testNamespace), message)
+	}
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// maxNearMisses is the maximum number of candidates listed for an object which was not found.
+const maxNearMisses = 5
+
+// nearMiss is an object which may be the object an assert expected.
+type nearMiss struct {
+	obj unstructured.Unstructured
+	// prefix is the length of the common prefix of the names.
+	prefix int
+	// labels is set if the object has the labels of the expected object.
+	labels bool
+}
+
+// nearMisses returns the objects of the kind in the namespace whose names are similar to name (they share at least
+// half of it as prefix) or which have the labels of the expected object, the closest first.
+func nearMisses(cl client.Client, gvk schema.GroupVersionKind, namespace, name string, labels map[string]string) ([]unstructured.Unstructured, error) {
+	objects, err := list(cl, gvk, namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	misses := []nearMiss{}
+	for _, obj := range objects {
+		miss := nearMiss{
+			obj:    obj,
+			prefix: commonPrefix(name, obj.GetName()),
+			labels: len(labels) > 0 && hasLabels(obj.GetLabels(), labels),
+		}
+		if miss.labels || (miss.prefix >= 3 && 2*miss.prefix >= len(name)) {
+			misses = append(misses, miss)
+		}
+	}
+
+	sort.SliceStable(misses, func(i, j int) bool {
+		if misses[i].prefix != misses[j].prefix {
+			return misses[i].prefix > misses[j].prefix
+		}
+		if misses[i].labels != misses[j].labels {
+			return misses[i].labels
+		}
+		return misses[i].obj.GetName() < misses[j].obj.GetName()
+	})
+	if len(misses) > maxNearMisses {
+		misses = misses[:maxNearMisses]
+	}
+
+	candidates := make([]unstructured.Unstructured, 0, len(misses))
+	for _, miss := range misses {
+		candidates = append(candidates, miss.obj)
+	}
+	return candidates, nil
+}
+
+// commonPrefix returns the length of the common prefix of a and b.
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// hasLabels returns true if actual has all of the expected labels.
+func hasLabels(actual, expected map[string]string) bool {
+	for key, value := range expected {
+		if v, ok := actual[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// notFoundError adds the near misses of the expected object to err, the error of getting it, with a diff against the
+// closest one.  err is returned unchanged if there are none.
+func notFoundError(err error, expected runtime.Object, candidates []unstructured.Unstructured) error {
+	if len(candidates) == 0 {
+		return err
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for i := range candidates {
+		ids = append(ids, testutils.ResourceID(&candidates[i]))
+	}
+	message := fmt.Sprintf("did you mean %s?", strings.Join(ids, ", "))
+
+	diff, diffErr := testutils.PrettyDiff(withDefaults(expected, candidates[0].Object), &candidates[0])
+	if diffErr == nil {
+		message += "\n" + diff
+	}
+	return fmt.Errorf("%w, %s", err, message)
+}
+
+// describeNotFound returns err, the error of getting the expected object, with its near misses.  err is returned
+// unchanged if they cannot be listed (ex. for lack of permissions), as it is checked again on each retry.
+func describeNotFound(cl client.Client, err error, expected runtime.Object, gvk schema.GroupVersionKind, namespace, name string) error {
+	var labels map[string]string
+	if m, accessorErr := meta.Accessor(expected); accessorErr == nil {
+		labels = m.GetLabels()
+	}
+
+	candidates, listErr := nearMisses(cl, gvk, namespace, name, labels)
+	if listErr != nil {
+		return err
+	}
+	return notFoundError(err, expected, candidates)
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestNearMisses(t *testing.T) {
+	labeled := testutils.NewPod("database", testNamespace)
+	labeled.SetLabels(map[string]string{"app": "web"})
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(
+		testutils.NewPod("web-7d9f-abcde", testNamespace),
+		testutils.NewPod("web-7d9f-fghij", testNamespace),
+		testutils.NewPod("worker", testNamespace),
+		testutils.NewPod("web-0", "other"),
+		labeled,
+	).Build()
+
+	for _, test := range []struct {
+		name     string
+		expected string
+		labels   map[string]string
+		misses   []string
+	}{
+		{
+			name:     "prefix",
+			expected: "web-7d9f-xyz",
+			misses:   []string{"web-7d9f-abcde", "web-7d9f-fghij"},
+		},
+		{
+			name:     "prefix and labels",
+			expected: "web",
+			labels:   map[string]string{"app": "web"},
+			misses:   []string{"web-7d9f-abcde", "web-7d9f-fghij", "database"},
+		},
+		{
+			name:     "short prefix",
+			expected: "webserver-main",
+			misses:   []string{},
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			pod := testutils.NewPod(test.expected, "")
+			candidates, err := nearMisses(cl, pod.GroupVersionKind(), testNamespace, test.expected, test.labels)
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, candidate := range candidates {
+				names = append(names, candidate.GetName())
+			}
+			assert.Equal(t, test.misses, names)
+		})
+	}
+}
+
+func TestCheckResourceNearMiss(t *testing.T) {
+	step := Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) {
+			return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(
+				testutils.NewPod("web-1", testNamespace),
+				testutils.NewPod("web-2", testNamespace),
+			).Build(), nil
+		},
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+
+	errs := step.CheckResource(testutils.NewPod("web-0", ""), testNamespace)
+	require.Len(t, errs, 1)
+	assert.True(t, k8serrors.IsNotFound(errs[0]))
+	assert.Contains(t, errs[0].Error(), `pods "web-0" not found, did you mean Pod:world/web-1, Pod:world/web-2?`)
+	assert.Contains(t, errs[0].Error(), "--- Pod:world/web-0\n+++ Pod:world/web-1\n")
+
+	errs = step.CheckResource(testutils.NewPod("cache", ""), testNamespace)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `pods "cache" not found`)
+}
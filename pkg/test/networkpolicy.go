@@ -0,0 +1,76 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// helperPolicyName is the name of the NetworkPolicy allowing the traffic of helper pods.
+const helperPolicyName = "kuttl-allow-helper-pods"
+
+// checkNetworkPolicies looks for NetworkPolicies in the namespace before commands run.  Commands creating pods which
+// are blocked by a NetworkPolicy otherwise hang silently until they time out.  If AllowHelperPodTraffic is set, a
+// NetworkPolicy allowing all traffic of pods with the helper label is created, otherwise a warning is logged.
+func (s *Step) checkNetworkPolicies(namespace string) error {
+	cl, err := s.Client(false)
+	if err != nil {
+		return err
+	}
+
+	policies := &networkingv1.NetworkPolicyList{}
+	if err := cl.List(context.TODO(), policies, client.InNamespace(namespace)); err != nil {
+		// not being able to list NetworkPolicies (ex. missing RBAC permissions) must not fail the test
+		s.Logger.Logf("unable to list NetworkPolicies in namespace %s: %v", namespace, err)
+		return nil
+	}
+
+	names := []string{}
+	for _, policy := range policies.Items {
+		if policy.Name != helperPolicyName {
+			names = append(names, policy.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	if !s.AllowHelperPodTraffic {
+		s.Logger.Logf("WARNING: namespace %s has NetworkPolicies (%s), pods created by commands may be blocked and the commands "+
+			"may hang until they time out. Set allowHelperPodTraffic in the TestSuite and label the pods with %s=true to allow their traffic.",
+			namespace, strings.Join(names, ", "), harness.HelperPodLabel)
+		return nil
+	}
+
+	if err := cl.Create(context.TODO(), helperPolicy(namespace)); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating NetworkPolicy %s/%s for helper pods: %w", namespace, helperPolicyName, err)
+	}
+	s.Logger.Logf("namespace %s has NetworkPolicies (%s), allowing traffic of pods labeled with %s=true", namespace, strings.Join(names, ", "), harness.HelperPodLabel)
+	return nil
+}
+
+// helperPolicy returns a NetworkPolicy allowing all ingress and egress traffic of pods with the helper label.
+func helperPolicy(namespace string) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      helperPolicyName,
+			Namespace: namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{harness.HelperPodLabel: "true"},
+			},
+			// empty rules allow all traffic
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{}},
+			Egress:      []networkingv1.NetworkPolicyEgressRule{{}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCheckNetworkPolicies(t *testing.T) {
+	denyAll := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: testNamespace},
+		Spec: networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+
+	for _, test := range []struct {
+		testName     string
+		actual       []runtime.Object
+		allow        bool
+		shouldCreate bool
+	}{
+		{
+			testName: "no network policies",
+			allow:    true,
+		},
+		{
+			testName: "network policies in other namespace",
+			actual: []runtime.Object{&networkingv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "other"},
+			}},
+			allow: true,
+		},
+		{
+			testName: "network policies without allowing helper pods",
+			actual:   []runtime.Object{denyAll},
+		},
+		{
+			testName:     "network policies allowing helper pods",
+			actual:       []runtime.Object{denyAll},
+			allow:        true,
+			shouldCreate: true,
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(test.actual...).Build()
+			step := Step{
+				AllowHelperPodTraffic: test.allow,
+				Logger:                testutils.NewTestLogger(t, ""),
+				Client:                func(bool) (client.Client, error) { return cl, nil },
+			}
+
+			assert.NoError(t, step.checkNetworkPolicies(testNamespace))
+			// the policy may already exist from a previous step
+			assert.NoError(t, step.checkNetworkPolicies(testNamespace))
+
+			policy := &networkingv1.NetworkPolicy{}
+			err := cl.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: helperPolicyName}, policy)
+			if !test.shouldCreate {
+				assert.True(t, k8serrors.IsNotFound(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]string{harness.HelperPodLabel: "true"}, policy.Spec.PodSelector.MatchLabels)
+			assert.Len(t, policy.Spec.Ingress, 1)
+			assert.Len(t, policy.Spec.Egress, 1)
+		})
+	}
+}
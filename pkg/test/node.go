@@ -0,0 +1,192 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// ChangeNodes applies the node changes of the step.  Unless SkipDelete is set, the changes are undone as a cleanup of
+// test, restoring the labels, taints and cordoning the changes touched.
+func (s *Step) ChangeNodes(test *testing.T) error {
+	if s.Step == nil || len(s.Step.Nodes) == 0 {
+		return nil
+	}
+	if err := s.checkSafeNodeChanges(); err != nil {
+		return err
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range s.Step.Nodes {
+		change := change
+
+		names, err := nodeNames(cl, change)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			name := name
+
+			var original *corev1.Node
+			if err := updateNode(cl, name, func(node *corev1.Node) error {
+				original = node.DeepCopy()
+				return applyNodeChange(node, change)
+			}); err != nil {
+				return fmt.Errorf("changing node %s: %w", name, err)
+			}
+			s.Logger.Logf("node %s changed", name)
+
+			if s.SkipDelete {
+				continue
+			}
+			test.Cleanup(func() {
+				if err := updateNode(cl, name, func(node *corev1.Node) error {
+					undoNodeChange(node, original, change)
+					return nil
+				}); err != nil {
+					test.Errorf("undoing changes of node %s: %v", name, err)
+				}
+			})
+		}
+	}
+	return nil
+}
+
+// nodeNames returns the names of the nodes selected by change.
+func nodeNames(cl client.Client, change harness.NodeChange) ([]string, error) {
+	if (change.Name == "") == (len(change.Selector) == 0) {
+		return nil, errors.New("exactly one of name or selector must be set for a node change")
+	}
+	if change.Name != "" {
+		return []string{change.Name}, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := cl.List(context.TODO(), nodes, client.MatchingLabels(change.Selector)); err != nil {
+		return nil, err
+	}
+	if len(nodes.Items) == 0 {
+		return nil, fmt.Errorf("no nodes match the selector %v", change.Selector)
+	}
+
+	names := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		names = append(names, node.Name)
+	}
+	return names, nil
+}
+
+// updateNode updates the node name with update, retrying on conflicts with the latest version of the node.
+func updateNode(cl client.Client, name string, update func(*corev1.Node) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node := &corev1.Node{}
+		if err := cl.Get(context.TODO(), client.ObjectKey{Name: name}, node); err != nil {
+			return err
+		}
+		if err := update(node); err != nil {
+			return err
+		}
+		return cl.Update(context.TODO(), node)
+	})
+}
+
+// applyNodeChange applies change to node.
+func applyNodeChange(node *corev1.Node, change harness.NodeChange) error {
+	labels := node.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range change.Labels {
+		labels[key] = value
+	}
+	for _, key := range change.RemoveLabels {
+		delete(labels, key)
+	}
+	node.SetLabels(labels)
+
+	for _, removed := range change.RemoveTaints {
+		key, effect, _ := strings.Cut(removed, ":")
+		node.Spec.Taints = removeTaints(node.Spec.Taints, func(taint corev1.Taint) bool {
+			return taint.Key == key && (effect == "" || string(taint.Effect) == effect)
+		})
+	}
+	for _, added := range change.Taints {
+		if added.Key == "" || added.Effect == "" {
+			return errors.New("taints must have a key and an effect")
+		}
+		added := added
+		node.Spec.Taints = append(removeTaints(node.Spec.Taints, func(taint corev1.Taint) bool {
+			return taint.Key == added.Key && taint.Effect == added.Effect
+		}), added)
+	}
+
+	if change.Cordon != nil {
+		node.Spec.Unschedulable = *change.Cordon
+	}
+	return nil
+}
+
+// undoNodeChange restores the labels, taints and cordoning of node which are touched by change to the original node.
+func undoNodeChange(node, original *corev1.Node, change harness.NodeChange) {
+	labels := node.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	keys := append([]string{}, change.RemoveLabels...)
+	for key := range change.Labels {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if value, ok := original.Labels[key]; ok {
+			labels[key] = value
+		} else {
+			delete(labels, key)
+		}
+	}
+	node.SetLabels(labels)
+
+	taintKeys := map[string]bool{}
+	for _, taint := range change.Taints {
+		taintKeys[taint.Key] = true
+	}
+	for _, removed := range change.RemoveTaints {
+		key, _, _ := strings.Cut(removed, ":")
+		taintKeys[key] = true
+	}
+	if len(taintKeys) > 0 {
+		node.Spec.Taints = removeTaints(node.Spec.Taints, func(taint corev1.Taint) bool { return taintKeys[taint.Key] })
+		for _, taint := range original.Spec.Taints {
+			if taintKeys[taint.Key] {
+				node.Spec.Taints = append(node.Spec.Taints, taint)
+			}
+		}
+	}
+
+	if change.Cordon != nil {
+		node.Spec.Unschedulable = original.Spec.Unschedulable
+	}
+}
+
+// removeTaints returns the taints without those matching remove.
+func removeTaints(taints []corev1.Taint, remove func(corev1.Taint) bool) []corev1.Taint {
+	kept := []corev1.Taint{}
+	for _, taint := range taints {
+		if !remove(taint) {
+			kept = append(kept, taint)
+		}
+	}
+	return kept
+}
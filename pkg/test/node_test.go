@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestChangeNodes(t *testing.T) {
+	cordon := true
+	newNode := func(name string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": "a", "zone": "east"}},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+				{Key: "dedicated", Value: "infra", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "other", Effect: corev1.TaintEffectNoExecute},
+			}},
+		}
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(newNode("node-1"), newNode("node-2")).Build()
+
+	step := Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) { return cl, nil },
+		Step: &harness.TestStep{Nodes: []harness.NodeChange{
+			{
+				Selector:     map[string]string{"pool": "a"},
+				Labels:       map[string]string{"zone": "west", "gpu": "true"},
+				RemoveLabels: []string{"pool"},
+				Taints:       []corev1.Taint{{Key: "dedicated", Value: "test", Effect: corev1.TaintEffectNoSchedule}},
+				RemoveTaints: []string{"other:NoExecute"},
+			},
+			{Name: "node-2", Cordon: &cordon},
+		}},
+	}
+
+	t.Run("change", func(t *testing.T) {
+		assert.NoError(t, step.ChangeNodes(t))
+
+		node := &corev1.Node{}
+		assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Name: "node-1"}, node))
+		assert.Equal(t, map[string]string{"zone": "west", "gpu": "true"}, node.Labels)
+		assert.Equal(t, []corev1.Taint{{Key: "dedicated", Value: "test", Effect: corev1.TaintEffectNoSchedule}}, node.Spec.Taints)
+		assert.False(t, node.Spec.Unschedulable)
+
+		assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Name: "node-2"}, node))
+		assert.True(t, node.Spec.Unschedulable)
+	})
+
+	// the changes are undone by the cleanup of the sub test
+	for _, name := range []string{"node-1", "node-2"} {
+		node := &corev1.Node{}
+		assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Name: name}, node))
+		original := newNode(name)
+		assert.Equal(t, original.Labels, node.Labels)
+		assert.ElementsMatch(t, original.Spec.Taints, node.Spec.Taints)
+		assert.False(t, node.Spec.Unschedulable)
+	}
+
+	invalid := Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) { return cl, nil },
+		Step:   &harness.TestStep{Nodes: []harness.NodeChange{{Selector: map[string]string{"pool": "missing"}}}},
+	}
+	assert.EqualError(t, invalid.ChangeNodes(t), "no nodes match the selector map[pool:missing]")
+
+	invalid.Step.Nodes = []harness.NodeChange{{}}
+	assert.Error(t, invalid.ChangeNodes(t))
+}
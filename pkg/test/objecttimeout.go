@@ -0,0 +1,93 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// expectedTimeout returns a copy of expected without the timeout annotation, as well as the timeout of the object in
+// seconds.  If the annotation is not set, expected is returned unmodified with a timeout of 0.
+func expectedTimeout(expected runtime.Object) (runtime.Object, int, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, ok := m.GetAnnotations()[harness.TimeoutAnnotation]
+	if !ok {
+		return expected, 0, nil
+	}
+
+	timeout, err := strconv.Atoi(value)
+	if err != nil || timeout <= 0 {
+		return nil, 0, fmt.Errorf("annotation %s: %q is not a positive number of seconds", harness.TimeoutAnnotation, value)
+	}
+
+	copied, err := withoutAnnotation(expected, harness.TimeoutAnnotation)
+	if err != nil {
+		return nil, 0, err
+	}
+	return copied, timeout, nil
+}
+
+// objectTimeoutError is an error of an expected object which has its own timeout.
+type objectTimeoutError struct {
+	timeout int
+	err     error
+}
+
+func (e *objectTimeoutError) Error() string {
+	return e.err.Error()
+}
+
+func (e *objectTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// withObjectTimeout marks the errors of an expected object with its timeout, they are returned unmodified if the
+// object has no timeout.
+func withObjectTimeout(errs []error, timeout int) []error {
+	if timeout == 0 {
+		return errs
+	}
+	marked := make([]error, 0, len(errs))
+	for _, err := range errs {
+		marked = append(marked, &objectTimeoutError{timeout: timeout, err: err})
+	}
+	return marked
+}
+
+// assertsExpired returns true if one of the errors of the asserts ran out of time, elapsed is the number of seconds the
+// step has been asserting.  The errors of expected objects with their own timeout are compared to it, the other errors
+// to the timeout of the step.
+func assertsExpired(errs []error, timeout, elapsed float64) bool {
+	for _, err := range errs {
+		errTimeout := timeout
+		objectErr := &objectTimeoutError{}
+		if errors.As(err, &objectErr) {
+			errTimeout = float64(objectErr.timeout)
+		}
+		if elapsed >= errTimeout {
+			return true
+		}
+	}
+	return false
+}
+
+// maxObjectTimeout returns the longest timeout of the expected objects of the step, 0 if none has its own timeout.
+// Invalid timeouts are ignored, they fail the asserts of the objects.
+func (s *Step) maxObjectTimeout() int {
+	longest := 0
+	for _, expected := range s.Asserts {
+		if _, timeout, err := expectedTimeout(expected); err == nil && timeout > longest {
+			longest = timeout
+		}
+	}
+	return longest
+}
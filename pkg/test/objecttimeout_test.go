@@ -0,0 +1,80 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedTimeout(t *testing.T) {
+	expected := testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.TimeoutAnnotation, "300")
+
+	stripped, timeout, err := expectedTimeout(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, 300, timeout)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+	// the original object is not modified
+	assert.Contains(t, expected.GetAnnotations(), harness.TimeoutAnnotation)
+
+	unannotated := testutils.NewPod("hello", "")
+	stripped, timeout, err = expectedTimeout(unannotated)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, timeout)
+	assert.Equal(t, unannotated, stripped)
+
+	for _, value := range []string{"5m", "0", "-10"} {
+		_, _, err = expectedTimeout(testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.TimeoutAnnotation, value))
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCheckObjectTimeouts(t *testing.T) {
+	quick := testutils.SetAnnotation(testutils.NewResource("v1", "ConfigMap", "config", ""), harness.TimeoutAnnotation, "10")
+	slow := testutils.SetAnnotation(testutils.NewResource("v1", "PersistentVolumeClaim", "data", ""), harness.TimeoutAnnotation, "300")
+	pod := testutils.NewPod("hello", "")
+
+	step := Step{
+		Timeout: 30,
+		Asserts: []client.Object{quick, slow, pod},
+		Logger:  testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) {
+			return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(testutils.NewPod("hello", testNamespace)).Build(), nil
+		},
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+	assert.Equal(t, 300, step.maxTimeout())
+
+	errs := step.Check(testNamespace, 30)
+	require.Len(t, errs, 2)
+	objectErr := &objectTimeoutError{}
+	require.True(t, errors.As(errs[0], &objectErr))
+	assert.Equal(t, 10, objectErr.timeout)
+	require.True(t, errors.As(errs[1], &objectErr))
+	assert.Equal(t, 300, objectErr.timeout)
+
+	// the quick object expires before the step timeout
+	assert.False(t, assertsExpired(errs, 30, 5))
+	assert.True(t, assertsExpired(errs, 30, 10))
+	// the slow object is retried after the step timeout
+	assert.False(t, assertsExpired(errs[1:], 30, 60))
+	assert.True(t, assertsExpired(errs[1:], 30, 300))
+	// errors without their own timeout expire with the step
+	assert.True(t, assertsExpired([]error{errors.New("pod not ready")}, 30, 30))
+
+	// invalid timeouts fail the asserts of the object
+	step.Asserts = []client.Object{testutils.SetAnnotation(pod, harness.TimeoutAnnotation, "soon")}
+	assert.Equal(t, 30, step.maxTimeout())
+	errs = step.Check(testNamespace, 30)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `annotation kuttl.dev/timeout: "soon" is not a positive number of seconds`)
+}
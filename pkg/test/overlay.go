@@ -0,0 +1,95 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// overlayMatches returns true if obj is selected by the target of an overlay.
+func overlayMatches(target harness.OverlayTarget, obj client.Object) (bool, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if (target.Group != "" || target.Version != "") && target.Group != gvk.Group {
+		return false, nil
+	}
+	if target.Version != "" && target.Version != gvk.Version {
+		return false, nil
+	}
+	if target.Kind != "" && target.Kind != gvk.Kind {
+		return false, nil
+	}
+	for _, pattern := range []struct{ pattern, value string }{{target.Name, obj.GetName()}, {target.Namespace, obj.GetNamespace()}} {
+		if pattern.pattern == "" {
+			continue
+		}
+		matches, err := path.Match(pattern.pattern, pattern.value)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern.pattern, err)
+		}
+		if !matches {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// applyOverlay applies the JSON patch to obj and returns the patched object.
+func applyOverlay(patch jsonpatch.Patch, obj client.Object) (client.Object, error) {
+	doc, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = patch.Apply(doc); err != nil {
+		return nil, err
+	}
+
+	patched := &unstructured.Unstructured{}
+	if err := patched.UnmarshalJSON(doc); err != nil {
+		return nil, err
+	}
+	if source, ok := testutils.SourceOf(obj); ok {
+		testutils.SetSource(patched, source)
+	}
+	return patched, nil
+}
+
+// applyOverlays applies the overlays of the test suite and then those of the step to the objects the step applies.
+func (s *Step) applyOverlays(suite []harness.Overlay) error {
+	overlays := append([]harness.Overlay{}, suite...)
+	if s.Step != nil {
+		overlays = append(overlays, s.Step.Overlays...)
+	}
+
+	for i, overlay := range overlays {
+		ops, err := yaml.ToJSON([]byte(overlay.Patch))
+		if err != nil {
+			return fmt.Errorf("overlay %d: parsing patch: %w", i+1, err)
+		}
+		patch, err := jsonpatch.DecodePatch(ops)
+		if err != nil {
+			return fmt.Errorf("overlay %d: parsing patch: %w", i+1, err)
+		}
+
+		for j, obj := range s.Apply {
+			matches, err := overlayMatches(overlay.Target, obj)
+			if err != nil {
+				return fmt.Errorf("overlay %d: %w", i+1, err)
+			}
+			if !matches {
+				continue
+			}
+			if s.Apply[j], err = applyOverlay(patch, obj); err != nil {
+				return fmt.Errorf("overlay %d: patching %s%s: %w", i+1, testutils.ResourceID(obj), testutils.DescribeSource(obj), err)
+			}
+		}
+	}
+	return nil
+}
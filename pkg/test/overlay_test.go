@@ -0,0 +1,92 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestOverlayMatches(t *testing.T) {
+	pvc := testutils.NewResource("v1", "PersistentVolumeClaim", "data-0", "")
+	deployment := testutils.NewResource("apps/v1", "Deployment", "operator", "operators")
+
+	for _, test := range []struct {
+		name    string
+		target  harness.OverlayTarget
+		obj     client.Object
+		matches bool
+		err     string
+	}{
+		{name: "empty target", obj: deployment, matches: true},
+		{name: "kind", target: harness.OverlayTarget{Kind: "PersistentVolumeClaim"}, obj: pvc, matches: true},
+		{name: "other kind", target: harness.OverlayTarget{Kind: "PersistentVolumeClaim"}, obj: deployment},
+		{name: "core group", target: harness.OverlayTarget{Version: "v1"}, obj: pvc, matches: true},
+		{name: "other group", target: harness.OverlayTarget{Version: "v1"}, obj: deployment},
+		{name: "group of all versions", target: harness.OverlayTarget{Group: "apps"}, obj: deployment, matches: true},
+		{name: "other version", target: harness.OverlayTarget{Group: "apps", Version: "v1beta2"}, obj: deployment},
+		{name: "name pattern", target: harness.OverlayTarget{Name: "data-*"}, obj: pvc, matches: true},
+		{name: "other name", target: harness.OverlayTarget{Name: "data-*"}, obj: deployment},
+		{name: "namespace pattern", target: harness.OverlayTarget{Namespace: "operator*"}, obj: deployment, matches: true},
+		{name: "object without namespace", target: harness.OverlayTarget{Namespace: "operator*"}, obj: pvc},
+		{name: "invalid pattern", target: harness.OverlayTarget{Name: "data-["}, obj: pvc, err: `invalid pattern "data-[": syntax error in pattern`},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			matches, err := overlayMatches(test.target, test.obj)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.matches, matches)
+		})
+	}
+}
+
+func TestApplyOverlays(t *testing.T) {
+	pvc := func(name string) client.Object {
+		obj := testutils.NewResource("v1", "PersistentVolumeClaim", name, "")
+		obj.Object["spec"] = map[string]interface{}{"storageClassName": "standard"}
+		return obj
+	}
+	suite := []harness.Overlay{{
+		Target: harness.OverlayTarget{Kind: "PersistentVolumeClaim"},
+		Patch:  "[{op: replace, path: /spec/storageClassName, value: fast}]",
+	}}
+
+	step := &Step{
+		Apply: []client.Object{pvc("data-0"), pvc("logs-0"), testutils.NewPod("hello", "")},
+		Step: &harness.TestStep{Overlays: []harness.Overlay{{
+			Target: harness.OverlayTarget{Name: "logs-*"},
+			Patch: `- op: replace
+  path: /spec/storageClassName
+  value: slow
+- op: add
+  path: /metadata/labels
+  value: {retention: short}`,
+		}}},
+	}
+	require.NoError(t, step.applyOverlays(suite))
+
+	storageClass := func(obj client.Object) interface{} {
+		return obj.(*unstructured.Unstructured).Object["spec"].(map[string]interface{})["storageClassName"]
+	}
+	assert.Equal(t, "fast", storageClass(step.Apply[0]))
+	assert.Equal(t, "slow", storageClass(step.Apply[1]))
+	assert.Equal(t, map[string]string{"retention": "short"}, step.Apply[1].GetLabels())
+	assert.Equal(t, testutils.NewPod("hello", ""), step.Apply[2])
+
+	step = &Step{Apply: []client.Object{testutils.NewPod("hello", "")}}
+	err := step.applyOverlays([]harness.Overlay{{Patch: "[{op: replace, path: /spec/storageClassName, value: fast}]"}})
+	assert.ErrorContains(t, err, "overlay 1: patching Pod:/hello: ")
+
+	err = step.applyOverlays([]harness.Overlay{{Patch: "{op: replace}"}})
+	assert.ErrorContains(t, err, "overlay 1: parsing patch: ")
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// owner is a reference to an owning object as declared by the owned-by annotation.
+type owner struct {
+	Kind string
+	Name string
+}
+
+func (o owner) String() string {
+	return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+}
+
+// ownedBy returns a copy of expected without the owned-by annotation, as well as the owners declared by it.
+// If the annotation is not set, expected is returned unmodified.
+func ownedBy(expected runtime.Object) (runtime.Object, []owner, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, ok := m.GetAnnotations()[harness.OwnedByAnnotation]
+	if !ok {
+		return expected, nil, nil
+	}
+
+	owners := []owner{}
+	for _, ref := range strings.Split(value, ",") {
+		kindName := strings.SplitN(strings.TrimSpace(ref), "/", 2)
+		if len(kindName) != 2 || kindName[0] == "" || kindName[1] == "" {
+			return nil, nil, fmt.Errorf("annotation %s: %q is not in the form <kind>/<name>", harness.OwnedByAnnotation, ref)
+		}
+		owners = append(owners, owner{Kind: kindName[0], Name: kindName[1]})
+	}
+
+	copied, err := withoutAnnotation(expected, harness.OwnedByAnnotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	return copied, owners, nil
+}
+
+// withoutAnnotation returns a copy of obj without the annotation, annotations are set to nil if none are left.
+func withoutAnnotation(obj runtime.Object, annotation string) (runtime.Object, error) {
+	copied := obj.DeepCopyObject()
+	copiedMeta, err := meta.Accessor(copied)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := copiedMeta.GetAnnotations()
+	delete(annotations, annotation)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	copiedMeta.SetAnnotations(annotations)
+
+	return copied, nil
+}
+
+// checkOwners verifies that actual has an owner reference to each of the owners and that the UID of each
+// reference matches the UID of the owner object currently in the cluster.
+func checkOwners(cl client.Client, actual *unstructured.Unstructured, owners []owner) error {
+	for _, o := range owners {
+		found := false
+
+		for _, ref := range actual.GetOwnerReferences() {
+			if !strings.EqualFold(ref.Kind, o.Kind) || ref.Name != o.Name {
+				continue
+			}
+			found = true
+
+			// owners are either in the same namespace or cluster scoped.
+			ownerObj := &unstructured.Unstructured{}
+			ownerObj.SetAPIVersion(ref.APIVersion)
+			ownerObj.SetKind(ref.Kind)
+			if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: actual.GetNamespace(), Name: ref.Name}, ownerObj); err != nil {
+				return fmt.Errorf("retrieving owner %s: %w", o, err)
+			}
+
+			if ownerObj.GetUID() != ref.UID {
+				return fmt.Errorf("owner reference to %s has UID %q, but %s has UID %q", o, ref.UID, o, ownerObj.GetUID())
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("not owned by %s", o)
+		}
+	}
+
+	return nil
+}
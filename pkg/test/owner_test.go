@@ -0,0 +1,115 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestOwnedBy(t *testing.T) {
+	expected := testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.OwnedByAnnotation, "ReplicaSet/rs, Deployment/deploy")
+
+	stripped, owners, err := ownedBy(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, []owner{{Kind: "ReplicaSet", Name: "rs"}, {Kind: "Deployment", Name: "deploy"}}, owners)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+	// the original object is not modified
+	assert.Contains(t, expected.GetAnnotations(), harness.OwnedByAnnotation)
+
+	unannotated := testutils.NewPod("hello", "")
+	stripped, owners, err = ownedBy(unannotated)
+	assert.NoError(t, err)
+	assert.Nil(t, owners)
+	assert.Equal(t, unannotated, stripped)
+
+	_, _, err = ownedBy(testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.OwnedByAnnotation, "rs"))
+	assert.Error(t, err)
+}
+
+func TestCheckResourceOwnedBy(t *testing.T) {
+	owned := func(name, ownerUID string) runtime.Object {
+		pod := testutils.NewPod(name, testNamespace)
+		pod.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       "deploy",
+			UID:        types.UID(ownerUID),
+		}})
+		return pod
+	}
+	deployment := testutils.NewResource("apps/v1", "Deployment", "deploy", testNamespace)
+	deployment.SetUID("1234")
+
+	for _, test := range []struct {
+		testName    string
+		actual      []runtime.Object
+		expected    runtime.Object
+		shouldError bool
+	}{
+		{
+			testName: "owner matches",
+			actual:   []runtime.Object{deployment, owned("hello", "1234")},
+			expected: testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.OwnedByAnnotation, "Deployment/deploy"),
+		},
+		{
+			testName:    "owner UID does not match",
+			actual:      []runtime.Object{deployment, owned("hello", "5678")},
+			expected:    testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.OwnedByAnnotation, "Deployment/deploy"),
+			shouldError: true,
+		},
+		{
+			testName:    "not owned",
+			actual:      []runtime.Object{deployment, testutils.NewPod("hello", testNamespace)},
+			expected:    testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.OwnedByAnnotation, "Deployment/deploy"),
+			shouldError: true,
+		},
+		{
+			testName:    "owner does not exist",
+			actual:      []runtime.Object{owned("hello", "1234")},
+			expected:    testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.OwnedByAnnotation, "Deployment/deploy"),
+			shouldError: true,
+		},
+		{
+			testName: "one of the listed resources is owned",
+			actual:   []runtime.Object{deployment, testutils.NewPod("other", testNamespace), owned("hello", "1234")},
+			expected: testutils.SetAnnotation(testutils.NewPod("", ""), harness.OwnedByAnnotation, "deployment/deploy"),
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			step := Step{
+				Logger: testutils.NewTestLogger(t, ""),
+				Client: func(bool) (client.Client, error) {
+					return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(test.actual...).Build(), nil
+				},
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			errors := step.CheckResource(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NotEqual(t, []error{}, errors)
+			} else {
+				assert.Equal(t, []error{}, errors)
+			}
+
+			absentErr := step.CheckResourceAbsent(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NoError(t, absentErr)
+			} else {
+				assert.Error(t, absentErr)
+			}
+		})
+	}
+}
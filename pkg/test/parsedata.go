@@ -0,0 +1,227 @@
+package test
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// dataParsers parse the content of a data key by format.
+var dataParsers = map[string]func(string) (interface{}, error){
+	"yaml":       parseYAMLData,
+	"json":       parseJSONData,
+	"ini":        parseINIData,
+	"properties": parsePropertiesData,
+}
+
+// expectedParsedData returns a copy of expected without the parse-data annotation, as well as the format of each data
+// key it names.  If the annotation is not set, expected is returned unmodified with no keys.
+func expectedParsedData(expected runtime.Object) (runtime.Object, map[string]string, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, ok := m.GetAnnotations()[harness.ParseDataAnnotation]
+	if !ok {
+		return expected, nil, nil
+	}
+	gvk := expected.GetObjectKind().GroupVersionKind()
+	if gvk.Group != "" || (gvk.Kind != "ConfigMap" && gvk.Kind != "Secret") {
+		return nil, nil, fmt.Errorf("annotation %s can only be used on ConfigMaps and Secrets", harness.ParseDataAnnotation)
+	}
+
+	keys := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		key, format, explicit := strings.Cut(strings.TrimSpace(entry), "=")
+		if !explicit {
+			format = strings.TrimPrefix(filepath.Ext(key), ".")
+			if format == "yml" {
+				format = "yaml"
+			}
+		}
+		if key == "" {
+			return nil, nil, fmt.Errorf("annotation %s: %q has an empty key", harness.ParseDataAnnotation, value)
+		}
+		if _, ok := dataParsers[format]; !ok {
+			return nil, nil, fmt.Errorf("annotation %s: unknown format %q of key %s, it must be one of %s", harness.ParseDataAnnotation,
+				format, key, strings.Join(dataFormats(), ", "))
+		}
+		keys[key] = format
+	}
+
+	copied, err := withoutAnnotation(expected, harness.ParseDataAnnotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	return copied, keys, nil
+}
+
+// dataFormats returns the formats data keys can be parsed as.
+func dataFormats() []string {
+	formats := []string{}
+	for format := range dataParsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// parsedData returns copies of the expected and actual objects with the content of the data keys replaced by the
+// parsed documents, they are returned unmodified if there are no keys.  The data of Secrets is decoded and their
+// expected stringData is compared with the data of the actual Secret.
+func parsedData(expected, actual map[string]interface{}, keys map[string]string) (map[string]interface{}, map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return expected, actual, nil
+	}
+
+	expected = runtime.DeepCopyJSON(expected)
+	actual = runtime.DeepCopyJSON(actual)
+	secret := expected["kind"] == "Secret"
+	for key, format := range keys {
+		content, ok, err := dataContent(expected, key, secret)
+		if err != nil {
+			return nil, nil, fmt.Errorf("expected data key %s: %w", key, err)
+		}
+		if !ok {
+			return nil, nil, fmt.Errorf("annotation %s: the expected object has no data key %s", harness.ParseDataAnnotation, key)
+		}
+		parsed, err := dataParsers[format](content)
+		if err != nil {
+			return nil, nil, fmt.Errorf("expected data key %s is not valid %s: %w", key, format, err)
+		}
+		unstructured.RemoveNestedField(expected, "stringData", key)
+		if stringData, _, _ := unstructured.NestedMap(expected, "stringData"); len(stringData) == 0 {
+			delete(expected, "stringData")
+		}
+		if err := unstructured.SetNestedField(expected, parsed, "data", key); err != nil {
+			return nil, nil, err
+		}
+
+		content, ok, err = dataContent(actual, key, secret)
+		if err != nil {
+			return nil, nil, fmt.Errorf("data key %s: %w", key, err)
+		}
+		if !ok {
+			// the missing key is reported by the comparison
+			continue
+		}
+		if parsed, err = dataParsers[format](content); err != nil {
+			return nil, nil, fmt.Errorf("data key %s is not valid %s: %w", key, format, err)
+		}
+		if err := unstructured.SetNestedField(actual, parsed, "data", key); err != nil {
+			return nil, nil, err
+		}
+	}
+	return expected, actual, nil
+}
+
+// dataContent returns the content of the data key of a ConfigMap or Secret, the data of Secrets is decoded and their
+// stringData is used if the key is not in their data.
+func dataContent(obj map[string]interface{}, key string, secret bool) (string, bool, error) {
+	content, ok, err := unstructured.NestedString(obj, "data", key)
+	if err != nil {
+		return "", false, err
+	}
+	if !secret {
+		return content, ok, nil
+	}
+	if !ok {
+		return unstructured.NestedString(obj, "stringData", key)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return "", false, err
+	}
+	return string(decoded), true, nil
+}
+
+func parseYAMLData(content string) (interface{}, error) {
+	raw, err := yaml.ToJSON([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONData(string(raw))
+}
+
+func parseJSONData(content string) (interface{}, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// parseINIData parses an ini file into a map of sections to their keys, the keys before the first section are at the
+// top level.  Lines starting with ; or # are comments.
+func parseINIData(content string) (interface{}, error) {
+	parsed := map[string]interface{}{}
+	section := parsed
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, ";") || strings.HasPrefix(text, "#"):
+		case strings.HasPrefix(text, "["):
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section", line)
+			}
+			name := strings.TrimSpace(text[1 : len(text)-1])
+			if existing, ok := parsed[name].(map[string]interface{}); ok {
+				section = existing
+			} else {
+				section = map[string]interface{}{}
+				parsed[name] = section
+			}
+		default:
+			key, value, ok := strings.Cut(text, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: %q is not a key=value pair", line, text)
+			}
+			section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return parsed, scanner.Err()
+}
+
+// parsePropertiesData parses a Java properties file into a map of keys to values.  Keys are separated from values by
+// = or :, lines starting with # or ! are comments and lines ending with a backslash are continued on the next line.
+func parsePropertiesData(content string) (interface{}, error) {
+	parsed := map[string]interface{}{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	logical := ""
+	for scanner.Scan() {
+		text := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical == "" && (text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "!")) {
+			continue
+		}
+		if strings.HasSuffix(text, `\`) && !strings.HasSuffix(text, `\\`) {
+			logical += strings.TrimSuffix(text, `\`)
+			continue
+		}
+		logical += text
+
+		separator := strings.IndexAny(logical, "=:")
+		key, value := logical, ""
+		if separator >= 0 {
+			key, value = logical[:separator], logical[separator+1:]
+		}
+		parsed[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		logical = ""
+	}
+	if logical != "" {
+		return nil, fmt.Errorf("the last line %q is continued", logical)
+	}
+	return parsed, scanner.Err()
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedParsedData(t *testing.T) {
+	configMap := testutils.NewResource("v1", "ConfigMap", "app", "")
+
+	expected, keys, err := expectedParsedData(configMap)
+	assert.NoError(t, err)
+	assert.Nil(t, keys)
+	assert.Equal(t, configMap, expected)
+
+	expected, keys, err = expectedParsedData(testutils.SetAnnotation(configMap, harness.ParseDataAnnotation, "config.yml, app.properties,settings=json"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"config.yml": "yaml", "app.properties": "properties", "settings": "json"}, keys)
+	assert.Equal(t, configMap, expected)
+
+	for value, message := range map[string]string{
+		"config":       `annotation kuttl.dev/parse-data: unknown format "" of key config, it must be one of ini, json, properties, yaml`,
+		"config=toml":  `annotation kuttl.dev/parse-data: unknown format "toml" of key config, it must be one of ini, json, properties, yaml`,
+		"a.json,,b.js": `annotation kuttl.dev/parse-data: "a.json,,b.js" has an empty key`,
+	} {
+		_, _, err = expectedParsedData(testutils.SetAnnotation(configMap, harness.ParseDataAnnotation, value))
+		assert.EqualError(t, err, message, value)
+	}
+
+	_, _, err = expectedParsedData(testutils.SetAnnotation(testutils.NewPod("app", ""), harness.ParseDataAnnotation, "config.yaml"))
+	assert.EqualError(t, err, "annotation kuttl.dev/parse-data can only be used on ConfigMaps and Secrets")
+}
+
+func TestParseData(t *testing.T) {
+	for _, test := range []struct {
+		format   string
+		content  string
+		expected interface{}
+		err      string
+	}{
+		{
+			format:   "yaml",
+			content:  "server:\n  port: 8080\n  hosts: [a, b]\n",
+			expected: map[string]interface{}{"server": map[string]interface{}{"port": float64(8080), "hosts": []interface{}{"a", "b"}}},
+		},
+		{
+			format:   "json",
+			content:  `{"debug": true, "level": 2.5}`,
+			expected: map[string]interface{}{"debug": true, "level": 2.5},
+		},
+		{format: "json", content: `{"debug":`, err: "unexpected end of JSON input"},
+		{
+			format:   "ini",
+			content:  "; comment\nname = app\n[db]\nhost=db.local\n# comment\nport = 5432\n",
+			expected: map[string]interface{}{"name": "app", "db": map[string]interface{}{"host": "db.local", "port": "5432"}},
+		},
+		{format: "ini", content: "[db\n", err: "line 1: unterminated section"},
+		{format: "ini", content: "[db]\nhost\n", err: `line 2: "host" is not a key=value pair`},
+		{
+			format:   "properties",
+			content:  "# comment\n! comment\napp.name=kuttl\napp.hosts = a,\\\n    b\nurl: http://localhost:8080\nflag\n",
+			expected: map[string]interface{}{"app.name": "kuttl", "app.hosts": "a,b", "url": "http://localhost:8080", "flag": ""},
+		},
+		{format: "properties", content: "a=b\\", err: `the last line "a=b" is continued`},
+	} {
+		parsed, err := dataParsers[test.format](test.content)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.content)
+			continue
+		}
+		assert.NoError(t, err, test.content)
+		assert.Equal(t, test.expected, parsed, test.content)
+	}
+}
+
+func TestCheckResourceParsedData(t *testing.T) {
+	configMap := testutils.NewResource("v1", "ConfigMap", "app", testNamespace)
+	configMap.Object["data"] = map[string]interface{}{
+		"config.yaml":    "server:\n  port: 8080\n  debug: false\nname: app\n",
+		"app.properties": "name=app\nreplicas = 3\n",
+	}
+	secret := testutils.NewResource("v1", "Secret", "app", testNamespace)
+	secret.Object["data"] = map[string]interface{}{"settings": base64.StdEncoding.EncodeToString([]byte(`{"token":"t","ttl":60}`))}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(configMap, secret).Build()
+	step := Step{
+		Logger:          testutils.NewTestLogger(t, ""),
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+	expectConfigMap := func(config, properties string) *unstructured.Unstructured {
+		expected := testutils.NewResource("v1", "ConfigMap", "app", "")
+		expected.Object["data"] = map[string]interface{}{"config.yaml": config, "app.properties": properties}
+		return testutils.SetAnnotation(expected, harness.ParseDataAnnotation, "config.yaml,app.properties")
+	}
+
+	// whitespace, the order of keys and additional keys do not matter
+	assert.Equal(t, []error{}, step.CheckResource(expectConfigMap("server: {port: 8080}", "replicas: 3"), testNamespace))
+	errs := step.CheckResource(expectConfigMap("server: {port: 8081}", "replicas: 3"), testNamespace)
+	require.NotEmpty(t, errs)
+	assert.Contains(t, errs[len(errs)-1].Error(), ".data.config.yaml.server.port: value mismatch, expected: 8081 != actual: 8080")
+
+	assert.NoError(t, step.CheckResourceAbsent(expectConfigMap("name: other", "replicas=3"), testNamespace))
+	assert.EqualError(t, step.CheckResourceAbsent(expectConfigMap("name: app", "replicas=3"), testNamespace),
+		"resource /v1, Kind=ConfigMap app matched error assertion")
+
+	expectSecret := testutils.NewResource("v1", "Secret", "app", "")
+	expectSecret.Object["stringData"] = map[string]interface{}{"settings": `{"ttl": 60}`}
+	expectSecret = testutils.SetAnnotation(expectSecret, harness.ParseDataAnnotation, "settings=json")
+	assert.Equal(t, []error{}, step.CheckResource(expectSecret, testNamespace))
+
+	errs = step.CheckResource(expectConfigMap("server: [", "replicas: 3"), testNamespace)
+	require.NotEmpty(t, errs)
+	assert.Contains(t, errs[len(errs)-1].Error(), "expected data key config.yaml is not valid yaml")
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// unmetPreconditions returns why the preconditions of the steps of the test case are not met, it returns an empty
+// string if they are met.
+func (t *Case) unmetPreconditions() (string, error) {
+	var preconditions []*harness.Preconditions
+	for _, step := range t.Steps {
+		if step.Step != nil && step.Step.Preconditions != nil {
+			preconditions = append(preconditions, step.Step.Preconditions)
+		}
+	}
+	if len(preconditions) == 0 {
+		return "", nil
+	}
+
+	cl, err := t.Client(false)
+	if err != nil {
+		return "", err
+	}
+
+	var reasons []string
+	for _, p := range preconditions {
+		unmet, err := checkPreconditions(cl, p)
+		if err != nil {
+			return "", fmt.Errorf("checking preconditions: %w", err)
+		}
+		reasons = append(reasons, unmet...)
+	}
+	return strings.Join(reasons, "; "), nil
+}
+
+// checkPreconditions returns the preconditions of p which are not met by the cluster.
+func checkPreconditions(cl client.Client, p *harness.Preconditions) ([]string, error) {
+	var unmet []string
+
+	for _, name := range p.CRDs {
+		crd := &apiextv1.CustomResourceDefinition{}
+		if err := cl.Get(context.TODO(), client.ObjectKey{Name: name}, crd); err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return nil, err
+			}
+			unmet = append(unmet, fmt.Sprintf("CRD %s is not installed", name))
+			continue
+		}
+		if !crdEstablished(crd) {
+			unmet = append(unmet, fmt.Sprintf("CRD %s is not established", name))
+		}
+	}
+
+	for _, name := range p.StorageClasses {
+		if err := cl.Get(context.TODO(), client.ObjectKey{Name: name}, &storagev1.StorageClass{}); err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return nil, err
+			}
+			unmet = append(unmet, fmt.Sprintf("StorageClass %s does not exist", name))
+		}
+	}
+
+	if p.MinNodes == 0 && p.AllocatableCPU == "" && p.AllocatableMemory == "" {
+		return unmet, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := cl.List(context.TODO(), nodes); err != nil {
+		return nil, err
+	}
+
+	ready := 0
+	cpu := resource.Quantity{}
+	memory := resource.Quantity{}
+	for _, node := range nodes.Items {
+		if !nodeSchedulable(&node) {
+			continue
+		}
+		ready++
+		cpu.Add(node.Status.Allocatable[corev1.ResourceCPU])
+		memory.Add(node.Status.Allocatable[corev1.ResourceMemory])
+	}
+
+	if ready < p.MinNodes {
+		unmet = append(unmet, fmt.Sprintf("%d ready nodes, at least %d required", ready, p.MinNodes))
+	}
+	for _, check := range []struct {
+		resource string
+		required string
+		actual   resource.Quantity
+	}{
+		{resource: "CPU", required: p.AllocatableCPU, actual: cpu},
+		{resource: "memory", required: p.AllocatableMemory, actual: memory},
+	} {
+		if check.required == "" {
+			continue
+		}
+		required, err := resource.ParseQuantity(check.required)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allocatable %s %q: %w", check.resource, check.required, err)
+		}
+		if check.actual.Cmp(required) < 0 {
+			unmet = append(unmet, fmt.Sprintf("%s allocatable %s, at least %s required", check.actual.String(), check.resource, required.String()))
+		}
+	}
+
+	return unmet, nil
+}
+
+// crdEstablished returns true if the CRD has an Established condition which is true.
+func crdEstablished(crd *apiextv1.CustomResourceDefinition) bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextv1.Established {
+			return condition.Status == apiextv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nodeSchedulable returns true if the node is ready and not cordoned.
+func nodeSchedulable(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
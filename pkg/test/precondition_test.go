@@ -0,0 +1,121 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	node := func(name string, ready, unschedulable bool, cpu, memory string) *corev1.Node {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		}
+	}
+	crd := func(name string, established bool) *apiextv1.CustomResourceDefinition {
+		status := apiextv1.ConditionFalse
+		if established {
+			status = apiextv1.ConditionTrue
+		}
+		return &apiextv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: apiextv1.CustomResourceDefinitionStatus{
+				Conditions: []apiextv1.CustomResourceDefinitionCondition{{Type: apiextv1.Established, Status: status}},
+			},
+		}
+	}
+
+	cluster := []runtime.Object{
+		node("node-1", true, false, "2", "4Gi"),
+		node("node-2", true, false, "1500m", "4Gi"),
+		node("node-3", false, false, "4", "8Gi"),
+		node("node-4", true, true, "4", "8Gi"),
+		crd("certificates.cert-manager.io", true),
+		crd("pending.example.com", false),
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}},
+	}
+
+	for _, test := range []struct {
+		name          string
+		preconditions harness.Preconditions
+		expected      []string
+		expectedErr   string
+	}{
+		{
+			name: "met",
+			preconditions: harness.Preconditions{
+				CRDs:              []string{"certificates.cert-manager.io"},
+				StorageClasses:    []string{"standard"},
+				MinNodes:          2,
+				AllocatableCPU:    "3500m",
+				AllocatableMemory: "8Gi",
+			},
+		},
+		{
+			name: "missing and unestablished CRDs",
+			preconditions: harness.Preconditions{
+				CRDs: []string{"missing.example.com", "pending.example.com"},
+			},
+			expected: []string{"CRD missing.example.com is not installed", "CRD pending.example.com is not established"},
+		},
+		{
+			name:          "missing StorageClass",
+			preconditions: harness.Preconditions{StorageClasses: []string{"fast"}},
+			expected:      []string{"StorageClass fast does not exist"},
+		},
+		{
+			name: "not enough nodes and resources",
+			preconditions: harness.Preconditions{
+				MinNodes:          3,
+				AllocatableCPU:    "4",
+				AllocatableMemory: "16Gi",
+			},
+			expected: []string{
+				"2 ready nodes, at least 3 required",
+				"3500m allocatable CPU, at least 4 required",
+				"8Gi allocatable memory, at least 16Gi required",
+			},
+		},
+		{
+			name:          "invalid quantity",
+			preconditions: harness.Preconditions{AllocatableCPU: "lots"},
+			expectedErr:   `invalid allocatable CPU "lots"`,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(testutils.Scheme()).WithRuntimeObjects(cluster...).Build()
+
+			unmet, err := checkPreconditions(cl, &test.preconditions)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, unmet)
+		})
+	}
+}
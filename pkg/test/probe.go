@@ -0,0 +1,195 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/probe"
+	"github.com/kudobuilder/kuttl/pkg/version"
+)
+
+// probePollInterval is the interval the helper pods running app probes are checked at.
+var probePollInterval = time.Second
+
+// probeStartMargin is the time the helper pods running app probes are given in addition to the timeout of the
+// probes to start, ex. to pull the probe image.
+var probeStartMargin = time.Minute
+
+// defaultProbeImage returns the kuttl image of the running version, the latest image for development builds.
+func defaultProbeImage() string {
+	v, err := version.FromGithubVersion(version.Get().GitVersion)
+	if err != nil {
+		return "kudobuilder/kuttl:latest"
+	}
+	return "kudobuilder/kuttl:v" + v.String()
+}
+
+// CheckProbes runs the app probes of the TestAssert of the step in helper pods and waits for them to complete.  The
+// probes are retried in the pods until they succeed or the timeout (in seconds) expires.
+func (s *Step) CheckProbes(namespace string, timeout int) []error {
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+	ctx := context.TODO()
+
+	errs := []error{}
+	// the pods and the services they probe
+	pods := []*corev1.Pod{}
+	services := []string{}
+	for _, appProbe := range s.Assert.Probes {
+		pod, err := s.probePod(ctx, cl, namespace, appProbe, timeout)
+		if err == nil {
+			err = cl.Create(ctx, pod)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("app probe of service %s: %w", appProbe.Service, err))
+			continue
+		}
+		pods = append(pods, pod)
+		services = append(services, appProbe.Service)
+	}
+
+	deadline := time.Now().Add(time.Duration(timeout)*time.Second + probeStartMargin)
+	for i, pod := range pods {
+		if err := waitForProbe(ctx, cl, pod, deadline); err != nil {
+			errs = append(errs, fmt.Errorf("app probe of service %s failed: %w", services[i], err))
+		}
+	}
+
+	if !s.SkipDelete {
+		for _, pod := range pods {
+			if err := cl.Delete(ctx, pod, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serrors.IsNotFound(err) {
+				s.Logger.Logf("deleting app probe pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			}
+		}
+	}
+	return errs
+}
+
+// probePod returns the helper pod running the app probe against the service in the namespace.
+func (s *Step) probePod(ctx context.Context, cl client.Client, namespace string, appProbe harness.AppProbe, timeout int) (*corev1.Pod, error) {
+	p := probe.Probe{
+		Protocol:     appProbe.Protocol,
+		Banner:       appProbe.Banner,
+		GRPCService:  appProbe.GRPCService,
+		GRPCMethod:   appProbe.GRPCMethod,
+		GRPCRequest:  appProbe.GRPCRequest,
+		GRPCResponse: appProbe.GRPCResponse,
+	}
+	if p.Protocol == "" {
+		p.Protocol = probe.TCP
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	serviceNamespace, name := namespace, appProbe.Service
+	if ns, n, ok := strings.Cut(appProbe.Service, "/"); ok {
+		serviceNamespace, name = ns, n
+	}
+	service := &corev1.Service{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: serviceNamespace, Name: name}, service); err != nil {
+		return nil, err
+	}
+	port, err := servicePort(service, appProbe.Port)
+	if err != nil {
+		return nil, err
+	}
+	p.Address = fmt.Sprintf("%s.%s.svc:%d", name, serviceNamespace, port)
+
+	command := []string{"kubectl-kuttl", "probe", p.Address, "--protocol", p.Protocol, "--timeout", fmt.Sprintf("%ds", timeout)}
+	for _, flag := range []struct{ name, value string }{
+		{"--banner", p.Banner},
+		{"--grpc-service", p.GRPCService},
+		{"--grpc-method", p.GRPCMethod},
+		{"--grpc-request", p.GRPCRequest},
+		{"--grpc-response", p.GRPCResponse},
+	} {
+		if flag.value != "" {
+			command = append(command, flag.name, flag.value)
+		}
+	}
+	image := s.ProbeImage
+	if image == "" {
+		image = defaultProbeImage()
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kuttl-probe-",
+			Namespace:    namespace,
+			Labels:       map[string]string{harness.HelperPodLabel: "true"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:                     "probe",
+				Image:                    image,
+				Command:                  command,
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			}},
+		},
+	}
+	scheduleHelperPod(&pod.Spec, s.HelperPods)
+	return pod, nil
+}
+
+// servicePort returns the port of the service to probe, the first port of the service if port is 0.
+func servicePort(service *corev1.Service, port int32) (int32, error) {
+	if len(service.Spec.Ports) == 0 {
+		return 0, fmt.Errorf("service %s/%s has no ports", service.Namespace, service.Name)
+	}
+	if port == 0 {
+		return service.Spec.Ports[0].Port, nil
+	}
+	for _, p := range service.Spec.Ports {
+		if p.Port == port {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("service %s/%s has no port %d", service.Namespace, service.Name, port)
+}
+
+// waitForProbe waits until the helper pod running an app probe has completed, it fails if the probe failed or the pod
+// has not completed by the deadline.
+func waitForProbe(ctx context.Context, cl client.Client, pod *corev1.Pod, deadline time.Time) error {
+	for {
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(pod), pod); err != nil {
+			return err
+		}
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			return nil
+		case corev1.PodFailed:
+			return errors.New(probeMessage(pod))
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pod %s/%s did not complete in time: %s", pod.Namespace, pod.Name, probeMessage(pod))
+		}
+		time.Sleep(probePollInterval)
+	}
+}
+
+// probeMessage describes the state of the helper pod running an app probe, the output of the probe once it has
+// terminated.
+func probeMessage(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.Message != "" {
+			return strings.TrimSpace(terminated.Message)
+		}
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" {
+			return fmt.Sprintf("pod %s, container %s", pod.Status.Phase, waiting.Reason)
+		}
+	}
+	return fmt.Sprintf("pod %s", pod.Status.Phase)
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// probeClient completes the helper pods running app probes when they are created, with the status returned by
+// complete for the probed address.
+type probeClient struct {
+	client.Client
+	complete func(address string) corev1.PodStatus
+	created  []*corev1.Pod
+}
+
+func (c *probeClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if pod, ok := obj.(*corev1.Pod); ok {
+		pod.Status = c.complete(pod.Spec.Containers[0].Command[2])
+		c.created = append(c.created, pod.DeepCopy())
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// probeService returns a service in the test namespace with the ports.
+func probeService(name string, ports ...int32) *corev1.Service {
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace}}
+	for _, port := range ports {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{Port: port})
+	}
+	return service
+}
+
+// terminated returns the status of a pod which terminated in the phase with the message.
+func terminated(phase corev1.PodPhase, message string) corev1.PodStatus {
+	return corev1.PodStatus{
+		Phase: phase,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "probe",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}},
+		}},
+	}
+}
+
+func TestCheckProbes(t *testing.T) {
+	pollInterval, startMargin := probePollInterval, probeStartMargin
+	probePollInterval, probeStartMargin = 10*time.Millisecond, 0
+	defer func() {
+		probePollInterval, probeStartMargin = pollInterval, startMargin
+	}()
+
+	services := []runtime.Object{
+		probeService("postgres", 5432),
+		probeService("redis", 6379, 16379),
+		probeService("headless"),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "ssh", Namespace: "other"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 22}}},
+		},
+	}
+	complete := func(address string) corev1.PodStatus {
+		switch {
+		case strings.HasPrefix(address, "redis."):
+			return terminated(corev1.PodFailed, "Error: redis probe of "+address+" failed: unexpected reply to PING: -LOADING\n")
+		case strings.HasPrefix(address, "ssh."):
+			return corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "probe",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			}
+		}
+		return terminated(corev1.PodSucceeded, "")
+	}
+
+	for _, test := range []struct {
+		testName string
+		probes   []harness.AppProbe
+		commands [][]string
+		errs     []string
+	}{
+		{
+			testName: "succeeded",
+			probes:   []harness.AppProbe{{Service: "postgres", Protocol: "postgres"}},
+			commands: [][]string{{"kubectl-kuttl", "probe", "postgres.world.svc:5432", "--protocol", "postgres", "--timeout", "1s"}},
+		},
+		{
+			testName: "grpc method",
+			probes:   []harness.AppProbe{{Service: "postgres", Protocol: "grpc", GRPCMethod: "test.v1.Items/Get", GRPCRequest: `{"id": "1"}`}},
+			commands: [][]string{{"kubectl-kuttl", "probe", "postgres.world.svc:5432", "--protocol", "grpc", "--timeout", "1s", "--grpc-method", "test.v1.Items/Get", "--grpc-request", `{"id": "1"}`}},
+		},
+		{
+			testName: "failed",
+			probes:   []harness.AppProbe{{Service: "redis", Port: 16379, Protocol: "redis"}},
+			commands: [][]string{{"kubectl-kuttl", "probe", "redis.world.svc:16379", "--protocol", "redis", "--timeout", "1s"}},
+			errs:     []string{"app probe of service redis failed: Error: redis probe of redis.world.svc:16379 failed: unexpected reply to PING: -LOADING"},
+		},
+		{
+			testName: "not completed in time",
+			probes:   []harness.AppProbe{{Service: "other/ssh", Banner: "^SSH-"}},
+			commands: [][]string{{"kubectl-kuttl", "probe", "ssh.other.svc:22", "--protocol", "tcp", "--timeout", "1s", "--banner", "^SSH-"}},
+			errs:     []string{"app probe of service other/ssh failed: pod world/kuttl-probe-"},
+		},
+		{
+			testName: "invalid probes",
+			probes: []harness.AppProbe{
+				{Service: "postgres", Protocol: "mongodb"},
+				{Service: "missing"},
+				{Service: "headless"},
+				{Service: "redis", Port: 80},
+				{Service: "postgres"},
+			},
+			commands: [][]string{{"kubectl-kuttl", "probe", "postgres.world.svc:5432", "--protocol", "tcp", "--timeout", "1s"}},
+			errs: []string{
+				`app probe of service postgres: unknown protocol "mongodb", must be one of tcp, postgres, mysql, redis, grpc`,
+				`app probe of service missing: services "missing" not found`,
+				"app probe of service headless: service world/headless has no ports",
+				"app probe of service redis: service world/redis has no port 80",
+			},
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			cl := &probeClient{
+				Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(services...).Build(),
+				complete: complete,
+			}
+			step := Step{
+				Assert:     &harness.TestAssert{Probes: test.probes},
+				ProbeImage: "kuttl:test",
+				Logger:     testutils.NewTestLogger(t, ""),
+				Client:     func(bool) (client.Client, error) { return cl, nil },
+			}
+
+			errs := step.CheckProbes(testNamespace, 1)
+			require.Len(t, errs, len(test.errs))
+			for i, err := range errs {
+				assert.Contains(t, err.Error(), test.errs[i])
+			}
+
+			require.Len(t, cl.created, len(test.commands))
+			for i, pod := range cl.created {
+				assert.Equal(t, map[string]string{harness.HelperPodLabel: "true"}, pod.Labels)
+				assert.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+				assert.Equal(t, "kuttl:test", pod.Spec.Containers[0].Image)
+				assert.Equal(t, test.commands[i], pod.Spec.Containers[0].Command)
+			}
+
+			// the helper pods are deleted
+			pods := &corev1.PodList{}
+			require.NoError(t, cl.List(context.TODO(), pods))
+			assert.Empty(t, pods.Items)
+		})
+	}
+}
+
+func TestProbeMessage(t *testing.T) {
+	assert.Equal(t, "pod Pending", probeMessage(&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}))
+	assert.Equal(t, "probe failed", probeMessage(&corev1.Pod{Status: terminated(corev1.PodFailed, "probe failed\n")}))
+}
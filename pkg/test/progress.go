@@ -0,0 +1,71 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// assertProgressInterval is how often a step waiting on its asserts logs the ones which are still not satisfied.
+var assertProgressInterval = 30 * time.Second
+
+// assertID identifies an expected object in the progress logs, with the location it was loaded from.
+func assertID(expected runtime.Object) string {
+	return testutils.ResourceID(expected) + testutils.DescribeSource(expected)
+}
+
+// diffError is the diff of an expected object and an actual object it did not match.
+type diffError struct {
+	diff string
+}
+
+func (e *diffError) Error() string {
+	return e.diff
+}
+
+// assertProgress throttles the progress logs of a step waiting on its asserts, so that long waits are not silent until
+// they time out.
+type assertProgress struct {
+	start time.Time
+	last  time.Time
+}
+
+func newAssertProgress(start time.Time) *assertProgress {
+	return &assertProgress{start: start, last: start}
+}
+
+// log logs the expected objects which are still not satisfied and the shortest diff of the errors of the last attempt,
+// unless it was logged less than assertProgressInterval ago.
+func (p *assertProgress) log(logger testutils.Logger, unsatisfied []string, errs []error) {
+	now := time.Now()
+	if now.Sub(p.last) < assertProgressInterval {
+		return
+	}
+	p.last = now
+
+	waiting := now.Sub(p.start).Round(time.Second)
+	if len(unsatisfied) > 0 {
+		logger.Logf("still waiting after %s on %d of the expected objects: %s", waiting, len(unsatisfied), strings.Join(unsatisfied, ", "))
+	} else {
+		logger.Logf("still waiting after %s, %d error(s): %v", waiting, len(errs), errs[0])
+	}
+	if diff := shortestDiff(errs); diff != "" {
+		logger.Logf("closest match so far:\n%s", diff)
+	}
+}
+
+// shortestDiff returns the shortest diff of the errors, or "" if none of them is a diff.
+func shortestDiff(errs []error) string {
+	shortest := ""
+	for _, err := range errs {
+		diffErr := &diffError{}
+		if errors.As(err, &diffErr) && (shortest == "" || len(diffErr.diff) < len(shortest)) {
+			shortest = diffErr.diff
+		}
+	}
+	return shortest
+}
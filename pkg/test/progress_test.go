@@ -0,0 +1,53 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger records the messages logged with Logf.
+type recordingLogger struct {
+	discardLogger
+	messages []string
+}
+
+func (l *recordingLogger) Logf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestAssertProgress(t *testing.T) {
+	interval := assertProgressInterval
+	defer func() { assertProgressInterval = interval }()
+	assertProgressInterval = time.Minute
+
+	errs := []error{
+		&diffError{diff: "--- Pod:ns/a\n+++ Pod:ns/a\n-  phase: Running\n+  phase: Pending\n"},
+		withObjectTimeout([]error{&diffError{diff: "--- Pod:ns/b\n+++ Pod:ns/b\n"}}, 10)[0],
+		errors.New("resource Pod:ns/b: .status.phase: value mismatch"),
+	}
+
+	logger := &recordingLogger{}
+	progress := newAssertProgress(time.Now())
+	progress.log(logger, []string{"Pod:ns/a", "Pod:ns/b (00-assert.yaml:3)"}, errs)
+	assert.Empty(t, logger.messages, "nothing is logged before the interval passed")
+
+	progress.start = time.Now().Add(-90 * time.Second)
+	progress.last = time.Now().Add(-time.Minute)
+	progress.log(logger, []string{"Pod:ns/a", "Pod:ns/b (00-assert.yaml:3)"}, errs)
+	assert.Equal(t, []string{
+		"still waiting after 1m30s on 2 of the expected objects: Pod:ns/a, Pod:ns/b (00-assert.yaml:3)",
+		"closest match so far:\n--- Pod:ns/b\n+++ Pod:ns/b\n",
+	}, logger.messages)
+
+	logger.messages = nil
+	progress.log(logger, nil, errs[2:])
+	assert.Empty(t, logger.messages, "the logs are throttled")
+
+	progress.last = time.Now().Add(-time.Minute)
+	progress.log(logger, nil, errs[2:])
+	assert.Equal(t, []string{"still waiting after 1m30s, 1 error(s): resource Pod:ns/b: .status.phase: value mismatch"}, logger.messages)
+}
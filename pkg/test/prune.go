@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// appliedKey identifies an applied object.
+type appliedKey struct {
+	groupKind schema.GroupKind
+	namespace string
+	name      string
+}
+
+func keyOf(obj client.Object) appliedKey {
+	return appliedKey{
+		groupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+		namespace: obj.GetNamespace(),
+		name:      obj.GetName(),
+	}
+}
+
+// Prune deletes the objects applied by earlier steps which are not applied by this step and match the prune kinds and
+// selector of the step, then waits for them to be deleted.  It must be called after the objects of the step are applied.
+func (s *Step) Prune() error {
+	if s.Step == nil || !s.Step.Prune {
+		return nil
+	}
+
+	selector := labels.Everything()
+	if s.Step.PruneSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(s.Step.PruneSelector); err != nil {
+			return fmt.Errorf("invalid prune selector: %w", err)
+		}
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return err
+	}
+
+	applied := map[appliedKey]bool{}
+	for _, obj := range s.Apply {
+		applied[keyOf(obj)] = true
+	}
+
+	pruned := []*unstructured.Unstructured{}
+	seen := map[appliedKey]bool{}
+	for _, obj := range s.PreviouslyApplied {
+		key := keyOf(obj)
+		if applied[key] || seen[key] || key.name == "" || !pruneKind(s.Step.PruneKinds, key.groupKind) {
+			continue
+		}
+		seen[key] = true
+
+		actual := &unstructured.Unstructured{}
+		actual.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+		if err := cl.Get(context.TODO(), testutils.ObjectKey(obj), actual); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !selector.Matches(labels.Set(actual.GetLabels())) {
+			continue
+		}
+		if err := s.checkSafe("prune", actual); err != nil {
+			return err
+		}
+
+		if err := cl.Delete(context.TODO(), actual); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		s.Logger.Log(testutils.ResourceID(actual), "pruned")
+		pruned = append(pruned, actual)
+	}
+
+	return wait.PollImmediate(100*time.Millisecond, time.Duration(s.GetTimeout())*time.Second, func() (bool, error) {
+		for _, obj := range pruned {
+			actual := &unstructured.Unstructured{}
+			actual.SetGroupVersionKind(obj.GroupVersionKind())
+			if err := cl.Get(context.TODO(), testutils.ObjectKey(obj), actual); err == nil || !k8serrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+}
+
+// pruneKind returns true if objects of groupKind may be pruned, kinds are in the form "<kind>" or "<kind>.<group>".
+// If kinds is empty, objects of all kinds may be pruned.
+func pruneKind(kinds []string, groupKind schema.GroupKind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, kind := range kinds {
+		if strings.EqualFold(kind, groupKind.Kind) || strings.EqualFold(kind, groupKind.String()) {
+			return true
+		}
+	}
+	return false
+}
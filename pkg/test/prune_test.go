@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestPrune(t *testing.T) {
+	configMap := func(name string, labels map[string]string) *unstructured.Unstructured {
+		cm := testutils.NewResource("v1", "ConfigMap", name, testNamespace)
+		cm.SetLabels(labels)
+		return cm
+	}
+	objects := func() []client.Object {
+		return []client.Object{
+			configMap("kept", nil),
+			configMap("removed", map[string]string{"app": "test"}),
+			configMap("other", nil),
+			testutils.NewResource("apps/v1", "Deployment", "deploy", testNamespace),
+		}
+	}
+
+	for _, test := range []struct {
+		name     string
+		step     *harness.TestStep
+		expected []string
+	}{
+		{
+			name:     "no prune",
+			step:     &harness.TestStep{},
+			expected: []string{"kept", "removed", "other", "deploy"},
+		},
+		{
+			name:     "prune all kinds",
+			step:     &harness.TestStep{Prune: true},
+			expected: []string{"kept"},
+		},
+		{
+			name:     "prune kinds",
+			step:     &harness.TestStep{Prune: true, PruneKinds: []string{"Deployment.apps"}},
+			expected: []string{"kept", "removed", "other"},
+		},
+		{
+			name: "prune selector",
+			step: &harness.TestStep{
+				Prune:         true,
+				PruneKinds:    []string{"configmap"},
+				PruneSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+			},
+			expected: []string{"kept", "other", "deploy"},
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			previous := objects()
+			runtimeObjects := []runtime.Object{}
+			for _, obj := range previous {
+				runtimeObjects = append(runtimeObjects, obj)
+			}
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(runtimeObjects...).Build()
+
+			step := Step{
+				Logger:            testutils.NewTestLogger(t, ""),
+				Step:              test.step,
+				Apply:             []client.Object{configMap("kept", nil)},
+				PreviouslyApplied: previous,
+				Client:            func(bool) (client.Client, error) { return cl, nil },
+			}
+			assert.NoError(t, step.Prune())
+
+			remaining := []string{}
+			for _, obj := range previous {
+				actual := &unstructured.Unstructured{}
+				actual.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+				err := cl.Get(context.TODO(), testutils.ObjectKey(obj), actual)
+				if k8serrors.IsNotFound(err) {
+					continue
+				}
+				assert.NoError(t, err)
+				remaining = append(remaining, obj.GetName())
+			}
+			assert.Equal(t, test.expected, remaining)
+		})
+	}
+}
+
+func TestPruneKind(t *testing.T) {
+	deployment := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	assert.True(t, pruneKind(nil, deployment))
+	assert.True(t, pruneKind([]string{"Deployment"}, deployment))
+	assert.True(t, pruneKind([]string{"deployment.apps"}, deployment))
+	assert.False(t, pruneKind([]string{"Deployment.extensions"}, deployment))
+	assert.True(t, pruneKind([]string{"ConfigMap"}, schema.GroupKind{Kind: "ConfigMap"}))
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaceQuotaName is the name of the ResourceQuota and LimitRange of test namespaces.
+const namespaceQuotaName = "kuttl-namespace-quota"
+
+// skipsNamespaceQuota returns true if a step of the test case opts out of the namespace quota.
+func (t *Case) skipsNamespaceQuota() bool {
+	for _, step := range t.Steps {
+		if step.Step != nil && step.Step.SkipNamespaceQuota {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceQuotaObjects returns the ResourceQuota and LimitRange of the namespace, only those which have limits.
+func (t *Case) namespaceQuotaObjects(namespace string) []client.Object {
+	if t.NamespaceQuota == nil || t.skipsNamespaceQuota() {
+		return nil
+	}
+
+	objs := []client.Object{}
+	if len(t.NamespaceQuota.Hard) > 0 {
+		objs = append(objs, &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: namespaceQuotaName, Namespace: namespace},
+			Spec:       corev1.ResourceQuotaSpec{Hard: t.NamespaceQuota.Hard.DeepCopy()},
+		})
+	}
+	if len(t.NamespaceQuota.Limits) > 0 {
+		limits := make([]corev1.LimitRangeItem, len(t.NamespaceQuota.Limits))
+		for i := range t.NamespaceQuota.Limits {
+			t.NamespaceQuota.Limits[i].DeepCopyInto(&limits[i])
+		}
+		objs = append(objs, &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: namespaceQuotaName, Namespace: namespace},
+			Spec:       corev1.LimitRangeSpec{Limits: limits},
+		})
+	}
+	return objs
+}
+
+// createNamespaceQuota creates the ResourceQuota and LimitRange of the test suite in the namespace.
+func (t *Case) createNamespaceQuota(ctx context.Context, cl client.Client, namespace string) error {
+	for _, obj := range t.namespaceQuotaObjects(namespace) {
+		if err := cl.Create(ctx, obj); err != nil {
+			return fmt.Errorf("creating the namespace quota of %s: %w", namespace, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestNamespaceQuota(t *testing.T) {
+	quota := &harness.NamespaceQuota{
+		Hard: corev1.ResourceList{
+			corev1.ResourceLimitsCPU: resource.MustParse("2"),
+			corev1.ResourcePods:      resource.MustParse("20"),
+		},
+		Limits: []corev1.LimitRangeItem{{
+			Type:    corev1.LimitTypeContainer,
+			Default: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+		}},
+	}
+	key := client.ObjectKey{Namespace: "kuttl-test-quota", Name: namespaceQuotaName}
+
+	for _, test := range []struct {
+		name    string
+		quota   *harness.NamespaceQuota
+		steps   []*Step
+		ns      *namespace
+		limited bool
+	}{
+		{
+			name:    "created",
+			quota:   quota,
+			steps:   []*Step{{Step: &harness.TestStep{}}, {}},
+			ns:      &namespace{Name: key.Namespace, AutoCreated: true},
+			limited: true,
+		},
+		{
+			name:  "opted out",
+			quota: quota,
+			steps: []*Step{{Step: &harness.TestStep{}}, {Step: &harness.TestStep{SkipNamespaceQuota: true}}},
+			ns:    &namespace{Name: key.Namespace, AutoCreated: true},
+		},
+		{
+			name: "no quota",
+			ns:   &namespace{Name: key.Namespace, AutoCreated: true},
+		},
+		{
+			name:  "user-supplied namespace",
+			quota: quota,
+			ns:    &namespace{Name: key.Namespace},
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			c := &Case{
+				Steps:          test.steps,
+				SkipDelete:     true,
+				NamespaceQuota: test.quota,
+				Logger:         testutils.NewTestLogger(t, ""),
+			}
+			require.NoError(t, c.CreateNamespace(t, cl, test.ns))
+
+			resourceQuota := &corev1.ResourceQuota{}
+			limitRange := &corev1.LimitRange{}
+			if !test.limited {
+				assert.True(t, k8serrors.IsNotFound(cl.Get(context.TODO(), key, resourceQuota)))
+				assert.True(t, k8serrors.IsNotFound(cl.Get(context.TODO(), key, limitRange)))
+				return
+			}
+
+			require.NoError(t, cl.Get(context.TODO(), key, resourceQuota))
+			assert.Equal(t, "20", resourceQuota.Spec.Hard.Pods().String())
+			require.NoError(t, cl.Get(context.TODO(), key, limitRange))
+			assert.Equal(t, quota.Limits, limitRange.Spec.Limits)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// isAccessReview returns true if obj is a SubjectAccessReview, LocalSubjectAccessReview or SelfSubjectAccessReview.
+// Access reviews in assert and errors files are created at assert time to ask the API server whether a subject is
+// allowed to perform an action, instead of being looked up.
+func isAccessReview(obj runtime.Object) bool {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Group != authorizationv1.GroupName {
+		return false
+	}
+	switch gvk.Kind {
+	case "SubjectAccessReview", "LocalSubjectAccessReview", "SelfSubjectAccessReview":
+		return true
+	}
+	return false
+}
+
+// checkAccessReview creates the access review and compares its status with the expected status.
+func checkAccessReview(cl client.Client, expected runtime.Object, namespace string) error {
+	review, err := reviewAccess(cl, expected, namespace)
+	if err != nil {
+		return err
+	}
+	return compareAccessReview(expected, review)
+}
+
+// reviewAccess creates the expected access review and returns the created review including the status.
+func reviewAccess(cl client.Client, expected runtime.Object, namespace string) (*unstructured.Unstructured, error) {
+	expectedObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(expected)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &unstructured.Unstructured{Object: runtime.DeepCopyJSON(expectedObj)}
+	delete(review.Object, "status")
+
+	if review.GetKind() == "LocalSubjectAccessReview" {
+		if review.GetNamespace() == "" {
+			review.SetNamespace(namespace)
+		}
+		// the namespace of the resource attributes must match the namespace of the review
+		if _, found, _ := unstructured.NestedMap(review.Object, "spec", "resourceAttributes"); found {
+			if ns, _, _ := unstructured.NestedString(review.Object, "spec", "resourceAttributes", "namespace"); ns == "" {
+				if err := unstructured.SetNestedField(review.Object, review.GetNamespace(), "spec", "resourceAttributes", "namespace"); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if err := cl.Create(context.TODO(), review); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", review.GetKind(), err)
+	}
+	return review, nil
+}
+
+// compareAccessReview compares the status of the created access review with the expected status.  If the expected
+// access review has no status, the action is expected to be allowed.
+func compareAccessReview(expected runtime.Object, review *unstructured.Unstructured) error {
+	expectedObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(expected)
+	if err != nil {
+		return err
+	}
+
+	expectedStatus, found, err := unstructured.NestedMap(expectedObj, "status")
+	if err != nil {
+		return err
+	}
+	if !found {
+		expectedStatus = map[string]interface{}{"allowed": true}
+	}
+	actualStatus, _, err := unstructured.NestedMap(review.Object, "status")
+	if err != nil {
+		return err
+	}
+
+	if err := testutils.IsSubset(expectedStatus, actualStatus); err != nil {
+		return fmt.Errorf("%s of %s: status%s (reason: %q)", review.GetKind(), describeAccessReview(review), err, actualStatus["reason"])
+	}
+	return nil
+}
+
+// describeAccessReview returns a short description of the subject and action of an access review for error messages.
+func describeAccessReview(review *unstructured.Unstructured) string {
+	subject, _, _ := unstructured.NestedString(review.Object, "spec", "user")
+	if subject == "" {
+		subject = "the current user"
+	}
+
+	if attrs, found, _ := unstructured.NestedStringMap(review.Object, "spec", "resourceAttributes"); found {
+		return fmt.Sprintf("%s to %s %s/%s %q in namespace %q", subject, attrs["verb"], attrs["group"], attrs["resource"], attrs["name"], attrs["namespace"])
+	}
+	if attrs, found, _ := unstructured.NestedStringMap(review.Object, "spec", "nonResourceAttributes"); found {
+		return fmt.Sprintf("%s to %s %s", subject, attrs["verb"], attrs["path"])
+	}
+	return subject
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// authorizer is a client which answers access reviews like an API server: only the "operator" user may get pods.
+type authorizer struct {
+	client.Client
+	reviews []*unstructured.Unstructured
+}
+
+func (a *authorizer) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	review := obj.(*unstructured.Unstructured)
+	a.reviews = append(a.reviews, review.DeepCopy())
+
+	user, _, _ := unstructured.NestedString(review.Object, "spec", "user")
+	verb, _, _ := unstructured.NestedString(review.Object, "spec", "resourceAttributes", "verb")
+	resource, _, _ := unstructured.NestedString(review.Object, "spec", "resourceAttributes", "resource")
+
+	allowed := user == "operator" && verb == "get" && resource == "pods"
+	status := map[string]interface{}{"allowed": allowed}
+	if !allowed {
+		status["reason"] = "no RBAC policy matched"
+	}
+	return unstructured.SetNestedMap(review.Object, status, "status")
+}
+
+func accessReview(kind, user, verb string, status map[string]interface{}) *unstructured.Unstructured {
+	review := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "authorization.k8s.io/v1",
+		"kind":       kind,
+		"spec": map[string]interface{}{
+			"user": user,
+			"resourceAttributes": map[string]interface{}{
+				"verb":     verb,
+				"resource": "pods",
+			},
+		},
+	}}
+	if status != nil {
+		review.Object["status"] = status
+	}
+	return review
+}
+
+func TestIsAccessReview(t *testing.T) {
+	assert.True(t, isAccessReview(accessReview("SubjectAccessReview", "operator", "get", nil)))
+	assert.True(t, isAccessReview(accessReview("LocalSubjectAccessReview", "operator", "get", nil)))
+	assert.False(t, isAccessReview(testutils.NewPod("hello", "")))
+	assert.False(t, isAccessReview(testutils.NewResource("example.com/v1", "SubjectAccessReview", "", "")))
+}
+
+func TestCheckAccessReview(t *testing.T) {
+	for _, test := range []struct {
+		testName    string
+		expected    *unstructured.Unstructured
+		shouldError bool
+	}{
+		{
+			testName: "allowed by default",
+			expected: accessReview("SubjectAccessReview", "operator", "get", nil),
+		},
+		{
+			testName:    "denied by default",
+			expected:    accessReview("SubjectAccessReview", "operator", "delete", nil),
+			shouldError: true,
+		},
+		{
+			testName: "expected to be denied",
+			expected: accessReview("SubjectAccessReview", "intruder", "get", map[string]interface{}{"allowed": false}),
+		},
+		{
+			testName:    "expected to be denied but allowed",
+			expected:    accessReview("SubjectAccessReview", "operator", "get", map[string]interface{}{"allowed": false}),
+			shouldError: true,
+		},
+		{
+			testName: "local access review",
+			expected: accessReview("LocalSubjectAccessReview", "operator", "get", nil),
+		},
+	} {
+		test := test
+
+		t.Run(test.testName, func(t *testing.T) {
+			cl := &authorizer{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+			step := Step{
+				Logger:          testutils.NewTestLogger(t, ""),
+				Client:          func(bool) (client.Client, error) { return cl, nil },
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			errors := step.CheckResource(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NotEqual(t, []error{}, errors)
+			} else {
+				assert.Equal(t, []error{}, errors)
+			}
+
+			absentErr := step.CheckResourceAbsent(test.expected, testNamespace)
+			if test.shouldError {
+				assert.NoError(t, absentErr)
+			} else {
+				assert.Error(t, absentErr)
+			}
+
+			// the status is never sent to the API server
+			for _, review := range cl.reviews {
+				_, found, _ := unstructured.NestedMap(review.Object, "status")
+				assert.False(t, found)
+			}
+		})
+	}
+}
+
+func TestLocalAccessReviewNamespace(t *testing.T) {
+	cl := &authorizer{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	assert.NoError(t, checkAccessReview(cl, accessReview("LocalSubjectAccessReview", "operator", "get", nil), testNamespace))
+
+	assert.Equal(t, testNamespace, cl.reviews[0].GetNamespace())
+	ns, _, _ := unstructured.NestedString(cl.reviews[0].Object, "spec", "resourceAttributes", "namespace")
+	assert.Equal(t, testNamespace, ns)
+}
@@ -0,0 +1,488 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// lastAppliedAnnotation is set by `kubectl apply` to the applied object, it is the best record of what was applied.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+var (
+	// unrecordedKinds are the kinds whose objects are created by the cluster itself, they are not recorded.
+	unrecordedKinds = map[schema.GroupKind]bool{
+		{Kind: "Event"}:                                    true,
+		{Group: "events.k8s.io", Kind: "Event"}:            true,
+		{Kind: "Endpoints"}:                                true,
+		{Group: "discovery.k8s.io", Kind: "EndpointSlice"}: true,
+		{Group: "coordination.k8s.io", Kind: "Lease"}:      true,
+	}
+
+	// assignedFields are the fields of objects set by the cluster, they are not applied.
+	assignedFields = map[schema.GroupKind][][]string{
+		{Kind: "Service"}:               {{"spec", "clusterIP"}, {"spec", "clusterIPs"}},
+		{Kind: "Pod"}:                   {{"spec", "nodeName"}},
+		{Kind: "PersistentVolumeClaim"}: {{"spec", "volumeName"}},
+	}
+
+	// volatileFields are the fields whose values differ between test runs, they are not asserted.
+	volatileFields = map[string]bool{
+		"clusterIP":          true,
+		"clusterIPs":         true,
+		"containerID":        true,
+		"finishedAt":         true,
+		"hostIP":             true,
+		"hostIPs":            true,
+		"imageID":            true,
+		"lastHeartbeatTime":  true,
+		"lastProbeTime":      true,
+		"lastTransitionTime": true,
+		"lastUpdateTime":     true,
+		"message":            true,
+		"nodeName":           true,
+		"observedGeneration": true,
+		"podIP":              true,
+		"podIPs":             true,
+		"startTime":          true,
+		"startedAt":          true,
+		"volumeName":         true,
+	}
+
+	// generatedLabels are the labels controllers set to values derived from object hashes or UIDs.
+	generatedLabels = []string{
+		"pod-template-hash",
+		"controller-revision-hash",
+		"controller-uid",
+		"batch.kubernetes.io/controller-uid",
+		"statefulset.kubernetes.io/pod-name",
+		"apps.kubernetes.io/pod-index",
+	}
+)
+
+// Recorder records the objects created, modified and deleted in a namespace while it is used manually, ex. with
+// kubectl, and writes them as the files of a candidate test step.  The namespace is listed every interval, so
+// objects which only exist between two snapshots are not recorded.
+type Recorder struct {
+	Namespace string
+	// Interval between two snapshots of the namespace.
+	Interval        time.Duration
+	Client          client.Client
+	DiscoveryClient discovery.DiscoveryInterface
+	// Out receives the progress of the recording.
+	Out io.Writer
+
+	kinds   []schema.GroupVersionKind
+	initial map[appliedKey]*unstructured.Unstructured
+	current map[appliedKey]*unstructured.Unstructured
+	// created are the objects which did not exist when the recording started, in the order they were observed.
+	created []appliedKey
+}
+
+// Record records the namespace until the context is done.
+func (r *Recorder) Record(ctx context.Context) error {
+	if err := r.start(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// the final state is observed once more after the recording is stopped
+			return r.observe(context.TODO())
+		case <-ticker.C:
+			if err := r.observe(ctx); err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
+
+// start discovers the recorded kinds and takes the initial snapshot of the namespace.
+func (r *Recorder) start() error {
+	if r.kinds == nil {
+		resources, err := r.DiscoveryClient.ServerPreferredNamespacedResources()
+		// the kinds of the groups which could be discovered are recorded
+		if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+			return fmt.Errorf("discovering the kinds of the namespace: %w", err)
+		}
+		r.kinds = recordedKinds(resources)
+	}
+
+	initial, err := r.snapshot(context.TODO())
+	if err != nil {
+		return err
+	}
+	r.initial = initial
+	r.current = initial
+	fmt.Fprintf(r.Out, "recording namespace %s, %d objects of %d kinds exist\n", r.Namespace, len(initial), len(r.kinds))
+	return nil
+}
+
+// observe takes a snapshot of the namespace and records the objects created since the previous one.
+func (r *Recorder) observe(ctx context.Context) error {
+	snapshot, err := r.snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen := map[appliedKey]bool{}
+	for _, key := range r.created {
+		seen[key] = true
+	}
+	created := []appliedKey{}
+	for key := range snapshot {
+		if _, ok := r.initial[key]; !ok && !seen[key] {
+			created = append(created, key)
+		}
+	}
+	// objects created between two snapshots are ordered by creation time, then owners before the objects they own
+	depths := ownerDepths(snapshot)
+	sort.Slice(created, func(i, j int) bool {
+		ti, tj := snapshot[created[i]].GetCreationTimestamp(), snapshot[created[j]].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		if di, dj := depths[created[i]], depths[created[j]]; di != dj {
+			return di < dj
+		}
+		return fmt.Sprint(created[i]) < fmt.Sprint(created[j])
+	})
+	for _, key := range created {
+		fmt.Fprintf(r.Out, "%s created\n", testutils.ResourceID(snapshot[key]))
+	}
+
+	r.created = append(r.created, created...)
+	r.current = snapshot
+	return nil
+}
+
+// ownerDepths returns the number of owners above each object of the snapshot, following the first owner of objects.
+func ownerDepths(snapshot map[appliedKey]*unstructured.Unstructured) map[appliedKey]int {
+	byUID := map[types.UID]*unstructured.Unstructured{}
+	for _, obj := range snapshot {
+		byUID[obj.GetUID()] = obj
+	}
+
+	depths := map[appliedKey]int{}
+	for key, obj := range snapshot {
+		// the depth is bounded by the number of objects in case of cycles
+		for depth := 0; depth < len(snapshot); depth++ {
+			refs := obj.GetOwnerReferences()
+			if len(refs) == 0 || byUID[refs[0].UID] == nil {
+				break
+			}
+			obj = byUID[refs[0].UID]
+			depths[key]++
+		}
+	}
+	return depths
+}
+
+// snapshot lists the objects of the recorded kinds in the namespace.
+func (r *Recorder) snapshot(ctx context.Context) (map[appliedKey]*unstructured.Unstructured, error) {
+	snapshot := map[appliedKey]*unstructured.Unstructured{}
+	for _, gvk := range r.kinds {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		items, err := list(r.Client, gvk, r.Namespace, nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing %s in namespace %s: %w", gvk.Kind, r.Namespace, err)
+		}
+		for i := range items {
+			snapshot[keyOf(&items[i])] = &items[i]
+		}
+	}
+	return snapshot, nil
+}
+
+// recordedKinds returns the kinds of the resources which can be listed, except the unrecorded kinds.
+func recordedKinds(resources []*metav1.APIResourceList) []schema.GroupVersionKind {
+	kinds := []schema.GroupVersionKind{}
+	for _, resourceList := range resources {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range resourceList.APIResources {
+			if strings.Contains(resource.Name, "/") || !hasVerb(resource.Verbs, "list") ||
+				unrecordedKinds[schema.GroupKind{Group: gv.Group, Kind: resource.Kind}] {
+				continue
+			}
+			kinds = append(kinds, gv.WithKind(resource.Kind))
+		}
+	}
+	return kinds
+}
+
+func hasVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// step returns the objects of the recorded test step.  The created and modified objects which are not owned by other
+// objects are applied, the final state of the applied objects and of the created objects owned by them is asserted.  The objects deleted which are not owned by other objects are deleted and asserted
+// not to exist.
+func (r *Recorder) step() (apply, asserts, errors []*unstructured.Unstructured) {
+	changed := []appliedKey{}
+	for _, key := range r.created {
+		if _, ok := r.current[key]; ok {
+			changed = append(changed, key)
+		}
+	}
+	modified := []appliedKey{}
+	for key, initial := range r.initial {
+		current, ok := r.current[key]
+		if ok && len(current.GetOwnerReferences()) == 0 && !equality.Semantic.DeepEqual(applyForm(initial), applyForm(current)) {
+			modified = append(modified, key)
+		}
+	}
+	sort.Slice(modified, func(i, j int) bool { return fmt.Sprint(modified[i]) < fmt.Sprint(modified[j]) })
+	changed = append(changed, modified...)
+
+	groups := map[string]bool{}
+	for _, key := range changed {
+		obj := r.current[key]
+		if len(obj.GetOwnerReferences()) == 0 {
+			apply = append(apply, applyForm(obj))
+		}
+		if !generatedName(obj) {
+			asserts = append(asserts, assertForm(obj))
+			continue
+		}
+		// objects with generated names are asserted by number, with their labels as the selector
+		group := generatedGroup(obj)
+		if id := fmt.Sprint(group.GroupVersionKind(), group.GetLabels()); !groups[id] {
+			groups[id] = true
+			group.SetAnnotations(map[string]string{harness.CountAnnotation: fmt.Sprint(r.count(group))})
+			asserts = append(asserts, group)
+		}
+	}
+
+	deleted := []appliedKey{}
+	for key, initial := range r.initial {
+		if _, ok := r.current[key]; !ok && len(initial.GetOwnerReferences()) == 0 {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return fmt.Sprint(deleted[i]) < fmt.Sprint(deleted[j]) })
+	for _, key := range deleted {
+		initial := r.initial[key]
+		ref := &unstructured.Unstructured{}
+		ref.SetGroupVersionKind(initial.GroupVersionKind())
+		ref.SetName(initial.GetName())
+		errors = append(errors, ref)
+	}
+	return apply, asserts, errors
+}
+
+// count returns the number of current objects of the kind of the object with its labels.
+func (r *Recorder) count(obj *unstructured.Unstructured) int {
+	count := 0
+	for _, current := range r.current {
+		if current.GroupVersionKind() == obj.GroupVersionKind() && hasLabels(current.GetLabels(), obj.GetLabels()) {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteStep writes the files of the recorded test step with the index to the directory, files which would be empty
+// are not written and existing files are never overwritten.
+func (r *Recorder) WriteStep(dir string, index int) ([]string, error) {
+	apply, asserts, errors := r.step()
+
+	var install []map[string]interface{}
+	if len(errors) > 0 {
+		refs := []interface{}{}
+		for _, obj := range errors {
+			refs = append(refs, map[string]interface{}{
+				"apiVersion": obj.GetAPIVersion(),
+				"kind":       obj.GetKind(),
+				"name":       obj.GetName(),
+			})
+		}
+		install = append(install, map[string]interface{}{
+			"apiVersion": "kuttl.dev/v1beta1",
+			"kind":       "TestStep",
+			"delete":     refs,
+		})
+	}
+	for _, obj := range apply {
+		install = append(install, obj.Object)
+	}
+
+	files := []struct {
+		name    string
+		objects []map[string]interface{}
+	}{
+		{name: "install", objects: install},
+		{name: "assert", objects: contents(asserts)},
+		{name: "errors", objects: contents(errors)},
+	}
+
+	written := []string{}
+	for _, file := range files {
+		if len(file.objects) == 0 {
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%02d-%s.yaml", index, file.name))
+		if err := writeObjects(path, file.objects); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+func contents(objs []*unstructured.Unstructured) []map[string]interface{} {
+	objects := []map[string]interface{}{}
+	for _, obj := range objs {
+		objects = append(objects, obj.Object)
+	}
+	return objects
+}
+
+// writeObjects writes the objects as the documents of a new YAML file.
+func writeObjects(path string, objects []map[string]interface{}) error {
+	buf := &bytes.Buffer{}
+	for i, obj := range objects {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		if err := testutils.MarshalObject(&unstructured.Unstructured{Object: obj}, buf); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = buf.WriteTo(f)
+	return err
+}
+
+// applyForm returns the object as it would be applied: the last applied configuration if it was applied with
+// kubectl, otherwise the object without its status, server set metadata and fields assigned by the cluster.
+func applyForm(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if lastApplied, ok := obj.GetAnnotations()[lastAppliedAnnotation]; ok {
+		applied := &unstructured.Unstructured{}
+		if err := json.Unmarshal([]byte(lastApplied), &applied.Object); err == nil {
+			applied.SetNamespace("")
+			return applied
+		}
+	}
+
+	applied := recordedMeta(obj)
+	for field, value := range obj.Object {
+		if field != "apiVersion" && field != "kind" && field != "metadata" && field != "status" {
+			applied.Object[field] = runtime.DeepCopyJSONValue(value)
+		}
+	}
+	for _, field := range assignedFields[obj.GroupVersionKind().GroupKind()] {
+		unstructured.RemoveNestedField(applied.Object, field...)
+	}
+	return applied
+}
+
+// assertForm returns the object without server set metadata and the volatile fields.
+func assertForm(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	asserted := recordedMeta(obj)
+	for field, value := range obj.Object {
+		if field != "apiVersion" && field != "kind" && field != "metadata" {
+			asserted.Object[field] = withoutVolatileFields(runtime.DeepCopyJSONValue(value))
+		}
+	}
+	return asserted
+}
+
+// recordedMeta returns an object with the kind, name, labels and annotations of the object, except the annotations
+// set by the cluster.
+func recordedMeta(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	recorded := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	recorded.SetGroupVersionKind(obj.GroupVersionKind())
+	recorded.SetName(obj.GetName())
+	recorded.SetLabels(obj.GetLabels())
+
+	annotations := map[string]string{}
+	for name, value := range obj.GetAnnotations() {
+		if name != lastAppliedAnnotation && name != "deployment.kubernetes.io/revision" && !strings.HasPrefix(name, "pv.kubernetes.io/") {
+			annotations[name] = value
+		}
+	}
+	if len(annotations) > 0 {
+		recorded.SetAnnotations(annotations)
+	}
+	return recorded
+}
+
+func withoutVolatileFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if volatileFields[field] {
+				delete(v, field)
+				continue
+			}
+			v[field] = withoutVolatileFields(fieldValue)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = withoutVolatileFields(v[i])
+		}
+	}
+	return value
+}
+
+// generatedName returns true if the object is owned and its name was generated, either with generateName or as the
+// name of its controller with a suffix (ex. the ReplicaSets of Deployments and the Pods of StatefulSets).
+func generatedName(obj *unstructured.Unstructured) bool {
+	if obj.GetGenerateName() != "" {
+		return true
+	}
+	controller := metav1.GetControllerOfNoCopy(obj)
+	return controller != nil && strings.HasPrefix(obj.GetName(), controller.Name+"-")
+}
+
+// generatedGroup returns an object without a name selecting the objects of the kind of the object by its labels,
+// except the generated labels.
+func generatedGroup(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	group := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	group.SetGroupVersionKind(obj.GroupVersionKind())
+	labels := obj.GetLabels()
+	for _, label := range generatedLabels {
+		delete(labels, label)
+	}
+	if len(labels) > 0 {
+		group.SetLabels(labels)
+	}
+	return group
+}
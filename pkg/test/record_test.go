@@ -0,0 +1,191 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestRecordedKinds(t *testing.T) {
+	kinds := recordedKinds([]*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Kind: "Pod", Verbs: metav1.Verbs{"get", "list", "watch"}},
+				{Name: "pods/status", Kind: "Pod", Verbs: metav1.Verbs{"get"}},
+				{Name: "events", Kind: "Event", Verbs: metav1.Verbs{"list"}},
+				{Name: "bindings", Kind: "Binding", Verbs: metav1.Verbs{"create"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Verbs: metav1.Verbs{"list"}}},
+		},
+	})
+	assert.Equal(t, []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Pod"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+	}, kinds)
+}
+
+func withController(obj *unstructured.Unstructured, owner *unstructured.Unstructured) *unstructured.Unstructured {
+	controller := true
+	obj.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: owner.GetAPIVersion(),
+		Kind:       owner.GetKind(),
+		Name:       owner.GetName(),
+		UID:        owner.GetUID(),
+		Controller: &controller,
+	}})
+	return obj
+}
+
+func TestRecorder(t *testing.T) {
+	configMap := func(name, value string) *unstructured.Unstructured {
+		cm := testutils.NewResource("v1", "ConfigMap", name, testNamespace)
+		cm.Object["data"] = map[string]interface{}{"value": value}
+		return cm
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(
+		configMap("kept", "1"),
+		configMap("edited", "1"),
+		configMap("removed", "1"),
+	).Build()
+	r := &Recorder{
+		Namespace: testNamespace,
+		Client:    cl,
+		Out:       &bytes.Buffer{},
+		kinds: []schema.GroupVersionKind{
+			{Version: "v1", Kind: "ConfigMap"},
+			{Version: "v1", Kind: "Pod"},
+			{Version: "v1", Kind: "Service"},
+			{Group: "apps", Version: "v1", Kind: "Deployment"},
+			{Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+		},
+	}
+	require.NoError(t, r.start())
+
+	// a Deployment is applied with kubectl, its controllers create a ReplicaSet and Pods
+	deployment := testutils.NewResource("apps/v1", "Deployment", "web", testNamespace)
+	deployment.SetAnnotations(map[string]string{
+		lastAppliedAnnotation:               `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"world"},"spec":{"replicas":2}}`,
+		"deployment.kubernetes.io/revision": "1",
+	})
+	deployment.SetUID(types.UID("deployment"))
+	deployment.Object["spec"] = map[string]interface{}{"replicas": int64(2), "revisionHistoryLimit": int64(10)}
+	require.NoError(t, cl.Create(context.TODO(), deployment))
+
+	replicaSet := withController(testutils.NewResource("apps/v1", "ReplicaSet", "web-5d8f", testNamespace), deployment)
+	replicaSet.SetLabels(map[string]string{"app": "web", "pod-template-hash": "5d8f"})
+	replicaSet.SetUID(types.UID("replicaset"))
+	require.NoError(t, cl.Create(context.TODO(), replicaSet))
+
+	for _, name := range []string{"web-5d8f-abcde", "web-5d8f-fghij"} {
+		pod := withController(testutils.NewPod(name, testNamespace), replicaSet)
+		pod.SetGenerateName("web-5d8f-")
+		pod.SetLabels(map[string]string{"app": "web", "pod-template-hash": "5d8f"})
+		require.NoError(t, cl.Create(context.TODO(), pod))
+	}
+	require.NoError(t, r.observe(context.TODO()))
+
+	// a Service is created, a ConfigMap is edited and another one is deleted
+	service := testutils.NewResource("v1", "Service", "web", testNamespace)
+	service.Object["spec"] = map[string]interface{}{
+		"clusterIP": "10.96.0.10",
+		"ports":     []interface{}{map[string]interface{}{"port": int64(80)}},
+	}
+	service.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Ready", "lastTransitionTime": "2024-01-01T00:00:00Z"}},
+	}
+	require.NoError(t, cl.Create(context.TODO(), service))
+
+	edited := &unstructured.Unstructured{}
+	edited.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "edited"}, edited))
+	edited.Object["data"] = map[string]interface{}{"value": "2"}
+	require.NoError(t, cl.Update(context.TODO(), edited))
+	require.NoError(t, cl.Delete(context.TODO(), configMap("removed", "1")))
+	require.NoError(t, r.observe(context.TODO()))
+
+	assert.Contains(t, r.Out.(*bytes.Buffer).String(), "ReplicaSet:world/web-5d8f created\n")
+	assert.Contains(t, r.Out.(*bytes.Buffer).String(), "Service:world/web created\n")
+
+	dir := t.TempDir()
+	files, err := r.WriteStep(dir, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "01-install.yaml"),
+		filepath.Join(dir, "01-assert.yaml"),
+		filepath.Join(dir, "01-errors.yaml"),
+	}, files)
+
+	install, err := testutils.LoadYAMLFromFile(files[0])
+	require.NoError(t, err)
+	require.Len(t, install, 4)
+	step, ok := install[0].(*harness.TestStep)
+	require.True(t, ok)
+	require.Len(t, step.Delete, 1)
+	assert.Equal(t, "removed", step.Delete[0].Name)
+	assert.Equal(t, "ConfigMap", step.Delete[0].Kind)
+	// the Deployment is applied as it was applied with kubectl
+	assert.Equal(t, map[string]interface{}{"replicas": int64(2)}, unstructuredOf(t, install[1]).Object["spec"])
+	assert.Empty(t, install[1].GetNamespace())
+	appliedService := unstructuredOf(t, install[2])
+	assert.Equal(t, "Service", appliedService.GetKind())
+	assert.NotContains(t, appliedService.Object["spec"], "clusterIP")
+	assert.NotContains(t, appliedService.Object, "status")
+	assert.Equal(t, "edited", install[3].GetName())
+
+	asserts, err := testutils.LoadYAMLFromFile(files[1])
+	require.NoError(t, err)
+	require.Len(t, asserts, 5)
+	assertedDeployment := unstructuredOf(t, asserts[0])
+	assert.Equal(t, "web", assertedDeployment.GetName())
+	assert.Empty(t, assertedDeployment.GetAnnotations())
+	assert.Equal(t, int64(10), assertedDeployment.Object["spec"].(map[string]interface{})["revisionHistoryLimit"])
+	// the objects with generated names are asserted by number
+	for i, kind := range map[int]string{1: "ReplicaSet", 2: "Pod"} {
+		assert.Equal(t, kind, asserts[i].GetObjectKind().GroupVersionKind().Kind)
+		assert.Empty(t, asserts[i].GetName())
+		assert.Equal(t, map[string]string{"app": "web"}, asserts[i].GetLabels())
+	}
+	assert.Equal(t, "1", asserts[1].GetAnnotations()[harness.CountAnnotation])
+	assert.Equal(t, "2", asserts[2].GetAnnotations()[harness.CountAnnotation])
+	conditions, _, err := unstructured.NestedSlice(unstructuredOf(t, asserts[3]).Object, "status", "conditions")
+	require.NoError(t, err)
+	require.Len(t, conditions, 1)
+	assert.Equal(t, "Ready", conditions[0].(map[string]interface{})["type"])
+	assert.NotContains(t, conditions[0], "lastTransitionTime")
+	assert.Equal(t, map[string]interface{}{"value": "2"}, unstructuredOf(t, asserts[4]).Object["data"])
+
+	errors, err := testutils.LoadYAMLFromFile(files[2])
+	require.NoError(t, err)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "removed", errors[0].GetName())
+
+	// existing files are not overwritten
+	_, err = r.WriteStep(dir, 1)
+	assert.True(t, os.IsExist(err))
+}
+
+func unstructuredOf(t *testing.T, obj client.Object) *unstructured.Unstructured {
+	u, ok := obj.(*unstructured.Unstructured)
+	require.True(t, ok, "%T is not unstructured", obj)
+	return u
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	docker "github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kindConfig "sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// RegistryEnv is the environment variable of commands and the Jsonnet external variable set to the address of the
+// local container registry started with kindRegistry.
+const RegistryEnv = "KUTTL_REGISTRY"
+
+const (
+	// registryImage is the image of the local container registry.
+	registryImage = "registry:2"
+	// registryPort is the port the registry listens on in its container.
+	registryPort = "5000/tcp"
+	// defaultRegistryPort is the default host port of the registry, 5000 is often taken by other services.
+	defaultRegistryPort = 5001
+)
+
+// registry is a local container registry the nodes of a kind cluster pull from, following
+// https://kind.sigs.k8s.io/docs/user/local-registry/.
+type registry struct {
+	// name of the registry container, it is also the host name of the registry on the kind network.
+	name string
+	// address of the registry on the host, images are pushed to and pulled from it with this address.
+	address string
+	port    int
+	id      string
+	// created is set if the registry container was created by the harness, only then it is removed.
+	created bool
+}
+
+// newRegistry returns the local container registry of the kind cluster of the context, listening on the host port.
+func newRegistry(kindContext string, port int) *registry {
+	if port == 0 {
+		port = defaultRegistryPort
+	}
+	return &registry{
+		name:    kindContext + "-registry",
+		address: fmt.Sprintf("localhost:%d", port),
+		port:    port,
+	}
+}
+
+// configure adds the registry as a mirror of its address to the containerd configuration of the kind nodes, so that
+// images pushed to it from the host are pulled with the same name in the cluster.
+func (r *registry) configure(kindCfg *kindConfig.Cluster) {
+	kindCfg.ContainerdConfigPatches = append(kindCfg.ContainerdConfigPatches, fmt.Sprintf(
+		"[plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors.%q]\n  endpoint = [%q]\n",
+		r.address, fmt.Sprintf("http://%s:5000", r.name)))
+}
+
+// start starts the registry container, an existing container of the same name is reused.
+func (r *registry) start(ctx context.Context, dockerClient testutils.DockerClient) error {
+	existing, err := dockerClient.ContainerInspect(ctx, r.name)
+	if err == nil {
+		r.id = existing.ID
+		if existing.State != nil && existing.State.Running {
+			return nil
+		}
+		return dockerClient.ContainerStart(ctx, r.id, dockertypes.ContainerStartOptions{})
+	}
+	if !docker.IsErrNotFound(err) {
+		return fmt.Errorf("inspecting registry container %s: %w", r.name, err)
+	}
+
+	pull, err := dockerClient.ImagePull(ctx, registryImage, dockertypes.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("pulling registry image %s: %w", registryImage, err)
+	}
+	// the pull completes once its progress has been read
+	_, err = io.Copy(io.Discard, pull)
+	pull.Close()
+	if err != nil {
+		return fmt.Errorf("pulling registry image %s: %w", registryImage, err)
+	}
+
+	created, err := dockerClient.ContainerCreate(ctx,
+		&container.Config{
+			Image:        registryImage,
+			ExposedPorts: nat.PortSet{registryPort: struct{}{}},
+		},
+		&container.HostConfig{
+			PortBindings:  nat.PortMap{registryPort: []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: fmt.Sprint(r.port)}}},
+			RestartPolicy: container.RestartPolicy{Name: "always"},
+		},
+		nil, nil, r.name)
+	if err != nil {
+		return fmt.Errorf("creating registry container %s: %w", r.name, err)
+	}
+	r.id = created.ID
+	r.created = true
+
+	return dockerClient.ContainerStart(ctx, r.id, dockertypes.ContainerStartOptions{})
+}
+
+// connect connects the registry container to the network of the kind nodes, it is created with the cluster.
+func (r *registry) connect(ctx context.Context, dockerClient testutils.DockerClient) error {
+	network := os.Getenv("KIND_EXPERIMENTAL_DOCKER_NETWORK")
+	if network == "" {
+		network = "kind"
+	}
+	err := dockerClient.NetworkConnect(ctx, network, r.id, nil)
+	// a reused registry may already be connected
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return fmt.Errorf("connecting registry container %s to network %s: %w", r.name, network, err)
+	}
+	return nil
+}
+
+// remove removes the registry container if it was created by the harness.
+func (r *registry) remove(ctx context.Context, dockerClient testutils.DockerClient) error {
+	if !r.created {
+		return nil
+	}
+	return dockerClient.ContainerRemove(ctx, r.id, dockertypes.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+}
+
+// document creates the ConfigMap documenting the registry for tools of the cluster, see
+// https://github.com/kubernetes/enhancements/tree/master/keps/sig-cluster-lifecycle/generic/1755-communicating-a-local-registry
+func (r *registry) document(ctx context.Context, cl client.Client) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "local-registry-hosting",
+			Namespace: metav1.NamespacePublic,
+		},
+		Data: map[string]string{
+			"localRegistryHosting.v1": fmt.Sprintf("host: %q\nhelp: \"https://kind.sigs.k8s.io/docs/user/local-registry/\"\n", r.address),
+		},
+	}
+	if err := cl.Create(ctx, configMap); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating ConfigMap %s/%s: %w", configMap.Namespace, configMap.Name, err)
+	}
+	return nil
+}
+
+// jsonnetVars returns the external variables of Jsonnet files, the variables of the test suite, the secrets, the
+// cluster domain and the address of the local container registry if one was started.
+func (h *Harness) jsonnetVars() map[string]string {
+	if h.registry == nil && len(h.secrets) == 0 && h.clusterDomain == "" {
+		return h.TestSuite.JsonnetVars
+	}
+	vars := map[string]string{}
+	if h.registry != nil {
+		vars[RegistryEnv] = h.registry.address
+	}
+	if h.clusterDomain != "" {
+		vars[ClusterDomainEnv] = h.clusterDomain
+		vars[APIServerEnv] = apiServerAddress(h.clusterDomain)
+	}
+	for name, value := range h.secrets {
+		vars[name] = value
+	}
+	for name, value := range h.TestSuite.JsonnetVars {
+		vars[name] = value
+	}
+	return vars
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	kindConfig "sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestRegistry(t *testing.T) {
+	r := newRegistry("kuttl", 0)
+	assert.Equal(t, "kuttl-registry", r.name)
+	assert.Equal(t, "localhost:5001", r.address)
+
+	kindCfg := &kindConfig.Cluster{}
+	r.configure(kindCfg)
+	assert.Equal(t, []string{"[plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors.\"localhost:5001\"]\n  endpoint = [\"http://kuttl-registry:5000\"]\n"}, kindCfg.ContainerdConfigPatches)
+
+	t.Run("created", func(t *testing.T) {
+		docker := newDockerMock()
+		r := newRegistry("kuttl", 5005)
+
+		require.NoError(t, r.start(context.TODO(), docker))
+		assert.Equal(t, []string{registryImage}, docker.pulled)
+		require.Len(t, docker.created, 1)
+		assert.Equal(t, "5005", docker.created[0].PortBindings[registryPort][0].HostPort)
+		assert.Equal(t, "127.0.0.1", docker.created[0].PortBindings[registryPort][0].HostIP)
+		assert.Equal(t, []string{"created"}, docker.started)
+
+		require.NoError(t, r.connect(context.TODO(), docker))
+		assert.Equal(t, []string{"kind/created"}, docker.connected)
+
+		require.NoError(t, r.remove(context.TODO(), docker))
+		assert.Equal(t, []string{"created"}, docker.removed)
+	})
+
+	t.Run("reused", func(t *testing.T) {
+		docker := newDockerMock()
+		docker.existing = &dockertypes.ContainerState{Running: false}
+		docker.connectErr = errors.New("endpoint with name kuttl-registry already exists in network kind")
+		r := newRegistry("kuttl", 0)
+
+		require.NoError(t, r.start(context.TODO(), docker))
+		assert.Empty(t, docker.pulled)
+		assert.Empty(t, docker.created)
+		assert.Equal(t, []string{"existing"}, docker.started)
+
+		require.NoError(t, r.connect(context.TODO(), docker))
+
+		// containers which were not created by the harness are kept
+		require.NoError(t, r.remove(context.TODO(), docker))
+		assert.Empty(t, docker.removed)
+	})
+
+	t.Run("connect failure", func(t *testing.T) {
+		docker := newDockerMock()
+		docker.connectErr = errors.New("network kind not found")
+		r := newRegistry("kuttl", 0)
+
+		require.NoError(t, r.start(context.TODO(), docker))
+		assert.EqualError(t, r.connect(context.TODO(), docker), "connecting registry container kuttl-registry to network kind: network kind not found")
+	})
+}
+
+func TestRegistryDocument(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	r := newRegistry("kind", 0)
+
+	require.NoError(t, r.document(context.TODO(), cl))
+	// the ConfigMap of a reused registry already exists
+	require.NoError(t, r.document(context.TODO(), cl))
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: "kube-public", Name: "local-registry-hosting"}, configMap))
+	assert.Contains(t, configMap.Data["localRegistryHosting.v1"], `host: "localhost:5001"`)
+}
+
+func TestHarnessJsonnetVars(t *testing.T) {
+	h := Harness{TestSuite: harness.TestSuite{JsonnetVars: map[string]string{"env": "ci"}}}
+	assert.Equal(t, map[string]string{"env": "ci"}, h.jsonnetVars())
+
+	h.registry = newRegistry("kind", 0)
+	assert.Equal(t, map[string]string{"env": "ci", RegistryEnv: "localhost:5001"}, h.jsonnetVars())
+
+	// the variables of the suite take precedence
+	h.TestSuite.JsonnetVars[RegistryEnv] = "registry.example.com"
+	assert.Equal(t, "registry.example.com", h.jsonnetVars()[RegistryEnv])
+}
@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// restartedAtAnnotation is set on the pod template of a workload to restart it, like `kubectl rollout restart`.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// restartInterval is the interval at which the rollout of restarted workloads is checked.
+var restartInterval = time.Second
+
+// Restart restarts the workloads of the step and waits until their rollouts are complete.
+func (s *Step) Restart(namespace string) []error {
+	if s.Step == nil || len(s.Step.Restarts) == 0 {
+		return nil
+	}
+
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, restart := range s.Step.Restarts {
+		if err := s.restart(cl, restart, namespace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// restart rolls out a restart of the workload of restart and waits until its restarted pods are ready.
+func (s *Step) restart(cl client.Client, restart harness.Restart, namespace string) error {
+	obj, err := restartObject(restart, namespace)
+	if err != nil {
+		return err
+	}
+	if err := s.checkSafe("restart", obj); err != nil {
+		return err
+	}
+	id := testutils.ResourceID(obj)
+
+	timeout := restart.Timeout
+	if timeout == 0 {
+		timeout = s.GetTimeout()
+	}
+
+	if err := cl.Patch(context.TODO(), obj, client.RawPatch(types.MergePatchType, restartPatch(time.Now()))); err != nil {
+		return fmt.Errorf("restarting %s: %w", id, err)
+	}
+	s.Logger.Logf("restarted %s", id)
+
+	status := "not checked"
+	err = wait.PollImmediate(restartInterval, time.Duration(timeout)*time.Second, func() (bool, error) {
+		actual := &unstructured.Unstructured{}
+		actual.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := cl.Get(context.TODO(), testutils.ObjectKey(obj), actual); err != nil {
+			return false, err
+		}
+
+		var done bool
+		var err error
+		done, status, err = rolledOut(actual)
+		return done, err
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out restarting %s: %s", id, status)
+	}
+	if err != nil {
+		return fmt.Errorf("restarting %s: %w", id, err)
+	}
+
+	s.Logger.Logf("%s rolled out", id)
+	return nil
+}
+
+// restartObject returns the workload to restart.
+func restartObject(restart harness.Restart, namespace string) (*unstructured.Unstructured, error) {
+	switch restart.Kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return nil, fmt.Errorf("cannot restart kind %q, it must be one of Deployment, StatefulSet or DaemonSet", restart.Kind)
+	}
+	if restart.Name == "" {
+		return nil, fmt.Errorf("restart of %s has no name", restart.Kind)
+	}
+	if restart.Namespace != "" {
+		namespace = restart.Namespace
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind(restart.Kind))
+	obj.SetName(restart.Name)
+	obj.SetNamespace(namespace)
+	return obj, nil
+}
+
+// restartPatch returns the merge patch restarting a workload at the time.
+func restartPatch(at time.Time) []byte {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{restartedAtAnnotation: at.Format(time.RFC3339)},
+				},
+			},
+		},
+	}
+	// the patch only has strings and maps, it cannot fail to marshal
+	data, _ := json.Marshal(patch)
+	return data
+}
+
+// rolledOut returns true if the rollout of the workload is complete, like `kubectl rollout status`.  It also returns
+// a description of the status and fails if the rollout cannot complete.
+func rolledOut(obj *unstructured.Unstructured) (bool, string, error) {
+	switch obj.GetKind() {
+	case "Deployment":
+		deployment := &appsv1.Deployment{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, deployment); err != nil {
+			return false, "", err
+		}
+		return deploymentRolledOut(deployment)
+	case "StatefulSet":
+		statefulSet := &appsv1.StatefulSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, statefulSet); err != nil {
+			return false, "", err
+		}
+		return statefulSetRolledOut(statefulSet)
+	default:
+		daemonSet := &appsv1.DaemonSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, daemonSet); err != nil {
+			return false, "", err
+		}
+		return daemonSetRolledOut(daemonSet)
+	}
+}
+
+func deploymentRolledOut(d *appsv1.Deployment) (bool, string, error) {
+	if d.Status.ObservedGeneration < d.Generation {
+		return false, fmt.Sprintf("generation %d was not observed yet", d.Generation), nil
+	}
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, "", fmt.Errorf("exceeded its progress deadline: %s", condition.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	switch {
+	case d.Status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas were updated", d.Status.UpdatedReplicas, replicas), nil
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d old replicas are pending termination", d.Status.Replicas-d.Status.UpdatedReplicas), nil
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d of %d updated replicas are available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas), nil
+	}
+	return true, "rolled out", nil
+}
+
+func statefulSetRolledOut(s *appsv1.StatefulSet) (bool, string, error) {
+	if s.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return false, "", fmt.Errorf("the pods of a StatefulSet with the %s update strategy are not restarted", appsv1.OnDeleteStatefulSetStrategyType)
+	}
+	if s.Status.ObservedGeneration < s.Generation {
+		return false, fmt.Sprintf("generation %d was not observed yet", s.Generation), nil
+	}
+
+	replicas := int32(1)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+	if s.Status.ReadyReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d pods are ready", s.Status.ReadyReplicas, replicas), nil
+	}
+	// partitioned rolling updates only update the pods with an ordinal of at least the partition
+	if rollingUpdate := s.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		if partitioned := replicas - *rollingUpdate.Partition; s.Status.UpdatedReplicas < partitioned {
+			return false, fmt.Sprintf("%d of %d pods were updated", s.Status.UpdatedReplicas, partitioned), nil
+		}
+		return true, "rolled out", nil
+	}
+	if s.Status.UpdateRevision != s.Status.CurrentRevision {
+		return false, fmt.Sprintf("%d of %d pods are at revision %s", s.Status.UpdatedReplicas, replicas, s.Status.UpdateRevision), nil
+	}
+	return true, "rolled out", nil
+}
+
+func daemonSetRolledOut(d *appsv1.DaemonSet) (bool, string, error) {
+	if d.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType {
+		return false, "", fmt.Errorf("the pods of a DaemonSet with the %s update strategy are not restarted", appsv1.OnDeleteDaemonSetStrategyType)
+	}
+	if d.Status.ObservedGeneration < d.Generation {
+		return false, fmt.Sprintf("generation %d was not observed yet", d.Generation), nil
+	}
+
+	switch {
+	case d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled:
+		return false, fmt.Sprintf("%d of %d pods were updated", d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled), nil
+	case d.Status.NumberAvailable < d.Status.DesiredNumberScheduled:
+		return false, fmt.Sprintf("%d of %d updated pods are available", d.Status.NumberAvailable, d.Status.DesiredNumberScheduled), nil
+	}
+	return true, "rolled out", nil
+}
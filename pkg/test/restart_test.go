@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestRestart(t *testing.T) {
+	restartInterval = 10 * time.Millisecond
+	defer func() { restartInterval = time.Second }()
+
+	operator := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: testNamespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+	}
+	db := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: testNamespace},
+		Spec:       appsv1.StatefulSetSpec{UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}},
+	}
+	rollOut := func(obj *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(obj.Object, obj.GetGeneration(), "status", "observedGeneration")
+		for _, field := range []string{"replicas", "updatedReplicas", "availableReplicas"} {
+			_ = unstructured.SetNestedField(obj.Object, int64(2), "status", field)
+		}
+	}
+
+	for _, test := range []struct {
+		name      string
+		restart   harness.Restart
+		reconcile func(obj *unstructured.Unstructured)
+		err       string
+	}{
+		{
+			name:      "deployment",
+			restart:   harness.Restart{Kind: "Deployment", Name: "operator"},
+			reconcile: rollOut,
+		},
+		{
+			name:      "not rolled out",
+			restart:   harness.Restart{Kind: "Deployment", Name: "operator", Timeout: 1},
+			reconcile: func(obj *unstructured.Unstructured) {},
+			err:       "timed out restarting Deployment:world/operator: 0 of 2 replicas were updated",
+		},
+		{
+			name:      "on delete update strategy",
+			restart:   harness.Restart{Kind: "StatefulSet", Name: "db"},
+			reconcile: func(obj *unstructured.Unstructured) {},
+			err:       "restarting StatefulSet:world/db: the pods of a StatefulSet with the OnDelete update strategy are not restarted",
+		},
+		{
+			name:    "missing",
+			restart: harness.Restart{Kind: "DaemonSet", Name: "agent"},
+			err:     `restarting DaemonSet:world/agent: daemonsets.apps "agent" not found`,
+		},
+		{
+			name:    "unknown kind",
+			restart: harness.Restart{Kind: "ReplicaSet", Name: "operator"},
+			err:     `cannot restart kind "ReplicaSet", it must be one of Deployment, StatefulSet or DaemonSet`,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := &reconcilingClient{
+				Client:    fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(operator.DeepCopy(), db.DeepCopy()).Build(),
+				reconcile: test.reconcile,
+			}
+
+			step := &Step{
+				Timeout: 5,
+				Logger:  testutils.NewTestLogger(t, ""),
+				Client:  func(bool) (client.Client, error) { return cl, nil },
+				Step:    &harness.TestStep{Restarts: []harness.Restart{test.restart}},
+			}
+
+			errs := step.Restart(testNamespace)
+			if test.err != "" {
+				require.Len(t, errs, 1)
+				assert.EqualError(t, errs[0], test.err)
+				return
+			}
+			assert.Empty(t, errs)
+
+			actual := &appsv1.Deployment{}
+			require.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(operator), actual))
+			assert.Contains(t, actual.Spec.Template.Annotations, restartedAtAnnotation)
+		})
+	}
+}
+
+func TestRolledOut(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		obj    runtime.Object
+		done   bool
+		status string
+	}{
+		{
+			name: "deployment generation not observed",
+			obj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+			},
+			status: "generation 2 was not observed yet",
+		},
+		{
+			name: "deployment with old replicas",
+			obj: &appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+			},
+			status: "1 old replicas are pending termination",
+		},
+		{
+			name: "deployment with unavailable replicas",
+			obj: &appsv1.Deployment{
+				Spec:   appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+				Status: appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2},
+			},
+			status: "2 of 3 updated replicas are available",
+		},
+		{
+			name: "statefulset at the previous revision",
+			obj: &appsv1.StatefulSet{
+				Status: appsv1.StatefulSetStatus{ReadyReplicas: 1, CurrentRevision: "db-1", UpdateRevision: "db-2"},
+			},
+			status: "0 of 1 pods are at revision db-2",
+		},
+		{
+			name: "partitioned statefulset",
+			obj: &appsv1.StatefulSet{
+				Spec: appsv1.StatefulSetSpec{
+					Replicas: int32Ptr(3),
+					UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+						RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: int32Ptr(2)},
+					},
+				},
+				Status: appsv1.StatefulSetStatus{ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "db-1", UpdateRevision: "db-2"},
+			},
+			done:   true,
+			status: "rolled out",
+		},
+		{
+			name: "daemonset with unavailable pods",
+			obj: &appsv1.DaemonSet{
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 1},
+			},
+			status: "1 of 3 updated pods are available",
+		},
+		{
+			name: "daemonset",
+			obj: &appsv1.DaemonSet{
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+			},
+			done:   true,
+			status: "rolled out",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(test.obj)
+			require.NoError(t, err)
+			obj := &unstructured.Unstructured{Object: u}
+			switch test.obj.(type) {
+			case *appsv1.Deployment:
+				obj.SetKind("Deployment")
+			case *appsv1.StatefulSet:
+				obj.SetKind("StatefulSet")
+			default:
+				obj.SetKind("DaemonSet")
+			}
+
+			done, status, err := rolledOut(obj)
+			require.NoError(t, err)
+			assert.Equal(t, test.done, done)
+			assert.Equal(t, test.status, status)
+		})
+	}
+}
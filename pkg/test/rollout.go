@@ -0,0 +1,53 @@
+package test
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// expectedRollout returns a copy of expected without the rolled-out annotation, as well as whether the rollout of the
+// matching object must be complete.  If the annotation is not set, expected is returned unmodified.
+func expectedRollout(expected runtime.Object) (runtime.Object, bool, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := m.GetAnnotations()[harness.RolledOutAnnotation]
+	if !ok {
+		return expected, false, nil
+	}
+	rollout, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("annotation %s: %q is not a boolean", harness.RolledOutAnnotation, value)
+	}
+	switch kind := expected.GetObjectKind().GroupVersionKind().Kind; kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return nil, false, fmt.Errorf("annotation %s: %s has no rollout, it can only be set on Deployments, StatefulSets and DaemonSets", harness.RolledOutAnnotation, kind)
+	}
+
+	copied, err := withoutAnnotation(expected, harness.RolledOutAnnotation)
+	if err != nil {
+		return nil, false, err
+	}
+	return copied, rollout, nil
+}
+
+// checkRolledOut fails if the rollout of the workload is not complete.
+func checkRolledOut(actual *unstructured.Unstructured) error {
+	done, status, err := rolledOut(actual)
+	if err != nil {
+		return err
+	}
+	if !done {
+		return fmt.Errorf("rollout is not complete: %s", status)
+	}
+	return nil
+}
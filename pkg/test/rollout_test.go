@@ -0,0 +1,87 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedRollout(t *testing.T) {
+	expected := testutils.SetAnnotation(testutils.NewResource("apps/v1", "Deployment", "web", ""), harness.RolledOutAnnotation, "true")
+
+	stripped, rollout, err := expectedRollout(expected)
+	assert.NoError(t, err)
+	assert.True(t, rollout)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+	// the original object is not modified
+	assert.Contains(t, expected.GetAnnotations(), harness.RolledOutAnnotation)
+
+	unannotated := testutils.NewResource("apps/v1", "Deployment", "web", "")
+	stripped, rollout, err = expectedRollout(unannotated)
+	assert.NoError(t, err)
+	assert.False(t, rollout)
+	assert.Equal(t, unannotated, stripped)
+
+	_, _, err = expectedRollout(testutils.SetAnnotation(testutils.NewResource("apps/v1", "Deployment", "web", ""), harness.RolledOutAnnotation, "soon"))
+	assert.EqualError(t, err, `annotation kuttl.dev/rolled-out: "soon" is not a boolean`)
+	_, _, err = expectedRollout(testutils.SetAnnotation(testutils.NewPod("web", ""), harness.RolledOutAnnotation, "true"))
+	assert.EqualError(t, err, "annotation kuttl.dev/rolled-out: Pod has no rollout, it can only be set on Deployments, StatefulSets and DaemonSets")
+}
+
+func TestCheckResourceRolledOut(t *testing.T) {
+	rolledOut := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rolled-out", Namespace: testNamespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		Status:     appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+	}
+	rollingOut := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rolling-out", Namespace: testNamespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		Status:     appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+	}
+
+	for _, test := range []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{name: "rolled-out", value: "true"},
+		{
+			name:  "rolling-out",
+			value: "true",
+			err:   "resource Deployment:world/rolling-out: rollout is not complete: 1 of 2 updated replicas are available",
+		},
+		{name: "rolling-out", value: "false"},
+	} {
+		test := test
+
+		t.Run(test.name+" "+test.value, func(t *testing.T) {
+			step := Step{
+				Logger: testutils.NewTestLogger(t, ""),
+				Client: func(bool) (client.Client, error) {
+					return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(rolledOut.DeepCopy(), rollingOut.DeepCopy()).Build(), nil
+				},
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			expected := testutils.SetAnnotation(testutils.NewResource("apps/v1", "Deployment", test.name, ""), harness.RolledOutAnnotation, test.value)
+			errors := step.CheckResource(expected, testNamespace)
+			if test.err == "" {
+				assert.Equal(t, []error{}, errors)
+			} else {
+				assert.Len(t, errors, 1)
+				assert.EqualError(t, errors[0], test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// safeModeID returns the identifier of obj the allow patterns of the safe mode are matched against,
+// "<kind>[.<group>]/[<namespace>/]<name>".
+func safeModeID(obj client.Object) string {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	// typed objects may not have their kind set
+	if gvk.Empty() {
+		gvk, _ = apiutil.GVKForObject(obj, testutils.Scheme())
+	}
+	id := gvk.GroupKind().String()
+	if namespace := obj.GetNamespace(); namespace != "" {
+		id += "/" + namespace
+	}
+	return id + "/" + obj.GetName()
+}
+
+// checkSafe fails if the step runs in safe mode and obj is outside the namespace created for the test, unless the safe
+// mode allows it.  The verb describes the operation in the error, ex. "delete".
+func (s *Step) checkSafe(verb string, obj client.Object) error {
+	if s.SafeMode == nil || s.SafeNamespace != "" && obj.GetNamespace() == s.SafeNamespace {
+		return nil
+	}
+	id := safeModeID(obj)
+	// the namespace created for the test itself
+	if s.SafeNamespace != "" && id == "Namespace/"+s.SafeNamespace {
+		return nil
+	}
+
+	for _, pattern := range s.SafeMode.Allow {
+		allowed, err := path.Match(pattern, id)
+		if err != nil {
+			return fmt.Errorf("invalid safe mode allow pattern %q: %w", pattern, err)
+		}
+		if allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("safe mode: refusing to %s %s outside the test namespace, it must be allowed by the safe mode of the test suite", verb, id)
+}
+
+// checkSafeNodeChanges fails if the step changes nodes in safe mode, unless the safe mode allows it.
+func (s *Step) checkSafeNodeChanges() error {
+	if s.SafeMode == nil || s.SafeMode.AllowNodeChanges || s.Step == nil || len(s.Step.Nodes) == 0 {
+		return nil
+	}
+	return errors.New("safe mode: refusing to change nodes, allowNodeChanges must be set in the safe mode of the test suite")
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestCheckSafe(t *testing.T) {
+	safeMode := &harness.SafeMode{Allow: []string{"ClusterRole.rbac.authorization.k8s.io/operator-*", "*/shared/*"}}
+
+	for _, test := range []struct {
+		name          string
+		safeMode      *harness.SafeMode
+		safeNamespace string
+		obj           client.Object
+		err           string
+	}{
+		{
+			name: "safe mode disabled",
+			obj:  testutils.NewResource("v1", "Namespace", "default", ""),
+		},
+		{
+			name:          "test namespace",
+			safeMode:      safeMode,
+			safeNamespace: "kuttl-test-a",
+			obj:           testutils.NewResource("v1", "ConfigMap", "config", "kuttl-test-a"),
+		},
+		{
+			name:          "test namespace itself",
+			safeMode:      safeMode,
+			safeNamespace: "kuttl-test-a",
+			obj:           testutils.NewResource("v1", "Namespace", "kuttl-test-a", ""),
+		},
+		{
+			name:          "other namespace",
+			safeMode:      safeMode,
+			safeNamespace: "kuttl-test-a",
+			obj:           testutils.NewResource("v1", "ConfigMap", "config", "kube-system"),
+			err:           "safe mode: refusing to delete ConfigMap/kube-system/config outside the test namespace, it must be allowed by the safe mode of the test suite",
+		},
+		{
+			name:     "user-supplied namespace",
+			safeMode: safeMode,
+			obj:      testutils.NewResource("v1", "ConfigMap", "config", "mine"),
+			err:      "safe mode: refusing to delete ConfigMap/mine/config outside the test namespace, it must be allowed by the safe mode of the test suite",
+		},
+		{
+			name:     "allowed namespace",
+			safeMode: safeMode,
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "shared"}},
+		},
+		{
+			name:     "allowed cluster-scoped object",
+			safeMode: safeMode,
+			obj:      testutils.NewResource("rbac.authorization.k8s.io/v1", "ClusterRole", "operator-view", ""),
+		},
+		{
+			name:     "cluster-scoped object",
+			safeMode: safeMode,
+			obj:      testutils.NewResource("rbac.authorization.k8s.io/v1", "ClusterRole", "admin", ""),
+			err:      "safe mode: refusing to delete ClusterRole.rbac.authorization.k8s.io/admin outside the test namespace, it must be allowed by the safe mode of the test suite",
+		},
+		{
+			name:     "invalid pattern",
+			safeMode: &harness.SafeMode{Allow: []string{"["}},
+			obj:      testutils.NewResource("v1", "Namespace", "default", ""),
+			err:      `invalid safe mode allow pattern "[": syntax error in pattern`,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			step := &Step{SafeMode: test.safeMode, SafeNamespace: test.safeNamespace}
+			err := step.checkSafe("delete", test.obj)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestSafeModeRefusesDeletes(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(testutils.Scheme()).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: testNamespace}},
+	).Build()
+	step := &Step{
+		Logger:          testutils.NewTestLogger(t, ""),
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+		Step: &harness.TestStep{Delete: []harness.ObjectReference{
+			{ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "config"}},
+			{ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "shared"}},
+		}},
+		SafeMode:      &harness.SafeMode{},
+		SafeNamespace: testNamespace,
+		Timeout:       1,
+	}
+
+	assert.EqualError(t, step.DeleteExisting(testNamespace), "safe mode: refusing to delete Namespace/shared outside the test namespace, it must be allowed by the safe mode of the test suite")
+	// nothing is deleted if one of the objects is refused
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Name: "config", Namespace: testNamespace}, &corev1.ConfigMap{}))
+
+	step.SafeMode.Allow = []string{"Namespace/shared"}
+	assert.NoError(t, step.DeleteExisting(testNamespace))
+	assert.Error(t, cl.Get(context.TODO(), client.ObjectKey{Name: "shared"}, &corev1.Namespace{}))
+
+	step.Step = &harness.TestStep{Nodes: []harness.NodeChange{{Name: "worker", Labels: map[string]string{"disk": "ssd"}}}}
+	assert.EqualError(t, step.ChangeNodes(t), "safe mode: refusing to change nodes, allowNodeChanges must be set in the safe mode of the test suite")
+}
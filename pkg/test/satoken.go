@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// defaultTokenExpiration is the lifetime in seconds of the ServiceAccount tokens requested by steps, the minimum of the
+// TokenRequest API.
+const defaultTokenExpiration = 600
+
+// reservedCommandEnv are the environment variables kuttl sets for commands, tokens cannot be set in them.
+var reservedCommandEnv = map[string]bool{
+	"NAMESPACE": true, "KUBECONFIG": true, "PATH": true,
+	SuiteEnv: true, TestEnv: true, StepEnv: true, NamespaceEnv: true, ClusterNameEnv: true, ArtifactsDirEnv: true, SeedEnv: true,
+}
+
+// RequestTokens requests the ServiceAccount tokens of the step with the TokenRequest API and sets them in the
+// environment of the commands of the step.  The tokens are redacted from the logs.
+func (s *Step) RequestTokens(namespace string) error {
+	if s.Step == nil || len(s.Step.ServiceAccountTokens) == 0 {
+		return nil
+	}
+	if s.Config == nil {
+		return errors.New("no cluster configuration available to request ServiceAccount tokens")
+	}
+	cfg, err := s.Config()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	env := map[string]string{}
+	for key, value := range s.CommandEnv {
+		env[key] = value
+	}
+	for _, token := range s.Step.ServiceAccountTokens {
+		if token.ServiceAccount == "" || token.Env == "" {
+			return errors.New("serviceAccount and env must be set for ServiceAccount tokens")
+		}
+		if reservedCommandEnv[token.Env] {
+			return fmt.Errorf("cannot set the token of service account %s in $%s, it is set by kuttl", token.ServiceAccount, token.Env)
+		}
+
+		saNamespace, name := namespace, token.ServiceAccount
+		if ns, n, ok := strings.Cut(token.ServiceAccount, "/"); ok {
+			saNamespace, name = ns, n
+		}
+		expiration := token.ExpirationSeconds
+		if expiration == 0 {
+			expiration = defaultTokenExpiration
+		}
+
+		request := &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{Audiences: token.Audiences, ExpirationSeconds: &expiration},
+		}
+		response, err := clientset.CoreV1().ServiceAccounts(saNamespace).CreateToken(context.TODO(), name, request, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("requesting token of service account %s/%s: %w", saNamespace, name, err)
+		}
+		testutils.AddRedactedValues(response.Status.Token)
+		env[token.Env] = response.Status.Token
+		s.Logger.Logf("requested token of service account %s/%s in $%s, it expires at %s", saNamespace, name, token.Env, response.Status.ExpirationTimestamp.Format(time.RFC3339))
+	}
+	s.CommandEnv = env
+	return nil
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestRequestTokens(t *testing.T) {
+	defer testutils.SetRedactedValues(nil)
+
+	requests := map[string]authenticationv1.TokenRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost || r.URL.Path == "/api/v1/namespaces/world/serviceaccounts/missing/token" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","message":"serviceaccounts \"missing\" not found","code":404}`))
+			return
+		}
+		request := authenticationv1.TokenRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests[r.URL.Path] = request
+		request.Status = authenticationv1.TokenRequestStatus{Token: "token-of-" + r.URL.Path, ExpirationTimestamp: metav1.Now()}
+		_ = json.NewEncoder(w).Encode(request)
+	}))
+	defer server.Close()
+
+	step := &Step{
+		Logger:     testutils.NewTestLogger(t, ""),
+		Config:     func() (*rest.Config, error) { return &rest.Config{Host: server.URL}, nil },
+		CommandEnv: map[string]string{"KUTTL_EVENT_JOURNAL": "journal.jsonl"},
+		Step: &harness.TestStep{ServiceAccountTokens: []harness.ServiceAccountToken{
+			{ServiceAccount: "operator", Env: "OPERATOR_TOKEN"},
+			{ServiceAccount: "kube-system/admin", Env: "ADMIN_TOKEN", Audiences: []string{"api"}, ExpirationSeconds: 3600},
+		}},
+	}
+
+	require.NoError(t, step.RequestTokens(testNamespace))
+	assert.Equal(t, map[string]string{
+		"KUTTL_EVENT_JOURNAL": "journal.jsonl",
+		"OPERATOR_TOKEN":      "token-of-/api/v1/namespaces/world/serviceaccounts/operator/token",
+		"ADMIN_TOKEN":         "token-of-/api/v1/namespaces/kube-system/serviceaccounts/admin/token",
+	}, step.CommandEnv)
+	assert.Equal(t, int64(600), *requests["/api/v1/namespaces/world/serviceaccounts/operator/token"].Spec.ExpirationSeconds)
+	admin := requests["/api/v1/namespaces/kube-system/serviceaccounts/admin/token"].Spec
+	assert.Equal(t, []string{"api"}, admin.Audiences)
+	assert.Equal(t, int64(3600), *admin.ExpirationSeconds)
+	// the tokens are redacted from the logs
+	assert.Equal(t, "[REDACTED]", testutils.Redact(step.CommandEnv["OPERATOR_TOKEN"]))
+
+	step.Step.ServiceAccountTokens = []harness.ServiceAccountToken{{ServiceAccount: "missing", Env: "TOKEN"}}
+	assert.EqualError(t, step.RequestTokens(testNamespace), `requesting token of service account world/missing: serviceaccounts "missing" not found`)
+
+	step.Step.ServiceAccountTokens = []harness.ServiceAccountToken{{ServiceAccount: "operator", Env: "KUBECONFIG"}}
+	assert.EqualError(t, step.RequestTokens(testNamespace), "cannot set the token of service account operator in $KUBECONFIG, it is set by kuttl")
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"context"
+	"os"
+
+	"github.com/kudobuilder/kuttl/pkg/secrets"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// resolveSecrets resolves the secrets of the test suite.  They are set in the environment of the harness, which
+// commands inherit, and are redacted from the logs of the tests.
+func (h *Harness) resolveSecrets() error {
+	if len(h.TestSuite.Secrets) == 0 {
+		return nil
+	}
+
+	values, err := secrets.Resolve(context.TODO(), h.TestSuite.Secrets)
+	if err != nil {
+		return err
+	}
+
+	redacted := make([]string, 0, len(values))
+	for name, value := range values {
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+		redacted = append(redacted, value)
+	}
+	testutils.SetRedactedValues(redacted)
+	h.secrets = values
+	h.T.Logf("resolved %d secrets", len(values))
+	return nil
+}
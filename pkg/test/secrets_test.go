@@ -0,0 +1,44 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/secrets"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+type staticSecret string
+
+func (s staticSecret) Resolve(context.Context, string, string) (string, error) {
+	return string(s), nil
+}
+
+func TestResolveSecrets(t *testing.T) {
+	defer func(previous map[string]secrets.Provider) { secrets.Providers = previous }(secrets.Providers)
+	secrets.Providers = map[string]secrets.Provider{"static": staticSecret("hunter2")}
+	defer testutils.SetRedactedValues(nil)
+	// restores the environment
+	t.Setenv("DB_PASSWORD", "")
+
+	h := Harness{
+		T: t,
+		TestSuite: harness.TestSuite{
+			Secrets:     []harness.Secret{{Name: "DB_PASSWORD", Provider: "static", Ref: "ci/db"}},
+			JsonnetVars: map[string]string{"env": "ci"},
+		},
+	}
+	require.NoError(t, h.resolveSecrets())
+
+	assert.Equal(t, "hunter2", os.Getenv("DB_PASSWORD"))
+	assert.Equal(t, map[string]string{"env": "ci", "DB_PASSWORD": "hunter2"}, h.jsonnetVars())
+	assert.Equal(t, "connecting with [REDACTED]", testutils.Redact("connecting with hunter2"))
+
+	h.TestSuite.Secrets[0].Provider = "keychain"
+	assert.EqualError(t, h.resolveSecrets(), `secret DB_PASSWORD: unknown provider "keychain", must be one of static`)
+}
@@ -0,0 +1,218 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/env"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// An AssertSequence is a loaded harness.AssertSequence, it contains the expected objects in the order they must be
+// observed.
+type AssertSequence struct {
+	Name     string
+	Expected []client.Object
+}
+
+// loadAssertSequences loads the expected objects of the sequences, files are relative to the test step directory.
+func (s *Step) loadAssertSequences(sequences []harness.AssertSequence) ([]AssertSequence, error) {
+	var loaded []AssertSequence
+
+	for i, sequence := range sequences {
+		name := sequence.Name
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		expected := []client.Object{}
+		for _, file := range sequence.Files {
+			exFile := env.Expand(file)
+			objs, err := objectsFromPath(exFile, s.Dir, s.jsonnetVars())
+			if err != nil {
+				return nil, fmt.Errorf("sequence %s path %s: %w", name, exFile, err)
+			}
+			expected = append(expected, objs...)
+		}
+		if len(expected) == 0 {
+			return nil, fmt.Errorf("sequence %s has no expected objects", name)
+		}
+		loaded = append(loaded, AssertSequence{Name: name, Expected: expected})
+	}
+
+	return loaded, nil
+}
+
+// observedSequence is the progress of a sequence, the expected objects before next were observed in order.
+type observedSequence struct {
+	name     string
+	expected []client.Object
+	contents []map[string]interface{}
+	next     int
+	// last is the last observed object of the kind and name of the next expected object.
+	last *unstructured.Unstructured
+}
+
+// sequenceWatch watches the kinds of the expected objects of the sequences of a step and observes them in the order
+// the watch events are received.  The events of different kinds are received in the order of their watches, which is
+// the order they happened in at the API server for events which are not close together.
+type sequenceWatch struct {
+	suppressions *Suppressions
+	cancel       context.CancelFunc
+
+	lock      sync.Mutex
+	sequences []*observedSequence
+	watches   []watch.Interface
+	wg        sync.WaitGroup
+}
+
+// watchSequences starts watching the kinds of the expected objects of the sequences of the step, it returns nil if the
+// step has no sequences.  The watches must be started before the step changes anything, so that the states the
+// objects are in at first are observed.
+func (s *Step) watchSequences(namespace string) (*sequenceWatch, error) {
+	if len(s.Sequences) == 0 {
+		return nil, nil
+	}
+	cl, err := s.Client(false)
+	if err != nil {
+		return nil, err
+	}
+	watchClient, ok := cl.(client.WithWatch)
+	if !ok {
+		return nil, errors.New("the client of the step cannot watch objects to observe its sequences")
+	}
+	dClient, err := s.DiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+
+	type watched struct {
+		gvk       schema.GroupVersionKind
+		namespace string
+	}
+	kinds := map[watched]bool{}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &sequenceWatch{suppressions: s.Suppressions, cancel: cancel}
+	for _, sequence := range s.Sequences {
+		observed := &observedSequence{name: sequence.Name, expected: sequence.Expected}
+		for _, expected := range sequence.Expected {
+			// the namespace is set on a copy, the expected object is described by its source
+			copied := expected.DeepCopyObject()
+			_, ns, err := testutils.Namespaced(dClient, copied, namespace)
+			if err != nil {
+				w.stop()
+				return nil, fmt.Errorf("sequence %s: %w", sequence.Name, err)
+			}
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(copied)
+			if err != nil {
+				w.stop()
+				return nil, fmt.Errorf("sequence %s: %w", sequence.Name, err)
+			}
+			observed.contents = append(observed.contents, content)
+			kinds[watched{gvk: expected.GetObjectKind().GroupVersionKind(), namespace: ns}] = true
+		}
+		w.sequences = append(w.sequences, observed)
+	}
+
+	for kind := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(kind.gvk.GroupVersion().WithKind(kind.gvk.Kind + "List"))
+		watcher, err := watchClient.Watch(ctx, list, client.InNamespace(kind.namespace))
+		if err != nil {
+			w.stop()
+			return nil, fmt.Errorf("watching %s to observe the sequences of the step: %w", kind.gvk.Kind, err)
+		}
+		w.watches = append(w.watches, watcher)
+		w.wg.Add(1)
+		go w.run(kind.gvk, watcher)
+	}
+	return w, nil
+}
+
+// run observes the objects of the events of a watch until it is stopped.
+func (w *sequenceWatch) run(gvk schema.GroupVersionKind, watcher watch.Interface) {
+	defer w.wg.Done()
+
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error || event.Type == watch.Bookmark {
+			continue
+		}
+		obj, err := journalObject(gvk, event.Object)
+		if err != nil {
+			continue
+		}
+		w.observe(obj)
+	}
+}
+
+// observe advances the sequences whose next expected object is contained in obj.
+func (w *sequenceWatch) observe(obj *unstructured.Unstructured) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, sequence := range w.sequences {
+		if sequence.next == len(sequence.expected) {
+			continue
+		}
+		expected := sequence.expected[sequence.next]
+		if expected.GetObjectKind().GroupVersionKind() != obj.GroupVersionKind() ||
+			expected.GetName() != "" && expected.GetName() != obj.GetName() {
+			continue
+		}
+
+		content := sequence.contents[sequence.next]
+		if testutils.IsSubset(w.suppressions.Apply(content, obj.Object), obj.Object) == nil {
+			sequence.next++
+			sequence.last = nil
+			continue
+		}
+		sequence.last = obj.DeepCopy()
+	}
+}
+
+// check returns an error for each sequence which was not observed completely yet, with the difference between its
+// next expected object and the last observed object of its kind and name.
+func (w *sequenceWatch) check() []error {
+	if w == nil {
+		return nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	testErrors := []error{}
+	for _, sequence := range w.sequences {
+		if sequence.next == len(sequence.expected) {
+			continue
+		}
+		expected := sequence.expected[sequence.next]
+		if sequence.last != nil {
+			if diff, err := testutils.PrettyDiff(expected, sequence.last); err == nil {
+				testErrors = append(testErrors, &diffError{diff: diff})
+			}
+		}
+		testErrors = append(testErrors, fmt.Errorf("sequence %s: observed %d of %d expected objects in order, waiting for %s",
+			sequence.name, sequence.next, len(sequence.expected), assertID(expected)))
+	}
+	return testErrors
+}
+
+// stop stops the watches of the sequences.
+func (w *sequenceWatch) stop() {
+	if w == nil {
+		return
+	}
+	w.cancel()
+	for _, watcher := range w.watches {
+		watcher.Stop()
+	}
+	w.wg.Wait()
+}
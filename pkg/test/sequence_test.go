@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// stateConfigMap returns a ConfigMap in the state, the expected objects of the tests are in states as well.
+func stateConfigMap(name, state string) *unstructured.Unstructured {
+	cm := testutils.NewResource("v1", "ConfigMap", name, "")
+	cm.Object["data"] = map[string]interface{}{"state": state}
+	return cm
+}
+
+func TestLoadAssertSequences(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "machine.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: machine
+data:
+  state: pending
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: machine
+data:
+  state: ready
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.yaml"), []byte{}, 0644))
+
+	step := &Step{Dir: dir}
+	sequences, err := step.loadAssertSequences([]harness.AssertSequence{{Files: []string{"machine.yaml"}}, {Name: "ready", Files: []string{"machine.yaml"}}})
+	require.NoError(t, err)
+	require.Len(t, sequences, 2)
+	assert.Equal(t, "1", sequences[0].Name)
+	assert.Equal(t, "ready", sequences[1].Name)
+	require.Len(t, sequences[0].Expected, 2)
+	assert.Equal(t, "pending", sequences[0].Expected[0].(*unstructured.Unstructured).Object["data"].(map[string]interface{})["state"])
+
+	_, err = step.loadAssertSequences([]harness.AssertSequence{{Name: "empty", Files: []string{"empty.yaml"}}})
+	assert.EqualError(t, err, "sequence empty has no expected objects")
+}
+
+func TestSequenceObserve(t *testing.T) {
+	expected := []client.Object{
+		stateConfigMap("machine", "pending"),
+		stateConfigMap("machine", "provisioning"),
+		stateConfigMap("machine", "ready"),
+	}
+	newWatch := func() *sequenceWatch {
+		observed := &observedSequence{name: "machine", expected: expected}
+		for _, obj := range expected {
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			require.NoError(t, err)
+			observed.contents = append(observed.contents, content)
+		}
+		return &sequenceWatch{sequences: []*observedSequence{observed}}
+	}
+
+	t.Run("in order", func(t *testing.T) {
+		w := newWatch()
+		for _, state := range []string{"pending", "pending", "provisioning", "ready"} {
+			w.observe(stateConfigMap("machine", state))
+		}
+		// objects of other names do not advance the sequence
+		w.observe(stateConfigMap("other", "pending"))
+		assert.Empty(t, w.check())
+	})
+
+	t.Run("skipped state", func(t *testing.T) {
+		w := newWatch()
+		for _, state := range []string{"pending", "ready"} {
+			w.observe(stateConfigMap("machine", state))
+		}
+		errs := w.check()
+		require.Len(t, errs, 2)
+		assert.IsType(t, &diffError{}, errs[0])
+		assert.Contains(t, errs[0].Error(), "-  state: provisioning\n+  state: ready")
+		assert.EqualError(t, errs[1], "sequence machine: observed 1 of 3 expected objects in order, waiting for ConfigMap:/machine")
+	})
+
+	t.Run("wrong order", func(t *testing.T) {
+		w := newWatch()
+		for _, state := range []string{"provisioning", "pending", "ready"} {
+			w.observe(stateConfigMap("machine", state))
+		}
+		errs := w.check()
+		require.Len(t, errs, 2)
+		assert.EqualError(t, errs[1], "sequence machine: observed 1 of 3 expected objects in order, waiting for ConfigMap:/machine")
+	})
+}
+
+func TestWatchSequences(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).Build()
+
+	step := &Step{
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+	w, err := step.watchSequences(testNamespace)
+	require.NoError(t, err)
+	assert.Nil(t, w)
+	assert.Nil(t, w.check())
+	w.stop()
+
+	step.Sequences = []AssertSequence{{Name: "machine", Expected: []client.Object{
+		stateConfigMap("machine", "pending"),
+		stateConfigMap("machine", "ready"),
+	}}}
+	w, err = step.watchSequences(testNamespace)
+	require.NoError(t, err)
+	defer w.stop()
+	assert.Len(t, w.check(), 1)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: testNamespace}, Data: map[string]string{"state": "pending"}}
+	require.NoError(t, cl.Create(context.TODO(), cm))
+	// the objects of other namespaces are not observed
+	require.NoError(t, cl.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "other"}, Data: map[string]string{"state": "ready"}}))
+	cm.Data["state"] = "ready"
+	require.NoError(t, cl.Update(context.TODO(), cm))
+
+	require.NoError(t, wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return len(w.check()) == 0, nil
+	}))
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// SharedKIND is a kind cluster shared by the test suites of one invocation which set shareKindCluster.  It is started
+// by the first of them and deleted after the last of them, with the settings of that suite.
+type SharedKIND struct {
+	lock     sync.Mutex
+	users    int
+	dir      string
+	context  string
+	kind     *kind
+	registry *registry
+	keep     bool
+}
+
+// NewSharedKIND returns a kind cluster shared by the given number of test suites.
+func NewSharedKIND(suites int) *SharedKIND {
+	return &SharedKIND{users: suites}
+}
+
+// sharedKIND returns the kind cluster the test suite shares with other suites, or nil if it does not share one.
+func (h *Harness) sharedKIND() *SharedKIND {
+	if h.SharedKIND == nil || !h.TestSuite.StartKIND || !h.TestSuite.ShareKINDCluster {
+		return nil
+	}
+	return h.SharedKIND
+}
+
+// start returns the configuration of the shared cluster, it is started by the harness of the first test suite.
+func (s *SharedKIND) start(h *Harness) (*rest.Config, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.kind != nil {
+		if h.TestSuite.KINDContext != s.context {
+			return nil, fmt.Errorf("the shared kind cluster has the context %s, the test suite uses %s", s.context, h.TestSuite.KINDContext)
+		}
+		h.T.Logf("reusing the kind cluster %s started by a previous test suite", s.context)
+		h.kind = s.kind
+		h.registry = s.registry
+		return clientcmd.BuildConfigFromFlags("", s.kubeconfigPath())
+	}
+
+	if s.dir == "" {
+		dir, err := os.MkdirTemp("", "kuttl-kind")
+		if err != nil {
+			return nil, err
+		}
+		s.dir = dir
+	}
+	cfg, err := h.RunKIND()
+	if err != nil {
+		return nil, err
+	}
+	s.context = h.TestSuite.KINDContext
+	s.kind = h.kind
+	s.registry = h.registry
+	return cfg, nil
+}
+
+// kubeconfigPath returns the path of the kubeconfig of the shared cluster.
+func (s *SharedKIND) kubeconfigPath() string {
+	return filepath.Join(s.dir, "kubeconfig")
+}
+
+// release marks the test suite of h as done with the cluster.  It returns true for the last suite, which deletes the
+// cluster, as well as whether the cluster is kept because a suite kept it.
+func (s *SharedKIND) release(h *Harness) (last, keep bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.users--
+	s.keep = s.keep || h.keepCluster()
+	if s.users > 0 {
+		return false, s.keep
+	}
+	h.kind = s.kind
+	h.registry = s.registry
+	return true, s.keep
+}
+
+// cleanup removes the directory of the cluster once it is deleted.
+func (s *SharedKIND) cleanup(h *Harness) {
+	if s.dir == "" {
+		return
+	}
+	if err := os.RemoveAll(s.dir); err != nil {
+		h.T.Log("error removing the directory of the shared kind cluster", err)
+	}
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestSharedKIND(t *testing.T) {
+	suite := harness.TestSuite{StartKIND: true, ShareKINDCluster: true, KINDContext: "kind"}
+	shared := NewSharedKIND(3)
+	shared.dir = t.TempDir()
+	shared.context = "kind"
+	shared.kind = &kind{context: "kind"}
+	require.NoError(t, os.WriteFile(shared.kubeconfigPath(), []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: kind
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: kind
+  context:
+    cluster: kind
+current-context: kind
+`), 0600))
+
+	first := &Harness{T: t, TestSuite: suite, SharedKIND: shared}
+	second := &Harness{T: t, TestSuite: suite, SharedKIND: shared}
+	second.TestSuite.KeepClusterOnFailure = true
+	notSharing := &Harness{T: t, TestSuite: harness.TestSuite{StartKIND: true, KINDContext: "kind"}, SharedKIND: shared}
+	assert.Nil(t, notSharing.sharedKIND())
+	assert.Equal(t, filepath.Join(shared.dir, "kubeconfig"), first.kubeconfigPath())
+
+	// the cluster started by a previous suite is reused
+	cfg, err := first.sharedKIND().start(first)
+	require.NoError(t, err)
+	assert.Equal(t, "https://127.0.0.1:6443", cfg.Host)
+	assert.Equal(t, shared.kind, first.kind)
+
+	other := &Harness{T: t, TestSuite: suite, SharedKIND: shared}
+	other.TestSuite.KINDContext = "other"
+	_, err = other.sharedKIND().start(other)
+	assert.EqualError(t, err, "the shared kind cluster has the context kind, the test suite uses other")
+
+	last, keep := shared.release(first)
+	assert.False(t, last)
+	assert.False(t, keep)
+	last, keep = shared.release(second)
+	assert.False(t, last)
+	assert.False(t, keep)
+
+	// the last suite deletes the cluster, unless a suite kept it
+	third := &Harness{T: t, TestSuite: suite, SharedKIND: shared}
+	third.TestSuite.SkipClusterDelete = true
+	last, keep = shared.release(third)
+	assert.True(t, last)
+	assert.True(t, keep)
+	assert.Equal(t, shared.kind, third.kind)
+
+	shared.cleanup(third)
+	assert.NoDirExists(t, shared.dir)
+}
+
+func TestSharedKINDStop(t *testing.T) {
+	suite := harness.TestSuite{StartKIND: true, ShareKINDCluster: true, KINDContext: "kind", SkipClusterDelete: true}
+	shared := NewSharedKIND(2)
+	shared.dir = t.TempDir()
+	shared.kind = &kind{context: "kind"}
+
+	// a suite which is not the last one removes its temp folder even if it keeps the cluster
+	first := &Harness{T: t, TestSuite: suite, SharedKIND: shared, tempPath: filepath.Join(t.TempDir(), "kuttl")}
+	require.NoError(t, os.Mkdir(first.tempPath, 0755))
+	first.Stop()
+	assert.NoDirExists(t, first.tempPath)
+	assert.DirExists(t, shared.dir)
+	assert.Equal(t, 1, shared.users)
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// defaultSnapshotKinds are the kinds of the cluster snapshot if it does not set any.
+var defaultSnapshotKinds = []metav1.TypeMeta{
+	{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition"},
+	{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+	{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+	{APIVersion: "admissionregistration.k8s.io/v1", Kind: "MutatingWebhookConfiguration"},
+	{APIVersion: "admissionregistration.k8s.io/v1", Kind: "ValidatingWebhookConfiguration"},
+	{APIVersion: "storage.k8s.io/v1", Kind: "StorageClass"},
+}
+
+// clusterSnapshot holds the compared content of the snapshotted objects by their safe mode identifier.
+type clusterSnapshot map[string]*unstructured.Unstructured
+
+// takeClusterSnapshot lists the objects of the kinds of the settings.
+func takeClusterSnapshot(cl client.Client, settings *harness.ClusterSnapshot) (clusterSnapshot, error) {
+	kinds := settings.Kinds
+	if len(kinds) == 0 {
+		kinds = defaultSnapshotKinds
+	}
+
+	snapshot := clusterSnapshot{}
+	for _, kind := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.FromAPIVersionAndKind(kind.APIVersion, kind.Kind+"List"))
+		if err := cl.List(context.TODO(), list); err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("snapshotting %s: %w", kind.Kind, err)
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			// list items may not have their kind set
+			obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(kind.APIVersion, kind.Kind))
+			snapshot[safeModeID(obj)] = snapshotContent(obj)
+		}
+	}
+	return snapshot, nil
+}
+
+// snapshotContent returns the part of obj which is compared, its metadata which the API server maintains and its
+// status are not.
+func snapshotContent(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	content := obj.DeepCopy()
+	unstructured.RemoveNestedField(content.Object, "status")
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "managedFields", "selfLink"} {
+		unstructured.RemoveNestedField(content.Object, "metadata", field)
+	}
+	return content
+}
+
+// differences returns the objects created, deleted or changed between the snapshot and a later one, except those the
+// ignore patterns match.
+func (s clusterSnapshot) differences(after clusterSnapshot, ignore []string) ([]string, error) {
+	ids := []string{}
+	for id := range s {
+		ids = append(ids, id)
+	}
+	for id := range after {
+		if _, ok := s[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	differences := []string{}
+	for _, id := range ids {
+		ignored, err := matchesAny(ignore, id)
+		if err != nil {
+			return nil, err
+		}
+		if ignored {
+			continue
+		}
+
+		before, existed := s[id]
+		current, exists := after[id]
+		switch {
+		case !existed:
+			differences = append(differences, fmt.Sprintf("%s was created", id))
+		case !exists:
+			differences = append(differences, fmt.Sprintf("%s was deleted", id))
+		case !equality.Semantic.DeepEqual(before.Object, current.Object):
+			diff, err := testutils.PrettyDiff(before, current)
+			if err != nil {
+				return nil, err
+			}
+			differences = append(differences, fmt.Sprintf("%s was changed:\n%s", id, diff))
+		}
+	}
+	return differences, nil
+}
+
+// matchesAny returns whether the identifier matches one of the patterns.
+func matchesAny(patterns []string, id string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, id)
+		if err != nil {
+			return false, fmt.Errorf("invalid cluster snapshot ignore pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// snapshotCluster takes the snapshot of the cluster before the tests, if the test suite sets a cluster snapshot.
+func (h *Harness) snapshotCluster() error {
+	if h.TestSuite.ClusterSnapshot == nil {
+		return nil
+	}
+	cl, err := h.Client(false)
+	if err != nil {
+		return err
+	}
+	h.snapshot, err = takeClusterSnapshot(cl, h.TestSuite.ClusterSnapshot)
+	return err
+}
+
+// checkClusterSnapshot returns the differences between the snapshot of the cluster before the tests and the cluster
+// after them.
+func (h *Harness) checkClusterSnapshot() ([]string, error) {
+	if h.snapshot == nil {
+		return nil, nil
+	}
+	cl, err := h.Client(false)
+	if err != nil {
+		return nil, err
+	}
+	after, err := takeClusterSnapshot(cl, h.TestSuite.ClusterSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	return h.snapshot.differences(after, h.TestSuite.ClusterSnapshot.Ignore)
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestClusterSnapshotDifferences(t *testing.T) {
+	clusterRole := func(name string, verbs ...string) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: verbs}},
+		}
+	}
+	changed := clusterRole("changed", "get")
+	relabeled := clusterRole("relabeled", "get")
+	deleted := clusterRole("deleted", "get")
+	cl := fake.NewClientBuilder().WithScheme(testutils.Scheme()).WithObjects(
+		changed, relabeled, deleted, clusterRole("unchanged", "get"),
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fast"}, Provisioner: "example.com/fast"},
+	).Build()
+
+	settings := &harness.ClusterSnapshot{
+		Kinds: []metav1.TypeMeta{
+			{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			{APIVersion: "storage.k8s.io/v1", Kind: "StorageClass"},
+		},
+		Ignore: []string{"ClusterRole.rbac.authorization.k8s.io/ignored-*"},
+	}
+	before, err := takeClusterSnapshot(cl, settings)
+	require.NoError(t, err)
+	assert.Len(t, before, 5)
+
+	ctx := context.TODO()
+	changed.Rules[0].Verbs = []string{"get", "delete"}
+	require.NoError(t, cl.Update(ctx, changed))
+	// updates only changing the metadata the API server maintains are not differences
+	require.NoError(t, cl.Update(ctx, relabeled))
+	require.NoError(t, cl.Delete(ctx, deleted))
+	require.NoError(t, cl.Create(ctx, clusterRole("created", "get")))
+	require.NoError(t, cl.Create(ctx, clusterRole("ignored-leftover", "get")))
+
+	after, err := takeClusterSnapshot(cl, settings)
+	require.NoError(t, err)
+	differences, err := before.differences(after, settings.Ignore)
+	require.NoError(t, err)
+	require.Len(t, differences, 3)
+	assert.Contains(t, differences[0], "ClusterRole.rbac.authorization.k8s.io/changed was changed:")
+	assert.Contains(t, differences[0], "+  - delete")
+	assert.Equal(t, "ClusterRole.rbac.authorization.k8s.io/created was created", differences[1])
+	assert.Equal(t, "ClusterRole.rbac.authorization.k8s.io/deleted was deleted", differences[2])
+
+	_, err = before.differences(after, []string{"["})
+	assert.ErrorContains(t, err, `invalid cluster snapshot ignore pattern "["`)
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// stablePollInterval is the interval the objects are read at during their stability window.
+var stablePollInterval = time.Second
+
+// Fields compared by the stable-field annotation.
+const (
+	stableResourceVersion = "resourceVersion"
+	stableGeneration      = "generation"
+)
+
+// stability is the window an object must not change during once the asserts of its step pass.
+type stability struct {
+	seconds int
+	field   string
+}
+
+// expectedStability returns a copy of expected without the stable-for and stable-field annotations, as well as the
+// stability window of the object.  If the annotations are not set, expected is returned unmodified without a window.
+func expectedStability(expected runtime.Object) (runtime.Object, *stability, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	annotations := m.GetAnnotations()
+	value, ok := annotations[harness.StableForAnnotation]
+	field, fieldSet := annotations[harness.StableFieldAnnotation]
+	if !ok {
+		if fieldSet {
+			return nil, nil, fmt.Errorf("annotation %s can only be used with %s", harness.StableFieldAnnotation, harness.StableForAnnotation)
+		}
+		return expected, nil, nil
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return nil, nil, fmt.Errorf("annotation %s: %q is not a positive number of seconds", harness.StableForAnnotation, value)
+	}
+	if m.GetName() == "" {
+		return nil, nil, fmt.Errorf("annotation %s can only be used on objects with a name", harness.StableForAnnotation)
+	}
+	if !fieldSet {
+		field = stableResourceVersion
+	}
+	if field != stableResourceVersion && field != stableGeneration {
+		return nil, nil, fmt.Errorf("annotation %s: %q must be %s or %s", harness.StableFieldAnnotation, field, stableResourceVersion, stableGeneration)
+	}
+
+	copied, err := withoutAnnotation(expected, harness.StableForAnnotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fieldSet {
+		if copied, err = withoutAnnotation(copied, harness.StableFieldAnnotation); err != nil {
+			return nil, nil, err
+		}
+	}
+	return copied, &stability{seconds: seconds, field: field}, nil
+}
+
+// stableValue returns the compared field of the object.
+func stableValue(obj *unstructured.Unstructured, field string) string {
+	if field == stableGeneration {
+		return strconv.FormatInt(obj.GetGeneration(), 10)
+	}
+	return obj.GetResourceVersion()
+}
+
+// stableObject is an object being checked during its stability window.
+type stableObject struct {
+	id      string
+	actual  *unstructured.Unstructured
+	window  *stability
+	initial string
+	done    bool
+}
+
+// CheckStability checks that the expected objects with a stability window do not change during it, it must be called
+// once the asserts of the step pass.  An object fails as soon as it changes or is deleted.
+func (s *Step) CheckStability(namespace string) []error {
+	cl, err := s.Client(false)
+	if err != nil {
+		return []error{err}
+	}
+	dClient, err := s.DiscoveryClient()
+	if err != nil {
+		return []error{err}
+	}
+
+	objects := []*stableObject{}
+	longest := 0
+	for _, expected := range s.Asserts {
+		_, window, err := expectedStability(expected)
+		if err != nil {
+			return []error{err}
+		}
+		if window == nil {
+			continue
+		}
+
+		name, ns, err := testutils.Namespaced(dClient, expected, namespace)
+		if err != nil {
+			return []error{err}
+		}
+		actual := &unstructured.Unstructured{}
+		actual.SetGroupVersionKind(expected.GetObjectKind().GroupVersionKind())
+		if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: name}, actual); err != nil {
+			return []error{err}
+		}
+		objects = append(objects, &stableObject{
+			id:      testutils.ResourceID(actual),
+			actual:  actual,
+			window:  window,
+			initial: stableValue(actual, window.field),
+		})
+		if window.seconds > longest {
+			longest = window.seconds
+		}
+	}
+	if len(objects) == 0 {
+		return []error{}
+	}
+
+	s.Logger.Logf("checking that %d object(s) do not change for up to %ds", len(objects), longest)
+	testErrors := []error{}
+	start := time.Now()
+	for remaining := len(objects); remaining > 0; {
+		time.Sleep(stablePollInterval)
+		elapsed := time.Since(start)
+
+		for _, obj := range objects {
+			if obj.done {
+				continue
+			}
+			actual := &unstructured.Unstructured{}
+			actual.SetGroupVersionKind(obj.actual.GroupVersionKind())
+			err := cl.Get(context.TODO(), client.ObjectKeyFromObject(obj.actual), actual)
+			switch {
+			case k8serrors.IsNotFound(err):
+				err = fmt.Errorf("%s was deleted %s into its stability window of %ds", obj.id, elapsed.Round(time.Second), obj.window.seconds)
+			case err == nil && stableValue(actual, obj.window.field) != obj.initial:
+				err = fmt.Errorf("%s changed %s into its stability window of %ds: %s %s -> %s", obj.id,
+					elapsed.Round(time.Second), obj.window.seconds, obj.window.field, obj.initial, stableValue(actual, obj.window.field))
+			}
+			if err != nil {
+				testErrors = append(testErrors, err)
+				obj.done = true
+				remaining--
+				continue
+			}
+			if elapsed >= time.Duration(obj.window.seconds)*time.Second {
+				obj.done = true
+				remaining--
+			}
+		}
+	}
+	return testErrors
+}
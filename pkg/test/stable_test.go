@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestExpectedStability(t *testing.T) {
+	stable := testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.StableForAnnotation, "60")
+
+	stripped, window, err := expectedStability(stable)
+	require.NoError(t, err)
+	assert.Equal(t, &stability{seconds: 60, field: "resourceVersion"}, window)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+	// the original object is not modified
+	assert.Contains(t, stable.GetAnnotations(), harness.StableForAnnotation)
+
+	stripped, window, err = expectedStability(testutils.SetAnnotation(stable.DeepCopy(), harness.StableFieldAnnotation, "generation"))
+	require.NoError(t, err)
+	assert.Equal(t, &stability{seconds: 60, field: "generation"}, window)
+	assert.Nil(t, stripped.(*unstructured.Unstructured).GetAnnotations())
+
+	unannotated := testutils.NewPod("hello", "")
+	stripped, window, err = expectedStability(unannotated)
+	require.NoError(t, err)
+	assert.Nil(t, window)
+	assert.Equal(t, unannotated, stripped)
+
+	tests := []struct {
+		name     string
+		expected *unstructured.Unstructured
+		err      string
+	}{
+		{
+			name:     "invalid window",
+			expected: testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.StableForAnnotation, "1m"),
+			err:      `annotation kuttl.dev/stable-for: "1m" is not a positive number of seconds`,
+		},
+		{
+			name:     "no name",
+			expected: testutils.SetAnnotation(testutils.NewPod("", ""), harness.StableForAnnotation, "60"),
+			err:      "annotation kuttl.dev/stable-for can only be used on objects with a name",
+		},
+		{
+			name:     "invalid field",
+			expected: testutils.SetAnnotation(stable.DeepCopy(), harness.StableFieldAnnotation, "status"),
+			err:      `annotation kuttl.dev/stable-field: "status" must be resourceVersion or generation`,
+		},
+		{
+			name:     "field without window",
+			expected: testutils.SetAnnotation(testutils.NewPod("hello", ""), harness.StableFieldAnnotation, "generation"),
+			err:      "annotation kuttl.dev/stable-field can only be used with kuttl.dev/stable-for",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := expectedStability(tt.expected)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+// rewritingClient increments the generation of the pod it writes on every Get, like an operator hot-looping on it.
+type rewritingClient struct {
+	client.Client
+	rewrite string
+	gets    atomic.Int32
+}
+
+func (c *rewritingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if key.Name == c.rewrite && c.gets.Add(1) > 1 {
+		pod := &unstructured.Unstructured{}
+		pod.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+		if err := c.Client.Get(ctx, key, pod); err != nil {
+			return err
+		}
+		pod.SetLabels(map[string]string{"rewritten": "true"})
+		if err := c.Client.Update(ctx, pod); err != nil {
+			return err
+		}
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func TestCheckStability(t *testing.T) {
+	defer func(previous time.Duration) { stablePollInterval = previous }(stablePollInterval)
+	stablePollInterval = 100 * time.Millisecond
+
+	cl := &rewritingClient{
+		Client:  fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(testutils.NewPod("stable", testNamespace), testutils.NewPod("hot-loop", testNamespace)).Build(),
+		rewrite: "hot-loop",
+	}
+	step := Step{
+		Logger:          testutils.NewTestLogger(t, ""),
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+
+	step.Asserts = []client.Object{
+		testutils.SetAnnotation(testutils.NewPod("stable", ""), harness.StableForAnnotation, "1"),
+		testutils.NewPod("hot-loop", ""),
+	}
+	assert.Empty(t, step.CheckStability(testNamespace))
+
+	step.Asserts = []client.Object{
+		testutils.SetAnnotation(testutils.NewPod("stable", ""), harness.StableForAnnotation, "1"),
+		testutils.SetAnnotation(testutils.NewPod("hot-loop", ""), harness.StableForAnnotation, "1"),
+	}
+	start := time.Now()
+	errs := step.CheckStability(testNamespace)
+	require.Len(t, errs, 1)
+	assert.Regexp(t, `^Pod:world/hot-loop changed 0s into its stability window of 1s: resourceVersion [0-9]+ -> [0-9]+$`, errs[0].Error())
+	// the other objects are still checked for their whole window
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+
+	// the generation of the pod is not changed by the fake client
+	step.Asserts = []client.Object{
+		testutils.SetAnnotation(testutils.SetAnnotation(testutils.NewPod("hot-loop", ""), harness.StableForAnnotation, "1"), harness.StableFieldAnnotation, "generation"),
+	}
+	assert.Empty(t, step.CheckStability(testNamespace))
+
+	// the annotations are not compared by the asserts
+	assert.Empty(t, step.CheckResource(step.Asserts[0], testNamespace))
+}
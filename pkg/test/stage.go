@@ -0,0 +1,15 @@
+package test
+
+// inStage returns whether stages, the stages of a step or of its asserts, contain the stage.  Steps and asserts without
+// stages are in every stage.
+func inStage(stages []string, stage string) bool {
+	if len(stages) == 0 {
+		return true
+	}
+	for _, s := range stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
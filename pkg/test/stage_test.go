@@ -0,0 +1,93 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestInStage(t *testing.T) {
+	assert.True(t, inStage(nil, "smoke"))
+	assert.True(t, inStage([]string{"smoke", "full"}, "smoke"))
+	assert.False(t, inStage([]string{"full"}, "smoke"))
+}
+
+func TestRunStage(t *testing.T) {
+	for _, test := range []struct {
+		stage   string
+		applied map[string]bool
+	}{
+		{
+			stage:   "smoke",
+			applied: map[string]bool{"zero": true, "one": false, "two": true},
+		},
+		{
+			stage:   "full",
+			applied: map[string]bool{"zero": true, "one": true, "two": true},
+		},
+	} {
+		test := test
+
+		t.Run(test.stage, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			steps := []*Step{
+				{Name: "zero", Index: 0, Apply: []client.Object{testutils.NewPod("zero", "")}},
+				{
+					Name:  "one",
+					Index: 1,
+					Step:  &harness.TestStep{Stages: []string{"full"}},
+					Apply: []client.Object{testutils.NewPod("one", "")},
+				},
+				{
+					Name:   "two",
+					Index:  2,
+					Step:   &harness.TestStep{Stages: []string{"smoke", "full"}},
+					Assert: &harness.TestAssert{Stages: []string{"full"}},
+					Apply:  []client.Object{testutils.NewPod("two", "")},
+					// the pod of step one only exists in the full stage
+					Asserts: []client.Object{testutils.NewPod("one", "")},
+					Timeout: 1,
+				},
+			}
+			c := &Case{
+				Name:               "stage",
+				Steps:              steps,
+				PreferredNamespace: testNamespace,
+				SkipDelete:         true,
+				Suppress:           []string{"events"},
+				Stage:              test.stage,
+				Timeout:            1,
+				Logger:             testutils.NewTestLogger(t, "stage"),
+				Client:             func(bool) (client.Client, error) { return cl, nil },
+				DiscoveryClient:    func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			tc := report.NewCase(c.Name)
+			c.Run(t, tc)
+			assert.Nil(t, tc.Failure)
+
+			for name, applied := range test.applied {
+				actual := &unstructured.Unstructured{}
+				actual.SetAPIVersion("v1")
+				actual.SetKind("Pod")
+				err := cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: name}, actual)
+				if applied {
+					assert.NoError(t, err, name)
+				} else {
+					assert.True(t, k8serrors.IsNotFound(err), name)
+				}
+			}
+		})
+	}
+}
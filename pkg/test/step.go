@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -14,11 +15,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
@@ -30,7 +33,7 @@ import (
 
 // fileNameRegex contains two capturing groups to determine whether a file has special
 // meaning (ex. assert) or contains an appliable object, and extra name elements.
-var fileNameRegex = regexp.MustCompile(`^(?:\d+-)?([^-\.]+)(-[^\.]+)?(?:\.yaml)?$`)
+var fileNameRegex = regexp.MustCompile(`^(?:\d+-)?([^-\.]+)(-[^\.]+)?(?:\.(?:yaml|json|jsonnet))?$`)
 
 // A Step contains the name of the test step, its index in the test,
 // and all of the test step's settings (including objects to apply and assert on).
@@ -41,21 +44,89 @@ type Step struct {
 
 	Dir           string
 	TestRunLabels labels.Set
+	// AllowHelperPodTraffic creates a NetworkPolicy allowing the traffic of helper pods before the commands of the step
+	// run in a namespace with NetworkPolicies.
+	AllowHelperPodTraffic bool
+	// FailOnDeprecatedAPIs fails the step when the API server warns that an applied object uses a deprecated API,
+	// unless the warning assertions of the step override it.
+	FailOnDeprecatedAPIs bool
+	// Hermetic runs the commands of the step with the built-in implementations of commands where possible.
+	Hermetic bool
+	// ProbeImage is the image of the helper pods running app probes, the kuttl image of the running version if empty.
+	ProbeImage string
+	// HelperPods are the scheduling constraints of the helper pods of the step, they may be nil.
+	HelperPods *harness.HelperPodScheduling
+	// Suppressions are the known differences between expected and actual objects which are ignored, they may be nil.
+	Suppressions *Suppressions
+	// SafeMode refuses the deletes, prunes, restarts and evictions of objects outside SafeNamespace and node changes
+	// unless it allows them, it may be nil.
+	SafeMode *harness.SafeMode
+	// SafeNamespace is the namespace kuttl created for the test, it is empty if the namespace was supplied by the user.
+	SafeNamespace string
+	// DetectDrift reports the field managers of the asserted objects which keep flapping between match and mismatch.
+	DetectDrift bool
+	// IPFamily is the IP family of the cluster (ipv4, ipv6 or dual), mock services served on the host listen on the
+	// loopback address of the family.
+	IPFamily string
+	// Stage is the stage the step runs in, its asserts are not checked if they are not in the stage.
+	Stage string
+	// Capture records the output of the commands of the step for the report of the test, it may be nil.
+	Capture *testutils.CommandCapture
 
 	Step   *harness.TestStep
 	Assert *harness.TestAssert
+	// Metadata describes the test case, it is declared by a TestMetadata in a file of one of its steps.
+	Metadata *harness.TestMetadata
 
 	Asserts []client.Object
 	Apply   []client.Object
 	Errors  []client.Object
+	// PreviouslyApplied are the objects applied by the earlier steps of the test case, they are pruned if the step
+	// prunes.
+	PreviouslyApplied []client.Object
+	// Custom are the objects of custom step kinds, they are run by the StepHandlers registered for their kind.
+	Custom       []client.Object
+	StepHandlers map[string]StepHandler
+
+	// AnyOf and AllOf are the assertion groups of the TestAssert of the step.
+	AnyOf []AssertGroup
+	AllOf []AssertGroup
+	// Sequences are the sequences of expected objects of the TestAssert of the step.
+	Sequences []AssertSequence
 
 	Timeout int
 
 	Kubeconfig      string
 	Client          func(forceNew bool) (client.Client, error)
 	DiscoveryClient func() (discovery.DiscoveryInterface, error)
+	// Config returns the cluster configuration, if set the warnings of the API server are recorded when the objects of
+	// the step are applied.
+	Config func() (*rest.Config, error)
 
 	Logger testutils.Logger
+	// CommandEnv are environment variables of the commands of the step in addition to the ones set by kuttl (ex. the
+	// path of the event journal of the test).
+	CommandEnv map[string]string
+	// Context is the test context of the step, it is set in the environment of its commands and in the external
+	// variables of its Jsonnet files.
+	Context TestContext
+	// RunContext is cancelled when the run is aborted, the commands of the step are then killed and its asserts are
+	// no longer retried.  It may be nil.
+	RunContext context.Context
+	// JsonnetVars are the external variables of the Jsonnet files of the test suite, the variables of Context override
+	// them.
+	JsonnetVars map[string]string
+
+	// warnings are the warnings returned by the API server when the objects of the step were applied.
+	warnings []apiWarning
+	// retries is the number of times the asserts were re-checked before they passed or timed out.
+	retries int
+	// unsatisfied are the expected objects which did not match in the last check of the asserts.
+	unsatisfied []string
+	// drifts are observed with DetectDrift while the asserts are retried, by the ID of the asserted object.
+	drifts map[string]*drift
+	// timings measure the phases of the step if the timings of the run are collected, they may be nil.
+	timings *stepTimings
 }
 
 // Clean deletes all resources defined in the Apply list.
@@ -97,6 +168,10 @@ func (s *Step) DeleteExisting(namespace string) error {
 	}
 
 	toDelete := []client.Object{}
+	// the DeleteAllOf requests of the references setting all and the objects they delete, the requests are sent once
+	// all objects to delete are checked
+	deleteAll := []func() error{}
+	deletedAll := map[client.Object]bool{}
 
 	if s.Step == nil {
 		return nil
@@ -104,6 +179,9 @@ func (s *Step) DeleteExisting(namespace string) error {
 
 	for _, ref := range s.Step.Delete {
 		gvk := ref.GroupVersionKind()
+		if ref.Name != "" && (ref.All || ref.FieldSelector != "") {
+			return fmt.Errorf("deleting %s %s: all and fieldSelector cannot be used with a name", gvk.Kind, ref.Name)
+		}
 
 		obj := testutils.NewResource(gvk.GroupVersion().String(), gvk.Kind, ref.Name, "")
 
@@ -122,13 +200,25 @@ func (s *Step) DeleteExisting(namespace string) error {
 			u.SetGroupVersionKind(gvk)
 
 			listOptions := []client.ListOption{}
+			deleteAllOptions := []client.DeleteAllOfOption{}
 
 			if ref.Labels != nil {
 				listOptions = append(listOptions, client.MatchingLabels(ref.Labels))
+				deleteAllOptions = append(deleteAllOptions, client.MatchingLabels(ref.Labels))
+			}
+
+			if ref.FieldSelector != "" {
+				selector, err := fields.ParseSelector(ref.FieldSelector)
+				if err != nil {
+					return fmt.Errorf("invalid field selector of %s: %w", gvk.Kind, err)
+				}
+				listOptions = append(listOptions, client.MatchingFieldsSelector{Selector: selector})
+				deleteAllOptions = append(deleteAllOptions, client.MatchingFieldsSelector{Selector: selector})
 			}
 
 			if objNs != "" {
 				listOptions = append(listOptions, client.InNamespace(objNs))
+				deleteAllOptions = append(deleteAllOptions, client.InNamespace(objNs))
 			}
 
 			err := cl.List(context.TODO(), u, listOptions...)
@@ -138,6 +228,20 @@ func (s *Step) DeleteExisting(namespace string) error {
 
 			for index := range u.Items {
 				toDelete = append(toDelete, &u.Items[index])
+				if ref.All {
+					deletedAll[&u.Items[index]] = true
+				}
+			}
+
+			if ref.All && len(u.Items) > 0 {
+				all := &unstructured.Unstructured{}
+				all.SetGroupVersionKind(gvk)
+				deleteAll = append(deleteAll, func() error {
+					if err := cl.DeleteAllOf(context.TODO(), all, deleteAllOptions...); err != nil {
+						return fmt.Errorf("deleting all matching %s: %w", gvk.Kind, err)
+					}
+					return nil
+				})
 			}
 		} else {
 			// Otherwise just append the object specified.
@@ -146,6 +250,21 @@ func (s *Step) DeleteExisting(namespace string) error {
 	}
 
 	for _, obj := range toDelete {
+		if err := s.checkSafe("delete", obj); err != nil {
+			return err
+		}
+	}
+
+	for _, deleteAllOf := range deleteAll {
+		if err := deleteAllOf(); err != nil {
+			return err
+		}
+	}
+
+	for _, obj := range toDelete {
+		if deletedAll[obj] {
+			continue
+		}
 		del := &unstructured.Unstructured{}
 		del.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
 		del.SetName(obj.GetName())
@@ -158,7 +277,7 @@ func (s *Step) DeleteExisting(namespace string) error {
 	}
 
 	// Wait for resources to be deleted.
-	return wait.PollImmediate(100*time.Millisecond, time.Duration(s.GetTimeout())*time.Second, func() (done bool, err error) {
+	err = wait.PollImmediate(100*time.Millisecond, time.Duration(s.GetTimeout())*time.Second, func() (done bool, err error) {
 		for _, obj := range toDelete {
 			actual := &unstructured.Unstructured{}
 			actual.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
@@ -170,11 +289,23 @@ func (s *Step) DeleteExisting(namespace string) error {
 
 		return true, nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// the kinds of deleted CRDs are forgotten by the client
+	crds := []string{}
+	for _, obj := range toDelete {
+		if isCRD(obj) {
+			crds = append(crds, obj.GetName())
+		}
+	}
+	return s.refreshCRDs(crds, true)
 }
 
 // Create applies all resources defined in the Apply list.
 func (s *Step) Create(test *testing.T, namespace string) []error {
-	cl, err := s.Client(true)
+	cl, recorder, err := s.applyClient()
 	if err != nil {
 		return []error{err}
 	}
@@ -185,8 +316,24 @@ func (s *Step) Create(test *testing.T, namespace string) []error {
 	}
 
 	errors := []error{}
+	// crds are the CRDs applied since the client was created, the client is refreshed before the next custom resource
+	crds := []string{}
+	refresh := func() error {
+		defer func() { crds = crds[:0] }()
+		if err := s.refreshCRDs(crds, false); err != nil {
+			return err
+		}
+		cl, recorder, err = s.applyClient()
+		return err
+	}
 
 	for _, obj := range s.Apply {
+		if len(crds) > 0 && !isCRD(obj) {
+			if err := refresh(); err != nil {
+				return append(errors, err)
+			}
+		}
+
 		_, _, err := testutils.Namespaced(dClient, obj, namespace)
 		if err != nil {
 			errors = append(errors, err)
@@ -199,7 +346,16 @@ func (s *Step) Create(test *testing.T, namespace string) []error {
 			defer cancel()
 		}
 
-		if updated, err := testutils.CreateOrUpdate(ctx, cl, obj, true); err != nil {
+		updated, err := s.apply(ctx, cl, obj)
+		if meta.IsNoMatchError(err) {
+			// the CRD of obj was installed after the client was created, ex. by a command of the step
+			if cl, recorder, err = s.applyClient(); err != nil {
+				return append(errors, err)
+			}
+			updated, err = s.apply(ctx, cl, obj)
+		}
+		s.recordWarnings(obj, recorder)
+		if err != nil {
 			errors = append(errors, err)
 		} else {
 			// if the object was created, register cleanup
@@ -216,12 +372,39 @@ func (s *Step) Create(test *testing.T, namespace string) []error {
 				action = "updated"
 			}
 			s.Logger.Log(testutils.ResourceID(obj), action)
+			if isCRD(obj) {
+				crds = append(crds, obj.GetName())
+			}
+		}
+	}
+	if len(crds) > 0 {
+		if err := refresh(); err != nil {
+			errors = append(errors, err)
 		}
 	}
 
 	return errors
 }
 
+// apply creates or updates obj, with server-side apply if the step sets it, and then writes its status if the step
+// updates the status.  It returns true if obj was updated.
+func (s *Step) apply(ctx context.Context, cl client.Client, obj client.Object) (updated bool, err error) {
+	if s.Step != nil && s.Step.ServerSideApply != nil {
+		updated, err = testutils.ServerSideApply(ctx, cl, obj, s.Step.ServerSideApply.FieldManager, s.Step.ServerSideApply.Force)
+	} else {
+		updated, err = testutils.CreateOrUpdate(ctx, cl, obj, true)
+	}
+	if err != nil || s.Step == nil || !s.Step.UpdateStatus {
+		return updated, err
+	}
+	return updated, testutils.UpdateStatus(ctx, cl, obj)
+}
+
+// subsetOptions returns how the asserted and the error objects of the step are compared with the actual objects.
+func (s *Step) subsetOptions() testutils.SubsetOptions {
+	return testutils.SubsetOptions{StrictConditions: s.Assert != nil && s.Assert.StrictConditions}
+}
+
 // GetTimeout gets the timeout defined for the test step.
 func (s *Step) GetTimeout() int {
 	timeout := s.Timeout
@@ -251,6 +434,95 @@ func list(cl client.Client, gvk schema.GroupVersionKind, namespace string, label
 	return list.Items, nil
 }
 
+// expectation is what the kuttl.dev annotations of an expected object expect from the matching objects besides their
+// contents.
+type expectation struct {
+	owners      []owner
+	count       int
+	subresource string
+	parsedKeys  map[string]string
+	lifecycle   lifecycle
+	rollout     bool
+	readiness   ingressReadiness
+}
+
+// stripExpectations returns a copy of expected without the kuttl.dev annotations, which are not part of the contents
+// of the matching objects, as well as what they expect.  Asserts and errors strip the same annotations, so that an
+// annotation of an error is not compared with the contents of the objects.  The timeout and the stability of the
+// object are looked up by the step itself.
+func stripExpectations(dClient discovery.DiscoveryInterface, expected runtime.Object, source string) (runtime.Object, expectation, error) {
+	exp := expectation{}
+
+	expected, owners, err := ownedBy(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+	exp.owners = owners
+
+	expected, exp.count, err = expectedCount(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	expected, exp.subresource, err = expectedSubresource(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	expected, _, err = expectedTimeout(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	expected, _, err = expectedStability(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	expected, exp.parsedKeys, err = expectedParsedData(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	expected, exp.lifecycle, err = expectedLifecycle(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	expected, exp.rollout, err = expectedRollout(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	expected, exp.readiness, err = expectedIngress(expected)
+	if err != nil {
+		return nil, exp, err
+	}
+
+	versioned, err := expectedVersion(dClient, expected)
+	if err != nil {
+		return nil, exp, fmt.Errorf("resource %s%s: %w", testutils.ResourceID(expected), source, err)
+	}
+	return versioned, exp, nil
+}
+
+// check returns an error if actual, whose contents match, does not meet the other expectations.
+func (e expectation) check(cl client.Client, actual *unstructured.Unstructured) error {
+	if err := checkOwners(cl, actual, e.owners); err != nil {
+		return err
+	}
+	if err := e.lifecycle.check(actual); err != nil {
+		return err
+	}
+	if err := e.readiness.check(actual); err != nil {
+		return err
+	}
+	if e.rollout {
+		return checkRolledOut(actual)
+	}
+	return nil
+}
+
 // CheckResource checks if the expected resource's state in Kubernetes is correct.
 func (s *Step) CheckResource(expected runtime.Object, namespace string) []error {
 	cl, err := s.Client(false)
@@ -258,6 +530,13 @@ func (s *Step) CheckResource(expected runtime.Object, namespace string) []error
 		return []error{err}
 	}
 
+	if isAccessReview(expected) {
+		if err := checkAccessReview(cl, expected, namespace); err != nil {
+			return []error{err}
+		}
+		return []error{}
+	}
+
 	dClient, err := s.DiscoveryClient()
 	if err != nil {
 		return []error{err}
@@ -265,6 +544,14 @@ func (s *Step) CheckResource(expected runtime.Object, namespace string) []error
 
 	testErrors := []error{}
 
+	// the location is looked up before the annotations are stripped, which copies the object
+	source := testutils.DescribeSource(expected)
+
+	expected, exp, err := stripExpectations(dClient, expected, source)
+	if err != nil {
+		return append(testErrors, err)
+	}
+
 	name, namespace, err := testutils.Namespaced(dClient, expected, namespace)
 	if err != nil {
 		return append(testErrors, err)
@@ -281,6 +568,9 @@ func (s *Step) CheckResource(expected runtime.Object, namespace string) []error
 			Namespace: namespace,
 			Name:      name,
 		}, &actual); err != nil {
+			if k8serrors.IsNotFound(err) {
+				err = describeNotFound(cl, err, expected, gvk, namespace, name)
+			}
 			return append(testErrors, err)
 		}
 
@@ -294,8 +584,8 @@ func (s *Step) CheckResource(expected runtime.Object, namespace string) []error
 		if err != nil {
 			return append(testErrors, err)
 		}
-		if len(matches) == 0 {
-			testErrors = append(testErrors, fmt.Errorf("no resources matched of kind: %s", gvk.String()))
+		if len(matches) == 0 && exp.count < 0 {
+			testErrors = append(testErrors, fmt.Errorf("no resources matched of kind: %s%s", gvk.String(), source))
 		}
 		actuals = append(actuals, matches...)
 	}
@@ -303,27 +593,58 @@ func (s *Step) CheckResource(expected runtime.Object, namespace string) []error
 	if err != nil {
 		return append(testErrors, err)
 	}
+	expectedObj = subresourceExpectation(expectedObj, exp.subresource)
 
-	for _, actual := range actuals {
+	contents, err := subresourceContents(cl, actuals, exp.subresource)
+	if err != nil {
+		return append(testErrors, err)
+	}
+
+	matched := 0
+	for i, actual := range actuals {
 		actual := actual
+		content := contents[i]
 		tmpTestErrors := []error{}
 
-		if err := testutils.IsSubset(expectedObj, actual.UnstructuredContent()); err != nil {
-			diff, diffErr := testutils.PrettyDiff(expected, &actual)
+		expectedContent, actualContent, err := parsedData(expectedObj, content.UnstructuredContent(), exp.parsedKeys)
+		if err == nil {
+			err = testutils.IsSubsetWithOptions(s.Suppressions.Apply(expectedContent, actualContent), actualContent, s.subsetOptions())
+		}
+		if err != nil {
+			diffExpected := expected
+			if exp.subresource == "" {
+				diffExpected = withDefaults(expected, content.UnstructuredContent())
+			}
+			diff, diffErr := testutils.PrettyDiff(diffExpected, &content)
 			if diffErr == nil {
-				tmpTestErrors = append(tmpTestErrors, fmt.Errorf(diff))
+				tmpTestErrors = append(tmpTestErrors, &diffError{diff: diff})
 			} else {
 				tmpTestErrors = append(tmpTestErrors, diffErr)
 			}
 
-			tmpTestErrors = append(tmpTestErrors, fmt.Errorf("resource %s: %s", testutils.ResourceID(expected), err))
+			tmpTestErrors = append(tmpTestErrors, fmt.Errorf("resource %s%s: %s", testutils.ResourceID(expected), source, err))
+		} else if err := exp.check(cl, &actual); err != nil {
+			tmpTestErrors = append(tmpTestErrors, fmt.Errorf("resource %s%s: %w", testutils.ResourceID(expected), source, err))
 		}
 
 		if len(tmpTestErrors) == 0 {
-			return tmpTestErrors
+			if exp.count < 0 {
+				return tmpTestErrors
+			}
+			matched++
+			continue
 		}
 
-		testErrors = append(testErrors, tmpTestErrors...)
+		if exp.count < 0 {
+			testErrors = append(testErrors, tmpTestErrors...)
+		}
+	}
+
+	if exp.count >= 0 {
+		if matched == exp.count {
+			return []error{}
+		}
+		return []error{fmt.Errorf("resource %s%s: expected %d matching resources, found %d", testutils.ResourceID(expected), source, exp.count, matched)}
 	}
 
 	return testErrors
@@ -336,11 +657,29 @@ func (s *Step) CheckResourceAbsent(expected runtime.Object, namespace string) er
 		return err
 	}
 
+	if isAccessReview(expected) {
+		review, err := reviewAccess(cl, expected, namespace)
+		if err != nil {
+			return err
+		}
+		if compareAccessReview(expected, review) == nil {
+			return fmt.Errorf("%s of %s matched error assertion", review.GetKind(), describeAccessReview(review))
+		}
+		return nil
+	}
+
 	dClient, err := s.DiscoveryClient()
 	if err != nil {
 		return err
 	}
 
+	source := testutils.DescribeSource(expected)
+
+	expected, exp, err := stripExpectations(dClient, expected, source)
+	if err != nil {
+		return err
+	}
+
 	name, namespace, err := testutils.Namespaced(dClient, expected, namespace)
 	if err != nil {
 		return err
@@ -381,47 +720,84 @@ func (s *Step) CheckResourceAbsent(expected runtime.Object, namespace string) er
 	if err != nil {
 		return err
 	}
+	expectedObj = subresourceExpectation(expectedObj, exp.subresource)
+
+	contents, err := subresourceContents(cl, actuals, exp.subresource)
+	if err != nil {
+		return err
+	}
 
 	var unexpectedObjects []unstructured.Unstructured
-	for _, actual := range actuals {
-		if err := testutils.IsSubset(expectedObj, actual.UnstructuredContent()); err == nil {
+	for i, actual := range actuals {
+		actual := actual
+		expectedContent, actualContent, err := parsedData(expectedObj, contents[i].UnstructuredContent(), exp.parsedKeys)
+		if err != nil {
+			return err
+		}
+		if err := testutils.IsSubsetWithOptions(expectedContent, actualContent, s.subsetOptions()); err == nil && exp.check(cl, &actual) == nil {
 			unexpectedObjects = append(unexpectedObjects, actual)
 		}
 	}
 
+	if exp.count >= 0 {
+		if len(unexpectedObjects) == exp.count {
+			return fmt.Errorf("resource %s%s matched error assertion: %d resources matched", testutils.ResourceID(expected), source, exp.count)
+		}
+		return nil
+	}
 	if len(unexpectedObjects) == 0 {
 		return nil
 	}
 	if len(unexpectedObjects) == 1 {
-		return fmt.Errorf("resource %s %s matched error assertion", unexpectedObjects[0].GroupVersionKind(), unexpectedObjects[0].GetName())
+		return fmt.Errorf("resource %s %s%s matched error assertion", unexpectedObjects[0].GroupVersionKind(), unexpectedObjects[0].GetName(), source)
 	}
-	return fmt.Errorf("resource %s %s (and %d other resources) matched error assertion", unexpectedObjects[0].GroupVersionKind(), unexpectedObjects[0].GetName(), len(unexpectedObjects)-1)
+	return fmt.Errorf("resource %s %s%s (and %d other resources) matched error assertion", unexpectedObjects[0].GroupVersionKind(), unexpectedObjects[0].GetName(), source, len(unexpectedObjects)-1)
 }
 
 // CheckAssertCommands Runs the commands provided in `commands` and check if have been run successfully.
 // the errors returned can be a a failure of executing the command or the failure of the command executed.
 func (s *Step) CheckAssertCommands(ctx context.Context, namespace string, commands []harness.TestAssertCommand, timeout int) []error {
 	testErrors := []error{}
-	if _, err := testutils.RunAssertCommands(ctx, s.Logger, namespace, commands, "", timeout, s.Kubeconfig); err != nil {
+	if _, err := testutils.RunAssertCommands(s.commandContext(ctx), s.Logger, namespace, commands, "", timeout, s.Kubeconfig); err != nil {
 		testErrors = append(testErrors, err)
 	}
 	return testErrors
 }
 
+// runContext returns the RunContext of the step, or a context which is never cancelled if it is not set.
+func (s *Step) runContext() context.Context {
+	if s.RunContext == nil {
+		return context.Background()
+	}
+	return s.RunContext
+}
+
 // Check checks if the resources defined in Asserts and Errors are in the correct state.
 func (s *Step) Check(namespace string, timeout int) []error {
 	testErrors := []error{}
+	s.unsatisfied = nil
 
 	for _, expected := range s.Asserts {
-		testErrors = append(testErrors, s.CheckResource(expected, namespace)...)
+		errs := s.CheckResource(expected, namespace)
+		if len(errs) > 0 {
+			s.unsatisfied = append(s.unsatisfied, assertID(expected))
+		}
+		// an invalid timeout is already an error of the object
+		if _, timeout, err := expectedTimeout(expected); err == nil {
+			errs = withObjectTimeout(errs, timeout)
+		}
+		testErrors = append(testErrors, errs...)
 	}
 
 	if s.Assert != nil {
-		testErrors = append(testErrors, s.CheckAssertCommands(context.TODO(), namespace, s.Assert.Commands, timeout)...)
+		testErrors = append(testErrors, s.CheckAssertCommands(s.runContext(), namespace, s.Assert.Commands, timeout)...)
+		testErrors = append(testErrors, s.CheckFieldAssertions(namespace)...)
+		testErrors = append(testErrors, s.CheckExpressionAssertions(namespace)...)
 	}
 
 	for _, expected := range s.Errors {
 		if testError := s.CheckResourceAbsent(expected, namespace); testError != nil {
+			s.unsatisfied = append(s.unsatisfied, assertID(expected)+" (expected absent)")
 			testErrors = append(testErrors, testError)
 		}
 	}
@@ -434,44 +810,164 @@ func (s *Step) Check(namespace string, timeout int) []error {
 // 2. Wait for all of the states defined in the test step's asserts to be true.'
 func (s *Step) Run(test *testing.T, namespace string) []error {
 	s.Logger.Log("starting test step", s.String())
+	s.timings.start(applyPhase)
+	defer s.timings.end()
 
 	if err := s.DeleteExisting(namespace); err != nil {
 		return []error{err}
 	}
 
+	if err := s.ChangeNodes(test); err != nil {
+		return []error{err}
+	}
+
+	if err := s.StartMocks(test, namespace); err != nil {
+		return []error{err}
+	}
+
+	if err := s.RequestTokens(namespace); err != nil {
+		return []error{err}
+	}
+
+	if err := s.CopyObjects(namespace); err != nil {
+		return []error{err}
+	}
+
+	sequences, err := s.watchSequences(namespace)
+	if err != nil {
+		return []error{err}
+	}
+	defer sequences.stop()
+
 	testErrors := []error{}
 
+	if s.Step != nil && len(s.Step.Commands) > 0 {
+		if err := s.checkNetworkPolicies(namespace); err != nil {
+			return []error{err}
+		}
+	}
+
 	if s.Step != nil {
+		s.timings.start(commandPhase)
 		for _, command := range s.Step.Commands {
 			if command.Background {
 				s.Logger.Log("background commands are not allowed for steps and will be run in foreground")
 				command.Background = false
 			}
 		}
-		if _, err := testutils.RunCommands(context.TODO(), s.Logger, namespace, s.Step.Commands, s.Dir, s.Timeout, s.Kubeconfig); err != nil {
+		if _, err := testutils.RunCommands(s.commandContext(s.runContext()), s.Logger, namespace, s.Step.Commands, s.Dir, s.Timeout, s.Kubeconfig); err != nil {
 			testErrors = append(testErrors, err)
 		}
 	}
 
+	s.timings.start(applyPhase)
+	testErrors = append(testErrors, s.RunCustom(s.runContext(), namespace)...)
 	testErrors = append(testErrors, s.Create(test, namespace)...)
+	testErrors = append(testErrors, s.CheckWarnings()...)
 
 	if len(testErrors) != 0 {
 		return testErrors
 	}
 
+	if err := s.Prune(); err != nil {
+		return []error{fmt.Errorf("pruning: %w", err)}
+	}
+
+	if errs := s.Sync(namespace); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := s.Restart(namespace); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := s.Evict(namespace); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := s.Autoscale(namespace); len(errs) > 0 {
+		return errs
+	}
+
+	if err := s.FetchWorkloadClusters(test, namespace); err != nil {
+		return []error{err}
+	}
+
+	s.timings.start(assertPhase)
+
+	if s.Stage != "" && s.Assert != nil && !inStage(s.Assert.Stages, s.Stage) {
+		s.Logger.Logf("skipping the asserts of step %s, they are not in stage %s", s.String(), s.Stage)
+		s.Logger.Log("test step completed", s.String())
+		return testErrors
+	}
+
+	// NetworkPolicies may be created by the step itself and affect its assert commands and app probes
+	if s.Assert != nil && (len(s.Assert.Commands) > 0 || len(s.Assert.Probes) > 0) {
+		if err := s.checkNetworkPolicies(namespace); err != nil {
+			return []error{err}
+		}
+	}
+
 	timeoutF := float64(s.GetTimeout())
+	maxTimeoutF := float64(s.maxTimeout())
 	start := time.Now()
+	progress := newAssertProgress(start)
+	escalated := false
 
-	for elapsed := 0.0; elapsed < timeoutF; elapsed = time.Since(start).Seconds() {
-		testErrors = s.Check(namespace, int(timeoutF-elapsed))
+	for elapsed := 0.0; elapsed < maxTimeoutF; elapsed = time.Since(start).Seconds() {
+		if elapsed > 0 {
+			s.retries++
+		}
+		if err := abortErr(s.RunContext); err != nil {
+			testErrors = []error{err}
+			break
+		}
+		// the objects of each kind and namespace are listed once per attempt
+		restore := s.cacheLists()
+		testErrors = s.Check(namespace, remainingTimeout(timeoutF, elapsed))
+		testErrors = append(testErrors, sequences.check()...)
+		// groups and objects may have a longer timeout than the step, but the other asserts must still pass in time
+		expired := assertsExpired(testErrors, timeoutF, elapsed)
+
+		groupErrors, groupsExpired := s.CheckGroups(namespace, elapsed)
+		testErrors = append(testErrors, groupErrors...)
+		restore()
+		if s.DetectDrift {
+			s.observeDrift(namespace)
+		}
 
 		if len(testErrors) == 0 {
 			break
 		}
-		if hasTimeoutErr(testErrors) {
+		if expired || groupsExpired || hasTimeoutErr(testErrors) {
 			break
 		}
-		time.Sleep(time.Second)
+		if testutils.V(testutils.LogRetry, 1) {
+			s.Logger.Logf("assert attempt %d failed with %d error(s), retrying: %v", s.retries+1, len(testErrors), testErrors[0])
+		}
+		progress.log(s.Logger, s.unsatisfied, testErrors)
+		if !escalated {
+			escalated = s.escalate(namespace, time.Since(start).Seconds(), timeoutF)
+		}
+		select {
+		case <-s.runContext().Done():
+		case <-time.After(time.Second):
+		}
+	}
+
+	// app probes retry on their own within the remaining time
+	if len(testErrors) == 0 && s.Assert != nil && len(s.Assert.Probes) > 0 {
+		testErrors = s.CheckProbes(namespace, remainingTimeout(timeoutF, time.Since(start).Seconds()))
+	}
+
+	// the patches expected to be rejected are applied once, when all asserts pass
+	if len(testErrors) == 0 && s.Assert != nil && len(s.Assert.ExpectErrors) > 0 {
+		testErrors = s.CheckExpectErrors(namespace)
+	}
+
+	// the stability windows start once all asserts pass
+	if len(testErrors) == 0 {
+		testErrors = s.CheckStability(namespace)
 	}
 
 	// all is good
@@ -484,19 +980,24 @@ func (s *Step) Run(test *testing.T, namespace string) []error {
 	if s.Assert == nil {
 		return testErrors
 	}
-	for _, collector := range s.Assert.Collectors {
+	s.runCollectors(namespace, s.Assert.Collectors)
+	s.Logger.Flush()
+	return testErrors
+}
+
+// runCollectors runs the collectors of the TestAssert of the step, their failures are logged.
+func (s *Step) runCollectors(namespace string, collectors []*harness.TestCollector) {
+	for _, collector := range collectors {
 		s.Logger.Logf("collecting log output for %s", collector.String())
 		if collector.Command() == nil {
 			s.Logger.Log("skipping invalid assertion collector")
 			continue
 		}
-		_, err := testutils.RunCommand(context.TODO(), namespace, *collector.Command(), s.Dir, s.Logger, s.Logger, s.Logger, s.Timeout, s.Kubeconfig)
+		_, err := testutils.RunCommand(s.commandContext(s.runContext()), namespace, *collector.Command(), s.Dir, s.Logger, s.Logger, s.Logger, s.Timeout, s.Kubeconfig)
 		if err != nil {
 			s.Logger.Log("post assert collector failure: %s", err)
 		}
 	}
-	s.Logger.Flush()
-	return testErrors
 }
 
 // String implements the string interface, returning the name of the test step.
@@ -511,12 +1012,17 @@ func (s *Step) String() string {
 //     if seen, mark a test immediately failed.
 //   - All other YAML files are considered resources to create.
 func (s *Step) LoadYAML(file string) error {
-	skipFile, objects, err := s.loadOrSkipFile(file)
+	skipFile, fileType, objects, err := s.loadOrSkipFile(file)
 	if skipFile || err != nil {
 		return err
 	}
 
-	if err = s.populateObjectsByFileName(filepath.Base(file), objects); err != nil {
+	if fileType != "" {
+		err = s.populateObjectsByType(fileType, fileNameRegex.FindStringSubmatch(filepath.Base(file)), objects)
+	} else {
+		err = s.populateObjectsByFileName(filepath.Base(file), objects)
+	}
+	if err != nil {
 		return fmt.Errorf("populating step: %v", err)
 	}
 
@@ -526,6 +1032,24 @@ func (s *Step) LoadYAML(file string) error {
 		if obj.GetObjectKind().GroupVersionKind().Kind == "TestAssert" {
 			if testAssert, ok := obj.DeepCopyObject().(*harness.TestAssert); ok {
 				s.Assert = testAssert
+				if err := validateFieldAssertions(testAssert.FieldAssertions); err != nil {
+					return fmt.Errorf("loading fieldAssertions of TestAssert from %s: %w", file, err)
+				}
+				if err := validateExpressionAssertions(testAssert.Expressions); err != nil {
+					return fmt.Errorf("loading expressions of TestAssert from %s: %w", file, err)
+				}
+				if err := validateEscalation(testAssert.Escalation); err != nil {
+					return fmt.Errorf("loading escalation of TestAssert from %s: %w", file, err)
+				}
+				if s.AnyOf, err = s.loadAssertGroups(testAssert.AnyOf); err != nil {
+					return fmt.Errorf("loading anyOf of TestAssert from %s: %w", file, err)
+				}
+				if s.AllOf, err = s.loadAssertGroups(testAssert.AllOf); err != nil {
+					return fmt.Errorf("loading allOf of TestAssert from %s: %w", file, err)
+				}
+				if s.Sequences, err = s.loadAssertSequences(testAssert.Sequences); err != nil {
+					return fmt.Errorf("loading sequences of TestAssert from %s: %w", file, err)
+				}
 			} else {
 				return fmt.Errorf("failed to load TestAssert object from %s: it contains an object of type %T", file, obj)
 			}
@@ -554,6 +1078,17 @@ func (s *Step) LoadYAML(file string) error {
 				exKubeconfig := env.Expand(s.Step.Kubeconfig)
 				s.Kubeconfig = cleanPath(exKubeconfig, s.Dir)
 			}
+		} else if obj.GetObjectKind().GroupVersionKind().Kind == "TestMetadata" {
+			metadata, ok := obj.(*harness.TestMetadata)
+			if !ok {
+				return fmt.Errorf("failed to load TestMetadata object from %s: it contains an object of type %T", file, obj)
+			}
+			if s.Metadata != nil {
+				return fmt.Errorf("more than 1 TestMetadata not allowed in step %q", s.Name)
+			}
+			s.Metadata = metadata
+		} else if isCustomStep(obj) {
+			s.Custom = append(s.Custom, obj)
 		} else {
 			applies = append(applies, obj)
 		}
@@ -564,7 +1099,7 @@ func (s *Step) LoadYAML(file string) error {
 		// process configured step applies
 		for _, applyPath := range s.Step.Apply {
 			exApply := env.Expand(applyPath)
-			apply, err := ObjectsFromPath(exApply, s.Dir)
+			apply, err := objectsFromPath(exApply, s.Dir, s.jsonnetVars())
 			if err != nil {
 				return fmt.Errorf("step %q apply path %s: %w", s.Name, exApply, err)
 			}
@@ -573,7 +1108,7 @@ func (s *Step) LoadYAML(file string) error {
 		// process configured step asserts
 		for _, assertPath := range s.Step.Assert {
 			exAssert := env.Expand(assertPath)
-			assert, err := ObjectsFromPath(exAssert, s.Dir)
+			assert, err := objectsFromPath(exAssert, s.Dir, s.jsonnetVars())
 			if err != nil {
 				return fmt.Errorf("step %q assert path %s: %w", s.Name, exAssert, err)
 			}
@@ -582,7 +1117,7 @@ func (s *Step) LoadYAML(file string) error {
 		// process configured errors
 		for _, errorPath := range s.Step.Error {
 			exError := env.Expand(errorPath)
-			errObjs, err := ObjectsFromPath(exError, s.Dir)
+			errObjs, err := objectsFromPath(exError, s.Dir, s.jsonnetVars())
 			if err != nil {
 				return fmt.Errorf("step %q error path %s: %w", s.Name, exError, err)
 			}
@@ -595,36 +1130,58 @@ func (s *Step) LoadYAML(file string) error {
 	return nil
 }
 
-func (s *Step) loadOrSkipFile(file string) (bool, []client.Object, error) {
-	loadedObjects, err := testutils.LoadYAMLFromFile(file)
+// jsonnetVars returns the external variables of the Jsonnet files of the step.
+func (s *Step) jsonnetVars() map[string]string {
+	vars := map[string]string{}
+	for name, value := range s.JsonnetVars {
+		vars[name] = value
+	}
+	for name, value := range s.Context.Env() {
+		vars[name] = value
+	}
+	return vars
+}
+
+// loadOrSkipFile returns the objects of a step file, whether its TestFile skips it in the test run and the type of its
+// objects set by its TestFile.
+func (s *Step) loadOrSkipFile(file string) (bool, string, []client.Object, error) {
+	loadedObjects, err := testutils.LoadYAMLFromFileWithVars(file, s.jsonnetVars())
 	if err != nil {
-		return false, nil, fmt.Errorf("loading %s: %s", file, err)
+		return false, "", nil, fmt.Errorf("loading %s: %s", file, err)
 	}
 
 	var objects []client.Object
 	shouldSkip := false
+	fileType := ""
 	testFileObjEncountered := false
 
 	for i, object := range loadedObjects {
 		if testFileObject, ok := object.(*harness.TestFile); ok {
 			if testFileObjEncountered {
-				return false, nil, fmt.Errorf("more than one TestFile object encountered in file %q", file)
+				return false, "", nil, fmt.Errorf("more than one TestFile object encountered in file %q", file)
 			}
 			testFileObjEncountered = true
+			switch fileType = testFileObject.Type; fileType {
+			case "", "apply", "assert", "errors":
+			default:
+				return false, "", nil, fmt.Errorf("the TestFile of %q has an unknown type %q, it must be apply, assert or errors", file, fileType)
+			}
 			selector, err := metav1.LabelSelectorAsSelector(testFileObject.TestRunSelector)
 			if err != nil {
-				return false, nil, fmt.Errorf("unrecognized test run selector in object %d of %q: %w", i, file, err)
+				return false, "", nil, fmt.Errorf("unrecognized test run selector in object %d of %q: %w", i, file, err)
 			}
-			if selector.Empty() || selector.Matches(s.TestRunLabels) {
+			// a TestFile without a selector, ex. one only setting the step of the file, matches all test runs
+			if testFileObject.TestRunSelector == nil || selector.Empty() || selector.Matches(s.TestRunLabels) {
 				continue
 			}
-			fmt.Printf("Skipping file %q, label selector does not match test run labels.\n", file)
+			// not printed to stdout, which may be a listing of the tests or their shell completions
+			fmt.Fprintf(os.Stderr, "Skipping file %q, label selector does not match test run labels.\n", file)
 			shouldSkip = true
 		} else {
 			objects = append(objects, object)
 		}
 	}
-	return shouldSkip, objects, nil
+	return shouldSkip, fileType, objects, nil
 }
 
 // populateObjectsByFileName populates s.Asserts, s.Errors, and/or s.Apply for files containing
@@ -634,14 +1191,19 @@ func (s *Step) populateObjectsByFileName(fileName string, objects []client.Objec
 	if len(matches) < 2 {
 		return fmt.Errorf("%s does not match file name regexp: %s", fileName, testStepRegex.String())
 	}
+	return s.populateObjectsByType(strings.ToLower(matches[1]), matches, objects)
+}
 
-	switch fname := strings.ToLower(matches[1]); fname {
+// populateObjectsByType populates s.Asserts, s.Errors or s.Apply for the "assert", "errors" or other types of files
+// respectively.  The step is named after the matches of fileNameRegex of an applied file, if it has no name yet.
+func (s *Step) populateObjectsByType(fileType string, matches []string, objects []client.Object) error {
+	switch fileType {
 	case "assert":
 		s.Asserts = append(s.Asserts, objects...)
 	case "errors":
 		s.Errors = append(s.Errors, objects...)
 	default:
-		if s.Name == "" {
+		if s.Name == "" && len(matches) > 1 {
 			if len(matches) > 2 {
 				// The second matching group will already have a hyphen prefix.
 				s.Name = matches[1] + matches[2]
@@ -657,6 +1219,12 @@ func (s *Step) populateObjectsByFileName(fileName string, objects []client.Objec
 
 // ObjectsFromPath returns an array of runtime.Objects for files / urls provided
 func ObjectsFromPath(path, dir string) ([]client.Object, error) {
+	return objectsFromPath(path, dir, nil)
+}
+
+// objectsFromPath returns the objects of the files or url like ObjectsFromPath, Jsonnet files are rendered with the
+// additional external variables.
+func objectsFromPath(path, dir string, vars map[string]string) ([]client.Object, error) {
 	if http.IsURL(path) {
 		apply, err := http.ToObjects(path)
 		if err != nil {
@@ -667,13 +1235,24 @@ func ObjectsFromPath(path, dir string) ([]client.Object, error) {
 
 	// it's a directory or file
 	cPath := cleanPath(path, dir)
-	paths, err := kfile.FromPath(cPath, "*.yaml")
+	files, err := kfile.FromPath(cPath, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to find YAML files in %s: %w", cPath, err)
 	}
-	apply, err := kfile.ToObjects(paths)
-	if err != nil {
-		return nil, err
+	paths := []string{}
+	for _, path := range files {
+		// a file given explicitly is loaded whatever its extension
+		if path == cPath || testutils.IsManifestFile(path) {
+			paths = append(paths, path)
+		}
+	}
+	apply := []client.Object{}
+	for _, path := range paths {
+		objs, err := testutils.LoadYAMLFromFileWithVars(path, vars)
+		if err != nil {
+			return nil, fmt.Errorf("file %q load yaml error: %w", path, err)
+		}
+		apply = append(apply, objs...)
 	}
 	return apply, nil
 }
@@ -2,12 +2,17 @@ package test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -133,6 +138,112 @@ func TestStepDeleteExisting(t *testing.T) {
 	assert.True(t, k8serrors.IsNotFound(cl.Get(context.TODO(), testutils.ObjectKey(podToDeleteDefaultNS), podToDeleteDefaultNS)))
 }
 
+// deleteCountingClient counts the Delete and DeleteAllOf requests.
+type deleteCountingClient struct {
+	client.Client
+	deletes, deleteAllOfs int
+}
+
+func (c *deleteCountingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.deletes++
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *deleteCountingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.deleteAllOfs++
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+// Verify that DeleteExisting deletes the objects selected by labels and fields, with DeleteAllOf if all is set.
+func TestStepDeleteExistingSelectors(t *testing.T) {
+	pod := func(name, node string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+	}
+	podRef := corev1.ObjectReference{Kind: "Pod", APIVersion: "v1"}
+
+	for _, test := range []struct {
+		name         string
+		ref          harness.ObjectReference
+		deleted      []string
+		deletes      int
+		deleteAllOfs int
+		err          string
+	}{
+		{
+			name:         "all with labels",
+			ref:          harness.ObjectReference{ObjectReference: podRef, Labels: map[string]string{"app": "generated"}, All: true},
+			deleted:      []string{"generated-0", "generated-1"},
+			deleteAllOfs: 1,
+		},
+		{
+			name:    "field selector",
+			ref:     harness.ObjectReference{ObjectReference: podRef, FieldSelector: "spec.nodeName=node-1"},
+			deleted: []string{"generated-1"},
+			deletes: 1,
+		},
+		{
+			name: "all without matching objects",
+			ref:  harness.ObjectReference{ObjectReference: podRef, Labels: map[string]string{"app": "missing"}, All: true},
+		},
+		{
+			name: "all with a name",
+			ref:  harness.ObjectReference{ObjectReference: corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Name: "keep-me"}, All: true},
+			err:  "deleting Pod keep-me: all and fieldSelector cannot be used with a name",
+		},
+		{
+			name: "invalid field selector",
+			ref:  harness.ObjectReference{ObjectReference: podRef, FieldSelector: "spec.nodeName"},
+			err:  "invalid field selector of Pod",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := &deleteCountingClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).
+				// the objects DeleteExisting lists are unstructured
+				WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+					node, _, _ := unstructured.NestedString(obj.(*unstructured.Unstructured).Object, "spec", "nodeName")
+					return []string{node}
+				}).
+				WithObjects(
+					pod("generated-0", "node-0", map[string]string{"app": "generated"}),
+					pod("generated-1", "node-1", map[string]string{"app": "generated"}),
+					pod("keep-me", "node-0", nil),
+				).Build()}
+
+			step := Step{
+				Logger:          testutils.NewTestLogger(t, test.name),
+				Step:            &harness.TestStep{Delete: []harness.ObjectReference{test.ref}},
+				Client:          func(bool) (client.Client, error) { return cl, nil },
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+
+			err := step.DeleteExisting(testNamespace)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			pods := &corev1.PodList{}
+			require.NoError(t, cl.List(context.TODO(), pods))
+			remaining := []string{}
+			for _, pod := range pods.Items {
+				remaining = append(remaining, pod.Name)
+			}
+			for _, name := range test.deleted {
+				assert.NotContains(t, remaining, name)
+			}
+			assert.Len(t, remaining, 3-len(test.deleted))
+			assert.Equal(t, test.deletes, cl.deletes)
+			assert.Equal(t, test.deleteAllOfs, cl.deleteAllOfs)
+		})
+	}
+}
+
 func TestCheckResource(t *testing.T) {
 	for _, test := range []struct {
 		testName    string
@@ -234,6 +345,49 @@ func TestCheckResource(t *testing.T) {
 	}
 }
 
+// Verify that conditions are asserted by type unless the TestAssert compares them strictly.
+func TestCheckResourceConditions(t *testing.T) {
+	actual := testutils.WithStatus(t, testutils.NewPod("hello", testNamespace), map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Initialized", "status": "True"},
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		},
+	})
+	expected := testutils.WithStatus(t, testutils.NewPod("hello", ""), map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		},
+	})
+
+	for _, test := range []struct {
+		name        string
+		assert      *harness.TestAssert
+		shouldError bool
+	}{
+		{name: "by type"},
+		{name: "strict", assert: &harness.TestAssert{StrictConditions: true}, shouldError: true},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			step := Step{
+				Logger: testutils.NewTestLogger(t, ""),
+				Assert: test.assert,
+				Client: func(bool) (client.Client, error) {
+					return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(actual.DeepCopy()).Build(), nil
+				},
+				DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+			}
+			errors := step.CheckResource(expected, testNamespace)
+			if test.shouldError {
+				assert.NotEmpty(t, errors)
+			} else {
+				assert.Empty(t, errors)
+			}
+		})
+	}
+}
+
 func TestCheckResourceAbsent(t *testing.T) {
 	for _, test := range []struct {
 		name        string
@@ -312,6 +466,99 @@ func TestCheckResourceAbsent(t *testing.T) {
 	}
 }
 
+func TestCheckResourceAbsentAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00-errors.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    kuttl.dev/timeout: "30"
+    kuttl.dev/stable-for: "60"
+    kuttl.dev/stable-field: generation
+    kuttl.dev/parse-data: config.yaml
+    kuttl.dev/terminating: "false"
+    kuttl.dev/owned-by: Deployment/web
+data:
+  config.yaml: |
+    replicas: 3
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    app: web
+  annotations:
+    kuttl.dev/count: "1"
+    kuttl.dev/timeout: "30"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    kuttl.dev/rolled-out: "true"
+    kuttl.dev/convert-version: "true"
+`), 0600))
+
+	step := Step{Dir: dir}
+	require.NoError(t, step.LoadYAML(filepath.Join(dir, "00-errors.yaml")))
+	require.Len(t, step.Errors, 3)
+
+	replicas := int32(1)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: testNamespace, Labels: map[string]string{"app": "web"},
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid"}}},
+		Data: map[string]string{"config.yaml": "image: web\nreplicas: 3\n"},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, UID: "web-uid"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1},
+	}
+	step.Logger = testutils.NewTestLogger(t, "")
+	step.Client = func(bool) (client.Client, error) {
+		return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(configMap, deployment).Build(), nil
+	}
+	step.DiscoveryClient = func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil }
+
+	// the annotations are not compared with the objects, which match the errors
+	assert.EqualError(t, step.CheckResourceAbsent(step.Errors[0], testNamespace), "resource /v1, Kind=ConfigMap config (00-errors.yaml#doc1 (line 1)) matched error assertion")
+	assert.EqualError(t, step.CheckResourceAbsent(step.Errors[1], testNamespace), "resource ConfigMap:world/ (00-errors.yaml#doc2 (line 16)) matched error assertion: 1 resources matched")
+	assert.EqualError(t, step.CheckResourceAbsent(step.Errors[2], testNamespace), "resource apps/v1, Kind=Deployment web (00-errors.yaml#doc3 (line 25)) matched error assertion")
+
+	// the objects must meet what the annotations expect to match the errors
+	deployment.Status.UpdatedReplicas = 0
+	assert.NoError(t, step.CheckResourceAbsent(step.Errors[2], testNamespace))
+}
+
+func TestCheckResourceSource(t *testing.T) {
+	expected := testutils.WithSpec(t, testutils.NewPod("hello", ""), map[string]interface{}{"restartPolicy": "Never"})
+	testutils.SetSource(expected, testutils.Source{Path: "test/00-assert.yaml", Document: 2, Line: 14})
+
+	actual := testutils.NewPod("hello", testNamespace)
+
+	step := Step{
+		Logger: testutils.NewTestLogger(t, ""),
+		Client: func(bool) (client.Client, error) {
+			return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(actual).Build(), nil
+		},
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+
+	errors := step.CheckResource(expected, testNamespace)
+	assert.NotEmpty(t, errors)
+	assert.Contains(t, errors[len(errors)-1].Error(), "resource Pod:world/hello (00-assert.yaml#doc2 (line 14)): ")
+
+	err := step.CheckResourceAbsent(testutils.NewPod("hello", ""), testNamespace)
+	assert.EqualError(t, err, "resource /v1, Kind=Pod hello matched error assertion")
+
+	absent := testutils.NewPod("hello", "")
+	testutils.SetSource(absent, testutils.Source{Path: "test/00-errors.yaml", Document: 1, Line: 1})
+	err = step.CheckResourceAbsent(absent, testNamespace)
+	assert.EqualError(t, err, "resource /v1, Kind=Pod hello (00-errors.yaml#doc1 (line 1)) matched error assertion")
+}
+
 func TestRun(t *testing.T) {
 	for _, test := range []struct {
 		testName     string
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// defaultCleanupTimeout is the timeout of a cleanup command in seconds if the step does not set cleanupTimeout, it is
+// longer than the default timeout of the steps as releasing external resources is often slow.
+const defaultCleanupTimeout = 300
+
+// RegisterCleanup registers the cleanup commands of the step as a cleanup of test, so that they run when the test case
+// ends whether it passes, fails or panics.  It must be called once the step is started, the cleanups of the later
+// steps run first and the namespace of the test case is deleted after them.
+func (s *Step) RegisterCleanup(test *testing.T, namespace string) {
+	if s.Step == nil || len(s.Step.Cleanup) == 0 {
+		return
+	}
+	if s.SkipDelete {
+		s.Logger.Log("skipping the cleanup commands, --skip-delete is set")
+		return
+	}
+
+	test.Cleanup(func() {
+		for _, err := range s.RunCleanup(namespace) {
+			test.Error(err)
+		}
+	})
+}
+
+// RunCleanup runs the cleanup commands of the step.  Unlike the commands of the step, a failing command does not skip
+// the following ones, it returns the errors of all failing commands.
+func (s *Step) RunCleanup(namespace string) []error {
+	timeout := s.Step.CleanupTimeout
+	if timeout == 0 {
+		timeout = defaultCleanupTimeout
+	}
+
+	// the context of the test may be done by now, the cleanup commands only stop at their own timeout
+	ctx := s.commandContext(context.Background())
+	var errs []error
+	for _, command := range s.Step.Cleanup {
+		if command.Background {
+			s.Logger.Log("background commands are not allowed for cleanup and will be run in foreground")
+			command.Background = false
+		}
+		if _, err := testutils.RunCommand(ctx, namespace, command, s.Dir, s.Logger, s.Logger, s.Logger, timeout, s.Kubeconfig); err != nil {
+			errs = append(errs, fmt.Errorf("cleanup of step %s: %w", s.String(), err))
+		}
+		s.Logger.Flush()
+	}
+	return errs
+}
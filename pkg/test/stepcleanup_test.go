@@ -0,0 +1,66 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestRunCleanup(t *testing.T) {
+	dir := t.TempDir()
+	step := Step{
+		Name:   "create-bucket",
+		Index:  1,
+		Dir:    dir,
+		Logger: testutils.NewTestLogger(t, ""),
+		Step: &harness.TestStep{Cleanup: []harness.Command{
+			{Script: "exit 1"},
+			{Script: "touch deleted", Background: true},
+		}},
+	}
+
+	// a failing command does not skip the following ones
+	errs := step.RunCleanup(testNamespace)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "cleanup of step 1-create-bucket")
+	assert.FileExists(t, filepath.Join(dir, "deleted"))
+
+	step.Step.CleanupTimeout = 1
+	step.Step.Cleanup = []harness.Command{{Command: "sleep 5"}}
+	errs = step.RunCleanup(testNamespace)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "exceeded 1 sec timeout")
+}
+
+func TestRegisterCleanup(t *testing.T) {
+	dir := t.TempDir()
+	newStep := func(skipDelete bool) *Step {
+		return &Step{
+			Dir:        dir,
+			SkipDelete: skipDelete,
+			Logger:     testutils.NewTestLogger(t, ""),
+			Step:       &harness.TestStep{Cleanup: []harness.Command{{Script: "echo $NAMESPACE >> cleaned"}}},
+		}
+	}
+
+	t.Run("skip-delete", func(t *testing.T) {
+		newStep(true).RegisterCleanup(t, "skipped")
+	})
+	assert.NoFileExists(t, filepath.Join(dir, "cleaned"))
+
+	// the cleanup runs when the test ends
+	t.Run("test", func(t *testing.T) {
+		newStep(false).RegisterCleanup(t, testNamespace)
+		assert.NoFileExists(t, filepath.Join(dir, "cleaned"))
+		(&Step{}).RegisterCleanup(t, testNamespace)
+	})
+	content, err := os.ReadFile(filepath.Join(dir, "cleaned"))
+	require.NoError(t, err)
+	assert.Equal(t, testNamespace+"\n", string(content))
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// expectedSubresource returns a copy of expected without the subresource annotation, as well as the subresource
+// it names.  If the annotation is not set, expected is returned unmodified with an empty subresource.
+func expectedSubresource(expected runtime.Object) (runtime.Object, string, error) {
+	m, err := meta.Accessor(expected)
+	if err != nil {
+		return nil, "", err
+	}
+
+	subresource, ok := m.GetAnnotations()[harness.SubresourceAnnotation]
+	if !ok {
+		return expected, "", nil
+	}
+	if subresource == "" || strings.Contains(subresource, "/") {
+		return nil, "", fmt.Errorf("annotation %s: %q is not a subresource name", harness.SubresourceAnnotation, subresource)
+	}
+
+	copied, err := withoutAnnotation(expected, harness.SubresourceAnnotation)
+	if err != nil {
+		return nil, "", err
+	}
+	return copied, subresource, nil
+}
+
+// subresourceContents returns the representations of the subresource of the actual objects, in the same order.
+// If subresource is empty, actuals are returned.
+func subresourceContents(cl client.Client, actuals []unstructured.Unstructured, subresource string) ([]unstructured.Unstructured, error) {
+	if subresource == "" {
+		return actuals, nil
+	}
+
+	contents := make([]unstructured.Unstructured, 0, len(actuals))
+	for _, actual := range actuals {
+		actual := actual
+		content := unstructured.Unstructured{}
+		if err := cl.SubResource(subresource).Get(context.TODO(), &actual, &content); err != nil {
+			return nil, fmt.Errorf("getting %s subresource of %s %s: %w", subresource, actual.GetKind(), actual.GetName(), err)
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// subresourceExpectation returns a copy of an expected object which is compared to a subresource without its
+// apiVersion, kind and labels.  The representation of a subresource has its own kind (ex. autoscaling/v1 Scale for the
+// scale subresource) and the labels of the expected object only select the objects to compare.
+func subresourceExpectation(expected map[string]interface{}, subresource string) map[string]interface{} {
+	if subresource == "" {
+		return expected
+	}
+	copied := runtime.DeepCopyJSON(expected)
+	delete(copied, "apiVersion")
+	delete(copied, "kind")
+	unstructured.RemoveNestedField(copied, "metadata", "labels")
+	return copied
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// scaleClient serves the scale subresource of objects from scales, which the fake client does not support.
+type scaleClient struct {
+	client.Client
+	scales map[string]map[string]interface{}
+}
+
+func (c *scaleClient) SubResource(subResource string) client.SubResourceClient {
+	return &scaleReader{scales: c.scales}
+}
+
+type scaleReader struct {
+	client.SubResourceClient
+	scales map[string]map[string]interface{}
+}
+
+func (r *scaleReader) Get(_ context.Context, obj, subResource client.Object, _ ...client.SubResourceGetOption) error {
+	scale, ok := r.scales[obj.GetName()]
+	if !ok {
+		return k8serrors.NewNotFound(schema.GroupResource{Resource: "pods"}, obj.GetName())
+	}
+	subResource.(*unstructured.Unstructured).Object = scale
+	return nil
+}
+
+func TestExpectedSubresource(t *testing.T) {
+	pod := testutils.NewPod("hello", "")
+
+	expected, subresource, err := expectedSubresource(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, "", subresource)
+	assert.Equal(t, pod, expected)
+
+	expected, subresource, err = expectedSubresource(testutils.SetAnnotation(pod.DeepCopy(), harness.SubresourceAnnotation, "scale"))
+	assert.NoError(t, err)
+	assert.Equal(t, "scale", subresource)
+	assert.Equal(t, pod, expected)
+
+	_, _, err = expectedSubresource(testutils.SetAnnotation(pod.DeepCopy(), harness.SubresourceAnnotation, "scale/status"))
+	assert.EqualError(t, err, `annotation kuttl.dev/subresource: "scale/status" is not a subresource name`)
+}
+
+func TestCheckResourceSubresource(t *testing.T) {
+	scale := func(name string, replicas int64) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "autoscaling/v1",
+			"kind":       "Scale",
+			"metadata":   map[string]interface{}{"name": name, "namespace": testNamespace},
+			"spec":       map[string]interface{}{"replicas": replicas},
+			"status":     map[string]interface{}{"replicas": replicas, "selector": "app=web"},
+		}
+	}
+	expectScale := func(name string, replicas int64) *unstructured.Unstructured {
+		pod := testutils.NewPod(name, "")
+		pod.SetLabels(map[string]string{"app": "web"})
+		pod.Object["status"] = map[string]interface{}{"replicas": replicas}
+		return testutils.SetAnnotation(pod, harness.SubresourceAnnotation, "scale")
+	}
+
+	web1 := testutils.NewPod("web-1", testNamespace)
+	web1.SetLabels(map[string]string{"app": "web"})
+	web2 := testutils.NewPod("web-2", testNamespace)
+	web2.SetLabels(map[string]string{"app": "web"})
+	cl := &scaleClient{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(web1, web2).Build(),
+		scales: map[string]map[string]interface{}{"web-1": scale("web-1", 3), "web-2": scale("web-2", 1)},
+	}
+	step := Step{
+		Logger:          testutils.NewTestLogger(t, ""),
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+
+	assert.Equal(t, []error{}, step.CheckResource(expectScale("web-1", 3), testNamespace))
+	errs := step.CheckResource(expectScale("web-1", 2), testNamespace)
+	assert.NotEmpty(t, errs)
+	assert.Contains(t, errs[len(errs)-1].Error(), "resource Pod:world/web-1: .status.replicas: value mismatch, expected: 2 != actual: 3")
+
+	// the subresources of all matching objects are compared if the expected object has no name
+	assert.Equal(t, []error{}, step.CheckResource(expectScale("", 1), testNamespace))
+	assert.EqualError(t, step.CheckResourceAbsent(expectScale("", 3), testNamespace), "resource /v1, Kind=Pod web-1 matched error assertion")
+	assert.NoError(t, step.CheckResourceAbsent(expectScale("web-2", 3), testNamespace))
+
+	delete(cl.scales, "web-2")
+	errs = step.CheckResource(expectScale("web-2", 1), testNamespace)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "getting scale subresource of Pod web-2")
+}
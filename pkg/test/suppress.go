@@ -0,0 +1,232 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Suppression is a known acceptable difference between the expected and the actual value of a field of the objects
+// of a kind (ex. the replicas of a Deployment on a single node cluster).
+type Suppression struct {
+	// APIVersion of the objects, any version of the kind if empty.
+	APIVersion string `yaml:"apiVersion"`
+	// Kind of the objects.
+	Kind string `yaml:"kind"`
+	// Name of the object, every object of the kind if empty.
+	Name string `yaml:"name"`
+	// Path of the field, with the keys of maps separated by dots and the indexes of lists in brackets (ex.
+	// "spec.template.spec.containers[0].image").
+	Path string `yaml:"path"`
+	// Values the actual value may have instead of the expected one, any value if empty.
+	Values []string `yaml:"values"`
+	// Reason the difference is acceptable (ex. a link to the issue).
+	Reason string `yaml:"reason"`
+
+	path []pathElement
+}
+
+// suppressionsFile is the format of the suppressions file of the test suite.
+type suppressionsFile struct {
+	Suppressions []*Suppression `yaml:"suppressions"`
+}
+
+// pathElement is a map key or, if key is empty, a list index of a field path.
+type pathElement struct {
+	key   string
+	index int
+}
+
+// Suppressions are the suppressions of a test suite and whether they suppressed a difference.  They are shared by
+// the tests running in parallel.
+type Suppressions struct {
+	suppressions []*Suppression
+	lock         sync.Mutex
+	used         map[*Suppression]int
+}
+
+// LoadSuppressions reads the suppressions file at path.
+func LoadSuppressions(path string) (*Suppressions, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := suppressionsFile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.SetStrict(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("reading suppressions %s: %w", path, err)
+	}
+
+	for i, suppression := range file.Suppressions {
+		if suppression.Kind == "" {
+			return nil, fmt.Errorf("suppression %d of %s has no kind", i+1, path)
+		}
+		if suppression.path, err = parseFieldPath(suppression.Path); err != nil {
+			return nil, fmt.Errorf("suppression %d of %s: %w", i+1, path, err)
+		}
+	}
+	return &Suppressions{suppressions: file.Suppressions, used: map[*Suppression]int{}}, nil
+}
+
+// String describes the suppression.
+func (s *Suppression) String() string {
+	target := s.Kind
+	if s.APIVersion != "" {
+		target = s.APIVersion + "/" + target
+	}
+	if s.Name != "" {
+		target += "/" + s.Name
+	}
+	return target + " " + s.Path
+}
+
+// parseFieldPath parses a field path like "spec.containers[0].image".
+func parseFieldPath(path string) ([]pathElement, error) {
+	if path == "" {
+		return nil, errors.New("the path is empty")
+	}
+
+	elements := []pathElement{}
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		indexes := ""
+		if i := strings.Index(part, "["); i >= 0 {
+			key, indexes = part[:i], part[i:]
+		}
+		if key == "" && (indexes == "" || len(elements) == 0) {
+			return nil, fmt.Errorf("invalid path %q: empty key", path)
+		}
+		if key != "" {
+			elements = append(elements, pathElement{key: key})
+		}
+		for indexes != "" {
+			end := strings.Index(indexes, "]")
+			if !strings.HasPrefix(indexes, "[") || end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated index", path)
+			}
+			index, err := strconv.Atoi(indexes[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %q: invalid index %q", path, indexes[1:end])
+			}
+			elements = append(elements, pathElement{index: index})
+			indexes = indexes[end+1:]
+		}
+	}
+	return elements, nil
+}
+
+// fieldValue returns the value of the field at the path of obj.
+func fieldValue(obj interface{}, path []pathElement) (interface{}, bool) {
+	for _, element := range path {
+		if element.key != "" {
+			m, ok := obj.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if obj, ok = m[element.key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		l, ok := obj.([]interface{})
+		if !ok || element.index >= len(l) {
+			return nil, false
+		}
+		obj = l[element.index]
+	}
+	return obj, true
+}
+
+// setFieldValue sets the existing field at the path of obj to value.
+func setFieldValue(obj interface{}, path []pathElement, value interface{}) {
+	parent, _ := fieldValue(obj, path[:len(path)-1])
+	last := path[len(path)-1]
+	if last.key != "" {
+		parent.(map[string]interface{})[last.key] = value
+		return
+	}
+	parent.([]interface{})[last.index] = value
+}
+
+// matches returns true if the suppression applies to the object.
+func (s *Suppression) matches(actual *unstructured.Unstructured) bool {
+	return s.Kind == actual.GetKind() &&
+		(s.APIVersion == "" || s.APIVersion == actual.GetAPIVersion()) &&
+		(s.Name == "" || s.Name == actual.GetName())
+}
+
+// allows returns true if the actual value is one of the values of the suppression.
+func (s *Suppression) allows(actual interface{}) bool {
+	if len(s.Values) == 0 {
+		return true
+	}
+	for _, value := range s.Values {
+		if value == fmt.Sprint(actual) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the expected object with the fields whose differences to the actual object are suppressed set to
+// their actual value.  The expected object is not modified, it is returned as is if no field is suppressed.
+func (s *Suppressions) Apply(expected, actual map[string]interface{}) map[string]interface{} {
+	if s == nil {
+		return expected
+	}
+
+	actualObj := &unstructured.Unstructured{Object: actual}
+	suppressed := expected
+	copied := false
+	for _, suppression := range s.suppressions {
+		if !suppression.matches(actualObj) {
+			continue
+		}
+		expectedValue, ok := fieldValue(suppressed, suppression.path)
+		if !ok {
+			continue
+		}
+		actualValue, ok := fieldValue(actual, suppression.path)
+		if !ok || reflect.DeepEqual(expectedValue, actualValue) || !suppression.allows(actualValue) {
+			continue
+		}
+
+		if !copied {
+			suppressed = runtime.DeepCopyJSON(expected)
+			copied = true
+		}
+		setFieldValue(suppressed, suppression.path, runtime.DeepCopyJSONValue(actualValue))
+		s.lock.Lock()
+		s.used[suppression]++
+		s.lock.Unlock()
+	}
+	return suppressed
+}
+
+// Unused returns the suppressions which did not suppress any difference.
+func (s *Suppressions) Unused() []*Suppression {
+	if s == nil {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	unused := []*Suppression{}
+	for _, suppression := range s.suppressions {
+		if s.used[suppression] == 0 {
+			unused = append(unused, suppression)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	path, err := parseFieldPath("spec.template.spec.containers[0].ports[1][2]")
+	require.NoError(t, err)
+	assert.Equal(t, []pathElement{
+		{key: "spec"}, {key: "template"}, {key: "spec"}, {key: "containers"}, {index: 0},
+		{key: "ports"}, {index: 1}, {index: 2},
+	}, path)
+
+	for path, message := range map[string]string{
+		"":                   "the path is empty",
+		"spec..replicas":     `invalid path "spec..replicas": empty key`,
+		"[0].name":           `invalid path "[0].name": empty key`,
+		"containers[0":       `invalid path "containers[0": unterminated index`,
+		"containers[a].name": `invalid path "containers[a].name": invalid index "a"`,
+		"containers[-1]":     `invalid path "containers[-1]": invalid index "-1"`,
+	} {
+		_, err := parseFieldPath(path)
+		assert.EqualError(t, err, message, path)
+	}
+}
+
+func TestLoadSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "suppressions.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	path := write(`suppressions:
+- kind: Deployment
+  name: operator
+  path: spec.replicas
+  values: ["1"]
+  reason: single node clusters
+`)
+	suppressions, err := LoadSuppressions(path)
+	require.NoError(t, err)
+	require.Len(t, suppressions.Unused(), 1)
+	assert.Equal(t, "Deployment/operator spec.replicas", suppressions.Unused()[0].String())
+
+	_, err = LoadSuppressions(write("suppressions:\n- path: spec.replicas\n"))
+	assert.EqualError(t, err, "suppression 1 of "+path+" has no kind")
+
+	_, err = LoadSuppressions(write("suppressions:\n- kind: Pod\n  path: spec.containers[\n"))
+	assert.EqualError(t, err, "suppression 1 of "+path+`: invalid path "spec.containers[": unterminated index`)
+
+	_, err = LoadSuppressions(write("suppressions:\n- kind: Pod\n  field: spec\n"))
+	assert.ErrorContains(t, err, "field field not found")
+}
+
+func TestSuppressionsApply(t *testing.T) {
+	suppressions := &Suppressions{used: map[*Suppression]int{}}
+	for _, suppression := range []*Suppression{
+		{Kind: "Deployment", Name: "operator", Path: "spec.replicas", Values: []string{"1"}},
+		{Kind: "Deployment", APIVersion: "apps/v1", Path: "spec.template.spec.containers[0].image"},
+		{Kind: "StatefulSet", Path: "spec.replicas"},
+	} {
+		path, err := parseFieldPath(suppression.Path)
+		require.NoError(t, err)
+		suppression.path = path
+		suppressions.suppressions = append(suppressions.suppressions, suppression)
+	}
+
+	deployment := func(name string, replicas int64, image string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{"name": "manager", "image": image}},
+					},
+				},
+			},
+		}
+	}
+
+	expected := deployment("operator", 3, "operator:v1")
+	actual := deployment("operator", 1, "mirror.local/operator:v1")
+	suppressed := suppressions.Apply(expected, actual)
+	assert.NoError(t, testutils.IsSubset(suppressed, actual))
+	assert.Equal(t, deployment("operator", 3, "operator:v1"), expected, "the expected object is not modified")
+
+	// the replicas of other deployments or with another value are not suppressed
+	actual = deployment("webhook", 1, "operator:v1")
+	assert.Equal(t, deployment("webhook", 3, "operator:v1"), suppressions.Apply(deployment("webhook", 3, "operator:v1"), actual))
+	actual = deployment("operator", 2, "operator:v1")
+	assert.Equal(t, expected, suppressions.Apply(expected, actual))
+
+	// the suppressions of fields which are not expected are not used
+	unused := suppressions.Unused()
+	require.Len(t, unused, 1)
+	assert.Equal(t, "StatefulSet spec.replicas", unused[0].String())
+
+	var none *Suppressions
+	assert.Equal(t, expected, none.Apply(expected, actual))
+	assert.Empty(t, none.Unused())
+}
+
+func TestCheckResourceSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suppressions.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`suppressions:
+- kind: Pod
+  path: spec.restartPolicy
+  values: [Always]
+  reason: the cluster defaults the restart policy
+`), 0600))
+	suppressions, err := LoadSuppressions(path)
+	require.NoError(t, err)
+
+	actual := testutils.WithSpec(t, testutils.NewPod("hello", testNamespace), map[string]interface{}{
+		"restartPolicy": "Always",
+	})
+	expected := testutils.WithSpec(t, testutils.NewPod("hello", ""), map[string]interface{}{
+		"restartPolicy": "Never",
+	})
+
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(actual).Build()
+	step := &Step{
+		Logger:          testutils.NewTestLogger(t, ""),
+		Client:          func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient: func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+	}
+	assert.NotEmpty(t, step.CheckResource(expected, testNamespace))
+
+	step.Suppressions = suppressions
+	assert.Empty(t, step.CheckResource(expected, testNamespace))
+	assert.Empty(t, suppressions.Unused())
+}
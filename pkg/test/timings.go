@@ -0,0 +1,237 @@
+package test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The formats of the timings of a run.
+const (
+	TimingsCSV  = "csv"
+	TimingsJSON = "json"
+)
+
+// timingsName is the name of the timings file in the artifacts directory, without its extension.
+const timingsName = "kuttl-timings"
+
+// The phases of a step whose timings are measured.
+const (
+	applyPhase   = "apply"
+	commandPhase = "command"
+	assertPhase  = "assert"
+)
+
+// Timing is a row of the timings of a run, the duration of a phase of a step and the API calls made by the client of the
+// step during it.  API calls made by commands (ex. kubectl) are not counted.
+type Timing struct {
+	Suite    string  `json:"suite"`
+	Test     string  `json:"test"`
+	Step     string  `json:"step"`
+	Phase    string  `json:"phase"`
+	Duration float64 `json:"durationSeconds"`
+	APICalls int64   `json:"apiCalls"`
+}
+
+// timingTable collects the timings of the steps of the tests of a run.  A nil timingTable collects nothing.
+type timingTable struct {
+	lock sync.Mutex
+	rows []Timing
+}
+
+// add adds the timings of the phases of a step of test.
+func (t *timingTable) add(suite, test string, step *Step) {
+	if t == nil || step.timings == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, phase := range step.timings.phases {
+		t.rows = append(t.rows, Timing{
+			Suite:    suite,
+			Test:     test,
+			Step:     step.String(),
+			Phase:    phase.phase,
+			Duration: phase.duration.Seconds(),
+			APICalls: phase.calls,
+		})
+	}
+}
+
+// write writes the timings to the timings file of format in dir.
+func (t *timingTable) write(dir, format string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if format != TimingsCSV && format != TimingsJSON {
+		return fmt.Errorf("unknown timings format %q, it must be %s or %s", format, TimingsCSV, TimingsJSON)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	path := filepath.Join(dir, timingsName+"."+format)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch format {
+	case TimingsJSON:
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		rows := t.rows
+		if rows == nil {
+			rows = []Timing{}
+		}
+		err = encoder.Encode(rows)
+	case TimingsCSV:
+		w := csv.NewWriter(f)
+		_ = w.Write([]string{"suite", "test", "step", "phase", "duration_seconds", "api_calls"})
+		for _, row := range t.rows {
+			_ = w.Write([]string{row.Suite, row.Test, row.Step, row.Phase,
+				strconv.FormatFloat(row.Duration, 'f', 3, 64), strconv.FormatInt(row.APICalls, 10)})
+		}
+		w.Flush()
+		err = w.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// stepTimings measures the phases of a step, a phase measured more than once (ex. apply before and after the commands
+// of the step) is added up.  A nil stepTimings measures nothing.
+type stepTimings struct {
+	// calls are the API calls made by the client of the step.
+	calls   int64
+	phases  []*phaseTiming
+	current *phaseTiming
+	started time.Time
+	// startCalls are the calls when the current phase started.
+	startCalls int64
+}
+
+// phaseTiming is the measured duration and API calls of a phase of a step.
+type phaseTiming struct {
+	phase    string
+	duration time.Duration
+	calls    int64
+}
+
+// start ends the current phase and starts measuring phase.
+func (t *stepTimings) start(phase string) {
+	if t == nil {
+		return
+	}
+	t.end()
+	for _, measured := range t.phases {
+		if measured.phase == phase {
+			t.current = measured
+		}
+	}
+	if t.current == nil {
+		t.current = &phaseTiming{phase: phase}
+		t.phases = append(t.phases, t.current)
+	}
+	t.started = time.Now()
+	t.startCalls = atomic.LoadInt64(&t.calls)
+}
+
+// end ends the current phase, if any.
+func (t *stepTimings) end() {
+	if t == nil || t.current == nil {
+		return
+	}
+	t.current.duration += time.Since(t.started)
+	t.current.calls += atomic.LoadInt64(&t.calls) - t.startCalls
+	t.current = nil
+}
+
+// countCalls returns newClient, whose clients count their API calls in the timings.
+func (t *stepTimings) countCalls(newClient func(bool) (client.Client, error)) func(bool) (client.Client, error) {
+	return func(forceNew bool) (client.Client, error) {
+		cl, err := newClient(forceNew)
+		if err != nil {
+			return nil, err
+		}
+		return &callCountingClient{Client: cl, calls: &t.calls}, nil
+	}
+}
+
+// callCountingClient counts the API calls a client makes, except those of its status and subresource clients.
+type callCountingClient struct {
+	client.Client
+	calls *int64
+}
+
+func (c *callCountingClient) count() {
+	atomic.AddInt64(c.calls, 1)
+}
+
+func (c *callCountingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.count()
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *callCountingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.count()
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *callCountingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.count()
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *callCountingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.count()
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *callCountingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.count()
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *callCountingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.count()
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *callCountingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.count()
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+// Watch counts the call which starts the watch, the events it receives are not calls.
+func (c *callCountingClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	watchClient, ok := c.Client.(client.WithWatch)
+	if !ok {
+		return nil, errors.New("the client cannot watch objects")
+	}
+	c.count()
+	return watchClient.Watch(ctx, list, opts...)
+}
+
+// writeTimings writes the timings of the run to the artifacts directory, if the test suite sets a timings format.
+func (h *Harness) writeTimings() {
+	if h.timings == nil {
+		return
+	}
+	if err := h.timings.write(h.TestSuite.ArtifactsDir, h.TestSuite.TimingsFormat); err != nil {
+		h.T.Errorf("writing the timings: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	"github.com/kudobuilder/kuttl/pkg/report"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestStepTimings(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	timings := &stepTimings{}
+	newClient := timings.countCalls(func(bool) (client.Client, error) { return fakeClient, nil })
+	cl, err := newClient(false)
+	require.NoError(t, err)
+	get := func() {
+		_ = cl.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "missing"}, &corev1.Pod{})
+	}
+
+	timings.start(applyPhase)
+	get()
+	timings.start(commandPhase)
+	timings.start(applyPhase)
+	get()
+	get()
+	timings.start(assertPhase)
+	get()
+	timings.end()
+	// calls after the last phase are not measured
+	get()
+
+	phases := []string{}
+	calls := []int64{}
+	for _, phase := range timings.phases {
+		phases = append(phases, phase.phase)
+		calls = append(calls, phase.calls)
+	}
+	assert.Equal(t, []string{applyPhase, commandPhase, assertPhase}, phases)
+	assert.Equal(t, []int64{3, 0, 1}, calls)
+
+	var nilTimings *stepTimings
+	nilTimings.start(applyPhase)
+	nilTimings.end()
+}
+
+func TestTimingTableWrite(t *testing.T) {
+	dir := t.TempDir()
+	table := &timingTable{rows: []Timing{
+		{Suite: "tests/e2e", Test: "install", Step: "0-install", Phase: applyPhase, Duration: 1.5, APICalls: 12},
+		{Suite: "tests/e2e", Test: "install", Step: "0-install", Phase: assertPhase, Duration: 20.25, APICalls: 40},
+	}}
+
+	require.NoError(t, table.write(dir, TimingsCSV))
+	data, err := os.ReadFile(filepath.Join(dir, "kuttl-timings.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, `suite,test,step,phase,duration_seconds,api_calls
+tests/e2e,install,0-install,apply,1.500,12
+tests/e2e,install,0-install,assert,20.250,40
+`, string(data))
+
+	require.NoError(t, table.write(dir, TimingsJSON))
+	data, err = os.ReadFile(filepath.Join(dir, "kuttl-timings.json"))
+	require.NoError(t, err)
+	rows := []Timing{}
+	require.NoError(t, json.Unmarshal(data, &rows))
+	assert.Equal(t, table.rows, rows)
+
+	assert.EqualError(t, table.write(dir, "xml"), `unknown timings format "xml", it must be csv or json`)
+}
+
+func TestRunStepTimings(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	table := &timingTable{}
+	c := &Case{
+		Name: "timings",
+		Steps: []*Step{{
+			Name:    "install",
+			Index:   0,
+			Step:    &harness.TestStep{Commands: []harness.Command{{Script: "true"}}},
+			Apply:   []client.Object{testutils.NewPod("web", "")},
+			Asserts: []client.Object{testutils.NewPod("web", "")},
+			Timeout: 1,
+		}},
+		PreferredNamespace: testNamespace,
+		SkipDelete:         true,
+		Suppress:           []string{"events"},
+		Timeout:            1,
+		Context:            TestContext{Suite: "tests/e2e"},
+		Logger:             testutils.NewTestLogger(t, "timings"),
+		Client:             func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient:    func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+		timings:            table,
+	}
+
+	tc := report.NewCase(c.Name)
+	c.Run(t, tc)
+	require.Nil(t, tc.Failure)
+
+	require.Len(t, table.rows, 3)
+	for i, phase := range []string{applyPhase, commandPhase, assertPhase} {
+		row := table.rows[i]
+		assert.Equal(t, "tests/e2e", row.Suite)
+		assert.Equal(t, "timings", row.Test)
+		assert.Equal(t, "0-install", row.Step)
+		assert.Equal(t, phase, row.Phase)
+	}
+	assert.Positive(t, table.rows[0].APICalls, "the apply phase creates the pod")
+	assert.Zero(t, table.rows[1].APICalls, "the API calls of commands are not counted")
+	assert.Positive(t, table.rows[2].APICalls, "the assert phase gets the pod")
+}
+
+func TestRunStepTimingsSequences(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).Build()
+	table := &timingTable{}
+	c := &Case{
+		Name: "timings",
+		Steps: []*Step{{
+			Name:      "machine",
+			Index:     0,
+			Step:      &harness.TestStep{},
+			Apply:     []client.Object{stateConfigMap("machine", "ready")},
+			Sequences: []AssertSequence{{Name: "machine", Expected: []client.Object{stateConfigMap("machine", "ready")}}},
+			Timeout:   5,
+		}},
+		PreferredNamespace: testNamespace,
+		SkipDelete:         true,
+		Suppress:           []string{"events"},
+		Timeout:            5,
+		Logger:             testutils.NewTestLogger(t, "timings"),
+		Client:             func(bool) (client.Client, error) { return cl, nil },
+		DiscoveryClient:    func() (discovery.DiscoveryInterface, error) { return testutils.FakeDiscoveryClient(), nil },
+		timings:            table,
+	}
+
+	// the client counting the calls watches the objects of the sequences
+	tc := report.NewCase(c.Name)
+	c.Run(t, tc)
+	require.Nil(t, tc.Failure)
+	assert.NotEmpty(t, table.rows)
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// anchorsKey is the key of the documents which only define anchors for the following documents of their file, they
+// are not loaded as objects.
+const anchorsKey = "$anchors"
+
+// sharedDocumentKey is the key of the document whose aliases are resolved in the document combining it with the
+// previous documents of its file.
+const sharedDocumentKey = "kuttl-document"
+
+// sharedAnchors are the anchors of the previous documents of a file, which the following documents may refer to.
+// YAML anchors only apply to the document defining them, a document referring to an anchor it does not define is
+// resolved in a document combining it with the previous documents defining anchors, ex. a first document holding
+// only the common blocks of the objects of the file under $anchors.
+type sharedAnchors struct {
+	documents [][]byte
+}
+
+// resolve returns the document with the aliases of the anchors of previous documents replaced by the nodes they refer
+// to, as JSON, and records the anchors of the document.  Documents which only refer to their own anchors are returned
+// unmodified.  It also returns whether the document only defines anchors under $anchors.
+func (a *sharedAnchors) resolve(data []byte) ([]byte, bool, error) {
+	defer func() {
+		if bytes.ContainsRune(data, '&') {
+			a.documents = append(a.documents, data)
+		}
+	}()
+
+	resolved, err := a.resolveAliases(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if !bytes.Contains(resolved, []byte(anchorsKey)) {
+		return resolved, false, nil
+	}
+	doc := map[string]interface{}{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(resolved), len(resolved)).Decode(&doc); err != nil {
+		// the document is decoded again as an object, which reports the error
+		return resolved, false, nil //nolint:nilerr
+	}
+	_, definitions := doc[anchorsKey]
+	if definitions && len(doc) > 1 {
+		return nil, false, fmt.Errorf("a document defining anchors may only have %s", anchorsKey)
+	}
+	return resolved, definitions, nil
+}
+
+// resolveAliases returns the document with the aliases of the anchors of previous documents replaced, if it refers to
+// anchors it does not define.
+func (a *sharedAnchors) resolveAliases(data []byte) ([]byte, error) {
+	if len(a.documents) == 0 || !bytes.ContainsRune(data, '*') {
+		return data, nil
+	}
+	if err := yamlv3.Unmarshal(data, &yamlv3.Node{}); err == nil || !strings.Contains(err.Error(), "unknown anchor") {
+		// the document is decoded again as an object, which reports any other error
+		return data, nil
+	}
+
+	var combined bytes.Buffer
+	for i, document := range a.documents {
+		fmt.Fprintf(&combined, "kuttl-anchors-%d:\n", i)
+		indentDocument(&combined, document)
+	}
+	combined.WriteString(sharedDocumentKey + ":\n")
+	indentDocument(&combined, data)
+
+	root := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(combined.Bytes(), &root); err != nil {
+		return nil, fmt.Errorf("resolving the anchors of previous documents: %w", err)
+	}
+	mapping := root.Content[0]
+	document := mapping.Content[len(mapping.Content)-1]
+	var value interface{}
+	if err := document.Decode(&value); err != nil {
+		return nil, fmt.Errorf("resolving the anchors of previous documents: %w", err)
+	}
+	return json.Marshal(value)
+}
+
+// indentDocument writes the lines of the document indented by two spaces, so that it is the value of a key.
+func indentDocument(b *bytes.Buffer, document []byte) {
+	for _, line := range strings.Split(strings.TrimRight(string(document), "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			b.WriteString("  ")
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLoadYAMLAnchors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		yaml   string
+		labels []map[string]interface{}
+		err    string
+	}{
+		{
+			name: "merge key within a document",
+			yaml: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: merged
+  annotations: &common
+    team: storage
+  labels:
+    <<: *common
+    app: db
+`,
+			labels: []map[string]interface{}{{"team": "storage", "app": "db"}},
+		},
+		{
+			name: "anchors of previous documents",
+			yaml: `$anchors:
+  labels: &labels
+    team: storage
+  metadata: &metadata
+    labels: *labels
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  <<: *metadata
+  name: first
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+  labels:
+    <<: *labels
+    app: db
+`,
+			labels: []map[string]interface{}{{"team": "storage"}, {"team": "storage", "app": "db"}},
+		},
+		{
+			name: "later anchors override earlier ones",
+			yaml: `$anchors:
+  labels: &labels
+    team: storage
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+  labels: &labels
+    team: network
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+  labels: *labels
+`,
+			labels: []map[string]interface{}{{"team": "network"}, {"team": "network"}},
+		},
+		{
+			name: "unknown anchor",
+			yaml: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+  labels: &labels
+    team: storage
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+  labels: *missing
+`,
+			err: "unknown anchor 'missing' referenced",
+		},
+		{
+			name: "anchors document with other keys",
+			yaml: `$anchors:
+  labels: &labels
+    team: storage
+kind: ConfigMap
+`,
+			err: "a document defining anchors may only have $anchors",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			objs, err := loadYAML("test.yaml", []byte(test.yaml), 0, nil)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, objs, len(test.labels))
+			for i, obj := range objs {
+				labels, _, _ := unstructured.NestedMap(obj.(*unstructured.Unstructured).Object, "metadata", "labels")
+				assert.Equal(t, test.labels[i], labels)
+			}
+		})
+	}
+}
+
+func TestLoadYAMLAnchorsSource(t *testing.T) {
+	objs, err := loadYAML("test.yaml", []byte(`$anchors:
+  labels: &labels
+    team: storage
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+  labels: *labels
+`), 0, nil)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	// the resolved document keeps its position in the file
+	source, ok := SourceOf(objs[0])
+	assert.True(t, ok)
+	assert.Equal(t, Source{Path: "test.yaml", Document: 2, Line: 5}, source)
+}
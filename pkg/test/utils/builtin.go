@@ -0,0 +1,305 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// builtinCommandsKey is the context key enabling built-in commands.
+type builtinCommandsKey struct{}
+
+// WithBuiltinCommands returns a context in which commands which have a built-in implementation (ex. "kubectl apply")
+// are run by the built-in implementation instead of the host binary.
+func WithBuiltinCommands(ctx context.Context) context.Context {
+	return context.WithValue(ctx, builtinCommandsKey{}, true)
+}
+
+// builtinCommandsEnabled returns true if the context enables built-in commands.
+func builtinCommandsEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(builtinCommandsKey{}).(bool)
+	return enabled
+}
+
+// builtinEnv is the environment of a built-in command.
+type builtinEnv struct {
+	kubeconfig string
+	dir        string
+	stdout     io.Writer
+}
+
+// builtinRunner runs a built-in command.
+type builtinRunner func(ctx context.Context, env builtinEnv) error
+
+// IsBuiltinCommand returns true if the command line args has a built-in implementation.
+func IsBuiltinCommand(args []string) bool {
+	return builtinCommand(args) != nil
+}
+
+// builtinCommand returns the built-in implementation of the command line args, it returns nil if there is none.
+// Commands using flags the built-in implementations do not support are not built-in.  "kubectl exec" and "kubectl
+// port-forward" are not built-in, they stream over SPDY connections to the kubelet which the harness does not
+// implement.
+func builtinCommand(args []string) builtinRunner {
+	if len(args) < 2 || args[0] != "kubectl" {
+		return nil
+	}
+
+	switch args[1] {
+	case "apply":
+		return builtinApply(args[2:])
+	case "wait":
+		return builtinWait(args[2:])
+	}
+	return nil
+}
+
+// builtinApply is the built-in implementation of "kubectl apply -f <file or directory>...", objects are created or
+// updated like the objects of test steps.
+func builtinApply(args []string) builtinRunner {
+	fs := pflag.NewFlagSet("apply", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	files := fs.StringSliceP("filename", "f", nil, "")
+	namespace := fs.StringP("namespace", "n", "", "")
+	if err := fs.Parse(args); err != nil || len(fs.Args()) != 0 || len(*files) == 0 {
+		return nil
+	}
+	for _, file := range *files {
+		if file == "-" || strings.Contains(file, "://") {
+			return nil
+		}
+	}
+
+	return func(ctx context.Context, env builtinEnv) error {
+		cl, dClient, ns, err := builtinClients(env.kubeconfig, *namespace)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range *files {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(env.dir, file)
+			}
+			objects, err := loadManifests(file)
+			if err != nil {
+				return err
+			}
+			for _, obj := range objects {
+				if _, _, err := Namespaced(dClient, obj, ns); err != nil {
+					return err
+				}
+				updated, err := CreateOrUpdate(ctx, cl, obj, true)
+				if err != nil {
+					return err
+				}
+				action := "created"
+				if updated {
+					action = "configured"
+				}
+				fmt.Fprintf(env.stdout, "%s %s\n", ResourceID(obj), action)
+			}
+		}
+		return nil
+	}
+}
+
+// loadManifests loads the objects of a file or of the YAML and JSON files in a directory, like kubectl without
+// --recursive.
+func loadManifests(path string) ([]client.Object, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return LoadYAMLFromFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	objects := []client.Object{}
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+		loaded, err := LoadYAMLFromFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, loaded...)
+	}
+	return objects, nil
+}
+
+// builtinWait is the built-in implementation of "kubectl wait <resource>/<name>... --for=condition=<condition>[=<value>]"
+// and "kubectl wait <resource>/<name>... --for=delete".
+func builtinWait(args []string) builtinRunner {
+	fs := pflag.NewFlagSet("wait", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	forValue := fs.String("for", "", "")
+	namespace := fs.StringP("namespace", "n", "", "")
+	timeout := fs.Duration("timeout", 30*time.Second, "")
+	if err := fs.Parse(args); err != nil || len(fs.Args()) == 0 {
+		return nil
+	}
+
+	var condition, value string
+	switch {
+	case *forValue == "delete":
+	case strings.HasPrefix(*forValue, "condition="):
+		condition, value, _ = strings.Cut(strings.TrimPrefix(*forValue, "condition="), "=")
+		if condition == "" {
+			return nil
+		}
+		if value == "" {
+			value = "True"
+		}
+	default:
+		return nil
+	}
+
+	refs := []schema.GroupResource{}
+	names := []string{}
+	for _, arg := range fs.Args() {
+		resource, name, ok := strings.Cut(arg, "/")
+		if !ok || resource == "" || name == "" {
+			return nil
+		}
+		refs = append(refs, schema.ParseGroupResource(resource))
+		names = append(names, name)
+	}
+
+	return func(ctx context.Context, env builtinEnv) error {
+		cl, dClient, ns, err := builtinClients(env.kubeconfig, *namespace)
+		if err != nil {
+			return err
+		}
+		mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dClient)), dClient)
+
+		for i, ref := range refs {
+			gvk, err := mapper.KindFor(ref.WithVersion(""))
+			if err != nil {
+				return err
+			}
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			obj.SetName(names[i])
+			if _, _, err := Namespaced(dClient, obj, ns); err != nil {
+				return err
+			}
+
+			description := fmt.Sprintf("%s/%s", strings.ToLower(gvk.Kind), names[i])
+			err = wait.PollImmediate(time.Second, *timeout, func() (bool, error) {
+				actual := &unstructured.Unstructured{}
+				actual.SetGroupVersionKind(gvk)
+				if err := cl.Get(ctx, ObjectKey(obj), actual); err != nil {
+					if k8serrors.IsNotFound(err) {
+						return condition == "", nil
+					}
+					return false, err
+				}
+				return condition != "" && hasCondition(actual, condition, value), nil
+			})
+			if err != nil {
+				if err == wait.ErrWaitTimeout {
+					return fmt.Errorf("timed out waiting for the condition on %s", description)
+				}
+				return err
+			}
+
+			if condition == "" {
+				fmt.Fprintf(env.stdout, "%s deleted\n", description)
+			} else {
+				fmt.Fprintf(env.stdout, "%s condition met\n", description)
+			}
+		}
+		return nil
+	}
+}
+
+// hasCondition returns true if obj has a status condition of the type with the status value, both compared
+// case-insensitively like by kubectl.
+func hasCondition(obj *unstructured.Unstructured, conditionType, value string) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _ := condition["type"].(string); !strings.EqualFold(t, conditionType) {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		return strings.EqualFold(status, value)
+	}
+	return false
+}
+
+// builtinClients returns the clients of a built-in command for the kubeconfig, as well as the namespace to use.
+// If namespace is empty, the namespace of the current context of the kubeconfig is used.
+func builtinClients(kubeconfig, namespace string) (client.Client, discovery.DiscoveryInterface, string, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{},
+	)
+	cfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	cl, err := NewRetryClient(cfg, client.Options{Scheme: Scheme()})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	dClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return cl, dClient, namespace, nil
+}
+
+// runBuiltin runs the built-in command if built-in commands are enabled by ctx and args has a built-in
+// implementation, it returns false if the command was not run.
+func runBuiltin(ctx context.Context, args []string, kubeconfig, dir string, stdout io.Writer) (bool, error) {
+	if !builtinCommandsEnabled(ctx) {
+		return false, nil
+	}
+	run := builtinCommand(args)
+	if run == nil {
+		return false, nil
+	}
+
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return true, err
+		}
+	}
+	return true, run(ctx, builtinEnv{kubeconfig: kubeconfig, dir: dir, stdout: stdout})
+}
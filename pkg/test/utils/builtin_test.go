@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsBuiltinCommand(t *testing.T) {
+	for _, test := range []struct {
+		args    []string
+		builtin bool
+	}{
+		{args: []string{"kubectl", "apply", "-f", "pod.yaml"}, builtin: true},
+		{args: []string{"kubectl", "apply", "--filename=a.yaml,b.yaml", "--namespace", "world"}, builtin: true},
+		{args: []string{"kubectl", "apply", "-f", "-"}},
+		{args: []string{"kubectl", "apply", "-f", "https://example.com/pod.yaml"}},
+		{args: []string{"kubectl", "apply", "-k", "overlay"}},
+		{args: []string{"kubectl", "apply", "--server-side", "-f", "pod.yaml"}},
+		{args: []string{"kubectl", "wait", "pod/hello", "--for=condition=Ready"}, builtin: true},
+		{args: []string{"kubectl", "wait", "deployment.apps/web", "pod/hello", "--for=condition=Available=false", "--timeout=1m", "-n", "world"}, builtin: true},
+		{args: []string{"kubectl", "wait", "pod/hello", "--for=delete"}, builtin: true},
+		{args: []string{"kubectl", "wait", "pod/hello", "--for=jsonpath={.status.phase}=Running"}},
+		{args: []string{"kubectl", "wait", "pods", "--all", "--for=condition=Ready"}},
+		{args: []string{"kubectl", "wait", "pod/hello"}},
+		{args: []string{"kubectl", "exec", "hello", "--", "ls"}},
+		{args: []string{"kubectl", "port-forward", "svc/web", "8080:80"}},
+		{args: []string{"kubectl"}},
+		{args: []string{"helm", "apply", "-f", "pod.yaml"}},
+	} {
+		assert.Equal(t, test.builtin, IsBuiltinCommand(test.args), test.args)
+	}
+}
+
+func TestHasCondition(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+				map[string]interface{}{"type": "Degraded", "status": "False"},
+			},
+		},
+	}}
+
+	assert.True(t, hasCondition(obj, "Ready", "True"))
+	assert.True(t, hasCondition(obj, "ready", "true"))
+	assert.True(t, hasCondition(obj, "Degraded", "False"))
+	assert.False(t, hasCondition(obj, "Degraded", "True"))
+	assert.False(t, hasCondition(obj, "Available", "True"))
+	assert.False(t, hasCondition(&unstructured.Unstructured{Object: map[string]interface{}{}}, "Ready", "True"))
+}
+
+func TestLoadManifests(t *testing.T) {
+	dir := t.TempDir()
+	pod := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: %s\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "b"}}`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(pod), 0600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "nested.yaml"), 0755))
+
+	objects, err := loadManifests(dir)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+
+	objects, err = loadManifests(filepath.Join(dir, "a.yaml"))
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	_, err = loadManifests(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestRunBuiltinDisabled(t *testing.T) {
+	ran, err := runBuiltin(context.TODO(), []string{"kubectl", "apply", "-f", "pod.yaml"}, "", "", nil)
+	assert.False(t, ran)
+	assert.NoError(t, err)
+
+	ran, err = runBuiltin(WithBuiltinCommands(context.TODO()), []string{"kubectl", "exec", "hello"}, "", "", nil)
+	assert.False(t, ran)
+	assert.NoError(t, err)
+}
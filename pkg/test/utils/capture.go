@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// ansiRegex matches the ANSI escape sequences of terminal output, ex. colors and cursor movements.
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// StripANSI returns s without its ANSI escape sequences.
+func StripANSI(s string) string {
+	return ansiRegex.ReplaceAllString(s, "")
+}
+
+// A CommandCapture records the stdout and the stderr of the commands run with a context separately, ex. to attach
+// them to the report of a test instead of interleaving them.  The output of each command is preceded by the command
+// in the streams it wrote to.  The output is streamed to temporary files while the commands run rather than kept in
+// memory, Close removes them.
+type CommandCapture struct {
+	// KeepColors keeps the ANSI escape sequences of the output, they are stripped by default.
+	KeepColors bool
+	// Dir is the directory of the temporary files, the default directory for temporary files if empty.
+	Dir string
+
+	lock   sync.Mutex
+	stdout capturedOutput
+	stderr capturedOutput
+	// err is the first error writing the output, the output written after it is dropped.
+	err error
+}
+
+// capturedOutput is the captured stdout or stderr of the commands, its file is created on the first write.
+type capturedOutput struct {
+	file *os.File
+}
+
+// capturedStream is the stdout or the stderr of a command written to a capture, the command is written before its
+// first output.
+type capturedStream struct {
+	capture *CommandCapture
+	output  *capturedOutput
+	header  string
+	started bool
+}
+
+// Write writes p to the file of the output of the stream.  Errors are recorded by the capture instead of being
+// returned, the commands must not fail because their output cannot be captured.
+func (s *capturedStream) Write(p []byte) (int, error) {
+	s.capture.lock.Lock()
+	defer s.capture.lock.Unlock()
+	if s.capture.err != nil {
+		return len(p), nil
+	}
+	if s.output.file == nil {
+		file, err := os.CreateTemp(s.capture.Dir, "kuttl-output-")
+		if err != nil {
+			s.capture.err = err
+			return len(p), nil
+		}
+		s.output.file = file
+	}
+	data := p
+	if !s.started {
+		s.started = true
+		data = append([]byte(s.header), p...)
+	}
+	if _, err := s.output.file.Write(data); err != nil {
+		s.capture.err = err
+	}
+	return len(p), nil
+}
+
+// streams returns the writers of the stdout and stderr of a command.
+func (c *CommandCapture) streams(command string) (io.Writer, io.Writer) {
+	header := fmt.Sprintf("$ %s\n", command)
+	return &capturedStream{capture: c, output: &c.stdout, header: header}, &capturedStream{capture: c, output: &c.stderr, header: header}
+}
+
+// Stdout returns the captured stdout of the commands.
+func (c *CommandCapture) Stdout() string {
+	return c.output(&c.stdout)
+}
+
+// Stderr returns the captured stderr of the commands.
+func (c *CommandCapture) Stderr() string {
+	return c.output(&c.stderr)
+}
+
+// output returns the captured output with the redacted values replaced, they are redacted when the output is
+// read rather than when it is written since a value may be split across writes.  A failure of the capture is
+// appended to the output.
+func (c *CommandCapture) output(captured *capturedOutput) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	output := ""
+	if captured.file != nil {
+		data, err := os.ReadFile(captured.file.Name())
+		if err != nil && c.err == nil {
+			c.err = err
+		}
+		output = string(data)
+	}
+	if !c.KeepColors {
+		output = StripANSI(output)
+	}
+	if c.err != nil {
+		output += fmt.Sprintf("capturing the output failed: %v\n", c.err)
+	}
+	return Redact(output)
+}
+
+// Close removes the files of the captured output, it is no longer available afterwards.
+func (c *CommandCapture) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	errs := []error{}
+	for _, captured := range []*capturedOutput{&c.stdout, &c.stderr} {
+		if captured.file == nil {
+			continue
+		}
+		if err := captured.file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := os.Remove(captured.file.Name()); err != nil {
+			errs = append(errs, err)
+		}
+		captured.file = nil
+	}
+	return errors.Join(errs...)
+}
+
+// commandCaptureKey is the context key of the capture of the output of commands.
+type commandCaptureKey struct{}
+
+// WithCommandCapture returns a context in which the output of commands is recorded by capture, in addition to being
+// logged.  The output of commands which skip logging their output is not recorded.
+func WithCommandCapture(ctx context.Context, capture *CommandCapture) context.Context {
+	if capture == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, commandCaptureKey{}, capture)
+}
+
+// commandCapture returns the capture of the output of commands run with the context, it is nil if there is none.
+func commandCapture(ctx context.Context) *CommandCapture {
+	capture, _ := ctx.Value(commandCaptureKey{}).(*CommandCapture)
+	return capture
+}
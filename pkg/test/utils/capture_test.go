@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestStripANSI(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain", input: "all good\n", expected: "all good\n"},
+		{name: "colors", input: "\x1b[32mPASS\x1b[0m done", expected: "PASS done"},
+		{name: "bold and color", input: "\x1b[1;31mFAIL\x1b[m", expected: "FAIL"},
+		{name: "cursor movement", input: "50%\x1b[2K\x1b[1G100%", expected: "50%100%"},
+		{name: "title", input: "\x1b]0;kuttl\x07output", expected: "output"},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, StripANSI(test.input))
+		})
+	}
+}
+
+func TestRunCommandCapture(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		keepColors bool
+		stdout     string
+		stderr     string
+	}{
+		{
+			name:   "colors stripped",
+			stdout: "$ printf '\\033[32mout\\033[0m\\n'; echo err >&2\nout\n",
+			stderr: "$ printf '\\033[32mout\\033[0m\\n'; echo err >&2\nerr\n",
+		},
+		{
+			name:       "colors kept",
+			keepColors: true,
+			stdout:     "$ printf '\\033[32mout\\033[0m\\n'; echo err >&2\n\x1b[32mout\x1b[0m\n",
+			stderr:     "$ printf '\\033[32mout\\033[0m\\n'; echo err >&2\nerr\n",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			capture := &CommandCapture{KeepColors: test.keepColors, Dir: t.TempDir()}
+			defer capture.Close()
+			ctx := WithCommandCapture(context.TODO(), capture)
+			logger := NewTestLogger(t, "")
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			_, err := RunCommand(ctx, "world", harness.Command{Script: `printf '\033[32mout\033[0m\n'; echo err >&2`}, "", stdout, stderr, logger, 0, "")
+			require.NoError(t, err)
+			// the command still writes to its own streams
+			assert.Equal(t, "\x1b[32mout\x1b[0m\n", stdout.String())
+			assert.Equal(t, "err\n", stderr.String())
+
+			// only the streams a command wrote to have its header
+			_, err = RunCommand(ctx, "world", harness.Command{Script: "echo again"}, "", stdout, stderr, logger, 0, "")
+			require.NoError(t, err)
+			// the output of commands skipping its logging is not captured
+			_, err = RunCommand(ctx, "world", harness.Command{Script: "echo secret", SkipLogOutput: true}, "", stdout, stderr, logger, 0, "")
+			require.NoError(t, err)
+
+			assert.Equal(t, test.stdout+"$ echo again\nagain\n", capture.Stdout())
+			assert.Equal(t, test.stderr, capture.Stderr())
+		})
+	}
+}
+
+func TestCommandCaptureRedact(t *testing.T) {
+	SetRedactedValues([]string{"s3cr3t"})
+	defer SetRedactedValues(nil)
+
+	capture := &CommandCapture{Dir: t.TempDir()}
+	defer capture.Close()
+	ctx := WithCommandCapture(context.TODO(), capture)
+	logger := NewTestLogger(t, "")
+
+	_, err := RunCommand(ctx, "world", harness.Command{Script: "printf s3c; echo r3t; echo s3cr3t >&2"}, "", &bytes.Buffer{}, &bytes.Buffer{}, logger, 0, "")
+	require.NoError(t, err)
+	assert.NotContains(t, capture.Stdout()+capture.Stderr(), "s3cr3t")
+	assert.Equal(t, "$ printf s3c; echo r3t; echo [REDACTED] >&2\n[REDACTED]\n", capture.Stdout())
+	assert.Equal(t, "$ printf s3c; echo r3t; echo [REDACTED] >&2\n[REDACTED]\n", capture.Stderr())
+}
+
+func TestCommandCaptureFiles(t *testing.T) {
+	dir := t.TempDir()
+	capture := &CommandCapture{Dir: dir}
+	ctx := WithCommandCapture(context.TODO(), capture)
+
+	_, err := RunCommand(ctx, "world", harness.Command{Script: "echo out"}, "", &bytes.Buffer{}, &bytes.Buffer{}, NewTestLogger(t, ""), 0, "")
+	require.NoError(t, err)
+	// only the streams the commands wrote to have a file
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "$ echo out\nout\n", capture.Stdout())
+	assert.Equal(t, "", capture.Stderr())
+
+	require.NoError(t, capture.Close())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCommandCaptureFailure(t *testing.T) {
+	capture := &CommandCapture{Dir: filepath.Join(t.TempDir(), "missing")}
+	stdout, _ := capture.streams("echo out")
+
+	// the commands do not fail, the failure is appended to the output
+	n, err := stdout.Write([]byte("out\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Contains(t, capture.Stdout(), "capturing the output failed: open ")
+	assert.NoError(t, capture.Close())
+}
@@ -5,7 +5,10 @@ import (
 	"io"
 
 	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	volumetypes "github.com/docker/docker/api/types/volume"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // DockerClient is a wrapper interface for the Docker library to support unit testing.
@@ -13,4 +16,12 @@ type DockerClient interface {
 	NegotiateAPIVersion(context.Context)
 	VolumeCreate(context.Context, volumetypes.VolumeCreateBody) (dockertypes.Volume, error)
 	ImageSave(context.Context, []string) (io.ReadCloser, error)
+	ImagePull(context.Context, string, dockertypes.ImagePullOptions) (io.ReadCloser, error)
+	ImageBuild(context.Context, io.Reader, dockertypes.ImageBuildOptions) (dockertypes.ImageBuildResponse, error)
+	ImageInspectWithRaw(context.Context, string) (dockertypes.ImageInspect, []byte, error)
+	ContainerInspect(context.Context, string) (dockertypes.ContainerJSON, error)
+	ContainerCreate(context.Context, *container.Config, *container.HostConfig, *network.NetworkingConfig, *specs.Platform, string) (container.ContainerCreateCreatedBody, error)
+	ContainerStart(context.Context, string, dockertypes.ContainerStartOptions) error
+	ContainerRemove(context.Context, string, dockertypes.ContainerRemoveOptions) error
+	NetworkConnect(context.Context, string, string, *network.EndpointSettings) error
 }
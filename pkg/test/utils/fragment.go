@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// fragmentRefKey is the key of the documents including the objects of a fragment file.
+const fragmentRefKey = "$ref"
+
+// maxFragmentDepth is the maximum number of nested fragment references, it stops reference cycles.
+const maxFragmentDepth = 10
+
+// fragmentParamRegex matches the parameters of fragments, ${name}, or an escaped parameter, $${name}.
+var fragmentParamRegex = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// fragmentsDir is the directory the references of fragments are relative to.
+var fragmentsDir = struct {
+	lock sync.RWMutex
+	dir  string
+}{}
+
+// SetFragmentsDir sets the directory of the shared fragments included with $ref, if it is empty references are
+// relative to the file including them.
+func SetFragmentsDir(dir string) {
+	fragmentsDir.lock.Lock()
+	defer fragmentsDir.lock.Unlock()
+	fragmentsDir.dir = dir
+}
+
+// fragmentRef is a document including the objects of a fragment file in place of itself:
+//
+//	$ref: rbac/operator.yaml
+//	params:
+//	  name: my-operator
+//
+// The parameters replace their ${name} references in the fragment, $${name} is not replaced.
+type fragmentRef struct {
+	Ref    string
+	Params map[string]interface{}
+}
+
+// parseFragmentRef returns the fragment reference of the document, it is nil if the document is not a reference.
+func parseFragmentRef(data []byte) (*fragmentRef, error) {
+	doc := map[string]interface{}{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), len(data)).Decode(&doc); err != nil {
+		// the document is decoded again as an object, which reports the error
+		return nil, nil //nolint:nilerr
+	}
+	if _, ok := doc[fragmentRefKey]; !ok {
+		return nil, nil
+	}
+
+	for key := range doc {
+		if key != fragmentRefKey && key != "params" {
+			return nil, fmt.Errorf("fragment reference has an unknown field %q, it may only have $ref and params", key)
+		}
+	}
+	ref, ok := doc[fragmentRefKey].(string)
+	if !ok || ref == "" {
+		return nil, fmt.Errorf("%s must be the path of a fragment file", fragmentRefKey)
+	}
+	params := map[string]interface{}{}
+	if doc["params"] != nil {
+		if params, ok = doc["params"].(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("params of fragment %s must be a map", ref)
+		}
+	}
+	return &fragmentRef{Ref: ref, Params: params}, nil
+}
+
+// path returns the path of the fragment file referenced from the file at from.
+func (f *fragmentRef) path(from string) string {
+	if filepath.IsAbs(f.Ref) {
+		return f.Ref
+	}
+
+	fragmentsDir.lock.RLock()
+	dir := fragmentsDir.dir
+	fragmentsDir.lock.RUnlock()
+	if dir == "" {
+		dir = filepath.Dir(from)
+	}
+	return filepath.Join(dir, f.Ref)
+}
+
+// render returns the content of the fragment file at path with the parameters replaced.  All parameters referenced
+// by the fragment must be set, Jsonnet fragments are rendered with the external variables vars.
+func (f *fragmentRef) render(path string, vars map[string]string) ([]byte, error) {
+	var raw []byte
+	var err error
+	if IsJsonnetFile(path) {
+		raw, err = RenderJsonnet(path, vars)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fragment %s: %w", f.Ref, err)
+	}
+
+	values := map[string]string{}
+	for name, value := range f.Params {
+		switch v := value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("fragment %s: parameter %s must be a string, number or boolean", f.Ref, name)
+		case string:
+			values[name] = v
+		default:
+			// numbers and booleans are inserted like they are written in YAML
+			encoded, _ := json.Marshal(value)
+			values[name] = string(encoded)
+		}
+	}
+
+	missing := map[string]bool{}
+	rendered := fragmentParamRegex.ReplaceAllStringFunc(string(raw), func(match string) string {
+		groups := fragmentParamRegex.FindStringSubmatch(match)
+		if groups[1] != "" {
+			return match[1:]
+		}
+		value, ok := values[groups[2]]
+		if !ok {
+			missing[groups[2]] = true
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("fragment %s: parameters not set: %s", f.Ref, strings.Join(names, ", "))
+	}
+	return []byte(rendered), nil
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const roleFragment = `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: ${name}
+  labels:
+    replicas: "${replicas}"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ${name}-config
+  annotations:
+    literal: $${name}
+`
+
+func writeFile(t *testing.T, path, content string) string {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadYAMLFragments(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "fragments", "rbac", "role.yaml"), roleFragment)
+	assertFile := writeFile(t, filepath.Join(dir, "tests", "install", "00-assert.yaml"), `apiVersion: v1
+kind: Pod
+metadata:
+  name: hello
+---
+$ref: ../../fragments/rbac/role.yaml
+params:
+  name: operator
+  replicas: 3
+`)
+
+	objs, err := LoadYAMLFromFile(assertFile)
+	require.NoError(t, err)
+	require.Len(t, objs, 3)
+	assert.Equal(t, "hello", objs[0].GetName())
+	assert.Equal(t, "operator", objs[1].GetName())
+	assert.Equal(t, map[string]string{"replicas": "3"}, objs[1].GetLabels())
+	assert.Equal(t, "operator-config", objs[2].GetName())
+	assert.Equal(t, map[string]string{"literal": "${name}"}, objs[2].GetAnnotations())
+	source, _ := SourceOf(objs[1])
+	assert.Equal(t, filepath.Join(dir, "fragments", "rbac", "role.yaml"), source.Path)
+
+	// with a fragments directory, references are relative to it
+	SetFragmentsDir(filepath.Join(dir, "fragments"))
+	defer SetFragmentsDir("")
+	assertFile = writeFile(t, filepath.Join(dir, "tests", "upgrade", "00-assert.yaml"), `$ref: rbac/role.yaml
+params: {name: upgraded, replicas: 1}
+`)
+	objs, err = LoadYAMLFromFile(assertFile)
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	assert.Equal(t, "upgraded", objs[0].GetName())
+
+	writeFile(t, filepath.Join(dir, "fragments", "cycle.yaml"), "$ref: cycle.yaml\n")
+	errorsFile := filepath.Join(dir, "tests", "errors", "00-assert.yaml")
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "missing parameter",
+			content: "$ref: rbac/role.yaml\nparams: {name: operator}\n",
+			err:     "error including fragment in " + errorsFile + ": fragment rbac/role.yaml: parameters not set: replicas",
+		},
+		{
+			name:    "unknown field",
+			content: "$ref: rbac/role.yaml\nparameters: {name: operator}\n",
+			err:     "error including fragment in " + errorsFile + `: fragment reference has an unknown field "parameters", it may only have $ref and params`,
+		},
+		{
+			name:    "object parameter",
+			content: "$ref: rbac/role.yaml\nparams: {name: {first: operator}, replicas: 1}\n",
+			err:     "error including fragment in " + errorsFile + ": fragment rbac/role.yaml: parameter name must be a string, number or boolean",
+		},
+		{
+			name:    "cycle",
+			content: "$ref: cycle.yaml\n",
+			err:     "error including fragment cycle.yaml in " + filepath.Join(dir, "fragments", "cycle.yaml") + ": more than 10 nested fragments",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, errorsFile, tt.content)
+			_, err := LoadYAMLFromFile(errorsFile)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
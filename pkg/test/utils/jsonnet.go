@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/go-jsonnet"
+)
+
+// IsJsonnetFile returns true if path is a Jsonnet file which is rendered when it is loaded.
+func IsJsonnetFile(path string) bool {
+	return filepath.Ext(path) == ".jsonnet"
+}
+
+// IsManifestFile returns true if path is a YAML, JSON or Jsonnet file of test step objects.
+func IsManifestFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".yaml", ".json", ".jsonnet":
+		return true
+	}
+	return false
+}
+
+// RenderJsonnet renders the Jsonnet file at path with the external variables vars, they are read with
+// std.extVar(name).  Imports are relative to the file.  It returns a JSON document, or a stream of JSON documents if
+// the file rendered an array of objects.
+func RenderJsonnet(path string, vars map[string]string) ([]byte, error) {
+	vm := jsonnet.MakeVM()
+	for name, value := range vars {
+		vm.ExtVar(name, value)
+	}
+
+	out, err := vm.EvaluateFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("rendering jsonnet %s: %w", path, err)
+	}
+	return jsonnetDocuments([]byte(out))
+}
+
+// jsonnetDocuments returns the rendered output of a Jsonnet file as documents which can be loaded like YAML, an array
+// of objects is split into documents.
+func jsonnetDocuments(out []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(out)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return out, nil
+	}
+
+	items := []json.RawMessage{}
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return nil, fmt.Errorf("decoding rendered jsonnet: %w", err)
+	}
+	documents := &bytes.Buffer{}
+	for _, item := range items {
+		documents.WriteString("---\n")
+		documents.Write(item)
+		documents.WriteString("\n")
+	}
+	return documents.Bytes(), nil
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestJsonnetDocuments(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		out      string
+		expected string
+	}{
+		{
+			name:     "object",
+			out:      "{\n  \"kind\": \"Pod\"\n}\n",
+			expected: "{\n  \"kind\": \"Pod\"\n}\n",
+		},
+		{
+			name:     "array",
+			out:      "[\n  {\"kind\": \"Pod\"},\n  {\"kind\": \"Service\"}\n]\n",
+			expected: "---\n{\"kind\": \"Pod\"}\n---\n{\"kind\": \"Service\"}\n",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			documents, err := jsonnetDocuments([]byte(test.out))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(documents))
+		})
+	}
+}
+
+func TestLoadJsonnet(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "configmap.libsonnet"), []byte(`{
+  configMap(name, data={}):: {
+    apiVersion: 'v1',
+    kind: 'ConfigMap',
+    metadata: { name: name },
+    data: data,
+  },
+}
+`), 0600))
+	path := filepath.Join(dir, "00-install.jsonnet")
+	require.NoError(t, os.WriteFile(path, []byte(`local lib = import 'configmap.libsonnet';
+local env = std.extVar('env');
+
+[
+  lib.configMap('first', { env: env, replicas: std.toString(std.parseInt(std.extVar('replicas')) + 1) }),
+  lib.configMap('second'),
+]
+`), 0600))
+
+	objects, err := LoadYAMLFromFileWithVars(path, map[string]string{"env": "ci", "replicas": "3"})
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "first", objects[0].GetName())
+	assert.Equal(t, "second", objects[1].GetName())
+	data, _, _ := unstructured.NestedStringMap(objects[0].(*unstructured.Unstructured).Object, "data")
+	assert.Equal(t, map[string]string{"env": "ci", "replicas": "4"}, data)
+
+	// the variables the file reads must be set
+	_, err = LoadYAMLFromFile(path)
+	assert.ErrorContains(t, err, "rendering jsonnet "+path)
+	assert.ErrorContains(t, err, "Undefined external variable: env")
+
+	single := filepath.Join(dir, "01-assert.jsonnet")
+	require.NoError(t, os.WriteFile(single, []byte(`(import 'configmap.libsonnet').configMap('single')`), 0600))
+	objects, err = LoadYAMLFromFile(single)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "single", objects[0].GetName())
+}
@@ -85,8 +85,10 @@ func ValidateErrors(err error, errValidationFuncs ...func(error) bool) error {
 // Retry retries a method until the context expires or the method returns an unvalidated error.
 func Retry(ctx context.Context, fn func(context.Context) error, errValidationFuncs ...func(error) bool) error {
 	var lastErr error
-	errCh := make(chan error)
-	doneCh := make(chan struct{})
+	// the channels are buffered so that the goroutine of a call still running when the context expires can exit,
+	// instead of blocking forever on a send nobody receives
+	errCh := make(chan error, 1)
+	doneCh := make(chan struct{}, 1)
 
 	if fn == nil {
 		log.Println("retry func is nil and will be ignored")
@@ -97,8 +99,17 @@ func Retry(ctx context.Context, fn func(context.Context) error, errValidationFun
 	for ok := true; ok; ok = lastErr != nil {
 		// run the function in a goroutine and close it once it is finished so that
 		// we can use select to wait for both the function return and the context deadline.
+		// the goroutine holds a worker until it returns, so that the number of goroutines is bounded.
+		workerCtx, release, err := acquireWorker(ctx)
+		if err != nil {
+			if lastErr == nil {
+				return err
+			}
+			return lastErr
+		}
 
 		go func() {
+			defer release()
 			// if the func we are calling panics, clean up and call it done
 			// the common case is when a shared reference, like a client, is nil and is called in the function
 			defer func() {
@@ -107,7 +118,7 @@ func Retry(ctx context.Context, fn func(context.Context) error, errValidationFun
 				}
 			}()
 
-			if err := fn(ctx); err != nil {
+			if err := fn(workerCtx); err != nil {
 				errCh <- err
 			} else {
 				doneCh <- struct{}{}
@@ -123,6 +134,9 @@ func Retry(ctx context.Context, fn func(context.Context) error, errValidationFun
 			if e := ValidateErrors(err, errValidationFuncs...); e != nil {
 				return e
 			}
+			if V(LogRetry, 2) {
+				log.Printf("retrying after error: %v", err)
+			}
 			lastErr = err
 		// timeout exceeded
 		case <-ctx.Done():
@@ -141,7 +155,7 @@ func Retry(ctx context.Context, fn func(context.Context) error, errValidationFun
 
 // RetryClient implements the Client interface, with retries built in.
 type RetryClient struct {
-	Client    client.Client
+	Client    client.WithWatch
 	dynamic   dynamic.Interface
 	discovery discovery.DiscoveryInterface
 }
@@ -170,7 +184,7 @@ func NewRetryClient(cfg *rest.Config, opts client.Options) (*RetryClient, error)
 		}
 	}
 
-	client, err := client.New(cfg, opts)
+	client, err := client.NewWithWatch(cfg, opts)
 	return &RetryClient{Client: client, dynamic: dynamicClient, discovery: discovery}, err
 }
 
@@ -244,15 +258,25 @@ func (r *RetryClient) List(ctx context.Context, list client.ObjectList, opts ...
 	}, IsJSONSyntaxError)
 }
 
-// Watch watches a specific object and returns all events for it.
-func (r *RetryClient) Watch(_ context.Context, obj runtime.Object) (watch.Interface, error) {
-	meta, err := meta.Accessor(obj)
-	if err != nil {
-		return nil, err
-	}
+// Watch watches the objects of a list for the given namespace and list options, the watch is stopped when the
+// context is done.
+func (r *RetryClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	var events watch.Interface
+	err := Retry(ctx, func(ctx context.Context) error {
+		var err error
+		events, err = r.Client.Watch(ctx, list, opts...)
+		return err
+	}, IsJSONSyntaxError)
+	return events, err
+}
 
+// WatchObject watches a specific object and returns all events for it, the watch is stopped when the context is done.
+func (r *RetryClient) WatchObject(ctx context.Context, obj client.Object) (watch.Interface, error) {
 	gvk := obj.GetObjectKind().GroupVersionKind()
 
+	if V(LogDiscovery, 2) {
+		log.Printf("refreshing API group resources to watch %s", ResourceID(obj))
+	}
 	groupResources, err := restmapper.GetAPIGroupResources(r.discovery)
 	if err != nil {
 		return nil, err
@@ -263,9 +287,12 @@ func (r *RetryClient) Watch(_ context.Context, obj runtime.Object) (watch.Interf
 		return nil, err
 	}
 
-	return r.dynamic.Resource(mapping.Resource).Watch(context.TODO(), metav1.SingleObject(metav1.ObjectMeta{
-		Name:      meta.GetName(),
-		Namespace: meta.GetNamespace(),
+	if V(LogWatch, 1) {
+		log.Printf("watching %s", ResourceID(obj))
+	}
+	return r.dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Watch(ctx, metav1.SingleObject(metav1.ObjectMeta{
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
 	}))
 }
 
@@ -402,6 +429,10 @@ func ConvertUnstructured(in client.Object) (client.Object, error) {
 	switch {
 	case kind == "TestFile":
 		converted = &harness.TestFile{}
+	case kind == "TestMetadata":
+		converted = &harness.TestMetadata{}
+	case kind == "TestCase":
+		converted = &harness.TestCase{}
 	case kind == "TestStep":
 		converted = &harness.TestStep{}
 	case kind == "TestAssert":
@@ -484,24 +515,48 @@ func MarshalObjectJSON(o runtime.Object, w io.Writer) error {
 	return json.NewSerializer(json.DefaultMetaFactory, nil, nil, false).Encode(copied, w)
 }
 
-// LoadYAMLFromFile loads all objects from a YAML file.
+// LoadYAMLFromFile loads all objects from a YAML or JSON file, Jsonnet files are rendered first.
 func LoadYAMLFromFile(path string) ([]client.Object, error) {
-	opened, err := os.Open(path)
+	return LoadYAMLFromFileWithVars(path, nil)
+}
+
+// LoadYAMLFromFileWithVars loads all objects from a file like LoadYAMLFromFile, Jsonnet files and the Jsonnet
+// fragments they reference are rendered with the external variables vars.
+func LoadYAMLFromFileWithVars(path string, vars map[string]string) ([]client.Object, error) {
+	var raw []byte
+	var err error
+	if IsJsonnetFile(path) {
+		raw, err = RenderJsonnet(path, vars)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer opened.Close()
-
-	return LoadYAML(path, opened)
+	return loadYAML(path, raw, 0, vars)
 }
 
-// LoadYAML loads all objects from a reader
+// LoadYAML loads all objects from a reader.  Documents with a $ref are replaced by the objects of the fragment file
+// they reference.
 func LoadYAML(path string, r io.Reader) ([]client.Object, error) {
-	yamlReader := yaml.NewYAMLReader(bufio.NewReader(r))
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading yaml %s: %w", path, err)
+	}
+	return loadYAML(path, raw, 0, nil)
+}
+
+// loadYAML loads all objects from raw, depth is the number of fragment references raw was included by.  Jsonnet
+// fragments are rendered with the external variables vars.
+func loadYAML(path string, raw []byte, depth int, vars map[string]string) ([]client.Object, error) {
+	lines := documentLines(raw)
+
+	yamlReader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(raw)))
 
 	objects := []client.Object{}
+	anchors := &sharedAnchors{}
 
-	for {
+	for document := 1; ; document++ {
 		data, err := yamlReader.Read()
 		if err != nil {
 			if err == io.EOF {
@@ -509,6 +564,34 @@ func LoadYAML(path string, r io.Reader) ([]client.Object, error) {
 			}
 			return nil, fmt.Errorf("error reading yaml %s: %w", path, err)
 		}
+		data, definitions, err := anchors.resolve(data)
+		if err != nil {
+			return nil, fmt.Errorf("error reading yaml %s: %w", path, err)
+		}
+		if definitions {
+			continue
+		}
+
+		ref, err := parseFragmentRef(data)
+		if err != nil {
+			return nil, fmt.Errorf("error including fragment in %s: %w", path, err)
+		}
+		if ref != nil {
+			if depth >= maxFragmentDepth {
+				return nil, fmt.Errorf("error including fragment %s in %s: more than %d nested fragments", ref.Ref, path, maxFragmentDepth)
+			}
+			fragmentPath := ref.path(path)
+			rendered, err := ref.render(fragmentPath, vars)
+			if err != nil {
+				return nil, fmt.Errorf("error including fragment in %s: %w", path, err)
+			}
+			included, err := loadYAML(fragmentPath, rendered, depth+1, vars)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, included...)
+			continue
+		}
 
 		unstructuredObj := &unstructured.Unstructured{}
 		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewBuffer(data), len(data))
@@ -526,6 +609,11 @@ func LoadYAML(path string, r io.Reader) ([]client.Object, error) {
 		if obj.GetObjectKind().GroupVersionKind().Kind == "" {
 			log.Println("object detected with no GVK Kind for path", path)
 		} else {
+			source := Source{Path: path, Document: document}
+			if document <= len(lines) {
+				source.Line = lines[document-1]
+			}
+			SetSource(obj, source)
 			objects = append(objects, obj)
 		}
 	}
@@ -564,9 +652,10 @@ func InstallManifests(ctx context.Context, c client.Client, dClient discovery.Di
 		}
 
 		extensions := map[string]bool{
-			".yaml": true,
-			".yml":  true,
-			".json": true,
+			".yaml":    true,
+			".yml":     true,
+			".json":    true,
+			".jsonnet": true,
 		}
 		if !extensions[filepath.Ext(path)] {
 			return nil
@@ -789,6 +878,8 @@ func FakeDiscoveryClient() discovery.DiscoveryInterface {
 						{Name: "pod", Namespaced: true, Kind: "Pod"},
 						{Name: "namespace", Namespaced: false, Kind: "Namespace"},
 						{Name: "service", Namespaced: true, Kind: "Service"},
+						{Name: "configmap", Namespaced: true, Kind: "ConfigMap"},
+						{Name: "secret", Namespaced: true, Kind: "Secret"},
 					},
 				},
 				{
@@ -869,6 +960,69 @@ func CreateOrUpdate(ctx context.Context, cl client.Client, obj client.Object, re
 	return updated, err
 }
 
+// DefaultFieldManager is the field manager of objects applied with server-side apply if none is set.
+const DefaultFieldManager = "kuttl"
+
+// ServerSideApply applies obj with server-side apply as fieldManager.  If force is set, fields owned by other field
+// managers are taken over, otherwise conflicts with them fail the apply.  It returns true if the object already
+// existed and was updated.
+func ServerSideApply(ctx context.Context, cl client.Client, obj client.Object, fieldManager string, force bool) (updated bool, err error) {
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	opts := []client.PatchOption{client.FieldOwner(fieldManager)}
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	err = cl.Get(ctx, ObjectKey(obj), actual)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+	updated = err == nil
+
+	// the response is decoded into the applied object, obj is kept as it was loaded so it can be applied again
+	applied, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return false, fmt.Errorf("cannot copy %s", ResourceID(obj))
+	}
+	if err := cl.Patch(ctx, applied, client.Apply, opts...); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return updated, errors.New("server-side apply timeout exceeded")
+		}
+		return updated, err
+	}
+	return updated, nil
+}
+
+// UpdateStatus writes the status of obj to the status subresource of the object in the cluster with a merge patch.
+// Objects without a status are left as they are.
+func UpdateStatus(ctx context.Context, cl client.Client, obj client.Object) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	status, ok := content["status"]
+	if !ok {
+		return nil
+	}
+	patch, err := apijson.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	actual.SetName(obj.GetName())
+	actual.SetNamespace(obj.GetNamespace())
+	if err := cl.Status().Patch(ctx, actual, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return fmt.Errorf("updating status of %s: %w", ResourceID(obj), err)
+	}
+	return nil
+}
+
 // SetAnnotation sets the given key and value in the object's annotations, returning a copy.
 func SetAnnotation(obj *unstructured.Unstructured, key, value string) *unstructured.Unstructured {
 	obj = obj.DeepCopy()
@@ -885,6 +1039,9 @@ func SetAnnotation(obj *unstructured.Unstructured, key, value string) *unstructu
 
 // GetAPIResource returns the APIResource object for a specific GroupVersionKind.
 func GetAPIResource(dClient discovery.DiscoveryInterface, gvk schema.GroupVersionKind) (metav1.APIResource, error) {
+	if V(LogDiscovery, 3) {
+		log.Printf("looking up API resource of %s", gvk)
+	}
 	resourceTypes, err := dClient.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
 	if err != nil {
 		return metav1.APIResource{}, err
@@ -945,13 +1102,16 @@ func WaitForSA(config *rest.Config, name, namespace string) error {
 	})
 }
 
-// Client is the controller-runtime Client interface with an added Watch method.
+// Client is the controller-runtime WithWatch interface with an added WatchObject method.
 type Client interface {
-	client.Client
-	// Watch watches a specific object and returns all events for it.
-	Watch(ctx context.Context, obj runtime.Object) (watch.Interface, error)
+	client.WithWatch
+	// WatchObject watches a specific object and returns all events for it, the watch is stopped when the context is
+	// done.
+	WatchObject(ctx context.Context, obj client.Object) (watch.Interface, error)
 }
 
+var _ Client = &RetryClient{}
+
 // TestEnvironment is a struct containing the envtest environment, Kubernetes config and clients.
 type TestEnvironment struct {
 	Environment     *envtest.Environment
@@ -960,11 +1120,41 @@ type TestEnvironment struct {
 	DiscoveryClient discovery.DiscoveryInterface
 }
 
+// serviceClusterIPRanges are the service CIDRs of the API server of a test environment by IP family, the IPv4 range is
+// the default of envtest.
+var serviceClusterIPRanges = map[string]string{
+	"ipv4": "10.0.0.0/24",
+	"ipv6": "fd00:10:96::/112",
+	"dual": "10.0.0.0/24,fd00:10:96::/112",
+}
+
+// TestEnvironmentOptions are the options of a test environment started with StartTestEnvironmentWithOptions.
+type TestEnvironmentOptions struct {
+	// AttachControlPlaneOutput attaches the output of the API server and etcd to the output of the process.
+	AttachControlPlaneOutput bool
+	// IPFamily is the IP family of the addresses of the services of the API server (ipv4, ipv6 or dual), the default
+	// is ipv4.
+	IPFamily string
+}
+
 // StartTestEnvironment is a wrapper for controller-runtime's envtest that creates a Kubernetes API server and etcd
 // suitable for use in tests.
 func StartTestEnvironment(attachControlPlaneOutput bool) (env TestEnvironment, err error) {
+	return StartTestEnvironmentWithOptions(TestEnvironmentOptions{AttachControlPlaneOutput: attachControlPlaneOutput})
+}
+
+// StartTestEnvironmentWithOptions starts a test environment like StartTestEnvironment with the options.
+func StartTestEnvironmentWithOptions(opts TestEnvironmentOptions) (env TestEnvironment, err error) {
 	env.Environment = &envtest.Environment{
-		AttachControlPlaneOutput: attachControlPlaneOutput,
+		AttachControlPlaneOutput: opts.AttachControlPlaneOutput,
+	}
+	if opts.IPFamily != "" {
+		serviceRange, ok := serviceClusterIPRanges[opts.IPFamily]
+		if !ok {
+			err = fmt.Errorf("invalid IP family %q, must be ipv4, ipv6 or dual", opts.IPFamily)
+			return
+		}
+		env.Environment.ControlPlane.GetAPIServer().Configure().Set("service-cluster-ip-range", serviceRange)
 	}
 
 	env.Config, err = env.Environment.Start()
@@ -997,8 +1187,9 @@ func GetArgs(ctx context.Context, cmd harness.Command, namespace string, envMap
 	}
 
 	if cmd.Script != "" {
+		shell := ScriptShell()
 		// #nosec G204 sec is challenged by a variable being used by exec, but that is by design
-		builtCmd := exec.CommandContext(ctx, "sh", "-c", cmd.Script)
+		builtCmd := exec.CommandContext(ctx, shell[0], append(shell[1:], cmd.Script)...)
 		return builtCmd, nil
 	}
 	c := env.ExpandWithMap(cmd.Command, envMap)
@@ -1030,19 +1221,55 @@ func GetArgs(ctx context.Context, cmd harness.Command, namespace string, envMap
 	return builtCmd, nil
 }
 
+// commandEnvKey is the context key of the additional environment variables of commands.
+type commandEnvKey struct{}
+
+// WithCommandEnv returns a context in which commands are run with the environment variables of env (ex. the path of
+// the event journal of a test) in addition to NAMESPACE, KUBECONFIG and PATH.  A KUBECONFIG in env is the kubeconfig
+// of the commands which have no kubeconfig override.
+func WithCommandEnv(ctx context.Context, env map[string]string) context.Context {
+	if len(env) == 0 {
+		return ctx
+	}
+	merged := map[string]string{}
+	for key, value := range commandEnv(ctx) {
+		merged[key] = value
+	}
+	for key, value := range env {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, commandEnvKey{}, merged)
+}
+
+// commandEnv returns the additional environment variables of commands run with the context.
+func commandEnv(ctx context.Context) map[string]string {
+	env, _ := ctx.Value(commandEnvKey{}).(map[string]string)
+	return env
+}
+
 // RunCommand runs a command with args.
 // args gets split on spaces (respecting quoted strings).
 // if the command is run in the background a reference to the process is returned for later cleanup
 func RunCommand(ctx context.Context, namespace string, cmd harness.Command, cwd string, stdout io.Writer, stderr io.Writer, logger Logger, timeout int, kubeconfigOverride string) (*exec.Cmd, error) {
+	if !MatchesPlatform(cmd.Platforms) {
+		logger.Logf("skipping command %q, it only runs on %v", commandName(cmd), cmd.Platforms)
+		return nil, nil
+	}
+
 	actualDir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("command %q with %w", cmd.Command, err)
 	}
 
 	kuttlENV := make(map[string]string)
+	for key, value := range commandEnv(ctx) {
+		kuttlENV[key] = value
+	}
 	kuttlENV["NAMESPACE"] = namespace
-	kuttlENV["KUBECONFIG"] = kubeconfigPath(actualDir, kubeconfigOverride)
-	kuttlENV["PATH"] = fmt.Sprintf("%s/bin/:%s", actualDir, os.Getenv("PATH"))
+	if _, ok := kuttlENV["KUBECONFIG"]; !ok || kubeconfigOverride != "" {
+		kuttlENV["KUBECONFIG"] = kubeconfigPath(actualDir, kubeconfigOverride)
+	}
+	kuttlENV["PATH"] = filepath.Join(actualDir, "bin") + string(os.PathListSeparator) + os.Getenv("PATH")
 
 	// by default testsuite timeout is the command timeout
 	// 0 is allowed for testsuite which means forever (or no timeout)
@@ -1065,18 +1292,48 @@ func RunCommand(ctx context.Context, namespace string, cmd harness.Command, cwd
 		cmdCtx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
 		defer cancel()
 	}
+	// foreground commands wait for a worker, background commands run as long as their test and do not take one
+	if !cmd.Background {
+		var release func()
+		if cmdCtx, release, err = acquireWorker(cmdCtx); err != nil {
+			return nil, fmt.Errorf("command %q: %w", commandName(cmd), err)
+		}
+		defer release()
+	}
 
 	builtCmd, err := GetArgs(cmdCtx, cmd, namespace, kuttlENV)
 	if err != nil {
 		return nil, fmt.Errorf("processing command %q with %w", cmd.Command, err)
 	}
 
+	cmdStdout, cmdStderr, ended, err := commandOutput(cmd, stdout, stderr)
+	if err != nil {
+		return nil, fmt.Errorf("command %q: %w", commandName(cmd), err)
+	}
+	if capture := commandCapture(ctx); capture != nil && !cmd.SkipLogOutput {
+		capturedStdout, capturedStderr := capture.streams(commandName(cmd))
+		cmdStdout, cmdStderr = io.MultiWriter(cmdStdout, capturedStdout), io.MultiWriter(cmdStderr, capturedStderr)
+	}
+
 	logger.Logf("running command: %v", builtCmd.Args)
 
+	if !cmd.Background {
+		if ran, err := runBuiltin(cmdCtx, builtCmd.Args, kuttlENV["KUBECONFIG"], cwd, cmdStdout); ran {
+			ended(err)
+			if err != nil && cmd.IgnoreFailure {
+				return nil, nil
+			}
+			if errors.Is(cmdCtx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("command %q exceeded %v sec timeout, %w", cmd.Command, timeout, cmdCtx.Err())
+			}
+			return nil, err
+		}
+	}
+
 	builtCmd.Dir = cwd
 	if !cmd.SkipLogOutput {
-		builtCmd.Stdout = stdout
-		builtCmd.Stderr = stderr
+		builtCmd.Stdout = cmdStdout
+		builtCmd.Stderr = cmdStderr
 	}
 	builtCmd.Env = os.Environ()
 	for key, value := range kuttlENV {
@@ -1098,6 +1355,7 @@ func RunCommand(ctx context.Context, namespace string, cmd harness.Command, cwd
 	}
 
 	err = builtCmd.Wait()
+	ended(err)
 	if errors.As(err, &exerr) && cmd.IgnoreFailure {
 		return nil, nil
 	}
@@ -1114,7 +1372,15 @@ func kubeconfigPath(actualDir, override string) string {
 		}
 		return filepath.Join(actualDir, override)
 	}
-	return fmt.Sprintf("%s/kubeconfig", actualDir)
+	return filepath.Join(actualDir, "kubeconfig")
+}
+
+// commandName returns the command or the script of cmd for log messages.
+func commandName(cmd harness.Command) string {
+	if cmd.Command != "" {
+		return cmd.Command
+	}
+	return cmd.Script
 }
 
 // convertAssertCommand converts a set of TestAssertCommand to Commands so it all the existing functions can be used
@@ -1128,6 +1394,8 @@ func convertAssertCommand(assertCommands []harness.TestAssertCommand, timeout in
 			Namespaced:    assertCommand.Namespaced,
 			Script:        assertCommand.Script,
 			SkipLogOutput: assertCommand.SkipLogOutput,
+			Output:        assertCommand.Output,
+			Platforms:     assertCommand.Platforms,
 			Timeout:       timeout,
 			// This fields will always be this constants for assertions
 			IgnoreFailure: false,
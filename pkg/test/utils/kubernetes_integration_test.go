@@ -8,11 +8,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -78,6 +81,34 @@ func TestCreateOrUpdate(t *testing.T) {
 	}
 }
 
+func TestServerSideApplyConflicts(t *testing.T) {
+	namespaceObj := NewResource("v1", "Namespace", "server-side-apply", "")
+	_, err := CreateOrUpdate(context.TODO(), testenv.Client, namespaceObj, true)
+	assert.Nil(t, err)
+
+	configMap := func(value string) *unstructured.Unstructured {
+		obj := NewResource("v1", "ConfigMap", "managed", "server-side-apply")
+		obj.Object["data"] = map[string]interface{}{"key": value}
+		return obj
+	}
+
+	updated, err := ServerSideApply(context.TODO(), testenv.Client, configMap("operator"), "operator", false)
+	assert.Nil(t, err)
+	assert.False(t, updated)
+
+	_, err = ServerSideApply(context.TODO(), testenv.Client, configMap("other"), "", false)
+	assert.True(t, k8serrors.IsConflict(err))
+
+	updated, err = ServerSideApply(context.TODO(), testenv.Client, configMap("other"), "", true)
+	assert.Nil(t, err)
+	assert.True(t, updated)
+
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(configMap("").GroupVersionKind())
+	assert.Nil(t, testenv.Client.Get(context.TODO(), ObjectKey(configMap("")), actual))
+	assert.Equal(t, map[string]interface{}{"key": "other"}, actual.Object["data"])
+}
+
 func TestClientWatch(t *testing.T) {
 	pod := WithSpec(t, NewPod("my-pod", "default"), map[string]interface{}{
 		"containers": []map[string]interface{}{
@@ -89,7 +120,7 @@ func TestClientWatch(t *testing.T) {
 	})
 	gvk := pod.GetObjectKind().GroupVersionKind()
 
-	events, err := testenv.Client.Watch(context.TODO(), pod)
+	events, err := testenv.Client.WatchObject(context.TODO(), pod)
 	assert.Nil(t, err)
 
 	go func() {
@@ -217,3 +248,32 @@ func TestRunCommandSkipLogOutput(t *testing.T) {
 	assert.Nil(t, cmd)
 	assert.True(t, stdout.Len() == 0)
 }
+
+func TestRunBuiltinCommands(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	f, err := os.Create(kubeconfig)
+	assert.NoError(t, err)
+	assert.NoError(t, Kubeconfig(testenv.Config, f))
+	assert.NoError(t, f.Close())
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "configmap.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: builtin\n"), 0600))
+
+	ctx := WithBuiltinCommands(context.TODO())
+	logger := NewTestLogger(t, "")
+	stdout := &bytes.Buffer{}
+
+	// kubectl need not be installed for built-in commands
+	_, err = RunCommand(ctx, "default", harness.Command{Command: "kubectl apply -f configmap.yaml", Namespaced: true}, dir, stdout, stdout, logger, 0, kubeconfig)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "ConfigMap:default/builtin created")
+
+	configMap := NewResource("v1", "ConfigMap", "builtin", "default")
+	assert.NoError(t, testenv.Client.Get(context.TODO(), ObjectKey(configMap), configMap))
+	assert.NoError(t, testenv.Client.Delete(context.TODO(), configMap))
+
+	stdout.Reset()
+	_, err = RunCommand(ctx, "default", harness.Command{Command: "kubectl wait configmap/builtin --for=delete --timeout=10s", Namespaced: true}, dir, stdout, stdout, logger, 0, kubeconfig)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "configmap/builtin deleted")
+}
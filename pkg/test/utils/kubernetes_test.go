@@ -5,16 +5,23 @@ import (
 	"context"
 	"errors"
 	"os"
+	goruntime "runtime"
 	"testing"
 	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
 
 	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
 )
@@ -167,6 +174,44 @@ func TestRetryWithTimeout(t *testing.T) {
 	}, func(err error) bool { return true }))
 }
 
+func TestRetryWithTimeoutDoesNotLeak(t *testing.T) {
+	before := goruntime.NumGoroutine()
+	release := make(chan struct{})
+
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		assert.Equal(t, context.DeadlineExceeded, Retry(ctx, func(context.Context) error {
+			<-release
+			return errors.New("error")
+		}))
+		cancel()
+	}
+
+	// the calls abandoned at the deadline return once they are released
+	close(release)
+	// assert.Eventually runs its condition in a goroutine of its own, the goroutines are counted here instead
+	deadline := time.Now().Add(5 * time.Second)
+	for goruntime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, goruntime.NumGoroutine(), before)
+}
+
+func TestRetryClientWatch(t *testing.T) {
+	c := RetryClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.Watch(ctx, &corev1.ConfigMapList{}, client.InNamespace("default"))
+	assert.NoError(t, err)
+	defer events.Stop()
+
+	assert.NoError(t, c.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "watched", Namespace: "default"}}))
+	event := <-events.ResultChan()
+	assert.Equal(t, watch.Added, event.Type)
+	assert.Equal(t, client.ObjectKey{Namespace: "default", Name: "watched"}, ObjectKey(event.Object))
+}
+
 func TestLoadYAML(t *testing.T) {
 	tmpfile, err := os.CreateTemp("", "test.yaml")
 	assert.Nil(t, err)
@@ -515,3 +560,113 @@ func TestRunScript(t *testing.T) {
 		})
 	}
 }
+
+// applyRecorder records server-side applies, which the fake client does not support.
+type applyRecorder struct {
+	client.Client
+	options *client.PatchOptions
+	applied client.Object
+}
+
+func (r *applyRecorder) Patch(_ context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return errors.New("not a server-side apply")
+	}
+	r.options = (&client.PatchOptions{}).ApplyOptions(opts)
+	r.applied = obj
+	return nil
+}
+
+func TestServerSideApplyOptions(t *testing.T) {
+	existing := NewPod("existing", "world")
+
+	for _, test := range []struct {
+		name            string
+		obj             client.Object
+		fieldManager    string
+		force           bool
+		updated         bool
+		expectedManager string
+	}{
+		{
+			name:            "default field manager",
+			obj:             NewPod("new", "world"),
+			expectedManager: DefaultFieldManager,
+		},
+		{
+			name:            "forced update",
+			obj:             existing,
+			fieldManager:    "other-controller",
+			force:           true,
+			updated:         true,
+			expectedManager: "other-controller",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cl := &applyRecorder{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(existing.DeepCopy()).Build()}
+
+			updated, err := ServerSideApply(context.TODO(), cl, test.obj, test.fieldManager, test.force)
+			assert.NoError(t, err)
+			assert.Equal(t, test.updated, updated)
+			assert.Equal(t, test.expectedManager, cl.options.FieldManager)
+			assert.Equal(t, test.force, cl.options.Force != nil && *cl.options.Force)
+			// a copy is applied, so that the response does not change the object
+			assert.NotSame(t, test.obj, cl.applied)
+			assert.Equal(t, test.obj, cl.applied)
+		})
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(NewPod("running", "world")).Build()
+
+	obj := NewPod("running", "world")
+	obj.Object["status"] = map[string]interface{}{"phase": "Running"}
+	assert.NoError(t, UpdateStatus(context.TODO(), cl, obj))
+
+	actual := &corev1.Pod{}
+	assert.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Name: "running", Namespace: "world"}, actual))
+	assert.Equal(t, corev1.PodRunning, actual.Status.Phase)
+
+	// objects without a status are not patched, even if they do not exist
+	assert.NoError(t, UpdateStatus(context.TODO(), cl, NewPod("missing", "world")))
+	obj = NewPod("missing", "world")
+	obj.Object["status"] = map[string]interface{}{"phase": "Running"}
+	assert.EqualError(t, UpdateStatus(context.TODO(), cl, obj), `updating status of Pod:world/missing: pods "missing" not found`)
+}
+
+func TestRunCommandEnv(t *testing.T) {
+	logger := NewTestLogger(t, "")
+	ctx := WithCommandEnv(context.TODO(), map[string]string{"JOURNAL": "events.jsonl", "NAMESPACE": "overridden"})
+	ctx = WithCommandEnv(ctx, map[string]string{"OTHER": "value"})
+
+	stdout := &bytes.Buffer{}
+	_, err := RunCommand(ctx, "world", harness.Command{Script: "echo $JOURNAL $OTHER $NAMESPACE"}, "", stdout, stdout, logger, 0, "")
+	assert.NoError(t, err)
+	// the variables of the context do not override the ones set by kuttl
+	assert.Equal(t, "events.jsonl value world\n", stdout.String())
+}
+
+func TestRunCommandKubeconfigEnv(t *testing.T) {
+	logger := NewTestLogger(t, "")
+	ctx := WithCommandEnv(context.TODO(), map[string]string{"KUBECONFIG": "/tmp/test/kubeconfig"})
+	cmd := harness.Command{Script: "echo $KUBECONFIG"}
+
+	stdout := &bytes.Buffer{}
+	_, err := RunCommand(ctx, "world", cmd, "", stdout, stdout, logger, 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/test/kubeconfig\n", stdout.String())
+
+	// the kubeconfig override of the command takes precedence
+	stdout.Reset()
+	_, err = RunCommand(ctx, "world", cmd, "", stdout, stdout, logger, 0, "/tmp/step/kubeconfig")
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/step/kubeconfig\n", stdout.String())
+}
+
+func TestStartTestEnvironmentIPFamily(t *testing.T) {
+	_, err := StartTestEnvironmentWithOptions(TestEnvironmentOptions{IPFamily: "ipv5"})
+	assert.EqualError(t, err, `invalid IP family "ipv5", must be ipv4, ipv6 or dual`)
+}
@@ -35,11 +35,15 @@ func NewTestLogger(test *testing.T, prefix string) *TestLogger {
 }
 
 // Log logs the provided arguments with the logger's prefix. See testing.Log for more details.
+// The values set by SetRedactedValues are redacted.
 func (t *TestLogger) Log(args ...interface{}) {
-	args = append([]interface{}{
+	redactedArgs := []interface{}{
 		fmt.Sprintf("%s | %s |", time.Now().Format("15:04:05"), t.prefix),
-	}, args...)
-	t.test.Log(args...)
+	}
+	for _, arg := range args {
+		redactedArgs = append(redactedArgs, Redact(fmt.Sprint(arg)))
+	}
+	t.test.Log(redactedArgs...)
 }
 
 // Logf logs the provided arguments with the logger's prefix. See testing.Logf for more details.
@@ -58,7 +62,8 @@ func (t *TestLogger) Write(p []byte) (n int, err error) {
 	t.buffer = append(t.buffer, p...)
 
 	splitBuf := bytes.Split(t.buffer, []byte{'\n'})
-	t.buffer = splitBuf[len(splitBuf)-1]
+	// the incomplete line is copied, so that the lines already logged are not kept in memory by the buffer
+	t.buffer = append([]byte{}, splitBuf[len(splitBuf)-1]...)
 
 	for _, line := range splitBuf[:len(splitBuf)-1] {
 		t.Log(string(line))
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// The policies of logging the output of commands.
+const (
+	OutputAlways      = "always"
+	OutputOnFailure   = "onFailure"
+	OutputSummaryOnly = "summaryOnly"
+)
+
+// defaultSummaryLines is the number of the first and of the last lines of the output logged with summaryOnly.
+const defaultSummaryLines = 10
+
+// bufferedOutput is the output of a command which is logged once the command ends, stdout and stderr are written to
+// it concurrently.
+type bufferedOutput struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (o *bufferedOutput) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.buf.Write(p)
+}
+
+// commandOutput returns the writers of the stdout and stderr of cmd following its output policy, and a function to be
+// called with the error of the command once it ended, which logs the output the policy buffered to stdout.
+func commandOutput(cmd harness.Command, stdout, stderr io.Writer) (io.Writer, io.Writer, func(err error), error) {
+	ended := func(error) {}
+	if cmd.SkipLogOutput {
+		return io.Discard, io.Discard, ended, nil
+	}
+	if cmd.Output == nil || cmd.Output.Log == "" || cmd.Output.Log == OutputAlways {
+		return stdout, stderr, ended, nil
+	}
+
+	policy := *cmd.Output
+	switch policy.Log {
+	case OutputOnFailure, OutputSummaryOnly:
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown output log %q, must be one of %s, %s or %s", policy.Log, OutputAlways, OutputOnFailure, OutputSummaryOnly)
+	}
+	if policy.Head < 0 || policy.Tail < 0 {
+		return nil, nil, nil, errors.New("the head and tail of the output cannot be negative")
+	}
+	if cmd.Background {
+		return nil, nil, nil, fmt.Errorf("the output of background commands cannot be logged %s", policy.Log)
+	}
+
+	buffered := &bufferedOutput{}
+	ended = func(err error) {
+		buffered.lock.Lock()
+		defer buffered.lock.Unlock()
+
+		switch {
+		case policy.Log == OutputSummaryOnly:
+			_, _ = io.WriteString(stdout, summarizeOutput(buffered.buf.String(), policy.Head, policy.Tail))
+		case err != nil:
+			_, _ = stdout.Write(buffered.buf.Bytes())
+		}
+	}
+	return buffered, buffered, ended, nil
+}
+
+// summarizeOutput returns the first head and the last tail lines of output, the lines in between are replaced with the
+// number of omitted lines.  Zero head or tail use the default number of lines.
+func summarizeOutput(output string, head, tail int) string {
+	if head == 0 {
+		head = defaultSummaryLines
+	}
+	if tail == 0 {
+		tail = defaultSummaryLines
+	}
+
+	lines := strings.SplitAfter(output, "\n")
+	// the output ends with a newline, it does not start a line
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= head+tail {
+		return output
+	}
+	omitted := len(lines) - head - tail
+	return strings.Join(lines[:head], "") + fmt.Sprintf("... %d lines omitted ...\n", omitted) + strings.Join(lines[len(lines)-tail:], "")
+}
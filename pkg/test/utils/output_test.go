@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// numberedLines returns the output of n numbered lines, ex. "1\n2\n".
+func numberedLines(n int) string {
+	lines := ""
+	for i := 1; i <= n; i++ {
+		lines += fmt.Sprintf("%d\n", i)
+	}
+	return lines
+}
+
+func TestSummarizeOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name, output string
+		head, tail   int
+		expected     string
+	}{
+		{name: "empty", output: "", expected: ""},
+		{name: "short", output: numberedLines(4), head: 2, tail: 2, expected: numberedLines(4)},
+		{name: "long", output: numberedLines(6), head: 2, tail: 1, expected: "1\n2\n... 3 lines omitted ...\n6\n"},
+		{name: "no trailing newline", output: strings.TrimSuffix(numberedLines(6), "\n"), head: 1, tail: 1, expected: "1\n... 4 lines omitted ...\n6"},
+		{name: "defaults", output: numberedLines(25), expected: numberedLines(10) + "... 5 lines omitted ...\n" + strings.TrimPrefix(numberedLines(25), numberedLines(15))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, summarizeOutput(tt.output, tt.head, tt.tail))
+		})
+	}
+}
+
+func TestCommandOutputErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cmd  harness.Command
+		err  string
+	}{
+		{name: "unknown", cmd: harness.Command{Output: &harness.CommandOutput{Log: "sometimes"}}, err: `unknown output log "sometimes", must be one of always, onFailure or summaryOnly`},
+		{name: "negative", cmd: harness.Command{Output: &harness.CommandOutput{Log: OutputSummaryOnly, Tail: -1}}, err: "the head and tail of the output cannot be negative"},
+		{name: "background", cmd: harness.Command{Background: true, Output: &harness.CommandOutput{Log: OutputOnFailure}}, err: "the output of background commands cannot be logged onFailure"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := commandOutput(tt.cmd, &bytes.Buffer{}, &bytes.Buffer{})
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestRunCommandOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		script   string
+		output   *harness.CommandOutput
+		skip     bool
+		expected string
+	}{
+		{name: "always", script: "echo out; echo err >&2", expected: "out\nerr\n"},
+		{name: "skipped", script: "echo out", skip: true, output: &harness.CommandOutput{Log: OutputAlways}, expected: ""},
+		{name: "on failure, succeeded", script: "echo out", output: &harness.CommandOutput{Log: OutputOnFailure}, expected: ""},
+		{name: "on failure, failed", script: "echo out; echo err >&2; exit 1", output: &harness.CommandOutput{Log: OutputOnFailure}, expected: "out\nerr\n"},
+		{name: "summary", script: "for i in 1 2 3 4 5; do echo $i; done", output: &harness.CommandOutput{Log: OutputSummaryOnly, Head: 1, Tail: 2}, expected: "1\n... 2 lines omitted ...\n4\n5\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			cmd := harness.Command{Script: tt.script, Output: tt.output, SkipLogOutput: tt.skip, IgnoreFailure: true}
+			_, err := RunCommand(context.TODO(), "", cmd, "", stdout, stdout, NewTestLogger(t, ""), 0, "")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
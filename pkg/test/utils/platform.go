@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// MatchesPlatform returns true if the current platform is one of platforms, in the form "<os>" or "<os>/<arch>"
+// (ex. "linux", "darwin/arm64").  An empty list matches all platforms.
+func MatchesPlatform(platforms []string) bool {
+	return matchesPlatform(platforms, runtime.GOOS, runtime.GOARCH)
+}
+
+func matchesPlatform(platforms []string, goos, goarch string) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, platform := range platforms {
+		platformOS, platformArch, hasArch := strings.Cut(strings.ToLower(strings.TrimSpace(platform)), "/")
+		if platformOS == goos && (!hasArch || platformArch == goarch) {
+			return true
+		}
+	}
+	return false
+}
+
+// scriptShell returns the shell and its arguments used to run scripts on goos.  On Windows PowerShell is used if it
+// is available, otherwise cmd.  All other platforms use sh.
+func scriptShell(goos string, lookPath func(string) (string, error)) []string {
+	if goos != "windows" {
+		return []string{"sh", "-c"}
+	}
+	for _, shell := range []string{"pwsh.exe", "powershell.exe"} {
+		if _, err := lookPath(shell); err == nil {
+			return []string{shell, "-NoProfile", "-NonInteractive", "-Command"}
+		}
+	}
+	return []string{"cmd.exe", "/C"}
+}
+
+// ScriptShell returns the shell and its arguments used to run scripts on the current platform.
+func ScriptShell() []string {
+	return scriptShell(runtime.GOOS, exec.LookPath)
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+func TestMatchesPlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		platforms []string
+		goos      string
+		goarch    string
+		expected  bool
+	}{
+		{name: "no platforms", goos: "linux", goarch: "amd64", expected: true},
+		{name: "matching os", platforms: []string{"linux"}, goos: "linux", goarch: "amd64", expected: true},
+		{name: "other os", platforms: []string{"windows"}, goos: "linux", goarch: "amd64", expected: false},
+		{name: "matching os and arch", platforms: []string{"linux", "darwin/arm64"}, goos: "darwin", goarch: "arm64", expected: true},
+		{name: "other arch", platforms: []string{"darwin/arm64"}, goos: "darwin", goarch: "amd64", expected: false},
+		{name: "case and whitespace", platforms: []string{" Darwin/ARM64 "}, goos: "darwin", goarch: "arm64", expected: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchesPlatform(tt.platforms, tt.goos, tt.goarch))
+		})
+	}
+}
+
+func TestScriptShell(t *testing.T) {
+	found := func(string) (string, error) { return "", nil }
+	notFound := func(string) (string, error) { return "", errors.New("not found") }
+
+	assert.Equal(t, []string{"sh", "-c"}, scriptShell("linux", notFound))
+	assert.Equal(t, []string{"sh", "-c"}, scriptShell("darwin", found))
+	assert.Equal(t, []string{"pwsh.exe", "-NoProfile", "-NonInteractive", "-Command"}, scriptShell("windows", found))
+	assert.Equal(t, []string{"cmd.exe", "/C"}, scriptShell("windows", notFound))
+}
+
+func TestRunCommandOtherPlatform(t *testing.T) {
+	logger := NewTestLogger(t, "")
+	cmd := harness.Command{
+		Command:   "this-command-does-not-exist",
+		Platforms: []string{"plan9"},
+	}
+	if runtime.GOOS == "plan9" {
+		t.Skip("the command is expected to be skipped on other platforms")
+	}
+
+	bg, err := RunCommand(context.TODO(), "", cmd, "", logger, logger, logger, 0, "")
+	assert.NoError(t, err)
+	assert.Nil(t, bg)
+}
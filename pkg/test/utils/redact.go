@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedText replaces the redacted values in logs.
+const redactedText = "[REDACTED]"
+
+// redacted are the values which are redacted from logs.
+var redacted = struct {
+	lock     sync.RWMutex
+	values   []string
+	replacer *strings.Replacer
+}{}
+
+// SetRedactedValues sets the values which are redacted from the logs of tests (ex. the values of secrets), empty
+// values are ignored.
+func SetRedactedValues(values []string) {
+	redacted.lock.Lock()
+	defer redacted.lock.Unlock()
+	setRedactedValues(values)
+}
+
+// AddRedactedValues adds values to the values which are redacted from the logs of tests (ex. tokens requested by a
+// test step), empty values are ignored.
+func AddRedactedValues(values ...string) {
+	redacted.lock.Lock()
+	defer redacted.lock.Unlock()
+	setRedactedValues(append(append([]string{}, redacted.values...), values...))
+}
+
+// setRedactedValues replaces the redacted values, the lock must be held.
+func setRedactedValues(values []string) {
+	sorted := []string{}
+	for _, value := range values {
+		if value != "" {
+			sorted = append(sorted, value)
+		}
+	}
+	// longer values are replaced first, so that values containing other values are redacted entirely
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, value := range sorted {
+		pairs = append(pairs, value, redactedText)
+	}
+
+	redacted.values = sorted
+	redacted.replacer = nil
+	if len(pairs) > 0 {
+		redacted.replacer = strings.NewReplacer(pairs...)
+	}
+}
+
+// Redact returns s with the values set by SetRedactedValues and AddRedactedValues replaced.
+func Redact(s string) string {
+	redacted.lock.RLock()
+	defer redacted.lock.RUnlock()
+	if redacted.replacer == nil {
+		return s
+	}
+	return redacted.replacer.Replace(s)
+}
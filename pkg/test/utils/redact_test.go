@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	defer SetRedactedValues(nil)
+
+	assert.Equal(t, "password=hunter2", Redact("password=hunter2"))
+
+	SetRedactedValues([]string{"hunter2", "", "hunter2-admin"})
+	assert.Equal(t, "password=[REDACTED] admin=[REDACTED]", Redact("password=hunter2 admin=hunter2-admin"))
+
+	AddRedactedValues("s3cr3t")
+	assert.Equal(t, "password=[REDACTED] token=[REDACTED]", Redact("password=hunter2 token=s3cr3t"))
+
+	SetRedactedValues(nil)
+	assert.Equal(t, "password=hunter2", Redact("password=hunter2"))
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Source is the location of an object in the YAML file it was loaded from.
+type Source struct {
+	// Path is the path or URL of the file.
+	Path string
+	// Document is the index of the YAML document in the file, starting at 1.
+	Document int
+	// Line is the line of the document in the file, starting at 1.
+	Line int
+}
+
+// String returns the location in the form "file.yaml#doc2 (line 14)".
+func (s Source) String() string {
+	return fmt.Sprintf("%s#doc%d (line %d)", filepath.Base(s.Path), s.Document, s.Line)
+}
+
+// sources maps loaded objects to their location, it is keyed by the object pointer.
+var sources sync.Map
+
+// SetSource records the location obj was loaded from.
+func SetSource(obj runtime.Object, source Source) {
+	sources.Store(obj, source)
+}
+
+// SourceOf returns the location obj was loaded from, if it was loaded from a YAML file.
+func SourceOf(obj runtime.Object) (Source, bool) {
+	source, ok := sources.Load(obj)
+	if !ok {
+		return Source{}, false
+	}
+	return source.(Source), true
+}
+
+// DescribeSource returns " (<source>)" if the location of obj is known and an empty string otherwise.  It is meant
+// to be appended to a resource ID in error messages.
+func DescribeSource(obj runtime.Object) string {
+	if source, ok := SourceOf(obj); ok {
+		return fmt.Sprintf(" (%s)", source)
+	}
+	return ""
+}
+
+// documentLines returns the line of each YAML document in data.  It splits documents the same way as the YAML reader
+// of apimachinery, so that the result can be matched with the documents it reads.  The line of a document is its first
+// line which is neither blank, a comment nor a separator, or 0 if the document is empty.
+func documentLines(data []byte) []int {
+	lines := []int{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	// buffered mirrors whether the YAML reader has buffered lines of the current document
+	buffered := false
+	first := 0
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "---") {
+			if buffered {
+				lines = append(lines, first)
+				buffered = false
+				first = 0
+				continue
+			}
+			// a separator without a buffered document is part of the next document
+			buffered = true
+			continue
+		}
+
+		buffered = true
+		if trimmed := strings.TrimSpace(line); first == 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			first = n
+		}
+	}
+	if buffered {
+		lines = append(lines, first)
+	}
+
+	return lines
+}
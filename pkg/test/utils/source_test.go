@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentLines(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		data     string
+		expected []int
+	}{
+		{
+			name:     "single document",
+			data:     "apiVersion: v1\nkind: Pod\n",
+			expected: []int{1},
+		},
+		{
+			name:     "leading separator and comments",
+			data:     "---\n# a pod\n\napiVersion: v1\nkind: Pod\n---\n# another pod\napiVersion: v1\nkind: Pod\n",
+			expected: []int{4, 8},
+		},
+		{
+			name:     "empty document",
+			data:     "apiVersion: v1\n---\n---\n---\nkind: Pod\n",
+			expected: []int{1, 0, 5},
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, documentLines([]byte(test.data)))
+		})
+	}
+}
+
+func TestLoadYAMLSource(t *testing.T) {
+	objs, err := LoadYAML("dir/00-assert.yaml", bytes.NewBufferString(`# the first pod
+apiVersion: v1
+kind: Pod
+metadata:
+  name: one
+---
+
+apiVersion: v1
+kind: Pod
+metadata:
+  name: two
+`))
+	assert.NoError(t, err)
+	assert.Len(t, objs, 2)
+
+	source, ok := SourceOf(objs[1])
+	assert.True(t, ok)
+	assert.Equal(t, Source{Path: "dir/00-assert.yaml", Document: 2, Line: 8}, source)
+	assert.Equal(t, " (00-assert.yaml#doc1 (line 2))", DescribeSource(objs[0]))
+
+	assert.Equal(t, "", DescribeSource(NewPod("hello", "")))
+}
@@ -34,9 +34,24 @@ func (e *SubsetError) Error() string {
 	return fmt.Sprintf("%s: %s", path, e.message)
 }
 
+// SubsetOptions change how IsSubsetWithOptions compares objects.
+type SubsetOptions struct {
+	// StrictConditions compares lists of conditions by position like other lists, instead of by their type.
+	StrictConditions bool
+}
+
 // IsSubset checks to see if `expected` is a subset of `actual`. A "subset" is an object that is equivalent to
-// the other object, but where map keys found in actual that are not defined in expected are ignored.
+// the other object, but where map keys found in actual that are not defined in expected are ignored.  Lists of
+// conditions are compared by the type of the conditions, see IsSubsetWithOptions.
 func IsSubset(expected, actual interface{}) error {
+	return IsSubsetWithOptions(expected, actual, SubsetOptions{})
+}
+
+// IsSubsetWithOptions checks to see if `expected` is a subset of `actual` like IsSubset.  Unless the options compare
+// conditions strictly, the lists under a "conditions" key whose expected items all have a type are compared by type:
+// each expected condition must be a subset of the actual condition of its type, in any order, and the actual
+// conditions of other types are ignored.  Controllers add conditions in varying order.
+func IsSubsetWithOptions(expected, actual interface{}, options SubsetOptions) error {
 	if reflect.TypeOf(expected) != reflect.TypeOf(actual) {
 		return &SubsetError{
 			message: fmt.Sprintf("type mismatch: %v != %v", reflect.TypeOf(expected), reflect.TypeOf(actual)),
@@ -56,7 +71,7 @@ func IsSubset(expected, actual interface{}) error {
 		}
 
 		for i := 0; i < reflect.ValueOf(expected).Len(); i++ {
-			if err := IsSubset(reflect.ValueOf(expected).Index(i).Interface(), reflect.ValueOf(actual).Index(i).Interface()); err != nil {
+			if err := IsSubsetWithOptions(reflect.ValueOf(expected).Index(i).Interface(), reflect.ValueOf(actual).Index(i).Interface(), options); err != nil {
 				return err
 			}
 		}
@@ -73,7 +88,13 @@ func IsSubset(expected, actual interface{}) error {
 				}
 			}
 
-			if err := IsSubset(iter.Value().Interface(), actualValue.Interface()); err != nil {
+			var err error
+			if iter.Key().String() == conditionsKey && !options.StrictConditions && isConditionList(iter.Value().Interface()) {
+				err = isConditionSubset(iter.Value().Interface().([]interface{}), actualValue.Interface(), options)
+			} else {
+				err = IsSubsetWithOptions(iter.Value().Interface(), actualValue.Interface(), options)
+			}
+			if err != nil {
 				subsetErr, ok := err.(*SubsetError)
 				if ok {
 					subsetErr.AppendPath(iter.Key().String())
@@ -90,3 +111,58 @@ func IsSubset(expected, actual interface{}) error {
 
 	return nil
 }
+
+// conditionsKey is the key of the lists of conditions compared by type.
+const conditionsKey = "conditions"
+
+// isConditionList returns whether value is a list of conditions, all its items have a string type.
+func isConditionList(value interface{}) bool {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := condition["type"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isConditionSubset checks that each expected condition is a subset of the actual condition of the same type.
+func isConditionSubset(expected []interface{}, actual interface{}, options SubsetOptions) error {
+	actualConditions, ok := actual.([]interface{})
+	if !ok {
+		return &SubsetError{
+			message: fmt.Sprintf("type mismatch: %v != %v", reflect.TypeOf(expected), reflect.TypeOf(actual)),
+		}
+	}
+
+	for _, item := range expected {
+		conditionType := item.(map[string]interface{})["type"].(string)
+		var actualCondition interface{}
+		for _, actualItem := range actualConditions {
+			if condition, ok := actualItem.(map[string]interface{}); ok && condition["type"] == conditionType {
+				actualCondition = condition
+				break
+			}
+		}
+		if actualCondition == nil {
+			return &SubsetError{
+				message: fmt.Sprintf("condition of type %q is missing", conditionType),
+			}
+		}
+		if err := IsSubsetWithOptions(item, actualCondition, options); err != nil {
+			if subsetErr, ok := err.(*SubsetError); ok {
+				subsetErr.AppendPath(fmt.Sprintf("[type=%s]", conditionType))
+				return subsetErr
+			}
+			return err
+		}
+	}
+	return nil
+}
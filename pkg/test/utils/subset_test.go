@@ -124,3 +124,62 @@ func TestIsSubset(t *testing.T) {
 		},
 	}))
 }
+
+func TestIsSubsetConditions(t *testing.T) {
+	condition := func(conditionType, status string) map[string]interface{} {
+		return map[string]interface{}{"type": conditionType, "status": status, "reason": conditionType + status}
+	}
+	status := func(conditions ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"status": map[string]interface{}{"conditions": conditions}}
+	}
+	actual := status(condition("Progressing", "True"), condition("Available", "True"), condition("Degraded", "False"))
+
+	for _, test := range []struct {
+		name     string
+		expected map[string]interface{}
+		options  SubsetOptions
+		err      string
+	}{
+		{
+			name:     "in any order",
+			expected: status(map[string]interface{}{"type": "Degraded", "status": "False"}, map[string]interface{}{"type": "Available", "status": "True"}),
+		},
+		{
+			name:     "mismatch",
+			expected: status(map[string]interface{}{"type": "Degraded", "status": "True"}),
+			err:      ".status.conditions.[type=Degraded].status: value mismatch, expected: True != actual: False",
+		},
+		{
+			name:     "missing",
+			expected: status(map[string]interface{}{"type": "Ready", "status": "True"}),
+			err:      `.status.conditions: condition of type "Ready" is missing`,
+		},
+		{
+			name:     "strict",
+			expected: status(map[string]interface{}{"type": "Available", "status": "True"}),
+			options:  SubsetOptions{StrictConditions: true},
+			err:      ".status.conditions: slice length mismatch: 1 != 3",
+		},
+		{
+			name:     "strict in order",
+			expected: status(condition("Progressing", "True"), condition("Available", "True"), condition("Degraded", "False")),
+			options:  SubsetOptions{StrictConditions: true},
+		},
+		{
+			name:     "items without a type are compared by position",
+			expected: status(map[string]interface{}{"status": "True"}),
+			err:      ".status.conditions: slice length mismatch: 1 != 3",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := IsSubsetWithOptions(test.expected, actual, test.options)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
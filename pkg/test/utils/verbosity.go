@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Log modules of the harness internals.  Their verbosity is set by the -v level unless it is set for the module with
+// --vmodule, the output of test steps and commands is always logged.
+const (
+	// LogKind logs the output of kind, kind level N is logged at level N+1.
+	LogKind = "kind"
+	// LogRetry logs retried API requests and assert attempts.
+	LogRetry = "retry"
+	// LogDiscovery logs API discovery lookups and refreshes.
+	LogDiscovery = "discovery"
+	// LogWatch logs watches and the pods the logs of deployments are streamed from.
+	LogWatch = "watch"
+	// LogClient logs the creation of Kubernetes clients.
+	LogClient = "client"
+)
+
+// logModules are the known log modules.
+var logModules = map[string]bool{LogKind: true, LogRetry: true, LogDiscovery: true, LogWatch: true, LogClient: true}
+
+// verbosity is the verbosity level of all log modules, and of individual modules.
+var verbosity = struct {
+	lock    sync.RWMutex
+	level   int
+	modules map[string]int
+}{modules: map[string]int{}}
+
+// SetVerbosity sets the verbosity level of the log modules which do not have their own level.
+func SetVerbosity(level int) {
+	verbosity.lock.Lock()
+	defer verbosity.lock.Unlock()
+	verbosity.level = level
+}
+
+// SetModuleVerbosity parses the verbosity levels of log modules in the form "<module>=<level>,..." (ex.
+// "retry=2,discovery=1") and sets them.
+func SetModuleVerbosity(value string) error {
+	modules := map[string]int{}
+	for _, setting := range strings.Split(value, ",") {
+		if setting == "" {
+			continue
+		}
+		module, level, ok := strings.Cut(setting, "=")
+		if !ok {
+			return fmt.Errorf("invalid module verbosity %q, must be <module>=<level>", setting)
+		}
+		if !logModules[module] {
+			return fmt.Errorf("unknown log module %q, must be one of %s", module, strings.Join(LogModules(), ", "))
+		}
+		v, err := strconv.Atoi(level)
+		if err != nil {
+			return fmt.Errorf("invalid verbosity level of log module %s: %w", module, err)
+		}
+		modules[module] = v
+	}
+
+	verbosity.lock.Lock()
+	defer verbosity.lock.Unlock()
+	for module, level := range modules {
+		verbosity.modules[module] = level
+	}
+	return nil
+}
+
+// ModuleVerbosity returns the verbosity levels set for individual log modules in the form accepted by
+// SetModuleVerbosity.
+func ModuleVerbosity() string {
+	verbosity.lock.RLock()
+	defer verbosity.lock.RUnlock()
+
+	settings := []string{}
+	for module, level := range verbosity.modules {
+		settings = append(settings, fmt.Sprintf("%s=%d", module, level))
+	}
+	sort.Strings(settings)
+	return strings.Join(settings, ",")
+}
+
+// V returns true if messages of the log module at the level are logged.
+func V(module string, level int) bool {
+	verbosity.lock.RLock()
+	defer verbosity.lock.RUnlock()
+
+	if v, ok := verbosity.modules[module]; ok {
+		return level <= v
+	}
+	return level <= verbosity.level
+}
+
+// LogModules returns the names of the log modules.
+func LogModules() []string {
+	modules := make([]string, 0, len(logModules))
+	for module := range logModules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	return modules
+}
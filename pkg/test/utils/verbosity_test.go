@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerbosity(t *testing.T) {
+	defer func() {
+		SetVerbosity(0)
+		verbosity.modules = map[string]int{}
+	}()
+
+	assert.True(t, V(LogRetry, 0))
+	assert.False(t, V(LogRetry, 1))
+
+	SetVerbosity(1)
+	assert.True(t, V(LogRetry, 1))
+	assert.False(t, V(LogRetry, 2))
+
+	assert.NoError(t, SetModuleVerbosity("retry=3,discovery=0"))
+	assert.Equal(t, "discovery=0,retry=3", ModuleVerbosity())
+	assert.True(t, V(LogRetry, 3))
+	assert.False(t, V(LogDiscovery, 1))
+	assert.True(t, V(LogWatch, 1))
+
+	for _, test := range []struct {
+		value string
+		err   string
+	}{
+		{value: "retry", err: `invalid module verbosity "retry", must be <module>=<level>`},
+		{value: "events=1", err: `unknown log module "events", must be one of client, discovery, kind, retry, watch`},
+		{value: "retry=high", err: `invalid verbosity level of log module retry: strconv.Atoi: parsing "high": invalid syntax`},
+	} {
+		assert.EqualError(t, SetModuleVerbosity(test.value), test.err)
+	}
+	// invalid settings do not change the levels
+	assert.Equal(t, "discovery=0,retry=3", ModuleVerbosity())
+}
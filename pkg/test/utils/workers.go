@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"context"
+)
+
+// maxWorkers is the number of goroutines of Retry calls and foreground commands which run at once.  The goroutine of
+// a call abandoned because its context is done keeps its worker until the call returns, so that abandoned calls
+// cannot pile up during long runs.
+const maxWorkers = 256
+
+// workers limits the goroutines of Retry calls and foreground commands, it holds one value per running goroutine.
+var workers = make(chan struct{}, maxWorkers)
+
+// workerKey is the context key marking the contexts of calls which hold a worker.
+type workerKey struct{}
+
+// acquireWorker waits for a worker, it fails if ctx is done first.  It returns the context of the call and a
+// function releasing the worker.  Calls made with a context holding a worker, ex. a Retry in the function of a Retry,
+// do not take another one, so that nested calls cannot deadlock.
+func acquireWorker(ctx context.Context) (context.Context, func(), error) {
+	if held, _ := ctx.Value(workerKey{}).(bool); held {
+		return ctx, func() {}, nil
+	}
+	select {
+	case workers <- struct{}{}:
+		return context.WithValue(ctx, workerKey{}, true), func() { <-workers }, nil
+	case <-ctx.Done():
+		return ctx, nil, ctx.Err()
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+)
+
+// takeWorkers holds all free workers until the test ends.
+func takeWorkers(t *testing.T) {
+	free := maxWorkers - len(workers)
+	for i := 0; i < free; i++ {
+		workers <- struct{}{}
+	}
+	t.Cleanup(func() {
+		for i := 0; i < free; i++ {
+			<-workers
+		}
+	})
+}
+
+func TestRetryWaitsForWorker(t *testing.T) {
+	ctx, release, err := acquireWorker(context.TODO())
+	require.NoError(t, err)
+	defer release()
+
+	t.Run("nested", func(t *testing.T) {
+		takeWorkers(t)
+
+		// the caller holds a worker already, the nested calls do not need another one
+		called := false
+		assert.NoError(t, Retry(ctx, func(ctx context.Context) error {
+			return Retry(ctx, func(context.Context) error {
+				called = true
+				return nil
+			})
+		}))
+		assert.True(t, called)
+
+		timeout, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, Retry(timeout, func(context.Context) error {
+			t.Error("the function must not run without a worker")
+			return nil
+		}), context.DeadlineExceeded)
+	})
+
+	assert.Len(t, workers, 1, "the workers of the calls are released")
+}
+
+func TestRunCommandWaitsForWorker(t *testing.T) {
+	takeWorkers(t)
+	logger := NewTestLogger(t, "")
+
+	_, err := RunCommand(context.TODO(), "world", harness.Command{Script: "echo hello", Timeout: 1}, "", &bytes.Buffer{}, &bytes.Buffer{}, logger, 0, "")
+	assert.EqualError(t, err, `command "echo hello": context deadline exceeded`)
+
+	// background commands do not take a worker
+	cmd, err := RunCommand(context.TODO(), "world", harness.Command{Script: "true", Background: true}, "", &bytes.Buffer{}, &bytes.Buffer{}, logger, 0, "")
+	require.NoError(t, err)
+	assert.NoError(t, cmd.Wait())
+}
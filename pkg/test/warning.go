@@ -0,0 +1,154 @@
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+// deprecationRegex matches the text of warnings the API server returns for deprecated APIs,
+// ex. "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+, unavailable in v1.25+".
+var deprecationRegex = regexp.MustCompile(`\bis deprecated\b`)
+
+// apiWarning is a warning returned by the API server when an object was applied.
+type apiWarning struct {
+	// resource is the ResourceID of the applied object.
+	resource string
+	text     string
+}
+
+// warningRecorder is a rest.WarningHandler recording the warnings returned by the API server.
+type warningRecorder struct {
+	lock     sync.Mutex
+	warnings []string
+}
+
+// HandleWarningHeader records the text of warnings, other warning codes than 299 are ignored like by client-go.
+func (w *warningRecorder) HandleWarningHeader(code int, agent string, text string) {
+	if code != 299 || text == "" {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, warning := range w.warnings {
+		if warning == text {
+			return
+		}
+	}
+	w.warnings = append(w.warnings, text)
+}
+
+// take returns the recorded warnings and clears them.
+func (w *warningRecorder) take() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	warnings := w.warnings
+	w.warnings = nil
+	return warnings
+}
+
+// applyClient returns a new client to apply the objects of the step with, the warnings of the API server to its
+// requests are recorded if the step has a Config.
+func (s *Step) applyClient() (client.Client, *warningRecorder, error) {
+	if s.Config == nil {
+		cl, err := s.Client(true)
+		return cl, nil, err
+	}
+
+	base, err := s.Config()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recorder := &warningRecorder{}
+	cfg := rest.CopyConfig(base)
+	cfg.WarningHandler = recorder
+	cl, err := testutils.NewRetryClient(cfg, client.Options{
+		Scheme: testutils.Scheme(),
+	})
+	return cl, recorder, err
+}
+
+// recordWarnings records the warnings returned by the API server while obj was applied.
+func (s *Step) recordWarnings(obj client.Object, recorder *warningRecorder) {
+	if recorder == nil {
+		return
+	}
+	for _, text := range recorder.take() {
+		s.Logger.Logf("warning applying %s: %s", testutils.ResourceID(obj), text)
+		s.warnings = append(s.warnings, apiWarning{resource: testutils.ResourceID(obj), text: text})
+	}
+}
+
+// CheckWarnings checks the warnings returned by the API server when the objects of the step were applied against the
+// warning assertions of the step and fails for deprecated APIs if FailOnDeprecatedAPIs is set.
+func (s *Step) CheckWarnings() []error {
+	failOnDeprecated := s.FailOnDeprecatedAPIs
+	var expected, unexpected []string
+	if s.Step != nil && s.Step.Warnings != nil {
+		expected = s.Step.Warnings.Expected
+		unexpected = s.Step.Warnings.Unexpected
+		if s.Step.Warnings.FailOnDeprecatedAPIs != nil {
+			failOnDeprecated = *s.Step.Warnings.FailOnDeprecatedAPIs
+		}
+	}
+
+	errs := []error{}
+
+	for _, pattern := range expected {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid expected warning pattern %q: %w", pattern, err))
+			continue
+		}
+		if len(s.matchingWarnings(re)) == 0 {
+			errs = append(errs, fmt.Errorf("no warning matching %q was returned, warnings: [%s]", pattern, s.warningTexts()))
+		}
+	}
+
+	for _, pattern := range unexpected {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid unexpected warning pattern %q: %w", pattern, err))
+			continue
+		}
+		for _, warning := range s.matchingWarnings(re) {
+			errs = append(errs, fmt.Errorf("unexpected warning applying %s: %s", warning.resource, warning.text))
+		}
+	}
+
+	if failOnDeprecated {
+		for _, warning := range s.matchingWarnings(deprecationRegex) {
+			errs = append(errs, fmt.Errorf("%s uses a deprecated API: %s", warning.resource, warning.text))
+		}
+	}
+
+	return errs
+}
+
+// matchingWarnings returns the recorded warnings matching re.
+func (s *Step) matchingWarnings(re *regexp.Regexp) []apiWarning {
+	matching := []apiWarning{}
+	for _, warning := range s.warnings {
+		if re.MatchString(warning.text) {
+			matching = append(matching, warning)
+		}
+	}
+	return matching
+}
+
+// warningTexts returns the texts of the recorded warnings separated by commas.
+func (s *Step) warningTexts() string {
+	texts := make([]string, 0, len(s.warnings))
+	for _, warning := range s.warnings {
+		texts = append(texts, warning.text)
+	}
+	return strings.Join(texts, ", ")
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	harness "github.com/kudobuilder/kuttl/pkg/apis/testharness/v1beta1"
+	testutils "github.com/kudobuilder/kuttl/pkg/test/utils"
+)
+
+func TestWarningRecorder(t *testing.T) {
+	recorder := &warningRecorder{}
+	recorder.HandleWarningHeader(299, "", "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+")
+	recorder.HandleWarningHeader(299, "", "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+")
+	recorder.HandleWarningHeader(199, "", "miscellaneous warning")
+	recorder.HandleWarningHeader(299, "", "")
+
+	step := &Step{Logger: testutils.NewTestLogger(t, "")}
+	step.recordWarnings(testutils.NewPod("hello", "world"), recorder)
+	assert.Equal(t, []apiWarning{{resource: "Pod:world/hello", text: "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+"}}, step.warnings)
+	assert.Nil(t, recorder.take())
+
+	// nothing is recorded without a recorder
+	step.recordWarnings(testutils.NewPod("other", "world"), nil)
+	assert.Len(t, step.warnings, 1)
+}
+
+func TestCheckWarnings(t *testing.T) {
+	enabled, disabled := true, false
+	warnings := []apiWarning{
+		{resource: "PodDisruptionBudget:world/pdb", text: "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21+, unavailable in v1.25+; use policy/v1 PodDisruptionBudget"},
+		{resource: "Pod:world/hello", text: "would violate PodSecurity \"restricted:latest\": allowPrivilegeEscalation != false"},
+	}
+
+	for _, test := range []struct {
+		name             string
+		failOnDeprecated bool
+		assertions       *harness.WarningAssertions
+		errors           []string
+	}{
+		{
+			name: "no assertions",
+		},
+		{
+			name:             "deprecated API",
+			failOnDeprecated: true,
+			errors:           []string{"PodDisruptionBudget:world/pdb uses a deprecated API: " + warnings[0].text},
+		},
+		{
+			name:             "deprecated API allowed for step",
+			failOnDeprecated: true,
+			assertions:       &harness.WarningAssertions{FailOnDeprecatedAPIs: &disabled},
+		},
+		{
+			name:       "deprecated API disallowed for step",
+			assertions: &harness.WarningAssertions{FailOnDeprecatedAPIs: &enabled},
+			errors:     []string{"PodDisruptionBudget:world/pdb uses a deprecated API: " + warnings[0].text},
+		},
+		{
+			name:       "expected warnings",
+			assertions: &harness.WarningAssertions{Expected: []string{"PodSecurity", `policy/v1beta1 \w+ is deprecated`}},
+		},
+		{
+			name:       "missing expected warning",
+			assertions: &harness.WarningAssertions{Expected: []string{"admission webhook"}},
+			errors:     []string{`no warning matching "admission webhook" was returned, warnings: [` + warnings[0].text + ", " + warnings[1].text + "]"},
+		},
+		{
+			name:       "unexpected warning",
+			assertions: &harness.WarningAssertions{Unexpected: []string{"PodSecurity", "admission webhook"}},
+			errors:     []string{"unexpected warning applying Pod:world/hello: " + warnings[1].text},
+		},
+		{
+			name:       "invalid pattern",
+			assertions: &harness.WarningAssertions{Expected: []string{"("}},
+			errors:     []string{"invalid expected warning pattern \"(\": error parsing regexp: missing closing ): `(`"},
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			step := &Step{
+				FailOnDeprecatedAPIs: test.failOnDeprecated,
+				Step:                 &harness.TestStep{Warnings: test.assertions},
+				warnings:             warnings,
+			}
+
+			errs := []string{}
+			for _, err := range step.CheckWarnings() {
+				errs = append(errs, err.Error())
+			}
+			if test.errors == nil {
+				test.errors = []string{}
+			}
+			assert.Equal(t, test.errors, errs)
+		})
+	}
+}